@@ -2,6 +2,7 @@ package blob
 
 import (
 	"bytes"
+	"compress/flate"
 	"crypto/rand"
 	"encoding/binary"
 	"errors"
@@ -37,28 +38,129 @@ const (
 	//    commit to-remote sig:           64 bytes, maybe blank
 	V0PlaintextSize = 274
 
+	// TaprootToLocalControlBlockSize is the size of the control block
+	// spending the revocation leaf of a taproot to-local output.
+	//    leaf version and parity:         1 byte
+	//    internal key:                   32 bytes
+	//    sibling leaf hash:              32 bytes
+	TaprootToLocalControlBlockSize = 65
+
+	// TaprootToRemoteControlBlockSize is the size of the control block
+	// spending the sole leaf of a taproot to-remote output.
+	//    leaf version and parity:         1 byte
+	//    internal key:                   32 bytes
+	TaprootToRemoteControlBlockSize = 33
+
+	// TaprootPlaintextSize is the plaintext size of an encoded blob for a
+	// simple taproot channel, which extends the version 0 encoding with
+	// the control blocks of the commitment outputs.
+	//    version 0 plaintext:           274 bytes
+	//    commit to-local control block:  65 bytes
+	//    commit to-remote control block: 33 bytes, maybe blank
+	TaprootPlaintextSize = V0PlaintextSize +
+		TaprootToLocalControlBlockSize +
+		TaprootToRemoteControlBlockSize
+
+	// CommitmentPointSize is the size of the commitment point carried by
+	// blobs that have data loss protection enabled, which is appended to
+	// the plaintext of their channel type.
+	CommitmentPointSize = 33
+
 	// MaxSweepAddrSize defines the maximum sweep address size that can be
 	// encoded in a blob.
 	MaxSweepAddrSize = 42
 )
 
-// Size returns the size of the encoded-and-encrypted blob in bytes.
+// KitVersion identifies the encoding of a JusticeKit's plaintext.
+type KitVersion uint8
+
+const (
+	// KitVersion0 is the original, unversioned encoding of a JusticeKit,
+	// whose plaintext consists solely of the blob type's encoding.
+	KitVersion0 KitVersion = 0
+
+	// KitVersion1 prefixes the blob type's encoding with a version byte
+	// and the blob type itself, so that a blob can't be misparsed under
+	// the wrong version or blob type.
+	//    version:                         1 byte
+	//    blob type:                       2 bytes
+	//    blob type's encoding:            n bytes
+	KitVersion1 KitVersion = 1
+
+	// VersionHeaderSize is the number of bytes prepended to the plaintext
+	// of versioned JusticeKits.
+	VersionHeaderSize = 3
+)
+
+// Size returns the size of the encoded-and-encrypted blob in bytes, using the
+// unversioned KitVersion0 encoding. For compressed blob types, this is the
+// maximum size of the blob under any version.
 //
 //	nonce:                24 bytes
 //	enciphered plaintext:  n bytes
 //	MAC:                  16 bytes
 func Size(blobType Type) int {
-	return NonceSize + PlaintextSize(blobType) + CiphertextExpansion
+	plaintextSize := PlaintextSize(blobType)
+	if blobType.IsCompressed() {
+		plaintextSize = MaxCompressedSize(
+			plaintextSize + VersionHeaderSize,
+		)
+	}
+
+	return NonceSize + plaintextSize + CiphertextExpansion
+}
+
+// SizeChecked returns the size of the encoded-and-encrypted blob in bytes, like
+// Size, but first validates the flags of the blob type. ErrUnknownBlobType is
+// returned if the type has unknown flags set, lacks FlagCommitOutputs, or is
+// flagged as both an anchor and a taproot channel, since Size would otherwise
+// return a meaningless size for it.
+func SizeChecked(blobType Type) (int, error) {
+	if !blobType.hasValidFlags() {
+		return 0, ErrUnknownBlobType
+	}
+
+	return Size(blobType), nil
+}
+
+// MaxCompressedSize returns an upper bound on the size of a plaintext of the
+// given size after being compressed with deflate. In the worst case, deflate
+// falls back to storing the plaintext in uncompressed blocks, each adding a
+// small header. The bound matches the one used by zlib's deflateBound.
+func MaxCompressedSize(plaintextSize int) int {
+	n := plaintextSize
+	return n + n>>12 + n>>14 + n>>25 + 13
+}
+
+// IsValidSize returns true if an encrypted blob of the given size is valid for
+// the blob type. Blobs are padded to a constant size for each KitVersion, with
+// the exception of compressed blobs which may be of any size up to the maximum.
+func IsValidSize(blobType Type, size int) bool {
+	if !blobType.IsCompressed() {
+		return size == Size(blobType) ||
+			size == Size(blobType)+VersionHeaderSize
+	}
+
+	return size >= NonceSize+CiphertextExpansion && size <= Size(blobType)
 }
 
 // PlaintextSize returns the size of the encoded-but-unencrypted blob in bytes.
 func PlaintextSize(blobType Type) int {
+	var size int
 	switch {
+	case blobType.Has(FlagCommitOutputs) && blobType.IsTaprootChannel():
+		size = TaprootPlaintextSize
 	case blobType.Has(FlagCommitOutputs):
-		return V0PlaintextSize
+		size = V0PlaintextSize
 	default:
 		return 0
 	}
+
+	if blobType.IsDataLossProtected() {
+		size += CommitmentPointSize
+	}
+
+	return size
 }
 
 var (
@@ -88,6 +190,26 @@ var (
 		"sweep address must be less than or equal to %d bytes long",
 		MaxSweepAddrSize,
 	)
+
+	// ErrUnknownKitVersion signals that we don't understand the version of
+	// a JusticeKit's encoding.
+	ErrUnknownKitVersion = errors.New("unknown justice kit version")
+
+	// ErrBlobTypeMismatch signals that the blob type committed to by a
+	// versioned JusticeKit differs from the blob type it is decoded with.
+	ErrBlobTypeMismatch = errors.New("justice kit blob type mismatch")
+
+	// ErrInvalidPlaintextSize signals that the plaintext of a versioned
+	// JusticeKit isn't of the size expected for its blob type.
+	ErrInvalidPlaintextSize = errors.New("invalid justice kit plaintext " +
+		"size")
+
+	// ErrDecompressedBlobTooLarge is returned when the plaintext of a
+	// compressed blob inflates to more than its blob type's plaintext
+	// size.
+	ErrDecompressedBlobTooLarge = errors.New(
+		"decompressed blob exceeds plaintext size",
+	)
 )
 
 // PubKey is a 33-byte, serialized compressed public key.
@@ -105,10 +227,16 @@ type JusticeKit struct {
 	// whether the justice transaction contains a reward for the tower, or
 	// whether the channel is a legacy or anchor channel.
 	//
-	// NOTE: This value is not serialized in the encrypted payload. It is
-	// stored separately and added to the JusticeKit after decryption.
+	// NOTE: This value is not serialized in the encrypted payload of
+	// KitVersion0 blobs. It is stored separately and added to the
+	// JusticeKit after decryption.
 	BlobType Type
 
+	// Version is the version of the encoding used for the JusticeKit's
+	// plaintext. The zero value selects the original, unversioned
+	// encoding.
+	Version KitVersion
+
 	// SweepAddress is the witness program of the output where the client's
 	// fund will be deposited. This value is included in the blobs, as
 	// opposed to the session info, such that the sweep addresses can't be
@@ -148,6 +276,30 @@ type JusticeKit struct {
 	// NOTE: This value is only used if CommitToRemotePubKey contains a valid
 	// compressed public key.
 	CommitToRemoteSig lnwire.Sig
+
+	// CommitToLocalControlBlock is the control block proving the inclusion
+	// of the revocation leaf in the taproot commitment of the to-local
+	// output.
+	//
+	// NOTE: This value is only serialized for taproot channel blob types.
+	CommitToLocalControlBlock [TaprootToLocalControlBlockSize]byte
+
+	// CommitToRemoteControlBlock is the control block proving the
+	// inclusion of the to-remote leaf in the taproot commitment of the
+	// to-remote output.
+	//
+	// NOTE: This value is only serialized for taproot channel blob types,
+	// and is only used if CommitToRemotePubKey contains a valid compressed
+	// public key.
+	CommitToRemoteControlBlock [TaprootToRemoteControlBlockSize]byte
+
+	// CommitmentPoint is the commitment point of the revoked state, which
+	// allows a client that has lost its channel state to derive the keys
+	// required to recover its funds.
+	//
+	// NOTE: This value is only serialized for blob types with data loss
+	// protection enabled.
+	CommitmentPoint PubKey
 }
 
 // CommitToLocalWitnessScript returns the serialized witness script for the
@@ -241,13 +393,22 @@ func (b *JusticeKit) CommitToRemoteWitnessStack() ([][]byte, error) {
 // creates a ciphertext using chacha20poly1305 under the chosen (nonce, key)
 // pair.
 //
+// If the blob type is compressed, the plaintext is deflated before being
+// encrypted, and the ciphertext is not padded. Its length therefore leaks how
+// well the kit compressed, which depends on its contents: a kit without a
+// to-remote output or with a short sweep address carries more zero bytes, and
+// produces a shorter blob. Anyone who sees the blob, including the tower, can
+// use this to tell such kits apart, and to link the updates of channels whose
+// kits compress alike. Clients that don't want to reveal this should not opt
+// into compressed blob types.
+//
 // NOTE: It is the caller's responsibility to ensure that this method is only
 // called once for a given (nonce, key) pair.
 func (b *JusticeKit) Encrypt(key BreachKey) ([]byte, error) {
 	// Encode the plaintext using the provided version, to obtain the
 	// plaintext bytes.
 	var ptxtBuf bytes.Buffer
-	err := b.encode(&ptxtBuf, b.BlobType)
+	err := b.encodeVersioned(&ptxtBuf)
 	if err != nil {
 		return nil, err
 	}
@@ -258,10 +419,20 @@ func (b *JusticeKit) Encrypt(key BreachKey) ([]byte, error) {
 		return nil, err
 	}
 
+	// If requested, compress the plaintext before encrypting it.
+	plaintext := ptxtBuf.Bytes()
+	if b.BlobType.IsCompressed() {
+		plaintext, err = compress(plaintext)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Allocate the ciphertext, which will contain the nonce, encrypted
 	// plaintext and MAC.
-	plaintext := ptxtBuf.Bytes()
-	ciphertext := make([]byte, Size(b.BlobType))
+	ciphertext := make(
+		[]byte, NonceSize+len(plaintext)+CiphertextExpansion,
+	)
 
 	// Generate a random  24-byte nonce in the ciphertext's prefix.
 	nonce := ciphertext[:NonceSize]
@@ -302,17 +473,29 @@ func Decrypt(key BreachKey, ciphertext []byte,
 	// Decrypt the ciphertext, placing the resulting plaintext in our
 	// plaintext buffer.
 	nonce := ciphertext[:NonceSize]
-	_, err = cipher.Open(plaintext[:0], nonce, ciphertext[NonceSize:], nil)
+	plaintext, err = cipher.Open(
+		plaintext[:0], nonce, ciphertext[NonceSize:], nil,
+	)
 	if err != nil {
 		return nil, err
 	}
 
+	// If the blob is compressed, inflate the plaintext before decoding it.
+	if blobType.IsCompressed() {
+		plaintext, err = decompress(
+			plaintext, PlaintextSize(blobType)+VersionHeaderSize,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// If decryption succeeded, we will then decode the plaintext bytes
 	// using the specified blob version.
 	boj := &JusticeKit{
 		BlobType: blobType,
 	}
-	err = boj.decode(bytes.NewReader(plaintext), blobType)
+	err = boj.decodeVersioned(plaintext, blobType)
 	if err != nil {
 		return nil, err
 	}
@@ -320,26 +503,150 @@ func Decrypt(key BreachKey, ciphertext []byte,
 	return boj, nil
 }
 
+// encodeVersioned serializes the JusticeKit using the encoding of its version.
+// KitVersion0 kits are serialized without any version information, keeping
+// them identical to blobs that predate versioning.
+func (b *JusticeKit) encodeVersioned(w io.Writer) error {
+	switch b.Version {
+	case KitVersion0:
+		return b.encode(w, b.BlobType)
+
+	case KitVersion1:
+		var header [VersionHeaderSize]byte
+		header[0] = byte(KitVersion1)
+		byteOrder.PutUint16(header[1:], uint16(b.BlobType))
+
+		if _, err := w.Write(header[:]); err != nil {
+			return err
+		}
+
+		return b.encode(w, b.BlobType)
+
+	default:
+		return ErrUnknownKitVersion
+	}
+}
+
+// decodeVersioned deserializes the JusticeKit from the given plaintext. A
+// plaintext of exactly the blob type's legacy size is decoded as KitVersion0,
+// otherwise the plaintext's leading byte determines its version.
+func (b *JusticeKit) decodeVersioned(plaintext []byte, blobType Type) error {
+	// Fail early if we don't know how to decode the blob type at all.
+	if PlaintextSize(blobType) == 0 {
+		return ErrUnknownBlobType
+	}
+
+	if len(plaintext) == PlaintextSize(blobType) {
+		b.Version = KitVersion0
+		return b.decode(bytes.NewReader(plaintext), blobType)
+	}
+
+	if len(plaintext) == 0 {
+		return ErrInvalidPlaintextSize
+	}
+
+	switch KitVersion(plaintext[0]) {
+	case KitVersion1:
+		if len(plaintext) != VersionHeaderSize+PlaintextSize(blobType) {
+			return ErrInvalidPlaintextSize
+		}
+
+		// Ensure the kit was encoded for the blob type we're decoding
+		// it with.
+		if Type(byteOrder.Uint16(plaintext[1:])) != blobType {
+			return ErrBlobTypeMismatch
+		}
+
+		b.Version = KitVersion1
+		body := plaintext[VersionHeaderSize:]
+
+		return b.decode(bytes.NewReader(body), blobType)
+
+	// KitVersion0 plaintexts are never prefixed with their version, so a
+	// leading zero byte is just as unknown as any other version.
+	default:
+		return ErrUnknownKitVersion
+	}
+}
+
+// compress compresses the given plaintext using deflate.
+func compress(plaintext []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decompress inflates the given deflate-compressed plaintext, failing if it
+// inflates to more than maxSize bytes.
+func decompress(compressed []byte, maxSize int) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(compressed))
+	defer r.Close()
+
+	plaintext, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(plaintext) > maxSize {
+		return nil, ErrDecompressedBlobTooLarge
+	}
+
+	return plaintext, nil
+}
+
 // encode serializes the JusticeKit according to the version, returning an
-// error if the version is unknown.
+// error if the version is unknown. Blob types with data loss protection enabled
+// have the commitment point appended to their channel type's encoding.
 func (b *JusticeKit) encode(w io.Writer, blobType Type) error {
+	var err error
 	switch {
+	case blobType.Has(FlagCommitOutputs) && blobType.IsTaprootChannel():
+		err = b.encodeTaproot(w)
 	case blobType.Has(FlagCommitOutputs):
-		return b.encodeV0(w)
+		err = b.encodeV0(w)
 	default:
 		return ErrUnknownBlobType
 	}
+	if err != nil || !blobType.IsDataLossProtected() {
+		return err
+	}
+
+	// Write 33-byte commitment point.
+	_, err = w.Write(b.CommitmentPoint[:])
+	return err
 }
 
 // decode deserializes the JusticeKit according to the version, returning an
 // error if the version is unknown.
 func (b *JusticeKit) decode(r io.Reader, blobType Type) error {
+	var err error
 	switch {
+	case blobType.Has(FlagCommitOutputs) && blobType.IsTaprootChannel():
+		err = b.decodeTaproot(r)
 	case blobType.Has(FlagCommitOutputs):
-		return b.decodeV0(r)
+		err = b.decodeV0(r)
 	default:
 		return ErrUnknownBlobType
 	}
+	if err != nil || !blobType.IsDataLossProtected() {
+		return err
+	}
+
+	// Read 33-byte commitment point.
+	_, err = io.ReadFull(r, b.CommitmentPoint[:])
+	return err
 }
 
 // encodeV0 encodes the JusticeKit using the version 0 encoding scheme to the
@@ -503,3 +810,67 @@ func (b *JusticeKit) decodeV0(r io.Reader) error {
 
 	return nil
 }
+
+// encodeTaproot encodes the JusticeKit of a simple taproot channel to the
+// provided io.Writer. The encoding extends the version 0 encoding with the
+// control blocks required to spend the commitment outputs via their script
+// paths, producing a constant-size plaintext of 372 bytes.
+//
+// blob taproot plaintext encoding:
+//
+//	version 0 plaintext:           274 bytes
+//	commit to-local control block:  65 bytes
+//	commit to-remote control block: 33 bytes, maybe blank
+func (b *JusticeKit) encodeTaproot(w io.Writer) error {
+	if err := b.encodeV0(w); err != nil {
+		return err
+	}
+
+	// Write 65-byte commit to-local control block.
+	_, err := w.Write(b.CommitToLocalControlBlock[:])
+	if err != nil {
+		return err
+	}
+
+	// Write 33-byte commit to-remote control block, which may be blank.
+	_, err = w.Write(b.CommitToRemoteControlBlock[:])
+	return err
+}
+
+// decodeTaproot reconstructs the JusticeKit of a simple taproot channel from
+// the io.Reader. This will parse a constant size input stream of 372 bytes to
+// recover information for the commit to-local output, and possibly the commit
+// to-remote output.
+//
+// blob taproot plaintext encoding:
+//
+//	version 0 plaintext:           274 bytes
+//	commit to-local control block:  65 bytes
+//	commit to-remote control block: 33 bytes, maybe blank
+func (b *JusticeKit) decodeTaproot(r io.Reader) error {
+	if err := b.decodeV0(r); err != nil {
+		return err
+	}
+
+	// Read 65-byte commit to-local control block.
+	_, err := io.ReadFull(r, b.CommitToLocalControlBlock[:])
+	if err != nil {
+		return err
+	}
+
+	// Read 33-byte commit to-remote control block, which may be
+	// discarded.
+	var commitToRemoteControlBlock [TaprootToRemoteControlBlockSize]byte
+	_, err = io.ReadFull(r, commitToRemoteControlBlock[:])
+	if err != nil {
+		return err
+	}
+
+	// Only populate the commit to-remote control block if the decoded
+	// blob has a commit to-remote output.
+	if b.HasCommitToRemoteOutput() {
+		b.CommitToRemoteControlBlock = commitToRemoteControlBlock
+	}
+
+	return nil
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/watchtower/blob"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/chacha20poly1305"
 )
 
 func makePubKey(i uint64) blob.PubKey {
@@ -33,6 +34,24 @@ func makeSig(i int) lnwire.Sig {
 	return sig
 }
 
+func makeToLocalControlBlock() [blob.TaprootToLocalControlBlockSize]byte {
+	var controlBlock [blob.TaprootToLocalControlBlockSize]byte
+	if _, err := io.ReadFull(rand.Reader, controlBlock[:]); err != nil {
+		panic("unable to create to-local control block")
+	}
+
+	return controlBlock
+}
+
+func makeToRemoteControlBlock() [blob.TaprootToRemoteControlBlockSize]byte {
+	var controlBlock [blob.TaprootToRemoteControlBlockSize]byte
+	if _, err := io.ReadFull(rand.Reader, controlBlock[:]); err != nil {
+		panic("unable to create to-remote control block")
+	}
+
+	return controlBlock
+}
+
 func makeAddr(size int) []byte {
 	addr := make([]byte, size)
 	if _, err := io.ReadFull(rand.Reader, addr); err != nil {
@@ -54,6 +73,9 @@ type descriptorTest struct {
 	hasCommitToRemote    bool
 	commitToRemotePubKey blob.PubKey
 	commitToRemoteSig    lnwire.Sig
+	toLocalControlBlock  [blob.TaprootToLocalControlBlockSize]byte
+	toRemoteControlBlock [blob.TaprootToRemoteControlBlockSize]byte
+	commitmentPoint      blob.PubKey
 	encErr               error
 	decErr               error
 }
@@ -82,6 +104,29 @@ var descriptorTests = []descriptorTest{
 		commitToRemotePubKey: makePubKey(2),
 		commitToRemoteSig:    makeSig(2),
 	},
+	{
+		name:             "anchor to-local only",
+		encVersion:       blob.TypeAltruistAnchorCommit,
+		decVersion:       blob.TypeAltruistAnchorCommit,
+		sweepAddr:        makeAddr(22),
+		revPubKey:        makePubKey(0),
+		delayPubKey:      makePubKey(1),
+		csvDelay:         144,
+		commitToLocalSig: makeSig(1),
+	},
+	{
+		name:                 "anchor to-local and to-remote",
+		encVersion:           blob.TypeAltruistAnchorCommit,
+		decVersion:           blob.TypeAltruistAnchorCommit,
+		sweepAddr:            makeAddr(22),
+		revPubKey:            makePubKey(0),
+		delayPubKey:          makePubKey(1),
+		csvDelay:             144,
+		commitToLocalSig:     makeSig(1),
+		hasCommitToRemote:    true,
+		commitToRemotePubKey: makePubKey(2),
+		commitToRemoteSig:    makeSig(2),
+	},
 	{
 		name:             "unknown encrypt version",
 		encVersion:       0,
@@ -135,6 +180,79 @@ var descriptorTests = []descriptorTest{
 		commitToLocalSig: makeSig(1),
 		encErr:           blob.ErrSweepAddressToLong,
 	},
+	{
+		name:                "taproot to-local only",
+		encVersion:          blob.TypeAltruistTaprootCommit,
+		decVersion:          blob.TypeAltruistTaprootCommit,
+		sweepAddr:           makeAddr(34),
+		revPubKey:           makePubKey(0),
+		delayPubKey:         makePubKey(1),
+		csvDelay:            144,
+		commitToLocalSig:    makeSig(1),
+		toLocalControlBlock: makeToLocalControlBlock(),
+	},
+	{
+		name:                 "taproot to-local and to-remote",
+		encVersion:           blob.TypeAltruistTaprootCommit,
+		decVersion:           blob.TypeAltruistTaprootCommit,
+		sweepAddr:            makeAddr(34),
+		revPubKey:            makePubKey(0),
+		delayPubKey:          makePubKey(1),
+		csvDelay:             144,
+		commitToLocalSig:     makeSig(1),
+		hasCommitToRemote:    true,
+		commitToRemotePubKey: makePubKey(2),
+		commitToRemoteSig:    makeSig(2),
+		toLocalControlBlock:  makeToLocalControlBlock(),
+		toRemoteControlBlock: makeToRemoteControlBlock(),
+	},
+	{
+		name: "data loss protected to-local only",
+		encVersion: blob.TypeAltruistCommit |
+			blob.Type(blob.FlagDataLossProtection),
+		decVersion: blob.TypeAltruistCommit |
+			blob.Type(blob.FlagDataLossProtection),
+		sweepAddr:        makeAddr(22),
+		revPubKey:        makePubKey(0),
+		delayPubKey:      makePubKey(1),
+		csvDelay:         144,
+		commitToLocalSig: makeSig(1),
+		commitmentPoint:  makePubKey(3),
+	},
+	{
+		name: "data loss protected anchor to-local and to-remote",
+		encVersion: blob.TypeAltruistAnchorCommit |
+			blob.Type(blob.FlagDataLossProtection),
+		decVersion: blob.TypeAltruistAnchorCommit |
+			blob.Type(blob.FlagDataLossProtection),
+		sweepAddr:            makeAddr(22),
+		revPubKey:            makePubKey(0),
+		delayPubKey:          makePubKey(1),
+		csvDelay:             144,
+		commitToLocalSig:     makeSig(1),
+		hasCommitToRemote:    true,
+		commitToRemotePubKey: makePubKey(2),
+		commitToRemoteSig:    makeSig(2),
+		commitmentPoint:      makePubKey(3),
+	},
+	{
+		name: "data loss protected taproot to-local and to-remote",
+		encVersion: blob.TypeAltruistTaprootCommit |
+			blob.Type(blob.FlagDataLossProtection),
+		decVersion: blob.TypeAltruistTaprootCommit |
+			blob.Type(blob.FlagDataLossProtection),
+		sweepAddr:            makeAddr(34),
+		revPubKey:            makePubKey(0),
+		delayPubKey:          makePubKey(1),
+		csvDelay:             144,
+		commitToLocalSig:     makeSig(1),
+		hasCommitToRemote:    true,
+		commitToRemotePubKey: makePubKey(2),
+		commitToRemoteSig:    makeSig(2),
+		toLocalControlBlock:  makeToLocalControlBlock(),
+		toRemoteControlBlock: makeToRemoteControlBlock(),
+		commitmentPoint:      makePubKey(3),
+	},
 }
 
 // TestBlobJusticeKitEncryptDecrypt asserts that encrypting and decrypting a
@@ -159,6 +277,10 @@ func testBlobJusticeKitEncryptDecrypt(t *testing.T, test descriptorTest) {
 		CommitToLocalSig:     test.commitToLocalSig,
 		CommitToRemotePubKey: test.commitToRemotePubKey,
 		CommitToRemoteSig:    test.commitToRemoteSig,
+
+		CommitToLocalControlBlock:  test.toLocalControlBlock,
+		CommitToRemoteControlBlock: test.toRemoteControlBlock,
+		CommitmentPoint:            test.commitmentPoint,
 	}
 
 	// Generate a random encryption key for the blob. The key is
@@ -383,3 +505,465 @@ func TestJusticeKitToLocalWitnessConstruction(t *testing.T) {
 	}
 	require.Equal(t, expWitnessStack, toLocalWitnessStack)
 }
+
+// TestJusticeKitV0Encoding asserts that the blob types that predate taproot
+// channels still encode to, and decode from, the exact version 0 plaintext
+// layout.
+func TestJusticeKitV0Encoding(t *testing.T) {
+	blobTypes := []blob.Type{
+		blob.TypeAltruistCommit,
+		blob.TypeAltruistAnchorCommit,
+		blob.TypeRewardCommit,
+	}
+
+	sweepAddr := makeAddr(22)
+	boj := &blob.JusticeKit{
+		SweepAddress:         sweepAddr,
+		RevocationPubKey:     makePubKey(0),
+		LocalDelayPubKey:     makePubKey(1),
+		CSVDelay:             144,
+		CommitToLocalSig:     makeSig(1),
+		CommitToRemotePubKey: makePubKey(2),
+		CommitToRemoteSig:    makeSig(2),
+	}
+
+	// Construct the expected version 0 plaintext by hand.
+	var expPlaintext bytes.Buffer
+	expPlaintext.WriteByte(byte(len(sweepAddr)))
+	expPlaintext.Write(sweepAddr)
+	expPlaintext.Write(make([]byte, blob.MaxSweepAddrSize-len(sweepAddr)))
+	expPlaintext.Write(boj.RevocationPubKey[:])
+	expPlaintext.Write(boj.LocalDelayPubKey[:])
+	require.NoError(t, binary.Write(
+		&expPlaintext, binary.BigEndian, boj.CSVDelay,
+	))
+	expPlaintext.Write(boj.CommitToLocalSig[:])
+	expPlaintext.Write(boj.CommitToRemotePubKey[:])
+	expPlaintext.Write(boj.CommitToRemoteSig[:])
+	require.Equal(t, blob.V0PlaintextSize, expPlaintext.Len())
+
+	for _, blobType := range blobTypes {
+		require.Equal(
+			t, blob.V0PlaintextSize, blob.PlaintextSize(blobType),
+		)
+
+		var key blob.BreachKey
+		_, err := rand.Read(key[:])
+		require.NoError(t, err)
+
+		cipher, err := chacha20poly1305.NewX(key[:])
+		require.NoError(t, err)
+
+		// Encrypting the kit should produce the expected plaintext.
+		boj.BlobType = blobType
+		ctxt, err := boj.Encrypt(key)
+		require.NoError(t, err)
+
+		plaintext, err := cipher.Open(
+			nil, ctxt[:blob.NonceSize], ctxt[blob.NonceSize:], nil,
+		)
+		require.NoError(t, err)
+		require.Equal(t, expPlaintext.Bytes(), plaintext)
+
+		// Decrypting a blob holding the expected plaintext should
+		// produce the original kit.
+		nonce := make([]byte, blob.NonceSize)
+		_, err = rand.Read(nonce)
+		require.NoError(t, err)
+
+		ctxt = cipher.Seal(nonce, nonce, expPlaintext.Bytes(), nil)
+		boj2, err := blob.Decrypt(key, ctxt, blobType)
+		require.NoError(t, err)
+		require.Equal(t, boj, boj2)
+	}
+}
+
+// TestJusticeKitTaprootSize asserts that taproot blobs are sized to include
+// the control blocks of the commitment outputs.
+func TestJusticeKitTaprootSize(t *testing.T) {
+	require.Equal(t, 372, blob.TaprootPlaintextSize)
+	require.Equal(
+		t, blob.TaprootPlaintextSize,
+		blob.PlaintextSize(blob.TypeAltruistTaprootCommit),
+	)
+	require.Equal(
+		t, blob.NonceSize+blob.TaprootPlaintextSize+
+			blob.CiphertextExpansion,
+		blob.Size(blob.TypeAltruistTaprootCommit),
+	)
+}
+
+// TestJusticeKitDataLossProtection asserts that blob types with data loss
+// protection enabled append the commitment point to the plaintext of their
+// channel type, while the default blob types are left unchanged.
+func TestJusticeKitDataLossProtection(t *testing.T) {
+	blobTypes := []blob.Type{
+		blob.TypeAltruistCommit,
+		blob.TypeAltruistAnchorCommit,
+		blob.TypeRewardCommit,
+		blob.TypeAltruistTaprootCommit,
+	}
+
+	for _, blobType := range blobTypes {
+		dlpType := blobType | blob.Type(blob.FlagDataLossProtection)
+
+		t.Run(dlpType.String(), func(t *testing.T) {
+			require.False(t, blobType.IsDataLossProtected())
+			require.True(t, dlpType.IsDataLossProtected())
+
+			// The data loss protected blob should be exactly large
+			// enough to hold the commitment point.
+			require.Equal(
+				t, blob.PlaintextSize(blobType)+
+					blob.CommitmentPointSize,
+				blob.PlaintextSize(dlpType),
+			)
+			require.Equal(
+				t, blob.Size(blobType)+blob.CommitmentPointSize,
+				blob.Size(dlpType),
+			)
+
+			boj := &blob.JusticeKit{
+				BlobType:         blobType,
+				SweepAddress:     makeAddr(22),
+				RevocationPubKey: makePubKey(0),
+				LocalDelayPubKey: makePubKey(1),
+				CSVDelay:         144,
+				CommitToLocalSig: makeSig(1),
+				CommitmentPoint:  makePubKey(3),
+			}
+			if blobType.IsTaprootChannel() {
+				boj.CommitToLocalControlBlock =
+					makeToLocalControlBlock()
+			}
+
+			var key blob.BreachKey
+			_, err := rand.Read(key[:])
+			require.NoError(t, err)
+
+			cipher, err := chacha20poly1305.NewX(key[:])
+			require.NoError(t, err)
+
+			open := func(ctxt []byte) []byte {
+				plaintext, err := cipher.Open(
+					nil, ctxt[:blob.NonceSize],
+					ctxt[blob.NonceSize:], nil,
+				)
+				require.NoError(t, err)
+
+				return plaintext
+			}
+
+			// Without the flag, the commitment point shouldn't be
+			// serialized at all.
+			ctxt, err := boj.Encrypt(key)
+			require.NoError(t, err)
+			require.Len(t, ctxt, blob.Size(blobType))
+			plaintext := open(ctxt)
+
+			boj2, err := blob.Decrypt(key, ctxt, blobType)
+			require.NoError(t, err)
+			require.Equal(t, blob.PubKey{}, boj2.CommitmentPoint)
+
+			// With the flag, the plaintext should be that of the
+			// channel type followed by the commitment point.
+			boj.BlobType = dlpType
+			dlpCtxt, err := boj.Encrypt(key)
+			require.NoError(t, err)
+			require.Len(t, dlpCtxt, blob.Size(dlpType))
+			require.True(t, blob.IsValidSize(dlpType, len(dlpCtxt)))
+
+			expPlaintext := append(
+				plaintext, boj.CommitmentPoint[:]...,
+			)
+			require.Equal(t, expPlaintext, open(dlpCtxt))
+
+			boj2, err = blob.Decrypt(key, dlpCtxt, dlpType)
+			require.NoError(t, err)
+			require.Equal(t, boj, boj2)
+
+			// The blobs can't be decoded as the other type, since
+			// their sizes differ.
+			_, err = blob.Decrypt(key, dlpCtxt, blobType)
+			require.Error(t, err)
+			_, err = blob.Decrypt(key, ctxt, dlpType)
+			require.Error(t, err)
+		})
+	}
+}
+
+// TestJusticeKitCompressedEncryptDecrypt asserts that a compressed-then-
+// encrypted blob decrypts and decompresses to the original JusticeKit, and that
+// its size is valid for its blob type.
+func TestJusticeKitCompressedEncryptDecrypt(t *testing.T) {
+	blobTypes := []blob.Type{
+		blob.TypeAltruistCommit,
+		blob.TypeAltruistAnchorCommit,
+		blob.TypeRewardCommit,
+		blob.TypeAltruistTaprootCommit,
+	}
+
+	for _, blobType := range blobTypes {
+		blobType := blobType | blob.Type(blob.FlagCompressed)
+
+		t.Run(blobType.String(), func(t *testing.T) {
+			boj := &blob.JusticeKit{
+				BlobType:             blobType,
+				SweepAddress:         makeAddr(22),
+				RevocationPubKey:     makePubKey(0),
+				LocalDelayPubKey:     makePubKey(1),
+				CSVDelay:             144,
+				CommitToLocalSig:     makeSig(1),
+				CommitToRemotePubKey: makePubKey(2),
+				CommitToRemoteSig:    makeSig(2),
+			}
+			if blobType.IsTaprootChannel() {
+				boj.CommitToLocalControlBlock =
+					makeToLocalControlBlock()
+				boj.CommitToRemoteControlBlock =
+					makeToRemoteControlBlock()
+			}
+
+			var key blob.BreachKey
+			_, err := rand.Read(key[:])
+			require.NoError(t, err)
+
+			ctxt, err := boj.Encrypt(key)
+			require.NoError(t, err)
+
+			// The blob should be no larger than the maximum size of
+			// its type.
+			require.LessOrEqual(t, len(ctxt), blob.Size(blobType))
+			require.True(t, blob.IsValidSize(blobType, len(ctxt)))
+
+			boj2, err := blob.Decrypt(key, ctxt, blobType)
+			require.NoError(t, err)
+			require.Equal(t, boj, boj2)
+		})
+	}
+}
+
+// TestJusticeKitCompressionSavesSpace asserts that compressing a kit with
+// highly redundant contents produces a smaller blob than the padded,
+// uncompressed encoding.
+func TestJusticeKitCompressionSavesSpace(t *testing.T) {
+	boj := &blob.JusticeKit{
+		BlobType:         blob.TypeAltruistCommit,
+		SweepAddress:     make([]byte, 22),
+		RevocationPubKey: makePubKey(0),
+		LocalDelayPubKey: makePubKey(1),
+		CSVDelay:         144,
+		CommitToLocalSig: makeSig(1),
+	}
+
+	var key blob.BreachKey
+	_, err := rand.Read(key[:])
+	require.NoError(t, err)
+
+	ctxt, err := boj.Encrypt(key)
+	require.NoError(t, err)
+	require.Len(t, ctxt, blob.Size(blob.TypeAltruistCommit))
+
+	boj.BlobType |= blob.Type(blob.FlagCompressed)
+	compressedCtxt, err := boj.Encrypt(key)
+	require.NoError(t, err)
+	require.Less(t, len(compressedCtxt), len(ctxt))
+
+	boj2, err := blob.Decrypt(key, compressedCtxt, boj.BlobType)
+	require.NoError(t, err)
+	require.Equal(t, boj, boj2)
+}
+
+// TestIsValidSize asserts that uncompressed blobs must be exactly of their
+// type's size, while compressed blobs may be of any size up to the maximum.
+func TestIsValidSize(t *testing.T) {
+	const compressed = blob.TypeAltruistCommit |
+		blob.Type(blob.FlagCompressed)
+
+	size := blob.Size(blob.TypeAltruistCommit)
+	require.True(t, blob.IsValidSize(blob.TypeAltruistCommit, size))
+	require.False(t, blob.IsValidSize(blob.TypeAltruistCommit, size-1))
+	require.False(t, blob.IsValidSize(blob.TypeAltruistCommit, size+1))
+	require.True(t, blob.IsValidSize(
+		blob.TypeAltruistCommit, size+blob.VersionHeaderSize,
+	))
+
+	maxSize := blob.Size(compressed)
+	require.Equal(
+		t, blob.NonceSize+blob.MaxCompressedSize(
+			blob.V0PlaintextSize+blob.VersionHeaderSize,
+		)+blob.CiphertextExpansion,
+		maxSize,
+	)
+	require.True(t, blob.IsValidSize(compressed, maxSize))
+	require.True(t, blob.IsValidSize(compressed, size/2))
+	require.False(t, blob.IsValidSize(compressed, maxSize+1))
+	require.False(t, blob.IsValidSize(
+		compressed, blob.NonceSize+blob.CiphertextExpansion-1,
+	))
+}
+
+// TestSizeChecked asserts that SizeChecked returns the same size as Size for
+// sensible combinations of flags, and rejects nonsensical ones.
+func TestSizeChecked(t *testing.T) {
+	tests := []struct {
+		name     string
+		blobType blob.Type
+		valid    bool
+	}{
+		{
+			name:     "commit",
+			blobType: blob.TypeAltruistCommit,
+			valid:    true,
+		},
+		{
+			name:     "anchor commit",
+			blobType: blob.TypeAltruistAnchorCommit,
+			valid:    true,
+		},
+		{
+			name:     "reward commit",
+			blobType: blob.TypeRewardCommit,
+			valid:    true,
+		},
+		{
+			name: "reward anchor commit",
+			blobType: blob.TypeFromFlags(
+				blob.FlagReward, blob.FlagCommitOutputs,
+				blob.FlagAnchorChannel,
+			),
+			valid: true,
+		},
+		{
+			name:     "taproot commit",
+			blobType: blob.TypeAltruistTaprootCommit,
+			valid:    true,
+		},
+		{
+			name: "compressed commit",
+			blobType: blob.TypeAltruistCommit |
+				blob.Type(blob.FlagCompressed),
+			valid: true,
+		},
+		{
+			name:     "no commit outputs",
+			blobType: blob.Type(blob.FlagReward),
+			valid:    false,
+		},
+		{
+			name:     "empty",
+			blobType: 0,
+			valid:    false,
+		},
+		{
+			name: "anchor and taproot",
+			blobType: blob.TypeAltruistAnchorCommit |
+				blob.Type(blob.FlagTaprootChannel),
+			valid: false,
+		},
+		{
+			name:     "unknown flag",
+			blobType: blob.TypeAltruistCommit | blob.Type(1<<15),
+			valid:    false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			size, err := blob.SizeChecked(test.blobType)
+			if !test.valid {
+				require.ErrorIs(t, err, blob.ErrUnknownBlobType)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, blob.Size(test.blobType), size)
+		})
+	}
+
+	// The sizes of legacy and anchor channel blobs should match, while
+	// taproot channel blobs are larger.
+	legacySize, err := blob.SizeChecked(blob.TypeAltruistCommit)
+	require.NoError(t, err)
+	anchorSize, err := blob.SizeChecked(blob.TypeAltruistAnchorCommit)
+	require.NoError(t, err)
+	taprootSize, err := blob.SizeChecked(blob.TypeAltruistTaprootCommit)
+	require.NoError(t, err)
+
+	require.Equal(t, legacySize, anchorSize)
+	require.Greater(t, taprootSize, legacySize)
+}
+
+// TestJusticeKitVersioning asserts that versioned JusticeKits round trip, and
+// that versioned plaintexts that have been tampered with are rejected rather
+// than misparsed.
+func TestJusticeKitVersioning(t *testing.T) {
+	newKit := func(blobType blob.Type,
+		version blob.KitVersion) *blob.JusticeKit {
+
+		return &blob.JusticeKit{
+			BlobType:         blobType,
+			Version:          version,
+			SweepAddress:     makeAddr(22),
+			RevocationPubKey: makePubKey(0),
+			LocalDelayPubKey: makePubKey(1),
+			CSVDelay:         144,
+			CommitToLocalSig: makeSig(1),
+		}
+	}
+
+	var key blob.BreachKey
+	_, err := rand.Read(key[:])
+	require.NoError(t, err)
+
+	cipher, err := chacha20poly1305.NewX(key[:])
+	require.NoError(t, err)
+
+	// A version 1 kit should be prefixed with its version header, and
+	// round trip with its version intact.
+	blobType := blob.TypeAltruistCommit
+	boj := newKit(blobType, blob.KitVersion1)
+	ctxt, err := boj.Encrypt(key)
+	require.NoError(t, err)
+	require.Len(t, ctxt, blob.Size(blobType)+blob.VersionHeaderSize)
+	require.True(t, blob.IsValidSize(blobType, len(ctxt)))
+
+	boj2, err := blob.Decrypt(key, ctxt, blobType)
+	require.NoError(t, err)
+	require.Equal(t, boj, boj2)
+
+	// Decoding the kit with a different blob type of the same plaintext
+	// size should fail, since the kit commits to its blob type.
+	_, err = blob.Decrypt(key, ctxt, blob.TypeAltruistAnchorCommit)
+	require.ErrorIs(t, err, blob.ErrBlobTypeMismatch)
+
+	// Truncate the version byte from the kit's plaintext and encrypt it
+	// again. The result must be rejected rather than misparsed.
+	plaintext, err := cipher.Open(
+		nil, ctxt[:blob.NonceSize], ctxt[blob.NonceSize:], nil,
+	)
+	require.NoError(t, err)
+	require.Equal(t, byte(blob.KitVersion1), plaintext[0])
+
+	nonce := make([]byte, blob.NonceSize)
+	_, err = rand.Read(nonce)
+	require.NoError(t, err)
+
+	truncated := cipher.Seal(nonce, nonce, plaintext[1:], nil)
+	_, err = blob.Decrypt(key, truncated, blobType)
+	require.ErrorIs(t, err, blob.ErrUnknownKitVersion)
+
+	// Versioned kits should also round trip when compressed.
+	compressedType := blobType | blob.Type(blob.FlagCompressed)
+	boj = newKit(compressedType, blob.KitVersion1)
+	ctxt, err = boj.Encrypt(key)
+	require.NoError(t, err)
+
+	boj2, err = blob.Decrypt(key, ctxt, compressedType)
+	require.NoError(t, err)
+	require.Equal(t, boj, boj2)
+
+	// Finally, encoding a kit with an unknown version should fail.
+	_, err = newKit(blobType, blob.KitVersion1+1).Encrypt(key)
+	require.ErrorIs(t, err, blob.ErrUnknownKitVersion)
+}
@@ -24,6 +24,24 @@ const (
 	// channel, and therefore must expect a P2WSH-style to-remote output if
 	// one exists.
 	FlagAnchorChannel Flag = 1 << 2
+
+	// FlagTaprootChannel signals that this blob is meant to spend a simple
+	// taproot channel, and therefore carries the control blocks required
+	// to spend its taproot commitment outputs via the script path.
+	FlagTaprootChannel Flag = 1 << 3
+
+	// FlagCompressed signals that the blob's plaintext is compressed using
+	// deflate before being encrypted, and must be inflated by the tower
+	// after decryption. Compressed blobs are not padded to a constant
+	// size, so their length leaks how well their contents compressed, see
+	// JusticeKit.Encrypt.
+	FlagCompressed Flag = 1 << 4
+
+	// FlagDataLossProtection signals that the blob additionally carries
+	// the commitment point of the revoked state, allowing a client that
+	// has lost its channel state to recover its funds with the help of
+	// the tower. Clients must opt into the larger blob explicitly.
+	FlagDataLossProtection Flag = 1 << 5
 )
 
 // Type returns a Type consisting solely of this flag enabled.
@@ -40,6 +58,12 @@ func (f Flag) String() string {
 		return "FlagCommitOutputs"
 	case FlagAnchorChannel:
 		return "FlagAnchorChannel"
+	case FlagTaprootChannel:
+		return "FlagTaprootChannel"
+	case FlagCompressed:
+		return "FlagCompressed"
+	case FlagDataLossProtection:
+		return "FlagDataLossProtection"
 	default:
 		return "FlagUnknown"
 	}
@@ -65,6 +89,11 @@ const (
 	// TypeRewardCommit sweeps only commitment outputs to a sweep address
 	// controlled by the user, and pays a negotiated reward to the tower.
 	TypeRewardCommit = Type(FlagCommitOutputs | FlagReward)
+
+	// TypeAltruistTaprootCommit sweeps only commitment outputs from a
+	// simple taproot commitment to a sweep address controlled by the user,
+	// and does not give the tower a reward.
+	TypeAltruistTaprootCommit = Type(FlagCommitOutputs | FlagTaprootChannel)
 )
 
 // Has returns true if the Type has the passed flag enabled.
@@ -87,11 +116,51 @@ func (t Type) IsAnchorChannel() bool {
 	return t.Has(FlagAnchorChannel)
 }
 
+// IsTaprootChannel returns true if the blob type is for a simple taproot
+// channel.
+func (t Type) IsTaprootChannel() bool {
+	return t.Has(FlagTaprootChannel)
+}
+
+// IsCompressed returns true if the blob type's plaintext is compressed before
+// encryption.
+func (t Type) IsCompressed() bool {
+	return t.Has(FlagCompressed)
+}
+
+// IsDataLossProtected returns true if the blob type carries the commitment
+// point of the revoked state.
+func (t Type) IsDataLossProtected() bool {
+	return t.Has(FlagDataLossProtection)
+}
+
+// hasValidFlags returns true if the type is composed solely of known flags,
+// and if those flags form a sensible combination. Every type must back up the
+// commitment outputs, and a channel can't be both an anchor and a taproot
+// channel.
+func (t Type) hasValidFlags() bool {
+	for f := Flag(1 << 15); f != 0; f >>= 1 {
+		if _, ok := knownFlags[f]; !ok && t.Has(f) {
+			return false
+		}
+	}
+
+	if !t.Has(FlagCommitOutputs) {
+		return false
+	}
+
+	return !(t.IsAnchorChannel() && t.IsTaprootChannel())
+}
+
 // knownFlags maps the supported flags to their name.
 var knownFlags = map[Flag]struct{}{
-	FlagReward:        {},
-	FlagCommitOutputs: {},
-	FlagAnchorChannel: {},
+	FlagReward:         {},
+	FlagCommitOutputs:  {},
+	FlagAnchorChannel:  {},
+	FlagTaprootChannel: {},
+	FlagCompressed:     {},
+
+	FlagDataLossProtection: {},
 }
 
 // String returns a human readable description of a Type.
@@ -137,10 +206,25 @@ func (t Type) String() string {
 
 // supportedTypes is the set of all configurations known to be supported by the
 // package.
+//
+// NOTE: TypeAltruistTaprootCommit is not yet included, as justice transactions
+// for taproot channels can't be constructed from its blobs yet.
 var supportedTypes = map[Type]struct{}{
 	TypeAltruistCommit:       {},
 	TypeRewardCommit:         {},
 	TypeAltruistAnchorCommit: {},
+
+	// Each of the above types is also supported with its plaintext
+	// compressed, which clients must opt into explicitly.
+	TypeAltruistCommit | Type(FlagCompressed):       {},
+	TypeRewardCommit | Type(FlagCompressed):         {},
+	TypeAltruistAnchorCommit | Type(FlagCompressed): {},
+
+	// Likewise, clients may opt into carrying the commitment point of
+	// each revoked state in their blobs.
+	TypeAltruistCommit | Type(FlagDataLossProtection):       {},
+	TypeRewardCommit | Type(FlagDataLossProtection):         {},
+	TypeAltruistAnchorCommit | Type(FlagDataLossProtection): {},
 }
 
 // IsSupportedType returns true if the given type is supported by the package.
@@ -149,6 +233,14 @@ func IsSupportedType(blobType Type) bool {
 	return ok
 }
 
+// IsKnownType returns true if the package knows how to encode and decode
+// blobs of the given type. This is a superset of the supported types, as it
+// also includes types that towers can't act upon yet.
+func IsKnownType(blobType Type) bool {
+	return IsSupportedType(blobType) ||
+		blobType == TypeAltruistTaprootCommit
+}
+
 // SupportedTypes returns a list of all supported blob types.
 func SupportedTypes() []Type {
 	supported := make([]Type, 0, len(supportedTypes))
@@ -157,3 +249,73 @@ func SupportedTypes() []Type {
 	}
 	return supported
 }
+
+// ErrNoCommonBlobType is returned by NegotiateType when the client and the
+// tower don't support any blob type in common.
+type ErrNoCommonBlobType struct {
+	// ClientSupported is the set of types supported by the client.
+	ClientSupported []Type
+
+	// TowerSupported is the set of types supported by the tower.
+	TowerSupported []Type
+}
+
+// Error returns a human-readable description of the error.
+func (e *ErrNoCommonBlobType) Error() string {
+	return fmt.Sprintf("no common blob type, client supports %v, tower "+
+		"supports %v", e.ClientSupported, e.TowerSupported)
+}
+
+// channelPreference ranks the type by the kind of channel it backs up, a
+// higher rank being more preferred. Taproot channels are preferred over anchor
+// channels, which in turn are preferred over legacy channels.
+func (t Type) channelPreference() int {
+	switch {
+	case t.IsTaprootChannel():
+		return 2
+
+	case t.IsAnchorChannel():
+		return 1
+
+	default:
+		return 0
+	}
+}
+
+// NegotiateType selects the blob type to use for a session between a client
+// and a tower, given the types each of them supports. Of the types supported
+// by both, the type backing up the most preferred kind of channel is chosen,
+// preferring taproot over anchor channels and anchor over legacy channels.
+// Ties are broken by the order of the client's types, earlier types being
+// preferred. An ErrNoCommonBlobType is returned if no type is supported by
+// both.
+func NegotiateType(clientSupported, towerSupported []Type) (Type, error) {
+	towerTypes := make(map[Type]struct{}, len(towerSupported))
+	for _, t := range towerSupported {
+		towerTypes[t] = struct{}{}
+	}
+
+	var (
+		best  Type
+		found bool
+	)
+	for _, t := range clientSupported {
+		if _, ok := towerTypes[t]; !ok {
+			continue
+		}
+
+		if !found || t.channelPreference() > best.channelPreference() {
+			best = t
+			found = true
+		}
+	}
+
+	if !found {
+		return 0, &ErrNoCommonBlobType{
+			ClientSupported: clientSupported,
+			TowerSupported:  towerSupported,
+		}
+	}
+
+	return best, nil
+}
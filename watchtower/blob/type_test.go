@@ -1,12 +1,13 @@
 package blob_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/lightningnetwork/lnd/watchtower/blob"
 )
 
-var unknownFlag = blob.Flag(16)
+var unknownFlag = blob.Flag(64)
 
 type typeStringTest struct {
 	name   string
@@ -18,17 +19,22 @@ var typeStringTests = []typeStringTest{
 	{
 		name:   "commit no-reward",
 		typ:    blob.TypeAltruistCommit,
-		expStr: "[No-FlagAnchorChannel|FlagCommitOutputs|No-FlagReward]",
+		expStr: "[No-FlagDataLossProtection|No-FlagCompressed|No-FlagTaprootChannel|No-FlagAnchorChannel|FlagCommitOutputs|No-FlagReward]",
 	},
 	{
 		name:   "commit reward",
 		typ:    blob.TypeRewardCommit,
-		expStr: "[No-FlagAnchorChannel|FlagCommitOutputs|FlagReward]",
+		expStr: "[No-FlagDataLossProtection|No-FlagCompressed|No-FlagTaprootChannel|No-FlagAnchorChannel|FlagCommitOutputs|FlagReward]",
+	},
+	{
+		name:   "taproot commit no-reward",
+		typ:    blob.TypeAltruistTaprootCommit,
+		expStr: "[No-FlagDataLossProtection|No-FlagCompressed|FlagTaprootChannel|No-FlagAnchorChannel|FlagCommitOutputs|No-FlagReward]",
 	},
 	{
 		name:   "unknown flag",
 		typ:    unknownFlag.Type(),
-		expStr: "0000000000010000[No-FlagAnchorChannel|No-FlagCommitOutputs|No-FlagReward]",
+		expStr: "0000000001000000[No-FlagDataLossProtection|No-FlagCompressed|No-FlagTaprootChannel|No-FlagAnchorChannel|No-FlagCommitOutputs|No-FlagReward]",
 	},
 }
 
@@ -139,3 +145,114 @@ func TestSupportedTypes(t *testing.T) {
 			supType)
 	}
 }
+
+type negotiateTypeTest struct {
+	name            string
+	clientSupported []blob.Type
+	towerSupported  []blob.Type
+	expType         blob.Type
+	expErr          bool
+}
+
+var negotiateTypeTests = []negotiateTypeTest{
+	{
+		name:            "single common type",
+		clientSupported: []blob.Type{blob.TypeAltruistCommit},
+		towerSupported:  []blob.Type{blob.TypeAltruistCommit},
+		expType:         blob.TypeAltruistCommit,
+	},
+	{
+		name: "anchor preferred over legacy",
+		clientSupported: []blob.Type{
+			blob.TypeAltruistCommit,
+			blob.TypeAltruistAnchorCommit,
+		},
+		towerSupported: []blob.Type{
+			blob.TypeAltruistAnchorCommit,
+			blob.TypeAltruistCommit,
+		},
+		expType: blob.TypeAltruistAnchorCommit,
+	},
+	{
+		name: "taproot preferred over anchor",
+		clientSupported: []blob.Type{
+			blob.TypeAltruistAnchorCommit,
+			blob.TypeAltruistTaprootCommit,
+			blob.TypeAltruistCommit,
+		},
+		towerSupported: []blob.Type{
+			blob.TypeAltruistCommit,
+			blob.TypeAltruistTaprootCommit,
+			blob.TypeAltruistAnchorCommit,
+		},
+		expType: blob.TypeAltruistTaprootCommit,
+	},
+	{
+		name: "preferred type not supported by tower",
+		clientSupported: []blob.Type{
+			blob.TypeAltruistTaprootCommit,
+			blob.TypeAltruistAnchorCommit,
+			blob.TypeAltruistCommit,
+		},
+		towerSupported: []blob.Type{
+			blob.TypeAltruistCommit,
+			blob.TypeAltruistAnchorCommit,
+		},
+		expType: blob.TypeAltruistAnchorCommit,
+	},
+	{
+		name: "tie broken by client order",
+		clientSupported: []blob.Type{
+			blob.TypeRewardCommit,
+			blob.TypeAltruistCommit,
+		},
+		towerSupported: []blob.Type{
+			blob.TypeAltruistCommit,
+			blob.TypeRewardCommit,
+		},
+		expType: blob.TypeRewardCommit,
+	},
+	{
+		name:            "no common type",
+		clientSupported: []blob.Type{blob.TypeAltruistAnchorCommit},
+		towerSupported:  []blob.Type{blob.TypeAltruistCommit},
+		expErr:          true,
+	},
+	{
+		name:            "tower supports nothing",
+		clientSupported: []blob.Type{blob.TypeAltruistCommit},
+		expErr:          true,
+	},
+}
+
+// TestNegotiateType asserts that NegotiateType selects the most preferred blob
+// type supported by both the client and the tower, and fails with an
+// ErrNoCommonBlobType if there is none.
+func TestNegotiateType(t *testing.T) {
+	for _, test := range negotiateTypeTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			blobType, err := blob.NegotiateType(
+				test.clientSupported, test.towerSupported,
+			)
+			if test.expErr {
+				var errNoCommon *blob.ErrNoCommonBlobType
+				if !errors.As(err, &errNoCommon) {
+					t.Fatalf("expected no common type "+
+						"error, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unable to negotiate type: %v", err)
+			}
+
+			if blobType != test.expType {
+				t.Fatalf("mismatch, expected blob type %s, "+
+					"got %s", test.expType, blobType)
+			}
+		})
+	}
+}
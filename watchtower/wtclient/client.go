@@ -44,11 +44,25 @@ const (
 )
 
 // genActiveSessionFilter generates a filter that selects active sessions that
-// also match the desired channel type, either legacy or anchor.
+// also match the desired channel type, either legacy or anchor. Exhausted
+// sessions are only selected if the tower has yet to apply all of their
+// updates, so that any committed updates can still be flushed after a restart.
 func genActiveSessionFilter(anchor bool) func(*wtdb.ClientSession) bool {
 	return func(s *wtdb.ClientSession) bool {
-		return s.Status == wtdb.CSessionActive &&
-			anchor == s.Policy.IsAnchorChannel()
+		if anchor != s.Policy.IsAnchorChannel() {
+			return false
+		}
+
+		switch s.Status {
+		case wtdb.CSessionActive:
+			return true
+
+		case wtdb.CSessionExhausted:
+			return s.TowerLastApplied < s.SeqNum
+
+		default:
+			return false
+		}
 	}
 }
 
@@ -418,18 +432,10 @@ func getTowerAndSessionCandidates(db DB, keyRing ECDHKeyRing,
 		}
 
 		for _, s := range sessions {
-			towerKeyDesc, err := keyRing.DeriveKey(
-				keychain.KeyLocator{
-					Family: keychain.KeyFamilyTowerSession,
-					Index:  s.KeyIndex,
-				},
-			)
+			s.SessionKeyECDH, err = deriveSessionKey(keyRing, s)
 			if err != nil {
 				return nil, err
 			}
-			s.SessionKeyECDH = keychain.NewPubKeyECDH(
-				towerKeyDesc, keyRing,
-			)
 
 			if !sessionFilter(s) {
 				continue
@@ -444,6 +450,31 @@ func getTowerAndSessionCandidates(db DB, keyRing ECDHKeyRing,
 	return candidateSessions, nil
 }
 
+// deriveSessionKey re-derives the session key of the given session from its key
+// index. If the session's public session key was stored when the session was
+// negotiated, ErrSessionKeyMismatch is returned unless the derived key matches
+// it. Sessions negotiated before the key was stored rely on the key index
+// alone.
+func deriveSessionKey(keyRing ECDHKeyRing,
+	s *wtdb.ClientSession) (keychain.SingleKeyECDH, error) {
+
+	towerKeyDesc, err := keyRing.DeriveKey(keychain.KeyLocator{
+		Family: keychain.KeyFamilyTowerSession,
+		Index:  s.KeyIndex,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.SessionKeyPub != nil &&
+		!s.SessionKeyPub.IsEqual(towerKeyDesc.PubKey) {
+
+		return nil, ErrSessionKeyMismatch
+	}
+
+	return keychain.NewPubKeyECDH(towerKeyDesc, keyRing), nil
+}
+
 // getClientSessions retrieves the client sessions for a particular tower if
 // specified, otherwise all client sessions for all towers are retrieved. An
 // optional filter can be provided to filter out any undesired client sessions.
@@ -467,14 +498,10 @@ func getClientSessions(db DB, keyRing ECDHKeyRing, forTower *wtdb.TowerID,
 	// requests. This prevents us from having to store the private keys on
 	// disk.
 	for _, s := range sessions {
-		towerKeyDesc, err := keyRing.DeriveKey(keychain.KeyLocator{
-			Family: keychain.KeyFamilyTowerSession,
-			Index:  s.KeyIndex,
-		})
+		s.SessionKeyECDH, err = deriveSessionKey(keyRing, s)
 		if err != nil {
 			return nil, err
 		}
-		s.SessionKeyECDH = keychain.NewPubKeyECDH(towerKeyDesc, keyRing)
 
 		// If an optional filter was provided, use it to filter out any
 		// undesired sessions.
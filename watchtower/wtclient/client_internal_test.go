@@ -0,0 +1,106 @@
+package wtclient
+
+import (
+	"net"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/watchtower/blob"
+	"github.com/lightningnetwork/lnd/watchtower/wtdb"
+	"github.com/lightningnetwork/lnd/watchtower/wtmock"
+	"github.com/lightningnetwork/lnd/watchtower/wtpolicy"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStoredSessionKey asserts that the session key stored when a session is
+// created survives a round trip through the database, and that loading the
+// session re-derives a matching session key. Sessions without a stored key
+// fall back to the key index, while sessions whose stored key doesn't match
+// the derived one are rejected.
+func TestStoredSessionKey(t *testing.T) {
+	t.Parallel()
+
+	const (
+		blobType     = blob.TypeAltruistCommit
+		sweepFeeRate = wtpolicy.DefaultSweepFeeRate
+	)
+
+	var (
+		db      = wtmock.NewClientDB()
+		keyRing = wtmock.NewSecretKeyRing()
+	)
+
+	towerKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	tower, err := db.CreateTower(&lnwire.NetAddress{
+		IdentityKey: towerKey.PubKey(),
+		Address:     &net.TCPAddr{IP: net.IPv4(1, 2, 3, 4), Port: 9911},
+	})
+	require.NoError(t, err)
+
+	createSession := func(id byte, withKey bool) *wtdb.ClientSession {
+		t.Helper()
+
+		keyIndex, err := db.NextSessionKeyIndex(tower.ID, blobType)
+		require.NoError(t, err)
+
+		keyDesc, err := keyRing.DeriveKey(keychain.KeyLocator{
+			Family: keychain.KeyFamilyTowerSession,
+			Index:  keyIndex,
+		})
+		require.NoError(t, err)
+
+		session := &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID:  tower.ID,
+				KeyIndex: keyIndex,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: sweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01},
+			},
+			ID: wtdb.SessionID([33]byte{id}),
+		}
+		if withKey {
+			session.SessionKeyPub = keyDesc.PubKey
+		}
+		require.NoError(t, db.CreateClientSession(session))
+
+		return session
+	}
+
+	withKey := createSession(0x01, true)
+	legacy := createSession(0x02, false)
+
+	sessions, err := getClientSessions(db, keyRing, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	// The stored session key must have survived the round trip, and match
+	// the session key derived from the key index.
+	loaded := sessions[withKey.ID]
+	require.NotNil(t, loaded.SessionKeyPub)
+	require.True(t, loaded.SessionKeyPub.IsEqual(withKey.SessionKeyPub))
+	require.True(
+		t, loaded.SessionKeyECDH.PubKey().IsEqual(loaded.SessionKeyPub),
+	)
+
+	// A session without a stored key is derived from its key index alone.
+	loaded = sessions[legacy.ID]
+	require.Nil(t, loaded.SessionKeyPub)
+	require.NotNil(t, loaded.SessionKeyECDH)
+
+	// Finally, a session whose stored key doesn't match the one derived
+	// from its key index must be rejected.
+	mismatched := createSession(0x03, false)
+	mismatched.SessionKeyPub = withKey.SessionKeyPub
+	_, err = deriveSessionKey(keyRing, mismatched)
+	require.ErrorIs(t, err, ErrSessionKeyMismatch)
+}
@@ -32,4 +32,10 @@ var (
 	// revoked state because the channel had not been previously registered
 	// with the client.
 	ErrUnregisteredChannel = errors.New("channel is not registered")
+
+	// ErrSessionKeyMismatch signals that the session key re-derived from a
+	// session's key index doesn't match the session key that was stored
+	// when the session was negotiated.
+	ErrSessionKeyMismatch = errors.New("derived session key doesn't " +
+		"match stored session key")
 )
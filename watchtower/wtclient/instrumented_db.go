@@ -0,0 +1,187 @@
+package wtclient
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lightningnetwork/lnd/watchtower/wtdb"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// metricsNamespace is the prometheus namespace under which all of the
+	// client DB metrics are exported.
+	metricsNamespace = "lnd"
+
+	// metricsSubsystem is the prometheus subsystem under which all of the
+	// client DB metrics are exported.
+	metricsSubsystem = "wtclient_db"
+
+	// methodLabel is the label identifying the instrumented DB method.
+	methodLabel = "method"
+
+	// errorLabel is the label identifying the error returned by an
+	// instrumented DB method.
+	errorLabel = "error"
+
+	// otherErrorLabel is the error label value of errors that aren't one
+	// of the instrumentedErrors.
+	otherErrorLabel = "other"
+)
+
+// instrumentedErrors are the errors of the instrumented DB methods that are
+// counted under their own error label. Errors are labeled by the first entry
+// they match with errors.Is, so wrapped errors such as CommitConflictError are
+// counted under their sentinel. All other errors are counted under
+// otherErrorLabel, which bounds the number of series, since the text of an
+// error may hold arbitrary details of the failed call.
+var instrumentedErrors = []struct {
+	err   error
+	label string
+}{
+	{wtdb.ErrUninitializedDB, "ErrUninitializedDB"},
+	{wtdb.ErrCorruptClientSession, "ErrCorruptClientSession"},
+	{wtdb.ErrClientSessionNotFound, "ErrClientSessionNotFound"},
+	{wtdb.ErrClientSessionAlreadyExists, "ErrClientSessionAlreadyExists"},
+	{wtdb.ErrSessionNotActive, "ErrSessionNotActive"},
+	{wtdb.ErrTowerNotFound, "ErrTowerNotFound"},
+	{wtdb.ErrTowerSessionLimitReached, "ErrTowerSessionLimitReached"},
+	{wtdb.ErrTowerPolicyMismatch, "ErrTowerPolicyMismatch"},
+	{wtdb.ErrNoReservedKeyIndex, "ErrNoReservedKeyIndex"},
+	{wtdb.ErrIncorrectKeyIndex, "ErrIncorrectKeyIndex"},
+	{wtdb.ErrMissingRewardPkScript, "ErrMissingRewardPkScript"},
+	{wtdb.ErrUnknownRewardScript, "ErrUnknownRewardScript"},
+	{wtdb.ErrBlobTypeMismatch, "ErrBlobTypeMismatch"},
+	{wtdb.ErrCommitUnorderedUpdate, "ErrCommitUnorderedUpdate"},
+	{wtdb.ErrUpdateAlreadyCommitted, "ErrUpdateAlreadyCommitted"},
+	{wtdb.ErrTooManyPendingUpdates, "ErrTooManyPendingUpdates"},
+	{wtdb.ErrCommittedUpdateNotFound, "ErrCommittedUpdateNotFound"},
+	{wtdb.ErrUnallocatedLastApplied, "ErrUnallocatedLastApplied"},
+	{wtdb.ErrLastAppliedReversion, "ErrLastAppliedReversion"},
+	{wtdb.ErrSeqNumAlreadyApplied, "ErrSeqNumAlreadyApplied"},
+}
+
+// errorLabelValue returns the error label value under which the given error is
+// counted.
+func errorLabelValue(err error) string {
+	for _, instrumented := range instrumentedErrors {
+		if errors.Is(err, instrumented.err) {
+			return instrumented.label
+		}
+	}
+
+	return otherErrorLabel
+}
+
+// InstrumentedDB wraps a DB and records prometheus metrics for the calls made
+// to its CreateClientSession, CommitUpdate and AckUpdate methods. All other
+// methods are delegated to the underlying DB untouched.
+//
+// NOTE: The wrapper lives in wtclient rather than wtdb since it implements the
+// DB interface defined here, which wtdb can't refer to without an import
+// cycle.
+type InstrumentedDB struct {
+	DB
+
+	calls   *prometheus.CounterVec
+	errors  *prometheus.CounterVec
+	latency *prometheus.HistogramVec
+}
+
+// A compile-time check to ensure InstrumentedDB implements the DB interface.
+var _ DB = (*InstrumentedDB)(nil)
+
+// NewInstrumentedDB wraps the given DB, registering its metrics with the
+// passed registerer.
+func NewInstrumentedDB(db DB,
+	registerer prometheus.Registerer) (*InstrumentedDB, error) {
+
+	calls := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "calls_total",
+		Help:      "Total number of calls made to the client DB.",
+	}, []string{methodLabel})
+
+	errors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "errors_total",
+		Help:      "Total number of client DB calls that failed.",
+	}, []string{methodLabel, errorLabel})
+
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "latency_seconds",
+		Help:      "Latency of client DB calls in seconds.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{methodLabel})
+
+	collectors := []prometheus.Collector{calls, errors, latency}
+	for _, collector := range collectors {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+
+	return &InstrumentedDB{
+		DB:      db,
+		calls:   calls,
+		errors:  errors,
+		latency: latency,
+	}, nil
+}
+
+// observe records a call to the given method that started at the given time
+// and returned the given error.
+func (i *InstrumentedDB) observe(method string, start time.Time, err error) {
+	i.calls.WithLabelValues(method).Inc()
+	i.latency.WithLabelValues(method).Observe(
+		time.Since(start).Seconds(),
+	)
+
+	if err != nil {
+		i.errors.WithLabelValues(method, errorLabelValue(err)).Inc()
+	}
+}
+
+// CreateClientSession saves a newly negotiated client session to the
+// underlying DB, recording the call's metrics.
+//
+// NOTE: This is part of the DB interface.
+func (i *InstrumentedDB) CreateClientSession(session *wtdb.ClientSession) error {
+	start := time.Now()
+	err := i.DB.CreateClientSession(session)
+	i.observe("CreateClientSession", start, err)
+
+	return err
+}
+
+// CommitUpdate writes the state update to the underlying DB, recording the
+// call's metrics.
+//
+// NOTE: This is part of the DB interface.
+func (i *InstrumentedDB) CommitUpdate(id *wtdb.SessionID,
+	update *wtdb.CommittedUpdate) (uint16, error) {
+
+	start := time.Now()
+	lastApplied, err := i.DB.CommitUpdate(id, update)
+	i.observe("CommitUpdate", start, err)
+
+	return lastApplied, err
+}
+
+// AckUpdate records the tower's acknowledgment of an update in the underlying
+// DB, recording the call's metrics.
+//
+// NOTE: This is part of the DB interface.
+func (i *InstrumentedDB) AckUpdate(id *wtdb.SessionID, seqNum,
+	lastApplied uint16) error {
+
+	start := time.Now()
+	err := i.DB.AckUpdate(id, seqNum, lastApplied)
+	i.observe("AckUpdate", start, err)
+
+	return err
+}
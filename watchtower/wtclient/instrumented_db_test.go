@@ -0,0 +1,192 @@
+package wtclient_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/watchtower/blob"
+	"github.com/lightningnetwork/lnd/watchtower/wtclient"
+	"github.com/lightningnetwork/lnd/watchtower/wtdb"
+	"github.com/lightningnetwork/lnd/watchtower/wtmock"
+	"github.com/lightningnetwork/lnd/watchtower/wtpolicy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRegisterer is a prometheus.Registerer that records the collectors
+// registered with it.
+type fakeRegisterer struct {
+	collectors []prometheus.Collector
+}
+
+// Register records the given collector.
+func (f *fakeRegisterer) Register(c prometheus.Collector) error {
+	f.collectors = append(f.collectors, c)
+	return nil
+}
+
+// MustRegister records the given collectors.
+func (f *fakeRegisterer) MustRegister(cs ...prometheus.Collector) {
+	f.collectors = append(f.collectors, cs...)
+}
+
+// Unregister is a no-op.
+func (f *fakeRegisterer) Unregister(prometheus.Collector) bool {
+	return false
+}
+
+// counterValue returns the value of the counter with the given label values
+// of the registered counter vec with the given fully qualified name.
+func (f *fakeRegisterer) counterValue(t *testing.T, name string,
+	lvs ...string) float64 {
+
+	t.Helper()
+
+	fqName := fmt.Sprintf("fqName: %q", name)
+	for _, c := range f.collectors {
+		vec, ok := c.(*prometheus.CounterVec)
+		if !ok {
+			continue
+		}
+
+		counter, err := vec.GetMetricWithLabelValues(lvs...)
+		if err != nil {
+			continue
+		}
+
+		if !strings.Contains(counter.Desc().String(), fqName) {
+			continue
+		}
+
+		return testutil.ToFloat64(counter)
+	}
+
+	t.Fatalf("counter %v not registered", name)
+
+	return 0
+}
+
+// TestInstrumentedDB asserts that the instrumented DB delegates to the wrapped
+// DB and counts the calls and errors of its instrumented methods.
+func TestInstrumentedDB(t *testing.T) {
+	t.Parallel()
+
+	const (
+		blobType = blob.TypeAltruistCommit
+
+		callsName  = "lnd_wtclient_db_calls_total"
+		errorsName = "lnd_wtclient_db_errors_total"
+	)
+
+	registerer := &fakeRegisterer{}
+	mockDB := wtmock.NewClientDB()
+	db, err := wtclient.NewInstrumentedDB(mockDB, registerer)
+	require.NoError(t, err)
+	require.Len(t, registerer.collectors, 3)
+
+	priv, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	tower, err := db.CreateTower(&lnwire.NetAddress{
+		IdentityKey: priv.PubKey(),
+		Address:     &net.TCPAddr{IP: net.IPv4(1, 2, 3, 4), Port: 9911},
+	})
+	require.NoError(t, err)
+
+	keyIndex, err := db.NextSessionKeyIndex(tower.ID, blobType)
+	require.NoError(t, err)
+
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID:  tower.ID,
+			KeyIndex: keyIndex,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: wtpolicy.MinSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	require.NoError(t, db.CreateClientSession(session))
+	require.Equal(t, 1.0, registerer.counterValue(
+		t, callsName, "CreateClientSession",
+	))
+
+	// Commit and ack an update, which should be counted as successful
+	// calls.
+	update := &wtdb.CommittedUpdate{
+		SeqNum: 1,
+		CommittedUpdateBody: wtdb.CommittedUpdateBody{
+			BackupID: wtdb.BackupID{
+				ChanID:       lnwire.ChannelID{0x01},
+				CommitHeight: 1,
+			},
+		},
+	}
+	_, err = db.CommitUpdate(&session.ID, update)
+	require.NoError(t, err)
+	require.NoError(t, db.AckUpdate(&session.ID, 1, 1))
+
+	require.Equal(t, 1.0, registerer.counterValue(
+		t, callsName, "CommitUpdate",
+	))
+	require.Equal(t, 1.0, registerer.counterValue(
+		t, callsName, "AckUpdate",
+	))
+
+	// Acking an update of an unknown session should fail, and the failure
+	// should be counted by its error.
+	unknownID := wtdb.SessionID([33]byte{0x02})
+	err = db.AckUpdate(&unknownID, 1, 1)
+	require.ErrorIs(t, err, wtdb.ErrClientSessionNotFound)
+
+	require.Equal(t, 2.0, registerer.counterValue(
+		t, callsName, "AckUpdate",
+	))
+	require.Equal(t, 1.0, registerer.counterValue(
+		t, errorsName, "AckUpdate", "ErrClientSessionNotFound",
+	))
+
+	// Committing a different update under an already committed sequence
+	// number should fail with a CommitConflictError, whose details
+	// shouldn't leak into the error label.
+	update2 := &wtdb.CommittedUpdate{
+		SeqNum: 2,
+		CommittedUpdateBody: wtdb.CommittedUpdateBody{
+			BackupID: wtdb.BackupID{
+				ChanID:       lnwire.ChannelID{0x01},
+				CommitHeight: 2,
+			},
+		},
+	}
+	_, err = db.CommitUpdate(&session.ID, update2)
+	require.NoError(t, err)
+
+	conflict := *update2
+	conflict.BackupID.CommitHeight = 3
+	conflict.Hint = blob.BreachHint{0x01}
+	_, err = db.CommitUpdate(&session.ID, &conflict)
+
+	var conflictErr *wtdb.CommitConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	require.Equal(t, 1.0, registerer.counterValue(
+		t, errorsName, "CommitUpdate", "ErrUpdateAlreadyCommitted",
+	))
+
+	// Any other error should be counted under a single label.
+	mockDB.SetFailPointOnce("CreateClientSession", errors.New("boom"))
+	require.Error(t, db.CreateClientSession(session))
+	require.Equal(t, 1.0, registerer.counterValue(
+		t, errorsName, "CreateClientSession", "other",
+	))
+}
@@ -1,7 +1,9 @@
 package wtclient
 
 import (
+	"io"
 	"net"
+	"time"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/lightningnetwork/lnd/keychain"
@@ -9,19 +11,78 @@ import (
 	"github.com/lightningnetwork/lnd/tor"
 	"github.com/lightningnetwork/lnd/watchtower/blob"
 	"github.com/lightningnetwork/lnd/watchtower/wtdb"
+	"github.com/lightningnetwork/lnd/watchtower/wtpolicy"
 	"github.com/lightningnetwork/lnd/watchtower/wtserver"
 )
 
+// DBView abstracts the read-only database operations that can be used to
+// inspect the state of the watchtower client without being able to mutate it.
+// Each call reads the latest committed state on its own, so separate calls
+// aren't guaranteed to observe the same state.
+type DBView interface {
+	// ListTowers retrieves the list of towers available within the
+	// database, ordered by ascending TowerID regardless of the backend.
+	// The TowerListOptions can be used to further filter the set of
+	// towers returned.
+	ListTowers(opts ...wtdb.TowerListOption) ([]*wtdb.Tower, error)
+
+	// ListClientSessions returns all sessions that have not yet been
+	// exhausted. This is used on startup to find any sessions which may
+	// still be able to accept state updates. An optional tower ID can be
+	// used to filter out any client sessions in the response that do not
+	// correspond to this tower.
+	ListClientSessions(*wtdb.TowerID, ...wtdb.ClientSessionListOption) (
+		map[wtdb.SessionID]*wtdb.ClientSession, error)
+
+	// FetchSessionCommittedUpdates retrieves the current set of un-acked
+	// updates of the given session.
+	FetchSessionCommittedUpdates(id *wtdb.SessionID) (
+		[]wtdb.CommittedUpdate, error)
+
+	// ForEachSessionCommittedUpdate calls cb with each of the current
+	// un-acked updates of the given session, in ascending order of
+	// sequence number, without loading them all into memory at once. The
+	// iteration stops as soon as cb returns an error, which is returned.
+	ForEachSessionCommittedUpdate(id *wtdb.SessionID,
+		cb func(*wtdb.CommittedUpdate) error) error
+
+	// FetchChanSummaries loads a mapping from all registered channels to
+	// their channel summaries. Soft-deleted channels are only included if
+	// the WithTombstoned option is given.
+	FetchChanSummaries(opts ...wtdb.ChanSummaryFetchOption) (
+		wtdb.ChannelSummaries, error)
+
+	// FetchChanSummariesForChannels loads the channel summaries of the
+	// given channels, omitting any channels that aren't registered.
+	FetchChanSummariesForChannels(chanIDs []lnwire.ChannelID) (
+		wtdb.ChannelSummaries, error)
+}
+
 // DB abstracts the required database operations required by the watchtower
 // client.
 type DB interface {
+	DBView
+
 	// CreateTower initialize an address record used to communicate with a
 	// watchtower. Each Tower is assigned a unique ID, that is used to
 	// amortize storage costs of the public key when used by multiple
 	// sessions. If the tower already exists, the address is appended to the
 	// list of all addresses used to that tower previously and its
-	// corresponding sessions are marked as active.
-	CreateTower(*lnwire.NetAddress) (*wtdb.Tower, error)
+	// corresponding sessions are marked as active. The
+	// CreateTowerOptions can be used to set optional fields on the tower,
+	// such as its nickname.
+	CreateTower(*lnwire.NetAddress, ...wtdb.CreateTowerOption) (*wtdb.Tower,
+		error)
+
+	// CreateTowerAndReserveKey atomically creates the tower for the given
+	// address, as CreateTower does, and reserves a session key index for
+	// the tower and blob type, returning the tower and the reserved index.
+	CreateTowerAndReserveKey(*lnwire.NetAddress, blob.Type) (*wtdb.Tower,
+		uint32, error)
+
+	// SetTowerNickname sets the human-readable nickname of the tower with
+	// the given ID. An empty nickname clears any existing one.
+	SetTowerNickname(id wtdb.TowerID, nickname string) error
 
 	// RemoveTower modifies a tower's record within the database. If an
 	// address is provided, then _only_ the address record should be removed
@@ -29,10 +90,79 @@ type DB interface {
 	// the tower as inactive by marking all of its sessions inactive. If any
 	// of its sessions has unacked updates, then ErrTowerUnackedUpdates is
 	// returned. If the tower doesn't have any sessions at all, it'll be
-	// completely removed from the database.
+	// completely removed from the database. The RemoveTowerOptions can be
+	// used to report the channels left without a backup by the removal.
 	//
 	// NOTE: An error is not returned if the tower doesn't exist.
-	RemoveTower(*btcec.PublicKey, net.Addr) error
+	RemoveTower(*btcec.PublicKey, net.Addr,
+		...wtdb.RemoveTowerOption) error
+
+	// RemoveTowerAddressByIndex removes the address at the given index, in
+	// the order returned by LoadTower, from the tower with the given ID.
+	// The remaining addresses keep their order, and ErrLastTowerAddr is
+	// returned if the address is the tower's only one.
+	RemoveTowerAddressByIndex(wtdb.TowerID, int) error
+
+	// RemoveTowerForce completely removes the tower along with all of its
+	// sessions and their updates, even if some updates are yet to be
+	// acked by the tower. The backups held by those updates are lost.
+	RemoveTowerForce(*btcec.PublicKey) error
+
+	// RemoveTowerCheck reports which of the tower's sessions have unacked
+	// updates, and would therefore block RemoveTower, without modifying
+	// the database.
+	RemoveTowerCheck(*btcec.PublicKey) (*wtdb.TowerRemovalReport, error)
+
+	// MarkTowerInactive marks all of the tower's sessions as inactive so
+	// that no further updates are sent to it, without removing the tower
+	// or any of its addresses.
+	MarkTowerInactive(*btcec.PublicKey) error
+
+	// MarkTowerActive marks all of the tower's sessions as active so that
+	// they can be used for backups once again.
+	MarkTowerActive(*btcec.PublicKey) error
+
+	// MarkTowerAddressUsed records that a connection to the tower with
+	// the given ID was last successfully made over the given address.
+	// Loaded towers list their addresses by most recent success first.
+	MarkTowerAddressUsed(wtdb.TowerID, net.Addr) error
+
+	// RecordTowerStat records the outcome of an attempt to deliver updates
+	// to the tower with the given ID. The accumulated stats are exposed
+	// on towers returned by LoadTower and LoadTowerByID.
+	RecordTowerStat(id wtdb.TowerID, success bool) error
+
+	// SetTowerMaxSessions sets the maximum number of non-exhausted
+	// sessions the client may hold with the tower with the given ID. A
+	// limit of zero means that the number of sessions is unlimited.
+	SetTowerMaxSessions(wtdb.TowerID, uint32) error
+
+	// SetTowerRateLimit records the number of updates per minute that the
+	// tower with the given ID was observed to accept, which is returned on
+	// the towers loaded from the database. A limit of zero means that the
+	// limit is unknown or that the tower is unlimited.
+	SetTowerRateLimit(id wtdb.TowerID, updatesPerMin uint32) error
+
+	// SetTowerBackoff records the backoff between connection attempts to
+	// the tower with the given ID, which is returned on the towers loaded
+	// individually from the database. Zero values clear the backoff,
+	// meaning that the tower can be connected to immediately.
+	SetTowerBackoff(id wtdb.TowerID, nextAttempt time.Time,
+		attempts uint32) error
+
+	// SetTowerFeatures records the feature bits advertised by the tower
+	// with the given ID, which are returned on the towers loaded from the
+	// database.
+	SetTowerFeatures(id wtdb.TowerID, fv *lnwire.FeatureVector) error
+
+	// SetTowerPolicy records the policy negotiated with the tower with
+	// the given ID. Sessions subsequently created with the tower must use
+	// this policy.
+	SetTowerPolicy(wtdb.TowerID, wtpolicy.Policy) error
+
+	// GetTowerPolicy returns the policy negotiated with the tower with the
+	// given ID.
+	GetTowerPolicy(wtdb.TowerID) (wtpolicy.Policy, error)
 
 	// LoadTower retrieves a tower by its public key.
 	LoadTower(*btcec.PublicKey) (*wtdb.Tower, error)
@@ -40,10 +170,6 @@ type DB interface {
 	// LoadTowerByID retrieves a tower by its tower ID.
 	LoadTowerByID(wtdb.TowerID) (*wtdb.Tower, error)
 
-	// ListTowers retrieves the list of towers available within the
-	// database.
-	ListTowers() ([]*wtdb.Tower, error)
-
 	// NextSessionKeyIndex reserves a new session key derivation index for a
 	// particular tower id and blob type. The index is reserved for that
 	// (tower, blob type) pair until CreateClientSession is invoked for that
@@ -52,54 +178,250 @@ type DB interface {
 	// invoked should return the same index.
 	NextSessionKeyIndex(wtdb.TowerID, blob.Type) (uint32, error)
 
+	// HighestSessionKeyIndex returns the largest session key index used
+	// by a session created with the given tower and blob type, or 0 if
+	// there is none. Reserved indexes aren't considered.
+	HighestSessionKeyIndex(wtdb.TowerID, blob.Type) (uint32, error)
+
+	// ReserveSessionKeyIndices reserves n session key derivation indexes
+	// for a particular tower id and blob type in a single call. Indexes
+	// that were reserved by a prior call and not yet used to create a
+	// session are returned first.
+	ReserveSessionKeyIndices(id wtdb.TowerID, blobType blob.Type,
+		n int) ([]uint32, error)
+
+	// ListReservedKeyIndices returns the session key indexes reserved for
+	// the given tower and blob type that haven't yet been used to create
+	// a session.
+	ListReservedKeyIndices(id wtdb.TowerID, blobType blob.Type) ([]uint32,
+		error)
+
+	// ReleaseReservedKeyIndex clears the reservation of a session key
+	// index, such that it can be reserved again for the same tower and
+	// blob type. ErrKeyIndexFinalized is returned if a session was
+	// already created with the index.
+	ReleaseReservedKeyIndex(id wtdb.TowerID, blobType blob.Type,
+		index uint32) error
+
 	// CreateClientSession saves a newly negotiated client session to the
 	// client's database. This enables the session to be used across
 	// restarts.
 	CreateClientSession(*wtdb.ClientSession) error
 
-	// ListClientSessions returns all sessions that have not yet been
-	// exhausted. This is used on startup to find any sessions which may
-	// still be able to accept state updates. An optional tower ID can be
-	// used to filter out any client sessions in the response that do not
-	// correspond to this tower.
-	ListClientSessions(*wtdb.TowerID, ...wtdb.ClientSessionListOption) (
-		map[wtdb.SessionID]*wtdb.ClientSession, error)
+	// CreateClientSessionAutoReserve saves a newly negotiated client
+	// session like CreateClientSession, reserving a session key index for
+	// it within the same transaction unless its KeyIndex is already
+	// reserved. The session's KeyIndex is set to the index used, which is
+	// also returned.
+	CreateClientSessionAutoReserve(*wtdb.ClientSession) (uint32, error)
 
-	// FetchSessionCommittedUpdates retrieves the current set of un-acked
-	// updates of the given session.
-	FetchSessionCommittedUpdates(id *wtdb.SessionID) (
-		[]wtdb.CommittedUpdate, error)
+	// RotateSession atomically marks the session with the given ID as
+	// exhausted and saves the new session that replaces it, which must be
+	// negotiated with the same tower.
+	RotateSession(oldID wtdb.SessionID,
+		newSession *wtdb.ClientSession) error
 
-	// FetchChanSummaries loads a mapping from all registered channels to
-	// their channel summaries.
-	FetchChanSummaries() (wtdb.ChannelSummaries, error)
+	// ListClientSessionsGrouped returns the sessions known to the db,
+	// including inactive and exhausted ones, grouped by their status. An
+	// optional tower ID restricts the sessions to those of the tower.
+	ListClientSessionsGrouped(id *wtdb.TowerID) (
+		map[wtdb.CSessionStatus][]*wtdb.ClientSession, error)
+
+	// GetClientSession loads the ClientSession with the given ID from the
+	// DB. The same options accepted by ListClientSessions can be used to
+	// iterate over the session's acked and committed updates. If no such
+	// session exists, ErrClientSessionNotFound is returned.
+	GetClientSession(wtdb.SessionID, ...wtdb.ClientSessionListOption) (
+		*wtdb.ClientSession, error)
+
+	// AddSessionRewardScript registers an additional reward pkscript for
+	// the session with the given ID, which committed updates may use in
+	// place of the session's RewardPkScript.
+	AddSessionRewardScript(id wtdb.SessionID, pkScript []byte) error
+
+	// UpdateSessionRewardScript replaces the RewardPkScript of the reward
+	// session with the given ID, leaving the rest of the session and its
+	// updates untouched.
+	UpdateSessionRewardScript(id wtdb.SessionID, newScript []byte) error
+
+	// SessionStorageBytes returns the total size of the encrypted blobs of
+	// the committed and acked updates of the session with the given ID.
+	SessionStorageBytes(id wtdb.SessionID) (uint64, error)
+
+	// DeleteClientSession removes a client session and all of its acked
+	// updates from the database. The session's tower is left untouched.
+	// If the session still has un-acked committed updates, then
+	// ErrSessionHasUnackedUpdates is returned.
+	DeleteClientSession(wtdb.SessionID) error
+
+	// SessionUpdateCounts returns the number of committed (un-acked) and
+	// acked updates of the given session, without loading the updates
+	// themselves.
+	SessionUpdateCounts(id wtdb.SessionID) (committed uint16,
+		acked uint16, err error)
+
+	// TotalPendingUpdates returns the number of committed updates that
+	// haven't yet been acked, across all sessions.
+	TotalPendingUpdates() (uint64, error)
+
+	// NextSeqNum returns the sequence number that the next update
+	// committed to the given session must use, which is 1 for a fresh
+	// session.
+	NextSeqNum(id wtdb.SessionID) (uint16, error)
+
+	// NumTowerSessions returns the number of sessions that have been
+	// created with the tower identified by the given ID.
+	NumTowerSessions(id wtdb.TowerID) (uint64, error)
+
+	// FetchAckedUpdatesForChannel returns, for each session holding acked
+	// backups of the given channel, the sequence numbers of those backups
+	// in ascending order.
+	FetchAckedUpdatesForChannel(chanID lnwire.ChannelID) (
+		map[wtdb.SessionID][]uint16, error)
+
+	// SessionsForChannel returns the IDs of all sessions holding committed
+	// or acked updates of the given channel, in ascending order.
+	SessionsForChannel(chanID lnwire.ChannelID) ([]wtdb.SessionID, error)
+
+	// ChannelsWithoutBackups returns the IDs of all registered channels
+	// for which no session holds a committed or acked update, in
+	// ascending order.
+	ChannelsWithoutBackups() ([]lnwire.ChannelID, error)
+
+	// DeleteChannelUpdates removes the acked updates of the given channel
+	// from all sessions, returning the number of updates deleted. Updates
+	// of other channels sharing a session are left untouched.
+	DeleteChannelUpdates(chanID lnwire.ChannelID) (int, error)
+
+	// CheckClientDB scans the database for orphaned index entries,
+	// sessions whose tower doesn't exist, and committed updates with
+	// out-of-range sequence numbers, without modifying the database.
+	CheckClientDB() (*wtdb.IntegrityReport, error)
+
+	// RepairClientDB removes the inconsistencies listed in a report
+	// produced by CheckClientDB.
+	RepairClientDB(report *wtdb.IntegrityReport) error
+
+	// ExportClientState writes the towers, sessions along with their
+	// committed and acked updates, and channel summaries of the database
+	// to the passed io.Writer as a versioned, backend-agnostic archive.
+	ExportClientState(w io.Writer) error
+
+	// ImportClientState adds the contents of an archive written by
+	// ExportClientState to the database. Unless the WithMerge option is
+	// used, ErrClientDBNotEmpty is returned if the database isn't empty.
+	ImportClientState(r io.Reader,
+		opts ...wtdb.ClientStateImportOption) error
 
 	// RegisterChannel registers a channel for use within the client
 	// database. For now, all that is stored in the channel summary is the
 	// sweep pkscript that we'd like any tower sweeps to pay into. In the
 	// future, this will be extended to contain more info to allow the
 	// client efficiently request historical states to be backed up under
-	// the client's active policy.
+	// the client's active policy. Re-registering a channel with the same
+	// pkscript succeeds, while a different pkscript is rejected.
 	RegisterChannel(lnwire.ChannelID, []byte) error
 
+	// RegisterChannelMulti registers a channel with distinct sweep
+	// pkscripts for different kinds of outputs, keyed by output kind. The
+	// pkscript under wtdb.DefaultSweepScriptKind is required and becomes
+	// the channel's sweep pkscript, which RegisterChannel compares against.
+	RegisterChannelMulti(lnwire.ChannelID, map[string][]byte) error
+
+	// RegisterChannels registers a batch of channels within a single
+	// transaction. The returned map holds the error of each channel that
+	// couldn't be registered, while the remaining channels are registered
+	// regardless. A non-nil error indicates that none were registered.
+	RegisterChannels(map[lnwire.ChannelID][]byte) (
+		map[lnwire.ChannelID]error, error)
+
+	// SetChannelPriority sets the backup priority of a registered
+	// channel, returning ErrChannelNotRegistered if the channel was never
+	// registered.
+	SetChannelPriority(lnwire.ChannelID, uint8) error
+
+	// UnregisterChannel removes the channel summary for the given channel,
+	// returning ErrChannelNotRegistered if the channel was never
+	// registered. Sessions that hold backups for the channel are left
+	// untouched. With the WithSoftDelete option, the summary is kept in a
+	// tombstone until it is purged.
+	UnregisterChannel(lnwire.ChannelID,
+		...wtdb.UnregisterChannelOption) error
+
+	// PurgeTombstones permanently removes the tombstones of the channels
+	// that were soft-deleted before the given time.
+	PurgeTombstones(before time.Time) error
+
 	// MarkBackupIneligible records that the state identified by the
 	// (channel id, commit height) tuple was ineligible for being backed up
 	// under the current policy. This state can be retried later under a
 	// different policy.
 	MarkBackupIneligible(chanID lnwire.ChannelID, commitHeight uint64) error
 
+	// ValidateUpdate checks whether the given state update would be
+	// accepted by CommitUpdate, returning the error that CommitUpdate
+	// would return, without persisting the update.
+	ValidateUpdate(id *wtdb.SessionID, update *wtdb.CommittedUpdate) error
+
 	// CommitUpdate writes the next state update for a particular
 	// session, so that we can be sure to resend it after a restart if it
 	// hasn't been ACK'd by the tower. The sequence number of the update
 	// should be exactly one greater than the existing entry, and less that
-	// or equal to the session's MaxUpdates.
+	// or equal to the session's MaxUpdates. New updates are only accepted
+	// by active sessions, though committed updates can be retransmitted
+	// regardless of the session's status. The update's blob type must
+	// either be unset or match the session's, and is set to the session's
+	// once the update is committed. An update conflicting with one that
+	// was already committed is rejected with a *wtdb.CommitConflictError.
 	CommitUpdate(id *wtdb.SessionID,
 		update *wtdb.CommittedUpdate) (uint16, error)
 
+	// CommitUpdateWithRemaining writes the next state update for a
+	// particular session just like CommitUpdate, but additionally returns
+	// the number of updates that can still be committed to the session. A
+	// remaining count of zero means that the session is exhausted.
+	CommitUpdateWithRemaining(id *wtdb.SessionID,
+		update *wtdb.CommittedUpdate) (uint16, uint16, error)
+
+	// CommitUpdates writes a contiguous run of state updates for a
+	// particular session within a single transaction, returning the
+	// session's last applied value after each update. The updates are
+	// subject to the same ordering rules as CommitUpdate, and if any of
+	// them is rejected then none of the updates are persisted.
+	CommitUpdates(id *wtdb.SessionID,
+		updates []*wtdb.CommittedUpdate) ([]uint16, error)
+
 	// AckUpdate records an acknowledgment from the watchtower that the
 	// update identified by seqNum was received and saved. The returned
 	// lastApplied will be recorded.
 	AckUpdate(id *wtdb.SessionID, seqNum, lastApplied uint16) error
+
+	// SubscribeSessionEvents returns a buffered channel over which
+	// session lifecycle events, such as creation and exhaustion, are
+	// delivered, along with a function that cancels the subscription.
+	// Events are dropped rather than blocking the database if the channel
+	// is full.
+	SubscribeSessionEvents() (<-chan wtdb.SessionEvent, func())
+
+	// DBStats returns a summary of the contents of the client database.
+	DBStats() (wtdb.ClientDBStats, error)
+
+	// Ping cheaply checks that the database can be read and holds its
+	// core buckets, without scanning any data. It's intended to be used
+	// as a health check.
+	Ping() error
+
+	// DeleteCommittedUpdate removes the committed update identified by
+	// seqNum from the session without acking it, leaving the session's
+	// other committed updates untouched.
+	DeleteCommittedUpdate(id *wtdb.SessionID, seqNum uint16) error
+
+	// AckUpdates records a batch of acknowledgments from the watchtower in
+	// a single transaction. The acks map each sequence number to the last
+	// applied value echoed by the tower, and are applied in ascending
+	// order of sequence number. If any of the acks is rejected, then none
+	// of them are persisted.
+	AckUpdates(id *wtdb.SessionID, acks map[uint16]uint16) error
 }
 
 // AuthDialer connects to a remote node using an authenticated transport, such
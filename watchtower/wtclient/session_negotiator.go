@@ -462,6 +462,7 @@ func (n *sessionNegotiator) tryAddress(sessionKey keychain.SingleKeyECDH,
 				KeyIndex:       keyIndex,
 				Policy:         n.cfg.Policy,
 				RewardPkScript: rewardPkScript,
+				SessionKeyPub:  sessionKey.PubKey(),
 			},
 			Tower:          tower,
 			SessionKeyECDH: sessionKey,
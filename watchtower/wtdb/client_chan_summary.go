@@ -1,11 +1,35 @@
 package wtdb
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"io"
+	"sort"
+	"time"
 
 	"github.com/lightningnetwork/lnd/lnwire"
 )
 
+// DefaultChannelPriority is the backup priority assigned to newly registered
+// channels, as well as to channels registered before priorities were stored.
+// It lies in the middle of the range so that channels can be moved both above
+// and below it.
+const DefaultChannelPriority uint8 = 128
+
+// DefaultSweepScriptKind is the output kind under which the pkscript used for
+// all kinds of outputs without a distinct pkscript is passed to
+// RegisterChannelMulti. It becomes the channel's SweepPkScript.
+const DefaultSweepScriptKind = "default"
+
+// MaxSweepPkScripts is the maximum number of distinct sweep pkscripts, besides
+// its SweepPkScript, that can be registered for a channel.
+const MaxSweepPkScripts = 8
+
+// ErrInvalidSweepPkScripts signals that the sweep pkscripts given when
+// registering a channel with multiple sweep pkscripts are invalid.
+var ErrInvalidSweepPkScripts = errors.New("invalid sweep pkscripts")
+
 // ChannelSummaries is a map for a given channel id to it's ClientChanSummary.
 type ChannelSummaries map[lnwire.ChannelID]ClientChanSummary
 
@@ -17,16 +41,196 @@ type ClientChanSummary struct {
 	// deposit recovered funds for this particular channel.
 	SweepPkScript []byte
 
+	// SweepPkScripts optionally maps the kinds of outputs swept by justice
+	// transactions, such as to_local or anchor outputs, to a distinct
+	// pkscript to which the recovered funds of that kind should be
+	// deposited. Kinds without an entry use SweepPkScript.
+	SweepPkScripts map[string][]byte
+
+	// Priority is the backup priority of the channel. How it's used to
+	// order backups is left to the client.
+	Priority uint8
+
+	// DeletedAt is the time at which the channel was soft-deleted. It is
+	// the zero time for registered channels, and is only set for the
+	// tombstoned channels returned when fetching summaries WithTombstoned.
+	//
+	// NOTE: This value is not serialized with the rest of the summary. It
+	// is stored as part of the channel's tombstone.
+	DeletedAt time.Time
+
 	// TODO(conner): later extend with info about initial commit height,
 	// ineligible states, etc.
 }
 
+// NewMultiScriptChanSummary constructs the summary of a channel registered
+// with the given sweep pkscripts, keyed by output kind. The pkscript given
+// under DefaultSweepScriptKind becomes the summary's SweepPkScript, while the
+// remaining ones populate its SweepPkScripts. ErrInvalidSweepPkScripts is
+// returned if the default pkscript is missing, if any pkscript is empty, or if
+// more than MaxSweepPkScripts additional pkscripts are given.
+func NewMultiScriptChanSummary(
+	scripts map[string][]byte) (*ClientChanSummary, error) {
+
+	if len(scripts[DefaultSweepScriptKind]) == 0 {
+		return nil, fmt.Errorf("%w: missing %q pkscript",
+			ErrInvalidSweepPkScripts, DefaultSweepScriptKind)
+	}
+
+	if len(scripts)-1 > MaxSweepPkScripts {
+		return nil, fmt.Errorf("%w: more than %d additional pkscripts",
+			ErrInvalidSweepPkScripts, MaxSweepPkScripts)
+	}
+
+	summary := &ClientChanSummary{
+		SweepPkScript: append(
+			[]byte(nil), scripts[DefaultSweepScriptKind]...,
+		),
+		Priority: DefaultChannelPriority,
+	}
+
+	for kind, pkScript := range scripts {
+		if kind == DefaultSweepScriptKind {
+			continue
+		}
+
+		if kind == "" {
+			return nil, fmt.Errorf("%w: empty output kind",
+				ErrInvalidSweepPkScripts)
+		}
+
+		if len(pkScript) == 0 {
+			return nil, fmt.Errorf("%w: empty pkscript for %q",
+				ErrInvalidSweepPkScripts, kind)
+		}
+
+		if summary.SweepPkScripts == nil {
+			summary.SweepPkScripts = make(map[string][]byte)
+		}
+		summary.SweepPkScripts[kind] = append([]byte(nil), pkScript...)
+	}
+
+	return summary, nil
+}
+
+// SweepPkScriptFor returns the pkscript to which recovered funds of the given
+// output kind should be deposited, falling back to SweepPkScript for kinds
+// without a distinct pkscript.
+func (s ClientChanSummary) SweepPkScriptFor(kind string) []byte {
+	if pkScript, ok := s.SweepPkScripts[kind]; ok {
+		return pkScript
+	}
+
+	return s.SweepPkScript
+}
+
+// HasSweepPkScripts returns true if the summary's SweepPkScripts hold exactly
+// the given pkscripts.
+func (s ClientChanSummary) HasSweepPkScripts(
+	sweepPkScripts map[string][]byte) bool {
+
+	if len(s.SweepPkScripts) != len(sweepPkScripts) {
+		return false
+	}
+
+	for kind, pkScript := range sweepPkScripts {
+		existing, ok := s.SweepPkScripts[kind]
+		if !ok || !bytes.Equal(existing, pkScript) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Encode writes the ClientChanSummary to the passed io.Writer.
 func (s *ClientChanSummary) Encode(w io.Writer) error {
-	return WriteElement(w, s.SweepPkScript)
+	err := WriteElements(w, s.SweepPkScript, s.Priority)
+	if err != nil {
+		return err
+	}
+
+	// The additional sweep pkscripts are optional, and only written if
+	// present so that summaries without them keep their encoding.
+	if len(s.SweepPkScripts) == 0 {
+		return nil
+	}
+
+	return writeSweepPkScripts(w, s.SweepPkScripts)
 }
 
 // Decode reads a ClientChanSummary form the passed io.Reader.
 func (s *ClientChanSummary) Decode(r io.Reader) error {
-	return ReadElement(r, &s.SweepPkScript)
+	if err := ReadElement(r, &s.SweepPkScript); err != nil {
+		return err
+	}
+
+	// The priority is optional, since summaries written before it was
+	// introduced won't have one.
+	err := ReadElement(r, &s.Priority)
+	switch {
+	case err == io.EOF:
+		s.Priority = DefaultChannelPriority
+		return nil
+
+	case err != nil:
+		return err
+	}
+
+	// The additional sweep pkscripts are optional as well.
+	s.SweepPkScripts, err = readSweepPkScripts(r)
+	if err == io.EOF {
+		return nil
+	}
+
+	return err
+}
+
+// writeSweepPkScripts writes the given sweep pkscripts to the passed io.Writer,
+// in order of output kind so that the same pkscripts always produce the same
+// encoding.
+func writeSweepPkScripts(w io.Writer, sweepPkScripts map[string][]byte) error {
+	kinds := make([]string, 0, len(sweepPkScripts))
+	for kind := range sweepPkScripts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	if err := WriteElement(w, uint8(len(kinds))); err != nil {
+		return err
+	}
+
+	for _, kind := range kinds {
+		err := WriteElements(w, []byte(kind), sweepPkScripts[kind])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readSweepPkScripts reads sweep pkscripts written by writeSweepPkScripts from
+// the passed io.Reader. A nil map is returned if no pkscripts were written.
+func readSweepPkScripts(r io.Reader) (map[string][]byte, error) {
+	var numScripts uint8
+	if err := ReadElement(r, &numScripts); err != nil {
+		return nil, err
+	}
+
+	if numScripts == 0 {
+		return nil, nil
+	}
+
+	sweepPkScripts := make(map[string][]byte, numScripts)
+	for i := uint8(0); i < numScripts; i++ {
+		var kind, pkScript []byte
+		if err := ReadElements(r, &kind, &pkScript); err != nil {
+			return nil, err
+		}
+
+		sweepPkScripts[string(kind)] = pkScript
+	}
+
+	return sweepPkScripts, nil
 }
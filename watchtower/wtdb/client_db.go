@@ -4,26 +4,43 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"net"
+	"sort"
+	"time"
 
 	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightningnetwork/lnd/batch"
+	"github.com/lightningnetwork/lnd/clock"
 	"github.com/lightningnetwork/lnd/kvdb"
 	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/multimutex"
 	"github.com/lightningnetwork/lnd/watchtower/blob"
+	"github.com/lightningnetwork/lnd/watchtower/wtpolicy"
 )
 
 var (
 	// cSessionKeyIndexBkt is a top-level bucket storing:
-	//   tower-id -> reserved-session-key-index (uint32).
+	//   tower-id || blob-type -> reserved-session-key-indexes (uint32s).
 	cSessionKeyIndexBkt = []byte("client-session-key-index-bucket")
 
+	// cReleasedKeyIndexBkt is a top-level bucket storing:
+	//   tower-id || blob-type -> released-session-key-index (uint32) -> 1
+	cReleasedKeyIndexBkt = []byte("client-released-key-index-bucket")
+
 	// cChanSummaryBkt is a top-level bucket storing:
 	//   channel-id -> encoded ClientChanSummary.
 	cChanSummaryBkt = []byte("client-channel-summary-bucket")
 
+	// cChanTombstoneBkt is a top-level bucket storing:
+	//   channel-id -> deletion-time (uint64) || encoded ClientChanSummary.
+	cChanTombstoneBkt = []byte("client-channel-tombstone-bucket")
+
 	// cSessionBkt is a top-level bucket storing:
 	//   session-id => cSessionBody -> encoded ClientSessionBody
+	//              => cSessionLastAcked -> seqnum
+	//              => cSessionStorageBytes -> total blob size
 	//              => cSessionCommits => seqnum -> encoded CommittedUpdate
 	//              => cSessionAcks => seqnum -> encoded BackupID
 	cSessionBkt = []byte("client-session-bucket")
@@ -32,6 +49,17 @@ var (
 	// the ClientSession.
 	cSessionBody = []byte("client-session-body")
 
+	// cSessionLastAcked is a key within the session's bucket storing the
+	// highest sequence number acked by the tower for the session. It is
+	// absent if no update of the session has been acked yet.
+	cSessionLastAcked = []byte("client-session-last-acked")
+
+	// cSessionStorageBytes is a key within the session's bucket storing the
+	// total size of the encrypted blobs of the session's committed and
+	// acked updates. It is absent for sessions whose updates predate it, in
+	// which case the size is derived from the stored updates.
+	cSessionStorageBytes = []byte("client-session-storage-bytes")
+
 	// cSessionBody is a sub-bucket of cSessionBkt storing:
 	//    seqnum -> encoded CommittedUpdate.
 	cSessionCommits = []byte("client-session-commits")
@@ -54,6 +82,30 @@ var (
 		"client-tower-to-session-index-bucket",
 	)
 
+	// cTowerSessionCountBkt is a top-level bucket storing:
+	// 	tower-id -> number of sessions (uint64)
+	cTowerSessionCountBkt = []byte("client-tower-session-count-bucket")
+
+	// cTowerPolicyBkt is a top-level bucket storing:
+	// 	tower-id -> encoded wtpolicy.Policy
+	cTowerPolicyBkt = []byte("client-tower-policy-bucket")
+
+	// cTowerAddrUsedBkt is a top-level bucket storing:
+	// 	tower-id -> addr -> last-success-unix-nano
+	cTowerAddrUsedBkt = []byte("client-tower-addr-used-bucket")
+
+	// cTowerStatsBkt is a top-level bucket storing:
+	// 	tower-id -> encoded TowerStats
+	cTowerStatsBkt = []byte("client-tower-stats-bucket")
+
+	// cTowerBackoffBkt is a top-level bucket storing:
+	// 	tower-id -> encoded TowerBackoff
+	cTowerBackoffBkt = []byte("client-tower-backoff-bucket")
+
+	// cChanSessionsBkt is a top-level bucket storing:
+	// 	channel-id -> session-id -> 1
+	cChanSessionsBkt = []byte("client-channel-sessions-bucket")
+
 	// ErrTowerNotFound signals that the target tower was not found in the
 	// database.
 	ErrTowerNotFound = errors.New("tower not found")
@@ -86,13 +138,40 @@ var (
 	ErrClientSessionNotFound = errors.New("client session not found")
 
 	// ErrUpdateAlreadyCommitted signals that the chosen sequence number has
-	// already been committed to an update with a different breach hint.
+	// already been committed to an update with a different breach hint. It
+	// is wrapped by a CommitConflictError describing the conflict.
 	ErrUpdateAlreadyCommitted = errors.New("update already committed")
 
 	// ErrCommitUnorderedUpdate signals the client tried to commit a
 	// sequence number other than the next unallocated sequence number.
 	ErrCommitUnorderedUpdate = errors.New("update seqnum not monotonic")
 
+	// ErrUnknownRewardScript signals that the client tried to commit an
+	// update using a reward pkscript that isn't registered for the
+	// session.
+	ErrUnknownRewardScript = errors.New("reward pkscript not registered " +
+		"for session")
+
+	// ErrSessionNotActive signals that the client tried to commit a new
+	// update to a session that is inactive or exhausted.
+	ErrSessionNotActive = errors.New("session not active")
+
+	// ErrBlobTypeMismatch signals that the client tried to commit an update
+	// whose blob type differs from that of the session's policy.
+	ErrBlobTypeMismatch = errors.New("update blob type doesn't match " +
+		"session")
+
+	// ErrTooManyPendingUpdates signals that the client tried to commit an
+	// update to a session that already holds the maximum number of
+	// committed updates that haven't been acked by the tower.
+	ErrTooManyPendingUpdates = errors.New("session has too many " +
+		"pending updates")
+
+	// ErrTooManyRewardScripts signals that registering another reward
+	// pkscript would exceed MaxRewardScripts for the session.
+	ErrTooManyRewardScripts = errors.New("too many reward pkscripts for " +
+		"session")
+
 	// ErrCommittedUpdateNotFound signals that the tower tried to ACK a
 	// sequence number that has not yet been allocated by the client.
 	ErrCommittedUpdateNotFound = errors.New("committed update not found")
@@ -106,21 +185,112 @@ var (
 	// created because no session key index was reserved.
 	ErrNoReservedKeyIndex = errors.New("key index not reserved")
 
+	// ErrKeyIndexFinalized signals that a session key index could not be
+	// released because a client session was already created with it.
+	ErrKeyIndexFinalized = errors.New("key index used by a session")
+
 	// ErrIncorrectKeyIndex signals that the client session could not be
 	// created because session key index differs from the reserved key
 	// index.
 	ErrIncorrectKeyIndex = errors.New("incorrect key index")
 
+	// ErrInvalidKeyIndexCount signals that an attempt was made to reserve
+	// a non-positive number of session key indexes.
+	ErrInvalidKeyIndexCount = errors.New("number of key indexes to " +
+		"reserve must be positive")
+
 	// ErrLastTowerAddr is an error returned when the last address of a
 	// watchtower is attempted to be removed.
 	ErrLastTowerAddr = errors.New("cannot remove last tower address")
+
+	// ErrTowerAddrIndexOutOfRange signals that an attempt was made to
+	// remove a tower address by an index that doesn't refer to any of the
+	// tower's addresses.
+	ErrTowerAddrIndexOutOfRange = errors.New("tower address index out " +
+		"of range")
+
+	// ErrTowerPolicyNotFound signals that no policy has been negotiated
+	// with the target tower.
+	ErrTowerPolicyNotFound = errors.New("tower policy not found")
+
+	// ErrTowerPolicyMismatch signals that a client session could not be
+	// created because its policy differs from the policy negotiated with
+	// its tower.
+	ErrTowerPolicyMismatch = errors.New("session policy does not match " +
+		"tower policy")
+
+	// ErrSessionHasUnackedUpdates is an error returned when we attempt to
+	// delete a client session that still has committed updates which have
+	// not yet been acked by the tower.
+	ErrSessionHasUnackedUpdates = errors.New("session has unacked updates")
+
+	// ErrRotateTowerMismatch signals that a session could not be rotated
+	// because the new session was negotiated with a different tower than
+	// the session it replaces.
+	ErrRotateTowerMismatch = errors.New("rotated sessions must share " +
+		"the same tower")
+
+	// ErrTowerSessionLimitReached signals that a client session could not
+	// be created because the client already holds the maximum number of
+	// non-exhausted sessions allowed by the tower.
+	ErrTowerSessionLimitReached = errors.New("tower session limit reached")
+
+	// ErrTowerAddrNotFound signals that the target address is not one of
+	// the tower's known addresses.
+	ErrTowerAddrNotFound = errors.New("tower address not found")
+
+	// ErrCorruptChanTombstone signals that a channel's tombstone is too
+	// short to hold its deletion time.
+	ErrCorruptChanTombstone = errors.New("channel tombstone is corrupted")
+
+	// ErrMissingRewardPkScript signals that a reward session could not be
+	// created because it doesn't specify the pkscript that the tower's
+	// reward should be paid to.
+	ErrMissingRewardPkScript = errors.New("reward session is missing a " +
+		"reward pkscript")
+
+	// ErrSessionNotReward signals an attempt to update the reward pkscript
+	// of an altruist session, which doesn't pay the tower a reward.
+	ErrSessionNotReward = errors.New("session is not a reward session")
 )
 
+// ErrInvalidPolicy signals that a client session could not be created because
+// its policy failed validation. The wtpolicy error describing the failure can
+// be recovered using errors.Is.
+type ErrInvalidPolicy struct {
+	// Err is the reason the policy was deemed invalid.
+	Err error
+}
+
+// Error returns a human-readable description of the invalid policy.
+func (e *ErrInvalidPolicy) Error() string {
+	return fmt.Sprintf("invalid session policy: %v", e.Err)
+}
+
+// Unwrap returns the reason the policy was deemed invalid.
+func (e *ErrInvalidPolicy) Unwrap() error {
+	return e.Err
+}
+
+// BoltBackendOption is a functional option that can be used to modify how the
+// bbolt backend of the watchtower database is opened.
+type BoltBackendOption func(cfg *kvdb.BoltBackendConfig)
+
+// WithBoltDBTimeout overrides the DBTimeout of the passed kvdb.BoltConfig,
+// which bounds how long opening the database waits for the file lock held by
+// another process, such as a node being restarted during an upgrade.
+func WithBoltDBTimeout(timeout time.Duration) BoltBackendOption {
+	return func(cfg *kvdb.BoltBackendConfig) {
+		cfg.DBTimeout = timeout
+	}
+}
+
 // NewBoltBackendCreator returns a function that creates a new bbolt backend for
-// the watchtower database.
-func NewBoltBackendCreator(active bool, dbPath,
-	dbFileName string) func(boltCfg *kvdb.BoltConfig) (kvdb.Backend,
-	error) {
+// the watchtower database. The BoltBackendOptions are applied on top of the
+// passed kvdb.BoltConfig.
+func NewBoltBackendCreator(active bool, dbPath, dbFileName string,
+	opts ...BoltBackendOption) func(boltCfg *kvdb.BoltConfig) (
+	kvdb.Backend, error) {
 
 	// If the watchtower client isn't active, we return a function that
 	// always returns a nil DB to make sure we don't create empty database
@@ -140,6 +310,9 @@ func NewBoltBackendCreator(active bool, dbPath,
 			AutoCompactMinAge: boltCfg.AutoCompactMinAge,
 			DBTimeout:         boltCfg.DBTimeout,
 		}
+		for _, opt := range opts {
+			opt(cfg)
+		}
 
 		db, err := kvdb.GetBoltBackend(cfg)
 		if err != nil {
@@ -154,6 +327,136 @@ func NewBoltBackendCreator(active bool, dbPath,
 // wtclient.
 type ClientDB struct {
 	db kvdb.Backend
+
+	// sessionEvents delivers session lifecycle events to subscribers.
+	sessionEvents *SessionEventDispatcher
+
+	// scripts seals the sweep and reward pkscripts stored on disk. It is
+	// nil if no encryption key was configured.
+	scripts *scriptCipher
+
+	// maxPendingUpdates is the maximum number of unacked committed updates
+	// a session may hold. Zero means that the number isn't capped.
+	maxPendingUpdates uint16
+
+	// keyIndexMtx serializes, per tower, the transactions that read and
+	// rewrite a tower's session key index reservations. This ensures that
+	// concurrent callers can't hand out or consume the same reservation
+	// twice, even on backends that don't serialize all writers.
+	keyIndexMtx *multimutex.Mutex
+
+	// clock is used to timestamp the towers, sessions and updates written
+	// to the database.
+	clock clock.Clock
+
+	// updateScheduler, if non-nil, combines the transactions of
+	// concurrent update commits and acks. If nil, they're committed in
+	// transactions of their own.
+	updateScheduler batch.Scheduler
+}
+
+// createBucketsRetryDelay is the delay before the first retry of a failed
+// creation of the client database's top-level buckets. The delay doubles with
+// every subsequent retry.
+const createBucketsRetryDelay = 100 * time.Millisecond
+
+// ClientDBCfg houses the options that can be used when opening the client
+// database.
+type ClientDBCfg struct {
+	// CreateBucketsRetries is the number of times the creation of the
+	// top-level buckets is retried after a transient failure of the
+	// backend.
+	//
+	// NOTE: A bolt database locked by another process already fails to
+	// open in the backend creator, so waiting for the lock is governed by
+	// the DBTimeout of the bolt backend instead.
+	CreateBucketsRetries int
+
+	// EncryptionKey is the AES key used to encrypt the sweep and reward
+	// pkscripts stored in the database. The pkscripts are stored in the
+	// clear if no key is set. Once a key is set, the pkscripts stored in
+	// the clear are encrypted, and the same key must be used every time
+	// the database is opened.
+	EncryptionKey []byte
+
+	// MaxPendingUpdates is the maximum number of committed updates a
+	// session may hold that haven't been acked by the tower. Committing
+	// another update fails with ErrTooManyPendingUpdates. A value of zero
+	// disables the cap.
+	MaxPendingUpdates uint16
+
+	// Clock is used to timestamp the towers, sessions and updates written
+	// to the database.
+	Clock clock.Clock
+
+	// BatchInterval, if non-zero, is the maximum delay for which the
+	// commit of an update or ack is held back, so that it can be combined
+	// with those of other sessions into a single write transaction. Each
+	// commit still only returns once its transaction is durable, so this
+	// trades latency for throughput.
+	BatchInterval time.Duration
+}
+
+// ClientDBOption is a functional option that can be used to modify how the
+// client database is opened.
+type ClientDBOption func(cfg *ClientDBCfg)
+
+// NewClientDBCfg constructs a new ClientDBCfg with the default values, which
+// don't retry any failures and use the system clock.
+func NewClientDBCfg() *ClientDBCfg {
+	return &ClientDBCfg{
+		Clock: clock.NewDefaultClock(),
+	}
+}
+
+// WithCreateBucketsRetry sets the number of times the creation of the client
+// database's top-level buckets is retried after a failure.
+func WithCreateBucketsRetry(n int) ClientDBOption {
+	return func(cfg *ClientDBCfg) {
+		cfg.CreateBucketsRetries = n
+	}
+}
+
+// WithEncryptionKey enables the at-rest encryption of the sweep and reward
+// pkscripts stored in the client database, using the given 16, 24 or 32 byte
+// AES key. The pkscripts are sealed using AES-GCM before they're written, and
+// transparently opened when they're read. Reward pkscripts recorded on
+// individual committed updates are not encrypted. Opening an encrypted database
+// without its key fails with ErrEncryptionKeyRequired, and with another key
+// fails with ErrEncryptionKeyMismatch.
+func WithEncryptionKey(key []byte) ClientDBOption {
+	return func(cfg *ClientDBCfg) {
+		cfg.EncryptionKey = key
+	}
+}
+
+// WithMaxPendingUpdates caps the number of committed updates a session may hold
+// that haven't been acked by the tower, so that the client stops piling updates
+// onto a tower that has gone offline. A value of zero disables the cap.
+func WithMaxPendingUpdates(n uint16) ClientDBOption {
+	return func(cfg *ClientDBCfg) {
+		cfg.MaxPendingUpdates = n
+	}
+}
+
+// WithClock sets the clock used to timestamp the towers, sessions and updates
+// written to the client database.
+func WithClock(c clock.Clock) ClientDBOption {
+	return func(cfg *ClientDBCfg) {
+		cfg.Clock = c
+	}
+}
+
+// WithBatchInterval combines the update commits and acks of concurrent callers
+// into shared write transactions of the client database, holding each one back
+// for at most the given interval. This reduces the number of fsyncs without
+// weakening durability, since every call still only returns once its
+// transaction has been committed. The settings of the backend itself are left
+// untouched.
+func WithBatchInterval(interval time.Duration) ClientDBOption {
+	return func(cfg *ClientDBCfg) {
+		cfg.BatchInterval = interval
+	}
 }
 
 // OpenClientDB opens the client database given the path to the database's
@@ -161,16 +464,47 @@ type ClientDB struct {
 // one using the latest version number and bucket structure. If a database
 // exists but has a lower version number than the current version, any necessary
 // migrations will be applied before returning. Any attempt to open a database
-// with a version number higher that the latest version will fail to prevent
-// accidental reversion.
-func OpenClientDB(db kvdb.Backend) (*ClientDB, error) {
+// with a version number higher that the latest version will fail with
+// ErrDBVersionTooNew to prevent accidental reversion. The ClientDBOptions can
+// be used to retry transient failures, and to encrypt the pkscripts stored in
+// the database.
+func OpenClientDB(db kvdb.Backend, opts ...ClientDBOption) (*ClientDB,
+	error) {
+
+	cfg := NewClientDBCfg()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var scripts *scriptCipher
+	if cfg.EncryptionKey != nil {
+		var err error
+		scripts, err = newScriptCipher(cfg.EncryptionKey)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
 	firstInit, err := isFirstInit(db)
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
 
 	clientDB := &ClientDB{
-		db: db,
+		db:                db,
+		sessionEvents:     NewSessionEventDispatcher(),
+		scripts:           scripts,
+		maxPendingUpdates: cfg.MaxPendingUpdates,
+		keyIndexMtx:       multimutex.NewMutex(),
+		clock:             cfg.Clock,
+	}
+
+	if cfg.BatchInterval != 0 {
+		clientDB.updateScheduler = batch.NewTimeScheduler(
+			db, nil, cfg.BatchInterval,
+		)
 	}
 
 	err = initOrSyncVersions(clientDB, firstInit, clientDBVersions)
@@ -184,7 +518,15 @@ func OpenClientDB(db kvdb.Backend) (*ClientDB, error) {
 	// initialized. This allows us to assume their presence throughout all
 	// operations. If an known top-level bucket is expected to exist but is
 	// missing, this will trigger a ErrUninitializedDB error.
-	err = kvdb.Update(clientDB.db, initClientDBBuckets, func() {})
+	err = createClientDBBuckets(clientDB.db, cfg)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	err = kvdb.Update(db, func(tx kvdb.RwTx) error {
+		return initEncryption(tx, scripts)
+	}, func() {})
 	if err != nil {
 		db.Close()
 		return nil, err
@@ -193,19 +535,184 @@ func OpenClientDB(db kvdb.Backend) (*ClientDB, error) {
 	return clientDB, nil
 }
 
+// initEncryption checks that the database is opened with the key its
+// pkscripts are encrypted with, if any. If the pkscripts of the database are
+// stored in the clear but a key is given, they're encrypted with that key.
+//
+// NOTE: The pages that held the pkscripts in the clear are only freed, so they
+// may linger in the database file until they're reused or the file is
+// compacted.
+func initEncryption(tx kvdb.RwTx, scripts *scriptCipher) error {
+	encrypted, err := checkEncryptionKey(tx, scripts)
+	if err != nil || encrypted || scripts == nil {
+		return err
+	}
+
+	log.Infof("Encrypting client db pkscripts stored in the clear")
+
+	err = rotateScripts(tx, nil, scripts)
+	if err != nil {
+		return err
+	}
+
+	return putEncryptionCheck(tx, scripts)
+}
+
+// rotateScripts opens all sweep and reward pkscripts stored in the database
+// with oldScripts, and seals them again with newScripts. ErrScriptDecryption is
+// returned if any of them can't be opened.
+func rotateScripts(tx kvdb.RwTx, oldScripts, newScripts *scriptCipher) error {
+	sessions := tx.ReadWriteBucket(cSessionBkt)
+	if sessions == nil {
+		return ErrUninitializedDB
+	}
+
+	chanSummaries := tx.ReadWriteBucket(cChanSummaryBkt)
+	if chanSummaries == nil {
+		return ErrUninitializedDB
+	}
+
+	// The buckets can't be modified while they're being iterated,
+	// so the sessions and channel summaries are collected first.
+	var rotated []*ClientSession
+	err := sessions.ForEach(func(k, _ []byte) error {
+		session, err := getClientSessionBody(sessions, k)
+		if err != nil {
+			return err
+		}
+
+		session, err = oldScripts.openSession(session)
+		if err != nil {
+			return err
+		}
+
+		session, err = newScripts.sealSession(session)
+		if err != nil {
+			return err
+		}
+
+		rotated = append(rotated, session)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	summaries := make(ChannelSummaries)
+	err = chanSummaries.ForEach(func(k, v []byte) error {
+		var chanID lnwire.ChannelID
+		copy(chanID[:], k)
+
+		summary, err := decodeChanSummary(v, chanID, oldScripts)
+		if err != nil {
+			return err
+		}
+
+		summaries[chanID] = *summary
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	tombstones := tx.ReadWriteBucket(cChanTombstoneBkt)
+	if tombstones == nil {
+		return ErrUninitializedDB
+	}
+
+	tombstoned := make(ChannelSummaries)
+	err = tombstones.ForEach(func(k, v []byte) error {
+		var chanID lnwire.ChannelID
+		copy(chanID[:], k)
+
+		summary, err := decodeChanTombstone(
+			v, chanID, oldScripts,
+		)
+		if err != nil {
+			return err
+		}
+
+		tombstoned[chanID] = *summary
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, session := range rotated {
+		err := putClientSessionBody(sessions, session)
+		if err != nil {
+			return err
+		}
+	}
+
+	for chanID, summary := range summaries {
+		summary := summary
+		err := putChanSummary(
+			chanSummaries, chanID, &summary, newScripts,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	for chanID, summary := range tombstoned {
+		summary := summary
+		err := putChanTombstone(
+			tombstones, chanID, &summary, newScripts,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createClientDBBuckets creates all top-level buckets of the client database,
+// retrying failures as permitted by the given config.
+func createClientDBBuckets(db kvdb.Backend, cfg *ClientDBCfg) error {
+	delay := createBucketsRetryDelay
+	for attempt := 0; ; attempt++ {
+		err := kvdb.Update(db, initClientDBBuckets, func() {})
+		if err == nil || attempt >= cfg.CreateBucketsRetries {
+			return err
+		}
+
+		log.Debugf("Unable to create client db buckets, retrying in "+
+			"%v: %v", delay, err)
+
+		<-cfg.Clock.TickAfter(delay)
+		delay *= 2
+	}
+}
+
+// clientDBBuckets are the top-level buckets required to handle database
+// operations required by the latest version.
+var clientDBBuckets = [][]byte{
+	cSessionKeyIndexBkt,
+	cChanSummaryBkt,
+	cSessionBkt,
+	cTowerBkt,
+	cTowerIndexBkt,
+	cTowerToSessionIndexBkt,
+	cTowerSessionCountBkt,
+	cTowerPolicyBkt,
+	cTowerAddrUsedBkt,
+	cTowerStatsBkt,
+	cChanSessionsBkt,
+	cChanTombstoneBkt,
+	cReleasedKeyIndexBkt,
+	cTowerBackoffBkt,
+}
+
 // initClientDBBuckets creates all top-level buckets required to handle database
 // operations required by the latest version.
 func initClientDBBuckets(tx kvdb.RwTx) error {
-	buckets := [][]byte{
-		cSessionKeyIndexBkt,
-		cChanSummaryBkt,
-		cSessionBkt,
-		cTowerBkt,
-		cTowerIndexBkt,
-		cTowerToSessionIndexBkt,
-	}
-
-	for _, bucket := range buckets {
+	for _, bucket := range clientDBBuckets {
 		_, err := tx.CreateTopLevelBucket(bucket)
 		if err != nil {
 			return err
@@ -246,956 +753,5398 @@ func (c *ClientDB) Close() error {
 	return c.db.Close()
 }
 
+// ClientDBView is a read-only view of a ClientDB. All of its methods are
+// served by read-only transactions, so inspecting the database through a view
+// can't mutate it and doesn't contend with the database's write path.
+//
+// NOTE: The view is not a snapshot of the database. Each method call runs in
+// its own read transaction, so the results of separate calls may reflect
+// different states of the database if it's written to in between.
+type ClientDBView struct {
+	db *ClientDB
+}
+
+// SubscribeSessionEvents returns a buffered channel over which session
+// lifecycle events are delivered: session creation, exhaustion, and status
+// changes caused by tower removal or (re)activation. Events are delivered once
+// the database transaction producing them has committed. If the channel's
+// buffer is full, events are dropped rather than blocking the database, and
+// counted by NumDroppedSessionEvents. The returned function cancels the
+// subscription and closes the channel.
+func (c *ClientDB) SubscribeSessionEvents() (<-chan SessionEvent, func()) {
+	return c.sessionEvents.Subscribe()
+}
+
+// NumDroppedSessionEvents returns the number of session events that were
+// dropped because a subscriber's buffer was full.
+func (c *ClientDB) NumDroppedSessionEvents() uint64 {
+	return c.sessionEvents.NumDropped()
+}
+
+// ReadView returns a read-only view of the client database, whose methods each
+// read the latest committed state of the database.
+func (c *ClientDB) ReadView() *ClientDBView {
+	return &ClientDBView{db: c}
+}
+
+// ListTowers retrieves the list of towers available within the database,
+// ordered by TowerID. The TowerListOptions can be used to further filter the
+// set of towers returned.
+func (v *ClientDBView) ListTowers(opts ...TowerListOption) ([]*Tower, error) {
+	return v.db.ListTowers(opts...)
+}
+
+// ListClientSessions returns the set of all client sessions known to the db. An
+// optional tower ID can be used to filter out any client sessions in the
+// response that do not correspond to this tower.
+func (v *ClientDBView) ListClientSessions(id *TowerID,
+	opts ...ClientSessionListOption) (map[SessionID]*ClientSession, error) {
+
+	return v.db.ListClientSessions(id, opts...)
+}
+
+// FetchSessionCommittedUpdates retrieves the current set of un-acked updates
+// of the given session.
+func (v *ClientDBView) FetchSessionCommittedUpdates(id *SessionID) (
+	[]CommittedUpdate, error) {
+
+	return v.db.FetchSessionCommittedUpdates(id)
+}
+
+// ForEachSessionCommittedUpdate calls cb with each of the current un-acked
+// updates of the given session, in ascending order of sequence number.
+func (v *ClientDBView) ForEachSessionCommittedUpdate(id *SessionID,
+	cb func(*CommittedUpdate) error) error {
+
+	return v.db.ForEachSessionCommittedUpdate(id, cb)
+}
+
+// FetchChanSummaries loads a mapping from all registered channels to their
+// channel summaries. The ChanSummaryFetchOptions can be used to include the
+// summaries of tombstoned channels.
+func (v *ClientDBView) FetchChanSummaries(
+	opts ...ChanSummaryFetchOption) (ChannelSummaries, error) {
+
+	return v.db.FetchChanSummaries(opts...)
+}
+
+// FetchChanSummariesForChannels loads the channel summaries of the given
+// channels, omitting any that aren't registered.
+func (v *ClientDBView) FetchChanSummariesForChannels(
+	chanIDs []lnwire.ChannelID) (ChannelSummaries, error) {
+
+	return v.db.FetchChanSummariesForChannels(chanIDs)
+}
+
 // CreateTower initialize an address record used to communicate with a
 // watchtower. Each Tower is assigned a unique ID, that is used to amortize
 // storage costs of the public key when used by multiple sessions. If the tower
 // already exists, the address is appended to the list of all addresses used to
 // that tower previously and its corresponding sessions are marked as active.
-func (c *ClientDB) CreateTower(lnAddr *lnwire.NetAddress) (*Tower, error) {
+// The CreateTowerOptions can be used to set optional fields on the tower, such
+// as its nickname. An ErrInvalidTowerAddr is returned if the address is
+// malformed.
+func (c *ClientDB) CreateTower(lnAddr *lnwire.NetAddress,
+	opts ...CreateTowerOption) (*Tower, error) {
+
+	if err := ValidateTowerAddr(lnAddr.Address); err != nil {
+		return nil, err
+	}
+
+	cfg := NewCreateTowerCfg()
+	for _, o := range opts {
+		o(cfg)
+	}
+
+	var (
+		tower  *Tower
+		events sessionEventLog
+	)
+	err := kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		var err error
+		tower, err = createTower(tx, lnAddr, cfg, c.now(), &events)
+		return err
+	}, func() {
+		tower = nil
+		events = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.sessionEvents.Notify(events...)
+
+	return tower, nil
+}
+
+// createTower creates the tower for the given address, or adds the address to
+// the existing tower with the same identity key, marking its sessions as
+// active. The tower's timestamps are set to now, and the resulting session
+// status changes are recorded in the given event log.
+func createTower(tx kvdb.RwTx, lnAddr *lnwire.NetAddress,
+	cfg *CreateTowerCfg, now time.Time, events *sessionEventLog) (*Tower,
+	error) {
+
+	towerIndex := tx.ReadWriteBucket(cTowerIndexBkt)
+	if towerIndex == nil {
+		return nil, ErrUninitializedDB
+	}
+
+	towers := tx.ReadWriteBucket(cTowerBkt)
+	if towers == nil {
+		return nil, ErrUninitializedDB
+	}
+
+	towerToSessionIndex := tx.ReadWriteBucket(cTowerToSessionIndexBkt)
+	if towerToSessionIndex == nil {
+		return nil, ErrUninitializedDB
+	}
+
 	var towerPubKey [33]byte
 	copy(towerPubKey[:], lnAddr.IdentityKey.SerializeCompressed())
 
+	// Check if the tower index already knows of this pubkey.
 	var tower *Tower
-	err := kvdb.Update(c.db, func(tx kvdb.RwTx) error {
-		towerIndex := tx.ReadWriteBucket(cTowerIndexBkt)
-		if towerIndex == nil {
-			return ErrUninitializedDB
+	towerIDBytes := towerIndex.Get(towerPubKey[:])
+	if len(towerIDBytes) == 8 {
+		// The tower already exists, deserialize the existing record.
+		var err error
+		tower, err = getTower(towers, towerIDBytes)
+		if err != nil {
+			return nil, err
 		}
 
-		towers := tx.ReadWriteBucket(cTowerBkt)
-		if towers == nil {
-			return ErrUninitializedDB
-		}
+		// Add the new address to the existing tower. If the address is
+		// a duplicate, this will result in no change.
+		numAddrs, numEvents := len(tower.Addresses), len(*events)
+		tower.AddAddress(lnAddr.Address)
 
-		towerToSessionIndex := tx.ReadWriteBucket(
-			cTowerToSessionIndexBkt,
+		// If there are any client sessions that correspond to this
+		// tower, we'll mark them as active to ensure we load them upon
+		// restarts.
+		towerSessIndex := towerToSessionIndex.NestedReadBucket(
+			tower.ID.Bytes(),
 		)
-		if towerToSessionIndex == nil {
-			return ErrUninitializedDB
+		if towerSessIndex == nil {
+			return nil, ErrTowerNotFound
+		}
+
+		sessions := tx.ReadWriteBucket(cSessionBkt)
+		if sessions == nil {
+			return nil, ErrUninitializedDB
 		}
 
-		// Check if the tower index already knows of this pubkey.
-		towerIDBytes := towerIndex.Get(towerPubKey[:])
-		if len(towerIDBytes) == 8 {
-			// The tower already exists, deserialize the existing
-			// record.
-			var err error
-			tower, err = getTower(towers, towerIDBytes)
+		err = towerSessIndex.ForEach(func(k, _ []byte) error {
+			session, err := getClientSessionBody(sessions, k)
 			if err != nil {
 				return err
 			}
 
-			// Add the new address to the existing tower. If the
-			// address is a duplicate, this will result in no
-			// change.
-			tower.AddAddress(lnAddr.Address)
+			return markSessionStatus(
+				sessions, session, CSessionActive, events,
+			)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// The tower is only modified if the address is new, or if
+		// any of its sessions were reactivated.
+		addrAdded := len(tower.Addresses) != numAddrs
+		reactivated := len(*events) != numEvents
+		if addrAdded || reactivated {
+			tower.LastModified = now
+		}
+	} else {
+		// No such tower exists, create a new tower id for our new
+		// tower. The error is unhandled since NextSequence never fails
+		// in an Update.
+		towerID, _ := towerIndex.NextSequence()
+
+		tower = &Tower{
+			ID:          TowerID(towerID),
+			IdentityKey: lnAddr.IdentityKey,
+			Addresses:   []net.Addr{lnAddr.Address},
+			Features:    NewTowerFeatures(nil),
+			CreatedAt:   now,
+		}
+		tower.LastModified = tower.CreatedAt
+
+		towerIDBytes = tower.ID.Bytes()
+
+		// Since this tower is new, record the mapping from tower pubkey
+		// to tower id in the tower index.
+		err := towerIndex.Put(towerPubKey[:], towerIDBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		// Create a new bucket for this tower in the tower-to-sessions
+		// index.
+		_, err = towerToSessionIndex.CreateBucket(towerIDBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply the nickname if one was provided.
+	if cfg.Nickname != "" {
+		tower.Nickname = cfg.Nickname
+	}
+
+	// Store the new or updated tower under its tower id.
+	if err := putTower(towers, tower); err != nil {
+		return nil, err
+	}
+
+	return tower, nil
+}
+
+// CreateTowerAndReserveKey creates the tower for the given address, exactly as
+// CreateTower does, and reserves a session key index for the tower and blob
+// type in the same transaction. This ensures that a newly onboarded tower
+// always has a reservation. The returned index is the one that
+// NextSessionKeyIndex returns until a session is created with it.
+func (c *ClientDB) CreateTowerAndReserveKey(lnAddr *lnwire.NetAddress,
+	blobType blob.Type) (*Tower, uint32, error) {
+
+	if err := ValidateTowerAddr(lnAddr.Address); err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		tower    *Tower
+		keyIndex uint32
+		events   sessionEventLog
+	)
+	err := kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		var err error
+		tower, err = createTower(
+			tx, lnAddr, NewCreateTowerCfg(), c.now(), &events,
+		)
+		if err != nil {
+			return err
+		}
+
+		indexes, err := reserveSessionKeyIndices(
+			tx, tower.ID, blobType, 1,
+		)
+		if err != nil {
+			return err
+		}
+		keyIndex = indexes[0]
+
+		return nil
+	}, func() {
+		tower = nil
+		keyIndex = 0
+		events = nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	c.sessionEvents.Notify(events...)
+
+	return tower, keyIndex, nil
+}
+
+// SetTowerNickname sets the human-readable nickname of the tower with the given
+// ID. An empty nickname clears any existing one. ErrTowerNotFound is returned
+// if the tower does not exist.
+func (c *ClientDB) SetTowerNickname(id TowerID, nickname string) error {
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		towers := tx.ReadWriteBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		tower, err := getTower(towers, id.Bytes())
+		if err != nil {
+			return err
+		}
+
+		tower.Nickname = nickname
+
+		return putTower(towers, tower)
+	}, func() {})
+}
+
+// MarkTowerAddressUsed records that a connection to the tower with the given ID
+// was last successfully made over the given address at the current time.
+// LoadTower and LoadTowerByID return the tower's addresses ordered by their
+// most recent success. ErrTowerNotFound is returned if the tower does not
+// exist, and ErrTowerAddrNotFound if the address isn't one of the tower's.
+func (c *ClientDB) MarkTowerAddressUsed(id TowerID, addr net.Addr) error {
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		towers := tx.ReadBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		addrUsed := tx.ReadWriteBucket(cTowerAddrUsedBkt)
+		if addrUsed == nil {
+			return ErrUninitializedDB
+		}
+
+		tower, err := getTower(towers, id.Bytes())
+		if err != nil {
+			return err
+		}
+
+		addrStr := addr.String()
+		var known bool
+		for _, towerAddr := range tower.Addresses {
+			if towerAddr.String() == addrStr {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return ErrTowerAddrNotFound
+		}
+
+		towerAddrUsed, err := addrUsed.CreateBucketIfNotExists(
+			id.Bytes(),
+		)
+		if err != nil {
+			return err
+		}
+
+		var b [8]byte
+		byteOrder.PutUint64(b[:], timeToUnixNano(c.now()))
+
+		return towerAddrUsed.Put([]byte(addrStr), b[:])
+	}, func() {})
+}
+
+// deleteTowerAddrUsed removes the time at which the given address of the tower
+// was last used. If no address is provided, the times of all of the tower's
+// addresses are removed.
+func deleteTowerAddrUsed(tx kvdb.RwTx, towerIDBytes []byte,
+	addr net.Addr) error {
+
+	addrUsed := tx.ReadWriteBucket(cTowerAddrUsedBkt)
+	if addrUsed == nil {
+		return ErrUninitializedDB
+	}
+
+	towerAddrUsed := addrUsed.NestedReadWriteBucket(towerIDBytes)
+	if towerAddrUsed == nil {
+		return nil
+	}
+
+	if addr != nil {
+		return towerAddrUsed.Delete([]byte(addr.String()))
+	}
+
+	return addrUsed.DeleteNestedBucket(towerIDBytes)
+}
+
+// sortTowerAddrs orders the tower's addresses by the time they were last
+// successfully used, as recorded in the tower-addr-used bucket.
+func sortTowerAddrs(addrUsed kvdb.RBucket, tower *Tower) error {
+	towerAddrUsed := addrUsed.NestedReadBucket(tower.ID.Bytes())
+	if towerAddrUsed == nil {
+		return nil
+	}
+
+	lastUsed := make(map[string]time.Time)
+	err := towerAddrUsed.ForEach(func(k, v []byte) error {
+		if len(v) != 8 {
+			return fmt.Errorf("invalid last used time for tower "+
+				"address %s", k)
+		}
+
+		lastUsed[string(k)] = timeFromUnixNano(byteOrder.Uint64(v))
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	tower.SortAddresses(lastUsed)
+
+	return nil
+}
+
+// RecordTowerStat records the outcome of an attempt to deliver updates to the
+// tower with the given ID, incrementing its persisted success or failure
+// counter and setting its last contact time to the current time. The stats
+// are exposed via the Stats field of towers returned by LoadTower and
+// LoadTowerByID. ErrTowerNotFound is returned if the tower does not exist.
+func (c *ClientDB) RecordTowerStat(id TowerID, success bool) error {
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		towers := tx.ReadBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		statsBkt := tx.ReadWriteBucket(cTowerStatsBkt)
+		if statsBkt == nil {
+			return ErrUninitializedDB
+		}
+
+		tower, err := getTower(towers, id.Bytes())
+		if err != nil {
+			return err
+		}
+
+		if err := getTowerStats(statsBkt, tower); err != nil {
+			return err
+		}
+
+		stats := tower.Stats
+		if success {
+			stats.NumSuccesses++
+		} else {
+			stats.NumFailures++
+		}
+		stats.LastContact = c.now()
+
+		var b bytes.Buffer
+		if err := stats.Encode(&b); err != nil {
+			return err
+		}
+
+		return statsBkt.Put(id.Bytes(), b.Bytes())
+	}, func() {})
+}
+
+// getTowerStats populates the Stats field of the tower from the tower-stats
+// bucket. Towers without any recorded stats are left with zero-valued stats.
+func getTowerStats(statsBkt kvdb.RBucket, tower *Tower) error {
+	statsBytes := statsBkt.Get(tower.ID.Bytes())
+	if statsBytes == nil {
+		return nil
+	}
+
+	return tower.Stats.Decode(bytes.NewReader(statsBytes))
+}
+
+// SetTowerBackoff records the backoff between the client's connection attempts
+// to the tower with the given ID, such that the backoff schedule can be resumed
+// across restarts. The backoff is exposed via the Backoff field of towers
+// returned by LoadTower and LoadTowerByID. Zero values clear the backoff,
+// meaning that the tower can be connected to immediately. ErrTowerNotFound is
+// returned if the tower does not exist.
+func (c *ClientDB) SetTowerBackoff(id TowerID, nextAttempt time.Time,
+	attempts uint32) error {
+
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		towers := tx.ReadBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		backoffBkt := tx.ReadWriteBucket(cTowerBackoffBkt)
+		if backoffBkt == nil {
+			return ErrUninitializedDB
+		}
+
+		if towers.Get(id.Bytes()) == nil {
+			return ErrTowerNotFound
+		}
+
+		backoff := TowerBackoff{
+			NextAttempt: nextAttempt,
+			Attempts:    attempts,
+		}
+		if backoff == (TowerBackoff{}) {
+			return backoffBkt.Delete(id.Bytes())
+		}
+
+		var b bytes.Buffer
+		if err := backoff.Encode(&b); err != nil {
+			return err
+		}
+
+		return backoffBkt.Put(id.Bytes(), b.Bytes())
+	}, func() {})
+}
+
+// getTowerBackoff populates the Backoff field of the tower from the
+// tower-backoff bucket. Towers without any recorded backoff are left with a
+// zero-valued backoff.
+func getTowerBackoff(backoffBkt kvdb.RBucket, tower *Tower) error {
+	backoffBytes := backoffBkt.Get(tower.ID.Bytes())
+	if backoffBytes == nil {
+		return nil
+	}
+
+	return tower.Backoff.Decode(bytes.NewReader(backoffBytes))
+}
+
+// SetTowerMaxSessions sets the maximum number of non-exhausted sessions the
+// client may hold with the tower with the given ID. A limit of zero means that
+// the number of sessions is unlimited. ErrTowerNotFound is returned if the
+// tower does not exist.
+func (c *ClientDB) SetTowerMaxSessions(id TowerID, maxSessions uint32) error {
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		towers := tx.ReadWriteBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		tower, err := getTower(towers, id.Bytes())
+		if err != nil {
+			return err
+		}
+
+		tower.MaxSessions = maxSessions
+
+		return putTower(towers, tower)
+	}, func() {})
+}
+
+// SetTowerRateLimit records the number of updates per minute that the tower
+// with the given ID was observed to accept from the client. A limit of zero
+// means that the limit is unknown or that the tower is unlimited. The limit is
+// only persisted, and is left to the client to enforce. ErrTowerNotFound is
+// returned if the tower does not exist.
+func (c *ClientDB) SetTowerRateLimit(id TowerID, updatesPerMin uint32) error {
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		towers := tx.ReadWriteBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		tower, err := getTower(towers, id.Bytes())
+		if err != nil {
+			return err
+		}
+
+		tower.RateLimit = updatesPerMin
+
+		return putTower(towers, tower)
+	}, func() {})
+}
+
+// SetTowerFeatures records the feature bits advertised by the tower with the
+// given ID, replacing any previously recorded ones. A nil feature vector clears
+// the tower's features. ErrTowerNotFound is returned if the tower does not
+// exist.
+func (c *ClientDB) SetTowerFeatures(id TowerID,
+	fv *lnwire.FeatureVector) error {
+
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		towers := tx.ReadWriteBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		tower, err := getTower(towers, id.Bytes())
+		if err != nil {
+			return err
+		}
+
+		var raw *lnwire.RawFeatureVector
+		if fv != nil {
+			raw = fv.RawFeatureVector
+		}
+		tower.Features = NewTowerFeatures(raw)
+
+		return putTower(towers, tower)
+	}, func() {})
+}
+
+// SetTowerPolicy records the policy negotiated with the tower with the given
+// ID, replacing any existing one. Sessions subsequently created with the tower
+// must use this policy. ErrTowerNotFound is returned if the tower does not
+// exist.
+func (c *ClientDB) SetTowerPolicy(id TowerID, policy wtpolicy.Policy) error {
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		towers := tx.ReadBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		policies := tx.ReadWriteBucket(cTowerPolicyBkt)
+		if policies == nil {
+			return ErrUninitializedDB
+		}
+
+		if _, err := getTower(towers, id.Bytes()); err != nil {
+			return err
+		}
+
+		var b bytes.Buffer
+		if err := WriteElement(&b, policy); err != nil {
+			return err
+		}
+
+		return policies.Put(id.Bytes(), b.Bytes())
+	}, func() {})
+}
+
+// GetTowerPolicy returns the policy negotiated with the tower with the given
+// ID. ErrTowerNotFound is returned if the tower does not exist, and
+// ErrTowerPolicyNotFound if no policy has been recorded for it.
+func (c *ClientDB) GetTowerPolicy(id TowerID) (wtpolicy.Policy, error) {
+	var policy wtpolicy.Policy
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		towers := tx.ReadBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		policies := tx.ReadBucket(cTowerPolicyBkt)
+		if policies == nil {
+			return ErrUninitializedDB
+		}
+
+		if _, err := getTower(towers, id.Bytes()); err != nil {
+			return err
+		}
+
+		var err error
+		policy, err = getTowerPolicy(policies, id)
+
+		return err
+	}, func() {
+		policy = wtpolicy.Policy{}
+	})
+	if err != nil {
+		return wtpolicy.Policy{}, err
+	}
+
+	return policy, nil
+}
+
+// getTowerPolicy loads the policy negotiated with the given tower from the
+// tower-policy bucket. ErrTowerPolicyNotFound is returned if no policy has
+// been recorded for the tower.
+func getTowerPolicy(policies kvdb.RBucket, id TowerID) (wtpolicy.Policy,
+	error) {
+
+	policyBytes := policies.Get(id.Bytes())
+	if policyBytes == nil {
+		return wtpolicy.Policy{}, ErrTowerPolicyNotFound
+	}
+
+	var policy wtpolicy.Policy
+	err := ReadElement(bytes.NewReader(policyBytes), &policy)
+	if err != nil {
+		return wtpolicy.Policy{}, err
+	}
+
+	return policy, nil
+}
+
+// RemoveTower modifies a tower's record within the database. If an address is
+// provided, then _only_ the address record should be removed from the tower's
+// persisted state. Otherwise, we'll attempt to mark the tower as inactive by
+// marking all of its sessions inactive. If any of its sessions has unacked
+// updates, then ErrTowerUnackedUpdates is returned. If the tower doesn't have
+// any sessions at all, it'll be completely removed from the database. The
+// RemoveTowerOptions can be used to report the channels left without a backup
+// by the removal.
+//
+// NOTE: An error is not returned if the tower doesn't exist.
+func (c *ClientDB) RemoveTower(pubKey *btcec.PublicKey, addr net.Addr,
+	opts ...RemoveTowerOption) error {
+
+	cfg := NewRemoveTowerCfg()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var (
+		events   sessionEventLog
+		removed  bool
+		orphaned []lnwire.ChannelID
+	)
+	err := kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		towers := tx.ReadWriteBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		towerIndex := tx.ReadWriteBucket(cTowerIndexBkt)
+		if towerIndex == nil {
+			return ErrUninitializedDB
+		}
+
+		towersToSessionsIndex := tx.ReadWriteBucket(
+			cTowerToSessionIndexBkt,
+		)
+		if towersToSessionsIndex == nil {
+			return ErrUninitializedDB
+		}
+
+		// Don't return an error if the watchtower doesn't exist to act
+		// as a NOP.
+		pubKeyBytes := pubKey.SerializeCompressed()
+		towerIDBytes := towerIndex.Get(pubKeyBytes)
+		if towerIDBytes == nil {
+			return nil
+		}
+
+		// If an address is provided, then we should _only_ remove the
+		// address record from the database.
+		if addr != nil {
+			tower, err := getTower(towers, towerIDBytes)
+			if err != nil {
+				return err
+			}
+
+			// Towers should always have at least one address saved.
+			tower.RemoveAddress(addr)
+			if len(tower.Addresses) == 0 {
+				return ErrLastTowerAddr
+			}
+			tower.LastModified = c.now()
+
+			// Forget when the address was last used, if ever.
+			err = deleteTowerAddrUsed(tx, towerIDBytes, addr)
+			if err != nil {
+				return err
+			}
+
+			return putTower(towers, tower)
+		}
+
+		// Otherwise, we should attempt to mark the tower's sessions as
+		// inactive.
+		sessions := tx.ReadWriteBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+		towerID := TowerIDFromBytes(towerIDBytes)
+
+		committedUpdateCount := make(map[SessionID]uint16)
+		perCommittedUpdate := func(s *ClientSession,
+			_ *CommittedUpdate) {
+
+			committedUpdateCount[s.ID]++
+		}
+
+		towerSessions, err := listTowerSessions(
+			towerID, sessions, towers, towersToSessionsIndex,
+			c.scripts, WithPerCommittedUpdate(perCommittedUpdate),
+		)
+		if err != nil {
+			return err
+		}
+
+		// If it doesn't have any, we can completely remove it from the
+		// database. Without any sessions, the tower can't have been
+		// backing up any channel.
+		removed = true
+		if len(towerSessions) == 0 {
+			return deleteTower(tx, pubKeyBytes, towerIDBytes)
+		}
+
+		// We'll mark its sessions as inactive as long as they don't
+		// have any pending updates to ensure we don't load them upon
+		// restarts.
+		for _, session := range towerSessions {
+			if committedUpdateCount[session.ID] > 0 {
+				return ErrTowerUnackedUpdates
+			}
+			err := markSessionStatus(
+				sessions, session, CSessionInactive, &events,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		if cfg.ReportOrphanedChannels != nil {
+			orphaned, err = listOrphanedChannels(
+				tx, towerSessions,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Record any change in the status of the tower's sessions.
+		if len(events) == 0 {
+			return nil
+		}
+
+		tower, err := getTower(towers, towerIDBytes)
+		if err != nil {
+			return err
+		}
+		tower.LastModified = c.now()
+
+		return putTower(towers, tower)
+	}, func() {
+		events = nil
+		removed = false
+		orphaned = nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.sessionEvents.Notify(events...)
+
+	if removed && cfg.ReportOrphanedChannels != nil {
+		cfg.ReportOrphanedChannels(orphaned)
+	}
+
+	return nil
+}
+
+// listOrphanedChannels returns the registered channels whose updates are held
+// by the given sessions of a removed tower, but by no session of another tower
+// that is still in use, in ascending order. Sessions are found using the
+// channel-to-session index, and a session is in use unless it's inactive.
+func listOrphanedChannels(tx kvdb.RTx,
+	towerSessions map[SessionID]*ClientSession) ([]lnwire.ChannelID,
+	error) {
+
+	chanSummaries := tx.ReadBucket(cChanSummaryBkt)
+	if chanSummaries == nil {
+		return nil, ErrUninitializedDB
+	}
+
+	chanSessions := tx.ReadBucket(cChanSessionsBkt)
+	if chanSessions == nil {
+		return nil, ErrUninitializedDB
+	}
+
+	sessions := tx.ReadBucket(cSessionBkt)
+	if sessions == nil {
+		return nil, ErrUninitializedDB
+	}
+
+	var orphaned []lnwire.ChannelID
+	err := chanSessions.ForEach(func(chanIDBytes, _ []byte) error {
+		if chanSummaries.Get(chanIDBytes) == nil {
+			return nil
+		}
+
+		chanBkt := chanSessions.NestedReadBucket(chanIDBytes)
+		if chanBkt == nil {
+			return nil
+		}
+
+		var backedByTower, backedElsewhere bool
+		err := chanBkt.ForEach(func(k, _ []byte) error {
+			var id SessionID
+			if len(k) != len(id) {
+				return ErrCorruptClientSession
+			}
+			copy(id[:], k)
+
+			if _, ok := towerSessions[id]; ok {
+				backedByTower = true
+				return nil
+			}
+
+			session, err := getClientSessionBody(sessions, k)
+			if err != nil {
+				return err
+			}
+			if session.Status != CSessionInactive {
+				backedElsewhere = true
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if backedByTower && !backedElsewhere {
+			var chanID lnwire.ChannelID
+			copy(chanID[:], chanIDBytes)
+			orphaned = append(orphaned, chanID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return orphaned, nil
+}
+
+// RemoveTowerForce completely removes the tower with the given public key from
+// the database, along with all of its sessions and their committed and acked
+// updates. Unlike RemoveTower, the removal proceeds even if the tower has yet
+// to ack some of the updates, which means that the backups they hold are lost.
+// ErrTowerNotFound is returned if the tower does not exist.
+//
+// NOTE: This is a destructive operation that should only be used for towers
+// that are known to be permanently unavailable.
+func (c *ClientDB) RemoveTowerForce(pubKey *btcec.PublicKey) error {
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		towerIndex := tx.ReadBucket(cTowerIndexBkt)
+		if towerIndex == nil {
+			return ErrUninitializedDB
+		}
+
+		towersToSessionsIndex := tx.ReadBucket(cTowerToSessionIndexBkt)
+		if towersToSessionsIndex == nil {
+			return ErrUninitializedDB
+		}
+
+		sessions := tx.ReadWriteBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		pubKeyBytes := pubKey.SerializeCompressed()
+		towerIDBytes := towerIndex.Get(pubKeyBytes)
+		if towerIDBytes == nil {
+			return ErrTowerNotFound
+		}
+
+		towerSessions := towersToSessionsIndex.NestedReadBucket(
+			towerIDBytes,
+		)
+		if towerSessions == nil {
+			return ErrTowerNotFound
+		}
+
+		// Collect the tower's session IDs before deleting anything,
+		// since a bucket can't be modified while iterating over it.
+		var sessionIDs [][]byte
+		err := towerSessions.ForEach(func(k, _ []byte) error {
+			sessionID := append([]byte(nil), k...)
+			sessionIDs = append(sessionIDs, sessionID)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		chanSessions := tx.ReadWriteBucket(cChanSessionsBkt)
+		if chanSessions == nil {
+			return ErrUninitializedDB
+		}
+
+		// Remove each session from the channel-to-session index, then
+		// remove its bucket, which also removes its body and its
+		// commits and acks sub-buckets.
+		for _, sessionID := range sessionIDs {
+			sessionBkt := sessions.NestedReadBucket(sessionID)
+			if sessionBkt != nil {
+				err := deleteSessionChanIndex(
+					chanSessions, sessionBkt, sessionID,
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			err := sessions.DeleteNestedBucket(sessionID)
+			if err != nil && err != kvdb.ErrBucketNotFound {
+				return err
+			}
+		}
+
+		// With the sessions gone, the tower itself and its index
+		// entries can be removed.
+		return deleteTower(tx, pubKeyBytes, towerIDBytes)
+	}, func() {})
+}
+
+// deleteTower removes the tower with the given public key and ID from the
+// database, along with its entries in the tower indexes and any state recorded
+// for it. The caller is responsible for removing any of the tower's sessions.
+func deleteTower(tx kvdb.RwTx, pubKeyBytes, towerIDBytes []byte) error {
+	towers := tx.ReadWriteBucket(cTowerBkt)
+	if towers == nil {
+		return ErrUninitializedDB
+	}
+
+	towerIndex := tx.ReadWriteBucket(cTowerIndexBkt)
+	if towerIndex == nil {
+		return ErrUninitializedDB
+	}
+
+	towersToSessionsIndex := tx.ReadWriteBucket(cTowerToSessionIndexBkt)
+	if towersToSessionsIndex == nil {
+		return ErrUninitializedDB
+	}
+
+	if err := towerIndex.Delete(pubKeyBytes); err != nil {
+		return err
+	}
+
+	if err := towers.Delete(towerIDBytes); err != nil {
+		return err
+	}
+
+	sessionCounts := tx.ReadWriteBucket(cTowerSessionCountBkt)
+	if sessionCounts == nil {
+		return ErrUninitializedDB
+	}
+
+	if err := sessionCounts.Delete(towerIDBytes); err != nil {
+		return err
+	}
+
+	policies := tx.ReadWriteBucket(cTowerPolicyBkt)
+	if policies == nil {
+		return ErrUninitializedDB
+	}
+
+	if err := policies.Delete(towerIDBytes); err != nil {
+		return err
+	}
+
+	err := deleteTowerAddrUsed(tx, towerIDBytes, nil)
+	if err != nil {
+		return err
+	}
+
+	stats := tx.ReadWriteBucket(cTowerStatsBkt)
+	if stats == nil {
+		return ErrUninitializedDB
+	}
+
+	if err := stats.Delete(towerIDBytes); err != nil {
+		return err
+	}
+
+	backoff := tx.ReadWriteBucket(cTowerBackoffBkt)
+	if backoff == nil {
+		return ErrUninitializedDB
+	}
+
+	if err := backoff.Delete(towerIDBytes); err != nil {
+		return err
+	}
+
+	return towersToSessionsIndex.DeleteNestedBucket(towerIDBytes)
+}
+
+// RemoveTowerCheck reports which of the tower's sessions have unacked updates,
+// and would therefore cause RemoveTower to fail with ErrTowerUnackedUpdates,
+// without modifying the database. ErrTowerNotFound is returned if the tower
+// does not exist.
+func (c *ClientDB) RemoveTowerCheck(pubKey *btcec.PublicKey) (
+	*TowerRemovalReport, error) {
+
+	var report *TowerRemovalReport
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		towers := tx.ReadBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		towerIndex := tx.ReadBucket(cTowerIndexBkt)
+		if towerIndex == nil {
+			return ErrUninitializedDB
+		}
+
+		towersToSessionsIndex := tx.ReadBucket(cTowerToSessionIndexBkt)
+		if towersToSessionsIndex == nil {
+			return ErrUninitializedDB
+		}
+
+		sessions := tx.ReadBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		towerIDBytes := towerIndex.Get(pubKey.SerializeCompressed())
+		if towerIDBytes == nil {
+			return ErrTowerNotFound
+		}
+
+		report = &TowerRemovalReport{
+			TowerID:          TowerIDFromBytes(towerIDBytes),
+			BlockingSessions: make(map[SessionID]uint16),
+		}
+		perCommittedUpdate := func(s *ClientSession,
+			_ *CommittedUpdate) {
+
+			report.BlockingSessions[s.ID]++
+			report.NumUnackedUpdates++
+		}
+
+		_, err := listTowerSessions(
+			report.TowerID, sessions, towers, towersToSessionsIndex,
+			c.scripts, WithPerCommittedUpdate(perCommittedUpdate),
+		)
+
+		return err
+	}, func() {
+		report = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// MarkTowerInactive marks all of the tower's sessions as inactive so that no
+// further updates are sent to it, without removing the tower or any of its
+// addresses. ErrTowerNotFound is returned if the tower doesn't exist.
+func (c *ClientDB) MarkTowerInactive(pubKey *btcec.PublicKey) error {
+	return c.setTowerSessionsStatus(pubKey, CSessionInactive)
+}
+
+// MarkTowerActive marks all of the tower's sessions as active so that they can
+// be used for backups once again. ErrTowerNotFound is returned if the tower
+// doesn't exist.
+func (c *ClientDB) MarkTowerActive(pubKey *btcec.PublicKey) error {
+	return c.setTowerSessionsStatus(pubKey, CSessionActive)
+}
+
+// setTowerSessionsStatus sets the status of all sessions belonging to the tower
+// with the given public key.
+func (c *ClientDB) setTowerSessionsStatus(pubKey *btcec.PublicKey,
+	status CSessionStatus) error {
+
+	var events sessionEventLog
+	err := kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		towerIndex := tx.ReadBucket(cTowerIndexBkt)
+		if towerIndex == nil {
+			return ErrUninitializedDB
+		}
+
+		towerToSessionIndex := tx.ReadBucket(cTowerToSessionIndexBkt)
+		if towerToSessionIndex == nil {
+			return ErrUninitializedDB
+		}
+
+		sessions := tx.ReadWriteBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		towerIDBytes := towerIndex.Get(pubKey.SerializeCompressed())
+		if towerIDBytes == nil {
+			return ErrTowerNotFound
+		}
+
+		towerSessIndex := towerToSessionIndex.NestedReadBucket(
+			towerIDBytes,
+		)
+		if towerSessIndex == nil {
+			return ErrTowerNotFound
+		}
+
+		return towerSessIndex.ForEach(func(k, _ []byte) error {
+			session, err := getClientSessionBody(sessions, k)
+			if err != nil {
+				return err
+			}
+
+			return markSessionStatus(
+				sessions, session, status, &events,
+			)
+		})
+	}, func() {
+		events = nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.sessionEvents.Notify(events...)
+
+	return nil
+}
+
+// RemoveTowerAddressByIndex removes the address at the given index from the
+// tower with the given ID. The index refers to the order in which LoadTower and
+// LoadTowerByID return the tower's addresses, while the remaining addresses
+// keep their stored order. ErrTowerAddrIndexOutOfRange is returned if the index
+// doesn't refer to any of the tower's addresses, and ErrLastTowerAddr if the
+// address is the tower's only one.
+func (c *ClientDB) RemoveTowerAddressByIndex(id TowerID, idx int) error {
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		towers := tx.ReadWriteBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		addrUsed := tx.ReadBucket(cTowerAddrUsedBkt)
+		if addrUsed == nil {
+			return ErrUninitializedDB
+		}
+
+		tower, err := getTower(towers, id.Bytes())
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case idx < 0 || idx >= len(tower.Addresses):
+			return ErrTowerAddrIndexOutOfRange
+
+		// Towers should always have at least one address saved.
+		case len(tower.Addresses) == 1:
+			return ErrLastTowerAddr
+		}
+
+		// Resolve the index against a sorted copy of the addresses, so
+		// that the stored order isn't affected by the sort.
+		sorted := &Tower{
+			ID:        tower.ID,
+			Addresses: append([]net.Addr(nil), tower.Addresses...),
+		}
+		if err := sortTowerAddrs(addrUsed, sorted); err != nil {
+			return err
+		}
+
+		addr := sorted.Addresses[idx]
+		tower.RemoveAddress(addr)
+		tower.LastModified = c.now()
+
+		// Forget when the address was last used, if ever.
+		err = deleteTowerAddrUsed(tx, id.Bytes(), addr)
+		if err != nil {
+			return err
+		}
+
+		return putTower(towers, tower)
+	}, func() {})
+}
+
+// LoadTowerByID retrieves a tower by its tower ID.
+func (c *ClientDB) LoadTowerByID(towerID TowerID) (*Tower, error) {
+	var tower *Tower
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		towers := tx.ReadBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		addrUsed := tx.ReadBucket(cTowerAddrUsedBkt)
+		if addrUsed == nil {
+			return ErrUninitializedDB
+		}
+
+		stats := tx.ReadBucket(cTowerStatsBkt)
+		if stats == nil {
+			return ErrUninitializedDB
+		}
+
+		backoff := tx.ReadBucket(cTowerBackoffBkt)
+		if backoff == nil {
+			return ErrUninitializedDB
+		}
+
+		var err error
+		tower, err = getTower(towers, towerID.Bytes())
+		if err != nil {
+			return err
+		}
+
+		if err := getTowerStats(stats, tower); err != nil {
+			return err
+		}
+
+		if err := getTowerBackoff(backoff, tower); err != nil {
+			return err
+		}
+
+		return sortTowerAddrs(addrUsed, tower)
+	}, func() {
+		tower = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tower, nil
+}
+
+// LoadTower retrieves a tower by its public key.
+func (c *ClientDB) LoadTower(pubKey *btcec.PublicKey) (*Tower, error) {
+	var tower *Tower
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		towers := tx.ReadBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+		towerIndex := tx.ReadBucket(cTowerIndexBkt)
+		if towerIndex == nil {
+			return ErrUninitializedDB
+		}
+
+		towerIDBytes := towerIndex.Get(pubKey.SerializeCompressed())
+		if towerIDBytes == nil {
+			return ErrTowerNotFound
+		}
+
+		addrUsed := tx.ReadBucket(cTowerAddrUsedBkt)
+		if addrUsed == nil {
+			return ErrUninitializedDB
+		}
+
+		stats := tx.ReadBucket(cTowerStatsBkt)
+		if stats == nil {
+			return ErrUninitializedDB
+		}
+
+		backoff := tx.ReadBucket(cTowerBackoffBkt)
+		if backoff == nil {
+			return ErrUninitializedDB
+		}
+
+		var err error
+		tower, err = getTower(towers, towerIDBytes)
+		if err != nil {
+			return err
+		}
+
+		if err := getTowerStats(stats, tower); err != nil {
+			return err
+		}
+
+		if err := getTowerBackoff(backoff, tower); err != nil {
+			return err
+		}
+
+		return sortTowerAddrs(addrUsed, tower)
+	}, func() {
+		tower = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tower, nil
+}
+
+// ListTowers retrieves the list of towers available within the database,
+// ordered by ascending TowerID. The towers are sorted once read, so the order
+// doesn't depend on how the backend iterates over its keys. The
+// TowerListOptions can be used to further filter the set of towers returned.
+func (c *ClientDB) ListTowers(opts ...TowerListOption) ([]*Tower, error) {
+	cfg := NewTowerListCfg()
+	for _, o := range opts {
+		o(cfg)
+	}
+
+	var towers []*Tower
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		towerBucket := tx.ReadBucket(cTowerBkt)
+		if towerBucket == nil {
+			return ErrUninitializedDB
+		}
+
+		sessions := tx.ReadBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		towerToSessionIndex := tx.ReadBucket(cTowerToSessionIndexBkt)
+		if towerToSessionIndex == nil {
+			return ErrUninitializedDB
+		}
+
+		return towerBucket.ForEach(func(towerIDBytes, _ []byte) error {
+			if cfg.OnlyActiveTowers {
+				active, err := towerHasActiveSession(
+					sessions, towerToSessionIndex,
+					towerIDBytes,
+				)
+				if err != nil {
+					return err
+				}
+
+				if !active {
+					return nil
+				}
+			}
+
+			tower, err := getTower(towerBucket, towerIDBytes)
+			if err != nil {
+				return err
+			}
+			towers = append(towers, tower)
+			return nil
+		})
+	}, func() {
+		towers = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(towers, func(i, j int) bool {
+		return towers[i].ID < towers[j].ID
+	})
+
+	return towers, nil
+}
+
+// towerHasActiveSession returns true if any of the sessions associated with the
+// tower identified by the serialized tower ID has a CSessionActive status.
+func towerHasActiveSession(sessions, towerToSessionIndex kvdb.RBucket,
+	towerIDBytes []byte) (bool, error) {
+
+	towerSessIndex := towerToSessionIndex.NestedReadBucket(towerIDBytes)
+	if towerSessIndex == nil {
+		return false, ErrTowerNotFound
+	}
+
+	// errActiveSessionFound is used to exit the ForEach early once an
+	// active session has been found.
+	errActiveSessionFound := errors.New("active session found")
+
+	err := towerSessIndex.ForEach(func(k, _ []byte) error {
+		session, err := getClientSessionBody(sessions, k)
+		if err != nil {
+			return err
+		}
+
+		if session.Status == CSessionActive {
+			return errActiveSessionFound
+		}
+
+		return nil
+	})
+	switch {
+	case err == errActiveSessionFound:
+		return true, nil
+
+	case err != nil:
+		return false, err
+
+	default:
+		return false, nil
+	}
+}
+
+// NextSessionKeyIndex reserves a new session key derivation index for a
+// particular tower id. The index is reserved for that tower until
+// CreateClientSession is invoked for that tower and index, at which point a new
+// index for that tower can be reserved. Multiple calls to this method before
+// CreateClientSession is invoked should return the same index, including
+// concurrent calls, which are serialized per tower.
+func (c *ClientDB) NextSessionKeyIndex(towerID TowerID,
+	blobType blob.Type) (uint32, error) {
+
+	indexes, err := c.ReserveSessionKeyIndices(towerID, blobType, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	return indexes[0], nil
+}
+
+// HighestSessionKeyIndex returns the largest session key index that has been
+// finalized by creating a session with the given tower and blob type, or 0 if
+// no such session exists. Indexes that are merely reserved aren't considered.
+func (c *ClientDB) HighestSessionKeyIndex(towerID TowerID,
+	blobType blob.Type) (uint32, error) {
+
+	var highest uint32
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		sessions := tx.ReadBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		towerToSessionIndex := tx.ReadBucket(cTowerToSessionIndexBkt)
+		if towerToSessionIndex == nil {
+			return ErrUninitializedDB
+		}
+
+		towerSessions := towerToSessionIndex.NestedReadBucket(
+			towerID.Bytes(),
+		)
+		if towerSessions == nil {
+			return nil
+		}
+
+		return towerSessions.ForEach(func(k, _ []byte) error {
+			session, err := getClientSessionBody(sessions, k)
+			if err != nil {
+				return err
+			}
+
+			if session.Policy.BlobType == blobType &&
+				session.KeyIndex > highest {
+
+				highest = session.KeyIndex
+			}
+
+			return nil
+		})
+	}, func() {
+		highest = 0
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return highest, nil
+}
+
+// ReserveSessionKeyIndices reserves n session key derivation indexes for a
+// particular tower id and blob type in a single transaction. Any indexes that
+// are already reserved, and have not yet been consumed by CreateClientSession,
+// are returned first, so that multiple calls to this method return the same
+// indexes until sessions are created with them.
+func (c *ClientDB) ReserveSessionKeyIndices(towerID TowerID,
+	blobType blob.Type, n int) ([]uint32, error) {
+
+	if n <= 0 {
+		return nil, ErrInvalidKeyIndexCount
+	}
+
+	c.keyIndexMtx.Lock(uint64(towerID))
+	defer c.keyIndexMtx.Unlock(uint64(towerID))
+
+	var indexes []uint32
+	err := kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		var err error
+		indexes, err = reserveSessionKeyIndices(
+			tx, towerID, blobType, n,
+		)
+		return err
+	}, func() {
+		indexes = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return indexes, nil
+}
+
+// reserveSessionKeyIndices reserves n session key derivation indexes for the
+// given tower id and blob type, returning any already reserved indexes first.
+func reserveSessionKeyIndices(tx kvdb.RwTx, towerID TowerID,
+	blobType blob.Type, n int) ([]uint32, error) {
+
+	keyIndex := tx.ReadWriteBucket(cSessionKeyIndexBkt)
+	if keyIndex == nil {
+		return nil, ErrUninitializedDB
+	}
+
+	// Check the session key index to see if any keys have already been
+	// reserved for this tower. If there are enough of them, we'll return
+	// them directly.
+	indexes, err := getSessionKeyIndexes(keyIndex, towerID, blobType)
+	switch {
+	// No indexes have been reserved for this tower yet.
+	case err == ErrNoReservedKeyIndex:
+
+	case err != nil:
+		return nil, err
+
+	case len(indexes) >= n:
+		return indexes[:n], nil
+	}
+
+	// Otherwise, reuse any session key indexes released by this tower
+	// and blob type, lowest first, before generating new ones. Indexes
+	// released by other towers are never reused here, since their session
+	// keys may already have been revealed to those towers.
+	indexes, err = reuseReleasedKeyIndexes(
+		tx, towerID, blobType, indexes, n,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate as many new session key indexes as are needed to make up
+	// the requested number.
+	for len(indexes) < n {
+		// The error is ignored since NextSequence can't fail inside
+		// Update.
+		index64, _ := keyIndex.NextSequence()
+
+		// As a sanity check, assert that the index is still in the
+		// valid range of unhardened pubkeys. In the future, we should
+		// move to only using hardened keys, and this will prevent any
+		// overlap from occurring until then. This also prevents us from
+		// overflowing uint32s.
+		if index64 > math.MaxInt32 {
+			return nil, fmt.Errorf("exhausted session key indexes")
+		}
+
+		indexes = append(indexes, uint32(index64))
+	}
+
+	// Record the reserved session key indexes under this tower's id.
+	err = putSessionKeyIndexes(keyIndex, towerID, blobType, indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexes, nil
+}
+
+// reuseReleasedKeyIndexes appends to indexes the session key indexes that were
+// released by the given tower and blob type, lowest first, until n indexes are
+// held. The reused indexes are removed from the released set.
+func reuseReleasedKeyIndexes(tx kvdb.RwTx, towerID TowerID, blobType blob.Type,
+	indexes []uint32, n int) ([]uint32, error) {
+
+	released := tx.ReadWriteBucket(cReleasedKeyIndexBkt)
+	if released == nil {
+		return nil, ErrUninitializedDB
+	}
+
+	key := createSessionKeyIndexKey(towerID, blobType)
+	towerReleased := released.NestedReadWriteBucket(key)
+	if towerReleased == nil {
+		return indexes, nil
+	}
+
+	var reused [][]byte
+	err := towerReleased.ForEach(func(k, _ []byte) error {
+		if len(indexes)+len(reused) < n {
+			reused = append(reused, append([]byte(nil), k...))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, k := range reused {
+		if err := towerReleased.Delete(k); err != nil {
+			return nil, err
+		}
+
+		indexes = append(indexes, byteOrder.Uint32(k))
+	}
+
+	return indexes, nil
+}
+
+// ListReservedKeyIndices returns the session key indexes reserved for the given
+// tower and blob type that haven't yet been used to create a session, in the
+// order they were reserved.
+func (c *ClientDB) ListReservedKeyIndices(towerID TowerID,
+	blobType blob.Type) ([]uint32, error) {
+
+	var indexes []uint32
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		keyIndexes := tx.ReadBucket(cSessionKeyIndexBkt)
+		if keyIndexes == nil {
+			return ErrUninitializedDB
+		}
+
+		var err error
+		indexes, err = getSessionKeyIndexes(
+			keyIndexes, towerID, blobType,
+		)
+		if err == ErrNoReservedKeyIndex {
+			return nil
+		}
+
+		return err
+	}, func() {
+		indexes = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return indexes, nil
+}
+
+// ReleaseReservedKeyIndex clears the reservation of the given session key index
+// for the given tower and blob type, such that the index is handed out again by
+// a later reservation for the same tower and blob type. Released indexes are
+// never handed to other towers, since the index alone determines the session
+// key. ErrKeyIndexFinalized is returned if a session was already created with
+// the index, and ErrNoReservedKeyIndex if the index isn't otherwise reserved.
+//
+// NOTE: Releasing an index whose session key was already revealed to the tower
+// allows that tower to link the sessions later created with the index.
+func (c *ClientDB) ReleaseReservedKeyIndex(towerID TowerID,
+	blobType blob.Type, index uint32) error {
+
+	c.keyIndexMtx.Lock(uint64(towerID))
+	defer c.keyIndexMtx.Unlock(uint64(towerID))
+
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		keyIndexes := tx.ReadWriteBucket(cSessionKeyIndexBkt)
+		if keyIndexes == nil {
+			return ErrUninitializedDB
+		}
+
+		released := tx.ReadWriteBucket(cReleasedKeyIndexBkt)
+		if released == nil {
+			return ErrUninitializedDB
+		}
+
+		err := removeSessionKeyIndex(
+			keyIndexes, towerID, blobType, index,
+		)
+		switch {
+		case err == ErrNoReservedKeyIndex,
+			err == ErrIncorrectKeyIndex:
+
+			finalized, err := sessionUsesKeyIndex(
+				tx, towerID, blobType, index,
+			)
+			if err != nil {
+				return err
+			}
+			if finalized {
+				return ErrKeyIndexFinalized
+			}
+
+			return ErrNoReservedKeyIndex
+
+		case err != nil:
+			return err
+		}
+
+		towerReleased, err := released.CreateBucketIfNotExists(
+			createSessionKeyIndexKey(towerID, blobType),
+		)
+		if err != nil {
+			return err
+		}
+
+		var indexBytes [4]byte
+		byteOrder.PutUint32(indexBytes[:], index)
+
+		return towerReleased.Put(indexBytes[:], []byte{1})
+	}, func() {})
+}
+
+// sessionUsesKeyIndex returns true if a session created with the given tower
+// and blob type uses the given session key index.
+func sessionUsesKeyIndex(tx kvdb.RTx, towerID TowerID, blobType blob.Type,
+	index uint32) (bool, error) {
+
+	sessions := tx.ReadBucket(cSessionBkt)
+	if sessions == nil {
+		return false, ErrUninitializedDB
+	}
+
+	towerToSessionIndex := tx.ReadBucket(cTowerToSessionIndexBkt)
+	if towerToSessionIndex == nil {
+		return false, ErrUninitializedDB
+	}
+
+	towerSessions := towerToSessionIndex.NestedReadBucket(towerID.Bytes())
+	if towerSessions == nil {
+		return false, nil
+	}
+
+	var used bool
+	err := towerSessions.ForEach(func(k, _ []byte) error {
+		session, err := getClientSessionBody(sessions, k)
+		if err != nil {
+			return err
+		}
+
+		if session.Policy.BlobType == blobType &&
+			session.KeyIndex == index {
+
+			used = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return used, nil
+}
+
+// CreateClientSession records a newly negotiated client session in the set of
+// active sessions. The session can be identified by its SessionID. An
+// ErrInvalidPolicy is returned if the session's policy fails validation.
+func (c *ClientDB) CreateClientSession(session *ClientSession) error {
+	c.keyIndexMtx.Lock(uint64(session.TowerID))
+	defer c.keyIndexMtx.Unlock(uint64(session.TowerID))
+
+	var events sessionEventLog
+	err := kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		return createClientSession(
+			tx, session, c.scripts, c.now(), &events,
+		)
+	}, func() {
+		events = nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.sessionEvents.Notify(events...)
+
+	return nil
+}
+
+// CreateClientSessionAutoReserve records a newly negotiated client session just
+// like CreateClientSession, but doesn't require a session key index to have
+// been reserved beforehand. Unless the session's KeyIndex is one of the indexes
+// reserved for its tower and blob type, an index is reserved and the session's
+// KeyIndex is set to it. The reservation and the creation of the session
+// happen within a single transaction, and the key index used is returned.
+func (c *ClientDB) CreateClientSessionAutoReserve(session *ClientSession) (
+	uint32, error) {
+
+	c.keyIndexMtx.Lock(uint64(session.TowerID))
+	defer c.keyIndexMtx.Unlock(uint64(session.TowerID))
+
+	origKeyIndex := session.KeyIndex
+
+	var events sessionEventLog
+	err := kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		keyIndex, err := autoReserveKeyIndex(tx, session)
+		if err != nil {
+			return err
+		}
+		session.KeyIndex = keyIndex
+
+		return createClientSession(
+			tx, session, c.scripts, c.now(), &events,
+		)
+	}, func() {
+		session.KeyIndex = origKeyIndex
+		events = nil
+	})
+	if err != nil {
+		session.KeyIndex = origKeyIndex
+		return 0, err
+	}
+
+	c.sessionEvents.Notify(events...)
+
+	return session.KeyIndex, nil
+}
+
+// autoReserveKeyIndex returns the session's KeyIndex if it is reserved for the
+// session's tower and blob type. Otherwise, the first index reserved for them
+// is returned, reserving a new one if there is none.
+func autoReserveKeyIndex(tx kvdb.RwTx, session *ClientSession) (uint32,
+	error) {
+
+	keyIndexes := tx.ReadWriteBucket(cSessionKeyIndexBkt)
+	if keyIndexes == nil {
+		return 0, ErrUninitializedDB
+	}
+
+	towerID := session.TowerID
+	blobType := session.Policy.BlobType
+
+	indexes, err := getSessionKeyIndexes(keyIndexes, towerID, blobType)
+	switch {
+	case err == ErrNoReservedKeyIndex:
+
+	case err != nil:
+		return 0, err
+	}
+
+	for _, index := range indexes {
+		if index == session.KeyIndex {
+			return index, nil
+		}
+	}
+
+	indexes, err = reserveSessionKeyIndices(tx, towerID, blobType, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	return indexes[0], nil
+}
+
+// RotateSession atomically marks the session with the given ID as exhausted
+// and records the new session that replaces it. The new session must be
+// negotiated with the same tower as the old one, using a freshly reserved
+// session key index. Any of the errors returned by CreateClientSession may be
+// returned for the new session.
+func (c *ClientDB) RotateSession(oldID SessionID,
+	newSession *ClientSession) error {
+
+	c.keyIndexMtx.Lock(uint64(newSession.TowerID))
+	defer c.keyIndexMtx.Unlock(uint64(newSession.TowerID))
+
+	var events sessionEventLog
+	err := kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		sessions := tx.ReadWriteBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		oldSession, err := getClientSessionBody(sessions, oldID[:])
+		if err != nil {
+			return err
+		}
+
+		if oldSession.TowerID != newSession.TowerID {
+			return ErrRotateTowerMismatch
+		}
+
+		err = markSessionStatus(
+			sessions, oldSession, CSessionExhausted, &events,
+		)
+		if err != nil {
+			return err
+		}
+
+		return createClientSession(
+			tx, newSession, c.scripts, c.now(), &events,
+		)
+	}, func() {
+		events = nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.sessionEvents.Notify(events...)
+
+	return nil
+}
+
+// createClientSession validates the given session and records it in the set
+// of active sessions, consuming its reserved session key index. The session's
+// reward pkscripts are sealed using the given script cipher, its creation time
+// is set to now, and its creation is recorded in the given event log.
+func createClientSession(tx kvdb.RwTx, session *ClientSession,
+	scripts *scriptCipher, now time.Time, events *sessionEventLog) error {
+	if err := session.Policy.Validate(); err != nil {
+		return &ErrInvalidPolicy{Err: err}
+	}
+
+	// Reward sessions must specify where the tower's reward is paid,
+	// while altruist sessions may omit it.
+	if session.IsReward() && len(session.RewardPkScript) == 0 {
+		return ErrMissingRewardPkScript
+	}
+
+	keyIndexes := tx.ReadWriteBucket(cSessionKeyIndexBkt)
+	if keyIndexes == nil {
+		return ErrUninitializedDB
+	}
+
+	sessions := tx.ReadWriteBucket(cSessionBkt)
+	if sessions == nil {
+		return ErrUninitializedDB
+	}
+
+	towers := tx.ReadBucket(cTowerBkt)
+	if towers == nil {
+		return ErrUninitializedDB
+	}
+
+	towerToSessionIndex := tx.ReadWriteBucket(
+		cTowerToSessionIndexBkt,
+	)
+	if towerToSessionIndex == nil {
+		return ErrUninitializedDB
+	}
+
+	// Check that  client session with this session id doesn't
+	// already exist.
+	existingSessionBytes := sessions.NestedReadWriteBucket(
+		session.ID[:],
+	)
+	if existingSessionBytes != nil {
+		return ErrClientSessionAlreadyExists
+	}
+
+	// Ensure that a tower with the given ID actually exists in the
+	// DB.
+	towerID := session.TowerID
+	tower, err := getTower(towers, towerID.Bytes())
+	if err != nil {
+		return err
+	}
+
+	// If a policy has been negotiated with the tower, ensure that
+	// the session uses it.
+	policies := tx.ReadBucket(cTowerPolicyBkt)
+	if policies == nil {
+		return ErrUninitializedDB
+	}
+
+	towerPolicy, err := getTowerPolicy(policies, towerID)
+	switch {
+	case err == ErrTowerPolicyNotFound:
+
+	case err != nil:
+		return err
+
+	case towerPolicy != session.Policy:
+		return ErrTowerPolicyMismatch
+	}
+
+	// If the tower limits the number of sessions we may hold with it,
+	// ensure that the new session doesn't exceed that limit.
+	if tower.MaxSessions > 0 {
+		indexBkt := towerToSessionIndex.NestedReadBucket(
+			towerID.Bytes(),
+		)
+		if indexBkt == nil {
+			return ErrTowerNotFound
+		}
+
+		numSessions, err := countUnexhaustedSessions(sessions, indexBkt)
+		if err != nil {
+			return err
+		}
+
+		if numSessions >= tower.MaxSessions {
+			return ErrTowerSessionLimitReached
+		}
+	}
+
+	// Assert that the key index of the inserted session matches
+	// one of the reserved session key indexes, and remove it from
+	// the reservations.
+	err = removeSessionKeyIndex(
+		keyIndexes, towerID, session.Policy.BlobType,
+		session.KeyIndex,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Add the new entry to the towerID-to-SessionID index.
+	indexBkt := towerToSessionIndex.NestedReadWriteBucket(
+		towerID.Bytes(),
+	)
+	if indexBkt == nil {
+		return ErrTowerNotFound
+	}
+
+	err = indexBkt.Put(session.ID[:], []byte{1})
+	if err != nil {
+		return err
+	}
+
+	// Bump the tower's session count to account for the new
+	// session.
+	sessionCounts := tx.ReadWriteBucket(cTowerSessionCountBkt)
+	if sessionCounts == nil {
+		return ErrUninitializedDB
+	}
+
+	err = addTowerSessionCount(sessionCounts, towerID, 1)
+	if err != nil {
+		return err
+	}
+
+	// Record the session's creation time, which also counts as
+	// its last update.
+	session.CreatedAt = now
+	session.LastUpdated = session.CreatedAt
+
+	// Finally, write the client session's body in the sessions
+	// bucket.
+	sealed, err := scripts.sealSession(session)
+	if err != nil {
+		return err
+	}
+
+	err = putClientSessionBody(sessions, sealed)
+	if err != nil {
+		return err
+	}
+
+	events.addCreated(session)
+
+	return nil
+}
+
+// AddSessionRewardScript registers an additional reward pkscript for the
+// session with the given ID, which subsequently committed updates may use in
+// place of the session's RewardPkScript. Registering a pkscript that is
+// already known to the session is a no-op. ErrTooManyRewardScripts is returned
+// if the session would end up with more than MaxRewardScripts pkscripts.
+func (c *ClientDB) AddSessionRewardScript(id SessionID, pkScript []byte) error {
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		sessions := tx.ReadWriteBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		session, err := getClientSessionBody(sessions, id[:])
+		if err != nil {
+			return err
+		}
+
+		session, err = c.scripts.openSession(session)
+		if err != nil {
+			return err
+		}
+
+		added, err := session.AddRewardScript(pkScript)
+		if err != nil || !added {
+			return err
+		}
+
+		session, err = c.scripts.sealSession(session)
+		if err != nil {
+			return err
+		}
+
+		return putClientSessionBody(sessions, session)
+	}, func() {})
+}
+
+// UpdateSessionRewardScript replaces the RewardPkScript of the reward session
+// with the given ID, allowing the reward address to be rotated without
+// abandoning a partially used session. The rest of the session, including its
+// KeyIndex, MaxUpdates, additional reward pkscripts and its updates, is left
+// untouched. ErrSessionNotReward is returned for altruist sessions, and
+// ErrMissingRewardPkScript if newScript is empty.
+func (c *ClientDB) UpdateSessionRewardScript(id SessionID,
+	newScript []byte) error {
+
+	if len(newScript) == 0 {
+		return ErrMissingRewardPkScript
+	}
+
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		sessions := tx.ReadWriteBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		session, err := getClientSessionBody(sessions, id[:])
+		if err != nil {
+			return err
+		}
+
+		if !session.IsReward() {
+			return ErrSessionNotReward
+		}
+
+		session, err = c.scripts.openSession(session)
+		if err != nil {
+			return err
+		}
+
+		session.RewardPkScript = append([]byte(nil), newScript...)
+
+		session, err = c.scripts.sealSession(session)
+		if err != nil {
+			return err
+		}
+
+		return putClientSessionBody(sessions, session)
+	}, func() {})
+}
+
+// RotateEncryptionKey re-encrypts the sweep and reward pkscripts stored in the
+// database, opening them with oldKey and sealing them again with newKey. All
+// pkscripts are rotated within a single transaction, so the database is left
+// untouched if oldKey isn't the key the database is currently encrypted with,
+// in which case ErrEncryptionKeyMismatch is returned. A nil oldKey denotes a
+// database whose pkscripts are stored in the clear, and a nil newKey stores
+// them in the clear from then on. Afterwards, the database uses newKey, and
+// must be opened with newKey.
+//
+// NOTE: This method must not be called concurrently with any other method of
+// the database.
+func (c *ClientDB) RotateEncryptionKey(oldKey, newKey []byte) error {
+	var (
+		oldScripts, newScripts *scriptCipher
+		err                    error
+	)
+	if oldKey != nil {
+		oldScripts, err = newScriptCipher(oldKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	if newKey != nil {
+		newScripts, err = newScriptCipher(newKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		encrypted, err := checkEncryptionKey(tx, oldScripts)
+		switch {
+		case errors.Is(err, ErrEncryptionKeyRequired):
+			return ErrEncryptionKeyMismatch
+
+		case err != nil:
+			return err
+
+		case !encrypted && oldScripts != nil:
+			return ErrEncryptionKeyMismatch
+		}
+
+		err = rotateScripts(tx, oldScripts, newScripts)
+		if err != nil {
+			return err
+		}
+
+		return putEncryptionCheck(tx, newScripts)
+	}, func() {})
+	if err != nil {
+		return err
+	}
+
+	c.scripts = newScripts
+
+	return nil
+}
+
+// DeleteClientSession removes the client session with the given ID from the
+// database, along with all of its acked updates and its entry in the
+// tower-to-session index. The session's tower is left untouched. If the
+// session still has committed updates that have not been acked by the tower,
+// ErrSessionHasUnackedUpdates is returned.
+func (c *ClientDB) DeleteClientSession(id SessionID) error {
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		sessions := tx.ReadWriteBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		towerToSessionIndex := tx.ReadWriteBucket(
+			cTowerToSessionIndexBkt,
+		)
+		if towerToSessionIndex == nil {
+			return ErrUninitializedDB
+		}
+
+		session, err := getClientSessionBody(sessions, id[:])
+		if err != nil {
+			return err
+		}
+
+		// Can't fail if the above didn't fail.
+		sessionBkt := sessions.NestedReadBucket(id[:])
+
+		// Refuse to delete the session if it has any committed updates
+		// that the tower has not yet acked.
+		sessionCommits := sessionBkt.NestedReadBucket(cSessionCommits)
+		if sessionCommits != nil {
+			err := isBucketEmpty(sessionCommits)
+			switch {
+			case err == errBucketNotEmpty:
+				return ErrSessionHasUnackedUpdates
+
+			case err != nil:
+				return err
+			}
+		}
+
+		// Remove the session from the towerID-to-SessionID index.
+		indexBkt := towerToSessionIndex.NestedReadWriteBucket(
+			session.TowerID.Bytes(),
+		)
+		if indexBkt == nil {
+			return ErrTowerNotFound
+		}
+
+		err = indexBkt.Delete(id[:])
+		if err != nil {
+			return err
+		}
+
+		sessionCounts := tx.ReadWriteBucket(cTowerSessionCountBkt)
+		if sessionCounts == nil {
+			return ErrUninitializedDB
+		}
+
+		err = addTowerSessionCount(sessionCounts, session.TowerID, -1)
+		if err != nil {
+			return err
+		}
+
+		chanSessions := tx.ReadWriteBucket(cChanSessionsBkt)
+		if chanSessions == nil {
+			return ErrUninitializedDB
+		}
+
+		// Remove the session from the index entries of the channels
+		// that its acked updates belong to.
+		err = deleteSessionChanIndex(chanSessions, sessionBkt, id[:])
+		if err != nil {
+			return err
+		}
+
+		// Finally, remove the session's bucket, which also removes its
+		// body and its commits and acks sub-buckets.
+		return sessions.DeleteNestedBucket(id[:])
+	}, func() {})
+}
+
+// NumTowerSessions returns the number of sessions that have been created with
+// the tower identified by the given ID. ErrTowerNotFound is returned if the
+// tower doesn't exist.
+func (c *ClientDB) NumTowerSessions(id TowerID) (uint64, error) {
+	var count uint64
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		towers := tx.ReadBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		sessionCounts := tx.ReadBucket(cTowerSessionCountBkt)
+		if sessionCounts == nil {
+			return ErrUninitializedDB
+		}
+
+		if _, err := getTower(towers, id.Bytes()); err != nil {
+			return err
+		}
+
+		count = getTowerSessionCount(sessionCounts, id)
+
+		return nil
+	}, func() {
+		count = 0
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// getTowerSessionCount returns the number of sessions recorded for the given
+// tower in the tower-session-count index. A missing entry is treated as zero.
+func getTowerSessionCount(sessionCounts kvdb.RBucket, id TowerID) uint64 {
+	countBytes := sessionCounts.Get(id.Bytes())
+	if len(countBytes) != 8 {
+		return 0
+	}
+
+	return byteOrder.Uint64(countBytes)
+}
+
+// addTowerSessionCount adjusts the number of sessions recorded for the given
+// tower in the tower-session-count index by delta, flooring the result at
+// zero.
+func addTowerSessionCount(sessionCounts kvdb.RwBucket, id TowerID,
+	delta int64) error {
+
+	count := getTowerSessionCount(sessionCounts, id)
+	if delta < 0 && uint64(-delta) > count {
+		count = 0
+	} else {
+		count = uint64(int64(count) + delta)
+	}
+
+	var countBytes [8]byte
+	byteOrder.PutUint64(countBytes[:], count)
+
+	return sessionCounts.Put(id.Bytes(), countBytes[:])
+}
+
+// createSessionKeyIndexKey returns the identifier used in the
+// session-key-index index, created as tower-id||blob-type.
+//
+// NOTE: The original serialization only used tower-id, which prevents
+// concurrent client types from reserving sessions with the same tower. A later
+// serialization truncated the tower-id to its first four bytes, which made the
+// keys of all towers with an ID below 2^32 collide.
+func createSessionKeyIndexKey(towerID TowerID, blobType blob.Type) []byte {
+	towerIDBytes := towerID.Bytes()
+
+	// Session key indexes are stored under as tower-id||blob-type.
+	var keyBytes [10]byte
+	copy(keyBytes[:8], towerIDBytes)
+	byteOrder.PutUint16(keyBytes[8:], uint16(blobType))
+
+	return keyBytes[:]
+}
+
+// getSessionKeyIndexes returns the session key indexes reserved for the given
+// tower and blob type, in the order they were reserved.
+func getSessionKeyIndexes(keyIndexes kvdb.RBucket, towerID TowerID,
+	blobType blob.Type) ([]uint32, error) {
+
+	// Session key indexes are store under as tower-id||blob-type. The
+	// original serialization only used tower-id, which prevents concurrent
+	// client types from reserving sessions with the same tower.
+	keyBytes := createSessionKeyIndexKey(towerID, blobType)
+
+	// Retrieve the indexes using the key bytes. If the key wasn't found,
+	// we will fall back to the legacy format that only uses the tower id,
+	// but _only_ if the blob type is for altruist commit sessions since
+	// that was the only operational session type prior to changing the key
+	// format.
+	keyIndexBytes := keyIndexes.Get(keyBytes)
+	if keyIndexBytes == nil && blobType == blob.TypeAltruistCommit {
+		keyIndexBytes = keyIndexes.Get(towerID.Bytes())
+	}
+
+	// All session key indexes should be serialized as a list of uint32's.
+	// If no key index was found, the length of keyIndexBytes will be 0.
+	if len(keyIndexBytes) == 0 || len(keyIndexBytes)%4 != 0 {
+		return nil, ErrNoReservedKeyIndex
+	}
+
+	indexes := make([]uint32, 0, len(keyIndexBytes)/4)
+	for i := 0; i < len(keyIndexBytes); i += 4 {
+		indexes = append(
+			indexes, byteOrder.Uint32(keyIndexBytes[i:i+4]),
+		)
+	}
+
+	return indexes, nil
+}
+
+// removeSessionKeyIndex removes the given session key index from the indexes
+// reserved for the given tower and blob type. ErrNoReservedKeyIndex is returned
+// if no index is reserved for them, and ErrIncorrectKeyIndex if the given index
+// isn't one of the reserved indexes.
+func removeSessionKeyIndex(keyIndexes kvdb.RwBucket, towerID TowerID,
+	blobType blob.Type, index uint32) error {
+
+	indexes, err := getSessionKeyIndexes(keyIndexes, towerID, blobType)
+	if err != nil {
+		return err
+	}
+
+	remaining := make([]uint32, 0, len(indexes))
+	for _, reserved := range indexes {
+		if reserved != index {
+			remaining = append(remaining, reserved)
+		}
+	}
+	if len(remaining) == len(indexes) {
+		return ErrIncorrectKeyIndex
+	}
+
+	// Remove the key index reservation. For altruist commit sessions,
+	// we'll also purge under the old legacy key format. Any reservations
+	// that remain are rewritten under the new key format.
+	key := createSessionKeyIndexKey(towerID, blobType)
+	if len(remaining) == 0 {
+		err = keyIndexes.Delete(key)
+	} else {
+		err = putSessionKeyIndexes(
+			keyIndexes, towerID, blobType, remaining,
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	if blobType == blob.TypeAltruistCommit {
+		return keyIndexes.Delete(towerID.Bytes())
+	}
+
+	return nil
+}
+
+// putSessionKeyIndexes records the given session key indexes as reserved for
+// the given tower and blob type.
+func putSessionKeyIndexes(keyIndexes kvdb.RwBucket, towerID TowerID,
+	blobType blob.Type, indexes []uint32) error {
+
+	keyBytes := createSessionKeyIndexKey(towerID, blobType)
+
+	indexBytes := make([]byte, 4*len(indexes))
+	for i, index := range indexes {
+		byteOrder.PutUint32(indexBytes[4*i:], index)
+	}
+
+	return keyIndexes.Put(keyBytes, indexBytes)
+}
+
+// ListClientSessions returns the set of all client sessions known to the db. An
+// optional tower ID can be used to filter out any client sessions in the
+// response that do not correspond to this tower.
+func (c *ClientDB) ListClientSessions(id *TowerID,
+	opts ...ClientSessionListOption) (map[SessionID]*ClientSession, error) {
+
+	var clientSessions map[SessionID]*ClientSession
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		sessions := tx.ReadBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		towers := tx.ReadBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		var err error
+
+		// If no tower ID is specified, then fetch all the sessions
+		// known to the db.
+		if id == nil {
+			clientSessions, err = listClientAllSessions(
+				sessions, towers, c.scripts, opts...,
+			)
+			return err
+		}
+
+		// Otherwise, fetch the sessions for the given tower.
+		towerToSessionIndex := tx.ReadBucket(cTowerToSessionIndexBkt)
+		if towerToSessionIndex == nil {
+			return ErrUninitializedDB
+		}
+
+		clientSessions, err = listTowerSessions(
+			*id, sessions, towers, towerToSessionIndex, c.scripts,
+			opts...,
+		)
+		return err
+	}, func() {
+		clientSessions = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return clientSessions, nil
+}
+
+// ListClientSessionsGrouped returns the sessions known to the db grouped by
+// their status, listing active, inactive and exhausted sessions alike. An
+// optional tower ID can be used to only list the sessions of that tower.
+// Within each group, the sessions are ordered by their IDs.
+func (c *ClientDB) ListClientSessionsGrouped(id *TowerID) (
+	map[CSessionStatus][]*ClientSession, error) {
+
+	var grouped map[CSessionStatus][]*ClientSession
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		sessions := tx.ReadBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		towers := tx.ReadBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		src := &boltSessionSource{
+			sessions:   sessions,
+			towers:     towers,
+			sessionIDs: sessions,
+			scripts:    c.scripts,
+		}
+
+		// If a tower ID is specified, only iterate over the sessions
+		// of the given tower.
+		if id != nil {
+			towerToSessionIndex := tx.ReadBucket(
+				cTowerToSessionIndexBkt,
+			)
+			if towerToSessionIndex == nil {
+				return ErrUninitializedDB
+			}
+
+			src.sessionIDs = towerToSessionIndex.NestedReadBucket(
+				id.Bytes(),
+			)
+			if src.sessionIDs == nil {
+				return ErrTowerNotFound
+			}
+		}
+
+		var err error
+		grouped, err = ListClientSessionsGroupedFromSource(src)
+
+		return err
+	}, func() {
+		grouped = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return grouped, nil
+}
+
+// GetClientSession loads the ClientSession with the given ID from the DB. Any
+// ClientSessionListOptions provided are applied to the session's acked and
+// committed updates. ErrClientSessionNotFound is returned if the session does
+// not exist.
+func (c *ClientDB) GetClientSession(id SessionID,
+	opts ...ClientSessionListOption) (*ClientSession, error) {
+
+	var session *ClientSession
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		sessions := tx.ReadBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		towers := tx.ReadBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		var err error
+		session, err = GetClientSessionFromSource(&boltSessionSource{
+			sessions: sessions,
+			towers:   towers,
+			scripts:  c.scripts,
+		}, id, opts...)
+
+		return err
+	}, func() {
+		session = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// listClientAllSessions returns the set of all client sessions known to the db,
+// opening their reward pkscripts using the given script cipher.
+func listClientAllSessions(sessions, towers kvdb.RBucket,
+	scripts *scriptCipher, opts ...ClientSessionListOption) (
+	map[SessionID]*ClientSession, error) {
+
+	return ListClientSessionsFromSource(&boltSessionSource{
+		sessions:   sessions,
+		towers:     towers,
+		sessionIDs: sessions,
+		scripts:    scripts,
+	}, opts...)
+}
+
+// listTowerSessions returns the set of all client sessions known to the db
+// that are associated with the given tower id, opening their reward pkscripts
+// using the given script cipher.
+func listTowerSessions(id TowerID, sessionsBkt, towersBkt,
+	towerToSessionIndex kvdb.RBucket, scripts *scriptCipher,
+	opts ...ClientSessionListOption) (map[SessionID]*ClientSession, error) {
+
+	towerIndexBkt := towerToSessionIndex.NestedReadBucket(id.Bytes())
+	if towerIndexBkt == nil {
+		return nil, ErrTowerNotFound
+	}
+
+	return ListClientSessionsFromSource(&boltSessionSource{
+		sessions:   sessionsBkt,
+		towers:     towersBkt,
+		sessionIDs: towerIndexBkt,
+		scripts:    scripts,
+	}, opts...)
+}
+
+// FetchSessionCommittedUpdates retrieves the current set of un-acked updates
+// of the given session.
+func (c *ClientDB) FetchSessionCommittedUpdates(id *SessionID) (
+	[]CommittedUpdate, error) {
+
+	committedUpdates := make([]CommittedUpdate, 0)
+	collect := func(update *CommittedUpdate) error {
+		committedUpdates = append(committedUpdates, *update)
+		return nil
+	}
+
+	err := c.forEachSessionCommittedUpdate(id, collect, func() {
+		committedUpdates = make([]CommittedUpdate, 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return committedUpdates, nil
+}
+
+// ForEachSessionCommittedUpdate calls cb with each of the current un-acked
+// updates of the given session, in ascending order of sequence number. The
+// updates are decoded one at a time within a single read transaction, so that
+// the session's updates are never all held in memory at once. The iteration
+// stops as soon as cb returns an error, which is then returned.
+func (c *ClientDB) ForEachSessionCommittedUpdate(id *SessionID,
+	cb func(*CommittedUpdate) error) error {
+
+	return c.forEachSessionCommittedUpdate(id, cb, func() {})
+}
+
+// forEachSessionCommittedUpdate calls cb with each of the current un-acked
+// updates of the given session. The reset closure is called before the read
+// transaction is retried, if it needs to be.
+func (c *ClientDB) forEachSessionCommittedUpdate(id *SessionID,
+	cb func(*CommittedUpdate) error, reset func()) error {
+
+	return kvdb.View(c.db, func(tx kvdb.RTx) error {
+		sessions := tx.ReadBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		session, err := getClientSessionBody(sessions, id[:])
+		if err != nil {
+			return err
+		}
+
+		// Can't fail if the above didn't fail.
+		sessionBkt := sessions.NestedReadBucket(id[:])
+
+		sessionCommits := sessionBkt.NestedReadBucket(cSessionCommits)
+		if sessionCommits == nil {
+			return nil
+		}
+
+		return sessionCommits.ForEach(func(k, v []byte) error {
+			update, err := decodeCommittedUpdate(k, v, session)
+			if err != nil {
+				return err
+			}
+
+			return cb(&update)
+		})
+	}, reset)
+}
+
+// TotalPendingUpdates returns the number of committed updates that haven't yet
+// been acked, across all sessions, computed within a single read transaction.
+// Since updates are removed from a session's committed updates once acked, only
+// the sessions and their backlogs are visited, not their acked updates.
+func (c *ClientDB) TotalPendingUpdates() (uint64, error) {
+	var total uint64
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		sessions := tx.ReadBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		return sessions.ForEach(func(k, _ []byte) error {
+			sessionBkt := sessions.NestedReadBucket(k)
+			if sessionBkt == nil {
+				return ErrCorruptClientSession
+			}
+
+			committed, err := countBucketKeys(
+				sessionBkt.NestedReadBucket(cSessionCommits),
+			)
+			if err != nil {
+				return err
+			}
+			total += uint64(committed)
+
+			return nil
+		})
+	}, func() {
+		total = 0
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// SessionUpdateCounts returns the number of committed (un-acked) and acked
+// updates of the given session. The counts are computed from the session's
+// sub-buckets without decoding any of the updates. ErrClientSessionNotFound is
+// returned if the session does not exist.
+func (c *ClientDB) SessionUpdateCounts(id SessionID) (uint16, uint16, error) {
+	var committed, acked uint16
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		sessions := tx.ReadBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		sessionBkt := sessions.NestedReadBucket(id[:])
+		if sessionBkt == nil {
+			return ErrClientSessionNotFound
+		}
+
+		var err error
+		committed, err = countBucketKeys(
+			sessionBkt.NestedReadBucket(cSessionCommits),
+		)
+		if err != nil {
+			return err
+		}
+
+		acked, err = countBucketKeys(
+			sessionBkt.NestedReadBucket(cSessionAcks),
+		)
+
+		return err
+	}, func() {
+		committed, acked = 0, 0
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return committed, acked, nil
+}
+
+// NextSeqNum returns the sequence number that the next update committed to the
+// session with the given ID must use, which is 1 for a fresh session. If the
+// session is exhausted, the returned sequence number exceeds the session's
+// MaxUpdates. ErrClientSessionNotFound is returned if the session doesn't
+// exist.
+func (c *ClientDB) NextSeqNum(id SessionID) (uint16, error) {
+	var seqNum uint16
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		sessions := tx.ReadBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		session, err := getClientSessionBody(sessions, id[:])
+		if err != nil {
+			return err
+		}
+
+		seqNum = session.SeqNum + 1
+
+		return nil
+	}, func() {
+		seqNum = 0
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return seqNum, nil
+}
+
+// countBucketKeys returns the number of keys in the given bucket. A nil bucket
+// is treated as empty.
+func countBucketKeys(bucket kvdb.RBucket) (uint16, error) {
+	if bucket == nil {
+		return 0, nil
+	}
+
+	var count uint16
+	err := bucket.ForEach(func(_, _ []byte) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// FetchAckedUpdatesForChannel returns, for each session holding acked backups
+// of the given channel, the sequence numbers of those backups in ascending
+// order. Only the serialized channel ID prefix of each acked update is
+// inspected, so the updates are never fully decoded.
+func (c *ClientDB) FetchAckedUpdatesForChannel(chanID lnwire.ChannelID) (
+	map[SessionID][]uint16, error) {
+
+	var seqNums map[SessionID][]uint16
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		sessions := tx.ReadBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		return sessions.ForEach(func(k, _ []byte) error {
+			sessionBkt := sessions.NestedReadBucket(k)
+			if sessionBkt == nil {
+				return ErrCorruptClientSession
+			}
+
+			sessionAcks := sessionBkt.NestedReadBucket(cSessionAcks)
+			if sessionAcks == nil {
+				return nil
+			}
+
+			var id SessionID
+			copy(id[:], k)
+
+			// Each acked update is serialized as a BackupID, which
+			// is prefixed by the channel ID.
+			return sessionAcks.ForEach(func(seq, v []byte) error {
+				if !bytes.HasPrefix(v, chanID[:]) {
+					return nil
+				}
+
+				seqNums[id] = append(
+					seqNums[id], byteOrder.Uint16(seq),
+				)
+
+				return nil
+			})
+		})
+	}, func() {
+		seqNums = make(map[SessionID][]uint16)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return seqNums, nil
+}
+
+// DeleteChannelUpdates removes the acked updates of the given channel from all
+// sessions, returning the number of updates deleted. Updates of other channels
+// sharing a session with the channel are left untouched. This is intended to
+// be used once a channel has been permanently closed, and its backups can
+// therefore never be needed again.
+func (c *ClientDB) DeleteChannelUpdates(chanID lnwire.ChannelID) (int, error) {
+	var numDeleted int
+	err := kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		sessions := tx.ReadWriteBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		chanSessions := tx.ReadWriteBucket(cChanSessionsBkt)
+		if chanSessions == nil {
+			return ErrUninitializedDB
+		}
+
+		// Collect the IDs of all sessions first, since we can't mutate
+		// the sessions bucket while iterating over it.
+		var sessionIDs [][]byte
+		err := sessions.ForEach(func(k, _ []byte) error {
+			sessionIDs = append(sessionIDs, k)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, id := range sessionIDs {
+			sessionBkt := sessions.NestedReadWriteBucket(id)
+			if sessionBkt == nil {
+				return ErrCorruptClientSession
+			}
+
+			sessionAcks := sessionBkt.NestedReadWriteBucket(
+				cSessionAcks,
+			)
+			if sessionAcks == nil {
+				continue
+			}
+
+			// Each acked update is serialized as a BackupID, which
+			// is prefixed by the channel ID.
+			var seqNums [][]byte
+			err := sessionAcks.ForEach(func(seq, v []byte) error {
+				if bytes.HasPrefix(v, chanID[:]) {
+					seqNums = append(seqNums, seq)
+				}
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			// The tower still holds the deleted updates, so they
+			// keep counting towards the session's storage size,
+			// which is recorded before it could no longer be
+			// derived from the session's stored updates.
+			if len(seqNums) > 0 {
+				session, err := getClientSessionBody(
+					sessions, id,
+				)
+				if err != nil {
+					return err
+				}
+
+				err = adjustSessionStorageBytes(
+					sessionBkt, session.Policy.BlobType,
+					0, 0,
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, seq := range seqNums {
+				if err := sessionAcks.Delete(seq); err != nil {
+					return err
+				}
+			}
+
+			numDeleted += len(seqNums)
+
+			if len(seqNums) == 0 {
+				continue
+			}
+
+			err = pruneChanSession(
+				chanSessions, sessionBkt, chanID, id,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func() {
+		numDeleted = 0
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return numDeleted, nil
+}
+
+// SessionsForChannel returns the IDs of all sessions holding committed or
+// acked updates of the given channel, in ascending order. The sessions are
+// found using the channel-to-session index, avoiding a scan over all sessions.
+func (c *ClientDB) SessionsForChannel(chanID lnwire.ChannelID) ([]SessionID,
+	error) {
+
+	var sessionIDs []SessionID
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		chanSessions := tx.ReadBucket(cChanSessionsBkt)
+		if chanSessions == nil {
+			return ErrUninitializedDB
+		}
+
+		chanBkt := chanSessions.NestedReadBucket(chanID[:])
+		if chanBkt == nil {
+			return nil
+		}
+
+		return chanBkt.ForEach(func(k, _ []byte) error {
+			var id SessionID
+			if len(k) != len(id) {
+				return ErrCorruptClientSession
+			}
+
+			copy(id[:], k)
+			sessionIDs = append(sessionIDs, id)
+
+			return nil
+		})
+	}, func() {
+		sessionIDs = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sessionIDs, nil
+}
+
+// ChannelsWithoutBackups returns the IDs of all registered channels for which
+// no session holds a committed or acked update, in ascending order. These are
+// the channels whose states aren't protected by any tower.
+func (c *ClientDB) ChannelsWithoutBackups() ([]lnwire.ChannelID, error) {
+	var chanIDs []lnwire.ChannelID
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		chanSummaries := tx.ReadBucket(cChanSummaryBkt)
+		if chanSummaries == nil {
+			return ErrUninitializedDB
+		}
+
+		chanSessions := tx.ReadBucket(cChanSessionsBkt)
+		if chanSessions == nil {
+			return ErrUninitializedDB
+		}
+
+		// The channel-to-session index entry of a channel is removed
+		// once no session references it anymore, so any registered
+		// channel without an entry has no backups.
+		return chanSummaries.ForEach(func(k, _ []byte) error {
+			if chanSessions.NestedReadBucket(k) != nil {
+				return nil
+			}
+
+			var chanID lnwire.ChannelID
+			copy(chanID[:], k)
+			chanIDs = append(chanIDs, chanID)
+
+			return nil
+		})
+	}, func() {
+		chanIDs = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return chanIDs, nil
+}
+
+// addChanSession records in the channel-to-session index that the session with
+// the given ID holds an update of the given channel.
+func addChanSession(chanSessions kvdb.RwBucket, chanID lnwire.ChannelID,
+	id []byte) error {
+
+	chanBkt, err := chanSessions.CreateBucketIfNotExists(chanID[:])
+	if err != nil {
+		return err
+	}
+
+	return chanBkt.Put(id, []byte{1})
+}
+
+// deleteChanSession removes the session with the given ID from the
+// channel-to-session index entry of the given channel, removing the entry
+// altogether once it no longer references any sessions.
+func deleteChanSession(chanSessions kvdb.RwBucket, chanID, id []byte) error {
+	chanBkt := chanSessions.NestedReadWriteBucket(chanID)
+	if chanBkt == nil {
+		return nil
+	}
+
+	if err := chanBkt.Delete(id); err != nil {
+		return err
+	}
+
+	err := isBucketEmpty(chanBkt)
+	switch {
+	case err == errBucketNotEmpty:
+		return nil
+
+	case err != nil:
+		return err
+	}
+
+	return chanSessions.DeleteNestedBucket(chanID)
+}
+
+// pruneChanSession removes the session with the given ID from the
+// channel-to-session index entry of the given channel, unless the session
+// still holds a committed or acked update of the channel.
+func pruneChanSession(chanSessions kvdb.RwBucket, sessionBkt kvdb.RBucket,
+	chanID lnwire.ChannelID, id []byte) error {
+
+	chanIDs, err := sessionChanIDs(sessionBkt)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := chanIDs[chanID]; ok {
+		return nil
+	}
+
+	return deleteChanSession(chanSessions, chanID[:], id)
+}
+
+// deleteSessionChanIndex removes the session with the given ID from the
+// channel-to-session index entries of all channels it holds updates of. This
+// must be called before the session's bucket is deleted.
+func deleteSessionChanIndex(chanSessions kvdb.RwBucket,
+	sessionBkt kvdb.RBucket, id []byte) error {
+
+	chanIDs, err := sessionChanIDs(sessionBkt)
+	if err != nil {
+		return err
+	}
+
+	for chanID := range chanIDs {
+		err := deleteChanSession(chanSessions, chanID[:], id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sessionChanIDs returns the set of channels that the committed and acked
+// updates held in the given session bucket belong to. Both are serialized with
+// the channel ID as their prefix.
+func sessionChanIDs(sessionBkt kvdb.RBucket) (map[lnwire.ChannelID]struct{},
+	error) {
+
+	chanIDs := make(map[lnwire.ChannelID]struct{})
+	for _, subBkt := range [][]byte{cSessionCommits, cSessionAcks} {
+		updates := sessionBkt.NestedReadBucket(subBkt)
+		if updates == nil {
+			continue
+		}
+
+		err := updates.ForEach(func(_, v []byte) error {
+			var chanID lnwire.ChannelID
+			if len(v) < len(chanID) {
+				return ErrCorruptClientSession
+			}
+
+			copy(chanID[:], v)
+			chanIDs[chanID] = struct{}{}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return chanIDs, nil
+}
+
+// ClientDBStats summarizes the contents of the client database.
+type ClientDBStats struct {
+	// NumTowers is the number of towers in the database.
+	NumTowers uint64
+
+	// NumSessions is the number of client sessions in the database.
+	NumSessions uint64
+
+	// NumCommittedUpdates is the number of committed updates that have
+	// not yet been acked, across all sessions.
+	NumCommittedUpdates uint64
+
+	// NumAckedUpdates is the number of acked updates across all sessions.
+	NumAckedUpdates uint64
+
+	// NumChannels is the number of registered channels.
+	NumChannels uint64
+
+	// AckedUpdatesBytes is the approximate number of bytes occupied by the
+	// acked updates of all sessions, counting their keys and values.
+	AckedUpdatesBytes uint64
+}
+
+// Ping checks that the database can be read by opening a read transaction and
+// confirming that each of its top-level buckets exists. No data is scanned, so
+// the check is cheap enough to be used as a health check. ErrUninitializedDB
+// is returned if any of the buckets is missing.
+func (c *ClientDB) Ping() error {
+	return kvdb.View(c.db, func(tx kvdb.RTx) error {
+		for _, bucket := range clientDBBuckets {
+			if tx.ReadBucket(bucket) == nil {
+				return ErrUninitializedDB
+			}
+		}
+
+		return nil
+	}, func() {})
+}
+
+// DBStats returns a summary of the contents of the client database, computed
+// within a single read transaction.
+func (c *ClientDB) DBStats() (ClientDBStats, error) {
+	var stats ClientDBStats
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		towers := tx.ReadBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		sessions := tx.ReadBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		chanSummaries := tx.ReadBucket(cChanSummaryBkt)
+		if chanSummaries == nil {
+			return ErrUninitializedDB
+		}
+
+		err := towers.ForEach(func(_, _ []byte) error {
+			stats.NumTowers++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = chanSummaries.ForEach(func(_, _ []byte) error {
+			stats.NumChannels++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return sessions.ForEach(func(k, _ []byte) error {
+			sessionBkt := sessions.NestedReadBucket(k)
+			if sessionBkt == nil {
+				return ErrCorruptClientSession
+			}
+			stats.NumSessions++
+
+			commits := sessionBkt.NestedReadBucket(cSessionCommits)
+			if commits != nil {
+				err := commits.ForEach(func(_, _ []byte) error {
+					stats.NumCommittedUpdates++
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			acks := sessionBkt.NestedReadBucket(cSessionAcks)
+			if acks == nil {
+				return nil
+			}
+
+			return acks.ForEach(func(k, v []byte) error {
+				size := uint64(len(k) + len(v))
+				stats.NumAckedUpdates++
+				stats.AckedUpdatesBytes += size
+
+				return nil
+			})
+		})
+	}, func() {
+		stats = ClientDBStats{}
+	})
+	if err != nil {
+		return ClientDBStats{}, err
+	}
+
+	return stats, nil
+}
+
+// CheckClientDB scans the database for orphaned entries in the
+// channel-to-session and tower-to-session indexes, sessions whose tower
+// doesn't exist, and committed updates with sequence numbers the session
+// never allocated. The database is not modified, the findings are returned as
+// an IntegrityReport that can be passed to RepairClientDB.
+func (c *ClientDB) CheckClientDB() (*IntegrityReport, error) {
+	var report *IntegrityReport
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		var err error
+		report, err = checkClientDB(tx)
+
+		return err
+	}, func() {
+		report = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// checkClientDB scans the database for inconsistencies within the passed
+// transaction.
+func checkClientDB(tx kvdb.RTx) (*IntegrityReport, error) {
+	towers := tx.ReadBucket(cTowerBkt)
+	if towers == nil {
+		return nil, ErrUninitializedDB
+	}
+
+	sessions := tx.ReadBucket(cSessionBkt)
+	if sessions == nil {
+		return nil, ErrUninitializedDB
+	}
+
+	towerToSessionIndex := tx.ReadBucket(cTowerToSessionIndexBkt)
+	if towerToSessionIndex == nil {
+		return nil, ErrUninitializedDB
+	}
+
+	chanSessions := tx.ReadBucket(cChanSessionsBkt)
+	if chanSessions == nil {
+		return nil, ErrUninitializedDB
+	}
+
+	report := NewIntegrityReport()
+
+	// First, inspect every session for a missing tower and for committed
+	// updates under sequence numbers it never allocated.
+	err := sessions.ForEach(func(k, _ []byte) error {
+		session, err := getClientSessionBody(sessions, k)
+		if err != nil {
+			return err
+		}
+
+		if towers.Get(session.TowerID.Bytes()) == nil {
+			report.SessionsMissingTower = append(
+				report.SessionsMissingTower, session.ID,
+			)
+		}
+
+		// Can't fail if the above didn't fail.
+		sessionBkt := sessions.NestedReadBucket(k)
+
+		sessionCommits := sessionBkt.NestedReadBucket(cSessionCommits)
+		if sessionCommits == nil {
+			return nil
+		}
+
+		return sessionCommits.ForEach(func(k, _ []byte) error {
+			seqNum := byteOrder.Uint16(k)
+			if session.IsValidCommitSeqNum(seqNum) {
+				return nil
+			}
+
+			report.InvalidCommittedUpdates[session.ID] = append(
+				report.InvalidCommittedUpdates[session.ID],
+				seqNum,
+			)
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Next, look for tower-to-session index entries of sessions that
+	// don't exist.
+	err = towerToSessionIndex.ForEach(func(towerIDBytes, _ []byte) error {
+		indexBkt := towerToSessionIndex.NestedReadBucket(towerIDBytes)
+		if indexBkt == nil {
+			return nil
+		}
+
+		towerID := TowerIDFromBytes(towerIDBytes)
+
+		return indexBkt.ForEach(func(k, _ []byte) error {
+			if sessions.NestedReadBucket(k) != nil {
+				return nil
+			}
+
+			var id SessionID
+			copy(id[:], k)
+
+			report.OrphanedTowerSessions[towerID] = append(
+				report.OrphanedTowerSessions[towerID], id,
+			)
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Finally, look for channel-to-session index entries of sessions that
+	// don't hold any update of the channel.
+	err = chanSessions.ForEach(func(chanIDBytes, _ []byte) error {
+		chanBkt := chanSessions.NestedReadBucket(chanIDBytes)
+		if chanBkt == nil {
+			return nil
+		}
+
+		var chanID lnwire.ChannelID
+		copy(chanID[:], chanIDBytes)
+
+		return chanBkt.ForEach(func(k, _ []byte) error {
+			orphaned, err := isOrphanedChanSession(
+				sessions, chanID, k,
+			)
+			if err != nil {
+				return err
+			}
+			if !orphaned {
+				return nil
+			}
+
+			var id SessionID
+			copy(id[:], k)
+
+			report.OrphanedChanSessions[chanID] = append(
+				report.OrphanedChanSessions[chanID], id,
+			)
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// isOrphanedChanSession returns true if the session with the given serialized
+// ID either doesn't exist or doesn't hold any committed or acked update of the
+// given channel.
+func isOrphanedChanSession(sessions kvdb.RBucket, chanID lnwire.ChannelID,
+	id []byte) (bool, error) {
+
+	sessionBkt := sessions.NestedReadBucket(id)
+	if sessionBkt == nil {
+		return true, nil
+	}
+
+	chanIDs, err := sessionChanIDs(sessionBkt)
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := chanIDs[chanID]
+
+	return !ok, nil
+}
+
+// RepairClientDB removes the inconsistencies listed in the given report, as
+// produced by CheckClientDB, within a single transaction. Orphaned index
+// entries and invalid committed updates are removed, and sessions whose tower
+// doesn't exist are deleted along with their updates. Each finding is checked
+// again before it is repaired, so that state which changed since the report
+// was produced is left untouched.
+func (c *ClientDB) RepairClientDB(report *IntegrityReport) error {
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		return repairClientDB(tx, report)
+	}, func() {})
+}
+
+// repairClientDB removes the inconsistencies listed in the given report within
+// the passed transaction.
+func repairClientDB(tx kvdb.RwTx, report *IntegrityReport) error {
+	towers := tx.ReadBucket(cTowerBkt)
+	if towers == nil {
+		return ErrUninitializedDB
+	}
+
+	sessions := tx.ReadWriteBucket(cSessionBkt)
+	if sessions == nil {
+		return ErrUninitializedDB
+	}
+
+	towerToSessionIndex := tx.ReadWriteBucket(cTowerToSessionIndexBkt)
+	if towerToSessionIndex == nil {
+		return ErrUninitializedDB
+	}
+
+	chanSessions := tx.ReadWriteBucket(cChanSessionsBkt)
+	if chanSessions == nil {
+		return ErrUninitializedDB
+	}
+
+	for id, seqNums := range report.InvalidCommittedUpdates {
+		id := id
+		err := deleteInvalidCommits(
+			sessions, chanSessions, &id, seqNums,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, id := range report.SessionsMissingTower {
+		session, err := getClientSessionBody(sessions, id[:])
+		switch {
+		case err == ErrClientSessionNotFound:
+			continue
+
+		case err != nil:
+			return err
+
+		case towers.Get(session.TowerID.Bytes()) != nil:
+			continue
+		}
+
+		// Can't fail if the above didn't fail.
+		sessionBkt := sessions.NestedReadBucket(id[:])
+
+		err = deleteSessionChanIndex(chanSessions, sessionBkt, id[:])
+		if err != nil {
+			return err
+		}
+
+		err = sessions.DeleteNestedBucket(id[:])
+		if err != nil {
+			return err
+		}
+	}
+
+	for towerID, ids := range report.OrphanedTowerSessions {
+		indexBkt := towerToSessionIndex.NestedReadWriteBucket(
+			towerID.Bytes(),
+		)
+		if indexBkt == nil {
+			continue
+		}
+
+		for _, id := range ids {
+			if sessions.NestedReadBucket(id[:]) != nil {
+				continue
+			}
+
+			if err := indexBkt.Delete(id[:]); err != nil {
+				return err
+			}
+		}
+	}
+
+	for chanID, ids := range report.OrphanedChanSessions {
+		for _, id := range ids {
+			orphaned, err := isOrphanedChanSession(
+				sessions, chanID, id[:],
+			)
+			if err != nil {
+				return err
+			}
+			if !orphaned {
+				continue
+			}
+
+			err = deleteChanSession(chanSessions, chanID[:], id[:])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// deleteInvalidCommits removes the committed updates of the session with the
+// given ID that are held under the given sequence numbers, if the session
+// never allocated them.
+func deleteInvalidCommits(sessions, chanSessions kvdb.RwBucket,
+	id *SessionID, seqNums []uint16) error {
+
+	session, err := getClientSessionBody(sessions, id[:])
+	switch {
+	case err == ErrClientSessionNotFound:
+		return nil
+
+	case err != nil:
+		return err
+	}
+
+	// Can't fail if the above didn't fail.
+	sessionBkt := sessions.NestedReadWriteBucket(id[:])
+
+	sessionCommits := sessionBkt.NestedReadWriteBucket(cSessionCommits)
+	if sessionCommits == nil {
+		return nil
+	}
+
+	for _, seqNum := range seqNums {
+		if session.IsValidCommitSeqNum(seqNum) {
+			continue
+		}
+
+		var seqNumBuf [2]byte
+		byteOrder.PutUint16(seqNumBuf[:], seqNum)
+
+		updateBytes := sessionCommits.Get(seqNumBuf[:])
+		if updateBytes == nil {
+			continue
+		}
+
+		var update CommittedUpdate
+		err := update.Decode(bytes.NewReader(updateBytes))
+		if err != nil {
+			return err
+		}
+
+		err = adjustSessionStorageBytes(
+			sessionBkt, session.Policy.BlobType, 0,
+			uint64(len(update.EncryptedBlob)),
+		)
+		if err != nil {
+			return err
+		}
+
+		err = sessionCommits.Delete(seqNumBuf[:])
+		if err != nil {
+			return err
+		}
+
+		err = pruneChanSession(
+			chanSessions, sessionBkt, update.BackupID.ChanID, id[:],
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExportClientState writes the towers, sessions along with their committed
+// and acked updates, and channel summaries of the database to the passed
+// io.Writer as a versioned client state archive. The state is read within a
+// single transaction, and can be imported into any ClientDB backend using
+// ImportClientState.
+func (c *ClientDB) ExportClientState(w io.Writer) error {
+	var state *ClientState
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		towers := tx.ReadBucket(cTowerBkt)
+		if towers == nil {
+			return ErrUninitializedDB
+		}
+
+		sessions := tx.ReadBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		chanSummaries := tx.ReadBucket(cChanSummaryBkt)
+		if chanSummaries == nil {
+			return ErrUninitializedDB
+		}
+
+		err := towers.ForEach(func(k, _ []byte) error {
+			tower, err := getTower(towers, k)
+			if err != nil {
+				return err
+			}
+
+			state.Towers = append(state.Towers, tower)
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = sessions.ForEach(func(k, _ []byte) error {
+			session, err := exportClientSession(
+				sessions, k, c.scripts,
+			)
+			if err != nil {
+				return err
+			}
+
+			state.Sessions = append(state.Sessions, session)
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return chanSummaries.ForEach(func(k, v []byte) error {
+			var chanID lnwire.ChannelID
+			copy(chanID[:], k)
+
+			summary, err := decodeChanSummary(v, chanID, c.scripts)
+			if err != nil {
+				return err
+			}
+
+			state.ChanSummaries[chanID] = *summary
+
+			return nil
+		})
+	}, func() {
+		state = &ClientState{
+			ChanSummaries: make(ChannelSummaries),
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return WriteClientState(w, state)
+}
+
+// exportClientSession loads the session with the given serialized ID, along
+// with its committed and acked updates, opening its reward pkscripts using the
+// given script cipher.
+func exportClientSession(sessions kvdb.RBucket, idBytes []byte,
+	scripts *scriptCipher) (*ClientSessionState, error) {
+
+	session, err := getClientSessionBody(sessions, idBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err = scripts.openSession(session)
+	if err != nil {
+		return nil, err
+	}
+
+	// Can't fail if the above didn't fail.
+	sessionBkt := sessions.NestedReadBucket(idBytes)
+
+	committedUpdates, err := getClientSessionCommits(
+		sessionBkt, session, nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &ClientSessionState{
+		Session:          session,
+		CommittedUpdates: committedUpdates,
+		AckedUpdates:     make(map[uint16]BackupID),
+	}
+
+	sessionAcks := sessionBkt.NestedReadBucket(cSessionAcks)
+	if sessionAcks == nil {
+		return state, nil
+	}
+
+	err = sessionAcks.ForEach(func(k, v []byte) error {
+		var backupID BackupID
+		err := backupID.Decode(bytes.NewReader(v))
+		if err != nil {
+			return err
+		}
+
+		state.AckedUpdates[byteOrder.Uint16(k)] = backupID
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// ImportClientState reads a client state archive written by ExportClientState
+// from the passed io.Reader and adds its towers, sessions and channel
+// summaries to the database. The archive is imported within a single
+// transaction, so either all of it is imported or none of it is.
+//
+// ErrClientDBNotEmpty is returned if the database already holds towers,
+// sessions or channels, unless the WithMerge option is used. Imported towers
+// are assigned new IDs by the database, and session key indexes that were
+// used by imported sessions won't be reserved again.
+func (c *ClientDB) ImportClientState(r io.Reader,
+	opts ...ClientStateImportOption) error {
+
+	cfg := NewClientStateImportCfg()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	state, err := ReadClientState(r)
+	if err != nil {
+		return err
+	}
+
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		return importClientState(
+			tx, state, cfg.Merge, c.scripts, c.now(),
+		)
+	}, func() {})
+}
+
+// importClientState adds the towers, sessions and channel summaries of the
+// given client state to the database within the passed transaction. Existing
+// towers that are merged with imported ones are marked as modified at now.
+func importClientState(tx kvdb.RwTx, state *ClientState, merge bool,
+	scripts *scriptCipher, now time.Time) error {
+
+	towers := tx.ReadWriteBucket(cTowerBkt)
+	if towers == nil {
+		return ErrUninitializedDB
+	}
+
+	sessions := tx.ReadWriteBucket(cSessionBkt)
+	if sessions == nil {
+		return ErrUninitializedDB
+	}
+
+	chanSummaries := tx.ReadWriteBucket(cChanSummaryBkt)
+	if chanSummaries == nil {
+		return ErrUninitializedDB
+	}
+
+	keyIndexes := tx.ReadWriteBucket(cSessionKeyIndexBkt)
+	if keyIndexes == nil {
+		return ErrUninitializedDB
+	}
+
+	// Unless asked to merge, refuse to import into a database that
+	// already holds any state.
+	if !merge {
+		for _, bkt := range []kvdb.RBucket{
+			towers, sessions, chanSummaries,
+		} {
+			switch err := isBucketEmpty(bkt); {
+			case err == errBucketNotEmpty:
+				return ErrClientDBNotEmpty
+
+			case err != nil:
+				return err
+			}
+		}
+	}
+
+	// Import the towers first, remembering the IDs that the database
+	// assigned to them so that the sessions can be linked to them.
+	towerIDs := make(map[TowerID]TowerID, len(state.Towers))
+	for _, tower := range state.Towers {
+		towerID, err := importTower(tx, tower, now)
+		if err != nil {
+			return err
+		}
+
+		towerIDs[tower.ID] = towerID
+	}
+
+	var maxKeyIndex uint32
+	for _, session := range state.Sessions {
+		towerID, ok := towerIDs[session.Session.TowerID]
+		if !ok {
+			return ErrTowerNotFound
+		}
+
+		err := importClientSession(tx, session, towerID, scripts)
+		if err != nil {
+			return err
+		}
+
+		if session.Session.KeyIndex > maxKeyIndex {
+			maxKeyIndex = session.Session.KeyIndex
+		}
+	}
+
+	// Advance the session key index sequence past the indexes used by the
+	// imported sessions, so that they won't be reserved again.
+	if uint64(maxKeyIndex) > keyIndexes.Sequence() {
+		err := keyIndexes.SetSequence(uint64(maxKeyIndex))
+		if err != nil {
+			return err
+		}
+	}
+
+	for chanID, summary := range state.ChanSummaries {
+		existing, err := getChanSummary(chanSummaries, chanID, scripts)
+		switch {
+		case err == ErrChannelNotRegistered:
+
+		case err != nil:
+			return err
+
+		// A channel that is already registered with the same sweep
+		// pkscripts doesn't need to be imported.
+		case bytes.Equal(
+			existing.SweepPkScript, summary.SweepPkScript,
+		) && (len(summary.SweepPkScripts) == 0 ||
+			existing.HasSweepPkScripts(summary.SweepPkScripts)):
+
+			continue
+
+		default:
+			return ErrChannelAlreadyRegistered
+		}
+
+		summary := summary
+		err = putChanSummary(chanSummaries, chanID, &summary, scripts)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importTower adds the given tower to the database, returning the ID assigned
+// to it. If a tower with the same identity key already exists, the addresses
+// of the given tower are added to it instead, marking the existing tower as
+// modified at the given time.
+func importTower(tx kvdb.RwTx, tower *Tower, now time.Time) (TowerID, error) {
+	towerIndex := tx.ReadWriteBucket(cTowerIndexBkt)
+	if towerIndex == nil {
+		return 0, ErrUninitializedDB
+	}
+
+	towers := tx.ReadWriteBucket(cTowerBkt)
+	if towers == nil {
+		return 0, ErrUninitializedDB
+	}
+
+	towerToSessionIndex := tx.ReadWriteBucket(cTowerToSessionIndexBkt)
+	if towerToSessionIndex == nil {
+		return 0, ErrUninitializedDB
+	}
+
+	towerPubKey := tower.IdentityKey.SerializeCompressed()
+
+	// If the tower is already known, merge the imported addresses into
+	// the existing record.
+	towerIDBytes := towerIndex.Get(towerPubKey)
+	if len(towerIDBytes) == 8 {
+		existing, err := getTower(towers, towerIDBytes)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, addr := range tower.Addresses {
+			existing.AddAddress(addr)
+		}
+		existing.LastModified = now
+
+		if existing.Nickname == "" {
+			existing.Nickname = tower.Nickname
+		}
+		if existing.RateLimit == 0 {
+			existing.RateLimit = tower.RateLimit
+		}
+
+		return existing.ID, putTower(towers, existing)
+	}
+
+	// Otherwise, allocate a new tower id for the imported tower. The error
+	// is unhandled since NextSequence never fails in an Update.
+	towerID, _ := towerIndex.NextSequence()
+
+	imported := *tower
+	imported.ID = TowerID(towerID)
+	towerIDBytes = imported.ID.Bytes()
+
+	err := towerIndex.Put(towerPubKey, towerIDBytes)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = towerToSessionIndex.CreateBucket(towerIDBytes)
+	if err != nil {
+		return 0, err
+	}
+
+	return imported.ID, putTower(towers, &imported)
+}
+
+// importClientSession adds the given session, along with its committed and
+// acked updates, to the database as a session with the given tower. The
+// session's reward pkscripts are sealed using the given script cipher.
+func importClientSession(tx kvdb.RwTx, state *ClientSessionState,
+	towerID TowerID, scripts *scriptCipher) error {
+
+	sessions := tx.ReadWriteBucket(cSessionBkt)
+	if sessions == nil {
+		return ErrUninitializedDB
+	}
+
+	towerToSessionIndex := tx.ReadWriteBucket(cTowerToSessionIndexBkt)
+	if towerToSessionIndex == nil {
+		return ErrUninitializedDB
+	}
+
+	sessionCounts := tx.ReadWriteBucket(cTowerSessionCountBkt)
+	if sessionCounts == nil {
+		return ErrUninitializedDB
+	}
+
+	chanSessions := tx.ReadWriteBucket(cChanSessionsBkt)
+	if chanSessions == nil {
+		return ErrUninitializedDB
+	}
+
+	id := state.Session.ID
+	if sessions.NestedReadBucket(id[:]) != nil {
+		return ErrClientSessionAlreadyExists
+	}
+
+	session := *state.Session
+	session.TowerID = towerID
+
+	sealed, err := scripts.sealSession(&session)
+	if err != nil {
+		return err
+	}
+
+	err = putClientSessionBody(sessions, sealed)
+	if err != nil {
+		return err
+	}
+
+	// Can't fail since the session bucket was just created.
+	sessionBkt := sessions.NestedReadWriteBucket(id[:])
+
+	if len(state.CommittedUpdates) > 0 {
+		sessionCommits, err := sessionBkt.CreateBucket(cSessionCommits)
+		if err != nil {
+			return err
+		}
 
-			// If there are any client sessions that correspond to
-			// this tower, we'll mark them as active to ensure we
-			// load them upon restarts.
-			towerSessIndex := towerToSessionIndex.NestedReadBucket(
-				tower.ID.Bytes(),
-			)
-			if towerSessIndex == nil {
-				return ErrTowerNotFound
-			}
+		for _, update := range state.CommittedUpdates {
+			var seqNumBuf [2]byte
+			byteOrder.PutUint16(seqNumBuf[:], update.SeqNum)
 
-			sessions := tx.ReadWriteBucket(cSessionBkt)
-			if sessions == nil {
-				return ErrUninitializedDB
+			var b bytes.Buffer
+			if err := update.Encode(&b); err != nil {
+				return err
 			}
 
-			err = towerSessIndex.ForEach(func(k, _ []byte) error {
-				session, err := getClientSessionBody(
-					sessions, k,
-				)
-				if err != nil {
-					return err
-				}
+			err := sessionCommits.Put(seqNumBuf[:], b.Bytes())
+			if err != nil {
+				return err
+			}
 
-				return markSessionStatus(
-					sessions, session, CSessionActive,
-				)
-			})
+			err = addChanSession(
+				chanSessions, update.BackupID.ChanID, id[:],
+			)
 			if err != nil {
 				return err
 			}
-		} else {
-			// No such tower exists, create a new tower id for our
-			// new tower. The error is unhandled since NextSequence
-			// never fails in an Update.
-			towerID, _ := towerIndex.NextSequence()
+		}
+	}
+
+	if len(state.AckedUpdates) > 0 {
+		sessionAcks, err := sessionBkt.CreateBucket(cSessionAcks)
+		if err != nil {
+			return err
+		}
 
-			tower = &Tower{
-				ID:          TowerID(towerID),
-				IdentityKey: lnAddr.IdentityKey,
-				Addresses:   []net.Addr{lnAddr.Address},
+		for seqNum, backupID := range state.AckedUpdates {
+			var seqNumBuf [2]byte
+			byteOrder.PutUint16(seqNumBuf[:], seqNum)
+
+			var b bytes.Buffer
+			if err := backupID.Encode(&b); err != nil {
+				return err
 			}
 
-			towerIDBytes = tower.ID.Bytes()
+			err := sessionAcks.Put(seqNumBuf[:], b.Bytes())
+			if err != nil {
+				return err
+			}
 
-			// Since this tower is new, record the mapping from
-			// tower pubkey to tower id in the tower index.
-			err := towerIndex.Put(towerPubKey[:], towerIDBytes)
+			err = bumpLastAckedSeqNum(sessionBkt, seqNum)
 			if err != nil {
 				return err
 			}
 
-			// Create a new bucket for this tower in the
-			// tower-to-sessions index.
-			_, err = towerToSessionIndex.CreateBucket(towerIDBytes)
+			err = addChanSession(
+				chanSessions, backupID.ChanID, id[:],
+			)
 			if err != nil {
 				return err
 			}
 		}
+	}
 
-		// Store the new or updated tower under its tower id.
-		return putTower(towers, tower)
-	}, func() {
-		tower = nil
-	})
+	// Record the storage size of the imported updates, whose acked blobs
+	// aren't archived and are therefore assumed to be of the size dictated
+	// by the session's blob type.
+	err = adjustSessionStorageBytes(
+		sessionBkt, session.Policy.BlobType, 0, 0,
+	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return tower, nil
-}
+	// Finally, link the session to its tower.
+	indexBkt := towerToSessionIndex.NestedReadWriteBucket(towerID.Bytes())
+	if indexBkt == nil {
+		return ErrTowerNotFound
+	}
 
-// RemoveTower modifies a tower's record within the database. If an address is
-// provided, then _only_ the address record should be removed from the tower's
-// persisted state. Otherwise, we'll attempt to mark the tower as inactive by
-// marking all of its sessions inactive. If any of its sessions has unacked
-// updates, then ErrTowerUnackedUpdates is returned. If the tower doesn't have
-// any sessions at all, it'll be completely removed from the database.
-//
-// NOTE: An error is not returned if the tower doesn't exist.
-func (c *ClientDB) RemoveTower(pubKey *btcec.PublicKey, addr net.Addr) error {
-	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
-		towers := tx.ReadWriteBucket(cTowerBkt)
-		if towers == nil {
-			return ErrUninitializedDB
-		}
+	err = indexBkt.Put(id[:], []byte{1})
+	if err != nil {
+		return err
+	}
 
-		towerIndex := tx.ReadWriteBucket(cTowerIndexBkt)
-		if towerIndex == nil {
-			return ErrUninitializedDB
-		}
+	return addTowerSessionCount(sessionCounts, towerID, 1)
+}
 
-		towersToSessionsIndex := tx.ReadWriteBucket(
-			cTowerToSessionIndexBkt,
-		)
-		if towersToSessionsIndex == nil {
+// FetchChanSummaries loads a mapping from all registered channels to their
+// channel summaries. Channels that were soft-deleted are excluded, unless the
+// WithTombstoned option is given. If a tombstoned channel has since been
+// registered again, its registered summary is returned.
+func (c *ClientDB) FetchChanSummaries(
+	opts ...ChanSummaryFetchOption) (ChannelSummaries, error) {
+
+	cfg := NewChanSummaryFetchCfg()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var summaries map[lnwire.ChannelID]ClientChanSummary
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		chanSummaries := tx.ReadBucket(cChanSummaryBkt)
+		if chanSummaries == nil {
 			return ErrUninitializedDB
 		}
 
-		// Don't return an error if the watchtower doesn't exist to act
-		// as a NOP.
-		pubKeyBytes := pubKey.SerializeCompressed()
-		towerIDBytes := towerIndex.Get(pubKeyBytes)
-		if towerIDBytes == nil {
-			return nil
-		}
+		err := chanSummaries.ForEach(func(k, v []byte) error {
+			var chanID lnwire.ChannelID
+			copy(chanID[:], k)
 
-		// If an address is provided, then we should _only_ remove the
-		// address record from the database.
-		if addr != nil {
-			tower, err := getTower(towers, towerIDBytes)
+			summary, err := decodeChanSummary(v, chanID, c.scripts)
 			if err != nil {
 				return err
 			}
 
-			// Towers should always have at least one address saved.
-			tower.RemoveAddress(addr)
-			if len(tower.Addresses) == 0 {
-				return ErrLastTowerAddr
-			}
-
-			return putTower(towers, tower)
-		}
+			summaries[chanID] = *summary
 
-		// Otherwise, we should attempt to mark the tower's sessions as
-		// inactive.
-		sessions := tx.ReadWriteBucket(cSessionBkt)
-		if sessions == nil {
-			return ErrUninitializedDB
+			return nil
+		})
+		if err != nil {
+			return err
 		}
-		towerID := TowerIDFromBytes(towerIDBytes)
-
-		committedUpdateCount := make(map[SessionID]uint16)
-		perCommittedUpdate := func(s *ClientSession,
-			_ *CommittedUpdate) {
 
-			committedUpdateCount[s.ID]++
+		if !cfg.IncludeTombstoned {
+			return nil
 		}
 
-		towerSessions, err := listTowerSessions(
-			towerID, sessions, towers, towersToSessionsIndex,
-			WithPerCommittedUpdate(perCommittedUpdate),
-		)
-		if err != nil {
-			return err
+		tombstones := tx.ReadBucket(cChanTombstoneBkt)
+		if tombstones == nil {
+			return ErrUninitializedDB
 		}
 
-		// If it doesn't have any, we can completely remove it from the
-		// database.
-		if len(towerSessions) == 0 {
-			if err := towerIndex.Delete(pubKeyBytes); err != nil {
-				return err
-			}
+		return tombstones.ForEach(func(k, v []byte) error {
+			var chanID lnwire.ChannelID
+			copy(chanID[:], k)
 
-			if err := towers.Delete(towerIDBytes); err != nil {
-				return err
+			if _, ok := summaries[chanID]; ok {
+				return nil
 			}
 
-			return towersToSessionsIndex.DeleteNestedBucket(
-				towerIDBytes,
-			)
-		}
-
-		// We'll mark its sessions as inactive as long as they don't
-		// have any pending updates to ensure we don't load them upon
-		// restarts.
-		for _, session := range towerSessions {
-			if committedUpdateCount[session.ID] > 0 {
-				return ErrTowerUnackedUpdates
-			}
-			err := markSessionStatus(
-				sessions, session, CSessionInactive,
+			summary, err := decodeChanTombstone(
+				v, chanID, c.scripts,
 			)
 			if err != nil {
 				return err
 			}
-		}
-
-		return nil
-	}, func() {})
-}
 
-// LoadTowerByID retrieves a tower by its tower ID.
-func (c *ClientDB) LoadTowerByID(towerID TowerID) (*Tower, error) {
-	var tower *Tower
-	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
-		towers := tx.ReadBucket(cTowerBkt)
-		if towers == nil {
-			return ErrUninitializedDB
-		}
+			summaries[chanID] = *summary
 
-		var err error
-		tower, err = getTower(towers, towerID.Bytes())
-		return err
+			return nil
+		})
 	}, func() {
-		tower = nil
+		summaries = make(map[lnwire.ChannelID]ClientChanSummary)
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return tower, nil
+	return summaries, nil
 }
 
-// LoadTower retrieves a tower by its public key.
-func (c *ClientDB) LoadTower(pubKey *btcec.PublicKey) (*Tower, error) {
-	var tower *Tower
+// FetchChanSummariesForChannels loads the channel summaries of the given
+// channels using a keyed read for each of them, rather than loading the
+// summaries of all registered channels. Channels that aren't registered are
+// omitted from the result.
+func (c *ClientDB) FetchChanSummariesForChannels(
+	chanIDs []lnwire.ChannelID) (ChannelSummaries, error) {
+
+	var summaries ChannelSummaries
 	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
-		towers := tx.ReadBucket(cTowerBkt)
-		if towers == nil {
-			return ErrUninitializedDB
-		}
-		towerIndex := tx.ReadBucket(cTowerIndexBkt)
-		if towerIndex == nil {
+		chanSummaries := tx.ReadBucket(cChanSummaryBkt)
+		if chanSummaries == nil {
 			return ErrUninitializedDB
 		}
 
-		towerIDBytes := towerIndex.Get(pubKey.SerializeCompressed())
-		if towerIDBytes == nil {
-			return ErrTowerNotFound
+		for _, chanID := range chanIDs {
+			summary, err := getChanSummary(
+				chanSummaries, chanID, c.scripts,
+			)
+			switch {
+			case err == ErrChannelNotRegistered:
+				continue
+
+			case err != nil:
+				return err
+			}
+
+			summaries[chanID] = *summary
 		}
 
-		var err error
-		tower, err = getTower(towers, towerIDBytes)
-		return err
+		return nil
 	}, func() {
-		tower = nil
+		summaries = make(ChannelSummaries)
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return tower, nil
+	return summaries, nil
 }
 
-// ListTowers retrieves the list of towers available within the database.
-func (c *ClientDB) ListTowers() ([]*Tower, error) {
-	var towers []*Tower
-	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
-		towerBucket := tx.ReadBucket(cTowerBkt)
-		if towerBucket == nil {
+// RegisterChannel registers a channel for use within the client database. For
+// now, all that is stored in the channel summary is the sweep pkscript that
+// we'd like any tower sweeps to pay into. In the future, this will be extended
+// to contain more info to allow the client efficiently request historical
+// states to be backed up under the client's active policy. Re-registering a
+// channel with the same sweep pkscript is a no-op, while registering it with a
+// different one fails with ErrChannelAlreadyRegistered.
+func (c *ClientDB) RegisterChannel(chanID lnwire.ChannelID,
+	sweepPkScript []byte) error {
+
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		chanSummaries := tx.ReadWriteBucket(cChanSummaryBkt)
+		if chanSummaries == nil {
 			return ErrUninitializedDB
 		}
 
-		return towerBucket.ForEach(func(towerIDBytes, _ []byte) error {
-			tower, err := getTower(towerBucket, towerIDBytes)
-			if err != nil {
+		return registerChannel(
+			chanSummaries, chanID, sweepPkScript, c.scripts,
+		)
+	}, func() {})
+}
+
+// RegisterChannels registers a batch of channels, mapped to their sweep
+// pkscripts, within a single database transaction. Each channel is registered
+// as if by RegisterChannel, and the returned map holds an entry for every
+// channel that couldn't be registered, such as ErrChannelAlreadyRegistered.
+// The remaining channels are registered regardless. A non-nil error indicates
+// that the transaction itself failed, in which case none of the channels are
+// registered.
+func (c *ClientDB) RegisterChannels(
+	sweepPkScripts map[lnwire.ChannelID][]byte) (map[lnwire.ChannelID]error,
+	error) {
+
+	var chanErrs map[lnwire.ChannelID]error
+	err := kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		chanSummaries := tx.ReadWriteBucket(cChanSummaryBkt)
+		if chanSummaries == nil {
+			return ErrUninitializedDB
+		}
+
+		for chanID, sweepPkScript := range sweepPkScripts {
+			err := registerChannel(
+				chanSummaries, chanID, sweepPkScript,
+				c.scripts,
+			)
+			switch {
+			case err == ErrChannelAlreadyRegistered:
+				chanErrs[chanID] = err
+
+			case err != nil:
 				return err
 			}
-			towers = append(towers, tower)
-			return nil
-		})
+		}
+
+		return nil
 	}, func() {
-		towers = nil
+		chanErrs = make(map[lnwire.ChannelID]error)
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return towers, nil
+	return chanErrs, nil
 }
 
-// NextSessionKeyIndex reserves a new session key derivation index for a
-// particular tower id. The index is reserved for that tower until
-// CreateClientSession is invoked for that tower and index, at which point a new
-// index for that tower can be reserved. Multiple calls to this method before
-// CreateClientSession is invoked should return the same index.
-func (c *ClientDB) NextSessionKeyIndex(towerID TowerID,
-	blobType blob.Type) (uint32, error) {
+// RegisterChannelMulti registers a channel for use within the client database,
+// with distinct sweep pkscripts for different kinds of outputs. The scripts map
+// output kinds to their pkscript, and must hold the pkscript used for all other
+// kinds of outputs under DefaultSweepScriptKind, which becomes the channel's
+// SweepPkScript. Re-registering a channel with the same pkscripts is a no-op,
+// while registering it with different ones fails with
+// ErrChannelAlreadyRegistered. ErrInvalidSweepPkScripts is returned if the
+// scripts are invalid, as described by NewMultiScriptChanSummary.
+func (c *ClientDB) RegisterChannelMulti(chanID lnwire.ChannelID,
+	scripts map[string][]byte) error {
+
+	summary, err := NewMultiScriptChanSummary(scripts)
+	if err != nil {
+		return err
+	}
 
-	var index uint32
-	err := kvdb.Update(c.db, func(tx kvdb.RwTx) error {
-		keyIndex := tx.ReadWriteBucket(cSessionKeyIndexBkt)
-		if keyIndex == nil {
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		chanSummaries := tx.ReadWriteBucket(cChanSummaryBkt)
+		if chanSummaries == nil {
 			return ErrUninitializedDB
 		}
 
-		// Check the session key index to see if a key has already been
-		// reserved for this tower. If so, we'll deserialize and return
-		// the index directly.
-		var err error
-		index, err = getSessionKeyIndex(keyIndex, towerID, blobType)
-		if err == nil {
-			return nil
-		}
+		return registerChanSummary(
+			chanSummaries, chanID, summary, c.scripts,
+		)
+	}, func() {})
+}
 
-		// Otherwise, generate a new session key index since the node
-		// doesn't already have reserved index. The error is ignored
-		// since NextSequence can't fail inside Update.
-		index64, _ := keyIndex.NextSequence()
+// registerChannel registers a channel within the given channel summaries
+// bucket, sealing its sweep pkscript using the given script cipher.
+// Re-registering a channel with the same sweep pkscript is a no-op, while
+// registering it with a different one fails with ErrChannelAlreadyRegistered.
+// Any additional sweep pkscripts the channel was registered with are ignored
+// when comparing its pkscript.
+func registerChannel(chanSummaries kvdb.RwBucket, chanID lnwire.ChannelID,
+	sweepPkScript []byte, scripts *scriptCipher) error {
+
+	return registerChanSummary(chanSummaries, chanID, &ClientChanSummary{
+		SweepPkScript: sweepPkScript,
+		Priority:      DefaultChannelPriority,
+	}, scripts)
+}
 
-		// As a sanity check, assert that the index is still in the
-		// valid range of unhardened pubkeys. In the future, we should
-		// move to only using hardened keys, and this will prevent any
-		// overlap from occurring until then. This also prevents us from
-		// overflowing uint32s.
-		if index64 > math.MaxInt32 {
-			return fmt.Errorf("exhausted session key indexes")
-		}
+// registerChanSummary registers a channel within the given channel summaries
+// bucket, storing the given summary with its sweep pkscripts sealed using the
+// given script cipher. If the channel is already registered with the same
+// SweepPkScript, and the summary's SweepPkScripts are either absent or equal
+// to the registered ones, this is a no-op. Otherwise, registering the channel
+// again fails with ErrChannelAlreadyRegistered.
+func registerChanSummary(chanSummaries kvdb.RwBucket, chanID lnwire.ChannelID,
+	summary *ClientChanSummary, scripts *scriptCipher) error {
 
-		// Create the key that will used to be store the reserved index.
-		keyBytes := createSessionKeyIndexKey(towerID, blobType)
+	existing, err := getChanSummary(chanSummaries, chanID, scripts)
+	switch {
 
-		index = uint32(index64)
+	// Summary already exists with the same pkscripts, nothing to do.
+	case err == nil && bytes.Equal(
+		existing.SweepPkScript, summary.SweepPkScript,
+	) && (len(summary.SweepPkScripts) == 0 ||
+		existing.HasSweepPkScripts(summary.SweepPkScripts)):
 
-		var indexBuf [4]byte
-		byteOrder.PutUint32(indexBuf[:], index)
+		return nil
 
-		// Record the reserved session key index under this tower's id.
-		return keyIndex.Put(keyBytes, indexBuf[:])
-	}, func() {
-		index = 0
-	})
-	if err != nil {
-		return 0, err
+	// Summary already exists with a different pkscript.
+	case err == nil:
+		return ErrChannelAlreadyRegistered
+
+	// Channel is not registered, proceed with registration.
+	case err == ErrChannelNotRegistered:
+
+	// Unexpected error.
+	default:
+		return err
 	}
 
-	return index, nil
+	return putChanSummary(chanSummaries, chanID, summary, scripts)
 }
 
-// CreateClientSession records a newly negotiated client session in the set of
-// active sessions. The session can be identified by its SessionID.
-func (c *ClientDB) CreateClientSession(session *ClientSession) error {
+// SetChannelPriority sets the backup priority of a registered channel.
+// ErrChannelNotRegistered is returned if the channel was never registered.
+func (c *ClientDB) SetChannelPriority(chanID lnwire.ChannelID,
+	priority uint8) error {
+
 	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
-		keyIndexes := tx.ReadWriteBucket(cSessionKeyIndexBkt)
-		if keyIndexes == nil {
+		chanSummaries := tx.ReadWriteBucket(cChanSummaryBkt)
+		if chanSummaries == nil {
 			return ErrUninitializedDB
 		}
 
-		sessions := tx.ReadWriteBucket(cSessionBkt)
-		if sessions == nil {
-			return ErrUninitializedDB
+		summary, err := getChanSummary(
+			chanSummaries, chanID, c.scripts,
+		)
+		if err != nil {
+			return err
 		}
 
-		towers := tx.ReadBucket(cTowerBkt)
-		if towers == nil {
-			return ErrUninitializedDB
+		if summary.Priority == priority {
+			return nil
 		}
+		summary.Priority = priority
 
-		towerToSessionIndex := tx.ReadWriteBucket(
-			cTowerToSessionIndexBkt,
+		return putChanSummary(
+			chanSummaries, chanID, summary, c.scripts,
 		)
-		if towerToSessionIndex == nil {
+	}, func() {})
+}
+
+// UnregisterChannel removes the channel summary for the given channel from the
+// client database. ErrChannelNotRegistered is returned if the channel was never
+// registered. Any backup IDs referencing the channel in existing sessions are
+// left untouched, as they are historical. If the WithSoftDelete option is
+// given, the summary is kept in a tombstone recording the time of deletion,
+// until it is removed by PurgeTombstones.
+func (c *ClientDB) UnregisterChannel(chanID lnwire.ChannelID,
+	opts ...UnregisterChannelOption) error {
+
+	cfg := NewUnregisterChannelCfg()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		chanSummaries := tx.ReadWriteBucket(cChanSummaryBkt)
+		if chanSummaries == nil {
 			return ErrUninitializedDB
 		}
 
-		// Check that  client session with this session id doesn't
-		// already exist.
-		existingSessionBytes := sessions.NestedReadWriteBucket(
-			session.ID[:],
-		)
-		if existingSessionBytes != nil {
-			return ErrClientSessionAlreadyExists
+		if chanSummaries.Get(chanID[:]) == nil {
+			return ErrChannelNotRegistered
 		}
 
-		// Ensure that a tower with the given ID actually exists in the
-		// DB.
-		towerID := session.TowerID
-		if _, err := getTower(towers, towerID.Bytes()); err != nil {
-			return err
-		}
+		if cfg.SoftDelete {
+			tombstones := tx.ReadWriteBucket(cChanTombstoneBkt)
+			if tombstones == nil {
+				return ErrUninitializedDB
+			}
 
-		blobType := session.Policy.BlobType
+			summary, err := getChanSummary(
+				chanSummaries, chanID, c.scripts,
+			)
+			if err != nil {
+				return err
+			}
 
-		// Check that this tower has a reserved key index.
-		index, err := getSessionKeyIndex(keyIndexes, towerID, blobType)
-		if err != nil {
-			return err
+			summary.DeletedAt = c.now()
+			err = putChanTombstone(
+				tombstones, chanID, summary, c.scripts,
+			)
+			if err != nil {
+				return err
+			}
 		}
 
-		// Assert that the key index of the inserted session matches the
-		// reserved session key index.
-		if index != session.KeyIndex {
-			return ErrIncorrectKeyIndex
+		return chanSummaries.Delete(chanID[:])
+	}, func() {})
+}
+
+// PurgeTombstones permanently removes the tombstones of the channels that were
+// soft-deleted before the given time.
+func (c *ClientDB) PurgeTombstones(before time.Time) error {
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		tombstones := tx.ReadWriteBucket(cChanTombstoneBkt)
+		if tombstones == nil {
+			return ErrUninitializedDB
 		}
 
-		// Remove the key index reservation. For altruist commit
-		// sessions, we'll also purge under the old legacy key format.
-		key := createSessionKeyIndexKey(towerID, blobType)
-		err = keyIndexes.Delete(key)
+		// The bucket can't be modified while it's being iterated, so
+		// the tombstones to purge are collected first.
+		var purged [][]byte
+		err := tombstones.ForEach(func(k, v []byte) error {
+			if len(v) < 8 {
+				return ErrCorruptChanTombstone
+			}
+
+			deletedAt := timeFromUnixNano(byteOrder.Uint64(v[:8]))
+			if deletedAt.Before(before) {
+				chanID := append([]byte(nil), k...)
+				purged = append(purged, chanID)
+			}
+
+			return nil
+		})
 		if err != nil {
 			return err
 		}
-		if blobType == blob.TypeAltruistCommit {
-			err = keyIndexes.Delete(towerID.Bytes())
-			if err != nil {
+
+		for _, k := range purged {
+			if err := tombstones.Delete(k); err != nil {
 				return err
 			}
 		}
 
-		// Add the new entry to the towerID-to-SessionID index.
-		indexBkt := towerToSessionIndex.NestedReadWriteBucket(
-			towerID.Bytes(),
-		)
-		if indexBkt == nil {
-			return ErrTowerNotFound
-		}
+		return nil
+	}, func() {})
+}
 
-		err = indexBkt.Put(session.ID[:], []byte{1})
-		if err != nil {
-			return err
-		}
+// MarkBackupIneligible records that the state identified by the (channel id,
+// commit height) tuple was ineligible for being backed up under the current
+// policy. This state can be retried later under a different policy.
+func (c *ClientDB) MarkBackupIneligible(chanID lnwire.ChannelID,
+	commitHeight uint64) error {
 
-		// Finally, write the client session's body in the sessions
-		// bucket.
-		return putClientSessionBody(sessions, session)
-	}, func() {})
+	return nil
 }
 
-// createSessionKeyIndexKey returns the identifier used in the
-// session-key-index index, created as tower-id||blob-type.
-//
-// NOTE: The original serialization only used tower-id, which prevents
-// concurrent client types from reserving sessions with the same tower.
-func createSessionKeyIndexKey(towerID TowerID, blobType blob.Type) []byte {
-	towerIDBytes := towerID.Bytes()
+// CommitUpdate persists the CommittedUpdate provided in the slot for (session,
+// seqNum). This allows the client to retransmit this update on startup.
+func (c *ClientDB) CommitUpdate(id *SessionID,
+	update *CommittedUpdate) (uint16, error) {
 
-	// Session key indexes are stored under as tower-id||blob-type.
-	var keyBytes [6]byte
-	copy(keyBytes[:4], towerIDBytes)
-	byteOrder.PutUint16(keyBytes[4:], uint16(blobType))
+	lastApplied, _, err := c.CommitUpdateWithRemaining(id, update)
 
-	return keyBytes[:]
+	return lastApplied, err
 }
 
-// getSessionKeyIndex is a helper method.
-func getSessionKeyIndex(keyIndexes kvdb.RwBucket, towerID TowerID,
-	blobType blob.Type) (uint32, error) {
+// CommitUpdateWithRemaining persists the CommittedUpdate just like
+// CommitUpdate, but additionally returns the number of updates that can still
+// be committed to the session. This allows the client to start negotiating a
+// replacement session before the current one is exhausted. A remaining count
+// of zero means that the session is exhausted.
+func (c *ClientDB) CommitUpdateWithRemaining(id *SessionID,
+	update *CommittedUpdate) (uint16, uint16, error) {
+
+	var (
+		lastApplied uint16
+		remaining   uint16
+		events      sessionEventLog
+	)
+	err := c.update(func(tx kvdb.RwTx) error {
+		sessions := tx.ReadWriteBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
 
-	// Session key indexes are store under as tower-id||blob-type. The
-	// original serialization only used tower-id, which prevents concurrent
-	// client types from reserving sessions with the same tower.
-	keyBytes := createSessionKeyIndexKey(towerID, blobType)
+		chanSessions := tx.ReadWriteBucket(cChanSessionsBkt)
+		if chanSessions == nil {
+			return ErrUninitializedDB
+		}
 
-	// Retrieve the index using the key bytes. If the key wasn't found, we
-	// will fall back to the legacy format that only uses the tower id, but
-	// _only_ if the blob type is for altruist commit sessions since that
-	// was the only operational session type prior to changing the key
-	// format.
-	keyIndexBytes := keyIndexes.Get(keyBytes)
-	if keyIndexBytes == nil && blobType == blob.TypeAltruistCommit {
-		keyIndexBytes = keyIndexes.Get(towerID.Bytes())
+		var err error
+		lastApplied, remaining, err = commitUpdate(
+			sessions, chanSessions, id, update, c.scripts,
+			c.maxPendingUpdates, c.now(), &events,
+		)
+		return err
+	}, func() {
+		lastApplied = 0
+		remaining = 0
+		events = nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	c.sessionEvents.Notify(events...)
+
+	return lastApplied, remaining, nil
+}
+
+// CommitUpdateTx persists the CommittedUpdate just like CommitUpdate, but
+// within the given transaction rather than one of its own. This allows the
+// update to be committed atomically with other writes to the same backend, as
+// the update is only persisted if the caller commits the transaction. Any
+// session events caused by the update are delivered once the transaction is
+// committed.
+//
+// NOTE: The transaction must have been opened on the backend that the ClientDB
+// was opened with.
+func (c *ClientDB) CommitUpdateTx(tx kvdb.RwTx, id *SessionID,
+	update *CommittedUpdate) (uint16, error) {
+
+	sessions := tx.ReadWriteBucket(cSessionBkt)
+	if sessions == nil {
+		return 0, ErrUninitializedDB
+	}
+
+	chanSessions := tx.ReadWriteBucket(cChanSessionsBkt)
+	if chanSessions == nil {
+		return 0, ErrUninitializedDB
+	}
+
+	var events sessionEventLog
+	lastApplied, _, err := commitUpdate(
+		sessions, chanSessions, id, update, c.scripts,
+		c.maxPendingUpdates, c.now(), &events,
+	)
+	if err != nil {
+		return 0, err
 	}
 
-	// All session key indexes should be serialized uint32's. If no key
-	// index was found, the length of keyIndexBytes will be 0.
-	if len(keyIndexBytes) != 4 {
-		return 0, ErrNoReservedKeyIndex
+	if len(events) > 0 {
+		tx.OnCommit(func() {
+			c.sessionEvents.Notify(events...)
+		})
 	}
 
-	return byteOrder.Uint32(keyIndexBytes), nil
+	return lastApplied, nil
 }
 
-// ListClientSessions returns the set of all client sessions known to the db. An
-// optional tower ID can be used to filter out any client sessions in the
-// response that do not correspond to this tower.
-func (c *ClientDB) ListClientSessions(id *TowerID,
-	opts ...ClientSessionListOption) (map[SessionID]*ClientSession, error) {
+// ValidateUpdate checks whether the CommittedUpdate could be committed to the
+// session using CommitUpdate, without persisting it. The same errors that
+// CommitUpdate would return are returned, such as ErrClientSessionNotFound,
+// ErrCommitUnorderedUpdate or ErrUpdateAlreadyCommitted, while nil is returned
+// if the commit would succeed. Since nothing is persisted, the outcome may
+// change if the session is modified before the update is committed.
+func (c *ClientDB) ValidateUpdate(id *SessionID,
+	update *CommittedUpdate) error {
 
-	var clientSessions map[SessionID]*ClientSession
-	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+	return kvdb.View(c.db, func(tx kvdb.RTx) error {
 		sessions := tx.ReadBucket(cSessionBkt)
 		if sessions == nil {
 			return ErrUninitializedDB
 		}
 
-		towers := tx.ReadBucket(cTowerBkt)
-		if towers == nil {
-			return ErrUninitializedDB
+		session, err := getClientSessionBody(sessions, id[:])
+		if err != nil {
+			return err
 		}
 
-		var err error
+		// Can't fail if the above didn't fail.
+		sessionBkt := sessions.NestedReadBucket(id[:])
+		sessionCommits := sessionBkt.NestedReadBucket(cSessionCommits)
 
-		// If no tower ID is specified, then fetch all the sessions
-		// known to the db.
-		if id == nil {
-			clientSessions, err = listClientAllSessions(
-				sessions, towers, opts...,
-			)
-			return err
+		_, err = validateCommit(
+			session, sessionCommits, update, c.scripts,
+			c.maxPendingUpdates,
+		)
+
+		return err
+	}, func() {})
+}
+
+// CommitUpdates persists a contiguous run of CommittedUpdates for the given
+// session within a single database transaction. Each update is subject to the
+// same ordering and idempotency rules as CommitUpdate, and the returned slice
+// holds the session's last applied value after each update in the batch. If
+// any update in the batch is rejected, none of the updates are persisted.
+func (c *ClientDB) CommitUpdates(id *SessionID,
+	updates []*CommittedUpdate) ([]uint16, error) {
+
+	var (
+		lastApplieds []uint16
+		events       sessionEventLog
+		now          = c.now()
+	)
+	err := c.update(func(tx kvdb.RwTx) error {
+		sessions := tx.ReadWriteBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
 		}
 
-		// Otherwise, fetch the sessions for the given tower.
-		towerToSessionIndex := tx.ReadBucket(cTowerToSessionIndexBkt)
-		if towerToSessionIndex == nil {
+		chanSessions := tx.ReadWriteBucket(cChanSessionsBkt)
+		if chanSessions == nil {
 			return ErrUninitializedDB
 		}
 
-		clientSessions, err = listTowerSessions(
-			*id, sessions, towers, towerToSessionIndex, opts...,
-		)
-		return err
+		for _, update := range updates {
+			lastApplied, _, err := commitUpdate(
+				sessions, chanSessions, id, update, c.scripts,
+				c.maxPendingUpdates, now, &events,
+			)
+			if err != nil {
+				return err
+			}
+
+			lastApplieds = append(lastApplieds, lastApplied)
+		}
+
+		return nil
 	}, func() {
-		clientSessions = nil
+		lastApplieds = nil
+		events = nil
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return clientSessions, nil
+	c.sessionEvents.Notify(events...)
+
+	return lastApplieds, nil
 }
 
-// listClientAllSessions returns the set of all client sessions known to the db.
-func listClientAllSessions(sessions, towers kvdb.RBucket,
-	opts ...ClientSessionListOption) (map[SessionID]*ClientSession, error) {
+// commitUpdate persists the CommittedUpdate provided in the slot for (session,
+// seqNum) using the given sessions bucket, records the session in the update's
+// channel-to-session index entry, and returns the session's last applied
+// value along with the number of updates that can still be committed to the
+// session. The session's reward pkscripts are opened using the given script
+// cipher when validating the update's reward pkscript. If the session already
+// holds maxPending unacked updates, ErrTooManyPendingUpdates is returned,
+// unless maxPending is zero. The session is marked as updated at the given
+// time, and if the update exhausts the session, this is recorded in the given
+// event log.
+func commitUpdate(sessions, chanSessions kvdb.RwBucket, id *SessionID,
+	update *CommittedUpdate, scripts *scriptCipher, maxPending uint16,
+	now time.Time, events *sessionEventLog) (uint16, uint16, error) {
+
+	// We'll only load the ClientSession body for performance, since we
+	// primarily need to inspect its SeqNum and TowerLastApplied fields.
+	// The CommittedUpdates will be modified on disk directly.
+	session, err := getClientSessionBody(sessions, id[:])
+	if err != nil {
+		return 0, 0, err
+	}
 
-	clientSessions := make(map[SessionID]*ClientSession)
-	err := sessions.ForEach(func(k, _ []byte) error {
-		// We'll load the full client session since the client will need
-		// the CommittedUpdates and AckedUpdates on startup to resume
-		// committed updates and compute the highest known commit height
-		// for each channel.
-		session, err := getClientSession(sessions, towers, k, opts...)
-		if err != nil {
-			return err
-		}
+	// Can't fail if the above didn't fail.
+	sessionBkt := sessions.NestedReadWriteBucket(id[:])
 
-		clientSessions[session.ID] = session
+	// Ensure the session commits sub-bucket is initialized.
+	sessionCommits, err := sessionBkt.CreateBucketIfNotExists(
+		cSessionCommits,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
 
-		return nil
-	})
+	committed, err := validateCommit(
+		session, sessionCommits, update, scripts, maxPending,
+	)
 	if err != nil {
-		return nil, err
+		return 0, 0, err
 	}
 
-	return clientSessions, nil
+	// If the update was already committed, return the last applied value
+	// and succeed.
+	if committed {
+		return session.TowerLastApplied, remainingUpdates(session), nil
+	}
+
+	// Increment the session's sequence number and store the updated client
+	// session.
+	//
+	// TODO(conner): split out seqnum and last applied own bucket to
+	// eliminate serialization of full struct during CommitUpdate?
+	// Can also read/write directly to byes [:2] without migration.
+	session.SeqNum++
+	session.LastUpdated = now
+
+	// If this update allocated the session's last sequence number, the
+	// session is exhausted and can't be used for any further backups.
+	if session.SeqNum == session.Policy.MaxUpdates {
+		events.addStatusChange(
+			session.ID, session.Status, CSessionExhausted,
+		)
+		session.Status = CSessionExhausted
+	}
+
+	err = putClientSessionBody(sessions, session)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Encode and store the committed update in the sessionCommits
+	// sub-bucket under the requested sequence number. The stored update
+	// records the blob type under which it was encrypted, so that it can
+	// be inspected without the session's policy. The caller's update is
+	// left untouched.
+	dbUpdate := *update
+	dbUpdate.BlobType = session.Policy.BlobType
+
+	var b bytes.Buffer
+	err = dbUpdate.Encode(&b)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var seqNumBuf [2]byte
+	byteOrder.PutUint16(seqNumBuf[:], update.SeqNum)
+
+	// Account for the update's blob in the session's storage size before
+	// storing it, since the size of sessions that don't track it yet is
+	// derived from their stored updates.
+	err = adjustSessionStorageBytes(
+		sessionBkt, session.Policy.BlobType,
+		uint64(len(update.EncryptedBlob)), 0,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	err = sessionCommits.Put(seqNumBuf[:], b.Bytes())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Record that the session now holds an update of the channel.
+	err = addChanSession(chanSessions, update.BackupID.ChanID, id[:])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Finally, return the session's last applied value so it can be sent
+	// in the next state update to the tower.
+	return session.TowerLastApplied, remainingUpdates(session), nil
 }
 
-// listTowerSessions returns the set of all client sessions known to the db
-// that are associated with the given tower id.
-func listTowerSessions(id TowerID, sessionsBkt, towersBkt,
-	towerToSessionIndex kvdb.RBucket, opts ...ClientSessionListOption) (
-	map[SessionID]*ClientSession, error) {
+// validateCommit checks that the given update can be committed to the session,
+// whose committed updates are stored in the given bucket. The bucket may be
+// nil if the session has never held any committed updates. The session's
+// reward pkscripts are opened using the given script cipher when validating
+// the update's reward pkscript. If the session already holds maxPending
+// unacked updates, ErrTooManyPendingUpdates is returned, unless maxPending is
+// zero. True is returned if an identical update has already been committed,
+// in which case committing it again is a no-op.
+func validateCommit(session *ClientSession, sessionCommits kvdb.RBucket,
+	update *CommittedUpdate, scripts *scriptCipher,
+	maxPending uint16) (bool, error) {
+
+	// The update must be encrypted under the session's blob type, if it
+	// records one at all.
+	if update.BlobType != 0 && update.BlobType != session.Policy.BlobType {
+		return false, ErrBlobTypeMismatch
+	}
 
-	towerIndexBkt := towerToSessionIndex.NestedReadBucket(id.Bytes())
-	if towerIndexBkt == nil {
-		return nil, ErrTowerNotFound
+	var seqNumBuf [2]byte
+	byteOrder.PutUint16(seqNumBuf[:], update.SeqNum)
+
+	// Check to see if a committed update already exists for this sequence
+	// number.
+	var committedUpdateBytes []byte
+	if sessionCommits != nil {
+		committedUpdateBytes = sessionCommits.Get(seqNumBuf[:])
 	}
+	if committedUpdateBytes != nil {
+		var dbUpdate CommittedUpdate
+		err := dbUpdate.Decode(bytes.NewReader(committedUpdateBytes))
+		if err != nil {
+			return false, err
+		}
 
-	clientSessions := make(map[SessionID]*ClientSession)
-	err := towerIndexBkt.ForEach(func(k, _ []byte) error {
-		// We'll load the full client session since the client will need
-		// the CommittedUpdates and AckedUpdates on startup to resume
-		// committed updates and compute the highest known commit height
-		// for each channel.
-		session, err := getClientSession(
-			sessionsBkt, towersBkt, k, opts...,
-		)
+		// If an existing committed update has a different hint, we'll
+		// reject this newer update.
+		if dbUpdate.Hint != update.Hint {
+			return false, &CommitConflictError{
+				SeqNum:           update.SeqNum,
+				ExistingHint:     dbUpdate.Hint,
+				ExistingBackupID: dbUpdate.BackupID,
+				Hint:             update.Hint,
+				BackupID:         update.BackupID,
+			}
+		}
+
+		return true, nil
+	}
+
+	// Only active sessions accept new updates, though updates that were
+	// committed before the session became inactive or exhausted can still
+	// be retransmitted.
+	if session.Status != CSessionActive {
+		return false, ErrSessionNotActive
+	}
+
+	// There's no committed update for this sequence number, ensure that we
+	// are committing the next unallocated one.
+	if update.SeqNum != session.SeqNum+1 {
+		return false, ErrCommitUnorderedUpdate
+	}
+
+	// Refuse to add to the session's backlog of unacked updates if it has
+	// already reached the cap.
+	if maxPending != 0 && sessionCommits != nil {
+		numPending, err := countCommittedUpdates(sessionCommits)
 		if err != nil {
-			return err
+			return false, err
+		}
+
+		if numPending >= int(maxPending) {
+			return false, ErrTooManyPendingUpdates
+		}
+	}
+
+	// The update must pay out to one of the session's reward pkscripts. The
+	// pkscripts only need to be opened if the update doesn't use the
+	// session's RewardPkScript.
+	if len(update.RewardPkScript) != 0 {
+		opened, err := scripts.openSession(session)
+		if err != nil {
+			return false, err
+		}
+
+		if !opened.AcceptsRewardScript(update.RewardPkScript) {
+			return false, ErrUnknownRewardScript
 		}
+	}
+
+	return false, nil
+}
 
-		clientSessions[session.ID] = session
+// countCommittedUpdates returns the number of committed updates stored in the
+// given session commits bucket.
+func countCommittedUpdates(sessionCommits kvdb.RBucket) (int, error) {
+	var numUpdates int
+	err := sessionCommits.ForEach(func(_, _ []byte) error {
+		numUpdates++
 		return nil
 	})
 	if err != nil {
-		return nil, err
+		return 0, err
+	}
+
+	return numUpdates, nil
+}
+
+// remainingUpdates returns the number of updates that can still be committed
+// to the given session before it is exhausted.
+func remainingUpdates(session *ClientSession) uint16 {
+	if session.SeqNum >= session.Policy.MaxUpdates {
+		return 0
 	}
 
-	return clientSessions, nil
+	return session.Policy.MaxUpdates - session.SeqNum
 }
 
-// FetchSessionCommittedUpdates retrieves the current set of un-acked updates
-// of the given session.
-func (c *ClientDB) FetchSessionCommittedUpdates(id *SessionID) (
-	[]CommittedUpdate, error) {
+// AckUpdate persists an acknowledgment for a given (session, seqnum) pair. This
+// removes the update from the set of committed updates, and validates the
+// lastApplied value returned from the tower.
+func (c *ClientDB) AckUpdate(id *SessionID, seqNum uint16,
+	lastApplied uint16) error {
 
-	var committedUpdates []CommittedUpdate
-	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
-		sessions := tx.ReadBucket(cSessionBkt)
+	return c.update(func(tx kvdb.RwTx) error {
+		sessions := tx.ReadWriteBucket(cSessionBkt)
 		if sessions == nil {
 			return ErrUninitializedDB
 		}
 
-		sessionBkt := sessions.NestedReadBucket(id[:])
-		if sessionBkt == nil {
-			return ErrClientSessionNotFound
+		chanSessions := tx.ReadWriteBucket(cChanSessionsBkt)
+		if chanSessions == nil {
+			return ErrUninitializedDB
 		}
 
-		var err error
-		committedUpdates, err = getClientSessionCommits(
-			sessionBkt, nil, nil,
+		return ackUpdate(
+			sessions, chanSessions, id, seqNum, lastApplied,
+			c.now(),
 		)
-		return err
 	}, func() {})
-	if err != nil {
-		return nil, err
-	}
-
-	return committedUpdates, nil
 }
 
-// FetchChanSummaries loads a mapping from all registered channels to their
-// channel summaries.
-func (c *ClientDB) FetchChanSummaries() (ChannelSummaries, error) {
-	var summaries map[lnwire.ChannelID]ClientChanSummary
-	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
-		chanSummaries := tx.ReadBucket(cChanSummaryBkt)
-		if chanSummaries == nil {
+// AckUpdates persists a batch of acknowledgments for the given session within a
+// single database transaction. The acks map each acked sequence number to the
+// last applied value echoed by the tower, and are applied in ascending order of
+// sequence number. Each ack is subject to the same validation as AckUpdate, and
+// if any of them is rejected then none of the acks are persisted.
+func (c *ClientDB) AckUpdates(id *SessionID, acks map[uint16]uint16) error {
+	now := c.now()
+	return c.update(func(tx kvdb.RwTx) error {
+		sessions := tx.ReadWriteBucket(cSessionBkt)
+		if sessions == nil {
 			return ErrUninitializedDB
 		}
 
-		return chanSummaries.ForEach(func(k, v []byte) error {
-			var chanID lnwire.ChannelID
-			copy(chanID[:], k)
+		chanSessions := tx.ReadWriteBucket(cChanSessionsBkt)
+		if chanSessions == nil {
+			return ErrUninitializedDB
+		}
+
+		// Fail early if the session doesn't exist, so that an empty
+		// batch is still rejected for an unknown session.
+		if sessions.NestedReadWriteBucket(id[:]) == nil {
+			return ErrClientSessionNotFound
+		}
 
-			var summary ClientChanSummary
-			err := summary.Decode(bytes.NewReader(v))
+		for _, seqNum := range sortedSeqNums(acks) {
+			err := ackUpdate(
+				sessions, chanSessions, id, seqNum,
+				acks[seqNum], now,
+			)
 			if err != nil {
 				return err
 			}
+		}
 
-			summaries[chanID] = summary
+		return nil
+	}, func() {})
+}
 
-			return nil
-		})
-	}, func() {
-		summaries = make(map[lnwire.ChannelID]ClientChanSummary)
+// sortedSeqNums returns the sequence numbers of the given acks in ascending
+// order.
+func sortedSeqNums(acks map[uint16]uint16) []uint16 {
+	seqNums := make([]uint16, 0, len(acks))
+	for seqNum := range acks {
+		seqNums = append(seqNums, seqNum)
+	}
+
+	sort.Slice(seqNums, func(i, j int) bool {
+		return seqNums[i] < seqNums[j]
 	})
+
+	return seqNums
+}
+
+// ackUpdate persists an acknowledgment for a given (session, seqnum) pair using
+// the given sessions bucket, marking the session as updated at the given time.
+// Since the acked update remains with the session, the session's entry in the
+// channel-to-session index is retained.
+func ackUpdate(sessions, chanSessions kvdb.RwBucket, id *SessionID, seqNum,
+	lastApplied uint16, now time.Time) error {
+
+	// We'll only load the ClientSession body for performance, since we
+	// primarily need to inspect its SeqNum and TowerLastApplied fields.
+	// The CommittedUpdates and AckedUpdates will be modified on disk
+	// directly.
+	session, err := getClientSessionBody(sessions, id[:])
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return summaries, nil
-}
+	// If the tower has acked a sequence number beyond our highest sequence
+	// number, fail.
+	if lastApplied > session.SeqNum {
+		return ErrUnallocatedLastApplied
+	}
 
-// RegisterChannel registers a channel for use within the client database. For
-// now, all that is stored in the channel summary is the sweep pkscript that
-// we'd like any tower sweeps to pay into. In the future, this will be extended
-// to contain more info to allow the client efficiently request historical
-// states to be backed up under the client's active policy.
-func (c *ClientDB) RegisterChannel(chanID lnwire.ChannelID,
-	sweepPkScript []byte) error {
+	// If the tower acked with a lower sequence number than it gave us
+	// prior, fail.
+	if lastApplied < session.TowerLastApplied {
+		return ErrLastAppliedReversion
+	}
 
-	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
-		chanSummaries := tx.ReadWriteBucket(cChanSummaryBkt)
-		if chanSummaries == nil {
-			return ErrUninitializedDB
-		}
+	// TODO(conner): split out seqnum and last applied own bucket to
+	// eliminate serialization of full struct during AckUpdate?  Can also
+	// read/write directly to byes [2:4] without migration.
+	session.TowerLastApplied = lastApplied
+	session.LastUpdated = now
 
-		_, err := getChanSummary(chanSummaries, chanID)
-		switch {
+	// Write the client session with the updated last applied value.
+	err = putClientSessionBody(sessions, session)
+	if err != nil {
+		return err
+	}
 
-		// Summary already exists.
-		case err == nil:
-			return ErrChannelAlreadyRegistered
+	// Can't fail because of getClientSession succeeded.
+	sessionBkt := sessions.NestedReadWriteBucket(id[:])
 
-		// Channel is not registered, proceed with registration.
-		case err == ErrChannelNotRegistered:
+	// If the commits sub-bucket doesn't exist, there can't possibly be a
+	// corresponding committed update to remove.
+	sessionCommits := sessionBkt.NestedReadWriteBucket(cSessionCommits)
+	if sessionCommits == nil {
+		return ErrCommittedUpdateNotFound
+	}
 
-		// Unexpected error.
-		default:
-			return err
-		}
+	var seqNumBuf [2]byte
+	byteOrder.PutUint16(seqNumBuf[:], seqNum)
 
-		summary := ClientChanSummary{
-			SweepPkScript: sweepPkScript,
-		}
+	// Assert that a committed update exists for this sequence number.
+	committedUpdateBytes := sessionCommits.Get(seqNumBuf[:])
+	if committedUpdateBytes == nil {
+		return ErrCommittedUpdateNotFound
+	}
 
-		return putChanSummary(chanSummaries, chanID, &summary)
-	}, func() {})
-}
+	var committedUpdate CommittedUpdate
+	err = committedUpdate.Decode(bytes.NewReader(committedUpdateBytes))
+	if err != nil {
+		return err
+	}
 
-// MarkBackupIneligible records that the state identified by the (channel id,
-// commit height) tuple was ineligible for being backed up under the current
-// policy. This state can be retried later under a different policy.
-func (c *ClientDB) MarkBackupIneligible(chanID lnwire.ChannelID,
-	commitHeight uint64) error {
+	// Remove the corresponding committed update.
+	err = sessionCommits.Delete(seqNumBuf[:])
+	if err != nil {
+		return err
+	}
 
-	return nil
-}
+	// Ensure that the session acks sub-bucket is initialized so we can
+	// insert an entry.
+	sessionAcks, err := sessionBkt.CreateBucketIfNotExists(cSessionAcks)
+	if err != nil {
+		return err
+	}
 
-// CommitUpdate persists the CommittedUpdate provided in the slot for (session,
-// seqNum). This allows the client to retransmit this update on startup.
-func (c *ClientDB) CommitUpdate(id *SessionID,
-	update *CommittedUpdate) (uint16, error) {
+	// The session acks only need to track the backup id of the update, so
+	// we can discard the blob and hint.
+	var b bytes.Buffer
+	err = committedUpdate.BackupID.Encode(&b)
+	if err != nil {
+		return err
+	}
 
-	var lastApplied uint16
-	err := kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+	// Insert the ack into the sessionAcks sub-bucket.
+	err = sessionAcks.Put(seqNumBuf[:], b.Bytes())
+	if err != nil {
+		return err
+	}
+
+	err = bumpLastAckedSeqNum(sessionBkt, seqNum)
+	if err != nil {
+		return err
+	}
+
+	// Finally, ensure the session is indexed under the update's channel.
+	// This is a no-op unless the update was committed before the index
+	// was populated.
+	return addChanSession(
+		chanSessions, committedUpdate.BackupID.ChanID, id[:],
+	)
+}
+
+// DeleteCommittedUpdate removes the committed update with the given sequence
+// number from the session without acking it, for use when the tower will never
+// accept it. The session's remaining committed updates keep their sequence
+// numbers. ErrCommittedUpdateNotFound is returned if the session has no such
+// committed update.
+func (c *ClientDB) DeleteCommittedUpdate(id *SessionID, seqNum uint16) error {
+	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
 		sessions := tx.ReadWriteBucket(cSessionBkt)
 		if sessions == nil {
 			return ErrUninitializedDB
 		}
 
-		// We'll only load the ClientSession body for performance, since
-		// we primarily need to inspect its SeqNum and TowerLastApplied
-		// fields. The CommittedUpdates will be modified on disk
-		// directly.
-		session, err := getClientSessionBody(sessions, id[:])
-		if err != nil {
-			return err
-		}
-
-		// Can't fail if the above didn't fail.
 		sessionBkt := sessions.NestedReadWriteBucket(id[:])
+		if sessionBkt == nil {
+			return ErrClientSessionNotFound
+		}
 
-		// Ensure the session commits sub-bucket is initialized.
-		sessionCommits, err := sessionBkt.CreateBucketIfNotExists(
+		sessionCommits := sessionBkt.NestedReadWriteBucket(
 			cSessionCommits,
 		)
-		if err != nil {
-			return err
+		if sessionCommits == nil {
+			return ErrCommittedUpdateNotFound
+		}
+
+		chanSessions := tx.ReadWriteBucket(cChanSessionsBkt)
+		if chanSessions == nil {
+			return ErrUninitializedDB
 		}
 
 		var seqNumBuf [2]byte
-		byteOrder.PutUint16(seqNumBuf[:], update.SeqNum)
+		byteOrder.PutUint16(seqNumBuf[:], seqNum)
 
-		// Check to see if a committed update already exists for this
-		// sequence number.
 		committedUpdateBytes := sessionCommits.Get(seqNumBuf[:])
-		if committedUpdateBytes != nil {
-			var dbUpdate CommittedUpdate
-			err := dbUpdate.Decode(
-				bytes.NewReader(committedUpdateBytes),
-			)
-			if err != nil {
-				return err
-			}
-
-			// If an existing committed update has a different hint,
-			// we'll reject this newer update.
-			if dbUpdate.Hint != update.Hint {
-				return ErrUpdateAlreadyCommitted
-			}
+		if committedUpdateBytes == nil {
+			return ErrCommittedUpdateNotFound
+		}
 
-			// Otherwise, capture the last applied value and
-			// succeed.
-			lastApplied = session.TowerLastApplied
-			return nil
+		var update CommittedUpdate
+		err := update.Decode(bytes.NewReader(committedUpdateBytes))
+		if err != nil {
+			return err
 		}
 
-		// There's no committed update for this sequence number, ensure
-		// that we are committing the next unallocated one.
-		if update.SeqNum != session.SeqNum+1 {
-			return ErrCommitUnorderedUpdate
+		// The update was never accepted by the tower, so its blob no
+		// longer counts towards the session's storage size.
+		session, err := getClientSessionBody(sessions, id[:])
+		if err != nil {
+			return err
 		}
 
-		// Increment the session's sequence number and store the updated
-		// client session.
-		//
-		// TODO(conner): split out seqnum and last applied own bucket to
-		// eliminate serialization of full struct during CommitUpdate?
-		// Can also read/write directly to byes [:2] without migration.
-		session.SeqNum++
-		err = putClientSessionBody(sessions, session)
+		err = adjustSessionStorageBytes(
+			sessionBkt, session.Policy.BlobType, 0,
+			uint64(len(update.EncryptedBlob)),
+		)
 		if err != nil {
 			return err
 		}
 
-		// Encode and store the committed update in the sessionCommits
-		// sub-bucket under the requested sequence number.
-		var b bytes.Buffer
-		err = update.Encode(&b)
+		err = sessionCommits.Delete(seqNumBuf[:])
 		if err != nil {
 			return err
 		}
 
-		err = sessionCommits.Put(seqNumBuf[:], b.Bytes())
+		// Drop the session from the channel's index entry if this was
+		// its last update of the channel.
+		return pruneChanSession(
+			chanSessions, sessionBkt, update.BackupID.ChanID, id[:],
+		)
+	}, func() {})
+}
+
+// SessionStorageBytes returns the total size of the encrypted blobs of the
+// committed and acked updates of the session with the given ID, which is a
+// running total maintained as updates are committed. Acked updates keep
+// counting towards the total even once deleted by DeleteChannelUpdates, since
+// the tower continues to store them, while committed updates that are deleted
+// without being acked don't. ErrClientSessionNotFound is returned if the
+// session doesn't exist.
+func (c *ClientDB) SessionStorageBytes(id SessionID) (uint64, error) {
+	var size uint64
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		sessions := tx.ReadBucket(cSessionBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		session, err := getClientSessionBody(sessions, id[:])
 		if err != nil {
 			return err
 		}
 
-		// Finally, capture the session's last applied value so it can
-		// be sent in the next state update to the tower.
-		lastApplied = session.TowerLastApplied
+		// Can't fail if the above didn't fail.
+		sessionBkt := sessions.NestedReadBucket(id[:])
 
-		return nil
+		size, err = getSessionStorageBytes(
+			sessionBkt, session.Policy.BlobType,
+		)
 
+		return err
 	}, func() {
-		lastApplied = 0
+		size = 0
 	})
 	if err != nil {
 		return 0, err
 	}
 
-	return lastApplied, nil
+	return size, nil
 }
 
-// AckUpdate persists an acknowledgment for a given (session, seqnum) pair. This
-// removes the update from the set of committed updates, and validates the
-// lastApplied value returned from the tower.
-func (c *ClientDB) AckUpdate(id *SessionID, seqNum uint16,
-	lastApplied uint16) error {
+// getClientSessionBody loads the body of a ClientSession from the sessions
+// bucket corresponding to the serialized session id. This does not deserialize
+// the CommittedUpdates, AckUpdates or the Tower associated with the session.
+// If the caller requires this info, use getClientSession.
+func getClientSessionBody(sessions kvdb.RBucket,
+	idBytes []byte) (*ClientSession, error) {
 
-	return kvdb.Update(c.db, func(tx kvdb.RwTx) error {
-		sessions := tx.ReadWriteBucket(cSessionBkt)
-		if sessions == nil {
-			return ErrUninitializedDB
-		}
+	sessionBkt := sessions.NestedReadBucket(idBytes)
+	if sessionBkt == nil {
+		return nil, ErrClientSessionNotFound
+	}
 
-		// We'll only load the ClientSession body for performance, since
-		// we primarily need to inspect its SeqNum and TowerLastApplied
-		// fields. The CommittedUpdates and AckedUpdates will be
-		// modified on disk directly.
-		session, err := getClientSessionBody(sessions, id[:])
-		if err != nil {
-			return err
-		}
+	// Should never have a sessionBkt without also having its body.
+	sessionBody := sessionBkt.Get(cSessionBody)
+	if sessionBody == nil {
+		return nil, ErrCorruptClientSession
+	}
 
-		// If the tower has acked a sequence number beyond our highest
-		// sequence number, fail.
-		if lastApplied > session.SeqNum {
-			return ErrUnallocatedLastApplied
-		}
+	var session ClientSession
+	copy(session.ID[:], idBytes)
 
-		// If the tower acked with a lower sequence number than it gave
-		// us prior, fail.
-		if lastApplied < session.TowerLastApplied {
-			return ErrLastAppliedReversion
-		}
+	err := session.Decode(bytes.NewReader(sessionBody))
+	if err != nil {
+		return nil, err
+	}
 
-		// TODO(conner): split out seqnum and last applied own bucket to
-		// eliminate serialization of full struct during AckUpdate?  Can
-		// also read/write directly to byes [2:4] without migration.
-		session.TowerLastApplied = lastApplied
+	session.LastAckedSeqNum, err = getLastAckedSeqNum(sessionBkt)
+	if err != nil {
+		return nil, err
+	}
 
-		// Write the client session with the updated last applied value.
-		err = putClientSessionBody(sessions, session)
-		if err != nil {
-			return err
-		}
+	return &session, nil
+}
 
-		// Can't fail because of getClientSession succeeded.
-		sessionBkt := sessions.NestedReadWriteBucket(id[:])
+// getLastAckedSeqNum returns the highest sequence number acked by the tower
+// for the session whose bucket is given, or 0 if none has been acked yet.
+func getLastAckedSeqNum(sessionBkt kvdb.RBucket) (uint16, error) {
+	seqNumBytes := sessionBkt.Get(cSessionLastAcked)
+	switch {
+	case seqNumBytes == nil:
+		return 0, nil
 
-		// If the commits sub-bucket doesn't exist, there can't possibly
-		// be a corresponding committed update to remove.
-		sessionCommits := sessionBkt.NestedReadWriteBucket(
-			cSessionCommits,
-		)
-		if sessionCommits == nil {
-			return ErrCommittedUpdateNotFound
-		}
+	case len(seqNumBytes) != 2:
+		return 0, ErrCorruptClientSession
+	}
 
-		var seqNumBuf [2]byte
-		byteOrder.PutUint16(seqNumBuf[:], seqNum)
+	return byteOrder.Uint16(seqNumBytes), nil
+}
 
-		// Assert that a committed update exists for this sequence
-		// number.
-		committedUpdateBytes := sessionCommits.Get(seqNumBuf[:])
-		if committedUpdateBytes == nil {
-			return ErrCommittedUpdateNotFound
-		}
+// bumpLastAckedSeqNum records that the tower acked the given sequence number
+// for the session whose bucket is given. The stored sequence number is only
+// ever raised, since acks may arrive out of order.
+func bumpLastAckedSeqNum(sessionBkt kvdb.RwBucket, seqNum uint16) error {
+	lastAcked, err := getLastAckedSeqNum(sessionBkt)
+	if err != nil {
+		return err
+	}
 
-		var committedUpdate CommittedUpdate
-		err = committedUpdate.Decode(
-			bytes.NewReader(committedUpdateBytes),
-		)
-		if err != nil {
-			return err
-		}
+	if seqNum <= lastAcked {
+		return nil
+	}
+
+	var seqNumBuf [2]byte
+	byteOrder.PutUint16(seqNumBuf[:], seqNum)
+
+	return sessionBkt.Put(cSessionLastAcked, seqNumBuf[:])
+}
+
+// getSessionStorageBytes returns the total size of the encrypted blobs of the
+// committed and acked updates of the session whose bucket is given. For
+// sessions that don't track their size yet, it is derived from their stored
+// updates. Since acked updates don't retain their blob, each is assumed to
+// hold a blob of the size dictated by the session's blob type.
+func getSessionStorageBytes(sessionBkt kvdb.RBucket,
+	blobType blob.Type) (uint64, error) {
+
+	sizeBytes := sessionBkt.Get(cSessionStorageBytes)
+	switch {
+	case len(sizeBytes) == 8:
+		return byteOrder.Uint64(sizeBytes), nil
+
+	case sizeBytes != nil:
+		return 0, ErrCorruptClientSession
+	}
+
+	var size uint64
+	sessionCommits := sessionBkt.NestedReadBucket(cSessionCommits)
+	if sessionCommits != nil {
+		err := sessionCommits.ForEach(func(_, v []byte) error {
+			var update CommittedUpdate
+			err := update.Decode(bytes.NewReader(v))
+			if err != nil {
+				return err
+			}
 
-		// Remove the corresponding committed update.
-		err = sessionCommits.Delete(seqNumBuf[:])
-		if err != nil {
-			return err
-		}
+			size += uint64(len(update.EncryptedBlob))
 
-		// Ensure that the session acks sub-bucket is initialized so we
-		// can insert an entry.
-		sessionAcks, err := sessionBkt.CreateBucketIfNotExists(
-			cSessionAcks,
-		)
+			return nil
+		})
 		if err != nil {
-			return err
+			return 0, err
 		}
+	}
 
-		// The session acks only need to track the backup id of the
-		// update, so we can discard the blob and hint.
-		var b bytes.Buffer
-		err = committedUpdate.BackupID.Encode(&b)
+	sessionAcks := sessionBkt.NestedReadBucket(cSessionAcks)
+	if sessionAcks != nil {
+		err := sessionAcks.ForEach(func(_, _ []byte) error {
+			blobSize, err := blob.SizeChecked(blobType)
+			if err != nil {
+				return err
+			}
+
+			size += uint64(blobSize)
+
+			return nil
+		})
 		if err != nil {
-			return err
+			return 0, err
 		}
+	}
 
-		// Finally, insert the ack into the sessionAcks sub-bucket.
-		return sessionAcks.Put(seqNumBuf[:], b.Bytes())
-	}, func() {})
+	return size, nil
 }
 
-// getClientSessionBody loads the body of a ClientSession from the sessions
-// bucket corresponding to the serialized session id. This does not deserialize
-// the CommittedUpdates, AckUpdates or the Tower associated with the session.
-// If the caller requires this info, use getClientSession.
-func getClientSessionBody(sessions kvdb.RBucket,
-	idBytes []byte) (*ClientSession, error) {
+// adjustSessionStorageBytes adds the added bytes to, and subtracts the removed
+// bytes from, the storage size of the session whose bucket is given. If the
+// session doesn't track its size yet, it is first derived from the session's
+// stored updates, so this must be called before the stored updates are
+// modified.
+func adjustSessionStorageBytes(sessionBkt kvdb.RwBucket, blobType blob.Type,
+	added, removed uint64) error {
 
-	sessionBkt := sessions.NestedReadBucket(idBytes)
-	if sessionBkt == nil {
-		return nil, ErrClientSessionNotFound
+	size, err := getSessionStorageBytes(sessionBkt, blobType)
+	if err != nil {
+		return err
 	}
 
-	// Should never have a sessionBkt without also having its body.
-	sessionBody := sessionBkt.Get(cSessionBody)
-	if sessionBody == nil {
-		return nil, ErrCorruptClientSession
+	size += added
+	if removed > size {
+		removed = size
 	}
+	size -= removed
 
-	var session ClientSession
-	copy(session.ID[:], idBytes)
-
-	err := session.Decode(bytes.NewReader(sessionBody))
-	if err != nil {
-		return nil, err
-	}
+	var sizeBuf [8]byte
+	byteOrder.PutUint64(sizeBuf[:], size)
 
-	return &session, nil
+	return sessionBkt.Put(cSessionStorageBytes, sizeBuf[:])
 }
 
 // PerAckedUpdateCB describes the signature of a callback function that can be
@@ -1222,6 +6171,62 @@ type ClientSessionListCfg struct {
 	// PerCommittedUpdate will, if set, be called for each of the session's
 	// committed (un-acked) updates.
 	PerCommittedUpdate PerCommittedUpdateCB
+
+	// PostEvalFilterStatus will, if non-empty, be used to filter out any
+	// sessions whose status is not in the set. The filter is only applied
+	// after the PerAckedUpdate and PerCommittedUpdate call-backs have been
+	// evaluated for the session.
+	PostEvalFilterStatus []CSessionStatus
+
+	// PostEvalFilterBlobType will, if set, be used to filter out any
+	// sessions whose policy is not for the given blob type. Like the
+	// status filter, it is only applied after the PerAckedUpdate and
+	// PerCommittedUpdate call-backs have been evaluated for the session.
+	PostEvalFilterBlobType *blob.Type
+
+	// Pagination will, if set, restrict the sessions returned to a single
+	// page of sessions ordered by their IDs.
+	Pagination *PaginationCfg
+
+	// BodiesOnly will, if true, only load the sessions themselves, without
+	// visiting any of their committed or acked updates. The PerAckedUpdate
+	// and PerCommittedUpdate call-backs are then never called.
+	BodiesOnly bool
+}
+
+// PaginationCfg describes a single page of sessions to be returned when
+// listing client sessions.
+type PaginationCfg struct {
+	// Offset is the cursor after which the page starts. Only sessions
+	// whose IDs sort strictly after the offset are returned. The zero
+	// SessionID can be used to fetch the first page.
+	Offset SessionID
+
+	// Limit is the maximum number of sessions to return.
+	Limit int
+}
+
+// MatchesStatus returns true if a session with the given status passes the
+// PostEvalFilterStatus filter of the config.
+func (c *ClientSessionListCfg) MatchesStatus(status CSessionStatus) bool {
+	if len(c.PostEvalFilterStatus) == 0 {
+		return true
+	}
+
+	for _, s := range c.PostEvalFilterStatus {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchesBlobType returns true if a session negotiated for the given blob type
+// passes the PostEvalFilterBlobType filter of the config.
+func (c *ClientSessionListCfg) MatchesBlobType(blobType blob.Type) bool {
+	return c.PostEvalFilterBlobType == nil ||
+		*c.PostEvalFilterBlobType == blobType
 }
 
 // NewClientSessionCfg constructs a new ClientSessionListCfg.
@@ -1245,54 +6250,210 @@ func WithPerCommittedUpdate(cb PerCommittedUpdateCB) ClientSessionListOption {
 	}
 }
 
-// getClientSession loads the full ClientSession associated with the serialized
-// session id. This method populates the CommittedUpdates, AckUpdates and Tower
-// in addition to the ClientSession's body.
-func getClientSession(sessions, towers kvdb.RBucket, idBytes []byte,
-	opts ...ClientSessionListOption) (*ClientSession, error) {
+// CreateTowerOption describes the signature of a functional option that can be
+// used when creating a tower in order to set any of its optional fields.
+type CreateTowerOption func(cfg *CreateTowerCfg)
 
-	cfg := NewClientSessionCfg()
-	for _, o := range opts {
-		o(cfg)
+// CreateTowerCfg defines the optional fields that can be set when creating a
+// tower.
+type CreateTowerCfg struct {
+	// Nickname is the human-readable label to assign to the tower. If
+	// empty, an existing tower's nickname is left unmodified.
+	Nickname string
+}
+
+// NewCreateTowerCfg constructs a new CreateTowerCfg.
+func NewCreateTowerCfg() *CreateTowerCfg {
+	return &CreateTowerCfg{}
+}
+
+// WithTowerNickname constructs a functional option that will assign the given
+// human-readable nickname to the tower.
+func WithTowerNickname(nickname string) CreateTowerOption {
+	return func(cfg *CreateTowerCfg) {
+		cfg.Nickname = nickname
 	}
+}
 
-	session, err := getClientSessionBody(sessions, idBytes)
-	if err != nil {
-		return nil, err
+// OrphanedChannelsCB is the signature of a call-back that is called with the
+// registered channels left without a backup by the removal of a tower.
+type OrphanedChannelsCB func(chanIDs []lnwire.ChannelID)
+
+// RemoveTowerOption describes the signature of a functional option that can be
+// used when removing a tower in order to provide any extra instruction to the
+// removal.
+type RemoveTowerOption func(cfg *RemoveTowerCfg)
+
+// RemoveTowerCfg defines the optional behavior of a tower's removal.
+type RemoveTowerCfg struct {
+	// ReportOrphanedChannels, if set, is called once the tower has been
+	// removed with the registered channels whose updates were held by the
+	// tower's sessions, but aren't held by any session of another tower
+	// that is still in use. The channel summaries themselves are left
+	// untouched.
+	ReportOrphanedChannels OrphanedChannelsCB
+}
+
+// NewRemoveTowerCfg constructs a new RemoveTowerCfg.
+func NewRemoveTowerCfg() *RemoveTowerCfg {
+	return &RemoveTowerCfg{}
+}
+
+// WithReportOrphanedChannels constructs a functional option that will report
+// the registered channels left without a backup by the removal of a tower to
+// the given call-back, in ascending order. The call-back isn't called if only
+// an address of the tower is removed.
+func WithReportOrphanedChannels(cb OrphanedChannelsCB) RemoveTowerOption {
+	return func(cfg *RemoveTowerCfg) {
+		cfg.ReportOrphanedChannels = cb
 	}
+}
 
-	// Fetch the tower associated with this session.
-	tower, err := getTower(towers, session.TowerID.Bytes())
-	if err != nil {
-		return nil, err
+// UnregisterChannelOption describes the signature of a functional option that
+// can be used when unregistering a channel in order to provide any extra
+// instruction to the removal.
+type UnregisterChannelOption func(cfg *UnregisterChannelCfg)
+
+// UnregisterChannelCfg defines the optional behavior of a channel's removal.
+type UnregisterChannelCfg struct {
+	// SoftDelete, if true, replaces the channel's summary with a tombstone
+	// recording the time of deletion, rather than removing it entirely.
+	SoftDelete bool
+}
+
+// NewUnregisterChannelCfg constructs a new UnregisterChannelCfg.
+func NewUnregisterChannelCfg() *UnregisterChannelCfg {
+	return &UnregisterChannelCfg{}
+}
+
+// WithSoftDelete constructs a functional option that will keep the summary of
+// the unregistered channel in a tombstone, until it is purged.
+func WithSoftDelete() UnregisterChannelOption {
+	return func(cfg *UnregisterChannelCfg) {
+		cfg.SoftDelete = true
 	}
-	session.Tower = tower
+}
 
-	// Can't fail because client session body has already been read.
-	sessionBkt := sessions.NestedReadBucket(idBytes)
+// ChanSummaryFetchOption describes the signature of a functional option that
+// can be used when fetching channel summaries in order to provide any extra
+// instruction to the query.
+type ChanSummaryFetchOption func(cfg *ChanSummaryFetchCfg)
+
+// ChanSummaryFetchCfg defines various query parameters that will be used when
+// fetching channel summaries.
+type ChanSummaryFetchCfg struct {
+	// IncludeTombstoned will, if true, include the summaries of the
+	// channels that were soft-deleted.
+	IncludeTombstoned bool
+}
 
-	// Pass the session's committed (un-acked) updates through the call-back
-	// if one is provided.
-	err = filterClientSessionCommits(
-		sessionBkt, session, cfg.PerCommittedUpdate,
-	)
-	if err != nil {
-		return nil, err
+// NewChanSummaryFetchCfg constructs a new ChanSummaryFetchCfg.
+func NewChanSummaryFetchCfg() *ChanSummaryFetchCfg {
+	return &ChanSummaryFetchCfg{}
+}
+
+// WithTombstoned constructs a functional option that will include the
+// summaries of soft-deleted channels, with their DeletedAt set.
+func WithTombstoned() ChanSummaryFetchOption {
+	return func(cfg *ChanSummaryFetchCfg) {
+		cfg.IncludeTombstoned = true
 	}
+}
 
-	// Pass the session's acked updates through the call-back if one is
-	// provided.
-	err = filterClientSessionAcks(sessionBkt, session, cfg.PerAckedUpdate)
-	if err != nil {
-		return nil, err
+// TowerListOption describes the signature of a functional option that can be
+// used when listing towers in order to provide any extra instruction to the
+// query.
+type TowerListOption func(cfg *TowerListCfg)
+
+// TowerListCfg defines various query parameters that will be used when querying
+// the DB for towers.
+type TowerListCfg struct {
+	// OnlyActiveTowers will, if true, filter out any towers that do not
+	// have at least one active session.
+	OnlyActiveTowers bool
+}
+
+// NewTowerListCfg constructs a new TowerListCfg.
+func NewTowerListCfg() *TowerListCfg {
+	return &TowerListCfg{}
+}
+
+// WithOnlyActiveTowers constructs a functional option that will filter out any
+// towers that do not have at least one active session.
+func WithOnlyActiveTowers() TowerListOption {
+	return func(cfg *TowerListCfg) {
+		cfg.OnlyActiveTowers = true
 	}
+}
 
-	return session, nil
+// WithPostEvalFilterStatus constructs a functional option that will filter out
+// any sessions whose status is not one of the given statuses. Note that any
+// PerAckedUpdate and PerCommittedUpdate call-backs will still be called for
+// the filtered sessions.
+func WithPostEvalFilterStatus(
+	statuses ...CSessionStatus) ClientSessionListOption {
+
+	return func(cfg *ClientSessionListCfg) {
+		cfg.PostEvalFilterStatus = statuses
+	}
+}
+
+// WithPostEvalFilterBlobType constructs a functional option that will filter
+// out any sessions whose policy was not negotiated for the given blob type.
+// Note that any PerAckedUpdate and PerCommittedUpdate call-backs will still be
+// called for the filtered sessions.
+func WithPostEvalFilterBlobType(blobType blob.Type) ClientSessionListOption {
+	return func(cfg *ClientSessionListCfg) {
+		cfg.PostEvalFilterBlobType = &blobType
+	}
+}
+
+// WithPaginate constructs a functional option that will restrict the sessions
+// returned to at most limit sessions whose IDs sort after the given offset.
+// The cursor for the next page can be obtained with NextPageCursor.
+func WithPaginate(offset SessionID, limit int) ClientSessionListOption {
+	return func(cfg *ClientSessionListCfg) {
+		cfg.Pagination = &PaginationCfg{
+			Offset: offset,
+			Limit:  limit,
+		}
+	}
+}
+
+// WithBodiesOnly constructs a functional option that will only load the
+// sessions themselves, skipping their committed and acked updates entirely.
+// This speeds up queries that are only interested in which sessions exist and
+// their status. Any PerAckedUpdate and PerCommittedUpdate call-backs are
+// ignored.
+func WithBodiesOnly() ClientSessionListOption {
+	return func(cfg *ClientSessionListCfg) {
+		cfg.BodiesOnly = true
+	}
+}
+
+// NextPageCursor returns the cursor to be used as the offset of WithPaginate
+// in order to fetch the page following the given one. This is the highest
+// session ID in the page. The returned boolean is false if the page is empty,
+// in which case there are no further pages.
+func NextPageCursor(page map[SessionID]*ClientSession) (SessionID, bool) {
+	var (
+		cursor SessionID
+		found  bool
+	)
+	for id := range page {
+		if !found || bytes.Compare(id[:], cursor[:]) > 0 {
+			cursor = id
+			found = true
+		}
+	}
+
+	return cursor, found
 }
 
 // getClientSessionCommits retrieves all committed updates for the session
 // identified by the serialized session id. If a PerCommittedUpdateCB is
 // provided, then it will be called for each of the session's committed updates.
+// Updates stored without a blob type are assigned that of the given session.
 func getClientSessionCommits(sessionBkt kvdb.RBucket, s *ClientSession,
 	cb PerCommittedUpdateCB) ([]CommittedUpdate, error) {
 
@@ -1306,12 +6467,10 @@ func getClientSessionCommits(sessionBkt kvdb.RBucket, s *ClientSession,
 	}
 
 	err := sessionCommits.ForEach(func(k, v []byte) error {
-		var committedUpdate CommittedUpdate
-		err := committedUpdate.Decode(bytes.NewReader(v))
+		committedUpdate, err := decodeCommittedUpdate(k, v, s)
 		if err != nil {
 			return err
 		}
-		committedUpdate.SeqNum = byteOrder.Uint16(k)
 
 		committedUpdates = append(committedUpdates, committedUpdate)
 
@@ -1328,71 +6487,171 @@ func getClientSessionCommits(sessionBkt kvdb.RBucket, s *ClientSession,
 	return committedUpdates, nil
 }
 
-// filterClientSessionAcks retrieves all acked updates for the session
-// identified by the serialized session id and passes them to the provided
-// call back if one is provided.
-func filterClientSessionAcks(sessionBkt kvdb.RBucket, s *ClientSession,
-	cb PerAckedUpdateCB) error {
+// decodeCommittedUpdate decodes the committed update stored under the given
+// sequence number key of the session's commits bucket. If the update was
+// stored before its blob type was recorded, it is assigned the blob type of
+// the session's policy.
+func decodeCommittedUpdate(k, v []byte,
+	session *ClientSession) (CommittedUpdate, error) {
 
-	if cb == nil {
-		return nil
+	var committedUpdate CommittedUpdate
+	err := committedUpdate.Decode(bytes.NewReader(v))
+	if err != nil {
+		return CommittedUpdate{}, err
 	}
+	committedUpdate.SeqNum = byteOrder.Uint16(k)
 
-	sessionAcks := sessionBkt.NestedReadBucket(cSessionAcks)
-	if sessionAcks == nil {
-		return nil
+	if committedUpdate.BlobType == 0 {
+		committedUpdate.BlobType = session.Policy.BlobType
 	}
 
-	err := sessionAcks.ForEach(func(k, v []byte) error {
-		seqNum := byteOrder.Uint16(k)
+	return committedUpdate, nil
+}
 
-		var backupID BackupID
-		err := backupID.Decode(bytes.NewReader(v))
+// boltSessionSource is a ClientSessionSource backed by the buckets of a single
+// bolt transaction.
+type boltSessionSource struct {
+	// sessions is the top-level sessions bucket.
+	sessions kvdb.RBucket
+
+	// towers is the top-level towers bucket.
+	towers kvdb.RBucket
+
+	// sessionIDs is the bucket whose keys are the IDs of the sessions to
+	// list, which is either the sessions bucket or a tower's bucket within
+	// the tower-to-session index.
+	sessionIDs kvdb.RBucket
+
+	// scripts opens the reward pkscripts of the fetched sessions. It is
+	// nil if the pkscripts are stored in the clear.
+	scripts *scriptCipher
+}
+
+// A compile-time check to ensure boltSessionSource implements the
+// ClientSessionSource interface.
+var _ ClientSessionSource = (*boltSessionSource)(nil)
+
+// ForEachSessionID calls next with each of the source's session IDs that sort
+// strictly after the given offset, in ascending order.
+//
+// NOTE: This is part of the ClientSessionSource interface.
+func (b *boltSessionSource) ForEachSessionID(offset *SessionID,
+	next func(SessionID) (bool, error)) error {
+
+	cursor := b.sessionIDs.ReadCursor()
+
+	// Seek to the first session ID that sorts strictly after the offset,
+	// if one is given.
+	k, _ := cursor.First()
+	if offset != nil {
+		k, _ = cursor.Seek(offset[:])
+		if k != nil && bytes.Equal(k, offset[:]) {
+			k, _ = cursor.Next()
+		}
+	}
+
+	for ; k != nil; k, _ = cursor.Next() {
+		var id SessionID
+		copy(id[:], k)
+
+		more, err := next(id)
 		if err != nil {
 			return err
 		}
+		if !more {
+			return nil
+		}
+	}
 
-		cb(s, seqNum, backupID)
-		return nil
-	})
+	return nil
+}
+
+// FetchSession loads the session with the given ID along with its tower.
+//
+// NOTE: This is part of the ClientSessionSource interface.
+func (b *boltSessionSource) FetchSession(id SessionID) (*ClientSession,
+	error) {
+
+	session, err := getClientSessionBody(b.sessions, id[:])
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	session, err = b.scripts.openSession(session)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch the tower associated with this session.
+	tower, err := getTower(b.towers, session.TowerID.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	session.Tower = tower
+
+	return session, nil
 }
 
-// filterClientSessionCommits retrieves all committed updates for the session
-// identified by the serialized session id and passes them to the given
-// PerCommittedUpdateCB callback.
-func filterClientSessionCommits(sessionBkt kvdb.RBucket, s *ClientSession,
-	cb PerCommittedUpdateCB) error {
+// ForEachCommittedUpdate calls cb with each of the session's committed updates,
+// in ascending order of sequence number.
+//
+// NOTE: This is part of the ClientSessionSource interface.
+func (b *boltSessionSource) ForEachCommittedUpdate(id SessionID,
+	cb func(*CommittedUpdate)) error {
 
-	if cb == nil {
-		return nil
+	session, err := getClientSessionBody(b.sessions, id[:])
+	if err != nil {
+		return err
 	}
 
+	// Can't fail if the above didn't fail.
+	sessionBkt := b.sessions.NestedReadBucket(id[:])
+
 	sessionCommits := sessionBkt.NestedReadBucket(cSessionCommits)
 	if sessionCommits == nil {
 		return nil
 	}
 
-	err := sessionCommits.ForEach(func(k, v []byte) error {
-		var committedUpdate CommittedUpdate
-		err := committedUpdate.Decode(bytes.NewReader(v))
+	return sessionCommits.ForEach(func(k, v []byte) error {
+		committedUpdate, err := decodeCommittedUpdate(k, v, session)
 		if err != nil {
 			return err
 		}
-		committedUpdate.SeqNum = byteOrder.Uint16(k)
 
-		cb(s, &committedUpdate)
+		cb(&committedUpdate)
+
 		return nil
 	})
-	if err != nil {
-		return err
+}
+
+// ForEachAckedUpdate calls cb with each of the session's acked updates, in
+// ascending order of sequence number.
+//
+// NOTE: This is part of the ClientSessionSource interface.
+func (b *boltSessionSource) ForEachAckedUpdate(id SessionID,
+	cb func(uint16, BackupID)) error {
+
+	sessionBkt := b.sessions.NestedReadBucket(id[:])
+	if sessionBkt == nil {
+		return ErrClientSessionNotFound
 	}
 
-	return nil
+	sessionAcks := sessionBkt.NestedReadBucket(cSessionAcks)
+	if sessionAcks == nil {
+		return nil
+	}
+
+	return sessionAcks.ForEach(func(k, v []byte) error {
+		var backupID BackupID
+		err := backupID.Decode(bytes.NewReader(v))
+		if err != nil {
+			return err
+		}
+
+		cb(byteOrder.Uint16(k), backupID)
+
+		return nil
+	})
 }
 
 // putClientSessionBody stores the body of the ClientSession (everything but the
@@ -1414,39 +6673,94 @@ func putClientSessionBody(sessions kvdb.RwBucket,
 	return sessionBkt.Put(cSessionBody, b.Bytes())
 }
 
+// now returns the current time of the database's clock, stripped of its
+// monotonic clock reading so that it is equal to the time decoded after a round
+// trip through the database.
+func (c *ClientDB) now() time.Time {
+	return time.Unix(0, c.clock.Now().UnixNano())
+}
+
 // markSessionStatus updates the persisted state of the session to the new
-// status.
+// status, recording the change in the given event log.
 func markSessionStatus(sessions kvdb.RwBucket, session *ClientSession,
-	status CSessionStatus) error {
+	status CSessionStatus, events *sessionEventLog) error {
+
+	// An exhausted session has no sequence numbers left, so it must never
+	// transition to any other status.
+	if session.Status == CSessionExhausted {
+		return nil
+	}
+
+	events.addStatusChange(session.ID, session.Status, status)
 
 	session.Status = status
 	return putClientSessionBody(sessions, session)
 }
 
-// getChanSummary loads a ClientChanSummary for the passed chanID.
-func getChanSummary(chanSummaries kvdb.RBucket,
-	chanID lnwire.ChannelID) (*ClientChanSummary, error) {
+// countUnexhaustedSessions returns the number of sessions in the given
+// tower-to-session index bucket that have not been exhausted.
+func countUnexhaustedSessions(sessions, towerIndexBkt kvdb.RBucket) (uint32,
+	error) {
+
+	var count uint32
+	err := towerIndexBkt.ForEach(func(k, _ []byte) error {
+		session, err := getClientSessionBody(sessions, k)
+		if err != nil {
+			return err
+		}
+
+		if session.Status != CSessionExhausted {
+			count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// getChanSummary loads a ClientChanSummary for the passed chanID, opening its
+// sweep pkscript using the given script cipher.
+func getChanSummary(chanSummaries kvdb.RBucket, chanID lnwire.ChannelID,
+	scripts *scriptCipher) (*ClientChanSummary, error) {
 
 	chanSummaryBytes := chanSummaries.Get(chanID[:])
 	if chanSummaryBytes == nil {
 		return nil, ErrChannelNotRegistered
 	}
 
+	return decodeChanSummary(chanSummaryBytes, chanID, scripts)
+}
+
+// decodeChanSummary decodes the serialized ClientChanSummary of the passed
+// chanID, opening its sweep pkscript using the given script cipher.
+func decodeChanSummary(chanSummaryBytes []byte, chanID lnwire.ChannelID,
+	scripts *scriptCipher) (*ClientChanSummary, error) {
+
 	var summary ClientChanSummary
 	err := summary.Decode(bytes.NewReader(chanSummaryBytes))
 	if err != nil {
 		return nil, err
 	}
 
-	return &summary, nil
+	return scripts.openChanSummary(chanID, &summary)
 }
 
-// putChanSummary stores a ClientChanSummary for the passed chanID.
+// putChanSummary stores a ClientChanSummary for the passed chanID, sealing its
+// sweep pkscript using the given script cipher.
 func putChanSummary(chanSummaries kvdb.RwBucket, chanID lnwire.ChannelID,
-	summary *ClientChanSummary) error {
+	summary *ClientChanSummary, scripts *scriptCipher) error {
+
+	sealed, err := scripts.sealChanSummary(chanID, summary)
+	if err != nil {
+		return err
+	}
 
 	var b bytes.Buffer
-	err := summary.Encode(&b)
+	err = sealed.Encode(&b)
 	if err != nil {
 		return err
 	}
@@ -1454,6 +6768,53 @@ func putChanSummary(chanSummaries kvdb.RwBucket, chanID lnwire.ChannelID,
 	return chanSummaries.Put(chanID[:], b.Bytes())
 }
 
+// decodeChanTombstone decodes the serialized tombstone of the passed chanID,
+// returning its ClientChanSummary with DeletedAt set. The sweep pkscript is
+// opened using the given script cipher.
+func decodeChanTombstone(tombstoneBytes []byte, chanID lnwire.ChannelID,
+	scripts *scriptCipher) (*ClientChanSummary, error) {
+
+	if len(tombstoneBytes) < 8 {
+		return nil, ErrCorruptChanTombstone
+	}
+
+	summary, err := decodeChanSummary(tombstoneBytes[8:], chanID, scripts)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.DeletedAt = timeFromUnixNano(
+		byteOrder.Uint64(tombstoneBytes[:8]),
+	)
+
+	return summary, nil
+}
+
+// putChanTombstone stores the tombstone of the passed chanID, holding the time
+// at which it was deleted followed by its ClientChanSummary. The sweep pkscript
+// is sealed using the given script cipher.
+func putChanTombstone(tombstones kvdb.RwBucket, chanID lnwire.ChannelID,
+	summary *ClientChanSummary, scripts *scriptCipher) error {
+
+	sealed, err := scripts.sealChanSummary(chanID, summary)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	err = WriteElement(&b, timeToUnixNano(summary.DeletedAt))
+	if err != nil {
+		return err
+	}
+
+	err = sealed.Encode(&b)
+	if err != nil {
+		return err
+	}
+
+	return tombstones.Put(chanID[:], b.Bytes())
+}
+
 // getTower loads a Tower identified by its serialized tower id.
 func getTower(towers kvdb.RBucket, id []byte) (*Tower, error) {
 	towerBytes := towers.Get(id)
@@ -1469,6 +6830,12 @@ func getTower(towers kvdb.RBucket, id []byte) (*Tower, error) {
 
 	tower.ID = TowerIDFromBytes(id)
 
+	// Tower records written before features were stored default to an
+	// empty feature vector.
+	if tower.Features == nil {
+		tower.Features = NewTowerFeatures(nil)
+	}
+
 	return &tower, nil
 }
 
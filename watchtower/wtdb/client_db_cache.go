@@ -0,0 +1,317 @@
+package wtdb
+
+import (
+	"container/list"
+	"io"
+	"sync"
+
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// CachedDB wraps a ClientDB, serving reads of channel summaries from an
+// in-memory LRU cache. Channel summaries are read on every backup but rarely
+// change, so this spares the backup path a database transaction in the common
+// case. The cache is invalidated by every method that modifies the summaries,
+// such that reads served from it are identical to those of the wrapped
+// database. All other methods are passed through to the wrapped database.
+//
+// NOTE: The summaries must only be modified through the CachedDB, otherwise
+// the cache may serve stale summaries.
+type CachedDB struct {
+	*ClientDB
+
+	// maxEntries is the maximum number of channel summaries held by the
+	// cache.
+	maxEntries int
+
+	mu sync.Mutex
+
+	// summaries maps the channels in the cache to their element in lru.
+	summaries map[lnwire.ChannelID]*list.Element
+
+	// lru holds the cached cachedChanSummary entries, ordered from the
+	// most to the least recently used.
+	lru *list.List
+
+	// complete is true if the cache holds the summary of every registered
+	// channel, allowing FetchChanSummaries to be served from it.
+	complete bool
+
+	// generation is incremented on every invalidation, so that summaries
+	// read from the database before an invalidation aren't cached after
+	// it.
+	generation uint64
+}
+
+// cachedChanSummary is an entry of the CachedDB's LRU cache.
+type cachedChanSummary struct {
+	chanID  lnwire.ChannelID
+	summary ClientChanSummary
+}
+
+// NewCachedDB wraps the given ClientDB, caching up to maxEntries channel
+// summaries in memory. FetchChanSummaries is only served from the cache if
+// every registered channel fits within it.
+func NewCachedDB(db *ClientDB, maxEntries int) *CachedDB {
+	return &CachedDB{
+		ClientDB:   db,
+		maxEntries: maxEntries,
+		summaries:  make(map[lnwire.ChannelID]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// FetchChanSummaries loads a mapping from all registered channels to their
+// channel summaries, serving it from the cache if possible. Tombstoned channels
+// aren't cached, so their inclusion is always served by the database.
+func (c *CachedDB) FetchChanSummaries(
+	opts ...ChanSummaryFetchOption) (ChannelSummaries, error) {
+
+	cfg := NewChanSummaryFetchCfg()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.IncludeTombstoned {
+		return c.ClientDB.FetchChanSummaries(opts...)
+	}
+
+	c.mu.Lock()
+	if c.complete {
+		summaries := make(ChannelSummaries, c.lru.Len())
+		for e := c.lru.Front(); e != nil; e = e.Next() {
+			entry := e.Value.(*cachedChanSummary)
+			summaries[entry.chanID] = copyChanSummary(entry.summary)
+		}
+		c.mu.Unlock()
+
+		return summaries, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	summaries, err := c.ClientDB.FetchChanSummaries()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only populate the cache if it can hold every registered channel,
+	// since it would otherwise never be complete.
+	if len(summaries) > c.maxEntries {
+		return summaries, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return summaries, nil
+	}
+
+	for chanID, summary := range summaries {
+		c.put(chanID, summary)
+	}
+	c.complete = true
+
+	return summaries, nil
+}
+
+// FetchChanSummariesForChannels loads the channel summaries of the given
+// channels, reading only those missing from the cache from the database.
+// Channels that aren't registered are omitted from the result.
+func (c *CachedDB) FetchChanSummariesForChannels(
+	chanIDs []lnwire.ChannelID) (ChannelSummaries, error) {
+
+	summaries := make(ChannelSummaries, len(chanIDs))
+
+	c.mu.Lock()
+	var missing []lnwire.ChannelID
+	for _, chanID := range chanIDs {
+		e, ok := c.summaries[chanID]
+		switch {
+		case ok:
+			c.lru.MoveToFront(e)
+			entry := e.Value.(*cachedChanSummary)
+			summaries[chanID] = copyChanSummary(entry.summary)
+
+		// If the cache holds every registered channel, a miss means
+		// that the channel isn't registered.
+		case c.complete:
+
+		default:
+			missing = append(missing, chanID)
+		}
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	if len(missing) == 0 {
+		return summaries, nil
+	}
+
+	fetched, err := c.ClientDB.FetchChanSummariesForChannels(missing)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for chanID, summary := range fetched {
+		summaries[chanID] = summary
+
+		if c.generation == generation {
+			c.put(chanID, summary)
+		}
+	}
+
+	return summaries, nil
+}
+
+// RegisterChannel registers a channel for use within the client database,
+// invalidating its cached summary.
+func (c *CachedDB) RegisterChannel(chanID lnwire.ChannelID,
+	sweepPkScript []byte) error {
+
+	defer c.invalidate(chanID)
+
+	return c.ClientDB.RegisterChannel(chanID, sweepPkScript)
+}
+
+// RegisterChannelMulti registers a channel with multiple sweep pkscripts for
+// use within the client database, invalidating its cached summary.
+func (c *CachedDB) RegisterChannelMulti(chanID lnwire.ChannelID,
+	scripts map[string][]byte) error {
+
+	defer c.invalidate(chanID)
+
+	return c.ClientDB.RegisterChannelMulti(chanID, scripts)
+}
+
+// RegisterChannels registers a batch of channels within a single database
+// transaction, invalidating their cached summaries.
+func (c *CachedDB) RegisterChannels(
+	sweepPkScripts map[lnwire.ChannelID][]byte) (map[lnwire.ChannelID]error,
+	error) {
+
+	chanIDs := make([]lnwire.ChannelID, 0, len(sweepPkScripts))
+	for chanID := range sweepPkScripts {
+		chanIDs = append(chanIDs, chanID)
+	}
+	defer c.invalidate(chanIDs...)
+
+	return c.ClientDB.RegisterChannels(sweepPkScripts)
+}
+
+// SetChannelPriority sets the backup priority of a registered channel,
+// invalidating its cached summary.
+func (c *CachedDB) SetChannelPriority(chanID lnwire.ChannelID,
+	priority uint8) error {
+
+	defer c.invalidate(chanID)
+
+	return c.ClientDB.SetChannelPriority(chanID, priority)
+}
+
+// UnregisterChannel removes the channel summary for the given channel from the
+// client database, invalidating its cached summary.
+func (c *CachedDB) UnregisterChannel(chanID lnwire.ChannelID,
+	opts ...UnregisterChannelOption) error {
+
+	defer c.invalidate(chanID)
+
+	return c.ClientDB.UnregisterChannel(chanID, opts...)
+}
+
+// ImportClientState imports a client state archive into the database,
+// invalidating the entire cache.
+func (c *CachedDB) ImportClientState(r io.Reader,
+	opts ...ClientStateImportOption) error {
+
+	defer c.invalidateAll()
+
+	return c.ClientDB.ImportClientState(r, opts...)
+}
+
+// put adds the summary of the given channel to the cache, evicting the least
+// recently used summary if the cache is full.
+//
+// NOTE: This method must be called with the mutex held.
+func (c *CachedDB) put(chanID lnwire.ChannelID, summary ClientChanSummary) {
+	summary = copyChanSummary(summary)
+
+	if e, ok := c.summaries[chanID]; ok {
+		e.Value.(*cachedChanSummary).summary = summary
+		c.lru.MoveToFront(e)
+
+		return
+	}
+
+	if c.maxEntries <= 0 {
+		return
+	}
+
+	for c.lru.Len() >= c.maxEntries {
+		oldest := c.lru.Remove(c.lru.Back()).(*cachedChanSummary)
+		delete(c.summaries, oldest.chanID)
+
+		// The evicted channel is still registered, so the cache no
+		// longer holds every registered channel.
+		c.complete = false
+	}
+
+	c.summaries[chanID] = c.lru.PushFront(&cachedChanSummary{
+		chanID:  chanID,
+		summary: summary,
+	})
+}
+
+// invalidate removes the summaries of the given channels from the cache.
+// Reads of the database that began before the invalidation won't populate the
+// cache once they complete.
+func (c *CachedDB) invalidate(chanIDs ...lnwire.ChannelID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, chanID := range chanIDs {
+		if e, ok := c.summaries[chanID]; ok {
+			c.lru.Remove(e)
+			delete(c.summaries, chanID)
+		}
+	}
+	c.complete = false
+	c.generation++
+}
+
+// invalidateAll removes every summary from the cache.
+func (c *CachedDB) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.summaries = make(map[lnwire.ChannelID]*list.Element)
+	c.lru.Init()
+	c.complete = false
+	c.generation++
+}
+
+// copyChanSummary returns a deep copy of the given summary, such that callers
+// can't modify the summaries held by the cache.
+func copyChanSummary(summary ClientChanSummary) ClientChanSummary {
+	if summary.SweepPkScript != nil {
+		pkScript := make([]byte, len(summary.SweepPkScript))
+		copy(pkScript, summary.SweepPkScript)
+		summary.SweepPkScript = pkScript
+	}
+
+	if summary.SweepPkScripts != nil {
+		pkScripts := make(
+			map[string][]byte, len(summary.SweepPkScripts),
+		)
+		for kind, pkScript := range summary.SweepPkScripts {
+			pkScripts[kind] = append([]byte(nil), pkScript...)
+		}
+		summary.SweepPkScripts = pkScripts
+	}
+
+	return summary
+}
@@ -0,0 +1,74 @@
+//go:build kvdb_postgres
+// +build kvdb_postgres
+
+package wtdb_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/watchtower/wtclient"
+	"github.com/lightningnetwork/lnd/watchtower/wtdb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain starts an embedded postgres instance that is shared by all of the
+// package's tests.
+func TestMain(m *testing.M) {
+	stop, err := kvdb.StartEmbeddedPostgres()
+	if err != nil {
+		fmt.Printf("unable to start embedded postgres: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	if err := stop(); err != nil {
+		fmt.Printf("unable to stop embedded postgres: %v\n", err)
+	}
+
+	os.Exit(code)
+}
+
+// TestClientDBPostgres runs the full client database test suite against a
+// postgres backed kvdb.Backend.
+//
+// NOTE: None of the client database's queries had to change to support
+// postgres. The only ordering the client database relies upon is the
+// byte-wise ordering of keys during ForEach and cursor iteration, which the
+// postgres backend provides by ordering all of its queries by key.
+func TestClientDBPostgres(t *testing.T) {
+	for _, test := range clientDBTests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			h := newClientDBHarness(t, newPostgresClientDB)
+
+			test.run(h)
+		})
+	}
+}
+
+// newPostgresClientDB opens a client database backed by a postgres database
+// that is unique to the given test, creating it if it doesn't exist yet. The
+// path is unused, as opening the same test's database again reopens it.
+func newPostgresClientDB(t *testing.T, _ string) wtclient.DB {
+	dbName := sha256.Sum256([]byte(t.Name()))
+	fixture, err := kvdb.NewPostgresFixture(
+		"test_" + hex.EncodeToString(dbName[:]),
+	)
+	require.NoError(t, err)
+
+	db, err := wtdb.OpenClientDB(fixture.DB())
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db
+}
@@ -0,0 +1,25 @@
+package wtdb
+
+import (
+	"github.com/lightningnetwork/lnd/batch"
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+// update executes f within a read/write transaction. If a batch interval is
+// configured, the transaction is scheduled to be combined with those of
+// concurrent callers, in which case f may be run more than once. The reset
+// closure is called before every run of f, so that f only ever leaves the
+// state of its last run behind.
+func (c *ClientDB) update(f func(tx kvdb.RwTx) error, reset func()) error {
+	if c.updateScheduler == nil {
+		return kvdb.Update(c.db, f, reset)
+	}
+
+	r := &batch.Request{
+		Reset:  reset,
+		Update: f,
+	}
+	batch.LazyAdd()(r)
+
+	return c.updateScheduler.Execute(r)
+}
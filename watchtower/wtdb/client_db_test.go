@@ -1,44 +1,112 @@
 package wtdb_test
 
 import (
+	"bytes"
 	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
 	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightningnetwork/lnd/clock"
 	"github.com/lightningnetwork/lnd/kvdb"
 	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/tor"
 	"github.com/lightningnetwork/lnd/watchtower/blob"
 	"github.com/lightningnetwork/lnd/watchtower/wtclient"
 	"github.com/lightningnetwork/lnd/watchtower/wtdb"
 	"github.com/lightningnetwork/lnd/watchtower/wtmock"
 	"github.com/lightningnetwork/lnd/watchtower/wtpolicy"
+	"github.com/lightningnetwork/lnd/watchtower/wtwire"
 	"github.com/stretchr/testify/require"
 )
 
 // pseudoAddr is a fake network address to be used for testing purposes.
 var pseudoAddr = &net.TCPAddr{IP: []byte{0x01, 0x00, 0x00, 0x00}, Port: 9911}
 
+// testSweepFeeRate is the sweep fee rate used by the policies of the sessions
+// created throughout the tests.
+const testSweepFeeRate = wtpolicy.DefaultSweepFeeRate
+
 // clientDBInit is a closure used to initialize a wtclient.DB instance.
-type clientDBInit func(t *testing.T) wtclient.DB
+// Persisted databases are stored under the given path, such that initializing
+// one again with the same path reopens it.
+type clientDBInit func(t *testing.T, path string) wtclient.DB
 
 type clientDBHarness struct {
-	t  *testing.T
-	db wtclient.DB
+	t    *testing.T
+	db   wtclient.DB
+	init clientDBInit
+	path string
 }
 
 func newClientDBHarness(t *testing.T, init clientDBInit) *clientDBHarness {
-	db := init(t)
+	path := t.TempDir()
+	db := init(t, path)
 
 	h := &clientDBHarness{
-		t:  t,
-		db: db,
+		t:    t,
+		db:   db,
+		init: init,
+		path: path,
 	}
 
 	return h
 }
 
+// openBoltClientDB opens the bolt client database stored under path with the
+// given options, creating it if it doesn't exist yet. The database is closed
+// once the test completes.
+func openBoltClientDB(t *testing.T, path string,
+	opts ...wtdb.ClientDBOption) *wtdb.ClientDB {
+
+	t.Helper()
+
+	db, err := wtdb.OpenClientDB(openBoltBackend(t, path), opts...)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db
+}
+
+// openBoltBackend opens the bolt backend of the client database stored under
+// path, creating it if it doesn't exist yet.
+func openBoltBackend(t *testing.T, path string) kvdb.Backend {
+	t.Helper()
+
+	dbCfg := &kvdb.BoltConfig{DBTimeout: kvdb.DefaultDBTimeout}
+	bdb, err := wtdb.NewBoltBackendCreator(true, path, "wtclient.db")(dbCfg)
+	require.NoError(t, err)
+
+	return bdb
+}
+
+// reopen closes the database and opens it again from the same path, such that
+// subsequent assertions are made against its persisted state. Databases that
+// aren't persisted, like the mock, are left untouched.
+func (h *clientDBHarness) reopen() {
+	h.t.Helper()
+
+	closer, ok := h.db.(io.Closer)
+	if !ok {
+		return
+	}
+	require.NoError(h.t, closer.Close())
+
+	h.db = h.init(h.t, h.path)
+}
+
 func (h *clientDBHarness) insertSession(session *wtdb.ClientSession,
 	expErr error) {
 
@@ -59,6 +127,24 @@ func (h *clientDBHarness) listSessions(id *wtdb.TowerID,
 	return sessions
 }
 
+func (h *clientDBHarness) getClientSession(id wtdb.SessionID, expErr error,
+	opts ...wtdb.ClientSessionListOption) *wtdb.ClientSession {
+
+	h.t.Helper()
+
+	session, err := h.db.GetClientSession(id, opts...)
+	require.ErrorIs(h.t, err, expErr)
+
+	return session
+}
+
+func (h *clientDBHarness) deleteSession(id wtdb.SessionID, expErr error) {
+	h.t.Helper()
+
+	err := h.db.DeleteClientSession(id)
+	require.ErrorIs(h.t, err, expErr)
+}
+
 func (h *clientDBHarness) nextKeyIndex(id wtdb.TowerID,
 	blobType blob.Type) uint32 {
 
@@ -80,7 +166,13 @@ func (h *clientDBHarness) createTower(lnAddr *lnwire.NetAddress,
 	require.ErrorIs(h.t, err, expErr)
 	require.NotZero(h.t, tower.ID, "tower id should never be 0")
 
+	// All of the tower's sessions should now be active, apart from any
+	// exhausted ones which can never be reactivated.
 	for _, session := range h.listSessions(&tower.ID) {
+		if session.Status == wtdb.CSessionExhausted {
+			continue
+		}
+
 		require.Equal(h.t, wtdb.CSessionActive, session.Status)
 	}
 
@@ -154,6 +246,17 @@ func (h *clientDBHarness) loadTowerByID(id wtdb.TowerID,
 	return tower
 }
 
+func (h *clientDBHarness) listTowers(
+	opts ...wtdb.TowerListOption) []*wtdb.Tower {
+
+	h.t.Helper()
+
+	towers, err := h.db.ListTowers(opts...)
+	require.NoError(h.t, err)
+
+	return towers
+}
+
 func (h *clientDBHarness) fetchChanSummaries() map[lnwire.ChannelID]wtdb.ClientChanSummary {
 	h.t.Helper()
 
@@ -172,6 +275,15 @@ func (h *clientDBHarness) registerChan(chanID lnwire.ChannelID,
 	require.ErrorIs(h.t, err, expErr)
 }
 
+func (h *clientDBHarness) unregisterChan(chanID lnwire.ChannelID,
+	expErr error) {
+
+	h.t.Helper()
+
+	err := h.db.UnregisterChannel(chanID)
+	require.ErrorIs(h.t, err, expErr)
+}
+
 func (h *clientDBHarness) commitUpdate(id *wtdb.SessionID,
 	update *wtdb.CommittedUpdate, expErr error) uint16 {
 
@@ -180,9 +292,49 @@ func (h *clientDBHarness) commitUpdate(id *wtdb.SessionID,
 	lastApplied, err := h.db.CommitUpdate(id, update)
 	require.ErrorIs(h.t, err, expErr)
 
+	if err == nil {
+		h.recordBlobType(id, update)
+	}
+
 	return lastApplied
 }
 
+func (h *clientDBHarness) commitUpdates(id *wtdb.SessionID,
+	updates []*wtdb.CommittedUpdate, expErr error) []uint16 {
+
+	h.t.Helper()
+
+	lastApplieds, err := h.db.CommitUpdates(id, updates)
+	require.ErrorIs(h.t, err, expErr)
+
+	if err == nil {
+		for _, update := range updates {
+			h.recordBlobType(id, update)
+		}
+	}
+
+	return lastApplieds
+}
+
+// recordBlobType sets the blob type of a successfully committed update to the
+// one recorded by the database, such that the update can be compared against
+// the stored committed updates.
+func (h *clientDBHarness) recordBlobType(id *wtdb.SessionID,
+	update *wtdb.CommittedUpdate) {
+
+	h.t.Helper()
+
+	updates, err := h.db.FetchSessionCommittedUpdates(id)
+	require.NoError(h.t, err)
+
+	for _, stored := range updates {
+		if stored.SeqNum == update.SeqNum {
+			update.BlobType = stored.BlobType
+			return
+		}
+	}
+}
+
 func (h *clientDBHarness) ackUpdate(id *wtdb.SessionID, seqNum uint16,
 	lastApplied uint16, expErr error) {
 
@@ -192,6 +344,15 @@ func (h *clientDBHarness) ackUpdate(id *wtdb.SessionID, seqNum uint16,
 	require.ErrorIs(h.t, err, expErr)
 }
 
+func (h *clientDBHarness) ackUpdates(id *wtdb.SessionID,
+	acks map[uint16]uint16, expErr error) {
+
+	h.t.Helper()
+
+	err := h.db.AckUpdates(id, acks)
+	require.ErrorIs(h.t, err, expErr)
+}
+
 // newTower is a helper function that creates a new tower with a randomly
 // generated public key and inserts it into the client DB.
 func (h *clientDBHarness) newTower() *wtdb.Tower {
@@ -237,7 +398,8 @@ func testCreateClientSession(h *clientDBHarness) {
 			TowerID: tower.ID,
 			Policy: wtpolicy.Policy{
 				TxPolicy: wtpolicy.TxPolicy{
-					BlobType: blobType,
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
 				},
 				MaxUpdates: 100,
 			},
@@ -290,6 +452,131 @@ func testCreateClientSession(h *clientDBHarness) {
 		"reserved after creating session")
 }
 
+// testGetClientSession asserts that a single session can be looked up by its
+// ID, and that the update call-backs are only applied to that session's
+// updates.
+func testGetClientSession(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	tower := h.newTower()
+
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+
+	// Looking up the session before it has been inserted should fail.
+	h.getClientSession(session.ID, wtdb.ErrClientSessionNotFound)
+
+	session.KeyIndex = h.nextKeyIndex(tower.ID, blobType)
+	h.insertSession(session, nil)
+
+	// Insert a second session for the same tower so that we can assert
+	// that its updates don't leak into the lookup of the first.
+	session2 := &wtdb.ClientSession{
+		ClientSessionBody: session.ClientSessionBody,
+		ID:                wtdb.SessionID([33]byte{0x02}),
+	}
+	session2.KeyIndex = h.nextKeyIndex(tower.ID, blobType)
+	h.insertSession(session2, nil)
+
+	update1 := randCommittedUpdate(h.t, 1)
+	update2 := randCommittedUpdate(h.t, 2)
+	h.commitUpdate(&session.ID, update1, nil)
+	h.commitUpdate(&session.ID, update2, nil)
+	h.ackUpdate(&session.ID, 1, 1, nil)
+	h.commitUpdate(&session2.ID, randCommittedUpdate(h.t, 1), nil)
+
+	var (
+		ackedUpdates     = make(map[uint16]wtdb.BackupID)
+		committedUpdates []wtdb.CommittedUpdate
+	)
+	dbSession := h.getClientSession(
+		session.ID, nil,
+		wtdb.WithPerAckedUpdate(perAckedUpdate(ackedUpdates)),
+		wtdb.WithPerCommittedUpdate(func(_ *wtdb.ClientSession,
+			u *wtdb.CommittedUpdate) {
+
+			committedUpdates = append(committedUpdates, *u)
+		}),
+	)
+	require.Equal(h.t, session.ID, dbSession.ID)
+	require.Equal(h.t, session.KeyIndex, dbSession.KeyIndex)
+	require.Equal(h.t, tower.ID, dbSession.Tower.ID)
+
+	checkAckedUpdates(h.t, ackedUpdates, map[uint16]wtdb.BackupID{
+		1: update1.BackupID,
+	})
+	checkCommittedUpdates(h.t, committedUpdates, []wtdb.CommittedUpdate{
+		*update2,
+	})
+}
+
+// testDeleteClientSession asserts that a session can only be deleted once all
+// of its committed updates have been acked, and that deleting it leaves the
+// session's tower intact.
+func testDeleteClientSession(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 1,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+
+	// Deleting a session that doesn't exist should fail.
+	h.deleteSession(session.ID, wtdb.ErrClientSessionNotFound)
+
+	session.KeyIndex = h.nextKeyIndex(tower.ID, blobType)
+	h.insertSession(session, nil)
+
+	// Commit an update to the session. Since it hasn't been acked yet, we
+	// should not be able to delete the session.
+	update := randCommittedUpdate(h.t, 1)
+	h.commitUpdate(&session.ID, update, nil)
+	h.deleteSession(session.ID, wtdb.ErrSessionHasUnackedUpdates)
+
+	// Once the update is acked, the session can be deleted.
+	h.ackUpdate(&session.ID, 1, 1, nil)
+	h.deleteSession(session.ID, nil)
+
+	// The session should no longer be returned by any of the listing
+	// methods, and its updates should be gone.
+	_, ok := h.listSessions(nil)[session.ID]
+	require.False(h.t, ok)
+	require.Empty(h.t, h.listSessions(&tower.ID))
+	h.getClientSession(session.ID, wtdb.ErrClientSessionNotFound)
+	h.fetchSessionCommittedUpdates(
+		&session.ID, wtdb.ErrClientSessionNotFound,
+	)
+
+	// The tower itself should remain.
+	h.loadTowerByID(tower.ID, nil)
+
+	// Deleting the session a second time should fail.
+	h.deleteSession(session.ID, wtdb.ErrClientSessionNotFound)
+}
+
 // testFilterClientSessions asserts that we can correctly filter client sessions
 // for a specific tower.
 func testFilterClientSessions(h *clientDBHarness) {
@@ -307,7 +594,8 @@ func testFilterClientSessions(h *clientDBHarness) {
 				TowerID: tower.ID,
 				Policy: wtpolicy.Policy{
 					TxPolicy: wtpolicy.TxPolicy{
-						BlobType: blobType,
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
 					},
 					MaxUpdates: 100,
 				},
@@ -335,6 +623,159 @@ func testFilterClientSessions(h *clientDBHarness) {
 	}
 }
 
+// testFilterClientSessionsByBlobType asserts that client sessions can be
+// filtered by the blob type of their policy.
+func testFilterClientSessionsByBlobType(h *clientDBHarness) {
+	tower := h.newTower()
+
+	// Create two sessions for each of two different blob types.
+	blobTypes := []blob.Type{
+		blob.TypeAltruistCommit, blob.TypeAltruistAnchorCommit,
+	}
+	typeSessions := make(map[blob.Type][]wtdb.SessionID)
+	for i := 0; i < 4; i++ {
+		blobType := blobTypes[i%len(blobTypes)]
+		keyIndex := h.nextKeyIndex(tower.ID, blobType)
+		sessionID := wtdb.SessionID([33]byte{byte(i)})
+		h.insertSession(&wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+				KeyIndex:       keyIndex,
+			},
+			ID: sessionID,
+		}, nil)
+		typeSessions[blobType] = append(
+			typeSessions[blobType], sessionID,
+		)
+	}
+
+	// Filtering by each blob type should only return the sessions
+	// negotiated for it.
+	for blobType, expectedSessions := range typeSessions {
+		sessions := h.listSessions(
+			nil, wtdb.WithPostEvalFilterBlobType(blobType),
+		)
+		require.Len(h.t, sessions, len(expectedSessions))
+		for _, id := range expectedSessions {
+			require.Contains(h.t, sessions, id)
+			require.Equal(
+				h.t, blobType, sessions[id].Policy.BlobType,
+			)
+		}
+	}
+
+	// A blob type that no session was negotiated for should yield no
+	// sessions, even when combined with a tower filter.
+	sessions := h.listSessions(
+		&tower.ID, wtdb.WithPostEvalFilterBlobType(
+			blob.TypeAltruistTaprootCommit,
+		),
+	)
+	require.Empty(h.t, sessions)
+}
+
+// testPaginateClientSessions asserts that client sessions can be listed in
+// pages ordered by session ID.
+func testPaginateClientSessions(h *clientDBHarness) {
+	const (
+		blobType    = blob.TypeAltruistCommit
+		numSessions = 5
+	)
+
+	// Create two towers and spread the sessions across them, inserting
+	// them out of order.
+	towers := []*wtdb.Tower{h.newTower(), h.newTower()}
+	for _, i := range []byte{3, 1, 5, 2, 4} {
+		tower := towers[i%2]
+		session := &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+				KeyIndex: h.nextKeyIndex(
+					tower.ID, blobType,
+				),
+			},
+			ID: wtdb.SessionID([33]byte{i}),
+		}
+		h.insertSession(session, nil)
+	}
+
+	// paginate fetches all pages of the given size and returns the IDs of
+	// the sessions in each page.
+	paginate := func(tower *wtdb.TowerID, limit int) [][]wtdb.SessionID {
+		h.t.Helper()
+
+		var (
+			pages  [][]wtdb.SessionID
+			offset wtdb.SessionID
+		)
+		for {
+			page := h.listSessions(
+				tower, wtdb.WithPaginate(offset, limit),
+			)
+			require.LessOrEqual(h.t, len(page), limit)
+
+			cursor, ok := wtdb.NextPageCursor(page)
+			if !ok {
+				return pages
+			}
+
+			var ids []wtdb.SessionID
+			for id := range page {
+				ids = append(ids, id)
+			}
+			sort.Slice(ids, func(i, j int) bool {
+				return ids[i][0] < ids[j][0]
+			})
+			pages = append(pages, ids)
+
+			offset = cursor
+		}
+	}
+
+	id := func(i byte) wtdb.SessionID {
+		return wtdb.SessionID([33]byte{i})
+	}
+
+	require.Equal(h.t, [][]wtdb.SessionID{
+		{id(1), id(2)},
+		{id(3), id(4)},
+		{id(5)},
+	}, paginate(nil, 2))
+
+	require.Equal(h.t, [][]wtdb.SessionID{
+		{id(1), id(2), id(3), id(4), id(5)},
+	}, paginate(nil, numSessions))
+
+	// Pagination should also apply when filtering by tower.
+	require.Equal(h.t, [][]wtdb.SessionID{
+		{id(2), id(4)},
+	}, paginate(&towers[0].ID, 2))
+
+	require.Equal(h.t, [][]wtdb.SessionID{
+		{id(1), id(3)},
+		{id(5)},
+	}, paginate(&towers[1].ID, 2))
+
+	// Listing without pagination should still return all sessions.
+	require.Len(h.t, h.listSessions(nil), numSessions)
+}
+
 // testCreateTower asserts the behavior of creating new Tower objects within the
 // database, and that the latest address is always prepended to the list of
 // known addresses for the tower.
@@ -391,69 +832,237 @@ func testCreateTower(h *clientDBHarness) {
 	require.Equal(h.t, tower.Addresses, towerNewAddr.Addresses[1:])
 }
 
-// testRemoveTower asserts the behavior of removing Tower objects as a whole and
-// removing addresses from Tower objects within the database.
-func testRemoveTower(h *clientDBHarness) {
-	// Generate a random public key we'll use for our tower.
-	pk, err := randPubKey()
-	require.NoError(h.t, err)
-
-	// Removing a tower that does not exist within the database should
-	// result in a NOP.
-	h.removeTower(pk, nil, false, nil)
-
-	// We'll create a tower with two addresses.
-	addr1 := &net.TCPAddr{IP: []byte{0x01, 0x00, 0x00, 0x00}, Port: 9911}
-	addr2 := &net.TCPAddr{IP: []byte{0x02, 0x00, 0x00, 0x00}, Port: 9911}
-	h.createTower(&lnwire.NetAddress{
-		IdentityKey: pk,
-		Address:     addr1,
-	}, nil)
-	h.createTower(&lnwire.NetAddress{
-		IdentityKey: pk,
-		Address:     addr2,
-	}, nil)
-
-	// We'll then remove the second address. We should now only see the
-	// first.
-	h.removeTower(pk, addr2, false, nil)
-
-	// We'll then remove the first address. We should now see that the tower
-	// has no addresses left.
-	h.removeTower(pk, addr1, false, wtdb.ErrLastTowerAddr)
+// testListTowers asserts the behavior of listing all towers, optionally
+// filtering out those without any active sessions.
+func testListTowers(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
 
-	// Removing the tower as a whole from the database should succeed since
-	// there aren't any active sessions for it.
-	h.removeTower(pk, nil, false, nil)
+	// Initially, there should be no towers.
+	require.Empty(h.t, h.listTowers())
+	require.Empty(h.t, h.listTowers(wtdb.WithOnlyActiveTowers()))
+
+	// Create a few towers and assert that they are all listed in order of
+	// their tower ID.
+	tower1 := h.newTower()
+	tower2 := h.newTower()
+	tower3 := h.newTower()
+	require.Equal(
+		h.t, []*wtdb.Tower{tower1, tower2, tower3}, h.listTowers(),
+	)
 
-	// We'll then recreate the tower, but this time we'll create a session
-	// for it.
-	tower := h.createTower(&lnwire.NetAddress{
-		IdentityKey: pk,
-		Address:     addr1,
-	}, nil)
+	// None of the towers have any sessions, so none of them are active.
+	require.Empty(h.t, h.listTowers(wtdb.WithOnlyActiveTowers()))
 
-	const blobType = blob.TypeAltruistCommit
+	// Create a session for the second tower, which should now be the only
+	// active one.
 	session := &wtdb.ClientSession{
 		ClientSessionBody: wtdb.ClientSessionBody{
-			TowerID: tower.ID,
+			TowerID: tower2.ID,
 			Policy: wtpolicy.Policy{
 				TxPolicy: wtpolicy.TxPolicy{
-					BlobType: blobType,
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
 				},
 				MaxUpdates: 100,
 			},
 			RewardPkScript: []byte{0x01, 0x02, 0x03},
-			KeyIndex:       h.nextKeyIndex(tower.ID, blobType),
+			KeyIndex:       h.nextKeyIndex(tower2.ID, blobType),
 		},
 		ID: wtdb.SessionID([33]byte{0x01}),
 	}
 	h.insertSession(session, nil)
-	update := randCommittedUpdate(h.t, 1)
-	h.commitUpdate(&session.ID, update, nil)
+	require.Equal(
+		h.t, []*wtdb.Tower{tower2},
+		h.listTowers(wtdb.WithOnlyActiveTowers()),
+	)
 
-	// We should not be able to fully remove it from the database since
-	// there's a session and it has unacked updates.
+	// Removing the first tower should remove it from the list entirely.
+	h.removeTower(tower1.IdentityKey, nil, false, nil)
+	require.Equal(h.t, []*wtdb.Tower{tower2, tower3}, h.listTowers())
+
+	// Removing the second tower should mark its session inactive, so it
+	// should still be listed but no longer reported as active. Doing so
+	// modifies the tower, so reload it before comparing.
+	h.removeTower(tower2.IdentityKey, nil, true, nil)
+	tower2 = h.loadTowerByID(tower2.ID, nil)
+	require.Equal(h.t, []*wtdb.Tower{tower2, tower3}, h.listTowers())
+	require.Empty(h.t, h.listTowers(wtdb.WithOnlyActiveTowers()))
+}
+
+// testMarkTowerStatus asserts that a tower's sessions can be toggled between
+// active and inactive without affecting the tower record itself.
+func testMarkTowerStatus(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	// Marking an unknown tower should fail.
+	pk, err := randPubKey()
+	require.NoError(h.t, err)
+	require.ErrorIs(
+		h.t, h.db.MarkTowerInactive(pk), wtdb.ErrTowerNotFound,
+	)
+	require.ErrorIs(h.t, h.db.MarkTowerActive(pk), wtdb.ErrTowerNotFound)
+
+	// Create a tower with two sessions, one of which has an unacked
+	// update.
+	tower := h.newTower()
+	for i := byte(1); i <= 2; i++ {
+		session := &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+				KeyIndex: h.nextKeyIndex(
+					tower.ID, blobType,
+				),
+			},
+			ID: wtdb.SessionID([33]byte{i}),
+		}
+		h.insertSession(session, nil)
+	}
+	h.commitUpdate(
+		&wtdb.SessionID{0x01}, randCommittedUpdate(h.t, 1), nil,
+	)
+
+	assertStatus := func(status wtdb.CSessionStatus) {
+		h.t.Helper()
+
+		sessions := h.listSessions(&tower.ID)
+		require.Len(h.t, sessions, 2)
+		for _, session := range sessions {
+			require.Equal(h.t, status, session.Status)
+		}
+	}
+	assertStatus(wtdb.CSessionActive)
+
+	// Marking the tower inactive should flip both sessions, even though
+	// one of them has an unacked update, and leave the tower untouched.
+	require.NoError(h.t, h.db.MarkTowerInactive(tower.IdentityKey))
+	assertStatus(wtdb.CSessionInactive)
+	require.Equal(h.t, tower, h.loadTower(tower.IdentityKey, nil))
+
+	// Marking the tower active again should restore both sessions.
+	require.NoError(h.t, h.db.MarkTowerActive(tower.IdentityKey))
+	assertStatus(wtdb.CSessionActive)
+	require.Equal(h.t, tower, h.loadTower(tower.IdentityKey, nil))
+}
+
+// testTowerNickname asserts that a tower's nickname can be set on creation and
+// updated later on.
+func testTowerNickname(h *clientDBHarness) {
+	// Setting the nickname of an unknown tower should fail.
+	err := h.db.SetTowerNickname(20, "unknown")
+	require.ErrorIs(h.t, err, wtdb.ErrTowerNotFound)
+
+	pk, err := randPubKey()
+	require.NoError(h.t, err)
+
+	lnAddr := &lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     pseudoAddr,
+	}
+
+	tower, err := h.db.CreateTower(
+		lnAddr, wtdb.WithTowerNickname("aws-us-east"),
+	)
+	require.NoError(h.t, err)
+	require.Equal(h.t, "aws-us-east", tower.Nickname)
+	require.Equal(h.t, tower, h.loadTower(pk, nil))
+
+	// Re-creating the tower without a nickname should leave the existing
+	// nickname untouched.
+	tower = h.createTower(lnAddr, nil)
+	require.Equal(h.t, "aws-us-east", tower.Nickname)
+
+	// Update the nickname and assert that it is reflected when loading the
+	// tower.
+	err = h.db.SetTowerNickname(tower.ID, "backup-friend")
+	require.NoError(h.t, err)
+	require.Equal(
+		h.t, "backup-friend", h.loadTowerByID(tower.ID, nil).Nickname,
+	)
+
+	// The nickname should survive a restart of the database.
+	h.reopen()
+	require.Equal(
+		h.t, "backup-friend", h.loadTowerByID(tower.ID, nil).Nickname,
+	)
+
+	// Finally, clear the nickname.
+	err = h.db.SetTowerNickname(tower.ID, "")
+	require.NoError(h.t, err)
+	require.Empty(h.t, h.loadTowerByID(tower.ID, nil).Nickname)
+}
+
+// testRemoveTower asserts the behavior of removing Tower objects as a whole and
+// removing addresses from Tower objects within the database.
+func testRemoveTower(h *clientDBHarness) {
+	// Generate a random public key we'll use for our tower.
+	pk, err := randPubKey()
+	require.NoError(h.t, err)
+
+	// Removing a tower that does not exist within the database should
+	// result in a NOP.
+	h.removeTower(pk, nil, false, nil)
+
+	// We'll create a tower with two addresses.
+	addr1 := &net.TCPAddr{IP: []byte{0x01, 0x00, 0x00, 0x00}, Port: 9911}
+	addr2 := &net.TCPAddr{IP: []byte{0x02, 0x00, 0x00, 0x00}, Port: 9911}
+	h.createTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     addr1,
+	}, nil)
+	h.createTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     addr2,
+	}, nil)
+
+	// We'll then remove the second address. We should now only see the
+	// first.
+	h.removeTower(pk, addr2, false, nil)
+
+	// We'll then remove the first address. We should now see that the tower
+	// has no addresses left.
+	h.removeTower(pk, addr1, false, wtdb.ErrLastTowerAddr)
+
+	// Removing the tower as a whole from the database should succeed since
+	// there aren't any active sessions for it.
+	h.removeTower(pk, nil, false, nil)
+
+	// We'll then recreate the tower, but this time we'll create a session
+	// for it.
+	tower := h.createTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     addr1,
+	}, nil)
+
+	const blobType = blob.TypeAltruistCommit
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+			KeyIndex:       h.nextKeyIndex(tower.ID, blobType),
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	h.insertSession(session, nil)
+	update := randCommittedUpdate(h.t, 1)
+	h.commitUpdate(&session.ID, update, nil)
+
+	// We should not be able to fully remove it from the database since
+	// there's a session and it has unacked updates.
 	h.removeTower(pk, nil, true, wtdb.ErrTowerUnackedUpdates)
 
 	// Removing the tower after all sessions no longer have unacked updates
@@ -492,9 +1101,114 @@ func testChanSummaries(h *clientDBHarness) {
 		chanID)
 	require.Equal(h.t, expPkScript, summary.SweepPkScript)
 
-	// Finally, assert that re-registering the same channel produces a
-	// failure.
-	h.registerChan(chanID, expPkScript, wtdb.ErrChannelAlreadyRegistered)
+	// Re-registering the channel with the same pkscript should succeed
+	// without modifying its summary.
+	h.registerChan(chanID, expPkScript, nil)
+	summary = h.fetchChanSummaries()[chanID]
+	require.Equal(h.t, expPkScript, summary.SweepPkScript)
+
+	// Finally, assert that re-registering the same channel with a
+	// different pkscript produces a failure.
+	otherPkScript := append([]byte{0x00}, expPkScript...)
+	h.registerChan(
+		chanID, otherPkScript, wtdb.ErrChannelAlreadyRegistered,
+	)
+
+	summary = h.fetchChanSummaries()[chanID]
+	require.Equal(h.t, expPkScript, summary.SweepPkScript)
+}
+
+// testFetchChanSummariesForChannels asserts that the summaries of a set of
+// channels can be fetched, omitting the channels that aren't registered.
+func testFetchChanSummariesForChannels(h *clientDBHarness) {
+	var (
+		chanA = lnwire.ChannelID{0x01}
+		chanB = lnwire.ChannelID{0x02}
+		chanC = lnwire.ChannelID{0x03}
+		chanD = lnwire.ChannelID{0x04}
+	)
+
+	h.registerChan(chanA, []byte{0x0a}, nil)
+	h.registerChan(chanB, []byte{0x0b}, nil)
+	h.registerChan(chanC, []byte{0x0c}, nil)
+
+	fetch := func(chanIDs ...lnwire.ChannelID) wtdb.ChannelSummaries {
+		h.t.Helper()
+
+		summaries, err := h.db.FetchChanSummariesForChannels(chanIDs)
+		require.NoError(h.t, err)
+
+		return summaries
+	}
+
+	// Requesting a mix of registered and unregistered channels should
+	// only return the registered ones.
+	require.Equal(h.t, wtdb.ChannelSummaries{
+		chanA: {
+			SweepPkScript: []byte{0x0a},
+			Priority:      wtdb.DefaultChannelPriority,
+		},
+		chanC: {
+			SweepPkScript: []byte{0x0c},
+			Priority:      wtdb.DefaultChannelPriority,
+		},
+	}, fetch(chanA, chanC, chanD))
+
+	// Requesting only unregistered channels, or none at all, results in
+	// an empty set of summaries.
+	require.Empty(h.t, fetch(chanD))
+	require.Empty(h.t, fetch())
+}
+
+// testUnregisterChannel asserts that a channel's summary can be removed, even
+// if backups for the channel have already been committed to a session.
+func testUnregisterChannel(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	// Unregistering a channel that was never registered should fail.
+	var chanID lnwire.ChannelID
+	h.unregisterChan(chanID, wtdb.ErrChannelNotRegistered)
+
+	h.registerChan(chanID, []byte{0x01, 0x02}, nil)
+	_, ok := h.fetchChanSummaries()[chanID]
+	require.True(h.t, ok)
+
+	// Commit an update for the channel to a session so that the session
+	// still references it after it is unregistered.
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	session.KeyIndex = h.nextKeyIndex(session.TowerID, blobType)
+	h.insertSession(session, nil)
+
+	update := randCommittedUpdate(h.t, 1)
+	update.BackupID.ChanID = chanID
+	h.commitUpdate(&session.ID, update, nil)
+
+	// Unregistering the channel should succeed and remove its summary.
+	h.unregisterChan(chanID, nil)
+	_, ok = h.fetchChanSummaries()[chanID]
+	require.False(h.t, ok)
+
+	// The session's committed update should be unaffected.
+	h.assertUpdates(session.ID, []wtdb.CommittedUpdate{*update}, nil)
+
+	// A second attempt should fail since the channel is no longer
+	// registered, but the channel can be registered again.
+	h.unregisterChan(chanID, wtdb.ErrChannelNotRegistered)
+	h.registerChan(chanID, []byte{0x03}, nil)
 }
 
 // testCommitUpdate tests the behavior of CommitUpdate, ensuring that they can
@@ -507,7 +1221,8 @@ func testCommitUpdate(h *clientDBHarness) {
 			TowerID: tower.ID,
 			Policy: wtpolicy.Policy{
 				TxPolicy: wtpolicy.TxPolicy{
-					BlobType: blobType,
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
 				},
 				MaxUpdates: 100,
 			},
@@ -580,282 +1295,5995 @@ func testCommitUpdate(h *clientDBHarness) {
 	}, nil)
 }
 
-func perAckedUpdate(updates map[uint16]wtdb.BackupID) func(
-	_ *wtdb.ClientSession, seq uint16, id wtdb.BackupID) {
-
-	return func(_ *wtdb.ClientSession, seq uint16,
-		id wtdb.BackupID) {
-
-		updates[seq] = id
-	}
-}
-
-// testAckUpdate asserts the behavior of AckUpdate.
-func testAckUpdate(h *clientDBHarness) {
+// testCommitUpdates asserts the behavior of CommitUpdates, ensuring that a
+// batch of updates is either committed in its entirety or not at all.
+func testCommitUpdates(h *clientDBHarness) {
 	const blobType = blob.TypeAltruistCommit
 
 	tower := h.newTower()
-
-	// Create a new session that the updates in this will be tied to.
 	session := &wtdb.ClientSession{
 		ClientSessionBody: wtdb.ClientSessionBody{
 			TowerID: tower.ID,
 			Policy: wtpolicy.Policy{
 				TxPolicy: wtpolicy.TxPolicy{
-					BlobType: blobType,
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
 				},
 				MaxUpdates: 100,
 			},
 			RewardPkScript: []byte{0x01, 0x02, 0x03},
 		},
-		ID: wtdb.SessionID([33]byte{0x03}),
+		ID: wtdb.SessionID([33]byte{0x02}),
 	}
 
-	// Try to ack an update before inserting the client session, which
-	// should fail.
-	h.ackUpdate(&session.ID, 1, 0, wtdb.ErrClientSessionNotFound)
+	update1 := randCommittedUpdate(h.t, 1)
+	update2 := randCommittedUpdate(h.t, 2)
+	update3 := randCommittedUpdate(h.t, 3)
+
+	// Committing a batch before the session exists should fail.
+	h.commitUpdates(
+		&session.ID, []*wtdb.CommittedUpdate{update1},
+		wtdb.ErrClientSessionNotFound,
+	)
 
-	// Reserve a session key and insert the client session.
 	session.KeyIndex = h.nextKeyIndex(session.TowerID, blobType)
 	h.insertSession(session, nil)
 
-	// Now, try to ack update 1. This should fail since update 1 was never
-	// committed.
-	h.ackUpdate(&session.ID, 1, 0, wtdb.ErrCommittedUpdateNotFound)
-
-	// Commit to a random update at seqnum 1.
-	update1 := randCommittedUpdate(h.t, 1)
-	lastApplied := h.commitUpdate(&session.ID, update1, nil)
-	require.Zero(h.t, lastApplied)
-
-	// Acking seqnum 1 should succeed.
-	h.ackUpdate(&session.ID, 1, 1, nil)
+	// Commit the first three updates in a single batch. Since the tower
+	// hasn't acked anything yet, the last applied values should all be 0.
+	lastApplieds := h.commitUpdates(
+		&session.ID, []*wtdb.CommittedUpdate{update1, update2, update3},
+		nil,
+	)
+	require.Equal(h.t, []uint16{0, 0, 0}, lastApplieds)
+	h.assertUpdates(session.ID, []wtdb.CommittedUpdate{
+		*update1, *update2, *update3,
+	}, nil)
 
-	// Acking seqnum 1 again should fail.
-	h.ackUpdate(&session.ID, 1, 1, wtdb.ErrCommittedUpdateNotFound)
+	// Now try to commit a batch that re-commits update 3, which is a
+	// no-op, followed by update 4 and then an update that skips seqnum 5.
+	// The whole batch should be rejected, leaving update 4 uncommitted.
+	update4 := randCommittedUpdate(h.t, 4)
+	update6 := randCommittedUpdate(h.t, 6)
+	h.commitUpdates(
+		&session.ID, []*wtdb.CommittedUpdate{update3, update4, update6},
+		wtdb.ErrCommitUnorderedUpdate,
+	)
+	h.assertUpdates(session.ID, []wtdb.CommittedUpdate{
+		*update1, *update2, *update3,
+	}, nil)
 
-	// Acking a valid seqnum with a reverted last applied value should fail.
-	h.ackUpdate(&session.ID, 1, 0, wtdb.ErrLastAppliedReversion)
+	// Similarly, a batch containing an update that conflicts with an
+	// existing update's hint should be rejected in its entirety.
+	conflict := randCommittedUpdate(h.t, 2)
+	h.commitUpdates(
+		&session.ID, []*wtdb.CommittedUpdate{update4, conflict},
+		wtdb.ErrUpdateAlreadyCommitted,
+	)
+	h.assertUpdates(session.ID, []wtdb.CommittedUpdate{
+		*update1, *update2, *update3,
+	}, nil)
 
-	// Acking with a last applied greater than any allocated seqnum should
-	// fail.
-	h.ackUpdate(&session.ID, 4, 3, wtdb.ErrUnallocatedLastApplied)
+	// After acking the first update, committing a valid batch should
+	// succeed and report the new last applied value for each update.
+	h.ackUpdate(&session.ID, 1, 1, nil)
 
-	// Assert that the ClientSession loaded from disk has one update in it's
-	// AckedUpdates map, and that the committed update has been removed.
-	h.assertUpdates(session.ID, nil, map[uint16]wtdb.BackupID{
+	update5 := randCommittedUpdate(h.t, 5)
+	lastApplieds = h.commitUpdates(
+		&session.ID, []*wtdb.CommittedUpdate{update4, update5}, nil,
+	)
+	require.Equal(h.t, []uint16{1, 1}, lastApplieds)
+	h.assertUpdates(session.ID, []wtdb.CommittedUpdate{
+		*update2, *update3, *update4, *update5,
+	}, map[uint16]wtdb.BackupID{
 		1: update1.BackupID,
 	})
+}
 
-	// Commit to another random update, and assert that the last applied
-	// value is 1, since this was what was provided in the last successful
-	// ack.
-	update2 := randCommittedUpdate(h.t, 2)
-	lastApplied = h.commitUpdate(&session.ID, update2, nil)
-	require.EqualValues(h.t, 1, lastApplied)
+// testSessionExhaustion asserts that a session is marked as exhausted once all
+// of its sequence numbers have been committed, and that exhausted sessions can
+// be filtered out when listing sessions.
+func testSessionExhaustion(h *clientDBHarness) {
+	const (
+		blobType   = blob.TypeAltruistCommit
+		maxUpdates = 3
+	)
 
-	// Ack seqnum 2.
-	h.ackUpdate(&session.ID, 2, 2, nil)
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: maxUpdates,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+			KeyIndex:       h.nextKeyIndex(tower.ID, blobType),
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	h.insertSession(session, nil)
 
-	// Assert that both updates exist as AckedUpdates when loaded from disk.
-	h.assertUpdates(session.ID, nil, map[uint16]wtdb.BackupID{
-		1: update1.BackupID,
-		2: update2.BackupID,
-	})
+	assertStatus := func(status wtdb.CSessionStatus) {
+		h.t.Helper()
 
-	// Acking again with a lower last applied should fail.
-	h.ackUpdate(&session.ID, 2, 1, wtdb.ErrLastAppliedReversion)
+		s := h.getClientSession(session.ID, nil)
+		require.Equal(h.t, status, s.Status)
+	}
 
-	// Acking an unallocated seqnum should fail.
-	h.ackUpdate(&session.ID, 4, 2, wtdb.ErrCommittedUpdateNotFound)
+	// The session should remain active until its final sequence number
+	// has been committed.
+	for i := uint16(1); i < maxUpdates; i++ {
+		h.commitUpdate(&session.ID, randCommittedUpdate(h.t, i), nil)
+		assertStatus(wtdb.CSessionActive)
+	}
 
-	// Acking with a last applied greater than any allocated seqnum should
-	// fail.
-	h.ackUpdate(&session.ID, 4, 3, wtdb.ErrUnallocatedLastApplied)
-}
+	update := randCommittedUpdate(h.t, maxUpdates)
+	h.commitUpdate(&session.ID, update, nil)
+	assertStatus(wtdb.CSessionExhausted)
 
-func (h *clientDBHarness) assertUpdates(id wtdb.SessionID,
-	expectedPending []wtdb.CommittedUpdate,
+	// Re-committing the final update should leave the session exhausted.
+	h.commitUpdate(&session.ID, update, nil)
+	assertStatus(wtdb.CSessionExhausted)
+
+	// Filtering for active sessions should skip the exhausted session,
+	// while its committed updates are still passed to any call-backs.
+	var numCommitted int
+	sessions := h.listSessions(
+		nil, wtdb.WithPostEvalFilterStatus(wtdb.CSessionActive),
+		wtdb.WithPerCommittedUpdate(
+			func(_ *wtdb.ClientSession, _ *wtdb.CommittedUpdate) {
+				numCommitted++
+			},
+		),
+	)
+	require.Empty(h.t, sessions)
+	require.Equal(h.t, maxUpdates, numCommitted)
+
+	sessions = h.listSessions(
+		&tower.ID, wtdb.WithPostEvalFilterStatus(
+			wtdb.CSessionActive, wtdb.CSessionExhausted,
+		),
+	)
+	require.Len(h.t, sessions, 1)
+
+	// Toggling the tower's status should not revive the session.
+	require.NoError(h.t, h.db.MarkTowerInactive(tower.IdentityKey))
+	assertStatus(wtdb.CSessionExhausted)
+	require.NoError(h.t, h.db.MarkTowerActive(tower.IdentityKey))
+	assertStatus(wtdb.CSessionExhausted)
+	h.createTower(tower.LNAddrs()[0], nil)
+	assertStatus(wtdb.CSessionExhausted)
+}
+
+// testSessionUpdateCounts asserts that the number of committed and acked
+// updates of a session are reported correctly.
+func testSessionUpdateCounts(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+
+	assertCounts := func(expCommitted, expAcked uint16, expErr error) {
+		h.t.Helper()
+
+		committed, acked, err := h.db.SessionUpdateCounts(session.ID)
+		require.ErrorIs(h.t, err, expErr)
+		require.Equal(h.t, expCommitted, committed)
+		require.Equal(h.t, expAcked, acked)
+	}
+
+	// Querying the counts of an unknown session should fail.
+	assertCounts(0, 0, wtdb.ErrClientSessionNotFound)
+
+	// A freshly inserted session has no updates.
+	session.KeyIndex = h.nextKeyIndex(session.TowerID, blobType)
+	h.insertSession(session, nil)
+	assertCounts(0, 0, nil)
+
+	// Commit three updates.
+	for i := uint16(1); i <= 3; i++ {
+		h.commitUpdate(&session.ID, randCommittedUpdate(h.t, i), nil)
+	}
+	assertCounts(3, 0, nil)
+
+	// Acking updates should move them from the committed to the acked
+	// count.
+	h.ackUpdate(&session.ID, 1, 1, nil)
+	assertCounts(2, 1, nil)
+
+	h.ackUpdate(&session.ID, 3, 3, nil)
+	assertCounts(1, 2, nil)
+}
+
+// testTotalPendingUpdates asserts that the total number of pending updates
+// counts the committed but unacked updates of every session.
+func testTotalPendingUpdates(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	assertTotal := func(expTotal uint64) {
+		h.t.Helper()
+
+		total, err := h.db.TotalPendingUpdates()
+		require.NoError(h.t, err)
+		require.Equal(h.t, expTotal, total)
+	}
+
+	// Initially, there are no pending updates.
+	assertTotal(0)
+
+	// Create two sessions with the same tower.
+	tower := h.newTower()
+	var sessions []*wtdb.ClientSession
+	for i := byte(1); i <= 2; i++ {
+		session := &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+				KeyIndex: h.nextKeyIndex(
+					tower.ID, blobType,
+				),
+			},
+			ID: wtdb.SessionID([33]byte{i}),
+		}
+		h.insertSession(session, nil)
+		sessions = append(sessions, session)
+	}
+	assertTotal(0)
+
+	// Commit three updates to the first session, and two to the second.
+	for i, numUpdates := range []uint16{3, 2} {
+		for seqNum := uint16(1); seqNum <= numUpdates; seqNum++ {
+			h.commitUpdate(
+				&sessions[i].ID,
+				randCommittedUpdate(h.t, seqNum), nil,
+			)
+		}
+	}
+	assertTotal(5)
+
+	// Acking updates of either session should shrink the backlog.
+	h.ackUpdate(&sessions[0].ID, 2, 2, nil)
+	assertTotal(4)
+
+	h.ackUpdate(&sessions[1].ID, 1, 1, nil)
+	h.ackUpdate(&sessions[1].ID, 2, 2, nil)
+	assertTotal(2)
+
+	h.ackUpdate(&sessions[0].ID, 1, 2, nil)
+	h.ackUpdate(&sessions[0].ID, 3, 3, nil)
+	assertTotal(0)
+}
+
+// testRemoveTowerOrphanedChannels asserts that removing a tower with the
+// WithReportOrphanedChannels option reports the registered channels that were
+// only backed up by the removed tower, without unregistering them.
+func testRemoveTowerOrphanedChannels(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	var (
+		chanA = lnwire.ChannelID{0x01}
+		chanB = lnwire.ChannelID{0x02}
+		chanC = lnwire.ChannelID{0x03}
+	)
+
+	// Register channels A and B. Channel C is backed up, but never
+	// registered.
+	h.registerChan(chanA, []byte{0x01}, nil)
+	h.registerChan(chanB, []byte{0x02}, nil)
+
+	newSession := func(tower *wtdb.Tower, id byte) *wtdb.ClientSession {
+		session := &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+				KeyIndex: h.nextKeyIndex(
+					tower.ID, blobType,
+				),
+			},
+			ID: wtdb.SessionID([33]byte{id}),
+		}
+		h.insertSession(session, nil)
+
+		return session
+	}
+
+	backup := func(session *wtdb.ClientSession, seqNum uint16,
+		chanID lnwire.ChannelID) {
+
+		update := randCommittedUpdate(h.t, seqNum)
+		update.BackupID.ChanID = chanID
+		h.commitUpdate(&session.ID, update, nil)
+		h.ackUpdate(&session.ID, seqNum, seqNum, nil)
+	}
+
+	// The first tower is the sole tower backing up channels A and C,
+	// while channel B is also backed up by the second tower.
+	tower1 := h.newTower()
+	session1 := newSession(tower1, 0x01)
+	backup(session1, 1, chanA)
+	backup(session1, 2, chanB)
+	backup(session1, 3, chanC)
+
+	tower2 := h.newTower()
+	session2 := newSession(tower2, 0x02)
+	backup(session2, 1, chanB)
+
+	var (
+		reported bool
+		orphaned []lnwire.ChannelID
+	)
+	report := wtdb.WithReportOrphanedChannels(
+		func(chanIDs []lnwire.ChannelID) {
+			reported = true
+			orphaned = chanIDs
+		},
+	)
+
+	// Removing only an address of the first tower shouldn't report
+	// anything.
+	err := h.db.RemoveTower(tower1.IdentityKey, pseudoAddr, report)
+	require.ErrorIs(h.t, err, wtdb.ErrLastTowerAddr)
+	require.False(h.t, reported)
+
+	// Removing the first tower should only report channel A, since
+	// channel B is still backed up by the second tower and channel C
+	// isn't registered.
+	err = h.db.RemoveTower(tower1.IdentityKey, nil, report)
+	require.NoError(h.t, err)
+	require.True(h.t, reported)
+	require.Equal(h.t, []lnwire.ChannelID{chanA}, orphaned)
+
+	// The orphaned channel should still be registered.
+	summaries := h.fetchChanSummaries()
+	require.Contains(h.t, summaries, chanA)
+
+	// Once the second tower is removed too, channel B is orphaned as
+	// well. Channel A isn't reported again, since it wasn't backed up by
+	// the second tower.
+	err = h.db.RemoveTower(tower2.IdentityKey, nil, report)
+	require.NoError(h.t, err)
+	require.Equal(h.t, []lnwire.ChannelID{chanB}, orphaned)
+}
+
+// testPing asserts that a fresh database can be pinged, and that pinging it
+// doesn't modify its contents.
+func testPing(h *clientDBHarness) {
+	require.NoError(h.t, h.db.Ping())
+
+	stats, err := h.db.DBStats()
+	require.NoError(h.t, err)
+	require.Equal(h.t, wtdb.ClientDBStats{}, stats)
+}
+
+// testSoftDeleteChannel asserts that a soft-deleted channel is hidden from the
+// registered channels while its tombstone is kept, and that its tombstone is
+// only removed once purged.
+func testSoftDeleteChannel(h *clientDBHarness) {
+	var (
+		chanA = lnwire.ChannelID{0x01}
+		chanB = lnwire.ChannelID{0x02}
+		chanC = lnwire.ChannelID{0x03}
+	)
+
+	fetchTombstoned := func() wtdb.ChannelSummaries {
+		h.t.Helper()
+
+		summaries, err := h.db.FetchChanSummaries(wtdb.WithTombstoned())
+		require.NoError(h.t, err)
+
+		return summaries
+	}
+
+	h.registerChan(chanA, []byte{0x01}, nil)
+	h.registerChan(chanB, []byte{0x02}, nil)
+	h.registerChan(chanC, []byte{0x03}, nil)
+
+	// Soft-delete channel A, and hard-delete channel B.
+	err := h.db.UnregisterChannel(chanA, wtdb.WithSoftDelete())
+	require.NoError(h.t, err)
+	h.unregisterChan(chanB, nil)
+
+	// Channel A is no longer registered, so it's hidden by default and
+	// can't be unregistered again.
+	summaries := h.fetchChanSummaries()
+	require.Len(h.t, summaries, 1)
+	require.Contains(h.t, summaries, chanC)
+	require.True(h.t, summaries[chanC].DeletedAt.IsZero())
+
+	summaries, err = h.db.FetchChanSummariesForChannels(
+		[]lnwire.ChannelID{chanA},
+	)
+	require.NoError(h.t, err)
+	require.Empty(h.t, summaries)
+
+	err = h.db.UnregisterChannel(chanA, wtdb.WithSoftDelete())
+	require.ErrorIs(h.t, err, wtdb.ErrChannelNotRegistered)
+
+	// Including the tombstoned channels should reveal channel A along
+	// with the time of its deletion, but not the hard-deleted channel B.
+	summaries = fetchTombstoned()
+	require.Len(h.t, summaries, 2)
+	require.Contains(h.t, summaries, chanC)
+	require.Contains(h.t, summaries, chanA)
+	require.Equal(h.t, []byte{0x01}, summaries[chanA].SweepPkScript)
+
+	deletedAt := summaries[chanA].DeletedAt
+	require.False(h.t, deletedAt.IsZero())
+
+	// Purging the tombstones deleted before channel A shouldn't remove
+	// it.
+	require.NoError(h.t, h.db.PurgeTombstones(deletedAt))
+	require.Contains(h.t, fetchTombstoned(), chanA)
+
+	// Once purged, channel A should be gone entirely.
+	require.NoError(h.t, h.db.PurgeTombstones(deletedAt.Add(time.Second)))
+	summaries = fetchTombstoned()
+	require.Len(h.t, summaries, 1)
+	require.Contains(h.t, summaries, chanC)
+}
+
+// testReleaseReservedKeyIndex asserts that reserved session key indexes can be
+// listed and released, that a released index is handed out again by the next
+// reservation, and that indexes finalized by a session can't be released.
+func testReleaseReservedKeyIndex(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	tower := h.newTower()
+
+	listReserved := func() []uint32 {
+		h.t.Helper()
+
+		indexes, err := h.db.ListReservedKeyIndices(tower.ID, blobType)
+		require.NoError(h.t, err)
+
+		return indexes
+	}
+
+	// Initially, no indexes are reserved.
+	require.Empty(h.t, listReserved())
+
+	indexes, err := h.db.ReserveSessionKeyIndices(tower.ID, blobType, 3)
+	require.NoError(h.t, err)
+	require.Len(h.t, indexes, 3)
+	require.Equal(h.t, indexes, listReserved())
+
+	// Release the second index, which should no longer be listed, and
+	// can't be released twice.
+	err = h.db.ReleaseReservedKeyIndex(tower.ID, blobType, indexes[1])
+	require.NoError(h.t, err)
+	require.Equal(
+		h.t, []uint32{indexes[0], indexes[2]}, listReserved(),
+	)
+
+	err = h.db.ReleaseReservedKeyIndex(tower.ID, blobType, indexes[1])
+	require.ErrorIs(h.t, err, wtdb.ErrNoReservedKeyIndex)
+
+	// Reserving three indexes again should hand out the released index
+	// instead of a new one.
+	reserved, err := h.db.ReserveSessionKeyIndices(tower.ID, blobType, 3)
+	require.NoError(h.t, err)
+	require.Equal(
+		h.t, []uint32{indexes[0], indexes[2], indexes[1]}, reserved,
+	)
+
+	// Once a session is created with an index, it can't be released.
+	h.insertSession(&wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			KeyIndex: indexes[0],
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}, nil)
+
+	err = h.db.ReleaseReservedKeyIndex(tower.ID, blobType, indexes[0])
+	require.ErrorIs(h.t, err, wtdb.ErrKeyIndexFinalized)
+	require.Equal(
+		h.t, []uint32{indexes[2], indexes[1]}, listReserved(),
+	)
+}
+
+// testReservedKeyIndexTowerIsolation asserts that the session key indexes
+// reserved or released by one tower can't be listed or released through
+// another, and are never handed out to it.
+func testReservedKeyIndexTowerIsolation(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	// Both towers have IDs below 2^32.
+	tower1 := h.newTower()
+	tower2 := h.newTower()
+	require.Less(h.t, uint64(tower1.ID), uint64(1<<32))
+	require.Less(h.t, uint64(tower2.ID), uint64(1<<32))
+
+	listReserved := func(towerID wtdb.TowerID) []uint32 {
+		h.t.Helper()
+
+		indexes, err := h.db.ListReservedKeyIndices(towerID, blobType)
+		require.NoError(h.t, err)
+
+		return indexes
+	}
+
+	indexes1, err := h.db.ReserveSessionKeyIndices(tower1.ID, blobType, 2)
+	require.NoError(h.t, err)
+	require.Equal(h.t, indexes1, listReserved(tower1.ID))
+
+	// The first tower's reservations shouldn't be visible to the second.
+	require.Empty(h.t, listReserved(tower2.ID))
+
+	// Nor should the second tower be able to release them.
+	err = h.db.ReleaseReservedKeyIndex(tower2.ID, blobType, indexes1[0])
+	require.ErrorIs(h.t, err, wtdb.ErrNoReservedKeyIndex)
+	require.Equal(h.t, indexes1, listReserved(tower1.ID))
+
+	// Indexes reserved for the second tower should be distinct from those
+	// of the first.
+	indexes2, err := h.db.ReserveSessionKeyIndices(tower2.ID, blobType, 2)
+	require.NoError(h.t, err)
+	for _, index := range indexes2 {
+		require.NotContains(h.t, indexes1, index)
+	}
+	require.NotContains(
+		h.t, indexes1, h.nextKeyIndex(tower2.ID, blobType),
+	)
+
+	require.Equal(h.t, indexes1, listReserved(tower1.ID))
+	require.Equal(h.t, indexes2, listReserved(tower2.ID))
+
+	// Releasing one of the first tower's indexes should leave the second
+	// tower's reservations untouched.
+	err = h.db.ReleaseReservedKeyIndex(tower1.ID, blobType, indexes1[0])
+	require.NoError(h.t, err)
+	require.Equal(h.t, indexes1[1:], listReserved(tower1.ID))
+	require.Equal(h.t, indexes2, listReserved(tower2.ID))
+
+	// The released index must not be handed to the second tower, as its
+	// session key may already have been revealed to the first.
+	reserved2, err := h.db.ReserveSessionKeyIndices(tower2.ID, blobType, 3)
+	require.NoError(h.t, err)
+	require.Equal(h.t, indexes2, reserved2[:2])
+	require.NotContains(h.t, reserved2, indexes1[0])
+
+	// Nor to the first tower under a different blob type.
+	reserved, err := h.db.ReserveSessionKeyIndices(
+		tower1.ID, blob.TypeRewardCommit, 1,
+	)
+	require.NoError(h.t, err)
+	require.NotContains(h.t, reserved, indexes1[0])
+
+	// Only the first tower and blob type should get the index back.
+	reserved, err = h.db.ReserveSessionKeyIndices(tower1.ID, blobType, 2)
+	require.NoError(h.t, err)
+	require.Equal(h.t, []uint32{indexes1[1], indexes1[0]}, reserved)
+}
+
+// testTowerBackoff asserts that the backoff recorded for a tower is returned on
+// the loaded tower, and that zero values clear it.
+func testTowerBackoff(h *clientDBHarness) {
+	// Setting the backoff of an unknown tower should fail.
+	err := h.db.SetTowerBackoff(100, time.Now(), 1)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerNotFound)
+
+	// A fresh tower can be connected to immediately.
+	tower := h.newTower()
+	require.Zero(h.t, h.loadTowerByID(tower.ID, nil).Backoff)
+
+	nextAttempt := time.Unix(0, time.Now().Add(time.Minute).UnixNano())
+	require.NoError(h.t, h.db.SetTowerBackoff(tower.ID, nextAttempt, 3))
+
+	expBackoff := wtdb.TowerBackoff{
+		NextAttempt: nextAttempt,
+		Attempts:    3,
+	}
+	assertBackoff := func() {
+		h.t.Helper()
+
+		for _, loaded := range []*wtdb.Tower{
+			h.loadTowerByID(tower.ID, nil),
+			h.loadTower(tower.IdentityKey, nil),
+		} {
+			require.True(
+				h.t, expBackoff.NextAttempt.Equal(
+					loaded.Backoff.NextAttempt,
+				),
+			)
+			require.Equal(
+				h.t, expBackoff.Attempts,
+				loaded.Backoff.Attempts,
+			)
+		}
+	}
+	assertBackoff()
+
+	// The backoff should survive a restart of the database, allowing its
+	// schedule to be resumed.
+	h.reopen()
+	assertBackoff()
+
+	// Zero values should clear the backoff.
+	require.NoError(h.t, h.db.SetTowerBackoff(tower.ID, time.Time{}, 0))
+	require.Zero(h.t, h.loadTowerByID(tower.ID, nil).Backoff)
+}
+
+// testUpdateSessionRewardScript asserts that the reward pkscript of a reward
+// session can be replaced without affecting the rest of the session or its
+// committed updates, and that altruist sessions are rejected.
+func testUpdateSessionRewardScript(h *clientDBHarness) {
+	tower := h.newTower()
+
+	newSession := func(blobType blob.Type, id byte,
+		rewardPkScript []byte) *wtdb.ClientSession {
+
+		return &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						RewardBase:   1,
+						RewardRate:   1,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: rewardPkScript,
+				KeyIndex: h.nextKeyIndex(
+					tower.ID, blobType,
+				),
+			},
+			ID: wtdb.SessionID([33]byte{id}),
+		}
+	}
+
+	oldScript := []byte{0x01, 0x02, 0x03}
+	newScript := []byte{0x04, 0x05, 0x06}
+
+	// Updating the reward script of an unknown session should fail.
+	err := h.db.UpdateSessionRewardScript(
+		wtdb.SessionID([33]byte{0x01}), newScript,
+	)
+	require.ErrorIs(h.t, err, wtdb.ErrClientSessionNotFound)
+
+	reward := newSession(blob.TypeRewardCommit, 0x01, oldScript)
+	h.insertSession(reward, nil)
+
+	update1 := randCommittedUpdate(h.t, 1)
+	update2 := randCommittedUpdate(h.t, 2)
+	h.commitUpdate(&reward.ID, update1, nil)
+	h.commitUpdate(&reward.ID, update2, nil)
+
+	// A reward session can't be left without a reward script.
+	err = h.db.UpdateSessionRewardScript(reward.ID, nil)
+	require.ErrorIs(h.t, err, wtdb.ErrMissingRewardPkScript)
+
+	require.NoError(h.t, h.db.UpdateSessionRewardScript(
+		reward.ID, newScript,
+	))
+
+	// Only the reward script should have changed, and the committed
+	// updates should remain intact.
+	dbSession := h.getClientSession(reward.ID, nil)
+	require.Equal(h.t, newScript, dbSession.RewardPkScript)
+	require.Equal(h.t, reward.KeyIndex, dbSession.KeyIndex)
+	require.Equal(h.t, reward.Policy, dbSession.Policy)
+	require.Empty(h.t, dbSession.AltRewardPkScripts)
+	h.assertUpdates(reward.ID, []wtdb.CommittedUpdate{
+		*update1, *update2,
+	}, nil)
+
+	// Subsequent updates can be committed using the new reward script.
+	update3 := randCommittedUpdate(h.t, 3)
+	update3.RewardPkScript = newScript
+	h.commitUpdate(&reward.ID, update3, nil)
+
+	// Altruist sessions don't pay a reward, so their reward script can't
+	// be updated.
+	altruist := newSession(blob.TypeAltruistCommit, 0x02, nil)
+	altruist.Policy.RewardBase = 0
+	altruist.Policy.RewardRate = 0
+	h.insertSession(altruist, nil)
+
+	err = h.db.UpdateSessionRewardScript(altruist.ID, newScript)
+	require.ErrorIs(h.t, err, wtdb.ErrSessionNotReward)
+	require.Empty(h.t, h.getClientSession(altruist.ID, nil).RewardPkScript)
+}
+
+// testSessionStorageBytes asserts that the storage size of a session tracks the
+// blobs of its committed and acked updates.
+func testSessionStorageBytes(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	// The storage size of an unknown session can't be queried.
+	_, err := h.db.SessionStorageBytes(wtdb.SessionID{0x01})
+	require.ErrorIs(h.t, err, wtdb.ErrClientSessionNotFound)
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			KeyIndex: h.nextKeyIndex(tower.ID, blobType),
+		},
+		ID: wtdb.SessionID{0x01},
+	}
+	h.insertSession(session, nil)
+
+	assertStorageBytes := func(expBytes uint64) {
+		h.t.Helper()
+
+		size, err := h.db.SessionStorageBytes(session.ID)
+		require.NoError(h.t, err)
+		require.Equal(h.t, expBytes, size)
+	}
+
+	// A fresh session doesn't store anything.
+	assertStorageBytes(0)
+
+	// Commit three updates of known sizes.
+	var updates []*wtdb.CommittedUpdate
+	for i, size := range []int{100, 200, 300} {
+		update := randCommittedUpdate(h.t, uint16(i+1))
+		update.EncryptedBlob = make([]byte, size)
+		h.commitUpdate(&session.ID, update, nil)
+
+		updates = append(updates, update)
+	}
+	assertStorageBytes(600)
+
+	// Committing the same update again shouldn't count it twice.
+	h.commitUpdate(&session.ID, updates[2], nil)
+	assertStorageBytes(600)
+
+	// Acked updates are still stored by the tower, so acking an update
+	// shouldn't affect the total, even once the acked update is deleted.
+	h.ackUpdate(&session.ID, 1, 1, nil)
+	assertStorageBytes(600)
+
+	numDeleted, err := h.db.DeleteChannelUpdates(
+		updates[0].BackupID.ChanID,
+	)
+	require.NoError(h.t, err)
+	require.Equal(h.t, 1, numDeleted)
+	assertStorageBytes(600)
+
+	// A committed update that is deleted without being acked no longer
+	// counts towards the total.
+	err = h.db.DeleteCommittedUpdate(&session.ID, 3)
+	require.NoError(h.t, err)
+	assertStorageBytes(300)
+}
+
+// testRegisterChannelMulti asserts that a channel can be registered with
+// distinct sweep pkscripts for different kinds of outputs, and that they're
+// returned along with its summary.
+func testRegisterChannelMulti(h *clientDBHarness) {
+	var (
+		chanID        = lnwire.ChannelID{0x01}
+		defaultScript = bytes.Repeat([]byte{0xaa}, 22)
+		anchorScript  = bytes.Repeat([]byte{0xbb}, 34)
+		htlcScript    = bytes.Repeat([]byte{0xcc}, 22)
+	)
+
+	scripts := map[string][]byte{
+		wtdb.DefaultSweepScriptKind: defaultScript,
+		"anchor":                    anchorScript,
+		"htlc":                      htlcScript,
+	}
+
+	// Scripts lacking a default pkscript, or holding an empty one, should
+	// be rejected.
+	err := h.db.RegisterChannelMulti(chanID, map[string][]byte{
+		"anchor": anchorScript,
+	})
+	require.ErrorIs(h.t, err, wtdb.ErrInvalidSweepPkScripts)
+
+	err = h.db.RegisterChannelMulti(chanID, map[string][]byte{
+		wtdb.DefaultSweepScriptKind: defaultScript,
+		"anchor":                    nil,
+	})
+	require.ErrorIs(h.t, err, wtdb.ErrInvalidSweepPkScripts)
+
+	require.NoError(h.t, h.db.RegisterChannelMulti(chanID, scripts))
+
+	// The caller's map must be left untouched.
+	require.Len(h.t, scripts, 3)
+
+	assertSummary := func(summary wtdb.ClientChanSummary) {
+		h.t.Helper()
+
+		require.Equal(h.t, defaultScript, summary.SweepPkScript)
+		require.Equal(h.t, map[string][]byte{
+			"anchor": anchorScript,
+			"htlc":   htlcScript,
+		}, summary.SweepPkScripts)
+		require.Equal(
+			h.t, anchorScript, summary.SweepPkScriptFor("anchor"),
+		)
+		require.Equal(
+			h.t, defaultScript,
+			summary.SweepPkScriptFor("to-local"),
+		)
+		require.Equal(
+			h.t, wtdb.DefaultChannelPriority, summary.Priority,
+		)
+	}
+
+	summaries, err := h.db.FetchChanSummaries()
+	require.NoError(h.t, err)
+	require.Contains(h.t, summaries, chanID)
+	assertSummary(summaries[chanID])
+
+	summaries, err = h.db.FetchChanSummariesForChannels(
+		[]lnwire.ChannelID{chanID},
+	)
+	require.NoError(h.t, err)
+	assertSummary(summaries[chanID])
+
+	// Re-registering the channel with the same pkscripts, or with only its
+	// default pkscript, should be a no-op.
+	require.NoError(h.t, h.db.RegisterChannelMulti(chanID, scripts))
+	require.NoError(h.t, h.db.RegisterChannel(chanID, defaultScript))
+
+	summaries, err = h.db.FetchChanSummaries()
+	require.NoError(h.t, err)
+	assertSummary(summaries[chanID])
+
+	// Registering it with different pkscripts should fail.
+	err = h.db.RegisterChannelMulti(chanID, map[string][]byte{
+		wtdb.DefaultSweepScriptKind: defaultScript,
+		"anchor":                    htlcScript,
+	})
+	require.ErrorIs(h.t, err, wtdb.ErrChannelAlreadyRegistered)
+
+	err = h.db.RegisterChannel(chanID, anchorScript)
+	require.ErrorIs(h.t, err, wtdb.ErrChannelAlreadyRegistered)
+
+	// A channel registered with a single pkscript shouldn't hold any
+	// additional ones.
+	chanID2 := lnwire.ChannelID{0x02}
+	require.NoError(h.t, h.db.RegisterChannel(chanID2, defaultScript))
+
+	summaries, err = h.db.FetchChanSummaries()
+	require.NoError(h.t, err)
+	require.Nil(h.t, summaries[chanID2].SweepPkScripts)
+	require.Equal(
+		h.t, defaultScript, summaries[chanID2].SweepPkScriptFor("htlc"),
+	)
+}
+
+// testDeleteChannelUpdates asserts that deleting the updates of a channel only
+// removes that channel's acked updates, leaving those of other channels that
+// share the session, as well as any committed updates, untouched.
+func testDeleteChannelUpdates(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	var (
+		chanA = lnwire.ChannelID{0x01}
+		chanB = lnwire.ChannelID{0x02}
+	)
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	session.KeyIndex = h.nextKeyIndex(tower.ID, blobType)
+	h.insertSession(session, nil)
+
+	// Back up both channels into the same session, acking all but the
+	// final update.
+	chanIDs := []lnwire.ChannelID{chanA, chanB, chanA, chanB, chanA}
+	for i, chanID := range chanIDs {
+		seqNum := uint16(i + 1)
+
+		update := randCommittedUpdate(h.t, seqNum)
+		update.BackupID.ChanID = chanID
+		h.commitUpdate(&session.ID, update, nil)
+
+		if int(seqNum) < len(chanIDs) {
+			h.ackUpdate(&session.ID, seqNum, seqNum, nil)
+		}
+	}
+
+	deleteUpdates := func(chanID lnwire.ChannelID, expNum int) {
+		h.t.Helper()
+
+		numDeleted, err := h.db.DeleteChannelUpdates(chanID)
+		require.NoError(h.t, err)
+		require.Equal(h.t, expNum, numDeleted)
+	}
+
+	// Deleting the updates of channel A should only remove its two acked
+	// updates.
+	deleteUpdates(chanA, 2)
+
+	seqNums, err := h.db.FetchAckedUpdatesForChannel(chanA)
+	require.NoError(h.t, err)
+	require.Empty(h.t, seqNums)
+
+	seqNums, err = h.db.FetchAckedUpdatesForChannel(chanB)
+	require.NoError(h.t, err)
+	require.Equal(h.t, map[wtdb.SessionID][]uint16{
+		session.ID: {2, 4},
+	}, seqNums)
+
+	// The un-acked update of channel A must remain so that it can still
+	// be sent to the tower.
+	updates := h.fetchSessionCommittedUpdates(&session.ID, nil)
+	require.Len(h.t, updates, 1)
+	require.Equal(h.t, uint16(5), updates[0].SeqNum)
+
+	// Deleting the updates again should be a no-op.
+	deleteUpdates(chanA, 0)
+}
+
+// testCommitTaprootUpdate asserts that updates carrying taproot blobs can be
+// committed and fetched from a taproot session.
+func testCommitTaprootUpdate(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistTaprootCommit
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	session.KeyIndex = h.nextKeyIndex(tower.ID, blobType)
+	h.insertSession(session, nil)
+
+	update := randCommittedUpdateForType(h.t, 1, blobType)
+	h.commitUpdate(&session.ID, update, nil)
+
+	updates := h.fetchSessionCommittedUpdates(&session.ID, nil)
+	require.Equal(h.t, []wtdb.CommittedUpdate{*update}, updates)
+	require.Len(h.t, updates[0].EncryptedBlob, blob.Size(blobType))
+}
+
+// testTowerPolicy asserts that a tower's negotiated policy can be stored and
+// loaded, and that sessions whose policy differs from it are rejected.
+func testTowerPolicy(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	policy := wtpolicy.Policy{
+		TxPolicy: wtpolicy.TxPolicy{
+			BlobType:     blobType,
+			SweepFeeRate: testSweepFeeRate,
+		},
+		MaxUpdates: 100,
+	}
+
+	// Setting or getting the policy of an unknown tower should fail.
+	unknownID := wtdb.TowerID(100)
+	err := h.db.SetTowerPolicy(unknownID, policy)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerNotFound)
+	_, err = h.db.GetTowerPolicy(unknownID)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerNotFound)
+
+	// A new tower should not have a policy yet.
+	tower := h.newTower()
+	_, err = h.db.GetTowerPolicy(tower.ID)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerPolicyNotFound)
+
+	// Once set, the policy should be returned as is.
+	require.NoError(h.t, h.db.SetTowerPolicy(tower.ID, policy))
+	dbPolicy, err := h.db.GetTowerPolicy(tower.ID)
+	require.NoError(h.t, err)
+	require.Equal(h.t, policy, dbPolicy)
+
+	// A session using a different policy than the tower's should be
+	// rejected.
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID:        tower.ID,
+			Policy:         policy,
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	session.Policy.MaxUpdates = 200
+	session.KeyIndex = h.nextKeyIndex(tower.ID, blobType)
+	h.insertSession(session, wtdb.ErrTowerPolicyMismatch)
+
+	// A session using the tower's policy should be accepted.
+	session.Policy = policy
+	h.insertSession(session, nil)
+
+	// Removing the tower along with its sessions should remove its policy
+	// as well.
+	h.deleteSession(session.ID, nil)
+	h.removeTower(tower.IdentityKey, nil, false, nil)
+	_, err = h.db.GetTowerPolicy(tower.ID)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerNotFound)
+}
+
+// testInvalidSessionPolicy asserts that sessions whose policy fails validation
+// are rejected with an ErrInvalidPolicy wrapping the reason.
+func testInvalidSessionPolicy(h *clientDBHarness) {
+	const blobType = blob.TypeRewardCommit
+
+	validPolicy := wtpolicy.Policy{
+		TxPolicy: wtpolicy.TxPolicy{
+			BlobType:     blobType,
+			SweepFeeRate: testSweepFeeRate,
+		},
+		MaxUpdates: 100,
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(p *wtpolicy.Policy)
+		expErr error
+	}{
+		{
+			name: "no max updates",
+			mutate: func(p *wtpolicy.Policy) {
+				p.MaxUpdates = 0
+			},
+			expErr: wtpolicy.ErrNoMaxUpdates,
+		},
+		{
+			name: "sweep fee rate too low",
+			mutate: func(p *wtpolicy.Policy) {
+				p.SweepFeeRate = wtpolicy.MinSweepFeeRate - 1
+			},
+			expErr: wtpolicy.ErrSweepFeeRateTooLow,
+		},
+		{
+			name: "unknown blob type",
+			mutate: func(p *wtpolicy.Policy) {
+				p.BlobType = blob.Type(blob.FlagReward)
+			},
+			expErr: wtpolicy.ErrUnknownBlobType,
+		},
+		{
+			name: "reward rate too high",
+			mutate: func(p *wtpolicy.Policy) {
+				p.RewardRate = wtpolicy.MaxRewardRate + 1
+			},
+			expErr: wtpolicy.ErrRewardRateTooHigh,
+		},
+		{
+			name: "altruist with reward",
+			mutate: func(p *wtpolicy.Policy) {
+				p.BlobType = blob.TypeAltruistCommit
+				p.RewardRate = 1
+			},
+			expErr: wtpolicy.ErrAltruistReward,
+		},
+	}
+
+	tower := h.newTower()
+	keyIndex := h.nextKeyIndex(tower.ID, blobType)
+
+	for i, test := range tests {
+		session := &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID:        tower.ID,
+				KeyIndex:       keyIndex,
+				Policy:         validPolicy,
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+			},
+			ID: wtdb.SessionID([33]byte{byte(i + 1)}),
+		}
+		test.mutate(&session.Policy)
+
+		err := h.db.CreateClientSession(session)
+		require.ErrorIsf(h.t, err, test.expErr, test.name)
+
+		var policyErr *wtdb.ErrInvalidPolicy
+		require.ErrorAsf(h.t, err, &policyErr, test.name)
+	}
+
+	// None of the above sessions should have been persisted, and a session
+	// with a valid policy should still be accepted.
+	require.Empty(h.t, h.listSessions(nil))
+	h.insertSession(&wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID:        tower.ID,
+			KeyIndex:       keyIndex,
+			Policy:         validPolicy,
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+		},
+		ID: wtdb.SessionID([33]byte{0xff}),
+	}, nil)
+}
+
+// testSessionRewardPkScript asserts that reward sessions can only be created
+// with a reward pkscript, while altruist sessions may be created without one.
+func testSessionRewardPkScript(h *clientDBHarness) {
+	tower := h.newTower()
+
+	newSession := func(blobType blob.Type, id byte,
+		rewardPkScript []byte) *wtdb.ClientSession {
+
+		return &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: rewardPkScript,
+				KeyIndex: h.nextKeyIndex(
+					tower.ID, blobType,
+				),
+			},
+			ID: wtdb.SessionID([33]byte{id}),
+		}
+	}
+
+	// A reward session without a reward pkscript should be rejected.
+	reward := newSession(blob.TypeRewardCommit, 0x01, nil)
+	require.True(h.t, reward.IsReward())
+	h.insertSession(reward, wtdb.ErrMissingRewardPkScript)
+
+	reward.RewardPkScript = []byte{}
+	h.insertSession(reward, wtdb.ErrMissingRewardPkScript)
+	require.Empty(h.t, h.listSessions(nil))
+
+	// Once given a reward pkscript, it should be accepted.
+	reward.RewardPkScript = []byte{0x01, 0x02, 0x03}
+	h.insertSession(reward, nil)
+
+	// An altruist session doesn't need a reward pkscript.
+	altruist := newSession(blob.TypeAltruistCommit, 0x02, nil)
+	require.False(h.t, altruist.IsReward())
+	h.insertSession(altruist, nil)
+
+	sessions := h.listSessions(nil)
+	require.Len(h.t, sessions, 2)
+	require.Equal(
+		h.t, reward.RewardPkScript, sessions[reward.ID].RewardPkScript,
+	)
+	require.Empty(h.t, sessions[altruist.ID].RewardPkScript)
+}
+
+// testRotateSession asserts that rotating a session marks the old session as
+// exhausted and records the new session alongside it.
+func testRotateSession(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	tower := h.newTower()
+	newSession := func(towerID wtdb.TowerID, id byte) *wtdb.ClientSession {
+		return &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: towerID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+			},
+			ID: wtdb.SessionID([33]byte{id}),
+		}
+	}
+
+	oldSession := newSession(tower.ID, 0x01)
+	oldSession.KeyIndex = h.nextKeyIndex(tower.ID, blobType)
+	h.insertSession(oldSession, nil)
+
+	// Rotating an unknown session should fail.
+	rotated := newSession(tower.ID, 0x02)
+	err := h.db.RotateSession(wtdb.SessionID{0xff}, rotated)
+	require.ErrorIs(h.t, err, wtdb.ErrClientSessionNotFound)
+
+	// Rotating to a session with a different tower should fail.
+	otherTower := h.newTower()
+	otherSession := newSession(otherTower.ID, 0x02)
+	otherSession.KeyIndex = h.nextKeyIndex(otherTower.ID, blobType)
+	err = h.db.RotateSession(oldSession.ID, otherSession)
+	require.ErrorIs(h.t, err, wtdb.ErrRotateTowerMismatch)
+
+	// Rotating to a session that reuses the old session's key index should
+	// fail, and leave the old session untouched.
+	rotated.KeyIndex = oldSession.KeyIndex
+	err = h.db.RotateSession(oldSession.ID, rotated)
+	require.ErrorIs(h.t, err, wtdb.ErrNoReservedKeyIndex)
+
+	dbSession := h.getClientSession(oldSession.ID, nil)
+	require.Equal(h.t, wtdb.CSessionActive, dbSession.Status)
+
+	// Once a fresh key index is reserved, the rotation should succeed.
+	rotated.KeyIndex = h.nextKeyIndex(tower.ID, blobType)
+	require.NoError(h.t, h.db.RotateSession(oldSession.ID, rotated))
+
+	// Both sessions should now exist, with the old one exhausted and the
+	// new one active.
+	sessions := h.listSessions(&tower.ID)
+	require.Len(h.t, sessions, 2)
+	require.Equal(
+		h.t, wtdb.CSessionExhausted, sessions[oldSession.ID].Status,
+	)
+	require.Equal(h.t, wtdb.CSessionActive, sessions[rotated.ID].Status)
+}
+
+// testTowerMaxSessions asserts that a tower's session limit is enforced when
+// creating sessions, and that exhausted sessions don't count towards it.
+func testTowerMaxSessions(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	// Setting the limit of an unknown tower should fail.
+	err := h.db.SetTowerMaxSessions(100, 2)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerNotFound)
+
+	tower := h.newTower()
+	require.NoError(h.t, h.db.SetTowerMaxSessions(tower.ID, 2))
+	require.EqualValues(h.t, 2, h.loadTowerByID(tower.ID, nil).MaxSessions)
+
+	newSession := func(id byte) *wtdb.ClientSession {
+		return &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID:  tower.ID,
+				KeyIndex: h.nextKeyIndex(tower.ID, blobType),
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+			},
+			ID: wtdb.SessionID([33]byte{id}),
+		}
+	}
+
+	// The first two sessions should be accepted, while the third one
+	// should exceed the tower's limit.
+	session1 := newSession(0x01)
+	h.insertSession(session1, nil)
+	h.insertSession(newSession(0x02), nil)
+
+	session3 := newSession(0x03)
+	h.insertSession(session3, wtdb.ErrTowerSessionLimitReached)
+
+	// Once one of the sessions is exhausted by rotating it, the third
+	// session should fit within the limit.
+	require.NoError(h.t, h.db.RotateSession(session1.ID, session3))
+
+	// Lifting the limit should allow further sessions to be created.
+	h.insertSession(newSession(0x04), wtdb.ErrTowerSessionLimitReached)
+	require.NoError(h.t, h.db.SetTowerMaxSessions(tower.ID, 0))
+	h.insertSession(newSession(0x05), nil)
+	require.Len(h.t, h.listSessions(&tower.ID), 4)
+}
+
+// testMarkTowerAddressUsed asserts that a tower's addresses are loaded in the
+// order they were last successfully used.
+func testMarkTowerAddressUsed(h *clientDBHarness) {
+	pk, err := randPubKey()
+	require.NoError(h.t, err)
+
+	addr1 := &net.TCPAddr{IP: []byte{0x01, 0x00, 0x00, 0x00}, Port: 9911}
+	addr2 := &net.TCPAddr{IP: []byte{0x02, 0x00, 0x00, 0x00}, Port: 9911}
+	addr3 := &net.TCPAddr{IP: []byte{0x03, 0x00, 0x00, 0x00}, Port: 9911}
+
+	// Marking an address of an unknown tower should fail.
+	err = h.db.MarkTowerAddressUsed(100, addr1)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerNotFound)
+
+	// Create a tower with three addresses, which should be listed with the
+	// freshest address first.
+	var tower *wtdb.Tower
+	for _, addr := range []net.Addr{addr1, addr2, addr3} {
+		tower = h.createTower(&lnwire.NetAddress{
+			IdentityKey: pk,
+			Address:     addr,
+		}, nil)
+	}
+
+	addrStrs := func(addrs []net.Addr) []string {
+		strs := make([]string, 0, len(addrs))
+		for _, addr := range addrs {
+			strs = append(strs, addr.String())
+		}
+
+		return strs
+	}
+
+	assertAddrs := func(expAddrs ...net.Addr) {
+		h.t.Helper()
+
+		expStrs := addrStrs(expAddrs)
+		require.Equal(
+			h.t, expStrs, addrStrs(h.loadTower(pk, nil).Addresses),
+		)
+		require.Equal(h.t, expStrs, addrStrs(
+			h.loadTowerByID(tower.ID, nil).Addresses,
+		))
+	}
+	assertAddrs(addr3, addr2, addr1)
+
+	// Marking an address the tower doesn't have should fail.
+	unknownAddr := &net.TCPAddr{
+		IP: []byte{0x04, 0x00, 0x00, 0x00}, Port: 9911,
+	}
+	err = h.db.MarkTowerAddressUsed(tower.ID, unknownAddr)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerAddrNotFound)
+
+	// Marking the last address as used should move it to the front, while
+	// the remaining addresses retain their order.
+	require.NoError(h.t, h.db.MarkTowerAddressUsed(tower.ID, addr1))
+	assertAddrs(addr1, addr3, addr2)
+
+	// Marking another address as used should move it in front of the
+	// previously used one.
+	time.Sleep(time.Millisecond)
+	require.NoError(h.t, h.db.MarkTowerAddressUsed(tower.ID, addr2))
+	assertAddrs(addr2, addr1, addr3)
+
+	// Re-adding a known address shouldn't duplicate it.
+	h.createTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     addr1,
+	}, nil)
+	assertAddrs(addr2, addr1, addr3)
+
+	// Once an address is removed and re-added, it should no longer be
+	// considered used.
+	h.removeTower(pk, addr2, false, nil)
+	h.createTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     addr2,
+	}, nil)
+	assertAddrs(addr1, addr2, addr3)
+}
+
+// testOnionTowerAddr asserts that towers can be created with well-formed onion
+// addresses that are reloaded intact, while malformed ones are rejected.
+func testOnionTowerAddr(h *clientDBHarness) {
+	pk, err := randPubKey()
+	require.NoError(h.t, err)
+
+	var host [tor.V3DecodedLen]byte
+	_, err = crand.Read(host[:])
+	require.NoError(h.t, err)
+
+	onionService := tor.Base32Encoding.EncodeToString(host[:]) +
+		tor.OnionSuffix
+	onionAddr := &tor.OnionAddr{
+		OnionService: onionService,
+		Port:         9911,
+	}
+
+	// A tower with a well-formed v3 onion address should be created, and
+	// its address should be reloaded as an onion address.
+	tower := h.createTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     onionAddr,
+	}, nil)
+
+	for _, dbTower := range []*wtdb.Tower{
+		h.loadTower(pk, nil), h.loadTowerByID(tower.ID, nil),
+	} {
+		require.Len(h.t, dbTower.Addresses, 1)
+		require.Equal(h.t, onionAddr, dbTower.Addresses[0])
+	}
+
+	// Malformed onion addresses should be rejected without being stored.
+	invalidServices := []string{
+		// Invalid length.
+		onionService[1:],
+
+		// Invalid suffix.
+		onionService[:len(onionService)-1] + "x",
+
+		// Invalid base32 character.
+		"1" + onionService[1:],
+	}
+	for _, service := range invalidServices {
+		_, err := h.db.CreateTower(&lnwire.NetAddress{
+			IdentityKey: pk,
+			Address: &tor.OnionAddr{
+				OnionService: service,
+				Port:         9911,
+			},
+		})
+
+		var addrErr *wtdb.ErrInvalidTowerAddr
+		require.ErrorAs(h.t, err, &addrErr)
+	}
+	require.Len(h.t, h.loadTower(pk, nil).Addresses, 1)
+}
+
+// testDeleteCommittedUpdate asserts that a single committed update can be
+// removed from a session without affecting its other committed updates.
+func testDeleteCommittedUpdate(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	session.KeyIndex = h.nextKeyIndex(tower.ID, blobType)
+
+	// Deleting an update of an unknown session should fail.
+	err := h.db.DeleteCommittedUpdate(&session.ID, 1)
+	require.ErrorIs(h.t, err, wtdb.ErrClientSessionNotFound)
+
+	h.insertSession(session, nil)
+
+	// Deleting an update that was never committed should fail.
+	err = h.db.DeleteCommittedUpdate(&session.ID, 1)
+	require.ErrorIs(h.t, err, wtdb.ErrCommittedUpdateNotFound)
+
+	update1 := randCommittedUpdate(h.t, 1)
+	update2 := randCommittedUpdate(h.t, 2)
+	h.commitUpdate(&session.ID, update1, nil)
+	h.commitUpdate(&session.ID, update2, nil)
+
+	// Deleting the first update should leave only the second one, with its
+	// sequence number intact.
+	require.NoError(h.t, h.db.DeleteCommittedUpdate(&session.ID, 1))
+	h.assertUpdates(session.ID, []wtdb.CommittedUpdate{*update2}, nil)
+
+	err = h.db.DeleteCommittedUpdate(&session.ID, 1)
+	require.ErrorIs(h.t, err, wtdb.ErrCommittedUpdateNotFound)
+
+	// With the remaining update acked, the tower should no longer have any
+	// unacked updates preventing its removal.
+	h.ackUpdate(&session.ID, 2, 2, nil)
+	h.removeTower(tower.IdentityKey, nil, true, nil)
+}
+
+// testDBStats asserts that the database statistics reflect the towers,
+// sessions, updates and channels inserted into the database.
+func testDBStats(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	stats, err := h.db.DBStats()
+	require.NoError(h.t, err)
+	require.Equal(h.t, wtdb.ClientDBStats{}, stats)
+
+	// Create two towers, with one session each.
+	var sessionIDs []wtdb.SessionID
+	for i := 0; i < 2; i++ {
+		tower := h.newTower()
+		session := &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+			},
+			ID: wtdb.SessionID([33]byte{byte(i + 1)}),
+		}
+		session.KeyIndex = h.nextKeyIndex(tower.ID, blobType)
+		h.insertSession(session, nil)
+
+		sessionIDs = append(sessionIDs, session.ID)
+	}
+
+	// Commit three updates to the first session and one to the second,
+	// and ack two of the first session's updates.
+	var ackedIDs []wtdb.BackupID
+	for seqNum := uint16(1); seqNum <= 3; seqNum++ {
+		update := randCommittedUpdate(h.t, seqNum)
+		h.commitUpdate(&sessionIDs[0], update, nil)
+
+		if seqNum <= 2 {
+			h.ackUpdate(&sessionIDs[0], seqNum, seqNum, nil)
+			ackedIDs = append(ackedIDs, update.BackupID)
+		}
+	}
+	h.commitUpdate(&sessionIDs[1], randCommittedUpdate(h.t, 1), nil)
+
+	// Finally, register three channels.
+	for i := 0; i < 3; i++ {
+		h.registerChan(lnwire.ChannelID{byte(i)}, []byte{0x01}, nil)
+	}
+
+	// Each acked update occupies its 2-byte sequence number along with its
+	// encoded backup ID.
+	var ackedBytes uint64
+	for _, backupID := range ackedIDs {
+		var b bytes.Buffer
+		require.NoError(h.t, backupID.Encode(&b))
+		ackedBytes += uint64(2 + b.Len())
+	}
+
+	stats, err = h.db.DBStats()
+	require.NoError(h.t, err)
+	require.Equal(h.t, wtdb.ClientDBStats{
+		NumTowers:           2,
+		NumSessions:         2,
+		NumCommittedUpdates: 2,
+		NumAckedUpdates:     2,
+		NumChannels:         3,
+		AckedUpdatesBytes:   ackedBytes,
+	}, stats)
+}
+
+// testRecordTowerStat asserts that the delivery outcomes recorded for a tower
+// are reflected in the stats of the loaded tower.
+func testRecordTowerStat(h *clientDBHarness) {
+	tower := h.newTower()
+
+	// A tower without any recorded outcomes should have zero-valued stats.
+	loaded := h.loadTower(tower.IdentityKey, nil)
+	require.Equal(h.t, wtdb.TowerStats{}, loaded.Stats)
+
+	// Recording stats for an unknown tower should fail.
+	err := h.db.RecordTowerStat(tower.ID+1, true)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerNotFound)
+
+	before := time.Now()
+	require.NoError(h.t, h.db.RecordTowerStat(tower.ID, true))
+	require.NoError(h.t, h.db.RecordTowerStat(tower.ID, true))
+	require.NoError(h.t, h.db.RecordTowerStat(tower.ID, false))
+
+	// Both the success and failure counters should have been incremented,
+	// and the last contact time updated.
+	for _, loaded := range []*wtdb.Tower{
+		h.loadTower(tower.IdentityKey, nil),
+		h.loadTowerByID(tower.ID, nil),
+	} {
+		require.EqualValues(h.t, 2, loaded.Stats.NumSuccesses)
+		require.EqualValues(h.t, 1, loaded.Stats.NumFailures)
+		require.False(h.t, loaded.Stats.LastContact.Before(before))
+	}
+
+	// The stats should survive a restart of the database.
+	stats := h.loadTowerByID(tower.ID, nil).Stats
+	h.reopen()
+	require.Equal(h.t, stats, h.loadTowerByID(tower.ID, nil).Stats)
+
+	// Once the tower is removed, its stats can no longer be recorded.
+	h.removeTower(tower.IdentityKey, nil, false, nil)
+	err = h.db.RecordTowerStat(tower.ID, true)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerNotFound)
+}
+
+// testCreateTowerAndReserveKey asserts that a tower can be created along with a
+// session key reservation, and that the reservation is the one subsequently
+// returned by NextSessionKeyIndex.
+func testCreateTowerAndReserveKey(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistAnchorCommit
+
+	pk, err := randPubKey()
+	require.NoError(h.t, err)
+
+	lnAddr := &lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     &net.TCPAddr{IP: []byte{0x01, 0x00, 0x00, 0x00}},
+	}
+	tower, keyIndex, err := h.db.CreateTowerAndReserveKey(lnAddr, blobType)
+	require.NoError(h.t, err)
+	require.NotZero(h.t, keyIndex)
+
+	// The tower should have been persisted, and the reserved index should
+	// be returned by NextSessionKeyIndex until a session consumes it.
+	require.Equal(h.t, tower, h.loadTower(pk, nil))
+	require.Equal(h.t, keyIndex, h.nextKeyIndex(tower.ID, blobType))
+	require.Equal(h.t, keyIndex, h.nextKeyIndex(tower.ID, blobType))
+
+	// Calling it again for the same tower with a new address should add
+	// the address to the existing tower, and return the same reservation.
+	lnAddr2 := &lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     &net.TCPAddr{IP: []byte{0x02, 0x00, 0x00, 0x00}},
+	}
+	tower2, keyIndex2, err := h.db.CreateTowerAndReserveKey(
+		lnAddr2, blobType,
+	)
+	require.NoError(h.t, err)
+	require.Equal(h.t, tower.ID, tower2.ID)
+	require.Len(h.t, tower2.Addresses, 2)
+	require.Equal(h.t, keyIndex, keyIndex2)
+
+	// Re-adding a known address should leave the addresses untouched.
+	tower3, _, err := h.db.CreateTowerAndReserveKey(lnAddr, blobType)
+	require.NoError(h.t, err)
+	require.Equal(h.t, tower2.Addresses, tower3.Addresses)
+
+	// Once the reservation is consumed by a session, a new one should be
+	// made.
+	h.insertSession(&wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+			KeyIndex:       keyIndex,
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}, nil)
+
+	_, keyIndex4, err := h.db.CreateTowerAndReserveKey(lnAddr, blobType)
+	require.NoError(h.t, err)
+	require.NotEqual(h.t, keyIndex, keyIndex4)
+	require.Equal(h.t, keyIndex4, h.nextKeyIndex(tower.ID, blobType))
+}
+
+// testSessionRewardScripts asserts that updates can be committed against any of
+// the reward pkscripts registered for a session.
+func testSessionRewardScripts(h *clientDBHarness) {
+	const blobType = blob.TypeRewardCommit
+
+	tower := h.newTower()
+	rewardScript1 := []byte{0x01, 0x02, 0x03}
+	rewardScript2 := []byte{0x04, 0x05, 0x06}
+
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					RewardBase:   1,
+					RewardRate:   1,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: rewardScript1,
+			KeyIndex:       h.nextKeyIndex(tower.ID, blobType),
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	h.insertSession(session, nil)
+
+	// Registering a reward script for an unknown session should fail.
+	err := h.db.AddSessionRewardScript(
+		wtdb.SessionID([33]byte{0x02}), rewardScript2,
+	)
+	require.ErrorIs(h.t, err, wtdb.ErrClientSessionNotFound)
+
+	// Committing an update using the session's reward script, either
+	// implicitly or explicitly, should succeed.
+	update1 := randCommittedUpdate(h.t, 1)
+	h.commitUpdate(&session.ID, update1, nil)
+
+	update2 := randCommittedUpdate(h.t, 2)
+	update2.RewardPkScript = rewardScript1
+	h.commitUpdate(&session.ID, update2, nil)
+
+	// An update using a reward script that hasn't been registered should
+	// be rejected.
+	update3 := randCommittedUpdate(h.t, 3)
+	update3.RewardPkScript = rewardScript2
+	h.commitUpdate(&session.ID, update3, wtdb.ErrUnknownRewardScript)
+
+	// Once the second reward script is registered, the update should be
+	// accepted. Registering it twice should have no effect.
+	require.NoError(h.t, h.db.AddSessionRewardScript(
+		session.ID, rewardScript2,
+	))
+	require.NoError(h.t, h.db.AddSessionRewardScript(
+		session.ID, rewardScript2,
+	))
+	h.commitUpdate(&session.ID, update3, nil)
+
+	dbSession := h.getClientSession(session.ID, nil)
+	require.Equal(h.t, rewardScript1, dbSession.RewardPkScript)
+	require.Equal(
+		h.t, [][]byte{rewardScript2}, dbSession.AltRewardPkScripts,
+	)
+
+	// The committed updates should record the reward script they used.
+	h.assertUpdates(session.ID, []wtdb.CommittedUpdate{
+		*update1, *update2, *update3,
+	}, nil)
+
+	// Only a limited number of reward scripts can be registered.
+	for i := 2; i < wtdb.MaxRewardScripts; i++ {
+		require.NoError(h.t, h.db.AddSessionRewardScript(
+			session.ID, []byte{byte(i)},
+		))
+	}
+	err = h.db.AddSessionRewardScript(session.ID, []byte{0xff})
+	require.ErrorIs(h.t, err, wtdb.ErrTooManyRewardScripts)
+}
+
+// testRemoveTowerCheck asserts that RemoveTowerCheck reports the sessions with
+// unacked updates that block a tower's removal, without modifying anything.
+func testRemoveTowerCheck(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	// Checking an unknown tower should fail.
+	pk, err := randPubKey()
+	require.NoError(h.t, err)
+	_, err = h.db.RemoveTowerCheck(pk)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerNotFound)
+
+	tower := h.newTower()
+
+	// A tower without any sessions can be removed.
+	report, err := h.db.RemoveTowerCheck(tower.IdentityKey)
+	require.NoError(h.t, err)
+	require.True(h.t, report.CanRemove())
+	require.Equal(h.t, tower.ID, report.TowerID)
+
+	// Create three sessions with the tower: one with all of its updates
+	// acked, one with a mix of acked and unacked updates, and one with
+	// only unacked updates.
+	numUpdates := []struct {
+		committed uint16
+		acked     uint16
+	}{
+		{committed: 2, acked: 2},
+		{committed: 3, acked: 1},
+		{committed: 2, acked: 0},
+	}
+	sessionIDs := make([]wtdb.SessionID, len(numUpdates))
+	for i, n := range numUpdates {
+		sessionIDs[i] = wtdb.SessionID([33]byte{byte(i + 1)})
+		keyIndex := h.nextKeyIndex(tower.ID, blobType)
+		h.insertSession(&wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+				KeyIndex:       keyIndex,
+			},
+			ID: sessionIDs[i],
+		}, nil)
+
+		for seqNum := uint16(1); seqNum <= n.committed; seqNum++ {
+			update := randCommittedUpdate(h.t, seqNum)
+			h.commitUpdate(&sessionIDs[i], update, nil)
+		}
+		for seqNum := uint16(1); seqNum <= n.acked; seqNum++ {
+			h.ackUpdate(&sessionIDs[i], seqNum, seqNum, nil)
+		}
+	}
+
+	// The report should list the two sessions with unacked updates.
+	report, err = h.db.RemoveTowerCheck(tower.IdentityKey)
+	require.NoError(h.t, err)
+	require.False(h.t, report.CanRemove())
+	require.Equal(h.t, map[wtdb.SessionID]uint16{
+		sessionIDs[1]: 2,
+		sessionIDs[2]: 2,
+	}, report.BlockingSessions)
+	require.EqualValues(h.t, 4, report.NumUnackedUpdates)
+	require.Equal(
+		h.t, fmt.Sprintf("tower %d blocked by 2 sessions, 4 updates",
+			tower.ID), report.String(),
+	)
+
+	// The check shouldn't have modified the tower's sessions, and the
+	// tower still can't be removed.
+	for _, session := range h.listSessions(&tower.ID) {
+		require.Equal(h.t, wtdb.CSessionActive, session.Status)
+	}
+	h.removeTower(
+		tower.IdentityKey, nil, true, wtdb.ErrTowerUnackedUpdates,
+	)
+}
+
+// testRemoveTowerForce asserts that a tower can be forcibly removed along with
+// all of its sessions and updates, even if some updates are unacked.
+func testRemoveTowerForce(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	// Force removing an unknown tower should fail.
+	pk, err := randPubKey()
+	require.NoError(h.t, err)
+	err = h.db.RemoveTowerForce(pk)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerNotFound)
+
+	// Create two towers, each with a session holding both acked and
+	// unacked updates.
+	towers := []*wtdb.Tower{h.newTower(), h.newTower()}
+	sessionIDs := make([]wtdb.SessionID, len(towers))
+	for i, tower := range towers {
+		sessionIDs[i] = wtdb.SessionID([33]byte{byte(i + 1)})
+		keyIndex := h.nextKeyIndex(tower.ID, blobType)
+		h.insertSession(&wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+				KeyIndex:       keyIndex,
+			},
+			ID: sessionIDs[i],
+		}, nil)
+
+		for seqNum := uint16(1); seqNum <= 3; seqNum++ {
+			update := randCommittedUpdate(h.t, seqNum)
+			h.commitUpdate(&sessionIDs[i], update, nil)
+		}
+		h.ackUpdate(&sessionIDs[i], 1, 1, nil)
+	}
+
+	// The first tower can't be removed normally due to its unacked
+	// updates, but it can be force removed.
+	h.removeTower(
+		towers[0].IdentityKey, nil, true, wtdb.ErrTowerUnackedUpdates,
+	)
+	require.NoError(h.t, h.db.RemoveTowerForce(towers[0].IdentityKey))
+
+	// The tower, its session and all of the session's updates should be
+	// gone.
+	h.loadTower(towers[0].IdentityKey, wtdb.ErrTowerNotFound)
+	h.loadTowerByID(towers[0].ID, wtdb.ErrTowerNotFound)
+	h.getClientSession(sessionIDs[0], wtdb.ErrClientSessionNotFound)
+	h.fetchSessionCommittedUpdates(
+		&sessionIDs[0], wtdb.ErrClientSessionNotFound,
+	)
+	_, _, err = h.db.SessionUpdateCounts(sessionIDs[0])
+	require.ErrorIs(h.t, err, wtdb.ErrClientSessionNotFound)
+
+	sessions := h.listSessions(nil)
+	require.Len(h.t, sessions, 1)
+	require.Contains(h.t, sessions, sessionIDs[1])
+
+	towerList := h.listTowers()
+	require.Len(h.t, towerList, 1)
+	require.Equal(h.t, towers[1].ID, towerList[0].ID)
+
+	// No acked updates of the removed session should remain.
+	stats, err := h.db.DBStats()
+	require.NoError(h.t, err)
+	require.EqualValues(h.t, 1, stats.NumAckedUpdates)
+	require.EqualValues(h.t, 2, stats.NumCommittedUpdates)
+
+	// The other tower's session should be unaffected.
+	committed, acked, err := h.db.SessionUpdateCounts(sessionIDs[1])
+	require.NoError(h.t, err)
+	require.EqualValues(h.t, 2, committed)
+	require.EqualValues(h.t, 1, acked)
+}
+
+// testSessionsForChannel asserts that the channel-to-session index reports the
+// sessions holding updates of a channel, and that sessions are dropped from it
+// once they no longer hold any updates of the channel.
+func testSessionsForChannel(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	var (
+		chanA = lnwire.ChannelID{0x01}
+		chanB = lnwire.ChannelID{0x02}
+		chanC = lnwire.ChannelID{0x03}
+	)
+
+	tower := h.newTower()
+	newSession := func(id byte) *wtdb.ClientSession {
+		session := &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+				KeyIndex: h.nextKeyIndex(
+					tower.ID, blobType,
+				),
+			},
+			ID: wtdb.SessionID([33]byte{id}),
+		}
+		h.insertSession(session, nil)
+
+		return session
+	}
+	session1 := newSession(0x01)
+	session2 := newSession(0x02)
+
+	commit := func(session *wtdb.ClientSession, seqNum uint16,
+		chanID lnwire.ChannelID) {
+
+		update := randCommittedUpdate(h.t, seqNum)
+		update.BackupID.ChanID = chanID
+		h.commitUpdate(&session.ID, update, nil)
+	}
+
+	sessionsFor := func(chanID lnwire.ChannelID) []wtdb.SessionID {
+		h.t.Helper()
+
+		sessionIDs, err := h.db.SessionsForChannel(chanID)
+		require.NoError(h.t, err)
+
+		return sessionIDs
+	}
+
+	// Initially, no session holds updates of any channel.
+	require.Empty(h.t, sessionsFor(chanA))
+
+	// Commit updates of channel A to both sessions, and an update of
+	// channel B to the first session only.
+	commit(session1, 1, chanA)
+	commit(session1, 2, chanB)
+	commit(session2, 1, chanA)
+
+	require.Equal(h.t, []wtdb.SessionID{session1.ID, session2.ID},
+		sessionsFor(chanA))
+	require.Equal(h.t, []wtdb.SessionID{session1.ID}, sessionsFor(chanB))
+	require.Empty(h.t, sessionsFor(chanC))
+
+	// Acking the updates keeps the sessions in the index, since they
+	// still hold the acked backups.
+	h.ackUpdate(&session1.ID, 1, 1, nil)
+	h.ackUpdate(&session1.ID, 2, 2, nil)
+
+	require.Equal(h.t, []wtdb.SessionID{session1.ID, session2.ID},
+		sessionsFor(chanA))
+	require.Equal(h.t, []wtdb.SessionID{session1.ID}, sessionsFor(chanB))
+
+	// Deleting the second session's only committed update of channel A
+	// drops it from the channel's entry.
+	err := h.db.DeleteCommittedUpdate(&session2.ID, 1)
+	require.NoError(h.t, err)
+
+	require.Equal(h.t, []wtdb.SessionID{session1.ID}, sessionsFor(chanA))
+
+	// Deleting the acked updates of channel B drops the first session from
+	// that channel's entry, but not from channel A's.
+	_, err = h.db.DeleteChannelUpdates(chanB)
+	require.NoError(h.t, err)
+
+	require.Empty(h.t, sessionsFor(chanB))
+	require.Equal(h.t, []wtdb.SessionID{session1.ID}, sessionsFor(chanA))
+
+	// Finally, deleting the first session removes it from the index
+	// altogether.
+	h.deleteSession(session1.ID, nil)
+
+	require.Empty(h.t, sessionsFor(chanA))
+}
+
+// testNextSeqNum asserts that NextSeqNum reports the sequence number expected
+// by the next commit of a session.
+func testNextSeqNum(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	// An unknown session should be reported as such.
+	_, err := h.db.NextSeqNum(wtdb.SessionID{0x01})
+	require.ErrorIs(h.t, err, wtdb.ErrClientSessionNotFound)
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+			KeyIndex:       h.nextKeyIndex(tower.ID, blobType),
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	h.insertSession(session, nil)
+
+	nextSeqNum := func() uint16 {
+		h.t.Helper()
+
+		seqNum, err := h.db.NextSeqNum(session.ID)
+		require.NoError(h.t, err)
+
+		return seqNum
+	}
+
+	// A fresh session expects its first update.
+	require.EqualValues(h.t, 1, nextSeqNum())
+
+	// After committing two updates, the third one is expected. Acking an
+	// update doesn't change this.
+	h.commitUpdate(&session.ID, randCommittedUpdate(h.t, 1), nil)
+	h.commitUpdate(&session.ID, randCommittedUpdate(h.t, 2), nil)
+	require.EqualValues(h.t, 3, nextSeqNum())
+
+	h.ackUpdate(&session.ID, 1, 1, nil)
+	require.EqualValues(h.t, 3, nextSeqNum())
+
+	// The reported sequence number is accepted by CommitUpdate.
+	h.commitUpdate(
+		&session.ID, randCommittedUpdate(h.t, nextSeqNum()), nil,
+	)
+	require.EqualValues(h.t, 4, nextSeqNum())
+}
+
+// testSessionEvents asserts that subscribers are notified of the creation and
+// exhaustion of a session.
+func testSessionEvents(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	events, cancel := h.db.SubscribeSessionEvents()
+	defer cancel()
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 2,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+			KeyIndex:       h.nextKeyIndex(tower.ID, blobType),
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	h.insertSession(session, nil)
+
+	// A rejected commit doesn't change the session's status, so it must
+	// not produce any event.
+	h.commitUpdate(
+		&session.ID, randCommittedUpdate(h.t, 2),
+		wtdb.ErrCommitUnorderedUpdate,
+	)
+
+	// Committing both updates allowed by the policy exhausts the session.
+	h.commitUpdate(&session.ID, randCommittedUpdate(h.t, 1), nil)
+	h.commitUpdate(&session.ID, randCommittedUpdate(h.t, 2), nil)
+
+	nextEvent := func() wtdb.SessionEvent {
+		h.t.Helper()
+
+		select {
+		case event := <-events:
+			return event
+
+		case <-time.After(time.Second):
+			h.t.Fatalf("no session event received")
+			return wtdb.SessionEvent{}
+		}
+	}
+
+	require.Equal(h.t, wtdb.SessionEvent{
+		Type:      wtdb.SessionCreated,
+		SessionID: session.ID,
+		OldStatus: wtdb.CSessionActive,
+		NewStatus: wtdb.CSessionActive,
+	}, nextEvent())
+
+	require.Equal(h.t, wtdb.SessionEvent{
+		Type:      wtdb.SessionStatusChanged,
+		SessionID: session.ID,
+		OldStatus: wtdb.CSessionActive,
+		NewStatus: wtdb.CSessionExhausted,
+	}, nextEvent())
+
+	select {
+	case event := <-events:
+		h.t.Fatalf("unexpected session event: %v", event)
+	default:
+	}
+
+	// Once canceled, the subscription's channel is closed.
+	cancel()
+	_, ok := <-events
+	require.False(h.t, ok)
+}
+
+// testTowerFeatures asserts that the feature bits advertised by a tower can be
+// recorded and are returned when the tower is loaded.
+func testTowerFeatures(h *clientDBHarness) {
+	// Recording the features of an unknown tower should fail.
+	err := h.db.SetTowerFeatures(1, wtdb.NewTowerFeatures(nil))
+	require.ErrorIs(h.t, err, wtdb.ErrTowerNotFound)
+
+	tower := h.newTower()
+
+	// A tower without any recorded features has an empty feature vector.
+	dbTower := h.loadTowerByID(tower.ID, nil)
+	require.NotNil(h.t, dbTower.Features)
+	require.True(h.t, dbTower.Features.IsEmpty())
+
+	features := wtdb.NewTowerFeatures(lnwire.NewRawFeatureVector(
+		wtwire.AltruistSessionsRequired, wtwire.AnchorCommitOptional,
+	))
+	require.NoError(h.t, h.db.SetTowerFeatures(tower.ID, features))
+
+	// The features should be returned by both lookups, and understand
+	// the watchtower feature bits.
+	for _, dbTower := range []*wtdb.Tower{
+		h.loadTowerByID(tower.ID, nil),
+		h.loadTower(tower.IdentityKey, nil),
+	} {
+		require.True(h.t, features.Equals(
+			dbTower.Features.RawFeatureVector,
+		))
+		require.True(h.t, dbTower.Features.HasFeature(
+			wtwire.AnchorCommitRequired,
+		))
+		require.Equal(h.t, "anchor-commit", dbTower.Features.Name(
+			wtwire.AnchorCommitOptional,
+		))
+	}
+
+	// Re-adding an address to the tower leaves its features untouched.
+	h.createTower(&lnwire.NetAddress{
+		IdentityKey: tower.IdentityKey,
+		Address:     tower.Addresses[0],
+	}, nil)
+	dbTower = h.loadTowerByID(tower.ID, nil)
+	require.True(h.t, features.Equals(dbTower.Features.RawFeatureVector))
+
+	// Finally, a nil feature vector clears the tower's features.
+	require.NoError(h.t, h.db.SetTowerFeatures(tower.ID, nil))
+	dbTower = h.loadTowerByID(tower.ID, nil)
+	require.True(h.t, dbTower.Features.IsEmpty())
+}
+
+// testCommitUpdateWithRemaining asserts that CommitUpdateWithRemaining reports
+// the number of updates that can still be committed to a session, reaching
+// zero once the session is exhausted.
+func testCommitUpdateWithRemaining(h *clientDBHarness) {
+	const (
+		blobType   = blob.TypeAltruistCommit
+		maxUpdates = 3
+	)
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: maxUpdates,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+			KeyIndex:       h.nextKeyIndex(tower.ID, blobType),
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	h.insertSession(session, nil)
+
+	commit := func(update *wtdb.CommittedUpdate) uint16 {
+		h.t.Helper()
+
+		_, remaining, err := h.db.CommitUpdateWithRemaining(
+			&session.ID, update,
+		)
+		require.NoError(h.t, err)
+
+		return remaining
+	}
+
+	// Each committed update should consume one of the session's slots.
+	update1 := randCommittedUpdate(h.t, 1)
+	require.EqualValues(h.t, 2, commit(update1))
+
+	// Retransmitting the same update doesn't consume a slot.
+	require.EqualValues(h.t, 2, commit(update1))
+
+	require.EqualValues(h.t, 1, commit(randCommittedUpdate(h.t, 2)))
+
+	// The last update exhausts the session.
+	require.EqualValues(h.t, 0, commit(randCommittedUpdate(h.t, 3)))
+
+	// Rejected updates report no remaining slots. Since the session is
+	// now exhausted, it no longer accepts new updates at all.
+	_, remaining, err := h.db.CommitUpdateWithRemaining(
+		&session.ID, randCommittedUpdate(h.t, 5),
+	)
+	require.ErrorIs(h.t, err, wtdb.ErrSessionNotActive)
+	require.Zero(h.t, remaining)
+}
+
+// testChannelsWithoutBackups asserts that ChannelsWithoutBackups reports the
+// registered channels for which no session holds a committed or acked update.
+func testChannelsWithoutBackups(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	var (
+		chanA = lnwire.ChannelID{0x01}
+		chanB = lnwire.ChannelID{0x02}
+		chanC = lnwire.ChannelID{0x03}
+		chanD = lnwire.ChannelID{0x04}
+	)
+
+	withoutBackups := func() []lnwire.ChannelID {
+		h.t.Helper()
+
+		chanIDs, err := h.db.ChannelsWithoutBackups()
+		require.NoError(h.t, err)
+
+		return chanIDs
+	}
+
+	// Without any registered channels, nothing is reported.
+	require.Empty(h.t, withoutBackups())
+
+	h.registerChan(chanA, []byte{0x01}, nil)
+	h.registerChan(chanB, []byte{0x02}, nil)
+	h.registerChan(chanC, []byte{0x03}, nil)
+	require.Equal(
+		h.t, []lnwire.ChannelID{chanA, chanB, chanC}, withoutBackups(),
+	)
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+			KeyIndex:       h.nextKeyIndex(tower.ID, blobType),
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	h.insertSession(session, nil)
+
+	commit := func(seqNum uint16, chanID lnwire.ChannelID) {
+		update := randCommittedUpdate(h.t, seqNum)
+		update.BackupID.ChanID = chanID
+		h.commitUpdate(&session.ID, update, nil)
+	}
+
+	// Back up channel A with an acked update, channel B with an update
+	// that is only committed, and channel D which isn't registered.
+	commit(1, chanA)
+	h.ackUpdate(&session.ID, 1, 1, nil)
+	commit(2, chanB)
+	commit(3, chanD)
+
+	// Only channel C should be reported.
+	require.Equal(h.t, []lnwire.ChannelID{chanC}, withoutBackups())
+}
+
+// testConcurrentNextSessionKeyIndex asserts that concurrent callers reserving a
+// session key index for the same tower are all handed the same reservation,
+// and that no index is handed out again once it has been finalized by creating
+// a session with it.
+func testConcurrentNextSessionKeyIndex(h *clientDBHarness) {
+	const (
+		blobType     = blob.TypeAltruistCommit
+		numRounds    = 5
+		numReservers = 20
+	)
+
+	tower := h.newTower()
+
+	finalized := make(map[uint32]struct{})
+	for round := 0; round < numRounds; round++ {
+		var (
+			wg      sync.WaitGroup
+			indexes = make(chan uint32, numReservers)
+			errs    = make(chan error, numReservers)
+		)
+		for i := 0; i < numReservers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				index, err := h.db.NextSessionKeyIndex(
+					tower.ID, blobType,
+				)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				indexes <- index
+			}()
+		}
+		wg.Wait()
+		close(indexes)
+		close(errs)
+
+		for err := range errs {
+			require.NoError(h.t, err)
+		}
+
+		// Every caller of this round must have been handed the same
+		// reservation, which must not have been handed out before.
+		keyIndex := <-indexes
+		for index := range indexes {
+			require.Equal(h.t, keyIndex, index)
+		}
+		require.NotContains(h.t, finalized, keyIndex)
+
+		// Finalize the reservation by creating a session with it.
+		session := &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+				KeyIndex:       keyIndex,
+			},
+			ID: wtdb.SessionID([33]byte{byte(round + 1)}),
+		}
+		h.insertSession(session, nil)
+
+		finalized[keyIndex] = struct{}{}
+	}
+}
+
+// testListClientSessionsGrouped asserts that ListClientSessionsGrouped returns
+// active, inactive and exhausted sessions, grouped by their status.
+func testListClientSessionsGrouped(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	newSession := func(tower *wtdb.Tower, id byte,
+		maxUpdates uint16) *wtdb.ClientSession {
+
+		session := &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: maxUpdates,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+				KeyIndex: h.nextKeyIndex(
+					tower.ID, blobType,
+				),
+			},
+			ID: wtdb.SessionID([33]byte{id}),
+		}
+		h.insertSession(session, nil)
+
+		return session
+	}
+
+	// groupedIDs holds the IDs of the sessions of each status.
+	type groupedIDs map[wtdb.CSessionStatus][]wtdb.SessionID
+
+	grouped := func(id *wtdb.TowerID) groupedIDs {
+		h.t.Helper()
+
+		sessions, err := h.db.ListClientSessionsGrouped(id)
+		require.NoError(h.t, err)
+
+		ids := make(groupedIDs)
+		for status, group := range sessions {
+			for _, session := range group {
+				require.Equal(h.t, status, session.Status)
+				ids[status] = append(ids[status], session.ID)
+			}
+		}
+
+		return ids
+	}
+
+	// Without any sessions, no groups are returned.
+	require.Empty(h.t, grouped(nil))
+
+	// Create two active sessions with the first tower, and exhaust one of
+	// them by committing its only update.
+	tower1 := h.newTower()
+	active := newSession(tower1, 0x01, 100)
+	exhausted := newSession(tower1, 0x02, 1)
+
+	update := randCommittedUpdate(h.t, 1)
+	h.commitUpdate(&exhausted.ID, update, nil)
+	h.ackUpdate(&exhausted.ID, 1, 1, nil)
+
+	// Create a session with the second tower, and mark it inactive.
+	tower2 := h.newTower()
+	inactive := newSession(tower2, 0x03, 100)
+	require.NoError(h.t, h.db.MarkTowerInactive(tower2.IdentityKey))
+
+	require.Equal(h.t, groupedIDs{
+		wtdb.CSessionActive:    {active.ID},
+		wtdb.CSessionInactive:  {inactive.ID},
+		wtdb.CSessionExhausted: {exhausted.ID},
+	}, grouped(nil))
+
+	// Restricting the listing to a tower only groups its sessions.
+	require.Equal(h.t, groupedIDs{
+		wtdb.CSessionActive:    {active.ID},
+		wtdb.CSessionExhausted: {exhausted.ID},
+	}, grouped(&tower1.ID))
+
+	// Listing the sessions of an unknown tower should fail.
+	unknownID := tower2.ID + 1
+	_, err := h.db.ListClientSessionsGrouped(&unknownID)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerNotFound)
+}
+
+// testCreateClientSessionAutoReserve asserts that a session can be created
+// without reserving a session key index beforehand, and that an existing
+// reservation is used if there is one.
+func testCreateClientSessionAutoReserve(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	tower := h.newTower()
+
+	newSession := func(id byte) *wtdb.ClientSession {
+		return &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+			},
+			ID: wtdb.SessionID([33]byte{id}),
+		}
+	}
+
+	// Without a prior reservation, an index should be reserved and set on
+	// the session.
+	session := newSession(0x01)
+	keyIndex, err := h.db.CreateClientSessionAutoReserve(session)
+	require.NoError(h.t, err)
+	require.NotZero(h.t, keyIndex)
+	require.Equal(h.t, keyIndex, session.KeyIndex)
+
+	stored := h.getClientSession(session.ID, nil)
+	require.Equal(h.t, keyIndex, stored.KeyIndex)
+
+	// The index has been consumed by the session, so the next reservation
+	// must hand out a different one.
+	reserved := h.nextKeyIndex(tower.ID, blobType)
+	require.NotEqual(h.t, keyIndex, reserved)
+
+	// Creating the same session again must still fail.
+	_, err = h.db.CreateClientSessionAutoReserve(session)
+	require.ErrorIs(h.t, err, wtdb.ErrClientSessionAlreadyExists)
+
+	// A session created while an index is reserved should use it.
+	session2 := newSession(0x02)
+	keyIndex2, err := h.db.CreateClientSessionAutoReserve(session2)
+	require.NoError(h.t, err)
+	require.Equal(h.t, reserved, keyIndex2)
+	require.Equal(h.t, reserved, session2.KeyIndex)
+}
+
+// testChannelPriority asserts that a channel's backup priority defaults to
+// DefaultChannelPriority and that a priority set on the channel is returned
+// by FetchChanSummaries.
+func testChannelPriority(h *clientDBHarness) {
+	var chanID lnwire.ChannelID
+	sweepPkScript := []byte{0x01, 0x02}
+
+	// Setting the priority of a channel that was never registered should
+	// fail.
+	err := h.db.SetChannelPriority(chanID, 1)
+	require.ErrorIs(h.t, err, wtdb.ErrChannelNotRegistered)
+
+	// A newly registered channel should have the default priority.
+	h.registerChan(chanID, sweepPkScript, nil)
+	summary := h.fetchChanSummaries()[chanID]
+	require.Equal(h.t, wtdb.DefaultChannelPriority, summary.Priority)
+
+	// Once set, the priority should be returned along with the rest of
+	// the summary.
+	for _, priority := range []uint8{0, 255, 7} {
+		err := h.db.SetChannelPriority(chanID, priority)
+		require.NoError(h.t, err)
+
+		summary := h.fetchChanSummaries()[chanID]
+		require.Equal(h.t, priority, summary.Priority)
+		require.Equal(h.t, sweepPkScript, summary.SweepPkScript)
+	}
+
+	// Re-registering the channel with the same pkscript should leave its
+	// priority untouched.
+	h.registerChan(chanID, sweepPkScript, nil)
+	summary = h.fetchChanSummaries()[chanID]
+	require.EqualValues(h.t, 7, summary.Priority)
+
+	// A summary serialized before priorities were stored should be
+	// decoded with the default priority.
+	var b bytes.Buffer
+	require.NoError(h.t, wtdb.WriteElement(&b, sweepPkScript))
+
+	var legacy wtdb.ClientChanSummary
+	require.NoError(h.t, legacy.Decode(&b))
+	require.Equal(h.t, wtdb.DefaultChannelPriority, legacy.Priority)
+}
+
+// testLastAckedSeqNum asserts that a session's LastAckedSeqNum tracks the
+// highest sequence number acked by the tower.
+func testLastAckedSeqNum(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	session.KeyIndex = h.nextKeyIndex(session.TowerID, blobType)
+	h.insertSession(session, nil)
+
+	// A session without any acked updates should report zero.
+	stored := h.getClientSession(session.ID, nil)
+	require.Zero(h.t, stored.LastAckedSeqNum)
+
+	for seqNum := uint16(1); seqNum <= 3; seqNum++ {
+		update := randCommittedUpdate(h.t, seqNum)
+		h.commitUpdate(&session.ID, update, nil)
+	}
+
+	// Acking sequence numbers 1 and 2 should leave the session reporting
+	// 2, even though sequence number 3 has been committed.
+	h.ackUpdate(&session.ID, 1, 1, nil)
+	stored = h.getClientSession(session.ID, nil)
+	require.EqualValues(h.t, 1, stored.LastAckedSeqNum)
+
+	h.ackUpdate(&session.ID, 2, 2, nil)
+	stored = h.getClientSession(session.ID, nil)
+	require.EqualValues(h.t, 2, stored.LastAckedSeqNum)
+
+	// The value should also be exposed on sessions loaded by listing.
+	sessions := h.listSessions(&tower.ID)
+	require.Contains(h.t, sessions, session.ID)
+	require.EqualValues(h.t, 2, sessions[session.ID].LastAckedSeqNum)
+
+	// A rejected ack shouldn't affect it.
+	h.ackUpdate(&session.ID, 4, 3, wtdb.ErrCommittedUpdateNotFound)
+	stored = h.getClientSession(session.ID, nil)
+	require.EqualValues(h.t, 2, stored.LastAckedSeqNum)
+}
+
+// testRemoveTowerAddressByIndex asserts that a tower's address can be removed
+// by its index, leaving the order of the remaining addresses intact.
+func testRemoveTowerAddressByIndex(h *clientDBHarness) {
+	pk, err := randPubKey()
+	require.NoError(h.t, err)
+
+	// Removing an address of an unknown tower should fail.
+	err = h.db.RemoveTowerAddressByIndex(wtdb.TowerID(1000), 0)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerNotFound)
+
+	// Create a tower with three addresses. Since new addresses are
+	// prepended, they're stored in the reverse order of their addition.
+	addr1 := &net.TCPAddr{IP: []byte{0x01, 0x00, 0x00, 0x00}, Port: 9911}
+	addr2 := &net.TCPAddr{IP: []byte{0x02, 0x00, 0x00, 0x00}, Port: 9911}
+	addr3 := &net.TCPAddr{IP: []byte{0x03, 0x00, 0x00, 0x00}, Port: 9911}
+
+	var tower *wtdb.Tower
+	for _, addr := range []net.Addr{addr1, addr2, addr3} {
+		tower = h.createTower(&lnwire.NetAddress{
+			IdentityKey: pk,
+			Address:     addr,
+		}, nil)
+	}
+
+	addrStrs := func(addrs []net.Addr) []string {
+		strs := make([]string, 0, len(addrs))
+		for _, addr := range addrs {
+			strs = append(strs, addr.String())
+		}
+
+		return strs
+	}
+
+	assertAddrs := func(expAddrs ...net.Addr) {
+		h.t.Helper()
+
+		require.Equal(h.t, addrStrs(expAddrs), addrStrs(
+			h.loadTowerByID(tower.ID, nil).Addresses,
+		))
+	}
+	assertAddrs(addr3, addr2, addr1)
+
+	// Indexes outside of the address list should be rejected.
+	err = h.db.RemoveTowerAddressByIndex(tower.ID, -1)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerAddrIndexOutOfRange)
+	err = h.db.RemoveTowerAddressByIndex(tower.ID, 3)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerAddrIndexOutOfRange)
+
+	// Removing the middle address should leave the others in order.
+	err = h.db.RemoveTowerAddressByIndex(tower.ID, 1)
+	require.NoError(h.t, err)
+	assertAddrs(addr3, addr1)
+
+	// Newly added addresses should still be prepended.
+	h.createTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     addr2,
+	}, nil)
+	assertAddrs(addr2, addr3, addr1)
+
+	// Once an address has been used, it's returned first, and the index
+	// refers to that order.
+	err = h.db.MarkTowerAddressUsed(tower.ID, addr1)
+	require.NoError(h.t, err)
+	assertAddrs(addr1, addr2, addr3)
+
+	err = h.db.RemoveTowerAddressByIndex(tower.ID, 0)
+	require.NoError(h.t, err)
+	assertAddrs(addr2, addr3)
+
+	// Finally, the tower's last address can't be removed.
+	err = h.db.RemoveTowerAddressByIndex(tower.ID, 0)
+	require.NoError(h.t, err)
+	assertAddrs(addr3)
+
+	err = h.db.RemoveTowerAddressByIndex(tower.ID, 0)
+	require.ErrorIs(h.t, err, wtdb.ErrLastTowerAddr)
+}
+
+// testRegisterChannels asserts that a batch of channels can be registered at
+// once, with the channels that can't be registered reported individually.
+func testRegisterChannels(h *clientDBHarness) {
+	var chanA, chanB, chanC, chanD lnwire.ChannelID
+	chanA[0], chanB[0], chanC[0], chanD[0] = 0x0a, 0x0b, 0x0c, 0x0d
+
+	// Register one of the channels beforehand, so that the batch holds a
+	// duplicate with a different pkscript, and another with the same one.
+	h.registerChan(chanA, []byte{0x0a}, nil)
+	h.registerChan(chanB, []byte{0x0b}, nil)
+
+	chanErrs, err := h.db.RegisterChannels(map[lnwire.ChannelID][]byte{
+		chanA: {0x01},
+		chanB: {0x0b},
+		chanC: {0x0c},
+		chanD: {0x0d},
+	})
+	require.NoError(h.t, err)
+
+	// Only the duplicate with a different pkscript should have failed.
+	require.Len(h.t, chanErrs, 1)
+	require.ErrorIs(h.t, chanErrs[chanA], wtdb.ErrChannelAlreadyRegistered)
+
+	// The others should have been registered, while the duplicate keeps
+	// its original pkscript.
+	summaries := h.fetchChanSummaries()
+	require.Len(h.t, summaries, 4)
+	require.Equal(h.t, []byte{0x0a}, summaries[chanA].SweepPkScript)
+	require.Equal(h.t, []byte{0x0b}, summaries[chanB].SweepPkScript)
+	require.Equal(h.t, []byte{0x0c}, summaries[chanC].SweepPkScript)
+	require.Equal(h.t, []byte{0x0d}, summaries[chanD].SweepPkScript)
+	require.Equal(
+		h.t, wtdb.DefaultChannelPriority, summaries[chanC].Priority,
+	)
+
+	// An empty batch should register nothing.
+	chanErrs, err = h.db.RegisterChannels(nil)
+	require.NoError(h.t, err)
+	require.Empty(h.t, chanErrs)
+	require.Len(h.t, h.fetchChanSummaries(), 4)
+}
+
+// testTowerTimestamps asserts that a tower records when it was created, and
+// that its LastModified time advances once an address is added to it.
+func testTowerTimestamps(h *clientDBHarness) {
+	tower := h.newTower()
+	require.False(h.t, tower.CreatedAt.IsZero())
+	require.Equal(h.t, tower.CreatedAt, tower.LastModified)
+
+	loaded := h.loadTowerByID(tower.ID, nil)
+	require.Equal(h.t, tower.CreatedAt, loaded.CreatedAt)
+	require.Equal(h.t, tower.LastModified, loaded.LastModified)
+
+	// Re-adding an existing address doesn't modify the tower.
+	h.createTower(tower.LNAddrs()[0], nil)
+	loaded = h.loadTowerByID(tower.ID, nil)
+	require.Equal(h.t, tower.LastModified, loaded.LastModified)
+
+	// Adding a new address should advance LastModified, while leaving
+	// CreatedAt untouched.
+	addr := &net.TCPAddr{IP: []byte{0x02, 0x00, 0x00, 0x00}, Port: 9911}
+	h.createTower(&lnwire.NetAddress{
+		IdentityKey: tower.IdentityKey,
+		Address:     addr,
+	}, nil)
+
+	loaded = h.loadTowerByID(tower.ID, nil)
+	require.Equal(h.t, tower.CreatedAt, loaded.CreatedAt)
+	require.True(h.t, loaded.LastModified.After(tower.LastModified))
+
+	// Removing the address should advance it once more.
+	lastModified := loaded.LastModified
+	h.removeTower(tower.IdentityKey, addr, false, nil)
+
+	loaded = h.loadTowerByID(tower.ID, nil)
+	require.Equal(h.t, tower.CreatedAt, loaded.CreatedAt)
+	require.True(h.t, loaded.LastModified.After(lastModified))
+}
+
+// testValidateUpdate asserts that ValidateUpdate reports the same outcome as
+// the corresponding CommitUpdate, without persisting the update.
+func testValidateUpdate(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	session.KeyIndex = h.nextKeyIndex(session.TowerID, blobType)
+	h.insertSession(session, nil)
+
+	update1 := randCommittedUpdate(h.t, 1)
+	conflicting1 := randCommittedUpdate(h.t, 1)
+	update2 := randCommittedUpdate(h.t, 2)
+	update3 := randCommittedUpdate(h.t, 3)
+	unknownID := wtdb.SessionID([33]byte{0x02})
+
+	tests := []struct {
+		name   string
+		id     *wtdb.SessionID
+		update *wtdb.CommittedUpdate
+		expErr error
+	}{
+		{
+			name:   "unknown session",
+			id:     &unknownID,
+			update: update1,
+			expErr: wtdb.ErrClientSessionNotFound,
+		},
+		{
+			name:   "unordered update",
+			id:     &session.ID,
+			update: update2,
+			expErr: wtdb.ErrCommitUnorderedUpdate,
+		},
+		{
+			name:   "next update",
+			id:     &session.ID,
+			update: update1,
+		},
+		{
+			name:   "duplicate update",
+			id:     &session.ID,
+			update: update1,
+		},
+		{
+			name:   "conflicting update",
+			id:     &session.ID,
+			update: conflicting1,
+			expErr: wtdb.ErrUpdateAlreadyCommitted,
+		},
+		{
+			name:   "skipped update",
+			id:     &session.ID,
+			update: update3,
+			expErr: wtdb.ErrCommitUnorderedUpdate,
+		},
+		{
+			name:   "following update",
+			id:     &session.ID,
+			update: update2,
+		},
+	}
+
+	for _, test := range tests {
+		// Validating the update shouldn't persist it, so validating it
+		// again should give the same outcome.
+		seqNum := h.getClientSession(session.ID, nil).SeqNum
+		for i := 0; i < 2; i++ {
+			err := h.db.ValidateUpdate(test.id, test.update)
+			require.ErrorIs(h.t, err, test.expErr, test.name)
+		}
+		require.Equal(
+			h.t, seqNum, h.getClientSession(session.ID, nil).SeqNum,
+		)
+
+		// Actually committing the update should give the outcome that
+		// was predicted.
+		_, err := h.db.CommitUpdate(test.id, test.update)
+		require.ErrorIs(h.t, err, test.expErr, test.name)
+
+		if err == nil {
+			h.recordBlobType(test.id, test.update)
+		}
+	}
+
+	h.assertUpdates(
+		session.ID, []wtdb.CommittedUpdate{*update1, *update2}, nil,
+	)
+}
+
+// testHighestSessionKeyIndex asserts that HighestSessionKeyIndex reports the
+// largest key index finalized by a session of the tower and blob type.
+func testHighestSessionKeyIndex(h *clientDBHarness) {
+	const (
+		blobType      = blob.TypeAltruistCommit
+		otherBlobType = blob.TypeAltruistAnchorCommit
+	)
+
+	tower := h.newTower()
+
+	highest := func(towerID wtdb.TowerID, blobType blob.Type) uint32 {
+		h.t.Helper()
+
+		index, err := h.db.HighestSessionKeyIndex(towerID, blobType)
+		require.NoError(h.t, err)
+
+		return index
+	}
+
+	newSession := func(blobType blob.Type, id byte) *wtdb.ClientSession {
+		return &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+			},
+			ID: wtdb.SessionID([33]byte{id}),
+		}
+	}
+
+	// Without any sessions, the highest index should be 0, even once an
+	// index has been reserved.
+	require.Zero(h.t, highest(tower.ID, blobType))
+	reserved := h.nextKeyIndex(tower.ID, blobType)
+	require.Zero(h.t, highest(tower.ID, blobType))
+
+	// Finalizing the reserved index should report it.
+	session1 := newSession(blobType, 0x01)
+	session1.KeyIndex = reserved
+	h.insertSession(session1, nil)
+	require.Equal(h.t, session1.KeyIndex, highest(tower.ID, blobType))
+
+	// Finalizing another index should report the larger of the two.
+	session2 := newSession(blobType, 0x02)
+	session2.KeyIndex = h.nextKeyIndex(tower.ID, blobType)
+	h.insertSession(session2, nil)
+
+	expHighest := session1.KeyIndex
+	if session2.KeyIndex > expHighest {
+		expHighest = session2.KeyIndex
+	}
+	require.Equal(h.t, expHighest, highest(tower.ID, blobType))
+
+	// Sessions of other blob types and towers are reported separately.
+	require.Zero(h.t, highest(tower.ID, otherBlobType))
+	require.Zero(h.t, highest(tower.ID+1, blobType))
+
+	session3 := newSession(otherBlobType, 0x03)
+	session3.KeyIndex = h.nextKeyIndex(tower.ID, otherBlobType)
+	h.insertSession(session3, nil)
+	require.Equal(
+		h.t, session3.KeyIndex, highest(tower.ID, otherBlobType),
+	)
+	require.Equal(h.t, expHighest, highest(tower.ID, blobType))
+}
+
+// testCommittedUpdateBlobType asserts that committed updates record the blob
+// type of their session, and that updates encrypted under another blob type
+// are rejected.
+func testCommittedUpdateBlobType(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistAnchorCommit
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+			KeyIndex:       h.nextKeyIndex(tower.ID, blobType),
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	h.insertSession(session, nil)
+
+	// An update encrypted under another blob type should be rejected.
+	update1 := randCommittedUpdateForType(h.t, 1, blobType)
+	update1.BlobType = blob.TypeAltruistCommit
+	err := h.db.ValidateUpdate(&session.ID, update1)
+	require.ErrorIs(h.t, err, wtdb.ErrBlobTypeMismatch)
+	h.commitUpdate(&session.ID, update1, wtdb.ErrBlobTypeMismatch)
+
+	// An update that doesn't record its blob type should be assigned the
+	// session's once committed, after which it can be retransmitted.
+	update1.BlobType = 0
+	_, err = h.db.CommitUpdate(&session.ID, update1)
+	require.NoError(h.t, err)
+	_, err = h.db.CommitUpdate(&session.ID, update1)
+	require.NoError(h.t, err)
+
+	// The caller's update should be left untouched.
+	require.Zero(h.t, update1.BlobType)
+
+	// An update recording the session's blob type is accepted as is.
+	update2 := randCommittedUpdateForType(h.t, 2, blobType)
+	update2.BlobType = blobType
+	h.commitUpdate(&session.ID, update2, nil)
+
+	// The blob types should be returned along with the updates, also
+	// after a restart of the database.
+	stored1 := *update1
+	stored1.BlobType = blobType
+	h.assertUpdates(
+		session.ID, []wtdb.CommittedUpdate{stored1, *update2}, nil,
+	)
+
+	h.reopen()
+	h.assertUpdates(
+		session.ID, []wtdb.CommittedUpdate{stored1, *update2}, nil,
+	)
+}
+
+// testCommitInactiveSession asserts that new updates can only be committed to
+// active sessions, while committed updates can still be retransmitted once a
+// session is exhausted.
+func testCommitInactiveSession(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	tower := h.newTower()
+	newSession := func(id byte, maxUpdates uint16) *wtdb.ClientSession {
+		session := &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: maxUpdates,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+				KeyIndex: h.nextKeyIndex(
+					tower.ID, blobType,
+				),
+			},
+			ID: wtdb.SessionID([33]byte{id}),
+		}
+		h.insertSession(session, nil)
+
+		return session
+	}
+
+	session := newSession(0x01, 100)
+	h.commitUpdate(&session.ID, randCommittedUpdate(h.t, 1), nil)
+	h.ackUpdate(&session.ID, 1, 1, nil)
+
+	// Removing the tower marks its session inactive, after which new
+	// updates should be rejected.
+	h.removeTower(tower.IdentityKey, nil, true, nil)
+
+	update2 := randCommittedUpdate(h.t, 2)
+	err := h.db.ValidateUpdate(&session.ID, update2)
+	require.ErrorIs(h.t, err, wtdb.ErrSessionNotActive)
+	h.commitUpdate(&session.ID, update2, wtdb.ErrSessionNotActive)
+
+	// Once the tower is added back, the session is active again and
+	// accepts the update.
+	h.createTower(tower.LNAddrs()[0], nil)
+	h.commitUpdate(&session.ID, update2, nil)
+
+	// A session whose final update has been committed is exhausted, so
+	// it rejects any further update while still allowing the final one
+	// to be retransmitted.
+	exhausted := newSession(0x02, 1)
+	update := randCommittedUpdate(h.t, 1)
+	h.commitUpdate(&exhausted.ID, update, nil)
+	h.commitUpdate(&exhausted.ID, update, nil)
+	h.commitUpdate(
+		&exhausted.ID, randCommittedUpdate(h.t, 2),
+		wtdb.ErrSessionNotActive,
+	)
+}
+
+// testForEachSessionCommittedUpdate asserts that a session's committed updates
+// can be iterated over one at a time, and that the iteration stops as soon as
+// the call-back returns an error.
+func testForEachSessionCommittedUpdate(h *clientDBHarness) {
+	const (
+		blobType   = blob.TypeAltruistCommit
+		numUpdates = 5
+	)
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+			KeyIndex:       h.nextKeyIndex(tower.ID, blobType),
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+
+	// Iterating over the updates of an unknown session should fail.
+	noop := func(*wtdb.CommittedUpdate) error { return nil }
+	err := h.db.ForEachSessionCommittedUpdate(&session.ID, noop)
+	require.ErrorIs(h.t, err, wtdb.ErrClientSessionNotFound)
+
+	h.insertSession(session, nil)
+
+	var expUpdates []wtdb.CommittedUpdate
+	for seqNum := uint16(1); seqNum <= numUpdates; seqNum++ {
+		update := randCommittedUpdate(h.t, seqNum)
+		h.commitUpdate(&session.ID, update, nil)
+		expUpdates = append(expUpdates, *update)
+	}
+
+	// A full iteration should visit every update in order, matching those
+	// fetched all at once.
+	var updates []wtdb.CommittedUpdate
+	err = h.db.ForEachSessionCommittedUpdate(
+		&session.ID, func(update *wtdb.CommittedUpdate) error {
+			updates = append(updates, *update)
+			return nil
+		},
+	)
+	require.NoError(h.t, err)
+	require.Len(h.t, updates, numUpdates)
+	require.Equal(h.t, expUpdates, updates)
+	checkCommittedUpdates(
+		h.t, h.fetchSessionCommittedUpdates(&session.ID, nil),
+		expUpdates,
+	)
+
+	// An error returned by the call-back should stop the iteration, and
+	// be returned to the caller.
+	errStop := errors.New("stop")
+	var numVisited int
+	err = h.db.ForEachSessionCommittedUpdate(
+		&session.ID, func(*wtdb.CommittedUpdate) error {
+			numVisited++
+			if numVisited == 2 {
+				return errStop
+			}
+
+			return nil
+		},
+	)
+	require.ErrorIs(h.t, err, errStop)
+	require.Equal(h.t, 2, numVisited)
+}
+
+// testTowerRateLimit asserts that the rate limit recorded for a tower is
+// returned on the loaded tower, and isn't reset when the tower is re-added.
+func testTowerRateLimit(h *clientDBHarness) {
+	// Setting the rate limit of an unknown tower should fail.
+	err := h.db.SetTowerRateLimit(100, 60)
+	require.ErrorIs(h.t, err, wtdb.ErrTowerNotFound)
+
+	// A fresh tower's rate limit should be unknown.
+	tower := h.newTower()
+	require.Zero(h.t, h.loadTowerByID(tower.ID, nil).RateLimit)
+
+	require.NoError(h.t, h.db.SetTowerRateLimit(tower.ID, 60))
+	require.EqualValues(h.t, 60, h.loadTowerByID(tower.ID, nil).RateLimit)
+	require.EqualValues(
+		h.t, 60, h.loadTower(tower.IdentityKey, nil).RateLimit,
+	)
+
+	// Re-adding the tower should leave its rate limit untouched.
+	h.createTower(&lnwire.NetAddress{
+		IdentityKey: tower.IdentityKey,
+		Address:     pseudoAddr,
+	}, nil)
+	require.EqualValues(h.t, 60, h.loadTowerByID(tower.ID, nil).RateLimit)
+
+	// Nor should a restart of the database.
+	h.reopen()
+	require.EqualValues(h.t, 60, h.loadTowerByID(tower.ID, nil).RateLimit)
+
+	// Resetting the rate limit should mark it as unknown again.
+	require.NoError(h.t, h.db.SetTowerRateLimit(tower.ID, 0))
+	require.Zero(h.t, h.loadTowerByID(tower.ID, nil).RateLimit)
+}
+
+// testCommitConflictDetails asserts that an update conflicting with one that
+// was already committed is rejected with a CommitConflictError describing both
+// updates, which still matches ErrUpdateAlreadyCommitted.
+func testCommitConflictDetails(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+			KeyIndex:       h.nextKeyIndex(tower.ID, blobType),
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	h.insertSession(session, nil)
+
+	update := randCommittedUpdate(h.t, 1)
+	h.commitUpdate(&session.ID, update, nil)
+
+	conflict := randCommittedUpdate(h.t, 1)
+	assertConflict := func(err error) {
+		h.t.Helper()
+
+		require.ErrorIs(h.t, err, wtdb.ErrUpdateAlreadyCommitted)
+
+		var conflictErr *wtdb.CommitConflictError
+		require.ErrorAs(h.t, err, &conflictErr)
+		require.Equal(h.t, &wtdb.CommitConflictError{
+			SeqNum:           1,
+			ExistingHint:     update.Hint,
+			ExistingBackupID: update.BackupID,
+			Hint:             conflict.Hint,
+			BackupID:         conflict.BackupID,
+		}, conflictErr)
+	}
+
+	// The conflict should be described the same way whether the update is
+	// validated, committed on its own or committed as part of a batch.
+	assertConflict(h.db.ValidateUpdate(&session.ID, conflict))
+
+	_, err := h.db.CommitUpdate(&session.ID, conflict)
+	assertConflict(err)
+
+	_, err = h.db.CommitUpdates(
+		&session.ID, []*wtdb.CommittedUpdate{conflict},
+	)
+	assertConflict(err)
+
+	// The stored update should be left untouched.
+	h.assertUpdates(session.ID, []wtdb.CommittedUpdate{*update}, nil)
+}
+
+// testListSessionsBodiesOnly asserts that listing sessions with WithBodiesOnly
+// returns the same sessions as a full listing, without visiting any of their
+// updates.
+func testListSessionsBodiesOnly(h *clientDBHarness) {
+	const (
+		blobType    = blob.TypeAltruistCommit
+		numSessions = 3
+		numUpdates  = 4
+	)
+
+	tower := h.newTower()
+	for i := 0; i < numSessions; i++ {
+		session := &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+				KeyIndex: h.nextKeyIndex(
+					tower.ID, blobType,
+				),
+			},
+			ID: wtdb.SessionID([33]byte{byte(i + 1)}),
+		}
+		h.insertSession(session, nil)
+
+		// Ack half of the session's updates, so that it has both
+		// committed and acked updates.
+		for seqNum := uint16(1); seqNum <= numUpdates; seqNum++ {
+			update := randCommittedUpdate(h.t, seqNum)
+			h.commitUpdate(&session.ID, update, nil)
+		}
+		for seqNum := uint16(1); seqNum <= numUpdates/2; seqNum++ {
+			h.ackUpdate(&session.ID, seqNum, seqNum, nil)
+		}
+	}
+
+	var numCommitted, numAcked int
+	perCommitted := func(*wtdb.ClientSession, *wtdb.CommittedUpdate) {
+		numCommitted++
+	}
+	perAcked := func(*wtdb.ClientSession, uint16, wtdb.BackupID) {
+		numAcked++
+	}
+
+	// A full listing should visit every update.
+	sessions := h.listSessions(
+		nil, wtdb.WithPerCommittedUpdate(perCommitted),
+		wtdb.WithPerAckedUpdate(perAcked),
+	)
+	require.Len(h.t, sessions, numSessions)
+	require.Equal(h.t, numSessions*numUpdates/2, numCommitted)
+	require.Equal(h.t, numSessions*numUpdates/2, numAcked)
+
+	// Listing only the bodies should return the same sessions without
+	// visiting any update, even if call-backs are given.
+	numCommitted, numAcked = 0, 0
+	bodies := h.listSessions(
+		nil, wtdb.WithBodiesOnly(),
+		wtdb.WithPerCommittedUpdate(perCommitted),
+		wtdb.WithPerAckedUpdate(perAcked),
+	)
+	require.Equal(h.t, sessions, bodies)
+	require.Zero(h.t, numCommitted)
+	require.Zero(h.t, numAcked)
+
+	// The option should compose with the post-evaluation filters.
+	bodies = h.listSessions(
+		&tower.ID, wtdb.WithBodiesOnly(),
+		wtdb.WithPostEvalFilterStatus(wtdb.CSessionActive),
+	)
+	require.Equal(h.t, sessions, bodies)
+}
+
+// testNumTowerSessions asserts that the number of sessions of a tower tracks
+// the creation and deletion of its sessions.
+func testNumTowerSessions(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	assertCount := func(id wtdb.TowerID, expCount uint64, expErr error) {
+		h.t.Helper()
+
+		count, err := h.db.NumTowerSessions(id)
+		require.ErrorIs(h.t, err, expErr)
+		require.Equal(h.t, expCount, count)
+	}
+
+	// Querying an unknown tower should fail.
+	assertCount(wtdb.TowerID(1), 0, wtdb.ErrTowerNotFound)
+
+	// A new tower should have no sessions.
+	tower1 := h.newTower()
+	tower2 := h.newTower()
+	assertCount(tower1.ID, 0, nil)
+	assertCount(tower2.ID, 0, nil)
+
+	newSession := func(towerID wtdb.TowerID, id byte) *wtdb.ClientSession {
+		session := &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: towerID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+			},
+			ID: wtdb.SessionID([33]byte{id}),
+		}
+		session.KeyIndex = h.nextKeyIndex(towerID, blobType)
+		h.insertSession(session, nil)
+
+		return session
+	}
+
+	// Create two sessions with the first tower and one with the second,
+	// and check that each tower's count is tracked separately.
+	session1 := newSession(tower1.ID, 0x01)
+	newSession(tower1.ID, 0x02)
+	newSession(tower2.ID, 0x03)
+	assertCount(tower1.ID, 2, nil)
+	assertCount(tower2.ID, 1, nil)
+
+	// Deleting a session should decrement its tower's count.
+	h.deleteSession(session1.ID, nil)
+	assertCount(tower1.ID, 1, nil)
+	assertCount(tower2.ID, 1, nil)
+}
+
+// testReserveSessionKeyIndices asserts that session key indexes can be
+// reserved in bulk, that unused reservations are returned again, and that
+// indexes consumed by new sessions are never handed out again.
+func testReserveSessionKeyIndices(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	tower := h.newTower()
+
+	reserve := func(n int, expErr error) []uint32 {
+		h.t.Helper()
+
+		indexes, err := h.db.ReserveSessionKeyIndices(
+			tower.ID, blobType, n,
+		)
+		require.ErrorIs(h.t, err, expErr)
+
+		return indexes
+	}
+
+	// Reserving a non-positive number of indexes should fail.
+	reserve(0, wtdb.ErrInvalidKeyIndexCount)
+
+	// Reserve five indexes, which should all be distinct.
+	indexes := reserve(5, nil)
+	require.Len(h.t, indexes, 5)
+
+	seen := make(map[uint32]struct{})
+	for _, index := range indexes {
+		require.NotZero(h.t, index)
+		seen[index] = struct{}{}
+	}
+	require.Len(h.t, seen, 5)
+
+	// Reserving again without creating any sessions should return the
+	// same indexes, and a single reservation should return the first.
+	require.Equal(h.t, indexes, reserve(5, nil))
+	require.Equal(h.t, indexes[:2], reserve(2, nil))
+	require.Equal(h.t, indexes[0], h.nextKeyIndex(tower.ID, blobType))
+
+	// Finalize two sessions using the first two indexes.
+	for i, index := range indexes[:2] {
+		h.insertSession(&wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID:  tower.ID,
+				KeyIndex: index,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+			},
+			ID: wtdb.SessionID([33]byte{byte(i + 1)}),
+		}, nil)
+	}
+
+	// A session can't be created with an index that has already been
+	// consumed.
+	h.insertSession(&wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID:  tower.ID,
+			KeyIndex: indexes[0],
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+		},
+		ID: wtdb.SessionID([33]byte{0x03}),
+	}, wtdb.ErrIncorrectKeyIndex)
+
+	// Reserving five indexes again should return the three unused
+	// reservations, followed by two new indexes that don't overlap with
+	// any prior ones.
+	newIndexes := reserve(5, nil)
+	require.Len(h.t, newIndexes, 5)
+	require.Equal(h.t, indexes[2:], newIndexes[:3])
+
+	for _, index := range newIndexes[3:] {
+		require.NotContains(h.t, seen, index)
+		seen[index] = struct{}{}
+	}
+	require.Len(h.t, seen, 7)
+
+	// A bulk reservation for another tower shouldn't return any of the
+	// first tower's reservations.
+	otherTower := h.newTower()
+	otherIndexes, err := h.db.ReserveSessionKeyIndices(
+		otherTower.ID, blobType, 3,
+	)
+	require.NoError(h.t, err)
+	for _, index := range otherIndexes {
+		require.NotContains(h.t, seen, index)
+	}
+}
+
+// testFetchAckedUpdatesForChannel asserts that acked updates are correctly
+// partitioned by channel and session.
+func testFetchAckedUpdatesForChannel(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	var (
+		chanA = lnwire.ChannelID{0x01}
+		chanB = lnwire.ChannelID{0x02}
+		chanC = lnwire.ChannelID{0x03}
+	)
+
+	tower := h.newTower()
+	newSession := func(id byte) *wtdb.ClientSession {
+		session := &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+				KeyIndex: h.nextKeyIndex(
+					tower.ID, blobType,
+				),
+			},
+			ID: wtdb.SessionID([33]byte{id}),
+		}
+		h.insertSession(session, nil)
+
+		return session
+	}
+	session1 := newSession(0x01)
+	session2 := newSession(0x02)
+
+	// commitAndAck commits an update for the given channel at the given
+	// seqnum, and acks it if requested.
+	commitAndAck := func(session *wtdb.ClientSession, seqNum uint16,
+		chanID lnwire.ChannelID, ack bool) {
+
+		update := randCommittedUpdate(h.t, seqNum)
+		update.BackupID.ChanID = chanID
+		h.commitUpdate(&session.ID, update, nil)
+
+		if ack {
+			h.ackUpdate(&session.ID, seqNum, seqNum, nil)
+		}
+	}
+
+	// Session 1 holds acked backups for both channels, while session 2
+	// only holds acked backups for channel B. The final update of each
+	// session remains un-acked and should not be reported.
+	commitAndAck(session1, 1, chanA, true)
+	commitAndAck(session1, 2, chanB, true)
+	commitAndAck(session1, 3, chanA, true)
+	commitAndAck(session1, 4, chanA, false)
+	commitAndAck(session2, 1, chanB, true)
+	commitAndAck(session2, 2, chanB, true)
+	commitAndAck(session2, 3, chanA, false)
+
+	fetch := func(chanID lnwire.ChannelID) map[wtdb.SessionID][]uint16 {
+		h.t.Helper()
+
+		seqNums, err := h.db.FetchAckedUpdatesForChannel(chanID)
+		require.NoError(h.t, err)
+
+		return seqNums
+	}
+
+	require.Equal(h.t, map[wtdb.SessionID][]uint16{
+		session1.ID: {1, 3},
+	}, fetch(chanA))
+
+	require.Equal(h.t, map[wtdb.SessionID][]uint16{
+		session1.ID: {2},
+		session2.ID: {1, 2},
+	}, fetch(chanB))
+
+	require.Empty(h.t, fetch(chanC))
+}
+
+// testSessionTimestamps asserts that a session's creation time is recorded,
+// and that its last updated time is bumped by commits and acks.
+func testSessionTimestamps(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+			KeyIndex:       h.nextKeyIndex(tower.ID, blobType),
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	h.insertSession(session, nil)
+
+	// A fresh session should have a non-zero creation time, which is also
+	// its last updated time.
+	dbSession := h.getClientSession(session.ID, nil)
+	require.False(h.t, dbSession.CreatedAt.IsZero())
+	require.Equal(h.t, session.CreatedAt, dbSession.CreatedAt)
+	require.Equal(h.t, dbSession.CreatedAt, dbSession.LastUpdated)
+
+	// Committing an update should advance the last updated time, but
+	// leave the creation time untouched.
+	h.commitUpdate(&session.ID, randCommittedUpdate(h.t, 1), nil)
+	committed := h.getClientSession(session.ID, nil)
+	require.Equal(h.t, dbSession.CreatedAt, committed.CreatedAt)
+	require.False(h.t, committed.LastUpdated.Before(dbSession.LastUpdated))
+
+	// Likewise for acking the update.
+	h.ackUpdate(&session.ID, 1, 1, nil)
+	acked := h.getClientSession(session.ID, nil)
+	require.Equal(h.t, dbSession.CreatedAt, acked.CreatedAt)
+	require.False(h.t, acked.LastUpdated.Before(committed.LastUpdated))
+}
+
+func perAckedUpdate(updates map[uint16]wtdb.BackupID) func(
+	_ *wtdb.ClientSession, seq uint16, id wtdb.BackupID) {
+
+	return func(_ *wtdb.ClientSession, seq uint16,
+		id wtdb.BackupID) {
+
+		updates[seq] = id
+	}
+}
+
+// testAckUpdate asserts the behavior of AckUpdate.
+func testAckUpdate(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	tower := h.newTower()
+
+	// Create a new session that the updates in this will be tied to.
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+		},
+		ID: wtdb.SessionID([33]byte{0x03}),
+	}
+
+	// Try to ack an update before inserting the client session, which
+	// should fail.
+	h.ackUpdate(&session.ID, 1, 0, wtdb.ErrClientSessionNotFound)
+
+	// Reserve a session key and insert the client session.
+	session.KeyIndex = h.nextKeyIndex(session.TowerID, blobType)
+	h.insertSession(session, nil)
+
+	// Now, try to ack update 1. This should fail since update 1 was never
+	// committed.
+	h.ackUpdate(&session.ID, 1, 0, wtdb.ErrCommittedUpdateNotFound)
+
+	// Commit to a random update at seqnum 1.
+	update1 := randCommittedUpdate(h.t, 1)
+	lastApplied := h.commitUpdate(&session.ID, update1, nil)
+	require.Zero(h.t, lastApplied)
+
+	// Acking seqnum 1 should succeed.
+	h.ackUpdate(&session.ID, 1, 1, nil)
+
+	// Acking seqnum 1 again should fail.
+	h.ackUpdate(&session.ID, 1, 1, wtdb.ErrCommittedUpdateNotFound)
+
+	// Acking a valid seqnum with a reverted last applied value should fail.
+	h.ackUpdate(&session.ID, 1, 0, wtdb.ErrLastAppliedReversion)
+
+	// Acking with a last applied greater than any allocated seqnum should
+	// fail.
+	h.ackUpdate(&session.ID, 4, 3, wtdb.ErrUnallocatedLastApplied)
+
+	// Assert that the ClientSession loaded from disk has one update in it's
+	// AckedUpdates map, and that the committed update has been removed.
+	h.assertUpdates(session.ID, nil, map[uint16]wtdb.BackupID{
+		1: update1.BackupID,
+	})
+
+	// Commit to another random update, and assert that the last applied
+	// value is 1, since this was what was provided in the last successful
+	// ack.
+	update2 := randCommittedUpdate(h.t, 2)
+	lastApplied = h.commitUpdate(&session.ID, update2, nil)
+	require.EqualValues(h.t, 1, lastApplied)
+
+	// Ack seqnum 2.
+	h.ackUpdate(&session.ID, 2, 2, nil)
+
+	// Assert that both updates exist as AckedUpdates when loaded from disk.
+	h.assertUpdates(session.ID, nil, map[uint16]wtdb.BackupID{
+		1: update1.BackupID,
+		2: update2.BackupID,
+	})
+
+	// Acking again with a lower last applied should fail.
+	h.ackUpdate(&session.ID, 2, 1, wtdb.ErrLastAppliedReversion)
+
+	// Acking an unallocated seqnum should fail.
+	h.ackUpdate(&session.ID, 4, 2, wtdb.ErrCommittedUpdateNotFound)
+
+	// Acking with a last applied greater than any allocated seqnum should
+	// fail.
+	h.ackUpdate(&session.ID, 4, 3, wtdb.ErrUnallocatedLastApplied)
+}
+
+// testAckUpdates asserts the behavior of AckUpdates.
+func testAckUpdates(h *clientDBHarness) {
+	const blobType = blob.TypeAltruistCommit
+
+	tower := h.newTower()
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blobType,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+		},
+		ID: wtdb.SessionID([33]byte{0x03}),
+	}
+
+	// Acking a batch before the session exists should fail.
+	h.ackUpdates(
+		&session.ID, map[uint16]uint16{1: 0},
+		wtdb.ErrClientSessionNotFound,
+	)
+
+	session.KeyIndex = h.nextKeyIndex(session.TowerID, blobType)
+	h.insertSession(session, nil)
+
+	update1 := randCommittedUpdate(h.t, 1)
+	update2 := randCommittedUpdate(h.t, 2)
+	update3 := randCommittedUpdate(h.t, 3)
+	h.commitUpdates(
+		&session.ID, []*wtdb.CommittedUpdate{update1, update2, update3},
+		nil,
+	)
+
+	// A batch that acks updates 1 and 2 but also an uncommitted update 4
+	// should be rejected in its entirety.
+	h.ackUpdates(&session.ID, map[uint16]uint16{
+		1: 1,
+		2: 2,
+		4: 3,
+	}, wtdb.ErrCommittedUpdateNotFound)
+	h.assertUpdates(session.ID, []wtdb.CommittedUpdate{
+		*update1, *update2, *update3,
+	}, nil)
+
+	// Acks are applied in ascending seqnum order, so a batch whose last
+	// applied values decrease with the seqnum should be rejected as a
+	// reversion.
+	h.ackUpdates(&session.ID, map[uint16]uint16{
+		1: 2,
+		2: 1,
+	}, wtdb.ErrLastAppliedReversion)
+	h.assertUpdates(session.ID, []wtdb.CommittedUpdate{
+		*update1, *update2, *update3,
+	}, nil)
+
+	// A batch with a last applied beyond the highest allocated seqnum
+	// should fail.
+	h.ackUpdates(&session.ID, map[uint16]uint16{
+		1: 1,
+		2: 4,
+	}, wtdb.ErrUnallocatedLastApplied)
+	h.assertUpdates(session.ID, []wtdb.CommittedUpdate{
+		*update1, *update2, *update3,
+	}, nil)
+
+	// Now ack the first two updates in a valid batch.
+	h.ackUpdates(&session.ID, map[uint16]uint16{
+		1: 1,
+		2: 2,
+	}, nil)
+	h.assertUpdates(session.ID, []wtdb.CommittedUpdate{
+		*update3,
+	}, map[uint16]wtdb.BackupID{
+		1: update1.BackupID,
+		2: update2.BackupID,
+	})
+
+	// Re-acking an update from the batch should fail, as should a batch
+	// reverting the last applied value.
+	h.ackUpdate(&session.ID, 2, 2, wtdb.ErrCommittedUpdateNotFound)
+	h.ackUpdates(
+		&session.ID, map[uint16]uint16{3: 1},
+		wtdb.ErrLastAppliedReversion,
+	)
+
+	// An empty batch is a no-op.
+	h.ackUpdates(&session.ID, nil, nil)
+	h.assertUpdates(session.ID, []wtdb.CommittedUpdate{
+		*update3,
+	}, map[uint16]wtdb.BackupID{
+		1: update1.BackupID,
+		2: update2.BackupID,
+	})
+}
+
+func (h *clientDBHarness) assertUpdates(id wtdb.SessionID,
+	expectedPending []wtdb.CommittedUpdate,
 	expectedAcked map[uint16]wtdb.BackupID) {
 
-	ackedUpdates := make(map[uint16]wtdb.BackupID)
-	_ = h.listSessions(
-		nil, wtdb.WithPerAckedUpdate(perAckedUpdate(ackedUpdates)),
+	// Collect the session's committed updates during the same list pass
+	// as the acked updates, which should match those fetched for the
+	// session directly.
+	var (
+		ackedUpdates  = make(map[uint16]wtdb.BackupID)
+		listedPending = make([]wtdb.CommittedUpdate, 0)
+	)
+	_ = h.listSessions(
+		nil, wtdb.WithPerAckedUpdate(perAckedUpdate(ackedUpdates)),
+		wtdb.WithPerCommittedUpdate(func(s *wtdb.ClientSession,
+			u *wtdb.CommittedUpdate) {
+
+			if s.ID == id {
+				listedPending = append(listedPending, *u)
+			}
+		}),
+	)
+	committedUpates := h.fetchSessionCommittedUpdates(&id, nil)
+	checkCommittedUpdates(h.t, committedUpates, expectedPending)
+	checkCommittedUpdates(h.t, listedPending, expectedPending)
+	checkAckedUpdates(h.t, ackedUpdates, expectedAcked)
+}
+
+// checkCommittedUpdates asserts that the CommittedUpdates on session match the
+// expUpdates provided.
+func checkCommittedUpdates(t *testing.T, actualUpdates,
+	expUpdates []wtdb.CommittedUpdate) {
+
+	t.Helper()
+
+	// We promote nil expUpdates to an initialized slice since the database
+	// should never return a nil slice. This promotion is done purely out of
+	// convenience for the testing framework.
+	if expUpdates == nil {
+		expUpdates = make([]wtdb.CommittedUpdate, 0)
+	}
+
+	require.Equal(t, expUpdates, actualUpdates)
+}
+
+// checkAckedUpdates asserts that the AckedUpdates on a session match the
+// expUpdates provided.
+func checkAckedUpdates(t *testing.T, actualUpdates,
+	expUpdates map[uint16]wtdb.BackupID) {
+
+	// We promote nil expUpdates to an initialized map since the database
+	// should never return a nil map. This promotion is done purely out of
+	// convenience for the testing framework.
+	if expUpdates == nil {
+		expUpdates = make(map[uint16]wtdb.BackupID)
+	}
+
+	require.Equal(t, expUpdates, actualUpdates)
+}
+
+// clientDBTests is the set of tests that each of the client database
+// implementations are expected to pass.
+var clientDBTests = []struct {
+	name string
+	run  func(*clientDBHarness)
+}{
+	{
+		name: "create client session",
+		run:  testCreateClientSession,
+	},
+	{
+		name: "get client session",
+		run:  testGetClientSession,
+	},
+	{
+		name: "delete client session",
+		run:  testDeleteClientSession,
+	},
+	{
+		name: "filter client sessions",
+		run:  testFilterClientSessions,
+	},
+	{
+		name: "paginate client sessions",
+		run:  testPaginateClientSessions,
+	},
+	{
+		name: "filter client sessions by blob type",
+		run:  testFilterClientSessionsByBlobType,
+	},
+	{
+		name: "record tower stat",
+		run:  testRecordTowerStat,
+	},
+	{
+		name: "create tower and reserve key",
+		run:  testCreateTowerAndReserveKey,
+	},
+	{
+		name: "session reward scripts",
+		run:  testSessionRewardScripts,
+	},
+	{
+		name: "remove tower check",
+		run:  testRemoveTowerCheck,
+	},
+	{
+		name: "remove tower force",
+		run:  testRemoveTowerForce,
+	},
+	{
+		name: "sessions for channel",
+		run:  testSessionsForChannel,
+	},
+	{
+		name: "next seqnum",
+		run:  testNextSeqNum,
+	},
+	{
+		name: "session events",
+		run:  testSessionEvents,
+	},
+	{
+		name: "tower features",
+		run:  testTowerFeatures,
+	},
+	{
+		name: "fetch chan summaries for channels",
+		run:  testFetchChanSummariesForChannels,
+	},
+	{
+		name: "commit update with remaining",
+		run:  testCommitUpdateWithRemaining,
+	},
+	{
+		name: "channels without backups",
+		run:  testChannelsWithoutBackups,
+	},
+	{
+		name: "concurrent next session key index",
+		run:  testConcurrentNextSessionKeyIndex,
+	},
+	{
+		name: "list client sessions grouped",
+		run:  testListClientSessionsGrouped,
+	},
+	{
+		name: "create client session auto reserve",
+		run:  testCreateClientSessionAutoReserve,
+	},
+	{
+		name: "channel priority",
+		run:  testChannelPriority,
+	},
+	{
+		name: "last acked seqnum",
+		run:  testLastAckedSeqNum,
+	},
+	{
+		name: "remove tower address by index",
+		run:  testRemoveTowerAddressByIndex,
+	},
+	{
+		name: "register channels",
+		run:  testRegisterChannels,
+	},
+	{
+		name: "tower timestamps",
+		run:  testTowerTimestamps,
+	},
+	{
+		name: "validate update",
+		run:  testValidateUpdate,
+	},
+	{
+		name: "highest session key index",
+		run:  testHighestSessionKeyIndex,
+	},
+	{
+		name: "committed update blob type",
+		run:  testCommittedUpdateBlobType,
+	},
+	{
+		name: "commit to inactive session",
+		run:  testCommitInactiveSession,
+	},
+	{
+		name: "for each session committed update",
+		run:  testForEachSessionCommittedUpdate,
+	},
+	{
+		name: "tower rate limit",
+		run:  testTowerRateLimit,
+	},
+	{
+		name: "commit conflict details",
+		run:  testCommitConflictDetails,
+	},
+	{
+		name: "list sessions bodies only",
+		run:  testListSessionsBodiesOnly,
+	},
+	{
+		name: "total pending updates",
+		run:  testTotalPendingUpdates,
+	},
+	{
+		name: "remove tower orphaned channels",
+		run:  testRemoveTowerOrphanedChannels,
+	},
+	{
+		name: "session reward pkscript",
+		run:  testSessionRewardPkScript,
+	},
+	{
+		name: "ping",
+		run:  testPing,
+	},
+	{
+		name: "soft delete channel",
+		run:  testSoftDeleteChannel,
+	},
+	{
+		name: "release reserved key index",
+		run:  testReleaseReservedKeyIndex,
+	},
+	{
+		name: "reserved key index tower isolation",
+		run:  testReservedKeyIndexTowerIsolation,
+	},
+	{
+		name: "tower backoff",
+		run:  testTowerBackoff,
+	},
+	{
+		name: "update session reward script",
+		run:  testUpdateSessionRewardScript,
+	},
+	{
+		name: "session storage bytes",
+		run:  testSessionStorageBytes,
+	},
+	{
+		name: "register channel multi",
+		run:  testRegisterChannelMulti,
+	},
+	{
+		name: "create tower",
+		run:  testCreateTower,
+	},
+	{
+		name: "remove tower",
+		run:  testRemoveTower,
+	},
+	{
+		name: "tower nickname",
+		run:  testTowerNickname,
+	},
+	{
+		name: "mark tower status",
+		run:  testMarkTowerStatus,
+	},
+	{
+		name: "list towers",
+		run:  testListTowers,
+	},
+	{
+		name: "chan summaries",
+		run:  testChanSummaries,
+	},
+	{
+		name: "unregister channel",
+		run:  testUnregisterChannel,
+	},
+	{
+		name: "commit update",
+		run:  testCommitUpdate,
+	},
+	{
+		name: "commit updates",
+		run:  testCommitUpdates,
+	},
+	{
+		name: "session exhaustion",
+		run:  testSessionExhaustion,
+	},
+	{
+		name: "ack update",
+		run:  testAckUpdate,
+	},
+	{
+		name: "ack updates",
+		run:  testAckUpdates,
+	},
+	{
+		name: "session update counts",
+		run:  testSessionUpdateCounts,
+	},
+	{
+		name: "session timestamps",
+		run:  testSessionTimestamps,
+	},
+	{
+		name: "fetch acked updates for channel",
+		run:  testFetchAckedUpdatesForChannel,
+	},
+	{
+		name: "num tower sessions",
+		run:  testNumTowerSessions,
+	},
+	{
+		name: "reserve session key indices",
+		run:  testReserveSessionKeyIndices,
+	},
+	{
+		name: "delete channel updates",
+		run:  testDeleteChannelUpdates,
+	},
+	{
+		name: "commit taproot update",
+		run:  testCommitTaprootUpdate,
+	},
+	{
+		name: "tower policy",
+		run:  testTowerPolicy,
+	},
+	{
+		name: "invalid session policy",
+		run:  testInvalidSessionPolicy,
+	},
+	{
+		name: "rotate session",
+		run:  testRotateSession,
+	},
+	{
+		name: "tower max sessions",
+		run:  testTowerMaxSessions,
+	},
+	{
+		name: "mark tower address used",
+		run:  testMarkTowerAddressUsed,
+	},
+	{
+		name: "onion tower addr",
+		run:  testOnionTowerAddr,
+	},
+	{
+		name: "delete committed update",
+		run:  testDeleteCommittedUpdate,
+	},
+	{
+		name: "db stats",
+		run:  testDBStats,
+	},
+}
+
+// TestClientDB asserts the behavior of a fresh client db, a reopened client db,
+// and the mock implementation. This ensures that all databases function
+// identically, especially in the negative paths.
+func TestClientDB(t *testing.T) {
+	dbs := []struct {
+		name string
+		init clientDBInit
+	}{
+		{
+			name: "fresh clientdb",
+			init: func(t *testing.T, path string) wtclient.DB {
+				return openBoltClientDB(t, path)
+			},
+		},
+		{
+			name: "reopened clientdb",
+			init: func(t *testing.T, path string) wtclient.DB {
+				db := openBoltClientDB(t, path)
+				require.NoError(t, db.Close())
+
+				return openBoltClientDB(t, path)
+			},
+		},
+		{
+			name: "cached clientdb",
+			init: func(t *testing.T, path string) wtclient.DB {
+				db := openBoltClientDB(t, path)
+
+				return wtdb.NewCachedDB(db, 10)
+			},
+		},
+		{
+			name: "mock",
+			init: func(t *testing.T, _ string) wtclient.DB {
+				return wtmock.NewClientDB()
+			},
+		},
+	}
+
+	for _, database := range dbs {
+		db := database
+		t.Run(db.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, test := range clientDBTests {
+				t.Run(test.name, func(t *testing.T) {
+					h := newClientDBHarness(t, db.init)
+
+					test.run(h)
+				})
+			}
+		})
+	}
+}
+
+// randCommittedUpdate generates a random committed update.
+func randCommittedUpdate(t *testing.T, seqNum uint16) *wtdb.CommittedUpdate {
+	return randCommittedUpdateForType(t, seqNum, blob.TypeAltruistCommit)
+}
+
+// randCommittedUpdateForType generates a random committed update whose
+// encrypted blob is sized for the given blob type.
+func randCommittedUpdateForType(t *testing.T, seqNum uint16,
+	blobType blob.Type) *wtdb.CommittedUpdate {
+
+	var chanID lnwire.ChannelID
+	_, err := io.ReadFull(crand.Reader, chanID[:])
+	require.NoError(t, err)
+
+	var hint blob.BreachHint
+	_, err = io.ReadFull(crand.Reader, hint[:])
+	require.NoError(t, err)
+
+	size, err := blob.SizeChecked(blobType)
+	require.NoError(t, err)
+
+	encBlob := make([]byte, size)
+	_, err = io.ReadFull(crand.Reader, encBlob)
+	require.NoError(t, err)
+
+	return &wtdb.CommittedUpdate{
+		SeqNum: seqNum,
+		CommittedUpdateBody: wtdb.CommittedUpdateBody{
+			BackupID: wtdb.BackupID{
+				ChanID:       chanID,
+				CommitHeight: 666,
+			},
+			Hint:          hint,
+			EncryptedBlob: encBlob,
+		},
+	}
+}
+
+// TestOpenClientDBOptions asserts that the options of OpenClientDB are applied
+// on top of defaults that don't retry, and that a database opened with them is
+// fully usable, both fresh and reopened.
+func TestOpenClientDBOptions(t *testing.T) {
+	cfg := wtdb.NewClientDBCfg()
+	require.Zero(t, cfg.CreateBucketsRetries)
+
+	wtdb.WithCreateBucketsRetry(3)(cfg)
+	require.Equal(t, 3, cfg.CreateBucketsRetries)
+
+	path := t.TempDir()
+
+	pk, err := randPubKey()
+	require.NoError(t, err)
+
+	db := openBoltClientDB(t, path, wtdb.WithCreateBucketsRetry(3))
+	tower, err := db.CreateTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     pseudoAddr,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db = openBoltClientDB(t, path, wtdb.WithCreateBucketsRetry(3))
+	tower2, err := db.LoadTower(pk)
+	require.NoError(t, err)
+	require.Equal(t, tower.ID, tower2.ID)
+}
+
+// TestBoltBackendDBTimeout asserts that the DBTimeout set through the backend
+// creator bounds how long opening a database that is locked by another opener
+// waits for the lock.
+func TestBoltBackendDBTimeout(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	dbCfg := &kvdb.BoltConfig{DBTimeout: kvdb.DefaultDBTimeout}
+	path := t.TempDir()
+
+	bdb, err := wtdb.NewBoltBackendCreator(true, path, "wtclient.db")(dbCfg)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		bdb.Close()
+	})
+
+	// While the database is held open, opening it again should give up
+	// once the overridden timeout expires, rather than waiting for the
+	// default one.
+	start := time.Now()
+	_, err = wtdb.NewBoltBackendCreator(
+		true, path, "wtclient.db", wtdb.WithBoltDBTimeout(timeout),
+	)(dbCfg)
+	require.ErrorContains(t, err, "timeout")
+	require.Less(t, time.Since(start), kvdb.DefaultDBTimeout)
+
+	// Once the database is released, it can be opened again.
+	require.NoError(t, bdb.Close())
+
+	bdb, err = wtdb.NewBoltBackendCreator(
+		true, path, "wtclient.db", wtdb.WithBoltDBTimeout(timeout),
+	)(dbCfg)
+	require.NoError(t, err)
+}
+
+// TestClientDBEncryptionKey asserts that the sweep and reward pkscripts of a
+// client database opened with an encryption key aren't stored in the clear,
+// and that they can be read back after reopening the database with the same
+// key.
+func TestClientDBEncryptionKey(t *testing.T) {
+	path := t.TempDir()
+
+	key := bytes.Repeat([]byte{0x42}, 32)
+
+	// A key of an invalid length should be rejected.
+	_, err := wtdb.OpenClientDB(
+		openBoltBackend(t, path),
+		wtdb.WithEncryptionKey([]byte{0x01, 0x02, 0x03}),
+	)
+	require.Error(t, err)
+
+	db := openBoltClientDB(t, path, wtdb.WithEncryptionKey(key))
+
+	var (
+		sweepPkScript     = bytes.Repeat([]byte{0xaa}, 22)
+		rewardPkScript    = bytes.Repeat([]byte{0xbb}, 22)
+		altRewardPkScript = bytes.Repeat([]byte{0xcc}, 22)
+		chanID            = lnwire.ChannelID{0x01}
+	)
+
+	require.NoError(t, db.RegisterChannel(chanID, sweepPkScript))
+
+	// The additional sweep pkscripts of a channel should be sealed as
+	// well.
+	var (
+		anchorPkScript = bytes.Repeat([]byte{0xdd}, 34)
+		multiChanID    = lnwire.ChannelID{0x02}
+	)
+	require.NoError(t, db.RegisterChannelMulti(
+		multiChanID, map[string][]byte{
+			wtdb.DefaultSweepScriptKind: sweepPkScript,
+			"anchor":                    anchorPkScript,
+		},
+	))
+
+	pk, err := randPubKey()
+	require.NoError(t, err)
+
+	tower, err := db.CreateTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     pseudoAddr,
+	})
+	require.NoError(t, err)
+
+	keyIndex, err := db.NextSessionKeyIndex(
+		tower.ID, blob.TypeAltruistCommit,
+	)
+	require.NoError(t, err)
+
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blob.TypeAltruistCommit,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: rewardPkScript,
+			KeyIndex:       keyIndex,
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	require.NoError(t, db.CreateClientSession(session))
+
+	// The caller's session must be left untouched.
+	require.Equal(t, rewardPkScript, session.RewardPkScript)
+
+	require.NoError(
+		t, db.AddSessionRewardScript(session.ID, altRewardPkScript),
+	)
+
+	// An update paying out to the alternate reward pkscript should be
+	// accepted, while one paying out to an unknown pkscript should not.
+	update := randCommittedUpdate(t, 1)
+	update.RewardPkScript = altRewardPkScript
+	_, err = db.CommitUpdate(&session.ID, update)
+	require.NoError(t, err)
+
+	update2 := randCommittedUpdate(t, 2)
+	update2.RewardPkScript = sweepPkScript
+	_, err = db.CommitUpdate(&session.ID, update2)
+	require.ErrorIs(t, err, wtdb.ErrUnknownRewardScript)
+
+	require.NoError(t, db.Close())
+
+	// None of the session and channel pkscripts should be found on disk.
+	dbBytes, err := os.ReadFile(filepath.Join(path, "wtclient.db"))
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(dbBytes, sweepPkScript))
+	require.False(t, bytes.Contains(dbBytes, rewardPkScript))
+	require.False(t, bytes.Contains(dbBytes, anchorPkScript))
+
+	// Reopening the database with the same key should yield the
+	// plaintext pkscripts.
+	db = openBoltClientDB(t, path, wtdb.WithEncryptionKey(key))
+
+	summaries, err := db.FetchChanSummaries()
+	require.NoError(t, err)
+	require.Equal(t, sweepPkScript, summaries[chanID].SweepPkScript)
+	require.Equal(
+		t, anchorPkScript,
+		summaries[multiChanID].SweepPkScriptFor("anchor"),
+	)
+
+	dbSession, err := db.GetClientSession(session.ID)
+	require.NoError(t, err)
+	require.Equal(t, rewardPkScript, dbSession.RewardPkScript)
+	require.Equal(
+		t, [][]byte{altRewardPkScript}, dbSession.AltRewardPkScripts,
+	)
+
+	// Re-registering the channel with the same pkscript is still a no-op.
+	require.NoError(t, db.RegisterChannel(chanID, sweepPkScript))
+	require.NoError(t, db.Close())
+
+	// Opening the database with a different key or without a key should
+	// fail before any pkscript is read.
+	_, err = wtdb.OpenClientDB(
+		openBoltBackend(t, path),
+		wtdb.WithEncryptionKey(bytes.Repeat([]byte{0x43}, 32)),
+	)
+	require.ErrorIs(t, err, wtdb.ErrEncryptionKeyMismatch)
+
+	_, err = wtdb.OpenClientDB(openBoltBackend(t, path))
+	require.ErrorIs(t, err, wtdb.ErrEncryptionKeyRequired)
+}
+
+// TestClientDBEncryptPlaintext asserts that the pkscripts of a client database
+// stored in the clear are encrypted the first time it's opened with a key.
+func TestClientDBEncryptPlaintext(t *testing.T) {
+	path := t.TempDir()
+
+	var (
+		key            = bytes.Repeat([]byte{0x42}, 32)
+		sweepPkScript  = bytes.Repeat([]byte{0xaa}, 22)
+		rewardPkScript = bytes.Repeat([]byte{0xbb}, 22)
+		chanID         = lnwire.ChannelID{0x01}
+	)
+
+	db := openBoltClientDB(t, path)
+	require.NoError(t, db.RegisterChannel(chanID, sweepPkScript))
+
+	pk, err := randPubKey()
+	require.NoError(t, err)
+
+	tower, err := db.CreateTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     pseudoAddr,
+	})
+	require.NoError(t, err)
+
+	keyIndex, err := db.NextSessionKeyIndex(
+		tower.ID, blob.TypeAltruistCommit,
+	)
+	require.NoError(t, err)
+
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blob.TypeAltruistCommit,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: rewardPkScript,
+			KeyIndex:       keyIndex,
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	require.NoError(t, db.CreateClientSession(session))
+	require.NoError(t, db.Close())
+
+	// Opening the database with a key for the first time should encrypt
+	// the pkscripts stored in the clear.
+	db = openBoltClientDB(t, path, wtdb.WithEncryptionKey(key))
+
+	summaries, err := db.FetchChanSummaries()
+	require.NoError(t, err)
+	require.Equal(t, sweepPkScript, summaries[chanID].SweepPkScript)
+
+	dbSession, err := db.GetClientSession(session.ID)
+	require.NoError(t, err)
+	require.Equal(t, rewardPkScript, dbSession.RewardPkScript)
+	require.NoError(t, db.Close())
+
+	// The pages that held the pkscripts in the clear may linger in the
+	// database file until they're reused, so the stored values are
+	// inspected instead.
+	bdb := openBoltBackend(t, path)
+
+	var walk func(bucket kvdb.RBucket) error
+	walk = func(bucket kvdb.RBucket) error {
+		return bucket.ForEach(func(k, v []byte) error {
+			if v == nil {
+				return walk(bucket.NestedReadBucket(k))
+			}
+
+			require.False(t, bytes.Contains(v, sweepPkScript))
+			require.False(t, bytes.Contains(v, rewardPkScript))
+
+			return nil
+		})
+	}
+	err = kvdb.View(bdb, func(tx kvdb.RTx) error {
+		return tx.ForEachBucket(func(k []byte) error {
+			return walk(tx.ReadBucket(k))
+		})
+	}, func() {})
+	require.NoError(t, err)
+	require.NoError(t, bdb.Close())
+
+	// From then on, the database can no longer be opened without the key.
+	_, err = wtdb.OpenClientDB(openBoltBackend(t, path))
+	require.ErrorIs(t, err, wtdb.ErrEncryptionKeyRequired)
+
+	db = openBoltClientDB(t, path, wtdb.WithEncryptionKey(key))
+	require.NoError(t, db.Close())
+}
+
+// TestClientDBRotateEncryptionKey asserts that rotating the encryption key of a
+// client database re-encrypts its pkscripts under the new key, and that a
+// rotation using the wrong old key leaves the database untouched.
+func TestClientDBRotateEncryptionKey(t *testing.T) {
+	path := t.TempDir()
+
+	var (
+		oldKey   = bytes.Repeat([]byte{0x42}, 32)
+		newKey   = bytes.Repeat([]byte{0x43}, 32)
+		wrongKey = bytes.Repeat([]byte{0x44}, 32)
+	)
+
+	var (
+		sweepPkScript     = bytes.Repeat([]byte{0xaa}, 22)
+		rewardPkScript    = bytes.Repeat([]byte{0xbb}, 22)
+		altRewardPkScript = bytes.Repeat([]byte{0xcc}, 22)
+		deletedPkScript   = bytes.Repeat([]byte{0xdd}, 22)
+		chanID            = lnwire.ChannelID{0x01}
+		deletedChanID     = lnwire.ChannelID{0x02}
+	)
+
+	db := openBoltClientDB(t, path, wtdb.WithEncryptionKey(oldKey))
+	require.NoError(t, db.RegisterChannel(chanID, sweepPkScript))
+
+	// The sweep pkscript of a soft-deleted channel is kept in its
+	// tombstone, which should be rotated as well.
+	require.NoError(t, db.RegisterChannel(deletedChanID, deletedPkScript))
+	require.NoError(
+		t, db.UnregisterChannel(deletedChanID, wtdb.WithSoftDelete()),
+	)
+
+	pk, err := randPubKey()
+	require.NoError(t, err)
+
+	tower, err := db.CreateTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     pseudoAddr,
+	})
+	require.NoError(t, err)
+
+	keyIndex, err := db.NextSessionKeyIndex(
+		tower.ID, blob.TypeAltruistCommit,
+	)
+	require.NoError(t, err)
+
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blob.TypeAltruistCommit,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: rewardPkScript,
+			KeyIndex:       keyIndex,
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	require.NoError(t, db.CreateClientSession(session))
+	require.NoError(
+		t, db.AddSessionRewardScript(session.ID, altRewardPkScript),
+	)
+
+	assertScripts := func(db *wtdb.ClientDB) {
+		t.Helper()
+
+		summaries, err := db.FetchChanSummaries(wtdb.WithTombstoned())
+		require.NoError(t, err)
+		require.Equal(t, sweepPkScript, summaries[chanID].SweepPkScript)
+		require.Equal(
+			t, deletedPkScript,
+			summaries[deletedChanID].SweepPkScript,
+		)
+
+		dbSession, err := db.GetClientSession(session.ID)
+		require.NoError(t, err)
+		require.Equal(t, rewardPkScript, dbSession.RewardPkScript)
+		require.Equal(
+			t, [][]byte{altRewardPkScript},
+			dbSession.AltRewardPkScripts,
+		)
+	}
+
+	// An invalid new key should be rejected.
+	err = db.RotateEncryptionKey(oldKey, []byte{0x01, 0x02, 0x03})
+	require.Error(t, err)
+
+	// Rotating with the wrong old key should fail without modifying the
+	// database, which should still be readable using the old key.
+	err = db.RotateEncryptionKey(wrongKey, newKey)
+	require.ErrorIs(t, err, wtdb.ErrEncryptionKeyMismatch)
+	assertScripts(db)
+
+	err = db.RotateEncryptionKey(nil, newKey)
+	require.ErrorIs(t, err, wtdb.ErrEncryptionKeyMismatch)
+	assertScripts(db)
+
+	// Rotating with the right old key should succeed, after which the
+	// database should transparently use the new key.
+	require.NoError(t, db.RotateEncryptionKey(oldKey, newKey))
+	assertScripts(db)
+	require.NoError(t, db.Close())
+
+	// The pkscripts still shouldn't be stored in the clear.
+	dbBytes, err := os.ReadFile(filepath.Join(path, "wtclient.db"))
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(dbBytes, sweepPkScript))
+	require.False(t, bytes.Contains(dbBytes, rewardPkScript))
+	require.False(t, bytes.Contains(dbBytes, altRewardPkScript))
+	require.False(t, bytes.Contains(dbBytes, deletedPkScript))
+
+	// Reopening the database with the new key should yield the plaintext
+	// pkscripts.
+	db = openBoltClientDB(t, path, wtdb.WithEncryptionKey(newKey))
+	assertScripts(db)
+	require.NoError(t, db.Close())
+
+	// The old key should no longer open the database.
+	_, err = wtdb.OpenClientDB(
+		openBoltBackend(t, path), wtdb.WithEncryptionKey(oldKey),
 	)
-	committedUpates := h.fetchSessionCommittedUpdates(&id, nil)
-	checkCommittedUpdates(h.t, committedUpates, expectedPending)
-	checkAckedUpdates(h.t, ackedUpdates, expectedAcked)
+	require.ErrorIs(t, err, wtdb.ErrEncryptionKeyMismatch)
+
+	// Rotating to a nil key should store the pkscripts in the clear again,
+	// after which the database should be opened without a key.
+	db = openBoltClientDB(t, path, wtdb.WithEncryptionKey(newKey))
+	require.NoError(t, db.RotateEncryptionKey(newKey, nil))
+	assertScripts(db)
+	require.NoError(t, db.Close())
+
+	dbBytes, err = os.ReadFile(filepath.Join(path, "wtclient.db"))
+	require.NoError(t, err)
+	require.True(t, bytes.Contains(dbBytes, sweepPkScript))
+
+	db = openBoltClientDB(t, path)
+	assertScripts(db)
+	require.NoError(t, db.Close())
 }
 
-// checkCommittedUpdates asserts that the CommittedUpdates on session match the
-// expUpdates provided.
-func checkCommittedUpdates(t *testing.T, actualUpdates,
-	expUpdates []wtdb.CommittedUpdate) {
+// TestCachedDB asserts that the channel summaries read through a CachedDB are
+// served from its cache, and that the cache is invalidated when the summaries
+// are modified through it.
+func TestCachedDB(t *testing.T) {
+	db := openBoltClientDB(t, t.TempDir())
+
+	const maxEntries = 2
+	cachedDB := wtdb.NewCachedDB(db, maxEntries)
+
+	var (
+		chanID1 = lnwire.ChannelID{0x01}
+		chanID2 = lnwire.ChannelID{0x02}
+		chanID3 = lnwire.ChannelID{0x03}
+	)
+
+	// assertSummaries asserts that the summaries read through the cache
+	// are identical to those of the backing database.
+	assertSummaries := func() {
+		t.Helper()
+
+		expSummaries, err := db.FetchChanSummaries()
+		require.NoError(t, err)
+
+		summaries, err := cachedDB.FetchChanSummaries()
+		require.NoError(t, err)
+		require.Equal(t, expSummaries, summaries)
+
+		chanIDs := []lnwire.ChannelID{chanID1, chanID2, chanID3}
+		expSummaries, err = db.FetchChanSummariesForChannels(chanIDs)
+		require.NoError(t, err)
+
+		summaries, err = cachedDB.FetchChanSummariesForChannels(chanIDs)
+		require.NoError(t, err)
+		require.Equal(t, expSummaries, summaries)
+	}
+
+	// Register two channels and populate the cache.
+	require.NoError(t, cachedDB.RegisterChannel(chanID1, []byte{0x01}))
+	require.NoError(t, cachedDB.RegisterChannel(chanID2, []byte{0x02}))
+	assertSummaries()
+
+	// Modifying a summary returned from the cache shouldn't modify the
+	// cached summary.
+	summaries, err := cachedDB.FetchChanSummaries()
+	require.NoError(t, err)
+	summaries[chanID1].SweepPkScript[0] = 0xff
+	assertSummaries()
+
+	// Modifying the backing database directly bypasses the cache, which
+	// should keep serving the summaries it holds.
+	require.NoError(t, db.SetChannelPriority(chanID1, 1))
+	summaries, err = cachedDB.FetchChanSummaries()
+	require.NoError(t, err)
+	require.Equal(
+		t, wtdb.DefaultChannelPriority, summaries[chanID1].Priority,
+	)
+
+	// Modifying the summary through the cache should invalidate it.
+	require.NoError(t, cachedDB.SetChannelPriority(chanID1, 2))
+	assertSummaries()
+
+	summaries, err = cachedDB.FetchChanSummaries()
+	require.NoError(t, err)
+	require.EqualValues(t, 2, summaries[chanID1].Priority)
+
+	// So should unregistering and registering channels.
+	require.NoError(t, cachedDB.UnregisterChannel(chanID2))
+	assertSummaries()
+
+	chanErrs, err := cachedDB.RegisterChannels(map[lnwire.ChannelID][]byte{
+		chanID2: {0x02},
+		chanID3: {0x03},
+	})
+	require.NoError(t, err)
+	require.Empty(t, chanErrs)
+	assertSummaries()
+
+	// With more channels registered than fit in the cache, reads should
+	// still be identical to those of the backing database.
+	summaries, err = cachedDB.FetchChanSummaries()
+	require.NoError(t, err)
+	require.Len(t, summaries, maxEntries+1)
+
+	require.NoError(t, cachedDB.UnregisterChannel(chanID3))
+	assertSummaries()
+}
+
+// clientDBOptsInit is a closure used to initialize a wtclient.DB instance
+// stored under the given path using the given options.
+type clientDBOptsInit func(t *testing.T, path string,
+	opts ...wtdb.ClientDBOption) wtclient.DB
+
+// TestClientDBMaxPendingUpdates asserts that a session can't hold more
+// unacked committed updates than allowed by WithMaxPendingUpdates, and that
+// acking updates makes room for new ones.
+func TestClientDBMaxPendingUpdates(t *testing.T) {
+	t.Run("clientdb", func(t *testing.T) {
+		testMaxPendingUpdates(t, func(t *testing.T, path string,
+			opts ...wtdb.ClientDBOption) wtclient.DB {
+
+			return openBoltClientDB(t, path, opts...)
+		})
+	})
+
+	t.Run("mock", func(t *testing.T) {
+		testMaxPendingUpdates(t, func(t *testing.T, _ string,
+			opts ...wtdb.ClientDBOption) wtclient.DB {
+
+			return wtmock.NewClientDB(opts...)
+		})
+	})
+}
+
+// testMaxPendingUpdates asserts the behavior of WithMaxPendingUpdates for the
+// databases created by the given init closure.
+func testMaxPendingUpdates(t *testing.T, init clientDBOptsInit) {
+	const maxPending = 2
+
+	newSession := func(h *clientDBHarness) *wtdb.ClientSession {
+		tower := h.newTower()
+		session := &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID:        tower.ID,
+				Policy:         wtpolicy.DefaultPolicy(),
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+				KeyIndex: h.nextKeyIndex(
+					tower.ID, blob.TypeAltruistCommit,
+				),
+			},
+			ID: wtdb.SessionID([33]byte{0x01}),
+		}
+		h.insertSession(session, nil)
+
+		return session
+	}
+
+	h := newClientDBHarness(t, func(t *testing.T, path string) wtclient.DB {
+		return init(t, path, wtdb.WithMaxPendingUpdates(maxPending))
+	})
+	session := newSession(h)
+
+	// Commit updates until the backlog reaches the cap.
+	update1 := randCommittedUpdate(t, 1)
+	h.commitUpdate(&session.ID, update1, nil)
+	h.commitUpdate(&session.ID, randCommittedUpdate(t, 2), nil)
+
+	// Committing past the cap should fail, while retransmitting a
+	// committed update still succeeds.
+	update3 := randCommittedUpdate(t, 3)
+	h.commitUpdate(&session.ID, update3, wtdb.ErrTooManyPendingUpdates)
+	h.commitUpdate(&session.ID, update1, nil)
+
+	// A batch that would exceed the cap is rejected too.
+	h.commitUpdates(
+		&session.ID, []*wtdb.CommittedUpdate{update3},
+		wtdb.ErrTooManyPendingUpdates,
+	)
+
+	// Once an update is acked, there's room for another one, but only
+	// one.
+	h.ackUpdate(&session.ID, 1, 1, nil)
+	h.commitUpdate(&session.ID, update3, nil)
+	h.commitUpdate(
+		&session.ID, randCommittedUpdate(t, 4),
+		wtdb.ErrTooManyPendingUpdates,
+	)
+
+	// Without the option, the backlog isn't capped.
+	h = newClientDBHarness(t, func(t *testing.T, path string) wtclient.DB {
+		return init(t, path)
+	})
+	session = newSession(h)
+
+	for i := uint16(1); i <= 2*maxPending; i++ {
+		h.commitUpdate(&session.ID, randCommittedUpdate(t, i), nil)
+	}
+}
+
+// TestClientDBClock asserts that the towers and sessions written to the
+// database are timestamped using the clock set by WithClock.
+func TestClientDBClock(t *testing.T) {
+	t.Run("clientdb", func(t *testing.T) {
+		testClock(t, func(t *testing.T, path string,
+			opts ...wtdb.ClientDBOption) wtclient.DB {
+
+			return openBoltClientDB(t, path, opts...)
+		})
+	})
+
+	t.Run("mock", func(t *testing.T) {
+		testClock(t, func(t *testing.T, _ string,
+			opts ...wtdb.ClientDBOption) wtclient.DB {
+
+			return wtmock.NewClientDB(opts...)
+		})
+	})
+}
+
+// testClock asserts the behavior of WithClock for the databases created by the
+// given init closure.
+func testClock(t *testing.T, init clientDBOptsInit) {
+	createdAt := time.Unix(1700000000, 0)
+	clk := clock.NewTestClock(createdAt)
+
+	h := newClientDBHarness(t, func(t *testing.T, path string) wtclient.DB {
+		return init(t, path, wtdb.WithClock(clk))
+	})
+
+	tower := h.newTower()
+	require.True(t, createdAt.Equal(tower.CreatedAt))
+	require.True(t, createdAt.Equal(tower.LastModified))
+
+	loaded := h.loadTowerByID(tower.ID, nil)
+	require.True(t, createdAt.Equal(loaded.CreatedAt))
+
+	// Once the clock advances, modifying the tower should record the new
+	// time, while leaving its creation time untouched.
+	modifiedAt := createdAt.Add(time.Hour)
+	clk.SetTime(modifiedAt)
+
+	h.createTower(&lnwire.NetAddress{
+		IdentityKey: tower.IdentityKey,
+		Address: &net.TCPAddr{
+			IP: []byte{0x02, 0x00, 0x00, 0x00}, Port: 9911,
+		},
+	}, nil)
+
+	loaded = h.loadTowerByID(tower.ID, nil)
+	require.True(t, createdAt.Equal(loaded.CreatedAt))
+	require.True(t, modifiedAt.Equal(loaded.LastModified))
+
+	// Sessions are timestamped using the same clock.
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID:        tower.ID,
+			Policy:         wtpolicy.DefaultPolicy(),
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+			KeyIndex: h.nextKeyIndex(
+				tower.ID, blob.TypeAltruistCommit,
+			),
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	h.insertSession(session, nil)
+
+	loadedSession := h.getClientSession(session.ID, nil)
+	require.True(t, modifiedAt.Equal(loadedSession.CreatedAt))
+}
+
+// TestClientStateExportImport asserts that the state exported from a bolt
+// client database can be imported into a fresh mock database, and that an
+// archive isn't imported into a non-empty database unless asked to merge.
+func TestClientStateExportImport(t *testing.T) {
+	t.Parallel()
+
+	db := openBoltClientDB(t, t.TempDir())
+
+	var (
+		chanID        = lnwire.ChannelID{0x01}
+		sweepPkScript = bytes.Repeat([]byte{0xaa}, 22)
+	)
+	require.NoError(t, db.RegisterChannel(chanID, sweepPkScript))
+
+	pk, err := randPubKey()
+	require.NoError(t, err)
+
+	tower, err := db.CreateTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     pseudoAddr,
+	}, wtdb.WithTowerNickname("tower"))
+	require.NoError(t, err)
+
+	keyIndex, err := db.NextSessionKeyIndex(
+		tower.ID, blob.TypeAltruistCommit,
+	)
+	require.NoError(t, err)
+
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blob.TypeAltruistCommit,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: bytes.Repeat([]byte{0xbb}, 22),
+			KeyIndex:       keyIndex,
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	require.NoError(t, db.CreateClientSession(session))
+
+	// Commit two updates and ack the first, so that the session holds both
+	// a committed and an acked update.
+	for seqNum := uint16(1); seqNum <= 2; seqNum++ {
+		update := randCommittedUpdate(t, seqNum)
+		update.BackupID.ChanID = chanID
+
+		_, err := db.CommitUpdate(&session.ID, update)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.AckUpdate(&session.ID, 1, 1))
+
+	var archive bytes.Buffer
+	require.NoError(t, db.ExportClientState(&archive))
+
+	mock := wtmock.NewClientDB()
+	err = mock.ImportClientState(bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+
+	// The imported towers, sessions, updates and channel summaries should
+	// match those of the exporting database.
+	assertSameClientState(t, db, mock)
+
+	// The key index used by the imported session must not be reserved
+	// again by the importing database.
+	nextIndex, err := mock.NextSessionKeyIndex(
+		tower.ID, blob.TypeAltruistCommit,
+	)
+	require.NoError(t, err)
+	require.Greater(t, nextIndex, keyIndex)
+
+	// Importing the archive again should fail since the mock is no longer
+	// empty, and merging it should fail since the session already exists.
+	err = mock.ImportClientState(bytes.NewReader(archive.Bytes()))
+	require.ErrorIs(t, err, wtdb.ErrClientDBNotEmpty)
+
+	err = mock.ImportClientState(
+		bytes.NewReader(archive.Bytes()), wtdb.WithMerge(),
+	)
+	require.ErrorIs(t, err, wtdb.ErrClientSessionAlreadyExists)
+
+	// Finally, data that isn't an archive should be rejected.
+	_, err = wtdb.ReadClientState(bytes.NewReader([]byte("junk")))
+	require.ErrorIs(t, err, wtdb.ErrInvalidClientState)
+}
+
+// assertSameClientState asserts that the towers, sessions, updates and channel
+// summaries of the given databases match, disregarding the IDs of the towers.
+func assertSameClientState(t *testing.T, expected, actual wtclient.DB) {
+	t.Helper()
+
+	expTowers, err := expected.ListTowers()
+	require.NoError(t, err)
+	towers, err := actual.ListTowers()
+	require.NoError(t, err)
+	require.Len(t, towers, len(expTowers))
+	for i, tower := range towers {
+		expTower := expTowers[i]
+		require.Equal(t, expTower.IdentityKey, tower.IdentityKey)
+		require.Equal(t, expTower.Addresses, tower.Addresses)
+		require.Equal(t, expTower.Nickname, tower.Nickname)
+	}
+
+	expSessions, expAcked := listSessionsWithAcks(t, expected)
+	sessions, acked := listSessionsWithAcks(t, actual)
+	require.Len(t, sessions, len(expSessions))
+	require.Equal(t, expAcked, acked)
+
+	for id, expSession := range expSessions {
+		session, ok := sessions[id]
+		require.True(t, ok)
+		require.Equal(
+			t, expSession.ClientSessionBody,
+			session.ClientSessionBody,
+		)
+
+		expUpdates, err := expected.FetchSessionCommittedUpdates(&id)
+		require.NoError(t, err)
+		updates, err := actual.FetchSessionCommittedUpdates(&id)
+		require.NoError(t, err)
+		require.Equal(t, expUpdates, updates)
+	}
+
+	expSummaries, err := expected.FetchChanSummaries()
+	require.NoError(t, err)
+	summaries, err := actual.FetchChanSummaries()
+	require.NoError(t, err)
+	require.Equal(t, expSummaries, summaries)
+}
+
+// listSessionsWithAcks returns all sessions of the given database, along with
+// the acked updates of all sessions keyed by session ID and sequence number.
+func listSessionsWithAcks(t *testing.T, db wtclient.DB) (
+	map[wtdb.SessionID]*wtdb.ClientSession,
+	map[wtdb.SessionID]map[uint16]wtdb.BackupID) {
+
+	acked := make(map[wtdb.SessionID]map[uint16]wtdb.BackupID)
+	perAck := func(s *wtdb.ClientSession, seqNum uint16,
+		id wtdb.BackupID) {
+
+		if acked[s.ID] == nil {
+			acked[s.ID] = make(map[uint16]wtdb.BackupID)
+		}
+		acked[s.ID][seqNum] = id
+	}
+
+	sessions, err := db.ListClientSessions(
+		nil, wtdb.WithPerAckedUpdate(perAck),
+	)
+	require.NoError(t, err)
+
+	return sessions, acked
+}
+
+// TestClientDBIntegrity asserts that orphaned index entries and out-of-range
+// committed updates injected into a bolt client database are reported by
+// CheckClientDB, and removed by RepairClientDB.
+func TestClientDBIntegrity(t *testing.T) {
+	t.Parallel()
+
+	bdb := openBoltBackend(t, t.TempDir())
+	db, err := wtdb.OpenClientDB(bdb)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	pk, err := randPubKey()
+	require.NoError(t, err)
+
+	tower, err := db.CreateTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     pseudoAddr,
+	})
+	require.NoError(t, err)
+
+	keyIndex, err := db.NextSessionKeyIndex(
+		tower.ID, blob.TypeAltruistCommit,
+	)
+	require.NoError(t, err)
+
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blob.TypeAltruistCommit,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+			KeyIndex:       keyIndex,
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	require.NoError(t, db.CreateClientSession(session))
+
+	update := randCommittedUpdate(t, 1)
+	_, err = db.CommitUpdate(&session.ID, update)
+	require.NoError(t, err)
+
+	// A freshly populated database should be consistent.
+	report, err := db.CheckClientDB()
+	require.NoError(t, err)
+	require.True(t, report.IsClean())
+
+	// Inject an index entry of a session that doesn't exist into both the
+	// channel-to-session and tower-to-session indexes, along with a
+	// committed update under a sequence number the session never
+	// allocated.
+	var (
+		missingID     = wtdb.SessionID([33]byte{0x02})
+		orphanChanID  = lnwire.ChannelID{0x03}
+		invalidSeqNum = uint16(50)
+	)
+	invalidUpdate := randCommittedUpdate(t, invalidSeqNum)
+
+	err = kvdb.Update(bdb, func(tx kvdb.RwTx) error {
+		chanSessions := tx.ReadWriteBucket(
+			[]byte("client-channel-sessions-bucket"),
+		)
+		chanBkt, err := chanSessions.CreateBucketIfNotExists(
+			orphanChanID[:],
+		)
+		if err != nil {
+			return err
+		}
+		if err := chanBkt.Put(missingID[:], []byte{1}); err != nil {
+			return err
+		}
+
+		towerIndex := tx.ReadWriteBucket(
+			[]byte("client-tower-to-session-index-bucket"),
+		).NestedReadWriteBucket(tower.ID.Bytes())
+		if err := towerIndex.Put(missingID[:], []byte{1}); err != nil {
+			return err
+		}
+
+		commits := tx.ReadWriteBucket(
+			[]byte("client-session-bucket"),
+		).NestedReadWriteBucket(session.ID[:]).NestedReadWriteBucket(
+			[]byte("client-session-commits"),
+		)
+
+		var b bytes.Buffer
+		if err := invalidUpdate.Encode(&b); err != nil {
+			return err
+		}
+
+		var seqNum [2]byte
+		binary.BigEndian.PutUint16(seqNum[:], invalidSeqNum)
+
+		return commits.Put(seqNum[:], b.Bytes())
+	}, func() {})
+	require.NoError(t, err)
+
+	report, err = db.CheckClientDB()
+	require.NoError(t, err)
+	require.False(t, report.IsClean())
+	require.Equal(t, map[lnwire.ChannelID][]wtdb.SessionID{
+		orphanChanID: {missingID},
+	}, report.OrphanedChanSessions)
+	require.Equal(t, map[wtdb.TowerID][]wtdb.SessionID{
+		tower.ID: {missingID},
+	}, report.OrphanedTowerSessions)
+	require.Equal(t, map[wtdb.SessionID][]uint16{
+		session.ID: {invalidSeqNum},
+	}, report.InvalidCommittedUpdates)
+	require.Empty(t, report.SessionsMissingTower)
+
+	// Checking must not have modified the database.
+	updates, err := db.FetchSessionCommittedUpdates(&session.ID)
+	require.NoError(t, err)
+	require.Len(t, updates, 2)
+
+	require.NoError(t, db.RepairClientDB(report))
+
+	report, err = db.CheckClientDB()
+	require.NoError(t, err)
+	require.True(t, report.IsClean())
+
+	// The session's valid committed update must have survived the repair,
+	// recording the session's blob type.
+	stored := *update
+	stored.BlobType = blob.TypeAltruistCommit
+
+	updates, err = db.FetchSessionCommittedUpdates(&session.ID)
+	require.NoError(t, err)
+	require.Equal(t, []wtdb.CommittedUpdate{stored}, updates)
+
+	sessionIDs, err := db.SessionsForChannel(orphanChanID)
+	require.NoError(t, err)
+	require.Empty(t, sessionIDs)
+}
+
+// TestSessionEventDispatcher asserts that events that don't fit in a
+// subscriber's buffer are dropped and counted, rather than blocking the caller.
+func TestSessionEventDispatcher(t *testing.T) {
+	d := wtdb.NewSessionEventDispatcher()
+
+	// Notifying without any subscribers is a no-op.
+	d.Notify(wtdb.SessionEvent{})
+	require.Zero(t, d.NumDropped())
+
+	events, cancel := d.Subscribe()
+	defer cancel()
+
+	// Fill the subscriber's buffer without reading from it, then send
+	// a few more events, which should be dropped.
+	const numDropped = 3
+	numBuffered := cap(events)
+	for i := 0; i < numBuffered+numDropped; i++ {
+		d.Notify(wtdb.SessionEvent{
+			SessionID: wtdb.SessionID{byte(i)},
+		})
+	}
+	require.EqualValues(t, numDropped, d.NumDropped())
+
+	// The buffered events are delivered in order.
+	for i := 0; i < numBuffered; i++ {
+		event := <-events
+		require.Equal(t, wtdb.SessionID{byte(i)}, event.SessionID)
+	}
+
+	// Canceling twice is safe.
+	cancel()
+	cancel()
+	_, ok := <-events
+	require.False(t, ok)
+}
+
+// TestClientDBReadView asserts that the client DB can be inspected through its
+// read-only view while a write transaction is in flight.
+func TestClientDBReadView(t *testing.T) {
+	bdb := openBoltBackend(t, t.TempDir())
+	db, err := wtdb.OpenClientDB(bdb)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	// The view should satisfy the client's read-only DB interface.
+	var view wtclient.DBView = db.ReadView()
+
+	pk, err := randPubKey()
+	require.NoError(t, err)
+
+	tower, err := db.CreateTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     pseudoAddr,
+	})
+	require.NoError(t, err)
+
+	var chanID lnwire.ChannelID
+	require.NoError(t, db.RegisterChannel(chanID, []byte{0x01}))
+
+	// Start a write transaction that is held open until we've finished
+	// reading through the view.
+	writeStarted := make(chan struct{})
+	release := make(chan struct{})
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- kvdb.Update(bdb, func(tx kvdb.RwTx) error {
+			close(writeStarted)
+			<-release
+
+			return nil
+		}, func() {})
+	}()
+
+	select {
+	case <-writeStarted:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("write transaction not started")
+	}
+
+	// Reads through the view must not block on the pending write.
+	towers, err := view.ListTowers()
+	require.NoError(t, err)
+	require.Equal(t, []*wtdb.Tower{tower}, towers)
 
-	t.Helper()
+	sessions, err := view.ListClientSessions(nil)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
 
-	// We promote nil expUpdates to an initialized slice since the database
-	// should never return a nil slice. This promotion is done purely out of
-	// convenience for the testing framework.
-	if expUpdates == nil {
-		expUpdates = make([]wtdb.CommittedUpdate, 0)
-	}
+	summaries, err := view.FetchChanSummaries()
+	require.NoError(t, err)
+	require.Contains(t, summaries, chanID)
 
-	require.Equal(t, expUpdates, actualUpdates)
+	var sessionID wtdb.SessionID
+	_, err = view.FetchSessionCommittedUpdates(&sessionID)
+	require.ErrorIs(t, err, wtdb.ErrClientSessionNotFound)
+
+	close(release)
+	require.NoError(t, <-errChan)
 }
 
-// checkAckedUpdates asserts that the AckedUpdates on a session match the
-// expUpdates provided.
-func checkAckedUpdates(t *testing.T, actualUpdates,
-	expUpdates map[uint16]wtdb.BackupID) {
+// TestClientSessionListOptionsParity asserts that the bolt and mock client DBs
+// apply every ClientSessionListOption identically when listing or fetching
+// sessions.
+func TestClientSessionListOptionsParity(t *testing.T) {
+	boltDB := openBoltClientDB(t, t.TempDir())
 
-	// We promote nil expUpdates to an initialized map since the database
-	// should never return a nil map. This promotion is done purely out of
-	// convenience for the testing framework.
-	if expUpdates == nil {
-		expUpdates = make(map[uint16]wtdb.BackupID)
-	}
+	dbs := []wtclient.DB{boltDB, wtmock.NewClientDB()}
 
-	require.Equal(t, expUpdates, actualUpdates)
-}
+	// Generate the towers, sessions and updates up front so that both
+	// databases are populated with exactly the same contents.
+	const numTowers = 2
+	towerKeys := make([]*btcec.PublicKey, numTowers)
+	for i := range towerKeys {
+		pk, err := randPubKey()
+		require.NoError(t, err)
 
-// TestClientDB asserts the behavior of a fresh client db, a reopened client db,
-// and the mock implementation. This ensures that all databases function
-// identically, especially in the negative paths.
-func TestClientDB(t *testing.T) {
-	dbCfg := &kvdb.BoltConfig{DBTimeout: kvdb.DefaultDBTimeout}
-	dbs := []struct {
-		name string
-		init clientDBInit
-	}{
-		{
-			name: "fresh clientdb",
-			init: func(t *testing.T) wtclient.DB {
-				bdb, err := wtdb.NewBoltBackendCreator(
-					true, t.TempDir(), "wtclient.db",
-				)(dbCfg)
-				require.NoError(t, err)
+		towerKeys[i] = pk
+	}
 
-				db, err := wtdb.OpenClientDB(bdb)
-				require.NoError(t, err)
+	const (
+		blobType         = blob.TypeAltruistCommit
+		sessionsPerTower = 3
+	)
+	sessionID := func(tower, n int) wtdb.SessionID {
+		return wtdb.SessionID([33]byte{
+			byte(tower*sessionsPerTower + n),
+		})
+	}
 
-				t.Cleanup(func() {
-					db.Close()
-				})
+	updates := make(map[wtdb.SessionID][]*wtdb.CommittedUpdate)
+	for i := 0; i < numTowers*sessionsPerTower; i++ {
+		id := sessionID(0, i+1)
+		for seqNum := uint16(1); seqNum <= 3; seqNum++ {
+			updates[id] = append(
+				updates[id], randCommittedUpdate(t, seqNum),
+			)
+		}
+	}
 
-				return db
+	newSession := func(towerID wtdb.TowerID, keyIndex uint32,
+		id wtdb.SessionID) *wtdb.ClientSession {
+
+		return &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: towerID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+				KeyIndex:       keyIndex,
 			},
-		},
-		{
-			name: "reopened clientdb",
-			init: func(t *testing.T) wtclient.DB {
-				path := t.TempDir()
+			ID: id,
+		}
+	}
 
-				bdb, err := wtdb.NewBoltBackendCreator(
-					true, path, "wtclient.db",
-				)(dbCfg)
+	for _, db := range dbs {
+		for i, pk := range towerKeys {
+			tower, err := db.CreateTower(&lnwire.NetAddress{
+				IdentityKey: pk,
+				Address:     pseudoAddr,
+			})
+			require.NoError(t, err)
+
+			for j := 0; j < sessionsPerTower; j++ {
+				keyIndex, err := db.NextSessionKeyIndex(
+					tower.ID, blobType,
+				)
 				require.NoError(t, err)
 
-				db, err := wtdb.OpenClientDB(bdb)
+				id := sessionID(i, j+1)
+				session := newSession(tower.ID, keyIndex, id)
+
+				// The first session of each tower is rotated
+				// out by the last one, leaving it exhausted.
+				if j == sessionsPerTower-1 {
+					err = db.RotateSession(
+						sessionID(i, 1), session,
+					)
+				} else {
+					err = db.CreateClientSession(session)
+				}
 				require.NoError(t, err)
-				db.Close()
 
-				bdb, err = wtdb.NewBoltBackendCreator(
-					true, path, "wtclient.db",
-				)(dbCfg)
-				require.NoError(t, err)
+				// Commit all of the session's updates, acking
+				// only the first.
+				for _, update := range updates[id] {
+					_, err := db.CommitUpdate(&id, update)
+					require.NoError(t, err)
+				}
+				require.NoError(t, db.AckUpdate(&id, 1, 1))
+			}
+		}
+	}
 
-				db, err = wtdb.OpenClientDB(bdb)
-				require.NoError(t, err)
+	// collect lists the sessions of the given DB, returning the sessions
+	// along with a log of the call-backs that were executed.
+	type callback struct {
+		session wtdb.SessionID
+		seqNum  uint16
+		acked   bool
+	}
+	collect := func(db wtclient.DB, tower *wtdb.TowerID, single bool,
+		opts ...wtdb.ClientSessionListOption) (
+		map[wtdb.SessionID]*wtdb.ClientSession, []callback) {
 
-				t.Cleanup(func() {
-					db.Close()
+		var log []callback
+		opts = append(opts,
+			wtdb.WithPerAckedUpdate(func(s *wtdb.ClientSession,
+				seqNum uint16, _ wtdb.BackupID) {
+
+				log = append(log, callback{
+					s.ID, seqNum, true,
 				})
+			}),
+			wtdb.WithPerCommittedUpdate(func(s *wtdb.ClientSession,
+				u *wtdb.CommittedUpdate) {
 
-				return db
-			},
-		},
-		{
-			name: "mock",
-			init: func(t *testing.T) wtclient.DB {
-				return wtmock.NewClientDB()
-			},
-		},
+				log = append(log, callback{
+					s.ID, u.SeqNum, false,
+				})
+			}),
+		)
+
+		var sessions map[wtdb.SessionID]*wtdb.ClientSession
+		if single {
+			id := wtdb.SessionID([33]byte{0x02})
+			session, err := db.GetClientSession(id, opts...)
+			require.NoError(t, err)
+
+			sessions = map[wtdb.SessionID]*wtdb.ClientSession{
+				id: session,
+			}
+		} else {
+			var err error
+			sessions, err = db.ListClientSessions(tower, opts...)
+			require.NoError(t, err)
+		}
+
+		// Strip the fields that legitimately differ between the
+		// backends before the results are compared.
+		for _, s := range sessions {
+			require.NotNil(t, s.Tower)
+			s.Tower = nil
+			s.CreatedAt = time.Time{}
+			s.LastUpdated = time.Time{}
+		}
+
+		return sessions, log
 	}
 
+	towerID := wtdb.TowerID(2)
 	tests := []struct {
-		name string
-		run  func(*clientDBHarness)
+		name   string
+		tower  *wtdb.TowerID
+		single bool
+		opts   []wtdb.ClientSessionListOption
 	}{
 		{
-			name: "create client session",
-			run:  testCreateClientSession,
+			name: "all sessions",
 		},
 		{
-			name: "filter client sessions",
-			run:  testFilterClientSessions,
+			name:  "tower sessions",
+			tower: &towerID,
 		},
 		{
-			name: "create tower",
-			run:  testCreateTower,
+			name: "filter status",
+			opts: []wtdb.ClientSessionListOption{
+				wtdb.WithPostEvalFilterStatus(
+					wtdb.CSessionExhausted,
+				),
+			},
 		},
 		{
-			name: "remove tower",
-			run:  testRemoveTower,
+			name: "filter blob type",
+			opts: []wtdb.ClientSessionListOption{
+				wtdb.WithPostEvalFilterBlobType(blobType),
+			},
 		},
 		{
-			name: "chan summaries",
-			run:  testChanSummaries,
+			name: "paginate",
+			opts: []wtdb.ClientSessionListOption{
+				wtdb.WithPaginate(
+					wtdb.SessionID([33]byte{0x02}), 3,
+				),
+			},
 		},
 		{
-			name: "commit update",
-			run:  testCommitUpdate,
+			name:  "paginate tower sessions with filter",
+			tower: &towerID,
+			opts: []wtdb.ClientSessionListOption{
+				wtdb.WithPaginate(wtdb.SessionID{}, 1),
+				wtdb.WithPostEvalFilterStatus(
+					wtdb.CSessionActive,
+				),
+			},
 		},
 		{
-			name: "ack update",
-			run:  testAckUpdate,
+			name:   "get client session",
+			single: true,
 		},
 	}
 
-	for _, database := range dbs {
-		db := database
-		t.Run(db.name, func(t *testing.T) {
-			t.Parallel()
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			boltSessions, boltLog := collect(
+				dbs[0], test.tower, test.single, test.opts...,
+			)
+			mockSessions, mockLog := collect(
+				dbs[1], test.tower, test.single, test.opts...,
+			)
+
+			require.NotEmpty(t, boltSessions)
+			require.NotEmpty(t, boltLog)
+			require.Equal(t, boltSessions, mockSessions)
+			require.Equal(t, boltLog, mockLog)
+		})
+	}
+}
 
-			for _, test := range tests {
-				t.Run(test.name, func(t *testing.T) {
-					h := newClientDBHarness(t, db.init)
+// TestClientDBCommitUpdateTx asserts that an update committed within a
+// caller-supplied transaction is persisted atomically with the caller's other
+// writes, and is rolled back along with them.
+func TestClientDBCommitUpdateTx(t *testing.T) {
+	bdb := openBoltBackend(t, t.TempDir())
+	db, err := wtdb.OpenClientDB(bdb)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
 
-					test.run(h)
-				})
+	pk, err := randPubKey()
+	require.NoError(t, err)
+
+	tower, err := db.CreateTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     pseudoAddr,
+	})
+	require.NoError(t, err)
+
+	keyIndex, err := db.NextSessionKeyIndex(
+		tower.ID, blob.TypeAltruistCommit,
+	)
+	require.NoError(t, err)
+
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blob.TypeAltruistCommit,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
+			},
+			KeyIndex: keyIndex,
+		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	require.NoError(t, db.CreateClientSession(session))
+
+	var (
+		otherBkt   = []byte("other-bucket")
+		otherKey   = []byte("other-key")
+		otherValue = []byte("other-value")
+		errAbort   = errors.New("abort")
+	)
+
+	// commitWithin commits the update along with an unrelated write in
+	// a single transaction, which is aborted if abort is true.
+	commitWithin := func(update *wtdb.CommittedUpdate, abort bool) error {
+		return kvdb.Update(bdb, func(tx kvdb.RwTx) error {
+			bkt, err := tx.CreateTopLevelBucket(otherBkt)
+			if err != nil {
+				return err
+			}
+			if err := bkt.Put(otherKey, otherValue); err != nil {
+				return err
+			}
+
+			_, err = db.CommitUpdateTx(tx, &session.ID, update)
+			if err != nil {
+				return err
 			}
+
+			if abort {
+				return errAbort
+			}
+
+			return nil
+		}, func() {})
+	}
+
+	otherWritten := func() bool {
+		var written bool
+		err := kvdb.View(bdb, func(tx kvdb.RTx) error {
+			bkt := tx.ReadBucket(otherBkt)
+			written = bkt != nil && bkt.Get(otherKey) != nil
+
+			return nil
+		}, func() {
+			written = false
 		})
+		require.NoError(t, err)
+
+		return written
 	}
+
+	// Aborting the outer transaction should roll back both the update
+	// and the unrelated write.
+	update := randCommittedUpdate(t, 1)
+	require.ErrorIs(t, commitWithin(update, true), errAbort)
+	require.False(t, otherWritten())
+
+	updates, err := db.FetchSessionCommittedUpdates(&session.ID)
+	require.NoError(t, err)
+	require.Empty(t, updates)
+
+	// An update rejected by the database should fail the transaction,
+	// leaving the unrelated write unpersisted too.
+	invalid := randCommittedUpdate(t, 2)
+	require.ErrorIs(
+		t, commitWithin(invalid, false), wtdb.ErrCommitUnorderedUpdate,
+	)
+	require.False(t, otherWritten())
+
+	// Otherwise, both should be persisted.
+	require.NoError(t, commitWithin(update, false))
+	require.True(t, otherWritten())
+
+	updates, err = db.FetchSessionCommittedUpdates(&session.ID)
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	require.Equal(t, update.SeqNum, updates[0].SeqNum)
 }
 
-// randCommittedUpdate generates a random committed update.
-func randCommittedUpdate(t *testing.T, seqNum uint16) *wtdb.CommittedUpdate {
-	var chanID lnwire.ChannelID
-	_, err := io.ReadFull(crand.Reader, chanID[:])
+// TestListTowersOrderParity asserts that the bolt and mock databases list the
+// same towers in the same order of ascending TowerID, including after towers
+// have been removed.
+func TestListTowersOrderParity(t *testing.T) {
+	boltDB := openBoltClientDB(t, t.TempDir())
+
+	dbs := []wtclient.DB{boltDB, wtmock.NewClientDB()}
+
+	const numTowers = 12
+	towerKeys := make([]*btcec.PublicKey, numTowers)
+	for i := range towerKeys {
+		pk, err := randPubKey()
+		require.NoError(t, err)
+
+		towerKeys[i] = pk
+	}
+
+	// Create the first half of the towers, remove a few of them, which
+	// deletes them as they have no sessions, then create the rest.
+	createTowers := func(db wtclient.DB, keys []*btcec.PublicKey) {
+		for _, key := range keys {
+			_, err := db.CreateTower(&lnwire.NetAddress{
+				IdentityKey: key,
+				Address:     pseudoAddr,
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	for _, db := range dbs {
+		createTowers(db, towerKeys[:numTowers/2])
+		for _, i := range []int{0, 3} {
+			require.NoError(t, db.RemoveTower(towerKeys[i], nil))
+		}
+		createTowers(db, towerKeys[numTowers/2:])
+	}
+
+	listed := make([][]*wtdb.Tower, len(dbs))
+	for i, db := range dbs {
+		towers, err := db.ListTowers()
+		require.NoError(t, err)
+		require.Len(t, towers, numTowers-2)
+		require.True(t, sort.SliceIsSorted(towers, func(j, k int) bool {
+			return towers[j].ID < towers[k].ID
+		}))
+
+		listed[i] = towers
+	}
+
+	for i := range listed[0] {
+		require.Equal(t, listed[0][i].ID, listed[1][i].ID)
+		require.True(t, listed[0][i].IdentityKey.IsEqual(
+			listed[1][i].IdentityKey,
+		))
+	}
+}
+
+// TestMockClientDBFailPoint asserts that fail points set on the mock ClientDB
+// cause calls to the named method to fail without modifying its state.
+func TestMockClientDBFailPoint(t *testing.T) {
+	db := wtmock.NewClientDB()
+
+	pk, err := randPubKey()
 	require.NoError(t, err)
 
-	var hint blob.BreachHint
-	_, err = io.ReadFull(crand.Reader, hint[:])
+	tower, err := db.CreateTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     pseudoAddr,
+	})
 	require.NoError(t, err)
 
-	encBlob := make([]byte, blob.Size(blob.FlagCommitOutputs.Type()))
-	_, err = io.ReadFull(crand.Reader, encBlob)
+	keyIndex, err := db.NextSessionKeyIndex(
+		tower.ID, blob.TypeAltruistCommit,
+	)
 	require.NoError(t, err)
 
-	return &wtdb.CommittedUpdate{
-		SeqNum: seqNum,
-		CommittedUpdateBody: wtdb.CommittedUpdateBody{
-			BackupID: wtdb.BackupID{
-				ChanID:       chanID,
-				CommitHeight: 666,
+	session := &wtdb.ClientSession{
+		ClientSessionBody: wtdb.ClientSessionBody{
+			TowerID: tower.ID,
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blob.TypeAltruistCommit,
+					SweepFeeRate: testSweepFeeRate,
+				},
+				MaxUpdates: 100,
 			},
-			Hint:          hint,
-			EncryptedBlob: encBlob,
+			RewardPkScript: []byte{0x01, 0x02, 0x03},
+			KeyIndex:       keyIndex,
 		},
+		ID: wtdb.SessionID([33]byte{0x01}),
+	}
+	require.NoError(t, db.CreateClientSession(session))
+
+	// A one-shot fail point should only cause the next commit to fail,
+	// leaving no committed update behind.
+	errInjected := errors.New("injected failure")
+	db.SetFailPointOnce("CommitUpdate", errInjected)
+
+	update1 := randCommittedUpdate(t, 1)
+	_, err = db.CommitUpdate(&session.ID, update1)
+	require.ErrorIs(t, err, errInjected)
+
+	updates, err := db.FetchSessionCommittedUpdates(&session.ID)
+	require.NoError(t, err)
+	require.Empty(t, updates)
+
+	_, err = db.CommitUpdate(&session.ID, update1)
+	require.NoError(t, err)
+
+	// A persistent fail point should cause every commit to fail until it
+	// is cleared, without affecting any other method.
+	db.SetFailPoint("CommitUpdate", wtdb.ErrTowerUnackedUpdates)
+
+	update2 := randCommittedUpdate(t, 2)
+	for i := 0; i < 2; i++ {
+		_, err = db.CommitUpdate(&session.ID, update2)
+		require.ErrorIs(t, err, wtdb.ErrTowerUnackedUpdates)
+	}
+	require.NoError(t, db.AckUpdate(&session.ID, 1, 1))
+
+	db.SetFailPoint("CommitUpdate", nil)
+	_, err = db.CommitUpdate(&session.ID, update2)
+	require.NoError(t, err)
+}
+
+// TestClientDBBatchInterval asserts that updates committed and acked
+// concurrently by a client database opened with WithBatchInterval can be read
+// back, both before and after a restart.
+func TestClientDBBatchInterval(t *testing.T) {
+	const (
+		blobType    = blob.TypeAltruistCommit
+		numSessions = 4
+		numUpdates  = 5
+		numAcks     = 3
+	)
+
+	path := t.TempDir()
+
+	pk, err := randPubKey()
+	require.NoError(t, err)
+
+	db := openBoltClientDB(
+		t, path, wtdb.WithBatchInterval(10*time.Millisecond),
+	)
+	tower, err := db.CreateTower(&lnwire.NetAddress{
+		IdentityKey: pk,
+		Address:     pseudoAddr,
+	})
+	require.NoError(t, err)
+
+	sessions := make([]*wtdb.ClientSession, 0, numSessions)
+	updates := make(map[wtdb.SessionID][]*wtdb.CommittedUpdate)
+	for i := 0; i < numSessions; i++ {
+		keyIndex, err := db.NextSessionKeyIndex(tower.ID, blobType)
+		require.NoError(t, err)
+
+		session := &wtdb.ClientSession{
+			ClientSessionBody: wtdb.ClientSessionBody{
+				TowerID: tower.ID,
+				Policy: wtpolicy.Policy{
+					TxPolicy: wtpolicy.TxPolicy{
+						BlobType:     blobType,
+						SweepFeeRate: testSweepFeeRate,
+					},
+					MaxUpdates: 100,
+				},
+				RewardPkScript: []byte{0x01, 0x02, 0x03},
+				KeyIndex:       keyIndex,
+			},
+			ID: wtdb.SessionID([33]byte{byte(i + 1)}),
+		}
+		require.NoError(t, db.CreateClientSession(session))
+		sessions = append(sessions, session)
+
+		for seqNum := uint16(1); seqNum <= numUpdates; seqNum++ {
+			updates[session.ID] = append(
+				updates[session.ID],
+				randCommittedUpdate(t, seqNum),
+			)
+		}
+	}
+
+	// Commit and ack the updates of every session concurrently, so that
+	// their transactions can be batched together.
+	var wg sync.WaitGroup
+	errChan := make(chan error, numSessions)
+	for _, session := range sessions {
+		wg.Add(1)
+		go func(id wtdb.SessionID) {
+			defer wg.Done()
+
+			for _, update := range updates[id] {
+				_, err := db.CommitUpdate(&id, update)
+				if err != nil {
+					errChan <- err
+					return
+				}
+			}
+
+			for seqNum := uint16(1); seqNum <= numAcks; seqNum++ {
+				err := db.AckUpdate(&id, seqNum, seqNum)
+				if err != nil {
+					errChan <- err
+					return
+				}
+			}
+		}(session.ID)
+	}
+	wg.Wait()
+	close(errChan)
+
+	for err := range errChan {
+		require.NoError(t, err)
+	}
+
+	assertUpdates := func(db *wtdb.ClientDB) {
+		for _, session := range sessions {
+			committed, err := db.FetchSessionCommittedUpdates(
+				&session.ID,
+			)
+			require.NoError(t, err)
+
+			expCommitted := make([]wtdb.CommittedUpdate, 0)
+			for _, update := range updates[session.ID][numAcks:] {
+				expUpdate := *update
+				expUpdate.BlobType = blobType
+				expCommitted = append(expCommitted, expUpdate)
+			}
+			require.Equal(t, expCommitted, committed)
+
+			acked := make(map[uint16]wtdb.BackupID)
+			_, err = db.GetClientSession(
+				session.ID,
+				wtdb.WithPerAckedUpdate(perAckedUpdate(acked)),
+			)
+			require.NoError(t, err)
+
+			expAcked := make(map[uint16]wtdb.BackupID)
+			for _, update := range updates[session.ID][:numAcks] {
+				expAcked[update.SeqNum] = update.BackupID
+			}
+			require.Equal(t, expAcked, acked)
+		}
 	}
+
+	assertUpdates(db)
+	require.NoError(t, db.Close())
+
+	db = openBoltClientDB(t, path)
+	assertUpdates(db)
+}
+
+// TestMockClientDBPing asserts that pinging the mock ClientDB succeeds unless a
+// fail point is set for Ping.
+func TestMockClientDBPing(t *testing.T) {
+	db := wtmock.NewClientDB()
+	require.NoError(t, db.Ping())
+
+	errInjected := errors.New("injected failure")
+	db.SetFailPointOnce("Ping", errInjected)
+	require.ErrorIs(t, db.Ping(), errInjected)
+	require.NoError(t, db.Ping())
+}
+
+// TestClientDBVersionTooNew asserts that opening a client database whose
+// version is newer than the latest version known to this release fails with
+// ErrDBVersionTooNew, as would happen after a downgrade.
+func TestClientDBVersionTooNew(t *testing.T) {
+	path := t.TempDir()
+
+	db := openBoltClientDB(t, path)
+	version, err := db.Version()
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// Write the version marker of a future release, as if the database
+	// had been migrated by it before the downgrade.
+	bdb := openBoltBackend(t, path)
+	err = kvdb.Update(bdb, func(tx kvdb.RwTx) error {
+		metadata := tx.ReadWriteBucket([]byte("metadata-bucket"))
+		require.NotNil(t, metadata)
+
+		var versionBytes [4]byte
+		binary.BigEndian.PutUint32(versionBytes[:], version+1)
+
+		return metadata.Put([]byte("version"), versionBytes[:])
+	}, func() {})
+	require.NoError(t, err)
+	require.NoError(t, bdb.Close())
+
+	_, err = wtdb.OpenClientDB(openBoltBackend(t, path))
+	require.ErrorIs(t, err, wtdb.ErrDBVersionTooNew)
 }
@@ -0,0 +1,52 @@
+package wtdb
+
+import (
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// IntegrityReport describes the inconsistencies found by a scan of the client
+// database, such as index entries left dangling by a crash or by an older
+// version of the database. It is produced by CheckClientDB and consumed by
+// RepairClientDB.
+type IntegrityReport struct {
+	// OrphanedChanSessions maps channels to the sessions that the
+	// channel-to-session index lists for them, but that either don't
+	// exist or don't hold any committed or acked update of the channel.
+	OrphanedChanSessions map[lnwire.ChannelID][]SessionID
+
+	// OrphanedTowerSessions maps towers to the sessions that the
+	// tower-to-session index lists for them, but that don't exist.
+	OrphanedTowerSessions map[TowerID][]SessionID
+
+	// SessionsMissingTower holds the sessions whose tower doesn't exist.
+	SessionsMissingTower []SessionID
+
+	// InvalidCommittedUpdates maps sessions to the sequence numbers of
+	// their committed updates that lie outside of the range of sequence
+	// numbers allocated by the session.
+	InvalidCommittedUpdates map[SessionID][]uint16
+}
+
+// NewIntegrityReport returns an empty IntegrityReport.
+func NewIntegrityReport() *IntegrityReport {
+	return &IntegrityReport{
+		OrphanedChanSessions:    make(map[lnwire.ChannelID][]SessionID),
+		OrphanedTowerSessions:   make(map[TowerID][]SessionID),
+		InvalidCommittedUpdates: make(map[SessionID][]uint16),
+	}
+}
+
+// IsClean returns true if the report holds no inconsistencies.
+func (r *IntegrityReport) IsClean() bool {
+	return len(r.OrphanedChanSessions) == 0 &&
+		len(r.OrphanedTowerSessions) == 0 &&
+		len(r.SessionsMissingTower) == 0 &&
+		len(r.InvalidCommittedUpdates) == 0
+}
+
+// IsValidCommitSeqNum returns true if the given sequence number is one that
+// may be held by a committed update of the session, that is, one that the
+// session has already allocated.
+func (s *ClientSession) IsValidCommitSeqNum(seqNum uint16) bool {
+	return seqNum > 0 && seqNum <= s.SeqNum
+}
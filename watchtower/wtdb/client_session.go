@@ -1,15 +1,22 @@
 package wtdb
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"time"
 
+	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/lightningnetwork/lnd/keychain"
 	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/watchtower/blob"
 	"github.com/lightningnetwork/lnd/watchtower/wtpolicy"
 )
 
+// MaxRewardScripts is the maximum number of reward pkscripts, including its
+// RewardPkScript, that can be registered for a session.
+const MaxRewardScripts = 4
+
 // CSessionStatus is a bit-field representing the possible statuses of
 // ClientSessions.
 type CSessionStatus uint8
@@ -22,6 +29,11 @@ const (
 	// CSessionInactive indicates that the ClientSession is inactive and
 	// cannot be used for backups.
 	CSessionInactive CSessionStatus = 1
+
+	// CSessionExhausted indicates that the ClientSession has allocated all
+	// of its sequence numbers and cannot be used for any further backups.
+	// This status is terminal.
+	CSessionExhausted CSessionStatus = 2
 )
 
 // ClientSession encapsulates a SessionInfo returned from a successful
@@ -37,6 +49,15 @@ type ClientSession struct {
 
 	ClientSessionBody
 
+	// LastAckedSeqNum is the highest sequence number acked by the tower
+	// for the session, or 0 if no update has been acked yet. It allows
+	// delivery to resume without walking all of the session's acked
+	// updates, and isn't lowered if acked updates are later deleted.
+	//
+	// NOTE: This value is not serialized with the body of the struct. It
+	// is stored alongside the body and maintained as updates are acked.
+	LastAckedSeqNum uint16
+
 	// Tower holds the pubkey and address of the watchtower.
 	//
 	// NOTE: This value is not serialized. It is recovered by looking up the
@@ -80,13 +101,37 @@ type ClientSessionBody struct {
 
 	// RewardPkScript is the pkscript that the tower's reward will be
 	// deposited to if a sweep transaction confirms and the sessions
-	// specifies a reward output.
+	// specifies a reward output. It must be set for reward sessions, while
+	// altruist sessions, which don't pay the tower a reward, may leave it
+	// nil.
 	RewardPkScript []byte
+
+	// AltRewardPkScripts holds any additional reward pkscripts registered
+	// for the session, allowing the client to rotate its reward address
+	// without negotiating a new session. Committed updates may use either
+	// the RewardPkScript or any of these.
+	AltRewardPkScripts [][]byte
+
+	// CreatedAt is the time at which the session was created in the
+	// database. This is the zero time for sessions created before the
+	// field was introduced.
+	CreatedAt time.Time
+
+	// LastUpdated is the time at which an update for the session was last
+	// committed or acked. This is the zero time for sessions created
+	// before the field was introduced.
+	LastUpdated time.Time
+
+	// SessionKeyPub is the public key of the session key that the client
+	// uses for ECDH with the tower, as negotiated when the session was
+	// created. It is nil for sessions created before the key was stored,
+	// whose session key can only be re-derived from the KeyIndex.
+	SessionKeyPub *btcec.PublicKey
 }
 
 // Encode writes a ClientSessionBody to the passed io.Writer.
 func (s *ClientSessionBody) Encode(w io.Writer) error {
-	return WriteElements(w,
+	err := WriteElements(w,
 		s.SeqNum,
 		s.TowerLastApplied,
 		uint64(s.TowerID),
@@ -94,7 +139,36 @@ func (s *ClientSessionBody) Encode(w io.Writer) error {
 		uint8(s.Status),
 		s.Policy,
 		s.RewardPkScript,
+		timeToUnixNano(s.CreatedAt),
+		timeToUnixNano(s.LastUpdated),
 	)
+	if err != nil {
+		return err
+	}
+
+	// The alternate reward pkscripts are only written if there are any, so
+	// that the encoding of single-script sessions is left unchanged. They
+	// must however precede the session key if it is known.
+	if len(s.AltRewardPkScripts) == 0 && s.SessionKeyPub == nil {
+		return nil
+	}
+
+	err = WriteElement(w, uint8(len(s.AltRewardPkScripts)))
+	if err != nil {
+		return err
+	}
+
+	for _, pkScript := range s.AltRewardPkScripts {
+		if err := WriteElement(w, pkScript); err != nil {
+			return err
+		}
+	}
+
+	if s.SessionKeyPub == nil {
+		return nil
+	}
+
+	return WriteElement(w, s.SessionKeyPub)
 }
 
 // Decode reads a ClientSessionBody from the passed io.Reader.
@@ -119,9 +193,127 @@ func (s *ClientSessionBody) Decode(r io.Reader) error {
 	s.TowerID = TowerID(towerID)
 	s.Status = CSessionStatus(status)
 
+	// The timestamps are optional, since session bodies written before
+	// they were introduced won't have them.
+	var createdAt, lastUpdated uint64
+	err = ReadElement(r, &createdAt)
+	switch {
+	case err == io.EOF:
+		return nil
+
+	case err != nil:
+		return err
+	}
+
+	if err := ReadElement(r, &lastUpdated); err != nil {
+		return err
+	}
+
+	s.CreatedAt = timeFromUnixNano(createdAt)
+	s.LastUpdated = timeFromUnixNano(lastUpdated)
+
+	// Similarly, the alternate reward pkscripts are optional.
+	var numAltScripts uint8
+	err = ReadElement(r, &numAltScripts)
+	switch {
+	case err == io.EOF:
+		return nil
+
+	case err != nil:
+		return err
+	}
+
+	if numAltScripts > 0 {
+		s.AltRewardPkScripts = make([][]byte, numAltScripts)
+	}
+	for i := range s.AltRewardPkScripts {
+		err := ReadElement(r, &s.AltRewardPkScripts[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	// Finally, the session key is optional, since it wasn't stored for
+	// sessions created before it was introduced.
+	var sessionKeyPub *btcec.PublicKey
+	err = ReadElement(r, &sessionKeyPub)
+	switch {
+	case err == io.EOF:
+		return nil
+
+	case err != nil:
+		return err
+	}
+
+	s.SessionKeyPub = sessionKeyPub
+
 	return nil
 }
 
+// IsReward returns true if the session's blob type specifies a reward output
+// for the tower, in which case the session must have a RewardPkScript.
+func (s *ClientSessionBody) IsReward() bool {
+	return s.Policy.BlobType.Has(blob.FlagReward)
+}
+
+// AddRewardScript registers the given pkscript as an alternate reward pkscript
+// of the session. False is returned if the pkscript is already one of the
+// session's reward pkscripts, in which case the session is left unmodified.
+// ErrTooManyRewardScripts is returned if the session would end up with more
+// than MaxRewardScripts reward pkscripts.
+func (s *ClientSessionBody) AddRewardScript(pkScript []byte) (bool, error) {
+	if s.AcceptsRewardScript(pkScript) {
+		return false, nil
+	}
+
+	if 1+len(s.AltRewardPkScripts) >= MaxRewardScripts {
+		return false, ErrTooManyRewardScripts
+	}
+
+	s.AltRewardPkScripts = append(
+		s.AltRewardPkScripts, append([]byte(nil), pkScript...),
+	)
+
+	return true, nil
+}
+
+// AcceptsRewardScript returns true if the given pkscript is one of the
+// session's reward pkscripts. An empty pkscript refers to the session's
+// RewardPkScript, and is always accepted.
+func (s *ClientSessionBody) AcceptsRewardScript(pkScript []byte) bool {
+	if len(pkScript) == 0 || bytes.Equal(pkScript, s.RewardPkScript) {
+		return true
+	}
+
+	for _, altPkScript := range s.AltRewardPkScripts {
+		if bytes.Equal(pkScript, altPkScript) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// timeToUnixNano serializes the given time as the number of nanoseconds since
+// the unix epoch. The zero time is serialized as 0.
+func timeToUnixNano(t time.Time) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return uint64(t.UnixNano())
+}
+
+// timeFromUnixNano is the inverse of timeToUnixNano, mapping 0 back to the zero
+// time.
+func timeFromUnixNano(nanos uint64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, int64(nanos))
+}
+
 // BackupID identifies a particular revoked, remote commitment by channel id and
 // commitment height.
 type BackupID struct {
@@ -164,6 +356,41 @@ type CommittedUpdate struct {
 	CommittedUpdateBody
 }
 
+// CommitConflictError signals that an update was committed using a sequence
+// number that was already committed to an update with a different breach hint.
+// It describes both sides of the conflict, and wraps ErrUpdateAlreadyCommitted.
+type CommitConflictError struct {
+	// SeqNum is the sequence number of the conflicting updates.
+	SeqNum uint16
+
+	// ExistingHint is the breach hint of the update that was already
+	// committed at SeqNum.
+	ExistingHint blob.BreachHint
+
+	// ExistingBackupID identifies the commitment backed up by the update
+	// that was already committed at SeqNum.
+	ExistingBackupID BackupID
+
+	// Hint is the breach hint of the rejected update.
+	Hint blob.BreachHint
+
+	// BackupID identifies the commitment backed up by the rejected update.
+	BackupID BackupID
+}
+
+// Error returns a human-readable description of the conflict.
+func (e *CommitConflictError) Error() string {
+	return fmt.Sprintf("%v: seqnum %d holds hint %v for %v, rejected "+
+		"hint %v for %v", ErrUpdateAlreadyCommitted, e.SeqNum,
+		e.ExistingHint, e.ExistingBackupID, e.Hint, e.BackupID)
+}
+
+// Unwrap returns ErrUpdateAlreadyCommitted, allowing the conflict to be
+// detected using errors.Is.
+func (e *CommitConflictError) Unwrap() error {
+	return ErrUpdateAlreadyCommitted
+}
+
 // CommittedUpdateBody represents the primary components of a CommittedUpdate.
 // On disk, this is stored under the sequence number, which acts as its key.
 type CommittedUpdateBody struct {
@@ -178,6 +405,18 @@ type CommittedUpdateBody struct {
 	// exacting justice if the commitment transaction matching the breach
 	// hint is broadcast.
 	EncryptedBlob []byte
+
+	// RewardPkScript is the reward pkscript used by the justice transaction
+	// of the update, which must be one of the session's reward pkscripts.
+	// It is empty if the update uses the session's RewardPkScript, or was
+	// committed before alternate reward pkscripts were introduced.
+	RewardPkScript []byte
+
+	// BlobType is the blob type under which EncryptedBlob was encrypted,
+	// which is that of the session's policy. Updates committed before the
+	// blob type was recorded are assigned the session's blob type when
+	// they're read from the database.
+	BlobType blob.Type
 }
 
 // Encode writes the CommittedUpdateBody to the passed io.Writer.
@@ -187,10 +426,32 @@ func (u *CommittedUpdateBody) Encode(w io.Writer) error {
 		return err
 	}
 
-	return WriteElements(w,
+	err = WriteElements(w,
 		u.Hint,
 		u.EncryptedBlob,
 	)
+	if err != nil {
+		return err
+	}
+
+	// The reward pkscript is only written if it is set, so that updates
+	// using the session's RewardPkScript keep their original encoding. It
+	// is always written if a blob type follows it, in which case an empty
+	// pkscript denotes the session's RewardPkScript.
+	if len(u.RewardPkScript) == 0 && u.BlobType == 0 {
+		return nil
+	}
+
+	err = WriteElement(w, u.RewardPkScript)
+	if err != nil {
+		return err
+	}
+
+	if u.BlobType == 0 {
+		return nil
+	}
+
+	return WriteElement(w, uint16(u.BlobType))
 }
 
 // Decode reads a CommittedUpdateBody from the passed io.Reader.
@@ -200,8 +461,43 @@ func (u *CommittedUpdateBody) Decode(r io.Reader) error {
 		return err
 	}
 
-	return ReadElements(r,
+	err = ReadElements(r,
 		&u.Hint,
 		&u.EncryptedBlob,
 	)
+	if err != nil {
+		return err
+	}
+
+	// The reward pkscript is optional, since it is only written for updates
+	// that don't use the session's RewardPkScript.
+	err = ReadElement(r, &u.RewardPkScript)
+	switch {
+	case err == io.EOF:
+		return nil
+
+	case err != nil:
+		return err
+	}
+
+	// An empty reward pkscript is only written to precede the blob type.
+	if len(u.RewardPkScript) == 0 {
+		u.RewardPkScript = nil
+	}
+
+	// The blob type is optional, since updates committed before it was
+	// recorded don't carry one.
+	var blobType uint16
+	err = ReadElement(r, &blobType)
+	switch {
+	case err == io.EOF:
+		return nil
+
+	case err != nil:
+		return err
+	}
+
+	u.BlobType = blob.Type(blobType)
+
+	return nil
 }
@@ -0,0 +1,156 @@
+package wtdb
+
+// ClientSessionSource abstracts the retrieval of client sessions and their
+// updates from a client DB backend. This allows all backends to apply the
+// ClientSessionListOptions identically, using ListClientSessionsFromSource and
+// GetClientSessionFromSource.
+type ClientSessionSource interface {
+	// ForEachSessionID calls next with each of the source's session IDs
+	// that sort strictly after the given offset, in ascending order. A nil
+	// offset starts from the first session ID. Iteration stops early once
+	// next returns false or an error.
+	ForEachSessionID(offset *SessionID,
+		next func(SessionID) (bool, error)) error
+
+	// FetchSession loads the session with the given ID along with its
+	// tower. ErrClientSessionNotFound is returned if the session does not
+	// exist.
+	FetchSession(id SessionID) (*ClientSession, error)
+
+	// ForEachCommittedUpdate calls cb with each of the session's committed
+	// updates, in ascending order of sequence number.
+	ForEachCommittedUpdate(id SessionID, cb func(*CommittedUpdate)) error
+
+	// ForEachAckedUpdate calls cb with each of the session's acked
+	// updates, in ascending order of sequence number.
+	ForEachAckedUpdate(id SessionID, cb func(uint16, BackupID)) error
+}
+
+// ListClientSessionsFromSource lists the sessions of the given source,
+// applying the given options. The PerAckedUpdate and PerCommittedUpdate
+// call-backs are evaluated for every session visited, before the session is
+// subjected to the PostEvalFilterStatus and PostEvalFilterBlobType filters.
+// If pagination is requested, at most a page worth of sessions passing the
+// filters is returned.
+func ListClientSessionsFromSource(src ClientSessionSource,
+	opts ...ClientSessionListOption) (map[SessionID]*ClientSession, error) {
+
+	cfg := NewClientSessionCfg()
+	for _, o := range opts {
+		o(cfg)
+	}
+
+	var offset *SessionID
+	if cfg.Pagination != nil {
+		offset = &cfg.Pagination.Offset
+	}
+
+	sessions := make(map[SessionID]*ClientSession)
+	err := src.ForEachSessionID(offset, func(id SessionID) (bool, error) {
+		if cfg.Pagination != nil &&
+			len(sessions) >= cfg.Pagination.Limit {
+
+			return false, nil
+		}
+
+		session, err := loadClientSession(src, id, cfg)
+		if err != nil {
+			return false, err
+		}
+
+		if cfg.MatchesStatus(session.Status) &&
+			cfg.MatchesBlobType(session.Policy.BlobType) {
+
+			sessions[id] = session
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// ListClientSessionsGroupedFromSource lists all sessions of the given source in
+// a single pass, grouped by their status. Within each group, the sessions are
+// ordered by their IDs.
+func ListClientSessionsGroupedFromSource(src ClientSessionSource) (
+	map[CSessionStatus][]*ClientSession, error) {
+
+	cfg := NewClientSessionCfg()
+
+	grouped := make(map[CSessionStatus][]*ClientSession)
+	err := src.ForEachSessionID(nil, func(id SessionID) (bool, error) {
+		session, err := loadClientSession(src, id, cfg)
+		if err != nil {
+			return false, err
+		}
+
+		grouped[session.Status] = append(
+			grouped[session.Status], session,
+		)
+
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return grouped, nil
+}
+
+// GetClientSessionFromSource loads the session with the given ID from the
+// source, passing its updates through the call-backs of the given options.
+// The post-evaluation filters and pagination don't apply to a single session.
+func GetClientSessionFromSource(src ClientSessionSource, id SessionID,
+	opts ...ClientSessionListOption) (*ClientSession, error) {
+
+	cfg := NewClientSessionCfg()
+	for _, o := range opts {
+		o(cfg)
+	}
+
+	return loadClientSession(src, id, cfg)
+}
+
+// loadClientSession loads the session with the given ID from the source, and
+// passes its committed and acked updates through any call-backs set on the
+// given config, unless only the session's body was requested.
+func loadClientSession(src ClientSessionSource, id SessionID,
+	cfg *ClientSessionListCfg) (*ClientSession, error) {
+
+	session, err := src.FetchSession(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.BodiesOnly {
+		return session, nil
+	}
+
+	if cfg.PerCommittedUpdate != nil {
+		err := src.ForEachCommittedUpdate(
+			id, func(update *CommittedUpdate) {
+				cfg.PerCommittedUpdate(session, update)
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.PerAckedUpdate != nil {
+		err := src.ForEachAckedUpdate(
+			id, func(seqNum uint16, backupID BackupID) {
+				cfg.PerAckedUpdate(session, seqNum, backupID)
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return session, nil
+}
@@ -0,0 +1,390 @@
+package wtdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// ClientStateVersion is the version of the client state archive format written
+// by WriteClientState.
+const ClientStateVersion uint32 = 1
+
+// clientStateMagic prefixes every client state archive, allowing readers to
+// reject data that isn't an archive before attempting to decode it.
+var clientStateMagic = [4]byte{'w', 't', 'c', 's'}
+
+var (
+	// ErrInvalidClientState signals that the data being read is not a
+	// client state archive.
+	ErrInvalidClientState = errors.New("invalid client state archive")
+
+	// ErrUnknownClientStateVersion signals that a client state archive was
+	// written using a format version that this reader doesn't understand.
+	ErrUnknownClientStateVersion = errors.New(
+		"unknown client state archive version",
+	)
+
+	// ErrClientDBNotEmpty signals an attempt to import a client state
+	// archive into a database that already holds towers, sessions or
+	// channels, without requesting that the archive be merged into it.
+	ErrClientDBNotEmpty = errors.New("client database not empty")
+)
+
+// ClientState is a backend-agnostic snapshot of the contents of a client
+// database, which can be exported from one database and imported into
+// another.
+type ClientState struct {
+	// Towers are the towers known to the database. The IDs of the towers
+	// are those assigned by the exporting database, and are only used to
+	// link the exported sessions to their towers.
+	Towers []*Tower
+
+	// Sessions are the client sessions known to the database, along with
+	// their committed and acked updates.
+	Sessions []*ClientSessionState
+
+	// ChanSummaries are the summaries of the registered channels.
+	ChanSummaries ChannelSummaries
+}
+
+// ClientSessionState holds a client session along with the updates that were
+// committed and acked by it.
+type ClientSessionState struct {
+	// Session is the ID and body of the client session.
+	Session *ClientSession
+
+	// CommittedUpdates are the session's updates that haven't yet been
+	// acked by the tower, in ascending order of sequence number.
+	CommittedUpdates []CommittedUpdate
+
+	// AckedUpdates maps the sequence numbers of the session's acked
+	// updates to the backups they contained.
+	AckedUpdates map[uint16]BackupID
+}
+
+// WriteClientState serializes the given client state to the passed io.Writer
+// as a versioned archive.
+//
+// NOTE: The pkscripts held by the state are written in the clear, regardless
+// of whether the exporting database encrypts them at rest.
+func WriteClientState(w io.Writer, state *ClientState) error {
+	if _, err := w.Write(clientStateMagic[:]); err != nil {
+		return err
+	}
+
+	err := WriteElements(w,
+		ClientStateVersion,
+		uint32(len(state.Towers)),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, tower := range state.Towers {
+		// Tower records have optional trailing fields, so they're
+		// length-prefixed to delimit them within the archive.
+		var b bytes.Buffer
+		if err := tower.Encode(&b); err != nil {
+			return err
+		}
+
+		err := WriteElements(w, uint64(tower.ID), b.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	err = WriteElement(w, uint32(len(state.Sessions)))
+	if err != nil {
+		return err
+	}
+
+	for _, session := range state.Sessions {
+		if err := writeClientSessionState(w, session); err != nil {
+			return err
+		}
+	}
+
+	// The channel summaries are written in order of channel ID so that
+	// the same state always produces the same archive.
+	chanIDs := make([]lnwire.ChannelID, 0, len(state.ChanSummaries))
+	for chanID := range state.ChanSummaries {
+		chanIDs = append(chanIDs, chanID)
+	}
+	sort.Slice(chanIDs, func(i, j int) bool {
+		return bytes.Compare(chanIDs[i][:], chanIDs[j][:]) < 0
+	})
+
+	err = WriteElement(w, uint32(len(chanIDs)))
+	if err != nil {
+		return err
+	}
+
+	for _, chanID := range chanIDs {
+		summary := state.ChanSummaries[chanID]
+		err := WriteElements(w,
+			chanID, summary.SweepPkScript, summary.Priority,
+		)
+		if err != nil {
+			return err
+		}
+
+		err = writeSweepPkScripts(w, summary.SweepPkScripts)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeClientSessionState serializes a single session of a client state
+// archive to the passed io.Writer.
+func writeClientSessionState(w io.Writer, state *ClientSessionState) error {
+	var body bytes.Buffer
+	err := state.Session.Encode(&body)
+	if err != nil {
+		return err
+	}
+
+	err = WriteElements(w,
+		state.Session.ID,
+		body.Bytes(),
+		uint16(len(state.CommittedUpdates)),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, update := range state.CommittedUpdates {
+		var b bytes.Buffer
+		if err := update.Encode(&b); err != nil {
+			return err
+		}
+
+		err := WriteElements(w, update.SeqNum, b.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	seqNums := make([]uint16, 0, len(state.AckedUpdates))
+	for seqNum := range state.AckedUpdates {
+		seqNums = append(seqNums, seqNum)
+	}
+	sort.Slice(seqNums, func(i, j int) bool {
+		return seqNums[i] < seqNums[j]
+	})
+
+	err = WriteElement(w, uint16(len(seqNums)))
+	if err != nil {
+		return err
+	}
+
+	for _, seqNum := range seqNums {
+		if err := WriteElement(w, seqNum); err != nil {
+			return err
+		}
+
+		backupID := state.AckedUpdates[seqNum]
+		if err := backupID.Encode(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadClientState deserializes a client state archive written by
+// WriteClientState from the passed io.Reader. ErrUnknownClientStateVersion is
+// returned if the archive uses any format other than ClientStateVersion.
+func ReadClientState(r io.Reader) (*ClientState, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, ErrInvalidClientState
+	}
+	if magic != clientStateMagic {
+		return nil, ErrInvalidClientState
+	}
+
+	var version, numTowers uint32
+	err := ReadElements(r, &version, &numTowers)
+	if err != nil {
+		return nil, err
+	}
+	if version != ClientStateVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownClientStateVersion,
+			version)
+	}
+
+	state := &ClientState{
+		Towers:        make([]*Tower, 0, numTowers),
+		ChanSummaries: make(ChannelSummaries),
+	}
+
+	for i := uint32(0); i < numTowers; i++ {
+		var (
+			towerID    uint64
+			towerBytes []byte
+		)
+		err := ReadElements(r, &towerID, &towerBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		var tower Tower
+		err = tower.Decode(bytes.NewReader(towerBytes))
+		if err != nil {
+			return nil, err
+		}
+		tower.ID = TowerID(towerID)
+
+		if tower.Features == nil {
+			tower.Features = NewTowerFeatures(nil)
+		}
+
+		state.Towers = append(state.Towers, &tower)
+	}
+
+	var numSessions uint32
+	if err := ReadElement(r, &numSessions); err != nil {
+		return nil, err
+	}
+
+	state.Sessions = make([]*ClientSessionState, 0, numSessions)
+	for i := uint32(0); i < numSessions; i++ {
+		session, err := readClientSessionState(r)
+		if err != nil {
+			return nil, err
+		}
+
+		state.Sessions = append(state.Sessions, session)
+	}
+
+	var numChannels uint32
+	if err := ReadElement(r, &numChannels); err != nil {
+		return nil, err
+	}
+
+	for i := uint32(0); i < numChannels; i++ {
+		var (
+			chanID  lnwire.ChannelID
+			summary ClientChanSummary
+		)
+		err := ReadElements(
+			r, &chanID, &summary.SweepPkScript, &summary.Priority,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		summary.SweepPkScripts, err = readSweepPkScripts(r)
+		if err != nil {
+			return nil, err
+		}
+
+		state.ChanSummaries[chanID] = summary
+	}
+
+	return state, nil
+}
+
+// readClientSessionState deserializes a single session of a client state
+// archive from the passed io.Reader.
+func readClientSessionState(r io.Reader) (*ClientSessionState, error) {
+	var (
+		session    ClientSession
+		body       []byte
+		numCommits uint16
+	)
+	err := ReadElements(r, &session.ID, &body, &numCommits)
+	if err != nil {
+		return nil, err
+	}
+
+	err = session.Decode(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	state := &ClientSessionState{
+		Session:          &session,
+		CommittedUpdates: make([]CommittedUpdate, 0, numCommits),
+		AckedUpdates:     make(map[uint16]BackupID),
+	}
+
+	for i := uint16(0); i < numCommits; i++ {
+		var (
+			update      CommittedUpdate
+			updateBytes []byte
+		)
+		err := ReadElements(r, &update.SeqNum, &updateBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		err = update.Decode(bytes.NewReader(updateBytes))
+		if err != nil {
+			return nil, err
+		}
+
+		state.CommittedUpdates = append(state.CommittedUpdates, update)
+	}
+
+	var numAcks uint16
+	if err := ReadElement(r, &numAcks); err != nil {
+		return nil, err
+	}
+
+	for i := uint16(0); i < numAcks; i++ {
+		var (
+			seqNum   uint16
+			backupID BackupID
+		)
+		if err := ReadElement(r, &seqNum); err != nil {
+			return nil, err
+		}
+
+		if err := backupID.Decode(r); err != nil {
+			return nil, err
+		}
+
+		state.AckedUpdates[seqNum] = backupID
+	}
+
+	return state, nil
+}
+
+// ClientStateImportCfg holds the options that influence how a client state
+// archive is imported.
+type ClientStateImportCfg struct {
+	// Merge, if true, permits the archive to be imported into a database
+	// that isn't empty.
+	Merge bool
+}
+
+// ClientStateImportOption is a type that can be used to modify the behavior of
+// ImportClientState.
+type ClientStateImportOption func(cfg *ClientStateImportCfg)
+
+// NewClientStateImportCfg constructs a new ClientStateImportCfg with the
+// default settings.
+func NewClientStateImportCfg() *ClientStateImportCfg {
+	return &ClientStateImportCfg{}
+}
+
+// WithMerge permits a client state archive to be merged into a database that
+// already holds towers, sessions or channels. Towers with the same identity
+// key are merged, and channels registered with the same sweep pkscript are
+// left untouched. The import fails if a session already exists, or if a
+// channel is registered with a different sweep pkscript.
+func WithMerge() ClientStateImportOption {
+	return func(cfg *ClientStateImportCfg) {
+		cfg.Merge = true
+	}
+}
@@ -3,16 +3,22 @@ package wtdb_test
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"io"
 	"math/rand"
 	"net"
 	"reflect"
 	"testing"
 	"testing/quick"
+	"time"
 
 	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/tor"
+	"github.com/lightningnetwork/lnd/watchtower/blob"
 	"github.com/lightningnetwork/lnd/watchtower/wtdb"
+	"github.com/lightningnetwork/lnd/watchtower/wtpolicy"
 	"github.com/stretchr/testify/require"
 )
 
@@ -176,9 +182,157 @@ func TestCodec(tt *testing.T) {
 			addrs, err := randAddrs(r)
 			require.NoError(t, err)
 
+			var nickname [8]byte
+			_, err = r.Read(nickname[:])
+			require.NoError(t, err)
+
 			obj := wtdb.Tower{
 				IdentityKey: pk,
 				Addresses:   addrs,
+				Nickname:    string(nickname[:]),
+				MaxSessions: r.Uint32(),
+			}
+
+			// Towers without features don't serialize a feature
+			// vector, so only set one half of the time.
+			if r.Intn(2) == 0 {
+				raw := lnwire.NewRawFeatureVector()
+				for i := 0; i < 16; i++ {
+					if r.Intn(2) == 0 {
+						raw.Set(lnwire.FeatureBit(i))
+					}
+				}
+				obj.Features = wtdb.NewTowerFeatures(raw)
+
+				// The timestamps follow the feature vector,
+				// so they're only set along with it.
+				if r.Intn(2) == 0 {
+					obj.CreatedAt = time.Unix(0, r.Int63())
+					obj.LastModified = time.Unix(
+						0, r.Int63(),
+					)
+				}
+
+				// The rate limit follows the timestamps,
+				// which are written as zero if unset.
+				if r.Intn(2) == 0 {
+					obj.RateLimit = r.Uint32()
+				}
+			}
+
+			v[0] = reflect.ValueOf(obj)
+		},
+		"ClientSessionBody": func(v []reflect.Value, r *rand.Rand) {
+			rewardPkScript := make([]byte, 1+r.Intn(34))
+			_, err := r.Read(rewardPkScript)
+			require.NoError(t, err)
+
+			policy := wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:   blob.Type(r.Uint32()),
+					RewardBase: r.Uint32(),
+					RewardRate: r.Uint32(),
+				},
+				MaxUpdates: uint16(r.Uint32()),
+			}
+			policy.SweepFeeRate = chainfee.SatPerKWeight(r.Uint32())
+			status := wtdb.CSessionStatus(r.Intn(3))
+
+			// Sessions without alternate reward pkscripts must
+			// decode with a nil set of them.
+			var altRewardPkScripts [][]byte
+			numAltScripts := r.Intn(wtdb.MaxRewardScripts)
+			for i := 0; i < numAltScripts; i++ {
+				altRewardPkScript := make([]byte, 1+r.Intn(34))
+				_, err := r.Read(altRewardPkScript)
+				require.NoError(t, err)
+
+				altRewardPkScripts = append(
+					altRewardPkScripts, altRewardPkScript,
+				)
+			}
+
+			// The timestamps are generated without a monotonic
+			// clock reading, since one can't survive encoding.
+			obj := wtdb.ClientSessionBody{
+				SeqNum:             uint16(r.Uint32()),
+				TowerLastApplied:   uint16(r.Uint32()),
+				TowerID:            wtdb.TowerID(r.Uint64()),
+				KeyIndex:           r.Uint32(),
+				Policy:             policy,
+				Status:             status,
+				RewardPkScript:     rewardPkScript,
+				AltRewardPkScripts: altRewardPkScripts,
+				CreatedAt:          time.Unix(0, r.Int63()),
+				LastUpdated:        time.Unix(0, r.Int63()),
+			}
+
+			// The session key is optional, so only set it half of
+			// the time.
+			if r.Intn(2) == 0 {
+				obj.SessionKeyPub, err = randPubKey()
+				require.NoError(t, err)
+			}
+
+			v[0] = reflect.ValueOf(obj)
+		},
+		"ClientChanSummary": func(v []reflect.Value, r *rand.Rand) {
+			sweepPkScript := make([]byte, 1+r.Intn(34))
+			_, err := r.Read(sweepPkScript)
+			require.NoError(t, err)
+
+			// The deletion time isn't serialized with the summary,
+			// so it's left unset.
+			obj := wtdb.ClientChanSummary{
+				SweepPkScript: sweepPkScript,
+				Priority:      uint8(r.Uint32()),
+			}
+
+			// The additional sweep pkscripts are optional, and
+			// decode as a nil map when absent.
+			numScripts := r.Intn(wtdb.MaxSweepPkScripts + 1)
+			for i := 0; i < numScripts; i++ {
+				pkScript := make([]byte, 1+r.Intn(34))
+				_, err := r.Read(pkScript)
+				require.NoError(t, err)
+
+				if obj.SweepPkScripts == nil {
+					obj.SweepPkScripts = make(
+						map[string][]byte,
+					)
+				}
+				kind := fmt.Sprintf("kind-%d", i)
+				obj.SweepPkScripts[kind] = pkScript
+			}
+
+			v[0] = reflect.ValueOf(obj)
+		},
+		"CommittedUpdateBody": func(v []reflect.Value, r *rand.Rand) {
+			obj := wtdb.CommittedUpdateBody{
+				BackupID: wtdb.BackupID{
+					CommitHeight: r.Uint64(),
+				},
+				EncryptedBlob: make([]byte, r.Intn(100)),
+			}
+			_, err := r.Read(obj.BackupID.ChanID[:])
+			require.NoError(t, err)
+			_, err = r.Read(obj.Hint[:])
+			require.NoError(t, err)
+			_, err = r.Read(obj.EncryptedBlob)
+			require.NoError(t, err)
+
+			// Updates using the session's reward pkscript must
+			// decode without one.
+			if r.Intn(2) == 0 {
+				obj.RewardPkScript = make([]byte, 1+r.Intn(34))
+				_, err = r.Read(obj.RewardPkScript)
+				require.NoError(t, err)
+			}
+
+			// Updates committed before the blob type was recorded
+			// must decode without one.
+			if r.Intn(2) == 0 {
+				obj.BlobType = blob.TypeAltruistAnchorCommit
 			}
 
 			v[0] = reflect.ValueOf(obj)
@@ -249,3 +403,98 @@ func TestCodec(tt *testing.T) {
 		})
 	}
 }
+
+// TestTowerDecodeWithoutNickname asserts that tower records serialized before
+// the nickname field was introduced can still be decoded, and that they decode
+// with an empty nickname.
+func TestTowerDecodeWithoutNickname(t *testing.T) {
+	pk, err := randPubKey()
+	require.NoError(t, err)
+
+	addrs, err := randAddrs(rand.New(rand.NewSource(0)))
+	require.NoError(t, err)
+
+	// Serialize the tower using the legacy format, which only consists of
+	// the identity key and addresses.
+	var b bytes.Buffer
+	err = wtdb.WriteElements(&b, pk, addrs)
+	require.NoError(t, err)
+
+	var tower wtdb.Tower
+	err = tower.Decode(bytes.NewReader(b.Bytes()))
+	require.NoError(t, err)
+
+	require.Equal(t, wtdb.Tower{
+		IdentityKey: pk,
+		Addresses:   addrs,
+	}, tower)
+}
+
+// TestTowerDecodeWithoutMaxSessions asserts that tower records serialized
+// before the session limit was introduced can still be decoded, and that they
+// decode without a limit.
+func TestTowerDecodeWithoutMaxSessions(t *testing.T) {
+	pk, err := randPubKey()
+	require.NoError(t, err)
+
+	addrs, err := randAddrs(rand.New(rand.NewSource(0)))
+	require.NoError(t, err)
+
+	// Serialize the tower using the format prior to the session limit,
+	// which ends with the nickname.
+	var b bytes.Buffer
+	err = wtdb.WriteElements(&b, pk, addrs, []byte("nickname"))
+	require.NoError(t, err)
+
+	var tower wtdb.Tower
+	err = tower.Decode(bytes.NewReader(b.Bytes()))
+	require.NoError(t, err)
+
+	require.Equal(t, wtdb.Tower{
+		IdentityKey: pk,
+		Addresses:   addrs,
+		Nickname:    "nickname",
+	}, tower)
+}
+
+// TestClientSessionBodyDecodeWithoutTimestamps asserts that session bodies
+// serialized before the timestamps were introduced can still be decoded, and
+// that they decode with zero timestamps.
+func TestClientSessionBodyDecodeWithoutTimestamps(t *testing.T) {
+	body := wtdb.ClientSessionBody{
+		SeqNum:           3,
+		TowerLastApplied: 2,
+		TowerID:          1,
+		KeyIndex:         4,
+		Policy: wtpolicy.Policy{
+			TxPolicy: wtpolicy.TxPolicy{
+				BlobType: blob.TypeAltruistCommit,
+			},
+			MaxUpdates: 10,
+		},
+		Status:         wtdb.CSessionInactive,
+		RewardPkScript: []byte{0x01, 0x02},
+	}
+
+	// Serialize the body using the legacy format, which ends with the
+	// reward pkscript.
+	var b bytes.Buffer
+	err := wtdb.WriteElements(&b,
+		body.SeqNum,
+		body.TowerLastApplied,
+		uint64(body.TowerID),
+		body.KeyIndex,
+		uint8(body.Status),
+		body.Policy,
+		body.RewardPkScript,
+	)
+	require.NoError(t, err)
+
+	var body2 wtdb.ClientSessionBody
+	err = body2.Decode(bytes.NewReader(b.Bytes()))
+	require.NoError(t, err)
+
+	require.Equal(t, body, body2)
+	require.True(t, body2.CreatedAt.IsZero())
+	require.True(t, body2.LastUpdated.IsZero())
+}
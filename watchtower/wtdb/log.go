@@ -4,6 +4,11 @@ import (
 	"github.com/btcsuite/btclog"
 	"github.com/lightningnetwork/lnd/build"
 	"github.com/lightningnetwork/lnd/watchtower/wtdb/migration1"
+	"github.com/lightningnetwork/lnd/watchtower/wtdb/migration2"
+	"github.com/lightningnetwork/lnd/watchtower/wtdb/migration3"
+	"github.com/lightningnetwork/lnd/watchtower/wtdb/migration4"
+	"github.com/lightningnetwork/lnd/watchtower/wtdb/migration5"
+	"github.com/lightningnetwork/lnd/watchtower/wtdb/migration6"
 )
 
 // log is a logger that is initialized with no output filters.  This
@@ -28,6 +33,11 @@ func DisableLog() {
 func UseLogger(logger btclog.Logger) {
 	log = logger
 	migration1.UseLogger(logger)
+	migration2.UseLogger(logger)
+	migration3.UseLogger(logger)
+	migration4.UseLogger(logger)
+	migration5.UseLogger(logger)
+	migration6.UseLogger(logger)
 }
 
 // logClosure is used to provide a closure over expensive logging operations so
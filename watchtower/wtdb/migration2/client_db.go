@@ -0,0 +1,94 @@
+package migration2
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	// cTowerIDToSessionIDIndexBkt is a top-level bucket storing:
+	// 	tower-id -> session-id -> 1
+	cTowerIDToSessionIDIndexBkt = []byte(
+		"client-tower-to-session-index-bucket",
+	)
+
+	// cTowerSessionCountBkt is a top-level bucket storing:
+	// 	tower-id -> number of sessions (uint64)
+	cTowerSessionCountBkt = []byte("client-tower-session-count-bucket")
+
+	// ErrUninitializedDB signals that top-level buckets for the database
+	// have not been initialized.
+	ErrUninitializedDB = errors.New("db not initialized")
+
+	// byteOrder is the default endianness used when serializing integers.
+	byteOrder = binary.BigEndian
+)
+
+// MigrateTowerSessionCount constructs a new towerID-to-session-count index for
+// the watchtower client DB, backfilled from the towerID-to-sessionID index.
+func MigrateTowerSessionCount(tx kvdb.RwTx) error {
+	log.Infof("Migrating the tower client db to add a " +
+		"towerID-to-session-count index")
+
+	// First, we count the number of sessions of each tower.
+	counts, err := getSessionCounts(tx)
+	if err != nil {
+		return err
+	}
+
+	// Then we create a new top-level bucket for the index.
+	countBkt, err := tx.CreateTopLevelBucket(cTowerSessionCountBkt)
+	if err != nil {
+		return err
+	}
+
+	// Finally, we add all the collected counts to the index.
+	for towerID, count := range counts {
+		var countBytes [8]byte
+		byteOrder.PutUint64(countBytes[:], count)
+
+		err := countBkt.Put([]byte(towerID), countBytes[:])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getSessionCounts returns the number of sessions of each tower in the
+// towerID-to-sessionID index, keyed by the serialized tower ID.
+func getSessionCounts(tx kvdb.RwTx) (map[string]uint64, error) {
+	index := tx.ReadBucket(cTowerIDToSessionIDIndexBkt)
+	if index == nil {
+		return nil, ErrUninitializedDB
+	}
+
+	counts := make(map[string]uint64)
+	err := index.ForEach(func(towerID, _ []byte) error {
+		towerBkt := index.NestedReadBucket(towerID)
+		if towerBkt == nil {
+			return ErrUninitializedDB
+		}
+
+		var count uint64
+		err := towerBkt.ForEach(func(_, _ []byte) error {
+			count++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		counts[string(towerID)] = count
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
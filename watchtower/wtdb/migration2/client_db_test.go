@@ -0,0 +1,130 @@
+package migration2
+
+import (
+	"testing"
+
+	"github.com/lightningnetwork/lnd/channeldb/migtest"
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	// pre is the expected data in the towerID-to-sessionID index before
+	// the migration.
+	pre = map[string]interface{}{
+		towerIDString(1): map[string]interface{}{
+			sessionIDString("1"): string([]byte{1}),
+			sessionIDString("3"): string([]byte{1}),
+			sessionIDString("4"): string([]byte{1}),
+		},
+		towerIDString(3): map[string]interface{}{
+			sessionIDString("5"): string([]byte{1}),
+		},
+		towerIDString(6): map[string]interface{}{},
+	}
+
+	// preFailNotBucket should fail the migration due to there being a
+	// tower entry in the index that isn't a bucket.
+	preFailNotBucket = map[string]interface{}{
+		towerIDString(1): map[string]interface{}{
+			sessionIDString("1"): string([]byte{1}),
+		},
+		towerIDString(2): string([]byte{1}),
+	}
+
+	// post is the expected data in the session count index after the
+	// migration.
+	post = map[string]interface{}{
+		towerIDString(1): countString(3),
+		towerIDString(3): countString(1),
+		towerIDString(6): countString(0),
+	}
+)
+
+// TestMigrateTowerSessionCount tests that the MigrateTowerSessionCount function
+// correctly adds a new towerID-to-session-count index to the tower client db.
+func TestMigrateTowerSessionCount(t *testing.T) {
+	tests := []struct {
+		name       string
+		shouldFail bool
+		pre        map[string]interface{}
+		post       map[string]interface{}
+	}{
+		{
+			name:       "migration ok",
+			shouldFail: false,
+			pre:        pre,
+			post:       post,
+		},
+		{
+			name:       "fail due to corrupt db",
+			shouldFail: true,
+			pre:        preFailNotBucket,
+			post:       nil,
+		},
+		{
+			name:       "no towers",
+			shouldFail: false,
+			pre:        nil,
+			post:       nil,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			// Before the migration we have a towerID-to-sessionID
+			// index bucket.
+			before := func(tx kvdb.RwTx) error {
+				return migtest.RestoreDB(
+					tx, cTowerIDToSessionIDIndexBkt,
+					test.pre,
+				)
+			}
+
+			// After the migration, we should have an untouched
+			// towerID-to-sessionID index and a new count index.
+			after := func(tx kvdb.RwTx) error {
+				if err := migtest.VerifyDB(
+					tx, cTowerIDToSessionIDIndexBkt,
+					test.pre,
+				); err != nil {
+					return err
+				}
+
+				// If we expect our migration to fail, we don't
+				// expect a count index bucket.
+				if test.shouldFail {
+					return nil
+				}
+
+				return migtest.VerifyDB(
+					tx, cTowerSessionCountBkt, test.post,
+				)
+			}
+
+			migtest.ApplyMigration(
+				t, before, after, MigrateTowerSessionCount,
+				test.shouldFail,
+			)
+		})
+	}
+}
+
+func sessionIDString(id string) string {
+	var sessID [33]byte
+	copy(sessID[:], id)
+	return string(sessID[:])
+}
+
+func towerIDString(id uint64) string {
+	var towerID [8]byte
+	byteOrder.PutUint64(towerID[:], id)
+	return string(towerID[:])
+}
+
+func countString(count uint64) string {
+	var countBytes [8]byte
+	byteOrder.PutUint64(countBytes[:], count)
+	return string(countBytes[:])
+}
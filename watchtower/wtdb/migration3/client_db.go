@@ -0,0 +1,125 @@
+package migration3
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	// cSessionKeyIndexBkt is a top-level bucket storing:
+	//   tower-id || blob-type -> reserved-session-key-indexes (uint32s).
+	cSessionKeyIndexBkt = []byte("client-session-key-index-bucket")
+
+	// cTowerBkt is a top-level bucket storing:
+	//    tower-id -> encoded Tower.
+	cTowerBkt = []byte("client-tower-bucket")
+
+	// ErrUninitializedDB signals that top-level buckets for the database
+	// have not been initialized.
+	ErrUninitializedDB = errors.New("db not initialized")
+)
+
+const (
+	// towerIDSize is the size of a serialized tower ID.
+	towerIDSize = 8
+
+	// truncatedTowerIDSize is the number of leading tower ID bytes kept by
+	// the truncated session key index keys.
+	truncatedTowerIDSize = 4
+
+	// blobTypeSize is the size of a serialized blob type.
+	blobTypeSize = 2
+)
+
+// MigrateSessionKeyIndexKeys rewrites the session key index reservations of the
+// watchtower client DB, which were keyed by the first four bytes of the tower
+// ID followed by the blob type, to be keyed by the full eight byte tower ID
+// followed by the blob type. Since tower IDs are big-endian, the truncated keys
+// of all towers with an ID below 2^32 collide, so a truncated key is only
+// rewritten if exactly one known tower matches it. Otherwise, its reservations
+// can't be attributed to a tower and are dropped. Dropped indexes are never
+// handed out again, since new indexes are always drawn from the bucket's
+// sequence, so no session key is ever reused for another tower.
+func MigrateSessionKeyIndexKeys(tx kvdb.RwTx) error {
+	log.Infof("Migrating the tower client db to key session key index " +
+		"reservations by the full tower id")
+
+	keyIndexes := tx.ReadWriteBucket(cSessionKeyIndexBkt)
+	if keyIndexes == nil {
+		return ErrUninitializedDB
+	}
+
+	towers := tx.ReadBucket(cTowerBkt)
+	if towers == nil {
+		return ErrUninitializedDB
+	}
+
+	// First, we collect the truncated keys and their reservations, since
+	// we can't mutate the bucket while iterating over it.
+	truncated := make(map[string][]byte)
+	err := keyIndexes.ForEach(func(k, v []byte) error {
+		if len(k) != truncatedTowerIDSize+blobTypeSize {
+			return nil
+		}
+
+		truncated[string(k)] = append([]byte(nil), v...)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(truncated) == 0 {
+		return nil
+	}
+
+	// Map each truncated tower ID prefix to the towers it matches.
+	candidates := make(map[string][][]byte)
+	err = towers.ForEach(func(k, _ []byte) error {
+		if len(k) != towerIDSize {
+			return nil
+		}
+
+		prefix := string(k[:truncatedTowerIDSize])
+		candidates[prefix] = append(
+			candidates[prefix], append([]byte(nil), k...),
+		)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var dropped int
+	for k, indexes := range truncated {
+		oldKey := []byte(k)
+		if err := keyIndexes.Delete(oldKey); err != nil {
+			return err
+		}
+
+		matches := candidates[k[:truncatedTowerIDSize]]
+		if len(matches) != 1 {
+			dropped += len(indexes) / 4
+			continue
+		}
+
+		var newKey bytes.Buffer
+		newKey.Write(matches[0])
+		newKey.Write(oldKey[truncatedTowerIDSize:])
+
+		if err := keyIndexes.Put(newKey.Bytes(), indexes); err != nil {
+			return err
+		}
+	}
+
+	if dropped > 0 {
+		log.Warnf("Dropped %d session key index reservations that "+
+			"couldn't be attributed to a single tower", dropped)
+	}
+
+	return nil
+}
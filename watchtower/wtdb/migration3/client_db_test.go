@@ -0,0 +1,138 @@
+package migration3
+
+import (
+	"testing"
+
+	"github.com/lightningnetwork/lnd/channeldb/migtest"
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	// blobTypeA and blobTypeB are serialized blob types.
+	blobTypeA = []byte{0x00, 0x02}
+	blobTypeB = []byte{0x00, 0x06}
+
+	// towers is the towers bucket holding a single tower whose ID is below
+	// 2^32, along with two towers whose IDs share the same four leading
+	// bytes.
+	towers = map[string]interface{}{
+		towerIDString(1):         "tower",
+		towerIDString(1 << 40):   "tower",
+		towerIDString(1<<40 + 1): "tower",
+	}
+
+	// pre is the expected data in the key index bucket before the
+	// migration. The truncated keys of the towers above 2^32 collide,
+	// while the legacy key of tower 1 lacks a blob type.
+	pre = map[string]interface{}{
+		truncatedKeyString(1, blobTypeA):     indexesString(1, 2),
+		truncatedKeyString(1, blobTypeB):     indexesString(3),
+		truncatedKeyString(1<<40, blobTypeA): indexesString(4),
+		towerIDString(1):                     indexesString(5),
+	}
+
+	// post is the expected data in the key index bucket after the
+	// migration.
+	post = map[string]interface{}{
+		keyString(1, blobTypeA): indexesString(1, 2),
+		keyString(1, blobTypeB): indexesString(3),
+		towerIDString(1):        indexesString(5),
+	}
+)
+
+// TestMigrateSessionKeyIndexKeys tests that the MigrateSessionKeyIndexKeys
+// function rewrites the truncated session key index keys of the tower client db
+// to use the full tower id.
+func TestMigrateSessionKeyIndexKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		pre  map[string]interface{}
+		post map[string]interface{}
+	}{
+		{
+			name: "migration ok",
+			pre:  pre,
+			post: post,
+		},
+		{
+			name: "migration idempotent",
+			pre:  post,
+			post: post,
+		},
+		{
+			name: "no reservations",
+			pre:  nil,
+			post: nil,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			// Before the migration we have a towers bucket and a
+			// key index bucket.
+			before := func(tx kvdb.RwTx) error {
+				err := migtest.RestoreDB(tx, cTowerBkt, towers)
+				if err != nil {
+					return err
+				}
+
+				return migtest.RestoreDB(
+					tx, cSessionKeyIndexBkt, test.pre,
+				)
+			}
+
+			// After the migration, the reservations should be
+			// keyed by the full tower id.
+			after := func(tx kvdb.RwTx) error {
+				err := migtest.VerifyDB(tx, cTowerBkt, towers)
+				if err != nil {
+					return err
+				}
+
+				return migtest.VerifyDB(
+					tx, cSessionKeyIndexBkt, test.post,
+				)
+			}
+
+			migtest.ApplyMigration(
+				t, before, after, MigrateSessionKeyIndexKeys,
+				false,
+			)
+		})
+	}
+}
+
+func towerIDString(id uint64) string {
+	var b [towerIDSize]byte
+	for i := range b {
+		b[i] = byte(id >> (8 * (towerIDSize - 1 - i)))
+	}
+
+	return string(b[:])
+}
+
+// truncatedKeyString returns the session key index key of the given tower and
+// blob type, keeping only the first four bytes of the tower id.
+func truncatedKeyString(id uint64, blobType []byte) string {
+	return towerIDString(id)[:truncatedTowerIDSize] + string(blobType)
+}
+
+// keyString returns the session key index key of the given tower and blob
+// type.
+func keyString(id uint64, blobType []byte) string {
+	return towerIDString(id) + string(blobType)
+}
+
+// indexesString returns the serialization of the given reserved session key
+// indexes.
+func indexesString(indexes ...uint32) string {
+	b := make([]byte, 0, 4*len(indexes))
+	for _, index := range indexes {
+		b = append(b, byte(index>>24), byte(index>>16),
+			byte(index>>8), byte(index))
+	}
+
+	return string(b)
+}
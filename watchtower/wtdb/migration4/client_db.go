@@ -0,0 +1,125 @@
+package migration4
+
+import (
+	"errors"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	// cSessionBkt is a top-level bucket storing:
+	//   session-id => cSessionBody -> encoded ClientSessionBody
+	//              => cSessionCommits => seqnum -> encoded CommittedUpdate
+	//              => cSessionAcks => seqnum -> encoded BackupID
+	cSessionBkt = []byte("client-session-bucket")
+
+	// cSessionCommits is a sub-bucket of cSessionBkt storing:
+	//    seqnum -> encoded CommittedUpdate.
+	cSessionCommits = []byte("client-session-commits")
+
+	// cSessionAcks is a sub-bucket of cSessionBkt storing:
+	//    seqnum -> encoded BackupID.
+	cSessionAcks = []byte("client-session-acks")
+
+	// cChanSessionsBkt is a top-level bucket storing:
+	// 	channel-id -> session-id -> 1
+	cChanSessionsBkt = []byte("client-channel-sessions-bucket")
+
+	// ErrUninitializedDB signals that top-level buckets for the database
+	// have not been initialized.
+	ErrUninitializedDB = errors.New("db not initialized")
+
+	// ErrCorruptClientSession signals that the client session's on-disk
+	// structure deviates from what is expected.
+	ErrCorruptClientSession = errors.New("client session corrupted")
+)
+
+// chanIDSize is the size of a serialized channel ID, which prefixes both the
+// encoded CommittedUpdates and the encoded BackupIDs.
+const chanIDSize = 32
+
+// MigrateChannelToSessionIndex constructs a new channel-to-session index for
+// the watchtower client DB, backfilled from the committed and acked updates of
+// all sessions.
+func MigrateChannelToSessionIndex(tx kvdb.RwTx) error {
+	log.Infof("Migrating the tower client db to add a " +
+		"channel-to-session index")
+
+	// First, we collect the channels of each session's updates.
+	index, err := getChanSessions(tx)
+	if err != nil {
+		return err
+	}
+
+	// Then we create a new top-level bucket for the index.
+	indexBkt, err := tx.CreateTopLevelBucket(cChanSessionsBkt)
+	if err != nil {
+		return err
+	}
+
+	// Finally, we add all the collected sessions to the index.
+	for chanID, sessionIDs := range index {
+		chanBkt, err := indexBkt.CreateBucketIfNotExists([]byte(chanID))
+		if err != nil {
+			return err
+		}
+
+		for sessionID := range sessionIDs {
+			err := chanBkt.Put([]byte(sessionID), []byte{1})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// getChanSessions returns, keyed by serialized channel ID, the set of
+// serialized IDs of the sessions holding committed or acked updates of each
+// channel.
+func getChanSessions(tx kvdb.RwTx) (map[string]map[string]struct{}, error) {
+	sessions := tx.ReadBucket(cSessionBkt)
+	if sessions == nil {
+		return nil, ErrUninitializedDB
+	}
+
+	index := make(map[string]map[string]struct{})
+	err := sessions.ForEach(func(sessionID, _ []byte) error {
+		sessionBkt := sessions.NestedReadBucket(sessionID)
+		if sessionBkt == nil {
+			return ErrCorruptClientSession
+		}
+
+		for _, subBkt := range [][]byte{cSessionCommits, cSessionAcks} {
+			updates := sessionBkt.NestedReadBucket(subBkt)
+			if updates == nil {
+				continue
+			}
+
+			err := updates.ForEach(func(_, v []byte) error {
+				if len(v) < chanIDSize {
+					return ErrCorruptClientSession
+				}
+
+				chanID := string(v[:chanIDSize])
+				if _, ok := index[chanID]; !ok {
+					index[chanID] = make(map[string]struct{})
+				}
+				index[chanID][string(sessionID)] = struct{}{}
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
@@ -0,0 +1,143 @@
+package migration4
+
+import (
+	"testing"
+
+	"github.com/lightningnetwork/lnd/channeldb/migtest"
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	// pre is the expected data in the sessions bucket before the
+	// migration.
+	pre = map[string]interface{}{
+		sessionIDString("1"): map[string]interface{}{
+			string(cSessionCommits): map[string]interface{}{
+				seqNumString(3): updateString("a"),
+			},
+			string(cSessionAcks): map[string]interface{}{
+				seqNumString(1): updateString("a"),
+				seqNumString(2): updateString("b"),
+			},
+		},
+		sessionIDString("2"): map[string]interface{}{
+			string(cSessionAcks): map[string]interface{}{
+				seqNumString(1): updateString("b"),
+			},
+		},
+		sessionIDString("3"): map[string]interface{}{},
+	}
+
+	// preFailCorruptUpdate should fail the migration due to there being an
+	// acked update that is too short to hold a channel ID.
+	preFailCorruptUpdate = map[string]interface{}{
+		sessionIDString("1"): map[string]interface{}{
+			string(cSessionAcks): map[string]interface{}{
+				seqNumString(1): "a",
+			},
+		},
+	}
+
+	// post is the expected data in the channel-to-session index after the
+	// migration.
+	post = map[string]interface{}{
+		chanIDString("a"): map[string]interface{}{
+			sessionIDString("1"): string([]byte{1}),
+		},
+		chanIDString("b"): map[string]interface{}{
+			sessionIDString("1"): string([]byte{1}),
+			sessionIDString("2"): string([]byte{1}),
+		},
+	}
+)
+
+// TestMigrateChannelToSessionIndex tests that the MigrateChannelToSessionIndex
+// function correctly adds a new channel-to-session index to the tower client
+// db.
+func TestMigrateChannelToSessionIndex(t *testing.T) {
+	tests := []struct {
+		name       string
+		shouldFail bool
+		pre        map[string]interface{}
+		post       map[string]interface{}
+	}{
+		{
+			name:       "migration ok",
+			shouldFail: false,
+			pre:        pre,
+			post:       post,
+		},
+		{
+			name:       "fail due to corrupt db",
+			shouldFail: true,
+			pre:        preFailCorruptUpdate,
+			post:       nil,
+		},
+		{
+			name:       "no sessions",
+			shouldFail: false,
+			pre:        nil,
+			post:       nil,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			// Before the migration we have a sessions bucket.
+			before := func(tx kvdb.RwTx) error {
+				return migtest.RestoreDB(
+					tx, cSessionBkt, test.pre,
+				)
+			}
+
+			// After the migration, we should have an untouched
+			// sessions bucket and a new channel-to-session index.
+			after := func(tx kvdb.RwTx) error {
+				if err := migtest.VerifyDB(
+					tx, cSessionBkt, test.pre,
+				); err != nil {
+					return err
+				}
+
+				// If we expect our migration to fail, we don't
+				// expect an index bucket.
+				if test.shouldFail {
+					return nil
+				}
+
+				return migtest.VerifyDB(
+					tx, cChanSessionsBkt, test.post,
+				)
+			}
+
+			migtest.ApplyMigration(
+				t, before, after, MigrateChannelToSessionIndex,
+				test.shouldFail,
+			)
+		})
+	}
+}
+
+func sessionIDString(id string) string {
+	var sessID [33]byte
+	copy(sessID[:], id)
+	return string(sessID[:])
+}
+
+func chanIDString(id string) string {
+	var chanID [chanIDSize]byte
+	copy(chanID[:], id)
+	return string(chanID[:])
+}
+
+// updateString returns a serialized update of the given channel. Only the
+// channel ID prefix is inspected by the migration.
+func updateString(chanID string) string {
+	return chanIDString(chanID) + string([]byte{0, 0, 0, 0, 0, 0, 0, 1})
+}
+
+func seqNumString(seqNum uint16) string {
+	return string([]byte{byte(seqNum >> 8), byte(seqNum)})
+}
@@ -0,0 +1,98 @@
+package migration5
+
+import (
+	"errors"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	// cSessionBkt is a top-level bucket storing:
+	//   session-id => cSessionBody -> encoded ClientSessionBody
+	//              => cSessionLastAcked -> seqnum
+	//              => cSessionCommits => seqnum -> encoded CommittedUpdate
+	//              => cSessionAcks => seqnum -> encoded BackupID
+	cSessionBkt = []byte("client-session-bucket")
+
+	// cSessionLastAcked is a key within the session's bucket storing the
+	// highest sequence number acked by the tower for the session.
+	cSessionLastAcked = []byte("client-session-last-acked")
+
+	// cSessionAcks is a sub-bucket of cSessionBkt storing:
+	//    seqnum -> encoded BackupID.
+	cSessionAcks = []byte("client-session-acks")
+
+	// ErrUninitializedDB signals that top-level buckets for the database
+	// have not been initialized.
+	ErrUninitializedDB = errors.New("db not initialized")
+
+	// ErrCorruptClientSession signals that the client session's on-disk
+	// structure deviates from what is expected.
+	ErrCorruptClientSession = errors.New("client session corrupted")
+)
+
+// seqNumSize is the size of a serialized sequence number, which is used as
+// the key of each acked update.
+const seqNumSize = 2
+
+// MigrateLastAckedSeqNum backfills the highest acked sequence number of each
+// session of the watchtower client DB from the session's acked updates.
+// Sessions without any acked updates are left untouched.
+func MigrateLastAckedSeqNum(tx kvdb.RwTx) error {
+	log.Infof("Migrating the tower client db to store the last acked " +
+		"sequence number of each session")
+
+	sessions := tx.ReadWriteBucket(cSessionBkt)
+	if sessions == nil {
+		return ErrUninitializedDB
+	}
+
+	// First, we collect the highest acked sequence number of each session,
+	// since we can't mutate the sessions bucket while iterating over it.
+	lastAcked := make(map[string][]byte)
+	err := sessions.ForEach(func(sessionID, _ []byte) error {
+		sessionBkt := sessions.NestedReadBucket(sessionID)
+		if sessionBkt == nil {
+			return ErrCorruptClientSession
+		}
+
+		acks := sessionBkt.NestedReadBucket(cSessionAcks)
+		if acks == nil {
+			return nil
+		}
+
+		// The acked updates are keyed by their big-endian sequence
+		// number, so the last key is the highest one.
+		seqNum, _ := acks.ReadCursor().Last()
+		switch {
+		case seqNum == nil:
+			return nil
+
+		case len(seqNum) != seqNumSize:
+			return ErrCorruptClientSession
+		}
+
+		lastAcked[string(sessionID)] = append([]byte(nil), seqNum...)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Then we store the collected sequence numbers in the buckets of their
+	// sessions.
+	for sessionID, seqNum := range lastAcked {
+		sessionBkt := sessions.NestedReadWriteBucket([]byte(sessionID))
+		if sessionBkt == nil {
+			return ErrCorruptClientSession
+		}
+
+		err := sessionBkt.Put(cSessionLastAcked, seqNum)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package migration5
+
+import (
+	"testing"
+
+	"github.com/lightningnetwork/lnd/channeldb/migtest"
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	// pre is the expected data in the sessions bucket before the
+	// migration.
+	pre = map[string]interface{}{
+		sessionIDString("1"): map[string]interface{}{
+			string(cSessionAcks): map[string]interface{}{
+				seqNumString(1):   "a",
+				seqNumString(2):   "b",
+				seqNumString(300): "c",
+			},
+		},
+		sessionIDString("2"): map[string]interface{}{
+			string(cSessionAcks): map[string]interface{}{
+				seqNumString(1): "a",
+			},
+		},
+		sessionIDString("3"): map[string]interface{}{
+			string(cSessionAcks): map[string]interface{}{},
+		},
+		sessionIDString("4"): map[string]interface{}{},
+	}
+
+	// preFailCorruptSeqNum should fail the migration due to there being
+	// an acked update whose key is not a sequence number.
+	preFailCorruptSeqNum = map[string]interface{}{
+		sessionIDString("1"): map[string]interface{}{
+			string(cSessionAcks): map[string]interface{}{
+				"abc": "a",
+			},
+		},
+	}
+
+	// post is the expected data in the sessions bucket after the
+	// migration.
+	post = map[string]interface{}{
+		sessionIDString("1"): map[string]interface{}{
+			string(cSessionLastAcked): seqNumString(300),
+			string(cSessionAcks): map[string]interface{}{
+				seqNumString(1):   "a",
+				seqNumString(2):   "b",
+				seqNumString(300): "c",
+			},
+		},
+		sessionIDString("2"): map[string]interface{}{
+			string(cSessionLastAcked): seqNumString(1),
+			string(cSessionAcks): map[string]interface{}{
+				seqNumString(1): "a",
+			},
+		},
+		sessionIDString("3"): map[string]interface{}{
+			string(cSessionAcks): map[string]interface{}{},
+		},
+		sessionIDString("4"): map[string]interface{}{},
+	}
+)
+
+// TestMigrateLastAckedSeqNum tests that the MigrateLastAckedSeqNum function
+// correctly backfills the last acked sequence number of each session.
+func TestMigrateLastAckedSeqNum(t *testing.T) {
+	tests := []struct {
+		name       string
+		shouldFail bool
+		pre        map[string]interface{}
+		post       map[string]interface{}
+	}{
+		{
+			name:       "migration ok",
+			shouldFail: false,
+			pre:        pre,
+			post:       post,
+		},
+		{
+			name:       "fail due to corrupt db",
+			shouldFail: true,
+			pre:        preFailCorruptSeqNum,
+			post:       preFailCorruptSeqNum,
+		},
+		{
+			name:       "no sessions",
+			shouldFail: false,
+			pre:        nil,
+			post:       nil,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			// Before the migration we have a sessions bucket.
+			before := func(tx kvdb.RwTx) error {
+				return migtest.RestoreDB(
+					tx, cSessionBkt, test.pre,
+				)
+			}
+
+			// After the migration, each session with acked updates
+			// should also store its highest acked sequence number.
+			after := func(tx kvdb.RwTx) error {
+				return migtest.VerifyDB(
+					tx, cSessionBkt, test.post,
+				)
+			}
+
+			migtest.ApplyMigration(
+				t, before, after, MigrateLastAckedSeqNum,
+				test.shouldFail,
+			)
+		})
+	}
+}
+
+func sessionIDString(id string) string {
+	var sessID [33]byte
+	copy(sessID[:], id)
+	return string(sessID[:])
+}
+
+func seqNumString(seqNum uint16) string {
+	return string([]byte{byte(seqNum >> 8), byte(seqNum)})
+}
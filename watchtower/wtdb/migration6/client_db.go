@@ -0,0 +1,172 @@
+package migration6
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	// cSessionBkt is a top-level bucket storing:
+	//   session-id => cSessionBody -> encoded ClientSessionBody
+	//              => cSessionCommits => seqnum -> encoded CommittedUpdate
+	//              => cSessionAcks => seqnum -> encoded BackupID
+	cSessionBkt = []byte("client-session-bucket")
+
+	// cSessionBody is a sub-bucket of cSessionBkt storing only the body of
+	// the ClientSession.
+	cSessionBody = []byte("client-session-body")
+
+	// cSessionCommits is a sub-bucket of cSessionBkt storing:
+	//    seqnum -> encoded CommittedUpdate.
+	cSessionCommits = []byte("client-session-commits")
+
+	// ErrUninitializedDB signals that top-level buckets for the database
+	// have not been initialized.
+	ErrUninitializedDB = errors.New("db not initialized")
+
+	// ErrCorruptClientSession signals that the client session's on-disk
+	// structure deviates from what is expected.
+	ErrCorruptClientSession = errors.New("client session corrupted")
+)
+
+const (
+	// blobTypeOffset is the offset of the blob type of the session's
+	// policy within an encoded ClientSessionBody, which is preceded by the
+	// session's SeqNum, TowerLastApplied, TowerID, KeyIndex and Status.
+	blobTypeOffset = 2 + 2 + 8 + 4 + 1
+
+	// blobTypeSize is the size of a serialized blob type.
+	blobTypeSize = 2
+
+	// updateHeaderSize is the size of the fixed-length prefix of an
+	// encoded CommittedUpdate, consisting of the channel ID and commit
+	// height of its BackupID, followed by its breach hint.
+	updateHeaderSize = 32 + 8 + 16
+)
+
+// MigrateCommittedUpdateBlobType backfills the blob type of each committed
+// update of the watchtower client DB from the policy of the update's session.
+// Updates that already record a blob type are left untouched, so the migration
+// can safely be applied more than once.
+func MigrateCommittedUpdateBlobType(tx kvdb.RwTx) error {
+	log.Infof("Migrating the tower client db to store the blob type of " +
+		"each committed update")
+
+	sessions := tx.ReadWriteBucket(cSessionBkt)
+	if sessions == nil {
+		return ErrUninitializedDB
+	}
+
+	// First, we collect the sessions' committed updates that lack a blob
+	// type, along with their new encodings, since we can't mutate the
+	// sessions bucket while iterating over it.
+	migrated := make(map[string]map[string][]byte)
+	err := sessions.ForEach(func(sessionID, _ []byte) error {
+		sessionBkt := sessions.NestedReadBucket(sessionID)
+		if sessionBkt == nil {
+			return ErrCorruptClientSession
+		}
+
+		commits := sessionBkt.NestedReadBucket(cSessionCommits)
+		if commits == nil {
+			return nil
+		}
+
+		body := sessionBkt.Get(cSessionBody)
+		if len(body) < blobTypeOffset+blobTypeSize {
+			return ErrCorruptClientSession
+		}
+		blobType := body[blobTypeOffset : blobTypeOffset+blobTypeSize]
+
+		updates := make(map[string][]byte)
+		err := commits.ForEach(func(seqNum, update []byte) error {
+			newUpdate, err := addBlobType(update, blobType)
+			if err != nil {
+				return err
+			}
+
+			if newUpdate != nil {
+				updates[string(seqNum)] = newUpdate
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(updates) > 0 {
+			migrated[string(sessionID)] = updates
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Then we store the new encodings of the collected updates.
+	for sessionID, updates := range migrated {
+		sessionBkt := sessions.NestedReadWriteBucket([]byte(sessionID))
+		if sessionBkt == nil {
+			return ErrCorruptClientSession
+		}
+
+		commits := sessionBkt.NestedReadWriteBucket(cSessionCommits)
+		if commits == nil {
+			return ErrCorruptClientSession
+		}
+
+		for seqNum, update := range updates {
+			err := commits.Put([]byte(seqNum), update)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// addBlobType returns the given encoded CommittedUpdate with the given blob
+// type appended to it. If the update already records a blob type, nil is
+// returned instead.
+func addBlobType(update, blobType []byte) ([]byte, error) {
+	if len(update) < updateHeaderSize {
+		return nil, ErrCorruptClientSession
+	}
+
+	r := bytes.NewReader(update[updateHeaderSize:])
+
+	var encryptedBlob []byte
+	if err := channeldb.ReadElement(r, &encryptedBlob); err != nil {
+		return nil, ErrCorruptClientSession
+	}
+
+	newUpdate := append([]byte(nil), update...)
+
+	// The reward pkscript is optional. If it's absent, an empty one is
+	// written in its place, as it must precede the blob type.
+	var rewardPkScript []byte
+	err := channeldb.ReadElement(r, &rewardPkScript)
+	switch {
+	case err == io.EOF:
+		newUpdate = append(newUpdate, 0x00)
+		return append(newUpdate, blobType...), nil
+
+	case err != nil:
+		return nil, ErrCorruptClientSession
+	}
+
+	// If the pkscript is followed by anything, then the update already
+	// records its blob type.
+	if r.Len() != 0 {
+		return nil, nil
+	}
+
+	return append(newUpdate, blobType...), nil
+}
@@ -0,0 +1,208 @@
+package migration6
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lightningnetwork/lnd/channeldb/migtest"
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	// blobTypeA and blobTypeB are the serialized blob types of the
+	// sessions' policies.
+	blobTypeA = []byte{0x00, 0x02}
+	blobTypeB = []byte{0x00, 0x06}
+
+	// pre is the expected data in the sessions bucket before the
+	// migration.
+	pre = map[string]interface{}{
+		sessionIDString("1"): map[string]interface{}{
+			string(cSessionBody): sessionBodyString(blobTypeA),
+			string(cSessionCommits): map[string]interface{}{
+				seqNumString(1): updateString(nil, nil),
+				seqNumString(2): updateString(
+					[]byte{0x51}, nil,
+				),
+			},
+		},
+		sessionIDString("2"): map[string]interface{}{
+			string(cSessionBody): sessionBodyString(blobTypeB),
+			string(cSessionCommits): map[string]interface{}{
+				seqNumString(1): updateString(nil, nil),
+				seqNumString(2): updateString(
+					[]byte{}, blobTypeB,
+				),
+			},
+		},
+		sessionIDString("3"): map[string]interface{}{
+			string(cSessionBody):    sessionBodyString(blobTypeA),
+			string(cSessionCommits): map[string]interface{}{},
+		},
+		sessionIDString("4"): map[string]interface{}{
+			string(cSessionBody): sessionBodyString(blobTypeA),
+		},
+	}
+
+	// preFailCorruptBody should fail the migration due to there being a
+	// session with committed updates whose body is too short to hold a
+	// blob type.
+	preFailCorruptBody = map[string]interface{}{
+		sessionIDString("1"): map[string]interface{}{
+			string(cSessionBody): "abc",
+			string(cSessionCommits): map[string]interface{}{
+				seqNumString(1): updateString(nil, nil),
+			},
+		},
+	}
+
+	// preFailCorruptUpdate should fail the migration due to there being a
+	// committed update that is too short to be decoded.
+	preFailCorruptUpdate = map[string]interface{}{
+		sessionIDString("1"): map[string]interface{}{
+			string(cSessionBody): sessionBodyString(blobTypeA),
+			string(cSessionCommits): map[string]interface{}{
+				seqNumString(1): "abc",
+			},
+		},
+	}
+
+	// post is the expected data in the sessions bucket after the
+	// migration.
+	post = map[string]interface{}{
+		sessionIDString("1"): map[string]interface{}{
+			string(cSessionBody): sessionBodyString(blobTypeA),
+			string(cSessionCommits): map[string]interface{}{
+				seqNumString(1): updateString(
+					[]byte{}, blobTypeA,
+				),
+				seqNumString(2): updateString(
+					[]byte{0x51}, blobTypeA,
+				),
+			},
+		},
+		sessionIDString("2"): map[string]interface{}{
+			string(cSessionBody): sessionBodyString(blobTypeB),
+			string(cSessionCommits): map[string]interface{}{
+				seqNumString(1): updateString(
+					[]byte{}, blobTypeB,
+				),
+				seqNumString(2): updateString(
+					[]byte{}, blobTypeB,
+				),
+			},
+		},
+		sessionIDString("3"): map[string]interface{}{
+			string(cSessionBody):    sessionBodyString(blobTypeA),
+			string(cSessionCommits): map[string]interface{}{},
+		},
+		sessionIDString("4"): map[string]interface{}{
+			string(cSessionBody): sessionBodyString(blobTypeA),
+		},
+	}
+)
+
+// TestMigrateCommittedUpdateBlobType tests that the
+// MigrateCommittedUpdateBlobType function correctly backfills the blob type of
+// each committed update from the policy of its session.
+func TestMigrateCommittedUpdateBlobType(t *testing.T) {
+	tests := []struct {
+		name       string
+		shouldFail bool
+		pre        map[string]interface{}
+		post       map[string]interface{}
+	}{
+		{
+			name:       "migration ok",
+			shouldFail: false,
+			pre:        pre,
+			post:       post,
+		},
+		{
+			name:       "migration idempotent",
+			shouldFail: false,
+			pre:        post,
+			post:       post,
+		},
+		{
+			name:       "fail due to corrupt session body",
+			shouldFail: true,
+			pre:        preFailCorruptBody,
+			post:       preFailCorruptBody,
+		},
+		{
+			name:       "fail due to corrupt committed update",
+			shouldFail: true,
+			pre:        preFailCorruptUpdate,
+			post:       preFailCorruptUpdate,
+		},
+		{
+			name:       "no sessions",
+			shouldFail: false,
+			pre:        nil,
+			post:       nil,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			// Before the migration we have a sessions bucket.
+			before := func(tx kvdb.RwTx) error {
+				return migtest.RestoreDB(
+					tx, cSessionBkt, test.pre,
+				)
+			}
+
+			// After the migration, each committed update should
+			// record the blob type of its session.
+			after := func(tx kvdb.RwTx) error {
+				return migtest.VerifyDB(
+					tx, cSessionBkt, test.post,
+				)
+			}
+
+			migtest.ApplyMigration(
+				t, before, after,
+				MigrateCommittedUpdateBlobType,
+				test.shouldFail,
+			)
+		})
+	}
+}
+
+func sessionIDString(id string) string {
+	var sessID [33]byte
+	copy(sessID[:], id)
+	return string(sessID[:])
+}
+
+func seqNumString(seqNum uint16) string {
+	return string([]byte{byte(seqNum >> 8), byte(seqNum)})
+}
+
+// sessionBodyString returns an encoded session body whose policy uses the
+// given serialized blob type.
+func sessionBodyString(blobType []byte) string {
+	body := make([]byte, blobTypeOffset)
+	body = append(body, blobType...)
+	body = append(body, bytes.Repeat([]byte{0x01}, 20)...)
+
+	return string(body)
+}
+
+// updateString returns an encoded committed update with a 3-byte encrypted
+// blob. The reward pkscript is only written if it is non-nil, and the blob
+// type only if it is non-nil too.
+func updateString(rewardPkScript, blobType []byte) string {
+	update := bytes.Repeat([]byte{0xaa}, updateHeaderSize)
+	update = append(update, 0x03, 0x01, 0x02, 0x03)
+
+	if rewardPkScript != nil {
+		update = append(update, byte(len(rewardPkScript)))
+		update = append(update, rewardPkScript...)
+	}
+
+	return string(append(update, blobType...))
+}
@@ -0,0 +1,263 @@
+package wtdb
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+var (
+	// ErrScriptDecryption signals that a pkscript sealed at rest could not
+	// be opened, which happens if the client database was opened with a
+	// different encryption key than the one the pkscript was sealed with.
+	ErrScriptDecryption = errors.New("unable to decrypt pkscript")
+
+	// ErrEncryptionKeyRequired signals that an encrypted client database
+	// was opened without an encryption key.
+	ErrEncryptionKeyRequired = errors.New("client db is encrypted, but " +
+		"no encryption key was given")
+
+	// ErrEncryptionKeyMismatch signals that the given encryption key isn't
+	// the one the client database is encrypted with.
+	ErrEncryptionKeyMismatch = errors.New("encryption key doesn't match " +
+		"the one the client db is encrypted with")
+
+	// encryptionCheckKey is a key in the metadataBkt under which the
+	// encryptionCheckValue, sealed with the database's encryption key, is
+	// stored. Its presence marks the pkscripts of the database as
+	// encrypted.
+	encryptionCheckKey = []byte("encryption-check")
+
+	// encryptionCheckValue is the known value that is sealed under
+	// encryptionCheckKey, which allows a key to be checked against the
+	// database before any pkscript is read.
+	encryptionCheckValue = []byte("wtclient-db-encryption-check")
+)
+
+// scriptCipher seals the sweep and reward pkscripts of the client database
+// before they're written to disk, and opens them again when they're read. A
+// nil scriptCipher leaves the pkscripts untouched, which is used when no
+// encryption key is configured.
+//
+// Each pkscript is sealed using AES-GCM under a fresh random nonce, which is
+// prepended to the ciphertext. The ID of the session or channel owning the
+// pkscript is used as additional data, binding the ciphertext to its owner.
+type scriptCipher struct {
+	aead cipher.AEAD
+}
+
+// newScriptCipher creates a scriptCipher from the given AES key, which must be
+// 16, 24 or 32 bytes long.
+func newScriptCipher(key []byte) (*scriptCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scriptCipher{
+		aead: aead,
+	}, nil
+}
+
+// seal encrypts the given pkscript, authenticating the owner's ID along with
+// it. Empty pkscripts are left untouched.
+func (s *scriptCipher) seal(pkScript, ownerID []byte) ([]byte, error) {
+	if s == nil || len(pkScript) == 0 {
+		return pkScript, nil
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return s.aead.Seal(nonce, nonce, pkScript, ownerID), nil
+}
+
+// open decrypts the given sealed pkscript of the owner with the given ID.
+// ErrScriptDecryption is returned if the pkscript can't be authenticated.
+func (s *scriptCipher) open(sealed, ownerID []byte) ([]byte, error) {
+	if s == nil || len(sealed) == 0 {
+		return sealed, nil
+	}
+
+	nonceSize := s.aead.NonceSize()
+	if len(sealed) < nonceSize+s.aead.Overhead() {
+		return nil, ErrScriptDecryption
+	}
+
+	pkScript, err := s.aead.Open(
+		nil, sealed[:nonceSize], sealed[nonceSize:], ownerID,
+	)
+	if err != nil {
+		return nil, ErrScriptDecryption
+	}
+
+	return pkScript, nil
+}
+
+// sealSession returns a copy of the given session whose reward pkscripts are
+// sealed. The session itself is returned if no encryption key is configured.
+func (s *scriptCipher) sealSession(session *ClientSession) (*ClientSession,
+	error) {
+
+	return s.mapSessionScripts(session, s.seal)
+}
+
+// openSession returns a copy of the given session whose reward pkscripts are
+// opened. The session itself is returned if no encryption key is configured.
+func (s *scriptCipher) openSession(session *ClientSession) (*ClientSession,
+	error) {
+
+	return s.mapSessionScripts(session, s.open)
+}
+
+// sealChanSummary returns a copy of the given channel summary whose sweep
+// pkscripts are sealed. The summary itself is returned if no encryption key is
+// configured.
+func (s *scriptCipher) sealChanSummary(chanID lnwire.ChannelID,
+	summary *ClientChanSummary) (*ClientChanSummary, error) {
+
+	return s.mapChanSummaryScripts(chanID, summary, s.seal)
+}
+
+// openChanSummary returns a copy of the given channel summary whose sweep
+// pkscripts are opened. The summary itself is returned if no encryption key is
+// configured.
+func (s *scriptCipher) openChanSummary(chanID lnwire.ChannelID,
+	summary *ClientChanSummary) (*ClientChanSummary, error) {
+
+	return s.mapChanSummaryScripts(chanID, summary, s.open)
+}
+
+// mapChanSummaryScripts returns a copy of the given channel summary whose sweep
+// pkscripts have been transformed by f.
+func (s *scriptCipher) mapChanSummaryScripts(chanID lnwire.ChannelID,
+	summary *ClientChanSummary,
+	f func(pkScript, ownerID []byte) ([]byte, error)) (*ClientChanSummary,
+	error) {
+
+	if s == nil {
+		return summary, nil
+	}
+
+	mapped := *summary
+
+	var err error
+	mapped.SweepPkScript, err = f(summary.SweepPkScript, chanID[:])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(summary.SweepPkScripts) == 0 {
+		return &mapped, nil
+	}
+
+	mapped.SweepPkScripts = make(
+		map[string][]byte, len(summary.SweepPkScripts),
+	)
+	for kind, pkScript := range summary.SweepPkScripts {
+		mapped.SweepPkScripts[kind], err = f(pkScript, chanID[:])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &mapped, nil
+}
+
+// mapSessionScripts returns a copy of the given session whose reward pkscripts
+// have been transformed by f.
+func (s *scriptCipher) mapSessionScripts(session *ClientSession,
+	f func(pkScript, ownerID []byte) ([]byte, error)) (*ClientSession,
+	error) {
+
+	if s == nil {
+		return session, nil
+	}
+
+	mapped := *session
+
+	var err error
+	mapped.RewardPkScript, err = f(session.RewardPkScript, session.ID[:])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(session.AltRewardPkScripts) == 0 {
+		return &mapped, nil
+	}
+
+	mapped.AltRewardPkScripts = make(
+		[][]byte, len(session.AltRewardPkScripts),
+	)
+	for i, pkScript := range session.AltRewardPkScripts {
+		mapped.AltRewardPkScripts[i], err = f(pkScript, session.ID[:])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &mapped, nil
+}
+
+// checkEncryptionKey returns whether the pkscripts of the client database are
+// encrypted, as recorded by its encryption check value. If they are,
+// ErrEncryptionKeyRequired is returned if s is nil, and
+// ErrEncryptionKeyMismatch is returned if s uses a different key than the one
+// the database is encrypted with.
+func checkEncryptionKey(tx kvdb.RTx, s *scriptCipher) (bool, error) {
+	metadata := tx.ReadBucket(metadataBkt)
+	if metadata == nil {
+		return false, ErrUninitializedDB
+	}
+
+	sealed := metadata.Get(encryptionCheckKey)
+	switch {
+	case sealed == nil:
+		return false, nil
+
+	case s == nil:
+		return true, ErrEncryptionKeyRequired
+	}
+
+	value, err := s.open(sealed, encryptionCheckKey)
+	if err != nil || !bytes.Equal(value, encryptionCheckValue) {
+		return true, ErrEncryptionKeyMismatch
+	}
+
+	return true, nil
+}
+
+// putEncryptionCheck records the key of s as the encryption key of the client
+// database by storing the encryption check value sealed with it. If s is nil,
+// the check value is removed instead, marking the pkscripts as stored in the
+// clear.
+func putEncryptionCheck(tx kvdb.RwTx, s *scriptCipher) error {
+	metadata := tx.ReadWriteBucket(metadataBkt)
+	if metadata == nil {
+		return ErrUninitializedDB
+	}
+
+	if s == nil {
+		return metadata.Delete(encryptionCheckKey)
+	}
+
+	sealed, err := s.seal(encryptionCheckValue, encryptionCheckKey)
+	if err != nil {
+		return err
+	}
+
+	return metadata.Put(encryptionCheckKey, sealed)
+}
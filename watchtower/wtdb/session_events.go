@@ -0,0 +1,157 @@
+package wtdb
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// sessionEventBufferSize is the number of session events buffered for each
+// subscriber. Events that don't fit in a subscriber's buffer are dropped.
+const sessionEventBufferSize = 100
+
+// SessionEventType identifies the kind of a SessionEvent.
+type SessionEventType uint8
+
+const (
+	// SessionCreated indicates that a new session has been created. Both
+	// the old and new status of the event hold the session's initial
+	// status.
+	SessionCreated SessionEventType = 0
+
+	// SessionStatusChanged indicates that the status of an existing session
+	// has changed.
+	SessionStatusChanged SessionEventType = 1
+)
+
+// String returns a human-readable description of the event type.
+func (t SessionEventType) String() string {
+	switch t {
+	case SessionCreated:
+		return "SessionCreated"
+
+	case SessionStatusChanged:
+		return "SessionStatusChanged"
+
+	default:
+		return fmt.Sprintf("SessionEventType(%d)", uint8(t))
+	}
+}
+
+// SessionEvent describes a transition in the lifecycle of a client session.
+type SessionEvent struct {
+	// Type is the kind of the event.
+	Type SessionEventType
+
+	// SessionID is the ID of the session the event applies to.
+	SessionID SessionID
+
+	// OldStatus is the status of the session before the event.
+	OldStatus CSessionStatus
+
+	// NewStatus is the status of the session after the event.
+	NewStatus CSessionStatus
+}
+
+// sessionEventLog accumulates the session events produced within a database
+// transaction, so that they can be delivered once the transaction commits.
+type sessionEventLog []SessionEvent
+
+// addCreated records the creation of the given session.
+func (l *sessionEventLog) addCreated(session *ClientSession) {
+	*l = append(*l, SessionEvent{
+		Type:      SessionCreated,
+		SessionID: session.ID,
+		OldStatus: session.Status,
+		NewStatus: session.Status,
+	})
+}
+
+// addStatusChange records a change in the status of the session with the given
+// ID. Nothing is recorded if the status didn't change.
+func (l *sessionEventLog) addStatusChange(id SessionID, oldStatus,
+	newStatus CSessionStatus) {
+
+	if oldStatus == newStatus {
+		return
+	}
+
+	*l = append(*l, SessionEvent{
+		Type:      SessionStatusChanged,
+		SessionID: id,
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+	})
+}
+
+// SessionEventDispatcher delivers session events to any number of
+// subscribers. Delivery never blocks: an event that doesn't fit in a
+// subscriber's buffer is dropped and counted instead.
+type SessionEventDispatcher struct {
+	dropped uint64 // to be used atomically
+
+	mu          sync.Mutex
+	nextID      uint64
+	subscribers map[uint64]chan SessionEvent
+}
+
+// NewSessionEventDispatcher creates a new SessionEventDispatcher without any
+// subscribers.
+func NewSessionEventDispatcher() *SessionEventDispatcher {
+	return &SessionEventDispatcher{
+		subscribers: make(map[uint64]chan SessionEvent),
+	}
+}
+
+// Subscribe registers a new subscriber, returning the buffered channel over
+// which it receives events and a function that cancels the subscription. The
+// channel is closed once the subscription is canceled.
+func (d *SessionEventDispatcher) Subscribe() (<-chan SessionEvent, func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	id := d.nextID
+	d.nextID++
+
+	events := make(chan SessionEvent, sessionEventBufferSize)
+	d.subscribers[id] = events
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+
+			delete(d.subscribers, id)
+			close(events)
+		})
+	}
+
+	return events, cancel
+}
+
+// Notify delivers the given events to all subscribers, in order.
+func (d *SessionEventDispatcher) Notify(events ...SessionEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, subscriber := range d.subscribers {
+		for _, event := range events {
+			select {
+			case subscriber <- event:
+			default:
+				atomic.AddUint64(&d.dropped, 1)
+			}
+		}
+	}
+}
+
+// NumDropped returns the number of events that were dropped because a
+// subscriber's buffer was full.
+func (d *SessionEventDispatcher) NumDropped() uint64 {
+	return atomic.LoadUint64(&d.dropped)
+}
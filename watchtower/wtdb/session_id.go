@@ -20,6 +20,12 @@ func NewSessionIDFromPubKey(pubKey *btcec.PublicKey) SessionID {
 	return sid
 }
 
+// PubKey parses the session id as the compressed public key it was derived
+// from. An error is returned if the session id isn't a valid public key.
+func (s SessionID) PubKey() (*btcec.PublicKey, error) {
+	return btcec.ParsePubKey(s[:])
+}
+
 // String returns a hex encoding of the session id.
 func (s SessionID) String() string {
 	return hex.EncodeToString(s[:])
@@ -0,0 +1,35 @@
+package wtdb_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightningnetwork/lnd/watchtower/wtdb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSessionIDPubKey asserts that a session id derived from a public key
+// can be parsed back into the same public key, and that session ids that
+// aren't valid public keys fail to parse.
+func TestSessionIDPubKey(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	id := wtdb.NewSessionIDFromPubKey(privKey.PubKey())
+	require.Equal(t, privKey.PubKey().SerializeCompressed(), id[:])
+
+	pubKey, err := id.PubKey()
+	require.NoError(t, err)
+	require.True(t, pubKey.IsEqual(privKey.PubKey()))
+
+	// Deriving the session id again from the parsed key should yield the
+	// same session id.
+	require.Equal(t, id, wtdb.NewSessionIDFromPubKey(pubKey))
+
+	// An all-zero session id isn't a valid public key.
+	var zeroID wtdb.SessionID
+	_, err = zeroID.PubKey()
+	require.Error(t, err)
+}
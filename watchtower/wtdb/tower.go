@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sort"
+	"time"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/tor"
+	"github.com/lightningnetwork/lnd/watchtower/wtwire"
 )
 
 // TowerID is a unique 64-bit identifier allocated to each unique watchtower.
@@ -29,6 +33,74 @@ func (id TowerID) Bytes() []byte {
 	return buf[:]
 }
 
+// ErrInvalidTowerAddr signals that a tower address is malformed and can't be
+// stored or dialed.
+type ErrInvalidTowerAddr struct {
+	// Addr is the malformed address.
+	Addr net.Addr
+
+	// Reason describes why the address is malformed.
+	Reason string
+}
+
+// Error returns a human-readable description of the malformed address.
+func (e *ErrInvalidTowerAddr) Error() string {
+	return fmt.Sprintf("invalid tower address %v: %v", e.Addr, e.Reason)
+}
+
+// ValidateTowerAddr ensures that the given tower address is well-formed. Onion
+// addresses must be v2 or v3 onion services, consisting of a base32-encoded
+// host of the expected length followed by the ".onion" suffix. An
+// ErrInvalidTowerAddr is returned if the address is malformed.
+func ValidateTowerAddr(addr net.Addr) error {
+	onionAddr, ok := addr.(*tor.OnionAddr)
+	if !ok {
+		return nil
+	}
+
+	invalid := func(format string, args ...interface{}) error {
+		return &ErrInvalidTowerAddr{
+			Addr:   addr,
+			Reason: fmt.Sprintf(format, args...),
+		}
+	}
+
+	var decodedLen int
+	service := onionAddr.OnionService
+	switch len(service) {
+	case tor.V2Len:
+		decodedLen = tor.V2DecodedLen
+
+	case tor.V3Len:
+		decodedLen = tor.V3DecodedLen
+
+	default:
+		return invalid("onion service has invalid length %d",
+			len(service))
+	}
+
+	hostLen := len(service) - tor.OnionSuffixLen
+	if service[hostLen:] != tor.OnionSuffix {
+		return invalid("onion service must end in %v", tor.OnionSuffix)
+	}
+
+	host, err := tor.Base32Encoding.DecodeString(service[:hostLen])
+	if err != nil {
+		return invalid("onion service is not base32 encoded: %v", err)
+	}
+
+	if len(host) != decodedLen {
+		return invalid("onion service decoded to %d bytes, expected "+
+			"%d", len(host), decodedLen)
+	}
+
+	if onionAddr.Port <= 0 || onionAddr.Port > 65535 {
+		return invalid("invalid port %d", onionAddr.Port)
+	}
+
+	return nil
+}
+
 // Tower holds the necessary components required to connect to a remote tower.
 // Communication is handled by brontide, and requires both a public key and an
 // address.
@@ -42,6 +114,151 @@ type Tower struct {
 
 	// Addresses is a list of possible addresses to reach the tower.
 	Addresses []net.Addr
+
+	// Nickname is an optional, human-readable label for the tower. Tower
+	// records written before nicknames were introduced will decode with an
+	// empty nickname.
+	Nickname string
+
+	// MaxSessions is the maximum number of non-exhausted sessions the
+	// tower allows the client to hold with it at once. A value of zero
+	// means that the number of sessions is unlimited. Tower records
+	// written before this limit was introduced will decode with no limit.
+	MaxSessions uint32
+
+	// Features is the set of feature bits advertised by the tower during
+	// the init handshake. Towers loaded from the database always have a
+	// feature vector, which is empty for tower records written before
+	// features were stored.
+	Features *lnwire.FeatureVector
+
+	// CreatedAt is the time at which the tower was first created in the
+	// database. This is the zero time for towers created before the field
+	// was introduced.
+	CreatedAt time.Time
+
+	// LastModified is the time at which one of the tower's addresses was
+	// last added or removed, or at which CreateTower or RemoveTower last
+	// changed the status of its sessions. This is the zero time for towers
+	// that haven't been modified since the field was introduced.
+	LastModified time.Time
+
+	// RateLimit is the number of updates per minute that the tower was
+	// last observed to accept from the client, allowing the client to pace
+	// its requests across restarts. A value of zero means that the limit
+	// is unknown or that the tower is unlimited. Tower records written
+	// before rate limits were stored will decode with no limit.
+	RateLimit uint32
+
+	// Stats holds the tower's delivery statistics. The stats are stored
+	// separately from the tower record, and are only populated when
+	// loading a single tower. Towers without any recorded stats have
+	// zero-valued stats.
+	Stats TowerStats
+
+	// Backoff holds the state of the client's backoff between connection
+	// attempts to the tower. Like the stats, it is stored separately from
+	// the tower record, and only populated when loading a single tower.
+	// Towers without any recorded backoff have a zero-valued backoff.
+	Backoff TowerBackoff
+}
+
+// TowerRemovalReport describes the unacked updates that prevent a tower from
+// being removed.
+type TowerRemovalReport struct {
+	// TowerID is the ID of the tower the report is for.
+	TowerID TowerID
+
+	// BlockingSessions maps each of the tower's sessions that still has
+	// unacked updates to the number of those updates.
+	BlockingSessions map[SessionID]uint16
+
+	// NumUnackedUpdates is the total number of unacked updates across all
+	// of the blocking sessions.
+	NumUnackedUpdates uint64
+}
+
+// CanRemove returns true if none of the tower's sessions have unacked updates,
+// in which case RemoveTower won't fail with ErrTowerUnackedUpdates.
+func (r *TowerRemovalReport) CanRemove() bool {
+	return len(r.BlockingSessions) == 0
+}
+
+// String returns a human-readable summary of the report.
+func (r *TowerRemovalReport) String() string {
+	return fmt.Sprintf("tower %d blocked by %d sessions, %d updates",
+		r.TowerID, len(r.BlockingSessions), r.NumUnackedUpdates)
+}
+
+// TowerStats tracks the outcomes of the client's attempts to deliver updates to
+// a tower.
+type TowerStats struct {
+	// NumSuccesses is the number of successful update deliveries.
+	NumSuccesses uint64
+
+	// NumFailures is the number of failed update deliveries.
+	NumFailures uint64
+
+	// LastContact is the time of the most recent delivery attempt,
+	// successful or not.
+	LastContact time.Time
+}
+
+// Encode writes the TowerStats to the passed io.Writer.
+func (s *TowerStats) Encode(w io.Writer) error {
+	return WriteElements(w,
+		s.NumSuccesses,
+		s.NumFailures,
+		timeToUnixNano(s.LastContact),
+	)
+}
+
+// Decode reads the TowerStats from the passed io.Reader.
+func (s *TowerStats) Decode(r io.Reader) error {
+	var lastContact uint64
+	err := ReadElements(r,
+		&s.NumSuccesses,
+		&s.NumFailures,
+		&lastContact,
+	)
+	if err != nil {
+		return err
+	}
+
+	s.LastContact = timeFromUnixNano(lastContact)
+
+	return nil
+}
+
+// TowerBackoff records the exponential backoff between the client's connection
+// attempts to a tower, allowing the schedule to be resumed across restarts. The
+// zero value means that the tower can be connected to immediately.
+type TowerBackoff struct {
+	// NextAttempt is the earliest time at which the client should next
+	// attempt to connect to the tower.
+	NextAttempt time.Time
+
+	// Attempts is the number of consecutive failed connection attempts
+	// that led to the current backoff.
+	Attempts uint32
+}
+
+// Encode writes the TowerBackoff to the passed io.Writer.
+func (b *TowerBackoff) Encode(w io.Writer) error {
+	return WriteElements(w, timeToUnixNano(b.NextAttempt), b.Attempts)
+}
+
+// Decode reads the TowerBackoff from the passed io.Reader.
+func (b *TowerBackoff) Decode(r io.Reader) error {
+	var nextAttempt uint64
+	err := ReadElements(r, &nextAttempt, &b.Attempts)
+	if err != nil {
+		return err
+	}
+
+	b.NextAttempt = timeFromUnixNano(nextAttempt)
+
+	return nil
 }
 
 // AddAddress adds the given address to the tower's in-memory list of addresses.
@@ -77,6 +294,26 @@ func (t *Tower) RemoveAddress(addr net.Addr) {
 	}
 }
 
+// SortAddresses orders the tower's in-memory list of addresses so that those
+// with the most recent entries in lastUsed come first. Addresses without an
+// entry follow, retaining their relative order.
+//
+// NOTE: This method is NOT safe for concurrent use.
+func (t *Tower) SortAddresses(lastUsed map[string]time.Time) {
+	sort.SliceStable(t.Addresses, func(i, j int) bool {
+		usedI, okI := lastUsed[t.Addresses[i].String()]
+		usedJ, okJ := lastUsed[t.Addresses[j].String()]
+
+		switch {
+		case okI && okJ:
+			return usedI.After(usedJ)
+
+		default:
+			return okI && !okJ
+		}
+	})
+}
+
 // LNAddrs generates a list of lnwire.NetAddress from a Tower instance's
 // addresses. This can be used to have a client try multiple addresses for the
 // same Tower.
@@ -104,19 +341,136 @@ func (t *Tower) String() string {
 }
 
 // Encode writes the Tower to the passed io.Writer. The TowerID is not
-// serialized, since it acts as the key.
+// serialized, since it acts as the key. The feature vector is only written if
+// it is set or if the tower has timestamps or a rate limit, which must follow
+// it. Similarly, the timestamps are written if the tower has a rate limit.
 func (t *Tower) Encode(w io.Writer) error {
-	return WriteElements(w,
+	err := WriteElements(w,
 		t.IdentityKey,
 		t.Addresses,
+		[]byte(t.Nickname),
+		t.MaxSessions,
+	)
+	if err != nil {
+		return err
+	}
+
+	hasRateLimit := t.RateLimit != 0
+	hasTimestamps := !t.CreatedAt.IsZero() || !t.LastModified.IsZero() ||
+		hasRateLimit
+	if t.Features == nil && !hasTimestamps {
+		return nil
+	}
+
+	features := t.Features
+	if features == nil {
+		features = NewTowerFeatures(nil)
+	}
+	if err := features.Encode(w); err != nil {
+		return err
+	}
+
+	if !hasTimestamps {
+		return nil
+	}
+
+	err = WriteElements(w,
+		timeToUnixNano(t.CreatedAt),
+		timeToUnixNano(t.LastModified),
 	)
+	if err != nil {
+		return err
+	}
+
+	if !hasRateLimit {
+		return nil
+	}
+
+	return WriteElement(w, t.RateLimit)
 }
 
 // Decode reads a Tower from the passed io.Reader. The TowerID is meant to be
 // decoded from the key.
 func (t *Tower) Decode(r io.Reader) error {
-	return ReadElements(r,
+	err := ReadElements(r,
 		&t.IdentityKey,
 		&t.Addresses,
 	)
+	if err != nil {
+		return err
+	}
+
+	// The nickname is length-prefixed and optional, since older tower
+	// records were written without one.
+	var nickname []byte
+	err = ReadElement(r, &nickname)
+	switch {
+	case err == io.EOF:
+		return nil
+
+	case err != nil:
+		return err
+	}
+
+	t.Nickname = string(nickname)
+
+	// Similarly, the session limit is optional since older tower records
+	// were written without one.
+	err = ReadElement(r, &t.MaxSessions)
+	switch {
+	case err == io.EOF:
+		return nil
+
+	case err != nil:
+		return err
+	}
+
+	// The feature vector is optional since older tower records were
+	// written without one.
+	features := lnwire.NewRawFeatureVector()
+	err = features.Decode(r)
+	switch {
+	case err == io.EOF:
+		return nil
+
+	case err != nil:
+		return err
+	}
+
+	t.Features = NewTowerFeatures(features)
+
+	// The timestamps are optional since older tower records were written
+	// without them.
+	var createdAt, lastModified uint64
+	err = ReadElement(r, &createdAt)
+	switch {
+	case err == io.EOF:
+		return nil
+
+	case err != nil:
+		return err
+	}
+
+	if err := ReadElement(r, &lastModified); err != nil {
+		return err
+	}
+
+	t.CreatedAt = timeFromUnixNano(createdAt)
+	t.LastModified = timeFromUnixNano(lastModified)
+
+	// Finally, the rate limit is optional since older tower records were
+	// written without one.
+	err = ReadElement(r, &t.RateLimit)
+	if err == io.EOF {
+		return nil
+	}
+
+	return err
+}
+
+// NewTowerFeatures wraps the given raw feature vector of a tower into a
+// FeatureVector that understands the watchtower feature bits. A nil raw vector
+// results in an empty feature vector.
+func NewTowerFeatures(raw *lnwire.RawFeatureVector) *lnwire.FeatureVector {
+	return lnwire.NewFeatureVector(raw, wtwire.FeatureNames)
 }
@@ -2,10 +2,15 @@ package wtdb
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"errors"
+	"io"
+	"time"
 
+	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/lightningnetwork/lnd/chainntnfs"
+	"github.com/lightningnetwork/lnd/clock"
 	"github.com/lightningnetwork/lnd/kvdb"
 	"github.com/lightningnetwork/lnd/watchtower/blob"
 )
@@ -16,17 +21,29 @@ var (
 	sessionsBkt = []byte("sessions-bucket")
 
 	// updatesBkt is a bucket containing all state updates sent by clients.
-	// The updates are further bucketed by session id to prevent clients
-	// from overwrite each other.
-	//   hint => session id -> update
+	// The updates are further keyed by session id and sequence number, such
+	// that distinct updates sharing a hint never overwrite each other. Each
+	// update is stored without its encrypted blob, followed by the hash of
+	// the blob in blobsBkt. Updates stored before blobs were deduplicated
+	// hold their blob inline, and updates stored before sequence numbers
+	// were part of the key are keyed by their session id alone.
+	//   hint => session id || seqnum -> update || blob hash
 	updatesBkt = []byte("updates-bucket")
 
+	// blobsBkt is a bucket containing the encrypted blobs of all state
+	// updates, keyed by their SHA-256 hash. Each distinct blob is stored
+	// once, along with the number of updates referencing it.
+	//   blob hash -> refcount || encrypted blob
+	blobsBkt = []byte("blobs-bucket")
+
 	// updateIndexBkt is a bucket that indexes all state updates by their
 	// overarching session id. This allows for efficient lookup of updates
 	// by their session id, which is currently used to aide deletion
-	// performance.
-	//  session id => hint1 -> []byte{}
-	//             => hint2 -> []byte{}
+	// performance. Each hint maps to the time at which the session's latest
+	// update under the hint was received, which is empty for updates stored
+	// before receipt times were recorded.
+	//  session id => hint1 -> receipt time
+	//             => hint2 -> receipt time
 	updateIndexBkt = []byte("update-index-bucket")
 
 	// lookoutTipBkt is a bucket containing the last block epoch processed
@@ -45,12 +62,48 @@ var (
 	// ErrInvalidBlobSize indicates that the encrypted blob provided by the
 	// client is not valid according to the blob type of the session.
 	ErrInvalidBlobSize = errors.New("invalid blob size")
+
+	// ErrBlobNotFound signals that a state update references an encrypted
+	// blob that isn't stored in the database.
+	ErrBlobNotFound = errors.New("encrypted blob not found")
 )
 
+// blobHashSize is the size of the hashes under which encrypted blobs are
+// stored.
+const blobHashSize = sha256.Size
+
 // TowerDB is single database providing a persistent storage engine for the
 // wtserver and lookout subsystems.
 type TowerDB struct {
-	db kvdb.Backend
+	db    kvdb.Backend
+	clock clock.Clock
+}
+
+// TowerDBCfg houses the options that can be used when opening the tower
+// database.
+type TowerDBCfg struct {
+	// Clock is used to timestamp the state updates received by the tower.
+	Clock clock.Clock
+}
+
+// TowerDBOption is a functional option that can be used to modify how the
+// tower database is opened.
+type TowerDBOption func(cfg *TowerDBCfg)
+
+// NewTowerDBCfg constructs a new TowerDBCfg with the default values, which
+// use the system clock.
+func NewTowerDBCfg() *TowerDBCfg {
+	return &TowerDBCfg{
+		Clock: clock.NewDefaultClock(),
+	}
+}
+
+// WithTowerClock sets the clock used to timestamp the state updates received
+// by the tower.
+func WithTowerClock(c clock.Clock) TowerDBOption {
+	return func(cfg *TowerDBCfg) {
+		cfg.Clock = c
+	}
 }
 
 // OpenTowerDB opens the tower database given the path to the database's
@@ -58,16 +111,22 @@ type TowerDB struct {
 // one using the latest version number and bucket structure. If a database
 // exists but has a lower version number than the current version, any necessary
 // migrations will be applied before returning. Any attempt to open a database
-// with a version number higher that the latest version will fail to prevent
-// accidental reversion.
-func OpenTowerDB(db kvdb.Backend) (*TowerDB, error) {
+// with a version number higher that the latest version will fail with
+// ErrDBVersionTooNew to prevent accidental reversion.
+func OpenTowerDB(db kvdb.Backend, opts ...TowerDBOption) (*TowerDB, error) {
+	cfg := NewTowerDBCfg()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	firstInit, err := isFirstInit(db)
 	if err != nil {
 		return nil, err
 	}
 
 	towerDB := &TowerDB{
-		db: db,
+		db:    db,
+		clock: cfg.Clock,
 	}
 
 	err = initOrSyncVersions(towerDB, firstInit, towerDBVersions)
@@ -97,6 +156,7 @@ func initTowerDBBuckets(tx kvdb.RwTx) error {
 		sessionsBkt,
 		updateIndexBkt,
 		updatesBkt,
+		blobsBkt,
 		lookoutTipBkt,
 	}
 
@@ -164,6 +224,45 @@ func (t *TowerDB) GetSessionInfo(id *SessionID) (*SessionInfo, error) {
 	return session, nil
 }
 
+// GetSessionsByClient returns the sessions negotiated by the client with the
+// given public key. Since a session is identified by the public key the client
+// used to negotiate it, the sessions bucket already indexes sessions by client,
+// and at most one session is returned. An empty slice is returned if the
+// client has no session.
+func (t *TowerDB) GetSessionsByClient(pk *btcec.PublicKey) ([]*SessionInfo,
+	error) {
+
+	id := NewSessionIDFromPubKey(pk)
+
+	var clientSessions []*SessionInfo
+	err := kvdb.View(t.db, func(tx kvdb.RTx) error {
+		sessions := tx.ReadBucket(sessionsBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		session, err := getSession(sessions, id[:])
+		switch {
+		case err == ErrSessionNotFound:
+			return nil
+
+		case err != nil:
+			return err
+		}
+
+		clientSessions = append(clientSessions, session)
+
+		return nil
+	}, func() {
+		clientSessions = make([]*SessionInfo, 0, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return clientSessions, nil
+}
+
 // InsertSessionInfo records a negotiated session in the tower database. An
 // error is returned if the session already exists.
 func (t *TowerDB) InsertSessionInfo(session *SessionInfo) error {
@@ -232,6 +331,11 @@ func (t *TowerDB) InsertStateUpdate(update *SessionStateUpdate) (uint16, error)
 			return ErrUninitializedDB
 		}
 
+		blobs := tx.ReadWriteBucket(blobsBkt)
+		if blobs == nil {
+			return ErrUninitializedDB
+		}
+
 		// Fetch the session corresponding to the update's session id.
 		// This will be used to validate that the update's sequence
 		// number and last applied values are sane.
@@ -242,8 +346,8 @@ func (t *TowerDB) InsertStateUpdate(update *SessionStateUpdate) (uint16, error)
 
 		// Assert that the blob is the correct size for the session's
 		// blob type.
-		expBlobSize := blob.Size(session.Policy.BlobType)
-		if len(update.EncryptedBlob) != expBlobSize {
+		blobType := session.Policy.BlobType
+		if !blob.IsValidSize(blobType, len(update.EncryptedBlob)) {
 			return ErrInvalidBlobSize
 		}
 
@@ -267,28 +371,21 @@ func (t *TowerDB) InsertStateUpdate(update *SessionStateUpdate) (uint16, error)
 			return err
 		}
 
-		// Create or load the hint bucket for this state update's hint
-		// and write the given update.
-		hints, err := updates.CreateBucketIfNotExists(update.Hint[:])
-		if err != nil {
-			return err
-		}
-
-		var b bytes.Buffer
-		err = update.Encode(&b)
-		if err != nil {
-			return err
-		}
-
-		err = hints.Put(update.ID[:], b.Bytes())
+		// Write the given update under its hint, storing its encrypted
+		// blob by reference.
+		err = putUpdate(updates, blobs, update)
 		if err != nil {
 			return err
 		}
 
 		// Finally, create an entry in the update index to track this
-		// hint under its session id. This will allow us to delete the
-		// entries efficiently if the session is ever removed.
-		return putHintForSession(updateIndex, &update.ID, update.Hint)
+		// hint under its session id along with the time the update was
+		// received. This will allow us to delete the entries
+		// efficiently if the session is ever removed, or once the
+		// update is old enough to be pruned.
+		return putHintForSession(
+			updateIndex, &update.ID, update.Hint, t.clock.Now(),
+		)
 	}, func() {
 		lastApplied = 0
 	})
@@ -318,6 +415,11 @@ func (t *TowerDB) DeleteSession(target SessionID) error {
 			return ErrUninitializedDB
 		}
 
+		blobs := tx.ReadWriteBucket(blobsBkt)
+		if blobs == nil {
+			return ErrUninitializedDB
+		}
+
 		// Fail if the session doesn't exit.
 		_, err := getSession(sessions, target[:])
 		if err != nil {
@@ -340,47 +442,110 @@ func (t *TowerDB) DeleteSession(target SessionID) error {
 		for _, hint := range hints {
 			// Remove the state updates for any blobs stored under
 			// the target session identifier.
-			updatesForHint := updates.NestedReadWriteBucket(hint[:])
-			if updatesForHint == nil {
-				continue
+			_, err := deleteUpdates(updates, blobs, target, hint)
+			if err != nil {
+				return err
 			}
+		}
 
-			update := updatesForHint.Get(target[:])
-			if update == nil {
-				continue
-			}
+		// Finally, remove this session from the update index, which
+		// also removes any of the indexed hints beneath it.
+		return removeSessionHintBkt(updateIndex, &target)
+	}, func() {})
+}
 
-			err := updatesForHint.Delete(target[:])
-			if err != nil {
-				return err
+// PruneServerUpdates removes all state updates that were received before the
+// given time, returning the number of updates removed. Updates stored before
+// receipt times were recorded are treated as infinitely old. The sessions
+// themselves, including their last applied values, are left untouched.
+func (t *TowerDB) PruneServerUpdates(before time.Time) (int, error) {
+	var numPruned int
+	err := kvdb.Update(t.db, func(tx kvdb.RwTx) error {
+		updates := tx.ReadWriteBucket(updatesBkt)
+		if updates == nil {
+			return ErrUninitializedDB
+		}
+
+		updateIndex := tx.ReadWriteBucket(updateIndexBkt)
+		if updateIndex == nil {
+			return ErrUninitializedDB
+		}
+
+		blobs := tx.ReadWriteBucket(blobsBkt)
+		if blobs == nil {
+			return ErrUninitializedDB
+		}
+
+		// Collect the (session, hint) pairs to prune first, since the
+		// buckets can't be modified while they're being iterated. All
+		// of a session's updates under a hint are pruned together, once
+		// the latest of them is old enough.
+		type prunedUpdate struct {
+			id   SessionID
+			hint blob.BreachHint
+		}
+		var pruned []prunedUpdate
+
+		err := updateIndex.ForEach(func(k, _ []byte) error {
+			sessionHints := updateIndex.NestedReadBucket(k)
+			if sessionHints == nil {
+				return nil
 			}
 
-			// If this was the last state update, we can also remove
-			// the hint that would map to an empty set.
-			err = isBucketEmpty(updatesForHint)
-			switch {
+			var id SessionID
+			copy(id[:], k)
 
-			// Other updates exist for this hint, keep the bucket.
-			case err == errBucketNotEmpty:
-				continue
+			return sessionHints.ForEach(func(h, v []byte) error {
+				if len(h) != blob.BreachHintSize {
+					return nil
+				}
+
+				received := getReceiptTime(v)
+				if !received.Before(before) {
+					return nil
+				}
 
-			// Unexpected error.
-			case err != nil:
+				var hint blob.BreachHint
+				copy(hint[:], h)
+
+				pruned = append(pruned, prunedUpdate{
+					id:   id,
+					hint: hint,
+				})
+
+				return nil
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		numPruned = 0
+		for _, p := range pruned {
+			n, err := deleteUpdates(updates, blobs, p.id, p.hint)
+			if err != nil {
 				return err
+			}
+			numPruned += n
 
-			// No more updates for this hint, prune hint bucket.
-			default:
-				err = updates.DeleteNestedBucket(hint[:])
-				if err != nil {
-					return err
-				}
+			sessionHints := updateIndex.NestedReadWriteBucket(
+				p.id[:],
+			)
+			err = sessionHints.Delete(p.hint[:])
+			if err != nil {
+				return err
 			}
 		}
 
-		// Finally, remove this session from the update index, which
-		// also removes any of the indexed hints beneath it.
-		return removeSessionHintBkt(updateIndex, &target)
-	}, func() {})
+		return nil
+	}, func() {
+		numPruned = 0
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return numPruned, nil
 }
 
 // QueryMatches searches against all known state updates for any that match the
@@ -399,6 +564,11 @@ func (t *TowerDB) QueryMatches(breachHints []blob.BreachHint) ([]Match, error) {
 			return ErrUninitializedDB
 		}
 
+		blobs := tx.ReadBucket(blobsBkt)
+		if blobs == nil {
+			return ErrUninitializedDB
+		}
+
 		// Iterate through the target breach hints, appending any
 		// matching updates to the set of matches.
 		for _, hint := range breachHints {
@@ -412,10 +582,15 @@ func (t *TowerDB) QueryMatches(breachHints []blob.BreachHint) ([]Match, error) {
 			// Otherwise, iterate through all (session id, update)
 			// pairs, creating a Match for each.
 			err := updatesForHint.ForEach(func(k, v []byte) error {
+				if len(k) < SessionIDSize {
+					return nil
+				}
+
 				// Load the session via the session id for this
 				// update. The session info contains further
 				// instructions for how to process the state
 				// update.
+				k = k[:SessionIDSize]
 				session, err := getSession(sessions, k)
 				switch {
 				case err == ErrSessionNotFound:
@@ -428,10 +603,9 @@ func (t *TowerDB) QueryMatches(breachHints []blob.BreachHint) ([]Match, error) {
 					return err
 				}
 
-				// Decode the state update containing the
+				// Decode the state update along with its
 				// encrypted blob.
-				update := &SessionStateUpdate{}
-				err = update.Decode(bytes.NewReader(v))
+				update, err := getUpdate(blobs, v)
 				if err != nil {
 					return err
 				}
@@ -469,6 +643,76 @@ func (t *TowerDB) QueryMatches(breachHints []blob.BreachHint) ([]Match, error) {
 	return matches, nil
 }
 
+// StoredBlob is a state update stored by the tower, as returned by
+// LookupBlobsByHint.
+type StoredBlob struct {
+	// ID is the session id of the client who uploaded the state update.
+	ID SessionID
+
+	// SeqNum is the session sequence number occupied by the state update.
+	SeqNum uint16
+
+	// Hint is the breach hint under which the state update is stored.
+	Hint blob.BreachHint
+
+	// EncryptedBlob is the encrypted payload uploaded by the client.
+	EncryptedBlob []byte
+}
+
+// LookupBlobsByHint returns all state updates stored under the given breach
+// hint, ordered by session id and sequence number. Unlike QueryMatches, the
+// updates are returned regardless of whether their session still exists.
+func (t *TowerDB) LookupBlobsByHint(hint blob.BreachHint) ([]StoredBlob,
+	error) {
+
+	var storedBlobs []StoredBlob
+	err := kvdb.View(t.db, func(tx kvdb.RTx) error {
+		updates := tx.ReadBucket(updatesBkt)
+		if updates == nil {
+			return ErrUninitializedDB
+		}
+
+		blobs := tx.ReadBucket(blobsBkt)
+		if blobs == nil {
+			return ErrUninitializedDB
+		}
+
+		updatesForHint := updates.NestedReadBucket(hint[:])
+		if updatesForHint == nil {
+			return nil
+		}
+
+		return updatesForHint.ForEach(func(k, v []byte) error {
+			if len(k) < SessionIDSize {
+				return nil
+			}
+
+			update, err := getUpdate(blobs, v)
+			if err != nil {
+				return err
+			}
+
+			storedBlob := StoredBlob{
+				SeqNum:        update.SeqNum,
+				Hint:          hint,
+				EncryptedBlob: update.EncryptedBlob,
+			}
+			copy(storedBlob.ID[:], k)
+
+			storedBlobs = append(storedBlobs, storedBlob)
+
+			return nil
+		})
+	}, func() {
+		storedBlobs = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return storedBlobs, nil
+}
+
 // SetLookoutTip stores the provided epoch as the latest lookout tip epoch in
 // the tower database.
 func (t *TowerDB) SetLookoutTip(epoch *chainntnfs.BlockEpoch) error {
@@ -505,6 +749,124 @@ func (t *TowerDB) GetLookoutTip() (*chainntnfs.BlockEpoch, error) {
 	return epoch, nil
 }
 
+// TowerServerStats summarizes the session state held by the tower.
+type TowerServerStats struct {
+	// NumSessions is the number of sessions stored by the tower.
+	NumSessions uint64
+
+	// NumUpdates is the total number of state updates stored across all
+	// sessions.
+	NumUpdates uint64
+
+	// NumClients is the number of distinct client public keys that have
+	// negotiated sessions with the tower. Since a session is identified by
+	// the public key of the client that negotiated it, clients that use a
+	// fresh key for every session are counted once per session.
+	NumClients uint64
+}
+
+// TowerServerStats returns a summary of the sessions and state updates stored
+// by the tower. All values are computed within a single read transaction.
+func (t *TowerDB) TowerServerStats() (TowerServerStats, error) {
+	var stats TowerServerStats
+	err := kvdb.View(t.db, func(tx kvdb.RTx) error {
+		sessions := tx.ReadBucket(sessionsBkt)
+		if sessions == nil {
+			return ErrUninitializedDB
+		}
+
+		updates := tx.ReadBucket(updatesBkt)
+		if updates == nil {
+			return ErrUninitializedDB
+		}
+
+		err := sessions.ForEach(func(_, _ []byte) error {
+			stats.NumSessions++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Sessions are keyed by the client's public key, so every
+		// stored session belongs to a distinct client key.
+		stats.NumClients = stats.NumSessions
+
+		// Each hint maps to a nested bucket holding every update that
+		// was stored under that hint.
+		return updates.ForEach(func(hint, _ []byte) error {
+			updatesForHint := updates.NestedReadBucket(hint)
+			if updatesForHint == nil {
+				return nil
+			}
+
+			return updatesForHint.ForEach(func(_, _ []byte) error {
+				stats.NumUpdates++
+				return nil
+			})
+		})
+	}, func() {
+		stats = TowerServerStats{}
+	})
+	if err != nil {
+		return TowerServerStats{}, err
+	}
+
+	return stats, nil
+}
+
+// DedupStats summarizes the storage saved by storing each distinct encrypted
+// blob held by the tower only once.
+type DedupStats struct {
+	// NumBlobs is the number of distinct encrypted blobs stored.
+	NumBlobs uint64
+
+	// NumBlobRefs is the number of state updates referencing the stored
+	// blobs. Updates stored before blobs were deduplicated hold their blob
+	// inline, and aren't counted.
+	NumBlobRefs uint64
+
+	// BytesSaved is the number of bytes of encrypted blobs that would
+	// have been stored in addition had every update held its own copy.
+	BytesSaved uint64
+}
+
+// DedupStats returns a summary of the encrypted blobs shared between the state
+// updates stored by the tower.
+func (t *TowerDB) DedupStats() (DedupStats, error) {
+	var stats DedupStats
+	err := kvdb.View(t.db, func(tx kvdb.RTx) error {
+		blobs := tx.ReadBucket(blobsBkt)
+		if blobs == nil {
+			return ErrUninitializedDB
+		}
+
+		return blobs.ForEach(func(_, v []byte) error {
+			if len(v) < 8 {
+				return nil
+			}
+
+			refs := byteOrder.Uint64(v[:8])
+			if refs == 0 {
+				return nil
+			}
+
+			stats.NumBlobs++
+			stats.NumBlobRefs += refs
+			stats.BytesSaved += (refs - 1) * uint64(len(v)-8)
+
+			return nil
+		})
+	}, func() {
+		stats = DedupStats{}
+	})
+	if err != nil {
+		return DedupStats{}, err
+	}
+
+	return stats, nil
+}
+
 // getSession retrieves the session info from the sessions bucket identified by
 // its session id. An error is returned if the session is not found or a
 // deserialization error occurs.
@@ -581,19 +943,238 @@ func getHintsForSession(updateIndex kvdb.RBucket,
 }
 
 // putHintForSession inserts a record into the update index for a given
-// (session, hint) pair. The hints are coalesced under a bucket for the target
-// session id, and used to perform efficient removal of updates. If the index
-// for the session has not been initialized, this method returns
-// ErrNoSessionHintIndex.
+// (session, hint) pair, storing the time at which the update was received. The
+// hints are coalesced under a bucket for the target session id, and used to
+// perform efficient removal of updates. If the index for the session has not
+// been initialized, this method returns ErrNoSessionHintIndex.
 func putHintForSession(updateIndex kvdb.RwBucket, id *SessionID,
-	hint blob.BreachHint) error {
+	hint blob.BreachHint, received time.Time) error {
 
 	sessionHints := updateIndex.NestedReadWriteBucket(id[:])
 	if sessionHints == nil {
 		return ErrNoSessionHintIndex
 	}
 
-	return sessionHints.Put(hint[:], []byte{})
+	var b [8]byte
+	byteOrder.PutUint64(b[:], timeToUnixNano(received))
+
+	return sessionHints.Put(hint[:], b[:])
+}
+
+// getReceiptTime decodes the receipt time stored in the update index. The zero
+// time is returned for updates stored before receipt times were recorded.
+func getReceiptTime(v []byte) time.Time {
+	if len(v) != 8 {
+		return time.Time{}
+	}
+
+	return timeFromUnixNano(byteOrder.Uint64(v))
+}
+
+// updateKey returns the key under which the state update with the given
+// session id and sequence number is stored within its hint's bucket.
+func updateKey(id *SessionID, seqNum uint16) []byte {
+	var key [SessionIDSize + 2]byte
+	copy(key[:], id[:])
+	byteOrder.PutUint16(key[SessionIDSize:], seqNum)
+
+	return key[:]
+}
+
+// putUpdate stores the state update under its hint, session id and sequence
+// number. The update's encrypted blob is stored by reference in the blobs
+// bucket, releasing the blob of any update previously stored for the same
+// (hint, session, seqnum) triple.
+func putUpdate(updates, blobs kvdb.RwBucket,
+	update *SessionStateUpdate) error {
+
+	hints, err := updates.CreateBucketIfNotExists(update.Hint[:])
+	if err != nil {
+		return err
+	}
+
+	key := updateKey(&update.ID, update.SeqNum)
+	if v := hints.Get(key); v != nil {
+		if err := releaseUpdateBlob(blobs, v); err != nil {
+			return err
+		}
+	}
+
+	blobHash, err := retainBlob(blobs, update.EncryptedBlob)
+	if err != nil {
+		return err
+	}
+
+	// The update is encoded without its blob, followed by the hash under
+	// which the blob is stored.
+	ref := *update
+	ref.EncryptedBlob = nil
+
+	var b bytes.Buffer
+	if err := ref.Encode(&b); err != nil {
+		return err
+	}
+	b.Write(blobHash[:])
+
+	return hints.Put(key, b.Bytes())
+}
+
+// decodeUpdate decodes a state update stored in the updates bucket, returning
+// the hash of its encrypted blob, or nil if the update holds its blob inline.
+func decodeUpdate(v []byte) (*SessionStateUpdate, []byte, error) {
+	r := bytes.NewReader(v)
+
+	update := &SessionStateUpdate{}
+	if err := update.Decode(r); err != nil {
+		return nil, nil, err
+	}
+
+	// Updates stored before blobs were deduplicated aren't followed by a
+	// blob hash.
+	if r.Len() == 0 {
+		return update, nil, nil
+	}
+
+	blobHash := make([]byte, blobHashSize)
+	if _, err := io.ReadFull(r, blobHash); err != nil {
+		return nil, nil, err
+	}
+
+	return update, blobHash, nil
+}
+
+// getUpdate decodes a state update stored in the updates bucket, loading its
+// encrypted blob from the blobs bucket if it's stored by reference.
+func getUpdate(blobs kvdb.RBucket, v []byte) (*SessionStateUpdate, error) {
+	update, blobHash, err := decodeUpdate(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if blobHash == nil {
+		return update, nil
+	}
+
+	blobBytes := blobs.Get(blobHash)
+	if len(blobBytes) < 8 {
+		return nil, ErrBlobNotFound
+	}
+
+	update.EncryptedBlob = make([]byte, len(blobBytes)-8)
+	copy(update.EncryptedBlob, blobBytes[8:])
+
+	return update, nil
+}
+
+// retainBlob stores the encrypted blob in the blobs bucket, or increments its
+// refcount if it's already stored, returning the hash under which it's stored.
+func retainBlob(blobs kvdb.RwBucket, encBlob []byte) ([blobHashSize]byte,
+	error) {
+
+	blobHash := sha256.Sum256(encBlob)
+
+	var refs uint64
+	if v := blobs.Get(blobHash[:]); len(v) >= 8 {
+		refs = byteOrder.Uint64(v[:8])
+	}
+
+	v := make([]byte, 8+len(encBlob))
+	byteOrder.PutUint64(v[:8], refs+1)
+	copy(v[8:], encBlob)
+
+	return blobHash, blobs.Put(blobHash[:], v)
+}
+
+// releaseUpdateBlob decrements the refcount of the encrypted blob referenced
+// by the given stored state update, removing the blob once it's no longer
+// referenced. Updates holding their blob inline are ignored.
+func releaseUpdateBlob(blobs kvdb.RwBucket, v []byte) error {
+	_, blobHash, err := decodeUpdate(v)
+	if err != nil {
+		return err
+	}
+
+	if blobHash == nil {
+		return nil
+	}
+
+	blobBytes := blobs.Get(blobHash)
+	if len(blobBytes) < 8 {
+		return nil
+	}
+
+	refs := byteOrder.Uint64(blobBytes[:8])
+	if refs <= 1 {
+		return blobs.Delete(blobHash)
+	}
+
+	// The value returned by Get must not be modified, so the blob is
+	// copied before its refcount is decremented.
+	newBlobBytes := make([]byte, len(blobBytes))
+	copy(newBlobBytes, blobBytes)
+	byteOrder.PutUint64(newBlobBytes[:8], refs-1)
+
+	return blobs.Put(blobHash, newBlobBytes)
+}
+
+// deleteUpdates removes the state updates stored for the given (session, hint)
+// pair, if any, releasing their references to their encrypted blobs, and
+// returns the number of updates removed. If these were the last updates stored
+// under the hint, the hint's bucket is removed as well.
+func deleteUpdates(updates, blobs kvdb.RwBucket, id SessionID,
+	hint blob.BreachHint) (int, error) {
+
+	updatesForHint := updates.NestedReadWriteBucket(hint[:])
+	if updatesForHint == nil {
+		return 0, nil
+	}
+
+	// The session's updates are keyed by its session id, followed by their
+	// sequence number unless they were stored before sequence numbers were
+	// part of the key. Since session ids have a fixed size, they're all
+	// found by seeking to the session id.
+	var keys [][]byte
+	cursor := updatesForHint.ReadCursor()
+	k, v := cursor.Seek(id[:])
+	for ; k != nil && bytes.HasPrefix(k, id[:]); k, v = cursor.Next() {
+		if err := releaseUpdateBlob(blobs, v); err != nil {
+			return 0, err
+		}
+
+		key := make([]byte, len(k))
+		copy(key, k)
+		keys = append(keys, key)
+	}
+
+	// The keys are deleted once the iteration is complete, since the
+	// bucket can't be modified while it's being iterated.
+	for _, key := range keys {
+		if err := updatesForHint.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	// If these were the last state updates, we can also remove the hint
+	// that would map to an empty set.
+	err := isBucketEmpty(updatesForHint)
+	switch {
+
+	// Other updates exist for this hint, keep the bucket.
+	case err == errBucketNotEmpty:
+		return len(keys), nil
+
+	// Unexpected error.
+	case err != nil:
+		return 0, err
+
+	// No more updates for this hint, prune hint bucket.
+	default:
+		return len(keys), updates.DeleteNestedBucket(hint[:])
+	}
 }
 
 // putLookoutEpoch stores the given lookout tip block epoch in provided bucket.
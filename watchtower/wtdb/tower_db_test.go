@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"encoding/binary"
 	"testing"
+	"time"
 
+	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/lightningnetwork/lnd/chainntnfs"
+	"github.com/lightningnetwork/lnd/clock"
 	"github.com/lightningnetwork/lnd/kvdb"
 	"github.com/lightningnetwork/lnd/watchtower"
 	"github.com/lightningnetwork/lnd/watchtower/blob"
@@ -20,23 +23,27 @@ var (
 	testBlob = make([]byte, blob.Size(blob.TypeAltruistCommit))
 )
 
-// dbInit is a closure used to initialize a watchtower.DB instance.
-type dbInit func(*testing.T) watchtower.DB
+// dbInit is a closure used to initialize a watchtower.DB instance that uses
+// the given clock.
+type dbInit func(*testing.T, clock.Clock) watchtower.DB
 
 // towerDBHarness holds the resources required to execute the tower db tests.
 type towerDBHarness struct {
-	t  *testing.T
-	db watchtower.DB
+	t     *testing.T
+	db    watchtower.DB
+	clock *clock.TestClock
 }
 
 // newTowerDBHarness initializes a fresh test harness for testing watchtower.DB
 // implementations.
 func newTowerDBHarness(t *testing.T, init dbInit) *towerDBHarness {
-	db := init(t)
+	testClock := clock.NewTestClock(time.Unix(1000, 0))
+	db := init(t, testClock)
 
 	h := &towerDBHarness{
-		t:  t,
-		db: db,
+		t:     t,
+		db:    db,
+		clock: testClock,
 	}
 
 	return h
@@ -207,6 +214,76 @@ func testMultipleMatches(h *towerDBHarness) {
 	}
 }
 
+// testBreachHintCollision asserts that distinct updates sharing a breach hint,
+// whether sent by the same or different sessions, are all stored and returned
+// by LookupBlobsByHint, rather than overwriting each other.
+func testBreachHintCollision(h *towerDBHarness) {
+	const numSessions = 2
+
+	for i := 0; i < numSessions; i++ {
+		h.insertSession(&wtdb.SessionInfo{
+			ID: *id(i),
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blob.TypeAltruistCommit,
+					SweepFeeRate: wtpolicy.DefaultSweepFeeRate,
+				},
+				MaxUpdates: 3,
+			},
+			RewardAddress: []byte{},
+		}, nil)
+	}
+
+	// Nothing is stored under the hint yet.
+	hint := blob.BreachHint{0x01}
+	storedBlobs, err := h.db.LookupBlobsByHint(hint)
+	require.NoError(h.t, err)
+	require.Empty(h.t, storedBlobs)
+
+	// Store two updates of the first session and one update of the second
+	// session, all under the same hint.
+	updates := []*wtdb.SessionStateUpdate{
+		updateFromInt(id(0), 1, 0),
+		updateFromInt(id(0), 2, 1),
+		updateFromInt(id(1), 1, 0),
+	}
+	for _, update := range updates {
+		update.Hint = hint
+		h.insertUpdate(update, nil)
+	}
+
+	// All three updates should be retrievable.
+	storedBlobs, err = h.db.LookupBlobsByHint(hint)
+	require.NoError(h.t, err)
+	require.Len(h.t, storedBlobs, len(updates))
+	for i, update := range updates {
+		require.Equal(h.t, wtdb.StoredBlob{
+			ID:            update.ID,
+			SeqNum:        update.SeqNum,
+			Hint:          hint,
+			EncryptedBlob: update.EncryptedBlob,
+		}, storedBlobs[i])
+	}
+
+	require.Len(h.t, h.queryMatches(hint), len(updates))
+
+	stats, err := h.db.TowerServerStats()
+	require.NoError(h.t, err)
+	require.EqualValues(h.t, len(updates), stats.NumUpdates)
+
+	// Deleting the first session should remove both of its updates, while
+	// leaving the second session's update intact.
+	h.deleteSession(*id(0), nil)
+
+	storedBlobs, err = h.db.LookupBlobsByHint(hint)
+	require.NoError(h.t, err)
+	require.Len(h.t, storedBlobs, 1)
+	require.Equal(h.t, *id(1), storedBlobs[0].ID)
+	require.Equal(
+		h.t, updates[2].EncryptedBlob, storedBlobs[0].EncryptedBlob,
+	)
+}
+
 // testLookoutTip asserts that the database properly stores and returns the
 // lookout tip block epochs. It also asserts that the epoch returned is nil when
 // no tip has ever been set.
@@ -237,6 +314,318 @@ func testLookoutTip(h *towerDBHarness) {
 	}
 }
 
+// testTowerServerStats asserts that TowerServerStats reports the number of
+// stored sessions, state updates and distinct clients.
+func testTowerServerStats(h *towerDBHarness) {
+	// A fresh database should report no sessions or updates.
+	stats, err := h.db.TowerServerStats()
+	require.NoError(h.t, err)
+	require.Equal(h.t, wtdb.TowerServerStats{}, stats)
+
+	// Insert two sessions, storing two updates under the first and one
+	// under the second. The second update of the first session shares a
+	// hint with the update of the second session.
+	for i := 0; i < 2; i++ {
+		session := &wtdb.SessionInfo{
+			ID: *id(i),
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blob.TypeAltruistCommit,
+					SweepFeeRate: wtpolicy.DefaultSweepFeeRate,
+				},
+				MaxUpdates: 3,
+			},
+			RewardAddress: []byte{},
+		}
+		h.insertSession(session, nil)
+	}
+
+	h.insertUpdate(&wtdb.SessionStateUpdate{
+		ID:            *id(0),
+		SeqNum:        1,
+		Hint:          blob.BreachHint{0x01},
+		EncryptedBlob: testBlob,
+	}, nil)
+	h.insertUpdate(&wtdb.SessionStateUpdate{
+		ID:            *id(0),
+		SeqNum:        2,
+		Hint:          blob.BreachHint{0x02},
+		EncryptedBlob: testBlob,
+	}, nil)
+	h.insertUpdate(&wtdb.SessionStateUpdate{
+		ID:            *id(1),
+		SeqNum:        1,
+		Hint:          blob.BreachHint{0x02},
+		EncryptedBlob: testBlob,
+	}, nil)
+
+	stats, err = h.db.TowerServerStats()
+	require.NoError(h.t, err)
+	require.Equal(h.t, wtdb.TowerServerStats{
+		NumSessions: 2,
+		NumUpdates:  3,
+		NumClients:  2,
+	}, stats)
+
+	// Deleting the first session should remove it and its updates from
+	// the stats.
+	h.deleteSession(*id(0), nil)
+
+	stats, err = h.db.TowerServerStats()
+	require.NoError(h.t, err)
+	require.Equal(h.t, wtdb.TowerServerStats{
+		NumSessions: 1,
+		NumUpdates:  1,
+		NumClients:  1,
+	}, stats)
+}
+
+// testPruneServerUpdates asserts that PruneServerUpdates only removes the
+// state updates received before the given time, leaving the sessions and any
+// newer updates intact.
+func testPruneServerUpdates(h *towerDBHarness) {
+	const numSessions = 2
+
+	// Pruning an empty database should be a no-op.
+	numPruned, err := h.db.PruneServerUpdates(h.clock.Now())
+	require.NoError(h.t, err)
+	require.Zero(h.t, numPruned)
+
+	for i := 0; i < numSessions; i++ {
+		session := &wtdb.SessionInfo{
+			ID: *id(i),
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blob.TypeAltruistCommit,
+					SweepFeeRate: wtpolicy.DefaultSweepFeeRate,
+				},
+				MaxUpdates: 3,
+			},
+			RewardAddress: []byte{},
+		}
+		h.insertSession(session, nil)
+	}
+
+	// Store one update for each session at the start time. Both updates
+	// share the same hint.
+	start := h.clock.Now()
+	oldHint := blob.BreachHint{0x01}
+	for i := 0; i < numSessions; i++ {
+		h.insertUpdate(&wtdb.SessionStateUpdate{
+			ID:            *id(i),
+			SeqNum:        1,
+			Hint:          oldHint,
+			EncryptedBlob: testBlob,
+		}, nil)
+	}
+
+	// An hour later, store a second update for the first session.
+	h.clock.SetTime(start.Add(time.Hour))
+	newHint := blob.BreachHint{0x02}
+	h.insertUpdate(&wtdb.SessionStateUpdate{
+		ID:            *id(0),
+		SeqNum:        2,
+		Hint:          newHint,
+		EncryptedBlob: testBlob,
+	}, nil)
+
+	// Pruning at the start time shouldn't remove anything, since no
+	// update was received strictly before it.
+	numPruned, err = h.db.PruneServerUpdates(start)
+	require.NoError(h.t, err)
+	require.Zero(h.t, numPruned)
+	require.Len(h.t, h.queryMatches(oldHint), numSessions)
+
+	// Pruning half an hour after the start should only remove the two
+	// older updates.
+	numPruned, err = h.db.PruneServerUpdates(start.Add(time.Hour / 2))
+	require.NoError(h.t, err)
+	require.Equal(h.t, numSessions, numPruned)
+
+	require.Empty(h.t, h.queryMatches(oldHint))
+	match := h.hasUpdate(newHint)
+	require.Equal(h.t, *id(0), match.ID)
+	require.EqualValues(h.t, 2, match.SeqNum)
+
+	// The sessions themselves must be untouched, including their last
+	// applied values.
+	session0 := h.getSession(id(0), nil)
+	require.EqualValues(h.t, 2, session0.LastApplied)
+	session1 := h.getSession(id(1), nil)
+	require.EqualValues(h.t, 1, session1.LastApplied)
+
+	// The pruned session can still accept new updates.
+	h.insertUpdate(&wtdb.SessionStateUpdate{
+		ID:            *id(1),
+		SeqNum:        2,
+		LastApplied:   1,
+		Hint:          oldHint,
+		EncryptedBlob: testBlob,
+	}, nil)
+
+	// Pruning again at the same time should be a no-op.
+	numPruned, err = h.db.PruneServerUpdates(start.Add(time.Hour / 2))
+	require.NoError(h.t, err)
+	require.Zero(h.t, numPruned)
+
+	// Deleting the first session should still succeed after some of its
+	// updates were pruned.
+	h.deleteSession(*id(0), nil)
+	require.Empty(h.t, h.queryMatches(newHint))
+}
+
+// testDedupBlobs asserts that identical encrypted blobs stored under different
+// sessions share a single copy, which is only removed once no update
+// references it anymore.
+func testDedupBlobs(h *towerDBHarness) {
+	// A fresh database shouldn't hold any blobs.
+	stats, err := h.db.DedupStats()
+	require.NoError(h.t, err)
+	require.Equal(h.t, wtdb.DedupStats{}, stats)
+
+	for i := 0; i < 2; i++ {
+		session := &wtdb.SessionInfo{
+			ID: *id(i),
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blob.TypeAltruistCommit,
+					SweepFeeRate: wtpolicy.DefaultSweepFeeRate,
+				},
+				MaxUpdates: 3,
+			},
+			RewardAddress: []byte{},
+		}
+		h.insertSession(session, nil)
+	}
+
+	// Store the same blob under both sessions, using different hints.
+	dupBlob := make([]byte, len(testBlob))
+	dupBlob[0] = 0x01
+
+	hint0 := blob.BreachHint{0x01}
+	hint1 := blob.BreachHint{0x02}
+	h.insertUpdate(&wtdb.SessionStateUpdate{
+		ID:            *id(0),
+		SeqNum:        1,
+		Hint:          hint0,
+		EncryptedBlob: dupBlob,
+	}, nil)
+	h.insertUpdate(&wtdb.SessionStateUpdate{
+		ID:            *id(1),
+		SeqNum:        1,
+		Hint:          hint1,
+		EncryptedBlob: dupBlob,
+	}, nil)
+
+	// A single copy of the blob should be stored, referenced by both
+	// updates.
+	stats, err = h.db.DedupStats()
+	require.NoError(h.t, err)
+	require.Equal(h.t, wtdb.DedupStats{
+		NumBlobs:    1,
+		NumBlobRefs: 2,
+		BytesSaved:  uint64(len(dupBlob)),
+	}, stats)
+
+	require.Equal(h.t, dupBlob, h.hasUpdate(hint0).EncryptedBlob)
+	require.Equal(h.t, dupBlob, h.hasUpdate(hint1).EncryptedBlob)
+
+	// A distinct blob should be stored separately.
+	h.insertUpdate(&wtdb.SessionStateUpdate{
+		ID:            *id(0),
+		SeqNum:        2,
+		LastApplied:   1,
+		Hint:          blob.BreachHint{0x03},
+		EncryptedBlob: testBlob,
+	}, nil)
+
+	stats, err = h.db.DedupStats()
+	require.NoError(h.t, err)
+	require.Equal(h.t, wtdb.DedupStats{
+		NumBlobs:    2,
+		NumBlobRefs: 3,
+		BytesSaved:  uint64(len(dupBlob)),
+	}, stats)
+
+	// Deleting the first session should release its references, leaving
+	// the shared blob in place for the second session.
+	h.deleteSession(*id(0), nil)
+
+	stats, err = h.db.DedupStats()
+	require.NoError(h.t, err)
+	require.Equal(h.t, wtdb.DedupStats{
+		NumBlobs:    1,
+		NumBlobRefs: 1,
+	}, stats)
+	require.Equal(h.t, dupBlob, h.hasUpdate(hint1).EncryptedBlob)
+
+	// Once the last update referencing the blob is pruned, the blob should
+	// be removed as well.
+	h.clock.SetTime(h.clock.Now().Add(time.Second))
+	numPruned, err := h.db.PruneServerUpdates(h.clock.Now())
+	require.NoError(h.t, err)
+	require.Equal(h.t, 1, numPruned)
+
+	stats, err = h.db.DedupStats()
+	require.NoError(h.t, err)
+	require.Equal(h.t, wtdb.DedupStats{}, stats)
+}
+
+// testGetSessionsByClient asserts that the sessions returned for a client only
+// include those negotiated using the client's public key.
+func testGetSessionsByClient(h *towerDBHarness) {
+	pk1, err := randPubKey()
+	require.NoError(h.t, err)
+	pk2, err := randPubKey()
+	require.NoError(h.t, err)
+
+	// A client without sessions should get an empty result.
+	sessions, err := h.db.GetSessionsByClient(pk1)
+	require.NoError(h.t, err)
+	require.Empty(h.t, sessions)
+
+	newSession := func(pk *btcec.PublicKey) *wtdb.SessionInfo {
+		session := &wtdb.SessionInfo{
+			ID: wtdb.NewSessionIDFromPubKey(pk),
+			Policy: wtpolicy.Policy{
+				TxPolicy: wtpolicy.TxPolicy{
+					BlobType:     blob.TypeAltruistCommit,
+					SweepFeeRate: wtpolicy.DefaultSweepFeeRate,
+				},
+				MaxUpdates: 3,
+			},
+			RewardAddress: []byte{},
+		}
+		h.insertSession(session, nil)
+
+		return session
+	}
+
+	session1 := newSession(pk1)
+	session2 := newSession(pk2)
+
+	// Each client should only get its own session.
+	sessions, err = h.db.GetSessionsByClient(pk1)
+	require.NoError(h.t, err)
+	require.Equal(h.t, []*wtdb.SessionInfo{session1}, sessions)
+
+	sessions, err = h.db.GetSessionsByClient(pk2)
+	require.NoError(h.t, err)
+	require.Equal(h.t, []*wtdb.SessionInfo{session2}, sessions)
+
+	// Once its session is deleted, the first client should be left
+	// without sessions, while the second one keeps its own.
+	h.deleteSession(session1.ID, nil)
+
+	sessions, err = h.db.GetSessionsByClient(pk1)
+	require.NoError(h.t, err)
+	require.Empty(h.t, sessions)
+
+	sessions, err = h.db.GetSessionsByClient(pk2)
+	require.NoError(h.t, err)
+	require.Equal(h.t, []*wtdb.SessionInfo{session2}, sessions)
+}
+
 // testDeleteSession asserts the behavior of a tower database when deleting
 // session data. The test asserts that the only proper the target session is
 // remmoved, and that only updates for a particular session are pruned.
@@ -583,7 +972,7 @@ func TestTowerDB(t *testing.T) {
 	}{
 		{
 			name: "fresh boltdb",
-			init: func(t *testing.T) watchtower.DB {
+			init: func(t *testing.T, c clock.Clock) watchtower.DB {
 				path := t.TempDir()
 
 				bdb, err := wtdb.NewBoltBackendCreator(
@@ -591,7 +980,9 @@ func TestTowerDB(t *testing.T) {
 				)(dbCfg)
 				require.NoError(t, err)
 
-				db, err := wtdb.OpenTowerDB(bdb)
+				db, err := wtdb.OpenTowerDB(
+					bdb, wtdb.WithTowerClock(c),
+				)
 				require.NoError(t, err)
 
 				t.Cleanup(func() {
@@ -603,7 +994,7 @@ func TestTowerDB(t *testing.T) {
 		},
 		{
 			name: "reopened boltdb",
-			init: func(t *testing.T) watchtower.DB {
+			init: func(t *testing.T, c clock.Clock) watchtower.DB {
 				path := t.TempDir()
 
 				bdb, err := wtdb.NewBoltBackendCreator(
@@ -611,7 +1002,9 @@ func TestTowerDB(t *testing.T) {
 				)(dbCfg)
 				require.NoError(t, err)
 
-				db, err := wtdb.OpenTowerDB(bdb)
+				db, err := wtdb.OpenTowerDB(
+					bdb, wtdb.WithTowerClock(c),
+				)
 				require.NoError(t, err)
 				db.Close()
 
@@ -623,7 +1016,9 @@ func TestTowerDB(t *testing.T) {
 				)(dbCfg)
 				require.NoError(t, err)
 
-				db, err = wtdb.OpenTowerDB(bdb)
+				db, err = wtdb.OpenTowerDB(
+					bdb, wtdb.WithTowerClock(c),
+				)
 				require.NoError(t, err)
 
 				t.Cleanup(func() {
@@ -635,8 +1030,8 @@ func TestTowerDB(t *testing.T) {
 		},
 		{
 			name: "mock",
-			init: func(t *testing.T) watchtower.DB {
-				return wtmock.NewTowerDB()
+			init: func(t *testing.T, c clock.Clock) watchtower.DB {
+				return wtmock.NewTowerDB(wtdb.WithTowerClock(c))
 			},
 		},
 	}
@@ -697,10 +1092,30 @@ func TestTowerDB(t *testing.T) {
 			name: "multiple breach matches",
 			run:  testMultipleMatches,
 		},
+		{
+			name: "breach hint collision",
+			run:  testBreachHintCollision,
+		},
 		{
 			name: "lookout tip",
 			run:  testLookoutTip,
 		},
+		{
+			name: "tower server stats",
+			run:  testTowerServerStats,
+		},
+		{
+			name: "prune server updates",
+			run:  testPruneServerUpdates,
+		},
+		{
+			name: "dedup blobs",
+			run:  testDedupBlobs,
+		},
+		{
+			name: "sessions by client",
+			run:  testGetSessionsByClient,
+		},
 	}
 
 	for _, database := range dbs {
@@ -1,11 +1,25 @@
 package wtdb
 
 import (
-	"github.com/lightningnetwork/lnd/channeldb"
+	"errors"
+	"fmt"
+
 	"github.com/lightningnetwork/lnd/kvdb"
 	"github.com/lightningnetwork/lnd/watchtower/wtdb/migration1"
+	"github.com/lightningnetwork/lnd/watchtower/wtdb/migration2"
+	"github.com/lightningnetwork/lnd/watchtower/wtdb/migration3"
+	"github.com/lightningnetwork/lnd/watchtower/wtdb/migration4"
+	"github.com/lightningnetwork/lnd/watchtower/wtdb/migration5"
+	"github.com/lightningnetwork/lnd/watchtower/wtdb/migration6"
 )
 
+// ErrDBVersionTooNew signals that the database was written by a newer release
+// that bumped the database version beyond the latest version known to this
+// release. The database is refused rather than risking corruption by
+// operating on data in a format this release doesn't understand.
+var ErrDBVersionTooNew = errors.New("database version is newer than the " +
+	"latest known version")
+
 // migration is a function which takes a prior outdated version of the database
 // instances and mutates the key/bucket structure to arrive at a more
 // up-to-date version of the database.
@@ -20,7 +34,18 @@ type version struct {
 // towerDBVersions stores all versions and migrations of the tower database.
 // This list will be used when opening the database to determine if any
 // migrations must be applied.
-var towerDBVersions = []version{}
+var towerDBVersions = []version{
+	{
+		// State updates now reference their encrypted blob in the
+		// blobs bucket, and are keyed by their session id and sequence
+		// number within their hint's bucket, such that updates sharing
+		// a hint no longer overwrite each other. Updates holding their
+		// blob inline, or keyed by their session id alone, remain
+		// readable, so no migration is needed, but the version is
+		// bumped to prevent older releases from reading new updates.
+		migration: nil,
+	},
+}
 
 // clientDBVersions stores all versions and migrations of the client database.
 // This list will be used when opening the database to determine if any
@@ -29,6 +54,21 @@ var clientDBVersions = []version{
 	{
 		migration: migration1.MigrateTowerToSessionIndex,
 	},
+	{
+		migration: migration2.MigrateTowerSessionCount,
+	},
+	{
+		migration: migration3.MigrateSessionKeyIndexKeys,
+	},
+	{
+		migration: migration4.MigrateChannelToSessionIndex,
+	},
+	{
+		migration: migration5.MigrateLastAckedSeqNum,
+	},
+	{
+		migration: migration6.MigrateCommittedUpdateBlobType,
+	},
 }
 
 // getLatestDBVersion returns the last known database version.
@@ -136,7 +176,9 @@ func syncVersions(db versionedDB, versions []version) error {
 	// Current version is higher than any known version, fail to prevent
 	// reversion.
 	case curVersion > latestVersion:
-		return channeldb.ErrDBReversion
+		return fmt.Errorf("%w: database version %d, latest known "+
+			"version %d", ErrDBVersionTooNew, curVersion,
+			latestVersion)
 
 	// Current version matches highest known version, nothing to do.
 	case curVersion == latestVersion:
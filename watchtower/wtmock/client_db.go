@@ -1,14 +1,20 @@
 package wtmock
 
 import (
+	"bytes"
+	"io"
 	"net"
+	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightningnetwork/lnd/clock"
 	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/watchtower/blob"
 	"github.com/lightningnetwork/lnd/watchtower/wtdb"
+	"github.com/lightningnetwork/lnd/watchtower/wtpolicy"
 )
 
 type towerPK [33]byte
@@ -28,26 +34,165 @@ type ClientDB struct {
 	activeSessions   map[wtdb.SessionID]wtdb.ClientSession
 	ackedUpdates     map[wtdb.SessionID]map[uint16]wtdb.BackupID
 	committedUpdates map[wtdb.SessionID][]wtdb.CommittedUpdate
+	storageBytes     map[wtdb.SessionID]uint64
 	towerIndex       map[towerPK]wtdb.TowerID
 	towers           map[wtdb.TowerID]*wtdb.Tower
+	towerPolicies    map[wtdb.TowerID]wtpolicy.Policy
+	towerAddrUsed    map[wtdb.TowerID]map[string]time.Time
+	towerStats       map[wtdb.TowerID]wtdb.TowerStats
+	towerBackoffs    map[wtdb.TowerID]wtdb.TowerBackoff
+	tombstones       map[lnwire.ChannelID]wtdb.ClientChanSummary
 
 	nextIndex     uint32
-	indexes       map[keyIndexKey]uint32
+	indexes       map[keyIndexKey][]uint32
 	legacyIndexes map[wtdb.TowerID]uint32
+
+	// releasedIndexes holds the session key indexes released by each
+	// tower and blob type, in ascending order, which are handed out again
+	// to the same tower and blob type before new ones.
+	releasedIndexes map[keyIndexKey][]uint32
+
+	// failPoints holds the errors that calls to the named methods should
+	// return. It is guarded by its own mutex so that it can be consulted
+	// before the database's lock is acquired.
+	failMu     sync.Mutex
+	failPoints map[string]failPoint
+
+	// sessionEvents delivers session lifecycle events to subscribers.
+	sessionEvents *wtdb.SessionEventDispatcher
+
+	// maxPendingUpdates is the maximum number of unacked committed updates
+	// a session may hold. Zero means that the number isn't capped.
+	maxPendingUpdates uint16
+
+	// clock is used to timestamp the towers, sessions and updates written
+	// to the database.
+	clock clock.Clock
+}
+
+// failPoint describes an error to be returned by calls to a mocked method.
+type failPoint struct {
+	// err is the error to return.
+	err error
+
+	// once, if true, causes the fail point to be cleared once triggered.
+	once bool
 }
 
-// NewClientDB initializes a new mock ClientDB.
-func NewClientDB() *ClientDB {
+// NewClientDB initializes a new mock ClientDB. Of the given options, only those
+// that affect the behavior of the database rather than its storage are
+// honored.
+func NewClientDB(opts ...wtdb.ClientDBOption) *ClientDB {
+	cfg := wtdb.NewClientDBCfg()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	return &ClientDB{
-		summaries:        make(map[lnwire.ChannelID]wtdb.ClientChanSummary),
-		activeSessions:   make(map[wtdb.SessionID]wtdb.ClientSession),
-		ackedUpdates:     make(map[wtdb.SessionID]map[uint16]wtdb.BackupID),
-		committedUpdates: make(map[wtdb.SessionID][]wtdb.CommittedUpdate),
-		towerIndex:       make(map[towerPK]wtdb.TowerID),
-		towers:           make(map[wtdb.TowerID]*wtdb.Tower),
-		indexes:          make(map[keyIndexKey]uint32),
-		legacyIndexes:    make(map[wtdb.TowerID]uint32),
+		summaries:         make(map[lnwire.ChannelID]wtdb.ClientChanSummary),
+		activeSessions:    make(map[wtdb.SessionID]wtdb.ClientSession),
+		ackedUpdates:      make(map[wtdb.SessionID]map[uint16]wtdb.BackupID),
+		committedUpdates:  make(map[wtdb.SessionID][]wtdb.CommittedUpdate),
+		storageBytes:      make(map[wtdb.SessionID]uint64),
+		towerIndex:        make(map[towerPK]wtdb.TowerID),
+		towers:            make(map[wtdb.TowerID]*wtdb.Tower),
+		towerPolicies:     make(map[wtdb.TowerID]wtpolicy.Policy),
+		towerAddrUsed:     make(map[wtdb.TowerID]map[string]time.Time),
+		towerStats:        make(map[wtdb.TowerID]wtdb.TowerStats),
+		towerBackoffs:     make(map[wtdb.TowerID]wtdb.TowerBackoff),
+		tombstones:        make(map[lnwire.ChannelID]wtdb.ClientChanSummary),
+		indexes:           make(map[keyIndexKey][]uint32),
+		legacyIndexes:     make(map[wtdb.TowerID]uint32),
+		releasedIndexes:   make(map[keyIndexKey][]uint32),
+		failPoints:        make(map[string]failPoint),
+		sessionEvents:     wtdb.NewSessionEventDispatcher(),
+		maxPendingUpdates: cfg.MaxPendingUpdates,
+		clock:             cfg.Clock,
+	}
+}
+
+// SetFailPoint causes every subsequent call to the ClientDB method with the
+// given name, e.g. "CommitUpdate", to fail with err without touching the
+// database's state. Passing a nil error clears the fail point. Any method that
+// is implemented in terms of the named one will fail as well.
+//
+// NOTE: This is a test-only hook to exercise the client's error handling.
+func (m *ClientDB) SetFailPoint(method string, err error) {
+	m.setFailPoint(method, err, false)
+}
+
+// SetFailPointOnce behaves like SetFailPoint, except that only the next call to
+// the named method fails, after which the fail point is cleared.
+//
+// NOTE: This is a test-only hook to exercise the client's error handling.
+func (m *ClientDB) SetFailPointOnce(method string, err error) {
+	m.setFailPoint(method, err, true)
+}
+
+// setFailPoint sets or, if err is nil, clears the fail point of a method.
+func (m *ClientDB) setFailPoint(method string, err error, once bool) {
+	m.failMu.Lock()
+	defer m.failMu.Unlock()
+
+	if err == nil {
+		delete(m.failPoints, method)
+		return
+	}
+
+	m.failPoints[method] = failPoint{
+		err:  err,
+		once: once,
+	}
+}
+
+// checkFailPoint returns the error that a call to the named method should fail
+// with, if any fail point has been set for it.
+func (m *ClientDB) checkFailPoint(method string) error {
+	m.failMu.Lock()
+	defer m.failMu.Unlock()
+
+	fp, ok := m.failPoints[method]
+	if !ok {
+		return nil
+	}
+
+	if fp.once {
+		delete(m.failPoints, method)
+	}
+
+	return fp.err
+}
+
+// SubscribeSessionEvents returns a buffered channel over which session
+// lifecycle events are delivered, along with a function that cancels the
+// subscription.
+func (m *ClientDB) SubscribeSessionEvents() (<-chan wtdb.SessionEvent,
+	func()) {
+
+	return m.sessionEvents.Subscribe()
+}
+
+// NumDroppedSessionEvents returns the number of session events that were
+// dropped because a subscriber's buffer was full.
+func (m *ClientDB) NumDroppedSessionEvents() uint64 {
+	return m.sessionEvents.NumDropped()
+}
+
+// addStatusEvent appends an event for a change in the status of the session
+// with the given ID to events, unless the status didn't change.
+func addStatusEvent(events *[]wtdb.SessionEvent, id wtdb.SessionID,
+	oldStatus, newStatus wtdb.CSessionStatus) {
+
+	if oldStatus == newStatus {
+		return
 	}
+
+	*events = append(*events, wtdb.SessionEvent{
+		Type:      wtdb.SessionStatusChanged,
+		SessionID: id,
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+	})
 }
 
 // CreateTower initialize an address record used to communicate with a
@@ -55,10 +200,79 @@ func NewClientDB() *ClientDB {
 // storage costs of the public key when used by multiple sessions. If the tower
 // already exists, the address is appended to the list of all addresses used to
 // that tower previously and its corresponding sessions are marked as active.
-func (m *ClientDB) CreateTower(lnAddr *lnwire.NetAddress) (*wtdb.Tower, error) {
+// The CreateTowerOptions can be used to set optional fields on the tower, such
+// as its nickname. An ErrInvalidTowerAddr is returned if the address is
+// malformed.
+func (m *ClientDB) CreateTower(lnAddr *lnwire.NetAddress,
+	opts ...wtdb.CreateTowerOption) (*wtdb.Tower, error) {
+
+	if err := m.checkFailPoint("CreateTower"); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := wtdb.ValidateTowerAddr(lnAddr.Address); err != nil {
+		return nil, err
+	}
+
+	cfg := wtdb.NewCreateTowerCfg()
+	for _, o := range opts {
+		o(cfg)
+	}
+
+	var events []wtdb.SessionEvent
+	tower, err := m.createTower(lnAddr, cfg, &events)
+	if err != nil {
+		return nil, err
+	}
+
+	m.sessionEvents.Notify(events...)
+
+	return tower, nil
+}
+
+// CreateTowerAndReserveKey creates the tower for the given address, exactly as
+// CreateTower does, and reserves a session key index for the tower and blob
+// type atomically. The returned index is the one that NextSessionKeyIndex
+// returns until a session is created with it.
+func (m *ClientDB) CreateTowerAndReserveKey(lnAddr *lnwire.NetAddress,
+	blobType blob.Type) (*wtdb.Tower, uint32, error) {
+
+	if err := m.checkFailPoint("CreateTowerAndReserveKey"); err != nil {
+		return nil, 0, err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if err := wtdb.ValidateTowerAddr(lnAddr.Address); err != nil {
+		return nil, 0, err
+	}
+
+	var events []wtdb.SessionEvent
+	tower, err := m.createTower(lnAddr, wtdb.NewCreateTowerCfg(), &events)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	indexes := m.reserveSessionKeyIndices(tower.ID, blobType, 1)
+
+	m.sessionEvents.Notify(events...)
+
+	return tower, indexes[0], nil
+}
+
+// createTower creates the tower for the given address, or adds the address to
+// the existing tower with the same identity key, marking its sessions as
+// active. The resulting session status changes are appended to events.
+//
+// NOTE: This method requires the database's lock to be acquired.
+func (m *ClientDB) createTower(lnAddr *lnwire.NetAddress,
+	cfg *wtdb.CreateTowerCfg, events *[]wtdb.SessionEvent) (*wtdb.Tower,
+	error) {
+
 	var towerPubKey towerPK
 	copy(towerPubKey[:], lnAddr.IdentityKey.SerializeCompressed())
 
@@ -66,6 +280,7 @@ func (m *ClientDB) CreateTower(lnAddr *lnwire.NetAddress) (*wtdb.Tower, error) {
 	towerID, ok := m.towerIndex[towerPubKey]
 	if ok {
 		tower = m.towers[towerID]
+		numAddrs, numEvents := len(tower.Addresses), len(*events)
 		tower.AddAddress(lnAddr.Address)
 
 		towerSessions, err := m.listClientSessions(&towerID)
@@ -73,16 +288,36 @@ func (m *ClientDB) CreateTower(lnAddr *lnwire.NetAddress) (*wtdb.Tower, error) {
 			return nil, err
 		}
 		for id, session := range towerSessions {
+			if session.Status == wtdb.CSessionExhausted {
+				continue
+			}
+
+			addStatusEvent(
+				events, id, session.Status, wtdb.CSessionActive,
+			)
 			session.Status = wtdb.CSessionActive
 			m.activeSessions[id] = *session
 		}
+
+		addrAdded := len(tower.Addresses) != numAddrs
+		reactivated := len(*events) != numEvents
+		if addrAdded || reactivated {
+			tower.LastModified = m.now()
+		}
 	} else {
 		towerID = wtdb.TowerID(atomic.AddUint64(&m.nextTowerID, 1))
 		tower = &wtdb.Tower{
 			ID:          towerID,
 			IdentityKey: lnAddr.IdentityKey,
 			Addresses:   []net.Addr{lnAddr.Address},
+			Features:    wtdb.NewTowerFeatures(nil),
+			CreatedAt:   m.now(),
 		}
+		tower.LastModified = tower.CreatedAt
+	}
+
+	if cfg.Nickname != "" {
+		tower.Nickname = cfg.Nickname
 	}
 
 	m.towerIndex[towerPubKey] = towerID
@@ -91,15 +326,49 @@ func (m *ClientDB) CreateTower(lnAddr *lnwire.NetAddress) (*wtdb.Tower, error) {
 	return copyTower(tower), nil
 }
 
+// SetTowerNickname sets the human-readable nickname of the tower with the given
+// ID. An empty nickname clears any existing one. ErrTowerNotFound is returned
+// if the tower does not exist.
+func (m *ClientDB) SetTowerNickname(id wtdb.TowerID, nickname string) error {
+	if err := m.checkFailPoint("SetTowerNickname"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tower, ok := m.towers[id]
+	if !ok {
+		return wtdb.ErrTowerNotFound
+	}
+
+	tower.Nickname = nickname
+
+	return nil
+}
+
 // RemoveTower modifies a tower's record within the database. If an address is
 // provided, then _only_ the address record should be removed from the tower's
 // persisted state. Otherwise, we'll attempt to mark the tower as inactive by
 // marking all of its sessions inactive. If any of its sessions has unacked
 // updates, then ErrTowerUnackedUpdates is returned. If the tower doesn't have
-// any sessions at all, it'll be completely removed from the database.
+// any sessions at all, it'll be completely removed from the database. The
+// RemoveTowerOptions can be used to report the channels left without a backup
+// by the removal.
 //
 // NOTE: An error is not returned if the tower doesn't exist.
-func (m *ClientDB) RemoveTower(pubKey *btcec.PublicKey, addr net.Addr) error {
+func (m *ClientDB) RemoveTower(pubKey *btcec.PublicKey, addr net.Addr,
+	opts ...wtdb.RemoveTowerOption) error {
+
+	if err := m.checkFailPoint("RemoveTower"); err != nil {
+		return err
+	}
+
+	cfg := wtdb.NewRemoveTowerCfg()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -116,7 +385,9 @@ func (m *ClientDB) RemoveTower(pubKey *btcec.PublicKey, addr net.Addr) error {
 		if len(tower.Addresses) == 0 {
 			return wtdb.ErrLastTowerAddr
 		}
+		tower.LastModified = m.now()
 		m.towers[tower.ID] = tower
+		delete(m.towerAddrUsed[tower.ID], addr.String())
 		return nil
 	}
 
@@ -125,346 +396,2494 @@ func (m *ClientDB) RemoveTower(pubKey *btcec.PublicKey, addr net.Addr) error {
 		return err
 	}
 	if len(towerSessions) == 0 {
-		var towerPK towerPK
-		copy(towerPK[:], pubKey.SerializeCompressed())
-		delete(m.towerIndex, towerPK)
-		delete(m.towers, tower.ID)
+		m.deleteTower(tower)
+
+		if cfg.ReportOrphanedChannels != nil {
+			cfg.ReportOrphanedChannels(nil)
+		}
+
 		return nil
 	}
 
+	var events []wtdb.SessionEvent
 	for id, session := range towerSessions {
 		if len(m.committedUpdates[session.ID]) > 0 {
 			return wtdb.ErrTowerUnackedUpdates
 		}
+		if session.Status == wtdb.CSessionExhausted {
+			continue
+		}
+		addStatusEvent(
+			&events, id, session.Status, wtdb.CSessionInactive,
+		)
 		session.Status = wtdb.CSessionInactive
 		m.activeSessions[id] = *session
 	}
 
-	return nil
-}
+	if len(events) > 0 {
+		tower.LastModified = m.now()
+		m.towers[tower.ID] = tower
+	}
 
-// LoadTower retrieves a tower by its public key.
-func (m *ClientDB) LoadTower(pubKey *btcec.PublicKey) (*wtdb.Tower, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	return m.loadTower(pubKey)
+	m.sessionEvents.Notify(events...)
+
+	if cfg.ReportOrphanedChannels != nil {
+		orphaned := m.listOrphanedChannels(towerSessions)
+		cfg.ReportOrphanedChannels(orphaned)
+	}
+
+	return nil
 }
 
-// loadTower retrieves a tower by its public key.
+// listOrphanedChannels returns the registered channels whose updates are held
+// by the given sessions of a removed tower, but by no session of another tower
+// that is still in use, in ascending order.
 //
-// NOTE: This method requires the database's lock to be acquired.
-func (m *ClientDB) loadTower(pubKey *btcec.PublicKey) (*wtdb.Tower, error) {
-	var towerPK towerPK
-	copy(towerPK[:], pubKey.SerializeCompressed())
+// NOTE: This method must be called with the mutex held.
+func (m *ClientDB) listOrphanedChannels(
+	sessions map[wtdb.SessionID]*wtdb.ClientSession) []lnwire.ChannelID {
+
+	// Collect the channels held by each session, noting whether they are
+	// held by the removed tower or by a session still in use.
+	backedByTower := make(map[lnwire.ChannelID]struct{})
+	backedElsewhere := make(map[lnwire.ChannelID]struct{})
+	for id, session := range m.activeSessions {
+		backed := backedElsewhere
+		if _, ok := sessions[id]; ok {
+			backed = backedByTower
+		} else if session.Status == wtdb.CSessionInactive {
+			continue
+		}
 
-	towerID, ok := m.towerIndex[towerPK]
-	if !ok {
-		return nil, wtdb.ErrTowerNotFound
+		for _, update := range m.committedUpdates[id] {
+			backed[update.BackupID.ChanID] = struct{}{}
+		}
+		for _, backupID := range m.ackedUpdates[id] {
+			backed[backupID.ChanID] = struct{}{}
+		}
 	}
-	tower, ok := m.towers[towerID]
-	if !ok {
-		return nil, wtdb.ErrTowerNotFound
+
+	var orphaned []lnwire.ChannelID
+	for chanID := range backedByTower {
+		if _, ok := backedElsewhere[chanID]; ok {
+			continue
+		}
+		if _, ok := m.summaries[chanID]; !ok {
+			continue
+		}
+
+		orphaned = append(orphaned, chanID)
 	}
 
-	return copyTower(tower), nil
+	sort.Slice(orphaned, func(i, j int) bool {
+		return bytes.Compare(orphaned[i][:], orphaned[j][:]) < 0
+	})
+
+	return orphaned
 }
 
-// LoadTowerByID retrieves a tower by its tower ID.
-func (m *ClientDB) LoadTowerByID(towerID wtdb.TowerID) (*wtdb.Tower, error) {
+// RemoveTowerForce completely removes the tower with the given public key from
+// the database, along with all of its sessions and their committed and acked
+// updates. Unlike RemoveTower, the removal proceeds even if the tower has yet
+// to ack some of the updates, which means that the backups they hold are lost.
+// ErrTowerNotFound is returned if the tower does not exist.
+func (m *ClientDB) RemoveTowerForce(pubKey *btcec.PublicKey) error {
+	if err := m.checkFailPoint("RemoveTowerForce"); err != nil {
+		return err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if tower, ok := m.towers[towerID]; ok {
-		return copyTower(tower), nil
+	tower, err := m.loadTower(pubKey)
+	if err != nil {
+		return err
 	}
 
-	return nil, wtdb.ErrTowerNotFound
-}
-
-// ListTowers retrieves the list of towers available within the database.
-func (m *ClientDB) ListTowers() ([]*wtdb.Tower, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	for id, session := range m.activeSessions {
+		if session.TowerID != tower.ID {
+			continue
+		}
 
-	towers := make([]*wtdb.Tower, 0, len(m.towers))
-	for _, tower := range m.towers {
-		towers = append(towers, copyTower(tower))
+		delete(m.activeSessions, id)
+		delete(m.committedUpdates, id)
+		delete(m.ackedUpdates, id)
+		delete(m.storageBytes, id)
 	}
 
-	return towers, nil
-}
+	m.deleteTower(tower)
 
-// MarkBackupIneligible records that particular commit height is ineligible for
-// backup. This allows the client to track which updates it should not attempt
-// to retry after startup.
-func (m *ClientDB) MarkBackupIneligible(_ lnwire.ChannelID, _ uint64) error {
 	return nil
 }
 
-// ListClientSessions returns the set of all client sessions known to the db. An
-// optional tower ID can be used to filter out any client sessions in the
-// response that do not correspond to this tower.
-func (m *ClientDB) ListClientSessions(tower *wtdb.TowerID,
-	opts ...wtdb.ClientSessionListOption) (
-	map[wtdb.SessionID]*wtdb.ClientSession, error) {
+// deleteTower removes the given tower along with any state recorded for it.
+//
+// NOTE: This method requires the database's lock to be acquired.
+func (m *ClientDB) deleteTower(tower *wtdb.Tower) {
+	var towerPK towerPK
+	copy(towerPK[:], tower.IdentityKey.SerializeCompressed())
+	delete(m.towerIndex, towerPK)
+	delete(m.towers, tower.ID)
+	delete(m.towerPolicies, tower.ID)
+	delete(m.towerAddrUsed, tower.ID)
+	delete(m.towerStats, tower.ID)
+	delete(m.towerBackoffs, tower.ID)
+}
+
+// RemoveTowerCheck reports which of the tower's sessions have unacked updates,
+// and would therefore cause RemoveTower to fail with ErrTowerUnackedUpdates,
+// without modifying the database. ErrTowerNotFound is returned if the tower
+// does not exist.
+func (m *ClientDB) RemoveTowerCheck(pubKey *btcec.PublicKey) (
+	*wtdb.TowerRemovalReport, error) {
+
+	if err := m.checkFailPoint("RemoveTowerCheck"); err != nil {
+		return nil, err
+	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return m.listClientSessions(tower, opts...)
-}
 
-// listClientSessions returns the set of all client sessions known to the db. An
-// optional tower ID can be used to filter out any client sessions in the
-// response that do not correspond to this tower.
-func (m *ClientDB) listClientSessions(tower *wtdb.TowerID,
-	opts ...wtdb.ClientSessionListOption) (
-	map[wtdb.SessionID]*wtdb.ClientSession, error) {
-
-	cfg := wtdb.NewClientSessionCfg()
-	for _, o := range opts {
-		o(cfg)
+	tower, err := m.loadTower(pubKey)
+	if err != nil {
+		return nil, err
 	}
 
-	sessions := make(map[wtdb.SessionID]*wtdb.ClientSession)
-	for _, session := range m.activeSessions {
-		session := session
-		if tower != nil && *tower != session.TowerID {
+	report := &wtdb.TowerRemovalReport{
+		TowerID:          tower.ID,
+		BlockingSessions: make(map[wtdb.SessionID]uint16),
+	}
+	for id, session := range m.activeSessions {
+		if session.TowerID != tower.ID {
 			continue
 		}
-		session.Tower = m.towers[session.TowerID]
-		sessions[session.ID] = &session
 
-		if cfg.PerAckedUpdate != nil {
-			for seq, id := range m.ackedUpdates[session.ID] {
-				cfg.PerAckedUpdate(&session, seq, id)
-			}
+		numUnacked := len(m.committedUpdates[id])
+		if numUnacked == 0 {
+			continue
 		}
 
-		if cfg.PerCommittedUpdate != nil {
-			for _, update := range m.committedUpdates[session.ID] {
-				update := update
-				cfg.PerCommittedUpdate(&session, &update)
-			}
-		}
+		report.BlockingSessions[id] = uint16(numUnacked)
+		report.NumUnackedUpdates += uint64(numUnacked)
 	}
 
-	return sessions, nil
+	return report, nil
 }
 
-// FetchSessionCommittedUpdates retrieves the current set of un-acked updates
-// of the given session.
-func (m *ClientDB) FetchSessionCommittedUpdates(id *wtdb.SessionID) (
-	[]wtdb.CommittedUpdate, error) {
+// MarkTowerInactive marks all of the tower's sessions as inactive so that no
+// further updates are sent to it, without removing the tower or any of its
+// addresses. ErrTowerNotFound is returned if the tower doesn't exist.
+func (m *ClientDB) MarkTowerInactive(pubKey *btcec.PublicKey) error {
+	if err := m.checkFailPoint("MarkTowerInactive"); err != nil {
+		return err
+	}
 
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	return m.setTowerSessionsStatus(pubKey, wtdb.CSessionInactive)
+}
 
-	updates, ok := m.committedUpdates[*id]
-	if !ok {
-		return nil, wtdb.ErrClientSessionNotFound
+// MarkTowerActive marks all of the tower's sessions as active so that they can
+// be used for backups once again. ErrTowerNotFound is returned if the tower
+// doesn't exist.
+func (m *ClientDB) MarkTowerActive(pubKey *btcec.PublicKey) error {
+	if err := m.checkFailPoint("MarkTowerActive"); err != nil {
+		return err
 	}
 
-	return updates, nil
+	return m.setTowerSessionsStatus(pubKey, wtdb.CSessionActive)
 }
 
-// CreateClientSession records a newly negotiated client session in the set of
-// active sessions. The session can be identified by its SessionID.
-func (m *ClientDB) CreateClientSession(session *wtdb.ClientSession) error {
+// MarkTowerAddressUsed records that a connection to the tower with the given ID
+// was last successfully made over the given address at the current time.
+// LoadTower and LoadTowerByID return the tower's addresses ordered by their
+// most recent success. ErrTowerNotFound is returned if the tower does not
+// exist, and ErrTowerAddrNotFound if the address isn't one of the tower's.
+func (m *ClientDB) MarkTowerAddressUsed(id wtdb.TowerID, addr net.Addr) error {
+	if err := m.checkFailPoint("MarkTowerAddressUsed"); err != nil {
+		return err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Ensure that we aren't overwriting an existing session.
-	if _, ok := m.activeSessions[session.ID]; ok {
-		return wtdb.ErrClientSessionAlreadyExists
+	tower, ok := m.towers[id]
+	if !ok {
+		return wtdb.ErrTowerNotFound
 	}
 
-	key := keyIndexKey{
-		towerID:  session.TowerID,
-		blobType: session.Policy.BlobType,
+	addrStr := addr.String()
+	var known bool
+	for _, towerAddr := range tower.Addresses {
+		if towerAddr.String() == addrStr {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return wtdb.ErrTowerAddrNotFound
 	}
 
-	// Ensure that a session key index has been reserved for this tower.
-	keyIndex, err := m.getSessionKeyIndex(key)
-	if err != nil {
-		return err
+	if _, ok := m.towerAddrUsed[id]; !ok {
+		m.towerAddrUsed[id] = make(map[string]time.Time)
 	}
+	m.towerAddrUsed[id][addrStr] = m.now()
 
-	// Ensure that the session's index matches the reserved index.
-	if keyIndex != session.KeyIndex {
-		return wtdb.ErrIncorrectKeyIndex
+	return nil
+}
+
+// RecordTowerStat records the outcome of an attempt to deliver updates to the
+// tower with the given ID, incrementing its success or failure counter and
+// setting its last contact time to the current time. The stats are exposed via
+// the Stats field of towers returned by LoadTower and LoadTowerByID.
+// ErrTowerNotFound is returned if the tower does not exist.
+func (m *ClientDB) RecordTowerStat(id wtdb.TowerID, success bool) error {
+	if err := m.checkFailPoint("RecordTowerStat"); err != nil {
+		return err
 	}
 
-	// Remove the key index reservation for this tower. Once committed, this
-	// permits us to create another session with this tower.
-	delete(m.indexes, key)
-	if key.blobType == blob.TypeAltruistCommit {
-		delete(m.legacyIndexes, key.towerID)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.towers[id]; !ok {
+		return wtdb.ErrTowerNotFound
 	}
 
-	m.activeSessions[session.ID] = wtdb.ClientSession{
-		ID: session.ID,
-		ClientSessionBody: wtdb.ClientSessionBody{
-			SeqNum:           session.SeqNum,
-			TowerLastApplied: session.TowerLastApplied,
-			TowerID:          session.TowerID,
-			KeyIndex:         session.KeyIndex,
-			Policy:           session.Policy,
-			RewardPkScript:   cloneBytes(session.RewardPkScript),
-		},
+	stats := m.towerStats[id]
+	if success {
+		stats.NumSuccesses++
+	} else {
+		stats.NumFailures++
 	}
-	m.ackedUpdates[session.ID] = make(map[uint16]wtdb.BackupID)
-	m.committedUpdates[session.ID] = make([]wtdb.CommittedUpdate, 0)
+	stats.LastContact = m.now()
+	m.towerStats[id] = stats
 
 	return nil
 }
 
-// NextSessionKeyIndex reserves a new session key derivation index for a
-// particular tower id. The index is reserved for that tower until
-// CreateClientSession is invoked for that tower and index, at which point a new
-// index for that tower can be reserved. Multiple calls to this method before
-// CreateClientSession is invoked should return the same index.
-func (m *ClientDB) NextSessionKeyIndex(towerID wtdb.TowerID,
-	blobType blob.Type) (uint32, error) {
+// SetTowerBackoff records the backoff between the client's connection attempts
+// to the tower with the given ID. Zero values clear the backoff, meaning that
+// the tower can be connected to immediately. ErrTowerNotFound is returned if
+// the tower does not exist.
+func (m *ClientDB) SetTowerBackoff(id wtdb.TowerID, nextAttempt time.Time,
+	attempts uint32) error {
+
+	if err := m.checkFailPoint("SetTowerBackoff"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.towers[id]; !ok {
+		return wtdb.ErrTowerNotFound
+	}
+
+	// The time is stripped of its monotonic clock reading to mirror the
+	// precision of the backoff persisted by the bolt implementation.
+	backoff := wtdb.TowerBackoff{
+		Attempts: attempts,
+	}
+	if !nextAttempt.IsZero() {
+		backoff.NextAttempt = time.Unix(0, nextAttempt.UnixNano())
+	}
+
+	if backoff == (wtdb.TowerBackoff{}) {
+		delete(m.towerBackoffs, id)
+		return nil
+	}
+
+	m.towerBackoffs[id] = backoff
+
+	return nil
+}
+
+// SetTowerMaxSessions sets the maximum number of non-exhausted sessions the
+// client may hold with the tower with the given ID. A limit of zero means that
+// the number of sessions is unlimited. ErrTowerNotFound is returned if the
+// tower does not exist.
+func (m *ClientDB) SetTowerMaxSessions(id wtdb.TowerID,
+	maxSessions uint32) error {
+
+	if err := m.checkFailPoint("SetTowerMaxSessions"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tower, ok := m.towers[id]
+	if !ok {
+		return wtdb.ErrTowerNotFound
+	}
+
+	tower.MaxSessions = maxSessions
+
+	return nil
+}
+
+// SetTowerRateLimit records the number of updates per minute that the tower
+// with the given ID was observed to accept from the client. A limit of zero
+// means that the limit is unknown or that the tower is unlimited.
+// ErrTowerNotFound is returned if the tower does not exist.
+func (m *ClientDB) SetTowerRateLimit(id wtdb.TowerID,
+	updatesPerMin uint32) error {
+
+	if err := m.checkFailPoint("SetTowerRateLimit"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tower, ok := m.towers[id]
+	if !ok {
+		return wtdb.ErrTowerNotFound
+	}
+
+	tower.RateLimit = updatesPerMin
+
+	return nil
+}
+
+// SetTowerFeatures records the feature bits advertised by the tower with the
+// given ID, replacing any previously recorded ones. A nil feature vector clears
+// the tower's features.
+func (m *ClientDB) SetTowerFeatures(id wtdb.TowerID,
+	fv *lnwire.FeatureVector) error {
+
+	if err := m.checkFailPoint("SetTowerFeatures"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tower, ok := m.towers[id]
+	if !ok {
+		return wtdb.ErrTowerNotFound
+	}
+
+	var raw *lnwire.RawFeatureVector
+	if fv != nil {
+		raw = fv.RawFeatureVector.Clone()
+	}
+	tower.Features = wtdb.NewTowerFeatures(raw)
+
+	return nil
+}
+
+// SetTowerPolicy records the policy negotiated with the tower with the given
+// ID, replacing any existing one. Sessions subsequently created with the tower
+// must use this policy. ErrTowerNotFound is returned if the tower does not
+// exist.
+func (m *ClientDB) SetTowerPolicy(id wtdb.TowerID,
+	policy wtpolicy.Policy) error {
+
+	if err := m.checkFailPoint("SetTowerPolicy"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.towers[id]; !ok {
+		return wtdb.ErrTowerNotFound
+	}
+
+	m.towerPolicies[id] = policy
+
+	return nil
+}
+
+// GetTowerPolicy returns the policy negotiated with the tower with the given
+// ID. ErrTowerNotFound is returned if the tower does not exist, and
+// ErrTowerPolicyNotFound if no policy has been recorded for it.
+func (m *ClientDB) GetTowerPolicy(id wtdb.TowerID) (wtpolicy.Policy, error) {
+	if err := m.checkFailPoint("GetTowerPolicy"); err != nil {
+		return wtpolicy.Policy{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.towers[id]; !ok {
+		return wtpolicy.Policy{}, wtdb.ErrTowerNotFound
+	}
+
+	policy, ok := m.towerPolicies[id]
+	if !ok {
+		return wtpolicy.Policy{}, wtdb.ErrTowerPolicyNotFound
+	}
+
+	return policy, nil
+}
+
+// setTowerSessionsStatus sets the status of all sessions belonging to the tower
+// with the given public key.
+func (m *ClientDB) setTowerSessionsStatus(pubKey *btcec.PublicKey,
+	status wtdb.CSessionStatus) error {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tower, err := m.loadTower(pubKey)
+	if err != nil {
+		return err
+	}
+
+	towerSessions, err := m.listClientSessions(&tower.ID)
+	if err != nil {
+		return err
+	}
+
+	var events []wtdb.SessionEvent
+	for id, session := range towerSessions {
+		// Exhausted sessions must never transition to another status.
+		if session.Status == wtdb.CSessionExhausted {
+			continue
+		}
+
+		addStatusEvent(&events, id, session.Status, status)
+		session.Status = status
+		m.activeSessions[id] = *session
+	}
+
+	m.sessionEvents.Notify(events...)
+
+	return nil
+}
+
+// LoadTower retrieves a tower by its public key.
+func (m *ClientDB) LoadTower(pubKey *btcec.PublicKey) (*wtdb.Tower, error) {
+	if err := m.checkFailPoint("LoadTower"); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tower, err := m.loadTower(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	tower.SortAddresses(m.towerAddrUsed[tower.ID])
+	tower.Stats = m.towerStats[tower.ID]
+	tower.Backoff = m.towerBackoffs[tower.ID]
+
+	return tower, nil
+}
+
+// loadTower retrieves a tower by its public key.
+//
+// NOTE: This method requires the database's lock to be acquired.
+func (m *ClientDB) loadTower(pubKey *btcec.PublicKey) (*wtdb.Tower, error) {
+	var towerPK towerPK
+	copy(towerPK[:], pubKey.SerializeCompressed())
+
+	towerID, ok := m.towerIndex[towerPK]
+	if !ok {
+		return nil, wtdb.ErrTowerNotFound
+	}
+	tower, ok := m.towers[towerID]
+	if !ok {
+		return nil, wtdb.ErrTowerNotFound
+	}
+
+	return copyTower(tower), nil
+}
+
+// RemoveTowerAddressByIndex removes the address at the given index from the
+// tower with the given ID. The index refers to the order in which LoadTower and
+// LoadTowerByID return the tower's addresses, while the remaining addresses
+// keep their stored order. ErrTowerAddrIndexOutOfRange is returned if the index
+// doesn't refer to any of the tower's addresses, and ErrLastTowerAddr if the
+// address is the tower's only one.
+func (m *ClientDB) RemoveTowerAddressByIndex(id wtdb.TowerID, idx int) error {
+	if err := m.checkFailPoint("RemoveTowerAddressByIndex"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tower, ok := m.towers[id]
+	if !ok {
+		return wtdb.ErrTowerNotFound
+	}
+
+	switch {
+	case idx < 0 || idx >= len(tower.Addresses):
+		return wtdb.ErrTowerAddrIndexOutOfRange
+
+	case len(tower.Addresses) == 1:
+		return wtdb.ErrLastTowerAddr
+	}
+
+	sorted := copyTower(tower)
+	sorted.SortAddresses(m.towerAddrUsed[id])
+	addr := sorted.Addresses[idx]
+
+	tower = copyTower(tower)
+	tower.RemoveAddress(addr)
+	tower.LastModified = m.now()
+	m.towers[id] = tower
+	delete(m.towerAddrUsed[id], addr.String())
+
+	return nil
+}
+
+// LoadTowerByID retrieves a tower by its tower ID.
+func (m *ClientDB) LoadTowerByID(towerID wtdb.TowerID) (*wtdb.Tower, error) {
+	if err := m.checkFailPoint("LoadTowerByID"); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tower, ok := m.towers[towerID]
+	if !ok {
+		return nil, wtdb.ErrTowerNotFound
+	}
+
+	tower = copyTower(tower)
+	tower.SortAddresses(m.towerAddrUsed[towerID])
+	tower.Stats = m.towerStats[towerID]
+	tower.Backoff = m.towerBackoffs[towerID]
+
+	return tower, nil
+}
+
+// ListTowers retrieves the list of towers available within the database,
+// ordered by ascending TowerID. The TowerListOptions can be used to further
+// filter the set of towers returned.
+func (m *ClientDB) ListTowers(opts ...wtdb.TowerListOption) ([]*wtdb.Tower,
+	error) {
+
+	if err := m.checkFailPoint("ListTowers"); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cfg := wtdb.NewTowerListCfg()
+	for _, o := range opts {
+		o(cfg)
+	}
+
+	// Collect the set of towers that have at least one active session so
+	// that we can filter out inactive towers if requested.
+	activeTowers := make(map[wtdb.TowerID]struct{})
+	for _, session := range m.activeSessions {
+		if session.Status == wtdb.CSessionActive {
+			activeTowers[session.TowerID] = struct{}{}
+		}
+	}
+
+	towers := make([]*wtdb.Tower, 0, len(m.towers))
+	for id, tower := range m.towers {
+		if _, ok := activeTowers[id]; cfg.OnlyActiveTowers && !ok {
+			continue
+		}
+
+		towers = append(towers, copyTower(tower))
+	}
+
+	sort.Slice(towers, func(i, j int) bool {
+		return towers[i].ID < towers[j].ID
+	})
+
+	return towers, nil
+}
+
+// MarkBackupIneligible records that particular commit height is ineligible for
+// backup. This allows the client to track which updates it should not attempt
+// to retry after startup.
+func (m *ClientDB) MarkBackupIneligible(_ lnwire.ChannelID, _ uint64) error {
+	if err := m.checkFailPoint("MarkBackupIneligible"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListClientSessions returns the set of all client sessions known to the db. An
+// optional tower ID can be used to filter out any client sessions in the
+// response that do not correspond to this tower.
+func (m *ClientDB) ListClientSessions(tower *wtdb.TowerID,
+	opts ...wtdb.ClientSessionListOption) (
+	map[wtdb.SessionID]*wtdb.ClientSession, error) {
+
+	if err := m.checkFailPoint("ListClientSessions"); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.listClientSessions(tower, opts...)
+}
+
+// listClientSessions returns the set of all client sessions known to the db. An
+// optional tower ID can be used to filter out any client sessions in the
+// response that do not correspond to this tower.
+//
+// NOTE: This method requires the database's lock to be acquired.
+// ListClientSessionsGrouped returns the sessions known to the db grouped by
+// their status, listing active, inactive and exhausted sessions alike. An
+// optional tower ID can be used to only list the sessions of that tower.
+// Within each group, the sessions are ordered by their IDs.
+func (m *ClientDB) ListClientSessionsGrouped(tower *wtdb.TowerID) (
+	map[wtdb.CSessionStatus][]*wtdb.ClientSession, error) {
+
+	if err := m.checkFailPoint("ListClientSessionsGrouped"); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if tower != nil {
+		if _, ok := m.towers[*tower]; !ok {
+			return nil, wtdb.ErrTowerNotFound
+		}
+	}
+
+	return wtdb.ListClientSessionsGroupedFromSource(&sessionSource{
+		db:    m,
+		tower: tower,
+	})
+}
+
+func (m *ClientDB) listClientSessions(tower *wtdb.TowerID,
+	opts ...wtdb.ClientSessionListOption) (
+	map[wtdb.SessionID]*wtdb.ClientSession, error) {
+
+	if tower != nil {
+		if _, ok := m.towers[*tower]; !ok {
+			return nil, wtdb.ErrTowerNotFound
+		}
+	}
+
+	return wtdb.ListClientSessionsFromSource(&sessionSource{
+		db:    m,
+		tower: tower,
+	}, opts...)
+}
+
+// sessionSource is a wtdb.ClientSessionSource backed by the in-memory state of
+// the mock ClientDB. This ensures that the ClientSessionListOptions are applied
+// exactly as they are by the bolt ClientDB.
+//
+// NOTE: The database's lock must be held while the source is in use.
+type sessionSource struct {
+	db *ClientDB
+
+	// tower, if set, restricts the source to the sessions of the tower.
+	tower *wtdb.TowerID
+}
+
+// A compile-time check to ensure sessionSource implements the
+// wtdb.ClientSessionSource interface.
+var _ wtdb.ClientSessionSource = (*sessionSource)(nil)
+
+// ForEachSessionID calls next with each of the source's session IDs that sort
+// strictly after the given offset, in ascending order.
+//
+// NOTE: This is part of the wtdb.ClientSessionSource interface.
+func (s *sessionSource) ForEachSessionID(offset *wtdb.SessionID,
+	next func(wtdb.SessionID) (bool, error)) error {
+
+	ids := make([]wtdb.SessionID, 0, len(s.db.activeSessions))
+	for id, session := range s.db.activeSessions {
+		if s.tower != nil && *s.tower != session.TowerID {
+			continue
+		}
+
+		if offset != nil && bytes.Compare(id[:], offset[:]) <= 0 {
+			continue
+		}
+
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i][:], ids[j][:]) < 0
+	})
+
+	for _, id := range ids {
+		more, err := next(id)
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// FetchSession loads the session with the given ID along with its tower.
+//
+// NOTE: This is part of the wtdb.ClientSessionSource interface.
+func (s *sessionSource) FetchSession(id wtdb.SessionID) (*wtdb.ClientSession,
+	error) {
+
+	session, ok := s.db.activeSessions[id]
+	if !ok {
+		return nil, wtdb.ErrClientSessionNotFound
+	}
+
+	tower, ok := s.db.towers[session.TowerID]
+	if !ok {
+		return nil, wtdb.ErrTowerNotFound
+	}
+	session.Tower = copyTower(tower)
+
+	return &session, nil
+}
+
+// ForEachCommittedUpdate calls cb with each of the session's committed updates,
+// in ascending order of sequence number.
+//
+// NOTE: This is part of the wtdb.ClientSessionSource interface.
+func (s *sessionSource) ForEachCommittedUpdate(id wtdb.SessionID,
+	cb func(*wtdb.CommittedUpdate)) error {
+
+	if _, ok := s.db.activeSessions[id]; !ok {
+		return wtdb.ErrClientSessionNotFound
+	}
+
+	updates := make([]wtdb.CommittedUpdate, len(s.db.committedUpdates[id]))
+	copy(updates, s.db.committedUpdates[id])
+	sort.Slice(updates, func(i, j int) bool {
+		return updates[i].SeqNum < updates[j].SeqNum
+	})
+
+	for i := range updates {
+		cb(&updates[i])
+	}
+
+	return nil
+}
+
+// ForEachAckedUpdate calls cb with each of the session's acked updates, in
+// ascending order of sequence number.
+//
+// NOTE: This is part of the wtdb.ClientSessionSource interface.
+func (s *sessionSource) ForEachAckedUpdate(id wtdb.SessionID,
+	cb func(uint16, wtdb.BackupID)) error {
+
+	if _, ok := s.db.activeSessions[id]; !ok {
+		return wtdb.ErrClientSessionNotFound
+	}
+
+	ackedUpdates := s.db.ackedUpdates[id]
+	seqNums := make([]uint16, 0, len(ackedUpdates))
+	for seqNum := range ackedUpdates {
+		seqNums = append(seqNums, seqNum)
+	}
+	sort.Slice(seqNums, func(i, j int) bool {
+		return seqNums[i] < seqNums[j]
+	})
+
+	for _, seqNum := range seqNums {
+		cb(seqNum, ackedUpdates[seqNum])
+	}
+
+	return nil
+}
+
+// GetClientSession loads the ClientSession with the given ID from the DB. Any
+// ClientSessionListOptions provided are applied to the session's acked and
+// committed updates. ErrClientSessionNotFound is returned if the session does
+// not exist.
+func (m *ClientDB) GetClientSession(id wtdb.SessionID,
+	opts ...wtdb.ClientSessionListOption) (*wtdb.ClientSession, error) {
+
+	if err := m.checkFailPoint("GetClientSession"); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return wtdb.GetClientSessionFromSource(
+		&sessionSource{db: m}, id, opts...,
+	)
+}
+
+// FetchSessionCommittedUpdates retrieves the current set of un-acked updates
+// of the given session.
+func (m *ClientDB) FetchSessionCommittedUpdates(id *wtdb.SessionID) (
+	[]wtdb.CommittedUpdate, error) {
+
+	if err := m.checkFailPoint("FetchSessionCommittedUpdates"); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	updates := make([]wtdb.CommittedUpdate, 0)
+	err := m.forEachSessionCommittedUpdate(
+		id, func(update *wtdb.CommittedUpdate) error {
+			updates = append(updates, *update)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return updates, nil
+}
+
+// ForEachSessionCommittedUpdate calls cb with each of the current un-acked
+// updates of the given session, in ascending order of sequence number. The
+// iteration stops as soon as cb returns an error, which is then returned.
+func (m *ClientDB) ForEachSessionCommittedUpdate(id *wtdb.SessionID,
+	cb func(*wtdb.CommittedUpdate) error) error {
+
+	err := m.checkFailPoint("ForEachSessionCommittedUpdate")
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.forEachSessionCommittedUpdate(id, cb)
+}
+
+// forEachSessionCommittedUpdate calls cb with each of the current un-acked
+// updates of the given session.
+//
+// NOTE: This method requires the database's lock to be acquired.
+func (m *ClientDB) forEachSessionCommittedUpdate(id *wtdb.SessionID,
+	cb func(*wtdb.CommittedUpdate) error) error {
+
+	updates, ok := m.committedUpdates[*id]
+	if !ok {
+		return wtdb.ErrClientSessionNotFound
+	}
+
+	for _, update := range updates {
+		update := update
+		if err := cb(&update); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreateClientSession records a newly negotiated client session in the set of
+// active sessions. The session can be identified by its SessionID. An
+// ErrInvalidPolicy is returned if the session's policy fails validation.
+func (m *ClientDB) CreateClientSession(session *wtdb.ClientSession) error {
+	if err := m.checkFailPoint("CreateClientSession"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var events []wtdb.SessionEvent
+	if err := m.createClientSession(session, &events); err != nil {
+		return err
+	}
+
+	m.sessionEvents.Notify(events...)
+
+	return nil
+}
+
+// CreateClientSessionAutoReserve records a newly negotiated client session just
+// like CreateClientSession, but doesn't require a session key index to have
+// been reserved beforehand. Unless the session's KeyIndex is one of the indexes
+// reserved for its tower and blob type, an index is reserved and the session's
+// KeyIndex is set to it. The key index used is returned.
+func (m *ClientDB) CreateClientSessionAutoReserve(
+	session *wtdb.ClientSession) (uint32, error) {
+
+	err := m.checkFailPoint("CreateClientSessionAutoReserve")
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := keyIndexKey{
+		towerID:  session.TowerID,
+		blobType: session.Policy.BlobType,
+	}
+
+	// Remember the current reservations, so that they can be restored if
+	// the session can't be created.
+	var (
+		origKeyIndex     = session.KeyIndex
+		origNextIndex    = m.nextIndex
+		origIndexes, had = m.indexes[key]
+	)
+	origIndexes = cloneIndexes(origIndexes)
+
+	// The error is ignored since it only signals that no indexes have been
+	// reserved yet.
+	indexes, _ := m.getSessionKeyIndexes(key)
+
+	keyIndex := m.reserveSessionKeyIndices(key.towerID, key.blobType, 1)[0]
+	for _, index := range indexes {
+		if index == session.KeyIndex {
+			keyIndex = index
+			break
+		}
+	}
+	session.KeyIndex = keyIndex
+
+	var events []wtdb.SessionEvent
+	if err := m.createClientSession(session, &events); err != nil {
+		session.KeyIndex = origKeyIndex
+		m.nextIndex = origNextIndex
+		if had {
+			m.indexes[key] = origIndexes
+		} else {
+			delete(m.indexes, key)
+		}
+
+		return 0, err
+	}
+
+	m.sessionEvents.Notify(events...)
+
+	return keyIndex, nil
+}
+
+// RotateSession atomically marks the session with the given ID as exhausted
+// and records the new session that replaces it. The new session must be
+// negotiated with the same tower as the old one, using a freshly reserved
+// session key index. Any of the errors returned by CreateClientSession may be
+// returned for the new session.
+func (m *ClientDB) RotateSession(oldID wtdb.SessionID,
+	newSession *wtdb.ClientSession) error {
+
+	if err := m.checkFailPoint("RotateSession"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldSession, ok := m.activeSessions[oldID]
+	if !ok {
+		return wtdb.ErrClientSessionNotFound
+	}
+
+	if oldSession.TowerID != newSession.TowerID {
+		return wtdb.ErrRotateTowerMismatch
+	}
+
+	var events []wtdb.SessionEvent
+	addStatusEvent(
+		&events, oldID, oldSession.Status, wtdb.CSessionExhausted,
+	)
+
+	// Exhaust the old session before creating the new one, so that the
+	// old session doesn't count towards the tower's session limit. It is
+	// restored if the new session can't be created.
+	prevSession := oldSession
+	oldSession.Status = wtdb.CSessionExhausted
+	m.activeSessions[oldID] = oldSession
+
+	if err := m.createClientSession(newSession, &events); err != nil {
+		m.activeSessions[oldID] = prevSession
+		return err
+	}
+
+	m.sessionEvents.Notify(events...)
+
+	return nil
+}
+
+// createClientSession validates the given session and records it in the set
+// of active sessions, consuming its reserved session key index. The session's
+// creation is appended to events.
+//
+// NOTE: This method requires the database's lock to be acquired.
+func (m *ClientDB) createClientSession(session *wtdb.ClientSession,
+	events *[]wtdb.SessionEvent) error {
+	if err := session.Policy.Validate(); err != nil {
+		return &wtdb.ErrInvalidPolicy{Err: err}
+	}
+
+	// Reward sessions must specify where the tower's reward is paid,
+	// while altruist sessions may omit it.
+	if session.IsReward() && len(session.RewardPkScript) == 0 {
+		return wtdb.ErrMissingRewardPkScript
+	}
+
+	// Ensure that we aren't overwriting an existing session.
+	if _, ok := m.activeSessions[session.ID]; ok {
+		return wtdb.ErrClientSessionAlreadyExists
+	}
+
+	// If a policy has been negotiated with the tower, ensure that the
+	// session uses it.
+	policy, ok := m.towerPolicies[session.TowerID]
+	if ok && policy != session.Policy {
+		return wtdb.ErrTowerPolicyMismatch
+	}
+
+	// If the tower limits the number of sessions we may hold with it,
+	// ensure that the new session doesn't exceed that limit.
+	tower, ok := m.towers[session.TowerID]
+	if ok && tower.MaxSessions > 0 {
+		var numSessions uint32
+		for _, other := range m.activeSessions {
+			if other.TowerID == session.TowerID &&
+				other.Status != wtdb.CSessionExhausted {
+
+				numSessions++
+			}
+		}
+
+		if numSessions >= tower.MaxSessions {
+			return wtdb.ErrTowerSessionLimitReached
+		}
+	}
+
+	key := keyIndexKey{
+		towerID:  session.TowerID,
+		blobType: session.Policy.BlobType,
+	}
+
+	// Ensure that session key indexes have been reserved for this tower.
+	indexes, err := m.getSessionKeyIndexes(key)
+	if err != nil {
+		return err
+	}
+
+	// Ensure that the session's index matches one of the reserved indexes.
+	remaining := make([]uint32, 0, len(indexes))
+	for _, index := range indexes {
+		if index != session.KeyIndex {
+			remaining = append(remaining, index)
+		}
+	}
+	if len(remaining) == len(indexes) {
+		return wtdb.ErrIncorrectKeyIndex
+	}
+
+	// Remove the key index reservation for this tower. Once committed, this
+	// permits us to create another session with this tower.
+	if len(remaining) == 0 {
+		delete(m.indexes, key)
+	} else {
+		m.indexes[key] = remaining
+	}
+	if key.blobType == blob.TypeAltruistCommit {
+		delete(m.legacyIndexes, key.towerID)
+	}
+
+	// Record the session's creation time, which also counts as its last
+	// update.
+	session.CreatedAt = m.now()
+	session.LastUpdated = session.CreatedAt
+
+	m.activeSessions[session.ID] = wtdb.ClientSession{
+		ID: session.ID,
+		ClientSessionBody: wtdb.ClientSessionBody{
+			SeqNum:           session.SeqNum,
+			TowerLastApplied: session.TowerLastApplied,
+			TowerID:          session.TowerID,
+			KeyIndex:         session.KeyIndex,
+			Policy:           session.Policy,
+			RewardPkScript:   cloneBytes(session.RewardPkScript),
+			AltRewardPkScripts: cloneScripts(
+				session.AltRewardPkScripts,
+			),
+			CreatedAt:     session.CreatedAt,
+			LastUpdated:   session.LastUpdated,
+			SessionKeyPub: session.SessionKeyPub,
+		},
+	}
+	m.ackedUpdates[session.ID] = make(map[uint16]wtdb.BackupID)
+	m.committedUpdates[session.ID] = make([]wtdb.CommittedUpdate, 0)
+
+	*events = append(*events, wtdb.SessionEvent{
+		Type:      wtdb.SessionCreated,
+		SessionID: session.ID,
+		OldStatus: session.Status,
+		NewStatus: session.Status,
+	})
+
+	return nil
+}
+
+// AddSessionRewardScript registers an additional reward pkscript for the
+// session with the given ID, which subsequently committed updates may use in
+// place of the session's RewardPkScript. Registering a pkscript that is
+// already known to the session is a no-op. ErrTooManyRewardScripts is returned
+// if the session would end up with more than MaxRewardScripts pkscripts.
+func (m *ClientDB) AddSessionRewardScript(id wtdb.SessionID,
+	pkScript []byte) error {
+
+	if err := m.checkFailPoint("AddSessionRewardScript"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.activeSessions[id]
+	if !ok {
+		return wtdb.ErrClientSessionNotFound
+	}
+
+	session.AltRewardPkScripts = cloneScripts(session.AltRewardPkScripts)
+	added, err := session.AddRewardScript(pkScript)
+	if err != nil || !added {
+		return err
+	}
+
+	m.activeSessions[id] = session
+
+	return nil
+}
+
+// UpdateSessionRewardScript replaces the RewardPkScript of the reward session
+// with the given ID, leaving the rest of the session and its updates untouched.
+// ErrSessionNotReward is returned for altruist sessions, and
+// ErrMissingRewardPkScript if newScript is empty.
+func (m *ClientDB) UpdateSessionRewardScript(id wtdb.SessionID,
+	newScript []byte) error {
+
+	if err := m.checkFailPoint("UpdateSessionRewardScript"); err != nil {
+		return err
+	}
+
+	if len(newScript) == 0 {
+		return wtdb.ErrMissingRewardPkScript
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.activeSessions[id]
+	if !ok {
+		return wtdb.ErrClientSessionNotFound
+	}
+
+	if !session.IsReward() {
+		return wtdb.ErrSessionNotReward
+	}
+
+	session.RewardPkScript = cloneBytes(newScript)
+	m.activeSessions[id] = session
+
+	return nil
+}
+
+// DeleteClientSession removes the client session with the given ID from the
+// database, along with all of its acked updates. The session's tower is left
+// untouched. If the session still has committed updates that have not been
+// acked by the tower, ErrSessionHasUnackedUpdates is returned.
+func (m *ClientDB) DeleteClientSession(id wtdb.SessionID) error {
+	if err := m.checkFailPoint("DeleteClientSession"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.activeSessions[id]; !ok {
+		return wtdb.ErrClientSessionNotFound
+	}
+
+	if len(m.committedUpdates[id]) > 0 {
+		return wtdb.ErrSessionHasUnackedUpdates
+	}
+
+	delete(m.activeSessions, id)
+	delete(m.ackedUpdates, id)
+	delete(m.committedUpdates, id)
+	delete(m.storageBytes, id)
+
+	return nil
+}
+
+// NextSessionKeyIndex reserves a new session key derivation index for a
+// particular tower id. The index is reserved for that tower until
+// CreateClientSession is invoked for that tower and index, at which point a new
+// index for that tower can be reserved. Multiple calls to this method before
+// CreateClientSession is invoked should return the same index.
+func (m *ClientDB) NextSessionKeyIndex(towerID wtdb.TowerID,
+	blobType blob.Type) (uint32, error) {
+
+	if err := m.checkFailPoint("NextSessionKeyIndex"); err != nil {
+		return 0, err
+	}
+
+	indexes, err := m.ReserveSessionKeyIndices(towerID, blobType, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	return indexes[0], nil
+}
+
+// HighestSessionKeyIndex returns the largest session key index that has been
+// finalized by creating a session with the given tower and blob type, or 0 if
+// no such session exists. Indexes that are merely reserved aren't considered.
+func (m *ClientDB) HighestSessionKeyIndex(towerID wtdb.TowerID,
+	blobType blob.Type) (uint32, error) {
+
+	if err := m.checkFailPoint("HighestSessionKeyIndex"); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var highest uint32
+	for _, session := range m.activeSessions {
+		if session.TowerID != towerID ||
+			session.Policy.BlobType != blobType {
+
+			continue
+		}
+
+		if session.KeyIndex > highest {
+			highest = session.KeyIndex
+		}
+	}
+
+	return highest, nil
+}
+
+// ReserveSessionKeyIndices reserves n session key derivation indexes for a
+// particular tower id and blob type. Any indexes that are already reserved,
+// and have not yet been consumed by CreateClientSession, are returned first.
+func (m *ClientDB) ReserveSessionKeyIndices(towerID wtdb.TowerID,
+	blobType blob.Type, n int) ([]uint32, error) {
+
+	if err := m.checkFailPoint("ReserveSessionKeyIndices"); err != nil {
+		return nil, err
+	}
+
+	if n <= 0 {
+		return nil, wtdb.ErrInvalidKeyIndexCount
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.reserveSessionKeyIndices(towerID, blobType, n), nil
+}
+
+// reserveSessionKeyIndices reserves n session key derivation indexes for the
+// given tower id and blob type, returning any already reserved indexes first.
+//
+// NOTE: This method requires the database's lock to be acquired.
+func (m *ClientDB) reserveSessionKeyIndices(towerID wtdb.TowerID,
+	blobType blob.Type, n int) []uint32 {
+
+	key := keyIndexKey{
+		towerID:  towerID,
+		blobType: blobType,
+	}
+
+	// The error is ignored since it only signals that no indexes have been
+	// reserved yet.
+	indexes, _ := m.getSessionKeyIndexes(key)
+	if len(indexes) >= n {
+		return cloneIndexes(indexes[:n])
+	}
+
+	// Reuse any indexes released by this tower and blob type, lowest
+	// first, before generating new ones.
+	released := m.releasedIndexes[key]
+	for len(indexes) < n && len(released) > 0 {
+		indexes = append(indexes, released[0])
+		released = released[1:]
+	}
+	if len(released) == 0 {
+		delete(m.releasedIndexes, key)
+	} else {
+		m.releasedIndexes[key] = released
+	}
+
+	for len(indexes) < n {
+		m.nextIndex++
+		indexes = append(indexes, m.nextIndex)
+	}
+	m.indexes[key] = indexes
+
+	return cloneIndexes(indexes)
+}
+
+// ListReservedKeyIndices returns the session key indexes reserved for the given
+// tower and blob type that haven't yet been used to create a session, in the
+// order they were reserved.
+func (m *ClientDB) ListReservedKeyIndices(towerID wtdb.TowerID,
+	blobType blob.Type) ([]uint32, error) {
+
+	if err := m.checkFailPoint("ListReservedKeyIndices"); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	indexes, err := m.getSessionKeyIndexes(keyIndexKey{
+		towerID:  towerID,
+		blobType: blobType,
+	})
+	if err == wtdb.ErrNoReservedKeyIndex {
+		return nil, nil
+	}
+
+	return indexes, err
+}
+
+// ReleaseReservedKeyIndex clears the reservation of the given session key index
+// for the given tower and blob type, such that the index is handed out again by
+// a later reservation for the same tower and blob type. Released indexes are
+// never handed to other towers, since the index alone determines the session
+// key. ErrKeyIndexFinalized is returned if a session was already created with
+// the index, and ErrNoReservedKeyIndex if the index isn't otherwise reserved.
+func (m *ClientDB) ReleaseReservedKeyIndex(towerID wtdb.TowerID,
+	blobType blob.Type, index uint32) error {
+
+	if err := m.checkFailPoint("ReleaseReservedKeyIndex"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := keyIndexKey{
+		towerID:  towerID,
+		blobType: blobType,
+	}
+
+	// The error is ignored since it only signals that no indexes have been
+	// reserved, in which case the index isn't found below.
+	indexes, _ := m.getSessionKeyIndexes(key)
+
+	remaining := make([]uint32, 0, len(indexes))
+	for _, reserved := range indexes {
+		if reserved != index {
+			remaining = append(remaining, reserved)
+		}
+	}
+
+	if len(remaining) == len(indexes) {
+		for _, session := range m.activeSessions {
+			if session.TowerID == towerID &&
+				session.Policy.BlobType == blobType &&
+				session.KeyIndex == index {
+
+				return wtdb.ErrKeyIndexFinalized
+			}
+		}
+
+		return wtdb.ErrNoReservedKeyIndex
+	}
+
+	if len(remaining) == 0 {
+		delete(m.indexes, key)
+	} else {
+		m.indexes[key] = remaining
+	}
+	if blobType == blob.TypeAltruistCommit {
+		delete(m.legacyIndexes, towerID)
+	}
+
+	released := m.releasedIndexes[key]
+	i := sort.Search(len(released), func(i int) bool {
+		return released[i] >= index
+	})
+	released = append(released, 0)
+	copy(released[i+1:], released[i:])
+	released[i] = index
+	m.releasedIndexes[key] = released
+
+	return nil
+}
+
+func (m *ClientDB) getSessionKeyIndexes(key keyIndexKey) ([]uint32, error) {
+	if indexes, ok := m.indexes[key]; ok {
+		return cloneIndexes(indexes), nil
+	}
+
+	if key.blobType == blob.TypeAltruistCommit {
+		if index, ok := m.legacyIndexes[key.towerID]; ok {
+			return []uint32{index}, nil
+		}
+	}
+
+	return nil, wtdb.ErrNoReservedKeyIndex
+}
+
+func cloneIndexes(indexes []uint32) []uint32 {
+	return append([]uint32(nil), indexes...)
+}
+
+// CommitUpdate persists the CommittedUpdate provided in the slot for (session,
+// seqNum). This allows the client to retransmit this update on startup.
+func (m *ClientDB) CommitUpdate(id *wtdb.SessionID,
+	update *wtdb.CommittedUpdate) (uint16, error) {
+
+	if err := m.checkFailPoint("CommitUpdate"); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var events []wtdb.SessionEvent
+	lastApplied, _, err := m.commitUpdate(id, update, &events)
+	if err != nil {
+		return 0, err
+	}
+
+	m.sessionEvents.Notify(events...)
+
+	return lastApplied, nil
+}
+
+// CommitUpdateWithRemaining persists the CommittedUpdate just like
+// CommitUpdate, but additionally returns the number of updates that can still
+// be committed to the session. A remaining count of zero means that the
+// session is exhausted.
+func (m *ClientDB) CommitUpdateWithRemaining(id *wtdb.SessionID,
+	update *wtdb.CommittedUpdate) (uint16, uint16, error) {
+
+	if err := m.checkFailPoint("CommitUpdateWithRemaining"); err != nil {
+		return 0, 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var events []wtdb.SessionEvent
+	lastApplied, remaining, err := m.commitUpdate(id, update, &events)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	m.sessionEvents.Notify(events...)
+
+	return lastApplied, remaining, nil
+}
+
+// CommitUpdates persists a contiguous run of CommittedUpdates for the given
+// session. Each update is subject to the same ordering and idempotency rules as
+// CommitUpdate, and the returned slice holds the session's last applied value
+// after each update in the batch. If any update in the batch is rejected, none
+// of the updates are persisted.
+func (m *ClientDB) CommitUpdates(id *wtdb.SessionID,
+	updates []*wtdb.CommittedUpdate) ([]uint16, error) {
+
+	if err := m.checkFailPoint("CommitUpdates"); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Snapshot the session's state so that we can roll back if any of the
+	// updates in the batch are rejected.
+	session, ok := m.activeSessions[*id]
+	if !ok {
+		return nil, wtdb.ErrClientSessionNotFound
+	}
+	committedUpdates := make(
+		[]wtdb.CommittedUpdate, len(m.committedUpdates[*id]),
+	)
+	copy(committedUpdates, m.committedUpdates[*id])
+	storageBytes := m.storageBytes[*id]
+
+	var events []wtdb.SessionEvent
+	lastApplieds := make([]uint16, 0, len(updates))
+	for _, update := range updates {
+		lastApplied, _, err := m.commitUpdate(id, update, &events)
+		if err != nil {
+			m.activeSessions[*id] = session
+			m.committedUpdates[*id] = committedUpdates
+			m.storageBytes[*id] = storageBytes
+
+			return nil, err
+		}
+
+		lastApplieds = append(lastApplieds, lastApplied)
+	}
+
+	m.sessionEvents.Notify(events...)
+
+	return lastApplieds, nil
+}
+
+// commitUpdate persists the CommittedUpdate provided in the slot for (session,
+// seqNum), returning the session's last applied value and the number of
+// updates that can still be committed to the session. If the update exhausts
+// the session, this is appended to events.
+//
+// NOTE: This method requires the database's lock to be acquired.
+func (m *ClientDB) commitUpdate(id *wtdb.SessionID,
+	update *wtdb.CommittedUpdate, events *[]wtdb.SessionEvent) (uint16,
+	uint16, error) {
+
+	committed, err := m.validateCommit(id, update)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	session := m.activeSessions[*id]
+
+	// If the breach hint matches that of an update that has already been
+	// committed, we'll just return the last applied value so the client
+	// can retransmit.
+	if committed {
+		remaining := remainingUpdates(&session)
+		return session.TowerLastApplied, remaining, nil
+	}
+
+	// Save the update and increment the sequence number. The stored
+	// update records the blob type under which it was encrypted, leaving
+	// the caller's update untouched. Updates using the session's
+	// RewardPkScript are stored without a pkscript, just as they would
+	// decode from disk.
+	dbUpdate := *update
+	dbUpdate.BlobType = session.Policy.BlobType
+	if len(dbUpdate.RewardPkScript) == 0 {
+		dbUpdate.RewardPkScript = nil
+	}
+	m.committedUpdates[session.ID] = append(
+		m.committedUpdates[session.ID], dbUpdate,
+	)
+	m.storageBytes[session.ID] += uint64(len(update.EncryptedBlob))
+	session.SeqNum++
+	session.LastUpdated = m.now()
+
+	// If this update allocated the session's last sequence number, the
+	// session is exhausted and can't be used for any further backups.
+	if session.SeqNum == session.Policy.MaxUpdates {
+		addStatusEvent(
+			events, session.ID, session.Status,
+			wtdb.CSessionExhausted,
+		)
+		session.Status = wtdb.CSessionExhausted
+	}
+
+	m.activeSessions[*id] = session
+
+	return session.TowerLastApplied, remainingUpdates(&session), nil
+}
+
+// ValidateUpdate checks whether the CommittedUpdate could be committed to the
+// session using CommitUpdate, without persisting it. The same errors that
+// CommitUpdate would return are returned, such as ErrClientSessionNotFound,
+// ErrCommitUnorderedUpdate or ErrUpdateAlreadyCommitted, while nil is returned
+// if the commit would succeed. Since nothing is persisted, the outcome may
+// change if the session is modified before the update is committed.
+func (m *ClientDB) ValidateUpdate(id *wtdb.SessionID,
+	update *wtdb.CommittedUpdate) error {
+
+	if err := m.checkFailPoint("ValidateUpdate"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := m.validateCommit(id, update)
+
+	return err
+}
+
+// validateCommit checks that the given update can be committed to the session.
+// True is returned if an identical update has already been committed, in which
+// case committing it again is a no-op.
+//
+// NOTE: This method requires the database's lock to be acquired.
+func (m *ClientDB) validateCommit(id *wtdb.SessionID,
+	update *wtdb.CommittedUpdate) (bool, error) {
+
+	// Fail if session doesn't exist.
+	session, ok := m.activeSessions[*id]
+	if !ok {
+		return false, wtdb.ErrClientSessionNotFound
+	}
+
+	// The update must be encrypted under the session's blob type, if it
+	// records one at all.
+	if update.BlobType != 0 && update.BlobType != session.Policy.BlobType {
+		return false, wtdb.ErrBlobTypeMismatch
+	}
+
+	// Check if an update has already been committed for this state.
+	for _, dbUpdate := range m.committedUpdates[session.ID] {
+		if dbUpdate.SeqNum == update.SeqNum {
+			// Fail if the breach hint doesn't match.
+			if dbUpdate.Hint != update.Hint {
+				return false, &wtdb.CommitConflictError{
+					SeqNum:           update.SeqNum,
+					ExistingHint:     dbUpdate.Hint,
+					ExistingBackupID: dbUpdate.BackupID,
+					Hint:             update.Hint,
+					BackupID:         update.BackupID,
+				}
+			}
+
+			return true, nil
+		}
+	}
+
+	// Only active sessions accept new updates, though updates that were
+	// committed before the session became inactive or exhausted can still
+	// be retransmitted.
+	if session.Status != wtdb.CSessionActive {
+		return false, wtdb.ErrSessionNotActive
+	}
+
+	// Sequence number must increment.
+	if update.SeqNum != session.SeqNum+1 {
+		return false, wtdb.ErrCommitUnorderedUpdate
+	}
+
+	// Refuse to add to the session's backlog of unacked updates if it has
+	// already reached the cap.
+	numPending := len(m.committedUpdates[session.ID])
+	if m.maxPendingUpdates != 0 && numPending >= int(m.maxPendingUpdates) {
+		return false, wtdb.ErrTooManyPendingUpdates
+	}
+
+	// The update must pay out to one of the session's reward pkscripts.
+	if !session.AcceptsRewardScript(update.RewardPkScript) {
+		return false, wtdb.ErrUnknownRewardScript
+	}
+
+	return false, nil
+}
+
+// AckUpdate persists an acknowledgment for a given (session, seqnum) pair. This
+// removes the update from the set of committed updates, and validates the
+// lastApplied value returned from the tower.
+func (m *ClientDB) AckUpdate(id *wtdb.SessionID, seqNum,
+	lastApplied uint16) error {
+
+	if err := m.checkFailPoint("AckUpdate"); err != nil {
+		return err
+	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	key := keyIndexKey{
-		towerID:  towerID,
-		blobType: blobType,
+	return m.ackUpdate(id, seqNum, lastApplied)
+}
+
+// AckUpdates persists a batch of acknowledgments for the given session. The
+// acks map each acked sequence number to the last applied value echoed by the
+// tower, and are applied in ascending order of sequence number. If any of them
+// is rejected then none of the acks are persisted.
+func (m *ClientDB) AckUpdates(id *wtdb.SessionID,
+	acks map[uint16]uint16) error {
+
+	if err := m.checkFailPoint("AckUpdates"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Snapshot the session's state so that we can roll back if any of the
+	// acks in the batch are rejected.
+	session, ok := m.activeSessions[*id]
+	if !ok {
+		return wtdb.ErrClientSessionNotFound
+	}
+	committedUpdates := make(
+		[]wtdb.CommittedUpdate, len(m.committedUpdates[*id]),
+	)
+	copy(committedUpdates, m.committedUpdates[*id])
+	ackedUpdates := make(map[uint16]wtdb.BackupID)
+	for seqNum, backupID := range m.ackedUpdates[*id] {
+		ackedUpdates[seqNum] = backupID
+	}
+
+	seqNums := make([]uint16, 0, len(acks))
+	for seqNum := range acks {
+		seqNums = append(seqNums, seqNum)
+	}
+	sort.Slice(seqNums, func(i, j int) bool {
+		return seqNums[i] < seqNums[j]
+	})
+
+	for _, seqNum := range seqNums {
+		err := m.ackUpdate(id, seqNum, acks[seqNum])
+		if err != nil {
+			m.activeSessions[*id] = session
+			m.committedUpdates[*id] = committedUpdates
+			m.ackedUpdates[*id] = ackedUpdates
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ackUpdate persists an acknowledgment for a given (session, seqnum) pair.
+//
+// NOTE: This method requires the database's lock to be acquired.
+func (m *ClientDB) ackUpdate(id *wtdb.SessionID, seqNum,
+	lastApplied uint16) error {
+
+	// Fail if session doesn't exist.
+	session, ok := m.activeSessions[*id]
+	if !ok {
+		return wtdb.ErrClientSessionNotFound
+	}
+
+	// Ensure the returned last applied value does not exceed the highest
+	// allocated sequence number.
+	if lastApplied > session.SeqNum {
+		return wtdb.ErrUnallocatedLastApplied
+	}
+
+	// Ensure the last applied value isn't lower than a previous one sent by
+	// the tower.
+	if lastApplied < session.TowerLastApplied {
+		return wtdb.ErrLastAppliedReversion
+	}
+
+	// Retrieve the committed update, failing if none is found. We should
+	// only receive acks for state updates that we send.
+	updates := m.committedUpdates[session.ID]
+	for i, update := range updates {
+		if update.SeqNum != seqNum {
+			continue
+		}
+
+		// Remove the committed update from disk and mark the update as
+		// acked. The tower last applied value is also recorded to send
+		// along with the next update.
+		copy(updates[i:], updates[i+1:])
+		updates[len(updates)-1] = wtdb.CommittedUpdate{}
+		m.committedUpdates[session.ID] = updates[:len(updates)-1]
+
+		m.ackedUpdates[*id][seqNum] = update.BackupID
+		session.TowerLastApplied = lastApplied
+		session.LastUpdated = m.now()
+		if seqNum > session.LastAckedSeqNum {
+			session.LastAckedSeqNum = seqNum
+		}
+
+		m.activeSessions[*id] = session
+		return nil
+	}
+
+	return wtdb.ErrCommittedUpdateNotFound
+}
+
+// DeleteCommittedUpdate removes the committed update with the given sequence
+// number from the session without acking it, for use when the tower will never
+// accept it. The session's remaining committed updates keep their sequence
+// numbers. ErrCommittedUpdateNotFound is returned if the session has no such
+// committed update.
+func (m *ClientDB) DeleteCommittedUpdate(id *wtdb.SessionID,
+	seqNum uint16) error {
+
+	if err := m.checkFailPoint("DeleteCommittedUpdate"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.activeSessions[*id]; !ok {
+		return wtdb.ErrClientSessionNotFound
+	}
+
+	updates := m.committedUpdates[*id]
+	for i, update := range updates {
+		if update.SeqNum != seqNum {
+			continue
+		}
+
+		m.releaseStorageBytes(*id, len(update.EncryptedBlob))
+
+		copy(updates[i:], updates[i+1:])
+		updates[len(updates)-1] = wtdb.CommittedUpdate{}
+		m.committedUpdates[*id] = updates[:len(updates)-1]
+
+		return nil
+	}
+
+	return wtdb.ErrCommittedUpdateNotFound
+}
+
+// SessionStorageBytes returns the total size of the encrypted blobs of the
+// committed and acked updates of the session with the given ID. Acked updates
+// keep counting towards the total even once deleted by DeleteChannelUpdates,
+// while committed updates that are deleted without being acked don't.
+// ErrClientSessionNotFound is returned if the session doesn't exist.
+func (m *ClientDB) SessionStorageBytes(id wtdb.SessionID) (uint64, error) {
+	if err := m.checkFailPoint("SessionStorageBytes"); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.activeSessions[id]; !ok {
+		return 0, wtdb.ErrClientSessionNotFound
+	}
+
+	return m.storageBytes[id], nil
+}
+
+// releaseStorageBytes subtracts the size of a blob that is no longer stored
+// from the storage size of the given session.
+//
+// NOTE: This method requires the database's lock to be acquired.
+func (m *ClientDB) releaseStorageBytes(id wtdb.SessionID, size int) {
+	if uint64(size) > m.storageBytes[id] {
+		m.storageBytes[id] = 0
+		return
+	}
+
+	m.storageBytes[id] -= uint64(size)
+}
+
+// Ping checks that the database can be read. The mock is always readable, so
+// only an error set with a fail point is returned.
+func (m *ClientDB) Ping() error {
+	return m.checkFailPoint("Ping")
+}
+
+// DBStats returns a summary of the contents of the client database. The size of
+// the acked updates is synthesized from the size of their encoding in the bolt
+// database.
+func (m *ClientDB) DBStats() (wtdb.ClientDBStats, error) {
+	if err := m.checkFailPoint("DBStats"); err != nil {
+		return wtdb.ClientDBStats{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := wtdb.ClientDBStats{
+		NumTowers:   uint64(len(m.towers)),
+		NumSessions: uint64(len(m.activeSessions)),
+		NumChannels: uint64(len(m.summaries)),
+	}
+
+	for _, updates := range m.committedUpdates {
+		stats.NumCommittedUpdates += uint64(len(updates))
+	}
+
+	for _, updates := range m.ackedUpdates {
+		for _, backupID := range updates {
+			var b bytes.Buffer
+			if err := backupID.Encode(&b); err != nil {
+				return wtdb.ClientDBStats{}, err
+			}
+
+			// Each acked update is keyed by its 2-byte sequence
+			// number.
+			stats.NumAckedUpdates++
+			stats.AckedUpdatesBytes += uint64(2 + b.Len())
+		}
+	}
+
+	return stats, nil
+}
+
+// SessionUpdateCounts returns the number of committed (un-acked) and acked
+// updates of the given session. ErrClientSessionNotFound is returned if the
+// session does not exist.
+func (m *ClientDB) SessionUpdateCounts(id wtdb.SessionID) (uint16, uint16,
+	error) {
+
+	if err := m.checkFailPoint("SessionUpdateCounts"); err != nil {
+		return 0, 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.activeSessions[id]; !ok {
+		return 0, 0, wtdb.ErrClientSessionNotFound
+	}
+
+	committed := uint16(len(m.committedUpdates[id]))
+	acked := uint16(len(m.ackedUpdates[id]))
+
+	return committed, acked, nil
+}
+
+// TotalPendingUpdates returns the number of committed updates that haven't yet
+// been acked, across all sessions.
+func (m *ClientDB) TotalPendingUpdates() (uint64, error) {
+	if err := m.checkFailPoint("TotalPendingUpdates"); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var total uint64
+	for _, updates := range m.committedUpdates {
+		total += uint64(len(updates))
+	}
+
+	return total, nil
+}
+
+// NextSeqNum returns the sequence number that the next update committed to the
+// session with the given ID must use.
+func (m *ClientDB) NextSeqNum(id wtdb.SessionID) (uint16, error) {
+	if err := m.checkFailPoint("NextSeqNum"); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.activeSessions[id]
+	if !ok {
+		return 0, wtdb.ErrClientSessionNotFound
+	}
+
+	return session.SeqNum + 1, nil
+}
+
+// NumTowerSessions returns the number of sessions that have been created with
+// the tower identified by the given ID. ErrTowerNotFound is returned if the
+// tower doesn't exist.
+func (m *ClientDB) NumTowerSessions(id wtdb.TowerID) (uint64, error) {
+	if err := m.checkFailPoint("NumTowerSessions"); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.towers[id]; !ok {
+		return 0, wtdb.ErrTowerNotFound
+	}
+
+	var count uint64
+	for _, session := range m.activeSessions {
+		if session.TowerID == id {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// FetchAckedUpdatesForChannel returns, for each session holding acked backups
+// of the given channel, the sequence numbers of those backups in ascending
+// order.
+func (m *ClientDB) FetchAckedUpdatesForChannel(chanID lnwire.ChannelID) (
+	map[wtdb.SessionID][]uint16, error) {
+
+	if err := m.checkFailPoint("FetchAckedUpdatesForChannel"); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seqNums := make(map[wtdb.SessionID][]uint16)
+	for id, ackedUpdates := range m.ackedUpdates {
+		for seqNum, backupID := range ackedUpdates {
+			if backupID.ChanID != chanID {
+				continue
+			}
+
+			seqNums[id] = append(seqNums[id], seqNum)
+		}
+	}
+
+	for id := range seqNums {
+		sessionSeqNums := seqNums[id]
+		sort.Slice(sessionSeqNums, func(i, j int) bool {
+			return sessionSeqNums[i] < sessionSeqNums[j]
+		})
+	}
+
+	return seqNums, nil
+}
+
+// SessionsForChannel returns the IDs of all sessions holding committed or
+// acked updates of the given channel, in ascending order.
+func (m *ClientDB) SessionsForChannel(chanID lnwire.ChannelID) (
+	[]wtdb.SessionID, error) {
+
+	if err := m.checkFailPoint("SessionsForChannel"); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	holdsChannel := func(id wtdb.SessionID) bool {
+		for _, update := range m.committedUpdates[id] {
+			if update.BackupID.ChanID == chanID {
+				return true
+			}
+		}
+
+		for _, backupID := range m.ackedUpdates[id] {
+			if backupID.ChanID == chanID {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var sessionIDs []wtdb.SessionID
+	for id := range m.activeSessions {
+		if holdsChannel(id) {
+			sessionIDs = append(sessionIDs, id)
+		}
+	}
+
+	sort.Slice(sessionIDs, func(i, j int) bool {
+		return bytes.Compare(sessionIDs[i][:], sessionIDs[j][:]) < 0
+	})
+
+	return sessionIDs, nil
+}
+
+// ChannelsWithoutBackups returns the IDs of all registered channels for which
+// no session holds a committed or acked update, in ascending order.
+func (m *ClientDB) ChannelsWithoutBackups() ([]lnwire.ChannelID, error) {
+	if err := m.checkFailPoint("ChannelsWithoutBackups"); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	backedUp := make(map[lnwire.ChannelID]struct{})
+	for id := range m.activeSessions {
+		for _, update := range m.committedUpdates[id] {
+			backedUp[update.BackupID.ChanID] = struct{}{}
+		}
+
+		for _, backupID := range m.ackedUpdates[id] {
+			backedUp[backupID.ChanID] = struct{}{}
+		}
+	}
+
+	var chanIDs []lnwire.ChannelID
+	for chanID := range m.summaries {
+		if _, ok := backedUp[chanID]; !ok {
+			chanIDs = append(chanIDs, chanID)
+		}
+	}
+
+	sort.Slice(chanIDs, func(i, j int) bool {
+		return bytes.Compare(chanIDs[i][:], chanIDs[j][:]) < 0
+	})
+
+	return chanIDs, nil
+}
+
+// DeleteChannelUpdates removes the acked updates of the given channel from all
+// sessions, returning the number of updates deleted.
+func (m *ClientDB) DeleteChannelUpdates(chanID lnwire.ChannelID) (int, error) {
+	if err := m.checkFailPoint("DeleteChannelUpdates"); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var numDeleted int
+	for _, ackedUpdates := range m.ackedUpdates {
+		for seqNum, backupID := range ackedUpdates {
+			if backupID.ChanID != chanID {
+				continue
+			}
+
+			delete(ackedUpdates, seqNum)
+			numDeleted++
+		}
+	}
+
+	return numDeleted, nil
+}
+
+// CheckClientDB scans the database for sessions whose tower doesn't exist and
+// committed updates with sequence numbers the session never allocated. Since
+// the mock derives its indexes from the sessions themselves, it never reports
+// orphaned index entries.
+func (m *ClientDB) CheckClientDB() (*wtdb.IntegrityReport, error) {
+	if err := m.checkFailPoint("CheckClientDB"); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	report := wtdb.NewIntegrityReport()
+	for id, session := range m.activeSessions {
+		if _, ok := m.towers[session.TowerID]; !ok {
+			report.SessionsMissingTower = append(
+				report.SessionsMissingTower, id,
+			)
+		}
+
+		for _, update := range m.committedUpdates[id] {
+			if session.IsValidCommitSeqNum(update.SeqNum) {
+				continue
+			}
+
+			report.InvalidCommittedUpdates[id] = append(
+				report.InvalidCommittedUpdates[id],
+				update.SeqNum,
+			)
+		}
 	}
 
-	if index, err := m.getSessionKeyIndex(key); err == nil {
-		return index, nil
+	return report, nil
+}
+
+// RepairClientDB removes the inconsistencies listed in the given report, as
+// produced by CheckClientDB. Invalid committed updates are removed, and
+// sessions whose tower doesn't exist are deleted along with their updates.
+func (m *ClientDB) RepairClientDB(report *wtdb.IntegrityReport) error {
+	if err := m.checkFailPoint("RepairClientDB"); err != nil {
+		return err
 	}
 
-	m.nextIndex++
-	index := m.nextIndex
-	m.indexes[key] = index
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	return index, nil
-}
+	for id := range report.InvalidCommittedUpdates {
+		session, ok := m.activeSessions[id]
+		if !ok {
+			continue
+		}
+
+		updates := m.committedUpdates[id][:0]
+		for _, update := range m.committedUpdates[id] {
+			if session.IsValidCommitSeqNum(update.SeqNum) {
+				updates = append(updates, update)
+				continue
+			}
 
-func (m *ClientDB) getSessionKeyIndex(key keyIndexKey) (uint32, error) {
-	if index, ok := m.indexes[key]; ok {
-		return index, nil
+			m.releaseStorageBytes(id, len(update.EncryptedBlob))
+		}
+		m.committedUpdates[id] = updates
 	}
 
-	if key.blobType == blob.TypeAltruistCommit {
-		if index, ok := m.legacyIndexes[key.towerID]; ok {
-			return index, nil
+	for _, id := range report.SessionsMissingTower {
+		session, ok := m.activeSessions[id]
+		if !ok {
+			continue
+		}
+
+		if _, ok := m.towers[session.TowerID]; ok {
+			continue
 		}
+
+		delete(m.activeSessions, id)
+		delete(m.committedUpdates, id)
+		delete(m.ackedUpdates, id)
+		delete(m.storageBytes, id)
 	}
 
-	return 0, wtdb.ErrNoReservedKeyIndex
+	return nil
 }
 
-// CommitUpdate persists the CommittedUpdate provided in the slot for (session,
-// seqNum). This allows the client to retransmit this update on startup.
-func (m *ClientDB) CommitUpdate(id *wtdb.SessionID,
-	update *wtdb.CommittedUpdate) (uint16, error) {
+// ExportClientState writes the towers, sessions along with their committed
+// and acked updates, and channel summaries of the database to the passed
+// io.Writer as a versioned client state archive.
+func (m *ClientDB) ExportClientState(w io.Writer) error {
+	if err := m.checkFailPoint("ExportClientState"); err != nil {
+		return err
+	}
 
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	state := &wtdb.ClientState{
+		Towers:        make([]*wtdb.Tower, 0, len(m.towers)),
+		Sessions:      make([]*wtdb.ClientSessionState, 0),
+		ChanSummaries: make(wtdb.ChannelSummaries, len(m.summaries)),
+	}
 
-	// Fail if session doesn't exist.
-	session, ok := m.activeSessions[*id]
-	if !ok {
-		return 0, wtdb.ErrClientSessionNotFound
+	for _, tower := range m.towers {
+		state.Towers = append(state.Towers, copyTower(tower))
 	}
 
-	// Check if an update has already been committed for this state.
-	for _, dbUpdate := range m.committedUpdates[session.ID] {
-		if dbUpdate.SeqNum == update.SeqNum {
-			// If the breach hint matches, we'll just return the
-			// last applied value so the client can retransmit.
-			if dbUpdate.Hint == update.Hint {
-				return session.TowerLastApplied, nil
-			}
+	for id, session := range m.activeSessions {
+		session := session
+		session.RewardPkScript = cloneBytes(session.RewardPkScript)
+		session.AltRewardPkScripts = cloneScripts(
+			session.AltRewardPkScripts,
+		)
+
+		committedUpdates := make(
+			[]wtdb.CommittedUpdate, len(m.committedUpdates[id]),
+		)
+		copy(committedUpdates, m.committedUpdates[id])
+
+		ackedUpdates := make(map[uint16]wtdb.BackupID)
+		for seqNum, backupID := range m.ackedUpdates[id] {
+			ackedUpdates[seqNum] = backupID
+		}
 
-			// Otherwise, fail since the breach hint doesn't match.
-			return 0, wtdb.ErrUpdateAlreadyCommitted
+		sessionState := &wtdb.ClientSessionState{
+			Session:          &session,
+			CommittedUpdates: committedUpdates,
+			AckedUpdates:     ackedUpdates,
 		}
+		state.Sessions = append(state.Sessions, sessionState)
 	}
 
-	// Sequence number must increment.
-	if update.SeqNum != session.SeqNum+1 {
-		return 0, wtdb.ErrCommitUnorderedUpdate
+	for chanID, summary := range m.summaries {
+		state.ChanSummaries[chanID] = cloneChanSummary(summary)
 	}
+	m.mu.Unlock()
 
-	// Save the update and increment the sequence number.
-	m.committedUpdates[session.ID] = append(
-		m.committedUpdates[session.ID], *update,
-	)
-	session.SeqNum++
-	m.activeSessions[*id] = session
-
-	return session.TowerLastApplied, nil
+	return wtdb.WriteClientState(w, state)
 }
 
-// AckUpdate persists an acknowledgment for a given (session, seqnum) pair. This
-// removes the update from the set of committed updates, and validates the
-// lastApplied value returned from the tower.
-func (m *ClientDB) AckUpdate(id *wtdb.SessionID, seqNum,
-	lastApplied uint16) error {
+// ImportClientState reads a client state archive written by ExportClientState
+// from the passed io.Reader and adds its towers, sessions and channel
+// summaries to the database. Either all of the archive is imported or none of
+// it is. ErrClientDBNotEmpty is returned if the database already holds
+// towers, sessions or channels, unless the WithMerge option is used.
+func (m *ClientDB) ImportClientState(r io.Reader,
+	opts ...wtdb.ClientStateImportOption) error {
+
+	if err := m.checkFailPoint("ImportClientState"); err != nil {
+		return err
+	}
+
+	cfg := wtdb.NewClientStateImportCfg()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	state, err := wtdb.ReadClientState(r)
+	if err != nil {
+		return err
+	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Fail if session doesn't exist.
-	session, ok := m.activeSessions[*id]
-	if !ok {
-		return wtdb.ErrClientSessionNotFound
-	}
+	if !cfg.Merge && (len(m.towers) > 0 || len(m.activeSessions) > 0 ||
+		len(m.summaries) > 0) {
 
-	// Ensure the returned last applied value does not exceed the highest
-	// allocated sequence number.
-	if lastApplied > session.SeqNum {
-		return wtdb.ErrUnallocatedLastApplied
+		return wtdb.ErrClientDBNotEmpty
 	}
 
-	// Ensure the last applied value isn't lower than a previous one sent by
-	// the tower.
-	if lastApplied < session.TowerLastApplied {
-		return wtdb.ErrLastAppliedReversion
+	// Validate the archive against the existing state before applying
+	// any of it, so that a failed import leaves the database untouched.
+	archivedTowers := make(map[wtdb.TowerID]struct{}, len(state.Towers))
+	for _, tower := range state.Towers {
+		archivedTowers[tower.ID] = struct{}{}
 	}
 
-	// Retrieve the committed update, failing if none is found. We should
-	// only receive acks for state updates that we send.
-	updates := m.committedUpdates[session.ID]
-	for i, update := range updates {
-		if update.SeqNum != seqNum {
+	// The acked updates' blobs aren't archived, so they're assumed to be
+	// of the size dictated by their session's blob type.
+	ackedBlobSizes := make(map[wtdb.SessionID]uint64, len(state.Sessions))
+	for _, session := range state.Sessions {
+		if _, ok := archivedTowers[session.Session.TowerID]; !ok {
+			return wtdb.ErrTowerNotFound
+		}
+
+		if _, ok := m.activeSessions[session.Session.ID]; ok {
+			return wtdb.ErrClientSessionAlreadyExists
+		}
+
+		if len(session.AckedUpdates) == 0 {
 			continue
 		}
 
-		// Remove the committed update from disk and mark the update as
-		// acked. The tower last applied value is also recorded to send
-		// along with the next update.
-		copy(updates[:i], updates[i+1:])
-		updates[len(updates)-1] = wtdb.CommittedUpdate{}
-		m.committedUpdates[session.ID] = updates[:len(updates)-1]
+		size, err := blob.SizeChecked(session.Session.Policy.BlobType)
+		if err != nil {
+			return err
+		}
+		ackedBlobSizes[session.Session.ID] = uint64(size)
+	}
 
-		m.ackedUpdates[*id][seqNum] = update.BackupID
-		session.TowerLastApplied = lastApplied
+	for chanID, summary := range state.ChanSummaries {
+		existing, ok := m.summaries[chanID]
+		if ok && !sameSweepPkScripts(existing, summary) {
+			return wtdb.ErrChannelAlreadyRegistered
+		}
+	}
 
-		m.activeSessions[*id] = session
-		return nil
+	towerIDs := make(map[wtdb.TowerID]wtdb.TowerID, len(state.Towers))
+	for _, tower := range state.Towers {
+		towerIDs[tower.ID] = m.importTower(tower)
 	}
 
-	return wtdb.ErrCommittedUpdateNotFound
+	for _, archived := range state.Sessions {
+		session := *archived.Session
+		session.TowerID = towerIDs[session.TowerID]
+		session.RewardPkScript = cloneBytes(session.RewardPkScript)
+		session.AltRewardPkScripts = cloneScripts(
+			session.AltRewardPkScripts,
+		)
+
+		committedUpdates := make(
+			[]wtdb.CommittedUpdate, len(archived.CommittedUpdates),
+		)
+		copy(committedUpdates, archived.CommittedUpdates)
+
+		// Updates archived before their blob type was recorded are
+		// assigned that of the session, as they would be when read
+		// from disk.
+		for i := range committedUpdates {
+			update := &committedUpdates[i]
+			if update.BlobType == 0 {
+				update.BlobType = session.Policy.BlobType
+			}
+		}
+		m.committedUpdates[session.ID] = committedUpdates
+
+		// The last acked sequence number isn't archived, so it's
+		// recovered from the session's acked updates.
+		session.LastAckedSeqNum = 0
+		ackedUpdates := make(map[uint16]wtdb.BackupID)
+		for seqNum, backupID := range archived.AckedUpdates {
+			ackedUpdates[seqNum] = backupID
+
+			if seqNum > session.LastAckedSeqNum {
+				session.LastAckedSeqNum = seqNum
+			}
+		}
+		m.ackedUpdates[session.ID] = ackedUpdates
+		m.activeSessions[session.ID] = session
+
+		var storageBytes uint64
+		for _, update := range committedUpdates {
+			storageBytes += uint64(len(update.EncryptedBlob))
+		}
+		storageBytes += uint64(len(ackedUpdates)) *
+			ackedBlobSizes[session.ID]
+		m.storageBytes[session.ID] = storageBytes
+
+		// Ensure that the key indexes used by the imported sessions
+		// won't be reserved again.
+		if session.KeyIndex > m.nextIndex {
+			m.nextIndex = session.KeyIndex
+		}
+	}
+
+	for chanID, summary := range state.ChanSummaries {
+		m.summaries[chanID] = cloneChanSummary(summary)
+	}
+
+	return nil
+}
+
+// importTower adds the given tower to the database, returning the ID assigned
+// to it. If a tower with the same identity key already exists, the addresses
+// of the given tower are added to it instead.
+//
+// NOTE: This method requires the database's lock to be acquired.
+func (m *ClientDB) importTower(tower *wtdb.Tower) wtdb.TowerID {
+	var towerPubKey towerPK
+	copy(towerPubKey[:], tower.IdentityKey.SerializeCompressed())
+
+	if towerID, ok := m.towerIndex[towerPubKey]; ok {
+		existing := m.towers[towerID]
+		for _, addr := range tower.Addresses {
+			existing.AddAddress(addr)
+		}
+		existing.LastModified = m.now()
+
+		if existing.Nickname == "" {
+			existing.Nickname = tower.Nickname
+		}
+		if existing.RateLimit == 0 {
+			existing.RateLimit = tower.RateLimit
+		}
+
+		return towerID
+	}
+
+	imported := copyTower(tower)
+	imported.ID = wtdb.TowerID(atomic.AddUint64(&m.nextTowerID, 1))
+
+	m.towerIndex[towerPubKey] = imported.ID
+	m.towers[imported.ID] = imported
+
+	return imported.ID
 }
 
 // FetchChanSummaries loads a mapping from all registered channels to their
-// channel summaries.
-func (m *ClientDB) FetchChanSummaries() (wtdb.ChannelSummaries, error) {
+// channel summaries. Soft-deleted channels are only included if the
+// WithTombstoned option is given.
+func (m *ClientDB) FetchChanSummaries(
+	opts ...wtdb.ChanSummaryFetchOption) (wtdb.ChannelSummaries, error) {
+
+	if err := m.checkFailPoint("FetchChanSummaries"); err != nil {
+		return nil, err
+	}
+
+	cfg := wtdb.NewChanSummaryFetchCfg()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	summaries := make(map[lnwire.ChannelID]wtdb.ClientChanSummary)
 	for chanID, summary := range m.summaries {
-		summaries[chanID] = wtdb.ClientChanSummary{
-			SweepPkScript: cloneBytes(summary.SweepPkScript),
+		summaries[chanID] = cloneChanSummary(summary)
+	}
+
+	if !cfg.IncludeTombstoned {
+		return summaries, nil
+	}
+
+	for chanID, summary := range m.tombstones {
+		if _, ok := summaries[chanID]; ok {
+			continue
+		}
+
+		summaries[chanID] = cloneChanSummary(summary)
+	}
+
+	return summaries, nil
+}
+
+// FetchChanSummariesForChannels loads the channel summaries of the given
+// channels, omitting any that aren't registered.
+func (m *ClientDB) FetchChanSummariesForChannels(
+	chanIDs []lnwire.ChannelID) (wtdb.ChannelSummaries, error) {
+
+	err := m.checkFailPoint("FetchChanSummariesForChannels")
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	summaries := make(wtdb.ChannelSummaries)
+	for _, chanID := range chanIDs {
+		summary, ok := m.summaries[chanID]
+		if !ok {
+			continue
 		}
+
+		summaries[chanID] = cloneChanSummary(summary)
 	}
 
 	return summaries, nil
@@ -474,24 +2893,210 @@ func (m *ClientDB) FetchChanSummaries() (wtdb.ChannelSummaries, error) {
 // now, all that is stored in the channel summary is the sweep pkscript that
 // we'd like any tower sweeps to pay into. In the future, this will be extended
 // to contain more info to allow the client efficiently request historical
-// states to be backed up under the client's active policy.
+// states to be backed up under the client's active policy. Re-registering a
+// channel with the same sweep pkscript is a no-op, while registering it with a
+// different one fails with ErrChannelAlreadyRegistered.
 func (m *ClientDB) RegisterChannel(chanID lnwire.ChannelID,
 	sweepPkScript []byte) error {
 
+	if err := m.checkFailPoint("RegisterChannel"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.registerChannel(chanID, &wtdb.ClientChanSummary{
+		SweepPkScript: sweepPkScript,
+		Priority:      wtdb.DefaultChannelPriority,
+	})
+}
+
+// RegisterChannelMulti registers a channel for use within the client database,
+// with distinct sweep pkscripts for different kinds of outputs. The pkscript
+// registered under wtdb.DefaultSweepScriptKind becomes the channel's
+// SweepPkScript. Re-registering a channel with the same pkscripts is a no-op,
+// while registering it with different ones fails with
+// ErrChannelAlreadyRegistered.
+func (m *ClientDB) RegisterChannelMulti(chanID lnwire.ChannelID,
+	scripts map[string][]byte) error {
+
+	if err := m.checkFailPoint("RegisterChannelMulti"); err != nil {
+		return err
+	}
+
+	summary, err := wtdb.NewMultiScriptChanSummary(scripts)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.registerChannel(chanID, summary)
+}
+
+// RegisterChannels registers a batch of channels, mapped to their sweep
+// pkscripts, within a single database transaction. Each channel is registered
+// as if by RegisterChannel, and the returned map holds an entry for every
+// channel that couldn't be registered, such as ErrChannelAlreadyRegistered.
+// The remaining channels are registered regardless. A non-nil error indicates
+// that the transaction itself failed, in which case none of the channels are
+// registered.
+func (m *ClientDB) RegisterChannels(
+	sweepPkScripts map[lnwire.ChannelID][]byte) (map[lnwire.ChannelID]error,
+	error) {
+
+	if err := m.checkFailPoint("RegisterChannels"); err != nil {
+		return nil, err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, ok := m.summaries[chanID]; ok {
+	chanErrs := make(map[lnwire.ChannelID]error)
+	for chanID, sweepPkScript := range sweepPkScripts {
+		err := m.registerChannel(chanID, &wtdb.ClientChanSummary{
+			SweepPkScript: sweepPkScript,
+			Priority:      wtdb.DefaultChannelPriority,
+		})
+		if err != nil {
+			chanErrs[chanID] = err
+		}
+	}
+
+	return chanErrs, nil
+}
+
+// registerChannel registers a channel for use within the client database,
+// storing a copy of the given summary.
+//
+// NOTE: This method requires the database's lock to be acquired.
+func (m *ClientDB) registerChannel(chanID lnwire.ChannelID,
+	summary *wtdb.ClientChanSummary) error {
+
+	if existing, ok := m.summaries[chanID]; ok {
+		if sameSweepPkScripts(existing, *summary) {
+			return nil
+		}
+
 		return wtdb.ErrChannelAlreadyRegistered
 	}
 
-	m.summaries[chanID] = wtdb.ClientChanSummary{
-		SweepPkScript: cloneBytes(sweepPkScript),
+	m.summaries[chanID] = cloneChanSummary(*summary)
+
+	return nil
+}
+
+// sameSweepPkScripts returns true if a channel registered with the existing
+// summary can be registered again with the given one. This is the case if both
+// share the same SweepPkScript, and the given summary's SweepPkScripts are
+// either absent or equal to the existing ones.
+func sameSweepPkScripts(existing, summary wtdb.ClientChanSummary) bool {
+	if !bytes.Equal(existing.SweepPkScript, summary.SweepPkScript) {
+		return false
+	}
+
+	return len(summary.SweepPkScripts) == 0 ||
+		existing.HasSweepPkScripts(summary.SweepPkScripts)
+}
+
+// SetChannelPriority sets the backup priority of a registered channel.
+// ErrChannelNotRegistered is returned if the channel was never registered.
+func (m *ClientDB) SetChannelPriority(chanID lnwire.ChannelID,
+	priority uint8) error {
+
+	if err := m.checkFailPoint("SetChannelPriority"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	summary, ok := m.summaries[chanID]
+	if !ok {
+		return wtdb.ErrChannelNotRegistered
+	}
+
+	summary.Priority = priority
+	m.summaries[chanID] = summary
+
+	return nil
+}
+
+// UnregisterChannel removes the channel summary for the given channel.
+// ErrChannelNotRegistered is returned if the channel was never registered. If
+// the WithSoftDelete option is given, the summary is kept in a tombstone until
+// it is purged.
+func (m *ClientDB) UnregisterChannel(chanID lnwire.ChannelID,
+	opts ...wtdb.UnregisterChannelOption) error {
+
+	if err := m.checkFailPoint("UnregisterChannel"); err != nil {
+		return err
+	}
+
+	cfg := wtdb.NewUnregisterChannelCfg()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	summary, ok := m.summaries[chanID]
+	if !ok {
+		return wtdb.ErrChannelNotRegistered
+	}
+
+	if cfg.SoftDelete {
+		summary.DeletedAt = m.now()
+		m.tombstones[chanID] = summary
+	}
+
+	delete(m.summaries, chanID)
+
+	return nil
+}
+
+// PurgeTombstones permanently removes the tombstones of the channels that were
+// soft-deleted before the given time.
+func (m *ClientDB) PurgeTombstones(before time.Time) error {
+	if err := m.checkFailPoint("PurgeTombstones"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for chanID, summary := range m.tombstones {
+		if summary.DeletedAt.Before(before) {
+			delete(m.tombstones, chanID)
+		}
 	}
 
 	return nil
 }
 
+// now returns the current time of the database's clock, stripped of its
+// monotonic clock reading to mirror the precision of the timestamps persisted
+// by the bolt implementation.
+func (m *ClientDB) now() time.Time {
+	return time.Unix(0, m.clock.Now().UnixNano())
+}
+
+func cloneScripts(scripts [][]byte) [][]byte {
+	if len(scripts) == 0 {
+		return nil
+	}
+
+	clones := make([][]byte, len(scripts))
+	for i, script := range scripts {
+		clones[i] = cloneBytes(script)
+	}
+
+	return clones
+}
+
 func cloneBytes(b []byte) []byte {
 	if b == nil {
 		return nil
@@ -503,13 +3108,53 @@ func cloneBytes(b []byte) []byte {
 	return bb
 }
 
+func cloneChanSummary(summary wtdb.ClientChanSummary) wtdb.ClientChanSummary {
+	clone := wtdb.ClientChanSummary{
+		SweepPkScript: cloneBytes(summary.SweepPkScript),
+		Priority:      summary.Priority,
+		DeletedAt:     summary.DeletedAt,
+	}
+
+	if summary.SweepPkScripts != nil {
+		clone.SweepPkScripts = make(
+			map[string][]byte, len(summary.SweepPkScripts),
+		)
+		for kind, pkScript := range summary.SweepPkScripts {
+			clone.SweepPkScripts[kind] = cloneBytes(pkScript)
+		}
+	}
+
+	return clone
+}
+
 func copyTower(tower *wtdb.Tower) *wtdb.Tower {
 	t := &wtdb.Tower{
-		ID:          tower.ID,
-		IdentityKey: tower.IdentityKey,
-		Addresses:   make([]net.Addr, len(tower.Addresses)),
+		ID:           tower.ID,
+		IdentityKey:  tower.IdentityKey,
+		Addresses:    make([]net.Addr, len(tower.Addresses)),
+		Nickname:     tower.Nickname,
+		MaxSessions:  tower.MaxSessions,
+		RateLimit:    tower.RateLimit,
+		CreatedAt:    tower.CreatedAt,
+		LastModified: tower.LastModified,
 	}
 	copy(t.Addresses, tower.Addresses)
 
+	if tower.Features != nil {
+		t.Features = wtdb.NewTowerFeatures(
+			tower.Features.RawFeatureVector.Clone(),
+		)
+	}
+
 	return t
 }
+
+// remainingUpdates returns the number of updates that can still be committed
+// to the given session before it is exhausted.
+func remainingUpdates(session *wtdb.ClientSession) uint16 {
+	if session.SeqNum >= session.Policy.MaxUpdates {
+		return 0
+	}
+
+	return session.Policy.MaxUpdates - session.SeqNum
+}
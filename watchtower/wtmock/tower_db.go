@@ -1,26 +1,48 @@
 package wtmock
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/lightningnetwork/lnd/chainntnfs"
+	"github.com/lightningnetwork/lnd/clock"
 	"github.com/lightningnetwork/lnd/watchtower/blob"
 	"github.com/lightningnetwork/lnd/watchtower/wtdb"
 )
 
+// updateKey identifies a state update stored under a breach hint, such that
+// distinct updates sharing a hint never overwrite each other.
+type updateKey struct {
+	id     wtdb.SessionID
+	seqNum uint16
+}
+
 // TowerDB is a mock, in-memory implementation of a watchtower.DB.
 type TowerDB struct {
 	mu        sync.Mutex
+	clock     clock.Clock
 	lastEpoch *chainntnfs.BlockEpoch
 	sessions  map[wtdb.SessionID]*wtdb.SessionInfo
-	blobs     map[blob.BreachHint]map[wtdb.SessionID]*wtdb.SessionStateUpdate
+	blobs     map[blob.BreachHint]map[updateKey]*wtdb.SessionStateUpdate
+	received  map[blob.BreachHint]map[wtdb.SessionID]time.Time
 }
 
 // NewTowerDB initializes a fresh mock TowerDB.
-func NewTowerDB() *TowerDB {
+func NewTowerDB(opts ...wtdb.TowerDBOption) *TowerDB {
+	cfg := wtdb.NewTowerDBCfg()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	return &TowerDB{
+		clock:    cfg.Clock,
 		sessions: make(map[wtdb.SessionID]*wtdb.SessionInfo),
-		blobs:    make(map[blob.BreachHint]map[wtdb.SessionID]*wtdb.SessionStateUpdate),
+		blobs:    make(map[blob.BreachHint]map[updateKey]*wtdb.SessionStateUpdate),
+		received: make(map[blob.BreachHint]map[wtdb.SessionID]time.Time),
 	}
 }
 
@@ -38,7 +60,8 @@ func (db *TowerDB) InsertStateUpdate(update *wtdb.SessionStateUpdate) (uint16, e
 	}
 
 	// Assert that the blob is the correct size for the session's blob type.
-	if len(update.EncryptedBlob) != blob.Size(info.Policy.BlobType) {
+	blobType := info.Policy.BlobType
+	if !blob.IsValidSize(blobType, len(update.EncryptedBlob)) {
 		return 0, wtdb.ErrInvalidBlobSize
 	}
 
@@ -47,12 +70,19 @@ func (db *TowerDB) InsertStateUpdate(update *wtdb.SessionStateUpdate) (uint16, e
 		return info.LastApplied, err
 	}
 
-	sessionsToUpdates, ok := db.blobs[update.Hint]
+	updatesForHint, ok := db.blobs[update.Hint]
 	if !ok {
-		sessionsToUpdates = make(map[wtdb.SessionID]*wtdb.SessionStateUpdate)
-		db.blobs[update.Hint] = sessionsToUpdates
+		updatesForHint = make(map[updateKey]*wtdb.SessionStateUpdate)
+		db.blobs[update.Hint] = updatesForHint
 	}
-	sessionsToUpdates[update.ID] = update
+	updatesForHint[updateKey{update.ID, update.SeqNum}] = update
+
+	sessionsToReceived, ok := db.received[update.Hint]
+	if !ok {
+		sessionsToReceived = make(map[wtdb.SessionID]time.Time)
+		db.received[update.Hint] = sessionsToReceived
+	}
+	sessionsToReceived[update.ID] = db.clock.Now()
 
 	return info.LastApplied, nil
 }
@@ -70,6 +100,23 @@ func (db *TowerDB) GetSessionInfo(id *wtdb.SessionID) (*wtdb.SessionInfo, error)
 	return nil, wtdb.ErrSessionNotFound
 }
 
+// GetSessionsByClient returns the sessions negotiated by the client with the
+// given public key. Since a session is identified by the public key the client
+// used to negotiate it, at most one session is returned.
+func (db *TowerDB) GetSessionsByClient(
+	pk *btcec.PublicKey) ([]*wtdb.SessionInfo, error) {
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	sessions := make([]*wtdb.SessionInfo, 0, 1)
+	if info, ok := db.sessions[wtdb.NewSessionIDFromPubKey(pk)]; ok {
+		sessions = append(sessions, info)
+	}
+
+	return sessions, nil
+}
+
 // InsertSessionInfo records a negotiated session in the tower database. An
 // error is returned if the session already exists.
 func (db *TowerDB) InsertSessionInfo(info *wtdb.SessionInfo) error {
@@ -107,19 +154,73 @@ func (db *TowerDB) DeleteSession(target wtdb.SessionID) error {
 
 	// Remove the state updates for any blobs stored under the target
 	// session identifier.
-	for hint, sessionUpdates := range db.blobs {
-		delete(sessionUpdates, target)
+	for hint := range db.blobs {
+		db.deleteUpdates(hint, target)
+		delete(db.received[hint], target)
 
 		// If this was the last state update, we can also remove the
 		// hint that would map to an empty set.
-		if len(sessionUpdates) == 0 {
-			delete(db.blobs, hint)
+		if len(db.blobs[hint]) == 0 {
+			delete(db.received, hint)
 		}
 	}
 
 	return nil
 }
 
+// deleteUpdates removes the state updates stored for the given (session, hint)
+// pair, returning the number of updates removed. If these were the last
+// updates stored under the hint, the hint is removed as well.
+//
+// NOTE: This method must be called with the mutex held.
+func (db *TowerDB) deleteUpdates(hint blob.BreachHint,
+	id wtdb.SessionID) int {
+
+	updatesForHint := db.blobs[hint]
+
+	var numDeleted int
+	for key := range updatesForHint {
+		if key.id != id {
+			continue
+		}
+
+		delete(updatesForHint, key)
+		numDeleted++
+	}
+
+	if len(updatesForHint) == 0 {
+		delete(db.blobs, hint)
+	}
+
+	return numDeleted
+}
+
+// PruneServerUpdates removes all state updates that were received before the
+// given time, returning the number of updates removed. The sessions
+// themselves are left untouched.
+func (db *TowerDB) PruneServerUpdates(before time.Time) (int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var numPruned int
+	for hint, sessionsToReceived := range db.received {
+		for id, received := range sessionsToReceived {
+			if !received.Before(before) {
+				continue
+			}
+
+			delete(sessionsToReceived, id)
+			numPruned += db.deleteUpdates(hint, id)
+		}
+
+		if len(sessionsToReceived) == 0 {
+			delete(db.received, hint)
+		}
+	}
+
+	return numPruned, nil
+}
+
 // QueryMatches searches against all known state updates for any that match the
 // passed breachHints. More than one Match will be returned for a given hint if
 // they exist in the database.
@@ -131,19 +232,19 @@ func (db *TowerDB) QueryMatches(
 
 	var matches []wtdb.Match
 	for _, hint := range breachHints {
-		sessionsToUpdates, ok := db.blobs[hint]
+		updatesForHint, ok := db.blobs[hint]
 		if !ok {
 			continue
 		}
 
-		for id, update := range sessionsToUpdates {
-			info, ok := db.sessions[id]
+		for key, update := range updatesForHint {
+			info, ok := db.sessions[key.id]
 			if !ok {
 				panic("session not found")
 			}
 
 			match := wtdb.Match{
-				ID:            id,
+				ID:            key.id,
 				SeqNum:        update.SeqNum,
 				Hint:          hint,
 				EncryptedBlob: update.EncryptedBlob,
@@ -156,6 +257,84 @@ func (db *TowerDB) QueryMatches(
 	return matches, nil
 }
 
+// TowerServerStats returns a summary of the sessions and state updates stored
+// by the tower.
+func (db *TowerDB) TowerServerStats() (wtdb.TowerServerStats, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var numUpdates uint64
+	for _, updatesForHint := range db.blobs {
+		numUpdates += uint64(len(updatesForHint))
+	}
+
+	return wtdb.TowerServerStats{
+		NumSessions: uint64(len(db.sessions)),
+		NumUpdates:  numUpdates,
+		NumClients:  uint64(len(db.sessions)),
+	}, nil
+}
+
+// DedupStats returns a summary of the encrypted blobs shared between the state
+// updates stored by the tower.
+func (db *TowerDB) DedupStats() (wtdb.DedupStats, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	// The mock stores a copy of the blob with every update, so the stats
+	// are computed by grouping identical blobs.
+	refs := make(map[[sha256.Size]byte]uint64)
+	sizes := make(map[[sha256.Size]byte]uint64)
+	for _, updatesForHint := range db.blobs {
+		for _, update := range updatesForHint {
+			blobHash := sha256.Sum256(update.EncryptedBlob)
+			refs[blobHash]++
+			sizes[blobHash] = uint64(len(update.EncryptedBlob))
+		}
+	}
+
+	var stats wtdb.DedupStats
+	for blobHash, numRefs := range refs {
+		stats.NumBlobs++
+		stats.NumBlobRefs += numRefs
+		stats.BytesSaved += (numRefs - 1) * sizes[blobHash]
+	}
+
+	return stats, nil
+}
+
+// LookupBlobsByHint returns all state updates stored under the given breach
+// hint, ordered by session id and sequence number.
+func (db *TowerDB) LookupBlobsByHint(
+	hint blob.BreachHint) ([]wtdb.StoredBlob, error) {
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var storedBlobs []wtdb.StoredBlob
+	for key, update := range db.blobs[hint] {
+		storedBlobs = append(storedBlobs, wtdb.StoredBlob{
+			ID:            key.id,
+			SeqNum:        key.seqNum,
+			Hint:          hint,
+			EncryptedBlob: update.EncryptedBlob,
+		})
+	}
+
+	sort.Slice(storedBlobs, func(i, j int) bool {
+		cmp := bytes.Compare(
+			storedBlobs[i].ID[:], storedBlobs[j].ID[:],
+		)
+		if cmp != 0 {
+			return cmp < 0
+		}
+
+		return storedBlobs[i].SeqNum < storedBlobs[j].SeqNum
+	})
+
+	return storedBlobs, nil
+}
+
 // SetLookoutTip stores the provided epoch as the latest lookout tip epoch in
 // the tower database.
 func (db *TowerDB) SetLookoutTip(epoch *chainntnfs.BlockEpoch) error {
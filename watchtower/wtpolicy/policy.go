@@ -33,6 +33,11 @@ const (
 	// MinSweepFeeRate is the minimum sweep fee rate a client may use in its
 	// policy, the current value is 4 sat/vbyte.
 	MinSweepFeeRate = chainfee.SatPerKWeight(1000)
+
+	// MaxRewardRate is the maximum reward rate a policy may specify, which
+	// entitles the tower to the entire balance of the revoked commitment.
+	// The value is expressed in millionths, i.e. 10000 basis points.
+	MaxRewardRate = RewardScale
 )
 
 var (
@@ -59,6 +64,14 @@ var (
 	// ErrSweepFeeRateTooLow signals that the policy's fee rate is too low
 	// to get into the mempool during low congestion.
 	ErrSweepFeeRateTooLow = errors.New("sweep fee rate too low")
+
+	// ErrUnknownBlobType signals that the policy's blob type isn't one
+	// that the blob package knows how to encode.
+	ErrUnknownBlobType = errors.New("unknown blob type")
+
+	// ErrRewardRateTooHigh signals that the policy's reward rate exceeds
+	// MaxRewardRate.
+	ErrRewardRateTooHigh = errors.New("reward rate too high")
 )
 
 // DefaultPolicy returns a Policy containing the default parameters that can be
@@ -120,14 +133,59 @@ func (p Policy) String() string {
 		p.SweepFeeRate)
 }
 
+// IsCompatibleWith returns true if sessions negotiated under the policy can be
+// reused with a tower that now offers the other policy. Every field of the
+// policy affects either the justice transactions signed for the session or
+// the number of updates the tower accepts, so the two policies are compatible
+// only if they agree on all of BlobType, RewardBase, RewardRate, SweepFeeRate
+// and MaxUpdates. Parameters negotiated outside of the policy, such as the
+// session's reward script, play no part in the comparison.
+func (p Policy) IsCompatibleWith(other Policy) bool {
+	return p.BlobType == other.BlobType &&
+		p.RewardBase == other.RewardBase &&
+		p.RewardRate == other.RewardRate &&
+		p.SweepFeeRate == other.SweepFeeRate &&
+		p.MaxUpdates == other.MaxUpdates
+}
+
 // IsAnchorChannel returns true if the session policy requires anchor channels.
 func (p Policy) IsAnchorChannel() bool {
 	return p.TxPolicy.BlobType.IsAnchorChannel()
 }
 
+// IsFeeRateStale returns true if the policy's SweepFeeRate has fallen too far
+// below the current fee rate, e.g. as returned by a chainfee.Estimator, for
+// justice transactions signed under the policy to reliably confirm. The
+// tolerance is the fraction of the current fee rate by which the sweep fee
+// rate may fall short of it, such that a tolerance of 0.1 deems the policy
+// stale once its fee rate drops below 90% of the current fee rate. A negative
+// tolerance is treated as zero, while a tolerance of one or more never deems
+// the policy stale. A sweep fee rate above the current fee rate is never
+// stale.
+func (p TxPolicy) IsFeeRateStale(current chainfee.SatPerKWeight,
+	tolerance float64) bool {
+
+	switch {
+	case tolerance < 0:
+		tolerance = 0
+
+	case tolerance >= 1:
+		return false
+	}
+
+	minFeeRate := float64(current) * (1 - tolerance)
+
+	return float64(p.SweepFeeRate) < minFeeRate
+}
+
 // Validate ensures that the policy satisfies some minimal correctness
 // constraints.
 func (p Policy) Validate() error {
+	// The blob type must be one that we know how to encode.
+	if !blob.IsKnownType(p.BlobType) {
+		return ErrUnknownBlobType
+	}
+
 	// RewardBase and RewardRate should not be set if the policy doesn't
 	// have a reward.
 	if !p.BlobType.Has(blob.FlagReward) &&
@@ -136,6 +194,12 @@ func (p Policy) Validate() error {
 		return ErrAltruistReward
 	}
 
+	// The reward rate can't entitle the tower to more than the entire
+	// balance of the revoked commitment.
+	if p.RewardRate > MaxRewardRate {
+		return ErrRewardRateTooHigh
+	}
+
 	// MaxUpdates must be positive.
 	if p.MaxUpdates == 0 {
 		return ErrNoMaxUpdates
@@ -3,6 +3,7 @@ package wtpolicy_test
 import (
 	"testing"
 
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
 	"github.com/lightningnetwork/lnd/watchtower/blob"
 	"github.com/lightningnetwork/lnd/watchtower/wtpolicy"
 	"github.com/stretchr/testify/require"
@@ -13,6 +14,29 @@ var validationTests = []struct {
 	policy wtpolicy.Policy
 	expErr error
 }{
+	{
+		name: "fail unknown blob type",
+		policy: wtpolicy.Policy{
+			TxPolicy: wtpolicy.TxPolicy{
+				BlobType:     blob.Type(blob.FlagReward),
+				SweepFeeRate: wtpolicy.DefaultSweepFeeRate,
+			},
+			MaxUpdates: 1,
+		},
+		expErr: wtpolicy.ErrUnknownBlobType,
+	},
+	{
+		name: "fail reward rate too high",
+		policy: wtpolicy.Policy{
+			TxPolicy: wtpolicy.TxPolicy{
+				BlobType:     blob.TypeRewardCommit,
+				RewardRate:   wtpolicy.MaxRewardRate + 1,
+				SweepFeeRate: wtpolicy.DefaultSweepFeeRate,
+			},
+			MaxUpdates: 1,
+		},
+		expErr: wtpolicy.ErrRewardRateTooHigh,
+	},
 	{
 		name: "fail no maxupdates",
 		policy: wtpolicy.Policy{
@@ -72,6 +96,28 @@ var validationTests = []struct {
 			MaxUpdates: 1,
 		},
 	},
+	{
+		name: "valid reward policy with max reward rate",
+		policy: wtpolicy.Policy{
+			TxPolicy: wtpolicy.TxPolicy{
+				BlobType:     blob.TypeRewardCommit,
+				RewardBase:   1,
+				RewardRate:   wtpolicy.MaxRewardRate,
+				SweepFeeRate: wtpolicy.DefaultSweepFeeRate,
+			},
+			MaxUpdates: 1,
+		},
+	},
+	{
+		name: "valid taproot policy",
+		policy: wtpolicy.Policy{
+			TxPolicy: wtpolicy.TxPolicy{
+				BlobType:     blob.TypeAltruistTaprootCommit,
+				SweepFeeRate: wtpolicy.DefaultSweepFeeRate,
+			},
+			MaxUpdates: 1,
+		},
+	},
 	{
 		name:   "valid default policy",
 		policy: wtpolicy.DefaultPolicy(),
@@ -110,3 +156,164 @@ func TestPolicyIsAnchorChannel(t *testing.T) {
 	}
 	require.Equal(t, true, policyAnchor.IsAnchorChannel())
 }
+
+// TestPolicyIsCompatibleWith asserts that two policies are only compatible if
+// they agree on all of their fields.
+func TestPolicyIsCompatibleWith(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		mutate        func(p *wtpolicy.Policy)
+		expCompatible bool
+	}{
+		{
+			name:          "equal",
+			mutate:        func(p *wtpolicy.Policy) {},
+			expCompatible: true,
+		},
+		{
+			name: "fee rate differs",
+			mutate: func(p *wtpolicy.Policy) {
+				p.SweepFeeRate++
+			},
+		},
+		{
+			name: "blob type differs",
+			mutate: func(p *wtpolicy.Policy) {
+				p.BlobType = blob.TypeAltruistAnchorCommit
+			},
+		},
+		{
+			name: "max updates differs",
+			mutate: func(p *wtpolicy.Policy) {
+				p.MaxUpdates++
+			},
+		},
+		{
+			name: "reward base differs",
+			mutate: func(p *wtpolicy.Policy) {
+				p.RewardBase++
+			},
+		},
+		{
+			name: "reward rate differs",
+			mutate: func(p *wtpolicy.Policy) {
+				p.RewardRate++
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := wtpolicy.DefaultPolicy()
+			other := wtpolicy.DefaultPolicy()
+			test.mutate(&other)
+
+			require.Equal(
+				t, test.expCompatible,
+				policy.IsCompatibleWith(other),
+			)
+			require.Equal(
+				t, test.expCompatible,
+				other.IsCompatibleWith(policy),
+			)
+		})
+	}
+}
+
+// TestTxPolicyIsFeeRateStale asserts that a policy's sweep fee rate is only
+// deemed stale once it falls below the current fee rate by more than the
+// given tolerance.
+func TestTxPolicyIsFeeRateStale(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		sweepFeeRate chainfee.SatPerKWeight
+		current      chainfee.SatPerKWeight
+		tolerance    float64
+		expStale     bool
+	}{
+		{
+			name:         "equal fee rates",
+			sweepFeeRate: 2500,
+			current:      2500,
+			tolerance:    0,
+		},
+		{
+			name:         "sweep fee rate above current",
+			sweepFeeRate: 5000,
+			current:      2500,
+			tolerance:    0,
+		},
+		{
+			name:         "sweep fee rate below current",
+			sweepFeeRate: 2499,
+			current:      2500,
+			tolerance:    0,
+			expStale:     true,
+		},
+		{
+			name:         "sweep fee rate within tolerance",
+			sweepFeeRate: 2250,
+			current:      2500,
+			tolerance:    0.1,
+		},
+		{
+			name:         "sweep fee rate just outside tolerance",
+			sweepFeeRate: 2249,
+			current:      2500,
+			tolerance:    0.1,
+			expStale:     true,
+		},
+		{
+			name:         "sweep fee rate far below current",
+			sweepFeeRate: 1000,
+			current:      10000,
+			tolerance:    0.5,
+			expStale:     true,
+		},
+		{
+			name:         "negative tolerance treated as zero",
+			sweepFeeRate: 2499,
+			current:      2500,
+			tolerance:    -0.5,
+			expStale:     true,
+		},
+		{
+			name:         "full tolerance never stale",
+			sweepFeeRate: 1000,
+			current:      100000,
+			tolerance:    1,
+		},
+		{
+			name:         "zero current fee rate",
+			sweepFeeRate: 1000,
+			current:      0,
+			tolerance:    0,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := wtpolicy.TxPolicy{
+				BlobType:     blob.TypeAltruistCommit,
+				SweepFeeRate: test.sweepFeeRate,
+			}
+
+			require.Equal(
+				t, test.expStale,
+				policy.IsFeeRateStale(
+					test.current, test.tolerance,
+				),
+			)
+		})
+	}
+}
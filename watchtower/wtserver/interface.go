@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightningnetwork/lnd/watchtower/blob"
 	"github.com/lightningnetwork/lnd/watchtower/wtdb"
 )
 
@@ -59,6 +60,10 @@ type DB interface {
 	// id, if it exists.
 	GetSessionInfo(*wtdb.SessionID) (*wtdb.SessionInfo, error)
 
+	// GetSessionsByClient returns the sessions negotiated by the client
+	// with the given public key.
+	GetSessionsByClient(pk *btcec.PublicKey) ([]*wtdb.SessionInfo, error)
+
 	// InsertStateUpdate persists a state update sent by a client, and
 	// validates the update against the current SessionInfo stored under the
 	// update's session id..
@@ -67,4 +72,21 @@ type DB interface {
 	// DeleteSession removes all data associated with a particular session
 	// id from the tower's database.
 	DeleteSession(wtdb.SessionID) error
+
+	// TowerServerStats returns a summary of the sessions and state updates
+	// stored by the tower.
+	TowerServerStats() (wtdb.TowerServerStats, error)
+
+	// DedupStats returns a summary of the encrypted blobs shared between
+	// the state updates stored by the tower.
+	DedupStats() (wtdb.DedupStats, error)
+
+	// PruneServerUpdates removes all state updates received before the
+	// given time, returning the number of updates removed. Sessions are
+	// left untouched.
+	PruneServerUpdates(before time.Time) (int, error)
+
+	// LookupBlobsByHint returns all state updates stored under the given
+	// breach hint.
+	LookupBlobsByHint(hint blob.BreachHint) ([]wtdb.StoredBlob, error)
 }